@@ -38,7 +38,8 @@ func main() {
 	}
 
 	// create application and node.
-	gnoApp, err := gnoland.NewApp(rootDir, logger)
+	// the local test chain keeps the gno store in "gnostore" from genesis.
+	gnoApp, err := gnoland.NewApp(rootDir, logger, 0)
 	if err != nil {
 		panic(fmt.Sprintf("error in creating new app: %v", err))
 	}
@@ -8,6 +8,7 @@ import (
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
 	"github.com/gnolang/gno/pkgs/crypto"
 	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/errors"
 	"github.com/gnolang/gno/pkgs/log"
 	"github.com/gnolang/gno/pkgs/sdk"
 	"github.com/gnolang/gno/pkgs/sdk/auth"
@@ -20,28 +21,51 @@ import (
 )
 
 // NewApp creates the GnoLand application.
-func NewApp(rootDir string, logger log.Logger) (abci.Application, error) {
+//
+// The gno store is kept in the "gnostore" IAVL store, committed with the
+// other stores. The chains started before kept it in the "base" store,
+// which the app hash does not cover: for them, legacyGnoStoreHeight is the
+// height of the upgrade at which it is moved to "gnostore", which changes
+// the app hash from that height on, and which all the nodes of the chain
+// must agree on. It is 0 for the chains which keep it in "gnostore" from
+// genesis.
+func NewApp(rootDir string, logger log.Logger, legacyGnoStoreHeight int64) (abci.Application, error) {
 	// Get main DB.
 	db := dbm.NewDB("gnolang", dbm.GoLevelDBBackend, filepath.Join(rootDir, "data"))
 
 	// Capabilities keys.
-	mainKey := store.NewStoreKey("main")
-	baseKey := store.NewStoreKey("base")
+	mainKey := store.NewKVStoreKey("main")
+	baseKey := store.NewKVStoreKey("base")
+	// the objects, types and packages of the gno store are in an IAVL
+	// store, so that they are committed atomically with the other stores,
+	// and are covered by the app hash.
+	gnoKey := store.NewKVStoreKey("gnostore")
 
-	// Create BaseApp.
-	baseApp := sdk.NewBaseApp("gnoland", logger, db, baseKey, mainKey)
+	// Create BaseApp. Consensus failures are reported next to the DB.
+	baseApp := sdk.NewBaseApp("gnoland", logger, db, baseKey, mainKey,
+		sdk.SetCrashReporter(sdk.NewCrashFileReporter(filepath.Join(rootDir, "data"))))
 
 	// Set mounts for BaseApp's MultiStore.
 	baseApp.MountStoreWithDB(mainKey, iavl.StoreConstructor, db)
 	baseApp.MountStoreWithDB(baseKey, dbadapter.StoreConstructor, db)
+	baseApp.MountStoreWithDB(gnoKey, iavl.StoreConstructor, nil)
 
 	// Construct keepers.
 	acctKpr := auth.NewAccountKeeper(mainKey, ProtoGnoAccount)
+	// fees are collected by the keepers, not sent with msgs.
+	acctKpr.RegisterModuleAccount(auth.FeeCollectorName, true)
 	bankKpr := bank.NewBankKeeper(acctKpr)
-	vmKpr := vm.NewVMKeeper(baseKey, mainKey, acctKpr, bankKpr, "./stdlibs")
+	metaKpr := bank.NewMetadataKeeper(mainKey)
+	vmKpr := vm.NewVMKeeper(gnoKey, mainKey, acctKpr, bankKpr, "./stdlibs")
+	if legacyGnoStoreHeight > 0 {
+		vmKpr.SetLegacyStore(baseKey, legacyGnoStoreHeight)
+		baseApp.SetBeginBlocker(vmKpr.BeginBlocker)
+	}
 
 	// Configure InitChainer for genesis.
 	baseApp.SetInitChainer(InitChainer(acctKpr, bankKpr))
+	baseApp.RegisterGenesisModule("denom_metadata", metaKpr)
+	baseApp.RegisterGenesisModule("vesting", auth.NewVestingGenesis(acctKpr))
 	authAnteHandler := auth.NewAnteHandler(
 		acctKpr, bankKpr, auth.DefaultSigVerificationGasConsumer)
 	baseApp.SetAnteHandler(
@@ -60,9 +84,15 @@ func NewApp(rootDir string, logger log.Logger) (abci.Application, error) {
 
 	// Set a handler Route.
 	baseApp.Router().AddRoute("auth", auth.NewHandler(acctKpr))
-	baseApp.Router().AddRoute("bank", bank.NewHandler(bankKpr))
+	baseApp.Router().AddRoute("bank", bank.NewHandler(bankKpr).WithDenomMetadata(metaKpr))
 	baseApp.Router().AddRoute("vm", vm.NewHandler(vmKpr))
 
+	// Fail txs breaking the assertions of the gno store as internal errors.
+	baseApp.AddRecoveryHandler(vm.RecoverStoreError)
+
+	// Serve the gno store stats on the diagnostics listener, if any.
+	baseApp.SetDiagnosticsSource("gno_store", vmKpr.StoreStats)
+
 	// Load latest version.
 	if err := baseApp.LoadLatestVersion(); err != nil {
 		return nil, err
@@ -76,12 +106,20 @@ func InitChainer(acctKpr auth.AccountKeeperI, bankKpr bank.BankKeeperI) func(sdk
 	return func(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
 		// Get genesis state.
 		genState := req.AppState.(GnoGenesisState)
+		// Report all the invalid genesis state at once.
+		if err := ValidateGenesis(genState); err != nil {
+			panic(fmt.Sprintf("invalid genesis state: %v", err))
+		}
 		// Parse and set genesis state balances.
 		for _, bal := range genState.Balances {
-			addr, coins := parseBalance(bal)
-			acc := acctKpr.NewAccountWithAddress(ctx, addr)
-			acctKpr.SetAccount(ctx, acc)
-			err := bankKpr.SetCoins(ctx, addr, coins)
+			addr, coins, _ := parseBalance(bal)
+			// the accounts of the vesting schedules already exist, and
+			// the balances are not locked.
+			if acctKpr.GetAccount(ctx, addr) == nil {
+				acc := acctKpr.NewAccountWithAddress(ctx, addr)
+				acctKpr.SetAccount(ctx, acc)
+			}
+			_, err := bankKpr.AddCoins(ctx, addr, coins)
 			if err != nil {
 				panic(err)
 			}
@@ -92,18 +130,30 @@ func InitChainer(acctKpr auth.AccountKeeperI, bankKpr bank.BankKeeperI) func(sdk
 	}
 }
 
-func parseBalance(bal string) (crypto.Address, std.Coins) {
+// ValidateGenesis returns the errors of all the invalid entries of the
+// genesis state, or nil if it is valid.
+func ValidateGenesis(genState GnoGenesisState) error {
+	var errs []error
+	for i, bal := range genState.Balances {
+		if _, _, err := parseBalance(bal); err != nil {
+			errs = append(errs, fmt.Errorf("balances[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseBalance(bal string) (crypto.Address, std.Coins, error) {
 	parts := strings.Split(bal, "=")
 	if len(parts) != 2 {
-		panic(fmt.Sprintf("invalid balance string %s", bal))
+		return crypto.Address{}, nil, fmt.Errorf("invalid balance string %s", bal)
 	}
 	addr, err := crypto.AddressFromBech32(parts[0])
 	if err != nil {
-		panic(fmt.Sprintf("invalid balance addr %s (%v)", bal, err))
+		return crypto.Address{}, nil, fmt.Errorf("invalid balance addr %s (%v)", bal, err)
 	}
 	coins, err := std.ParseCoins(parts[1])
 	if err != nil {
-		panic(fmt.Sprintf("invalid balance coins %s (%v)", bal, err))
+		return crypto.Address{}, nil, fmt.Errorf("invalid balance coins %s (%v)", bal, err)
 	}
-	return addr, coins
+	return addr, coins, nil
 }
@@ -1,6 +1,8 @@
 package gnoland
 
 import (
+	"github.com/gnolang/gno/pkgs/sdk/auth"
+	"github.com/gnolang/gno/pkgs/sdk/bank"
 	"github.com/gnolang/gno/pkgs/std"
 )
 
@@ -13,5 +15,7 @@ func ProtoGnoAccount() std.Account {
 }
 
 type GnoGenesisState struct {
-	Balances []string `json:"balances"`
+	Balances      []string               `json:"balances"`
+	DenomMetadata []bank.Metadata        `json:"denom_metadata"`
+	Vesting       []auth.VestingSchedule `json:"vesting"`
 }
@@ -65,7 +65,7 @@ func handlerRealm(app gotuna.App) http.Handler {
 		rlmPath := "gno.land/r/" + vars["rlmpath"]
 		expr := "Render(\"\")"
 
-		qpath := "vm/qeval"
+		qpath := "/vm/qeval"
 		data := []byte(fmt.Sprintf("%s\n%s", rlmPath, expr))
 		opts2 := client.ABCIQueryOptions{
 			// Height: height, XXX
@@ -115,7 +115,7 @@ func handlerRealmExpr(app gotuna.App) http.Handler {
 		rlmPath := "gno.land/r/" + vars["rlmpath"]
 		expr := vars["expr"]
 
-		qpath := "vm/qeval"
+		qpath := "/vm/qeval"
 		data := []byte(fmt.Sprintf("%s\n%s", rlmPath, expr))
 		opts2 := client.ABCIQueryOptions{
 			// Height: height, XXX
@@ -165,7 +165,7 @@ func handlerRealmPath(app gotuna.App) http.Handler {
 		rlmPath := "gno.land/r/" + vars["rlmpath"]
 		path := vars["path"]
 
-		qpath := "vm/qpath"
+		qpath := "/vm/qpath"
 		data := []byte(fmt.Sprintf("%s\n%s", rlmPath, path))
 		opts2 := client.ABCIQueryOptions{
 			// Height: height, XXX
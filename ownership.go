@@ -50,27 +50,44 @@ func (oid ObjectID) MarshalAmino() (string, error) {
 }
 
 func (oid *ObjectID) UnmarshalAmino(oids string) error {
-	parts := strings.Split(oids, ":")
-	if len(parts) != 2 {
-		return errors.New("invalid ObjectID %s", oids)
-	}
-	_, err := hex.Decode(oid.PkgID.Hashlet[:], []byte(parts[0]))
+	parsed, err := ParseObjectID(oids)
 	if err != nil {
 		return err
 	}
-	newTime, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return err
-	}
-	oid.NewTime = uint64(newTime)
+	*oid = parsed
 	return nil
 }
 
+// String returns the canonical form of oid, "<pkgid>:<newtime>", with the
+// hex of the hashlet of its PkgID, as parsed by ParseObjectID.
 func (oid ObjectID) String() string {
 	oids, _ := oid.MarshalAmino()
 	return oids
 }
 
+// ParseObjectID parses the canonical form of an ObjectID, as returned by
+// ObjectID.String, e.g. from logs or query parameters.
+func ParseObjectID(oids string) (ObjectID, error) {
+	parts := strings.Split(oids, ":")
+	if len(parts) != 2 {
+		return ObjectID{}, errors.New("invalid ObjectID %s", oids)
+	}
+	var oid ObjectID
+	if hex.DecodedLen(len(parts[0])) != HashSize {
+		return ObjectID{}, errors.New("invalid ObjectID %s: expected %d hex bytes of PkgID", oids, HashSize)
+	}
+	_, err := hex.Decode(oid.PkgID.Hashlet[:], []byte(parts[0]))
+	if err != nil {
+		return ObjectID{}, errors.Wrap(err, "invalid ObjectID %s", oids)
+	}
+	newTime, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return ObjectID{}, errors.Wrap(err, "invalid ObjectID %s", oids)
+	}
+	oid.NewTime = newTime
+	return oid, nil
+}
+
 // TODO: make faster by making PkgID a pointer
 // and enforcing that the value of PkgID is never zero.
 func (oid ObjectID) IsZero() bool {
@@ -84,6 +101,15 @@ func (oid ObjectID) IsZero() bool {
 	return oid.PkgID.IsZero()
 }
 
+// objectString describes oo in panics, with its id, its type, and the path
+// of the package if oo is a package value.
+func objectString(oo Object) string {
+	if pv, ok := oo.(*PackageValue); ok {
+		return fmt.Sprintf("%s (package %s)", pv.GetObjectID(), pv.PkgPath)
+	}
+	return fmt.Sprintf("%s (%T)", oo.GetObjectID(), oo)
+}
+
 type Object interface {
 	Value
 	GetObjectInfo() *ObjectInfo
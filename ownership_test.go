@@ -0,0 +1,62 @@
+package gno
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jaekwon/testify/assert"
+	"github.com/jaekwon/testify/require"
+)
+
+func TestParseObjectID(t *testing.T) {
+	oid := ObjectID{PkgID: PkgIDFromPkgPath("gno.land/r/demo"), NewTime: 42}
+	oids := oid.String()
+	require.Regexp(t, oids, "^[0-9a-f]{40}:42$")
+	parsed, err := ParseObjectID(oids)
+	require.NoError(t, err)
+	require.Equal(t, oid, parsed)
+
+	// the amino encoding is the canonical form.
+	var decoded ObjectID
+	require.NoError(t, decoded.UnmarshalAmino(oids))
+	require.Equal(t, oid, decoded)
+
+	for _, invalid := range []string{
+		"",
+		"42",
+		oids + ":1",
+		oids[:38] + ":42", // short PkgID.
+		"ab" + oids,       // long PkgID.
+		"zz" + oids[2:],   // not hex.
+		oids[:41] + "-1",  // negative NewTime.
+		oids[:41] + "x",   // invalid NewTime.
+	} {
+		_, err := ParseObjectID(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseTypeID(t *testing.T) {
+	tid := (&StructType{PkgPath: "gno.land/r/demo"}).TypeID()
+	parsed, err := ParseTypeID(tid.String())
+	require.NoError(t, err)
+	require.Equal(t, tid, parsed)
+
+	_, err = ParseTypeID("")
+	require.Error(t, err)
+}
+
+func TestEnsureUniqPanic(t *testing.T) {
+	sv := &StructValue{}
+	sv.SetObjectID(ObjectID{PkgID: PkgIDFromPkgPath("gno.land/r/demo"), NewTime: 3})
+	pv := &PackageValue{PkgPath: "gno.land/r/demo"}
+	pv.SetObjectID(ObjectIDFromPkgPath("gno.land/r/demo"))
+
+	require.PanicsWithValue(t,
+		func() { ensureUniq([]Object{sv}, []Object{sv}) },
+		fmt.Sprintf("duplicate object %s (*gno.StructValue)", sv.GetObjectID()))
+	require.PanicsWithValue(t,
+		func() { ensureUniq([]Object{pv, sv, pv}) },
+		fmt.Sprintf("duplicate object %s (package gno.land/r/demo)", pv.GetObjectID()))
+	require.NotPanics(t, func() { ensureUniq([]Object{pv}, []Object{sv}) })
+}
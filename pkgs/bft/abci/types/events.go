@@ -0,0 +1,87 @@
+package abci
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+//----------------------------------------
+// TypedEvent
+
+// TypedEvent is an Event of a type with a list of key/value attributes,
+// e.g. a "transfer" event with "sender", "recipient", and "amount"
+// attributes. Use NewEvent to construct one.
+type TypedEvent struct {
+	Type       string           `json:"type"`
+	Attributes []EventAttribute `json:"attributes"`
+}
+
+func (_ TypedEvent) AssertABCIEvent() {}
+
+// EventAttribute is an attribute of a TypedEvent. Index tells indexers
+// whether the attribute is meant to be queried.
+type EventAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Index bool   `json:"index"`
+}
+
+// NewEvent returns a TypedEvent of type typ with the given attributes.
+func NewEvent(typ string, attrs ...EventAttribute) TypedEvent {
+	return TypedEvent{Type: typ, Attributes: attrs}
+}
+
+// NewAttribute returns an indexed attribute.
+func NewAttribute(key, value string) EventAttribute {
+	return EventAttribute{Key: key, Value: value, Index: true}
+}
+
+// NoIndex returns a copy of the attribute that is not indexed.
+func (attr EventAttribute) NoIndex() EventAttribute {
+	attr.Index = false
+	return attr
+}
+
+// Validate returns an error if the type is empty or not printable, if an
+// attribute key is empty, not printable, or set more than once, or if an
+// attribute value is not valid UTF-8.
+func (ev TypedEvent) Validate() error {
+	if ev.Type == "" {
+		return fmt.Errorf("event type cannot be empty")
+	}
+	if !isPrintable(ev.Type) {
+		return fmt.Errorf("event type %q is not printable", ev.Type)
+	}
+	keys := make(map[string]struct{}, len(ev.Attributes))
+	for i, attr := range ev.Attributes {
+		if attr.Key == "" {
+			return fmt.Errorf("event %s: attribute #%d has an empty key", ev.Type, i)
+		}
+		if !isPrintable(attr.Key) {
+			return fmt.Errorf("event %s: attribute key %q is not printable", ev.Type, attr.Key)
+		}
+		if _, dup := keys[attr.Key]; dup {
+			return fmt.Errorf("event %s: duplicate attribute key %q", ev.Type, attr.Key)
+		}
+		keys[attr.Key] = struct{}{}
+		if !utf8.ValidString(attr.Value) {
+			return fmt.Errorf("event %s: value of attribute %q is not valid UTF-8", ev.Type, attr.Key)
+		}
+	}
+	return nil
+}
+
+// isPrintable returns whether s is valid UTF-8 made of printable runes,
+// excluding spaces other than U+0020.
+func isPrintable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
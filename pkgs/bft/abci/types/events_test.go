@@ -0,0 +1,63 @@
+package abci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+)
+
+func TestTypedEventValidate(t *testing.T) {
+	valid := NewEvent("transfer",
+		NewAttribute("sender", "g1sender"),
+		NewAttribute("memo", "héllo wörld").NoIndex(),
+	)
+	assert.NoError(t, valid.Validate())
+	assert.True(t, valid.Attributes[0].Index)
+	assert.False(t, valid.Attributes[1].Index)
+	assert.NoError(t, NewEvent("empty").Validate())
+
+	cases := []struct {
+		name string
+		ev   TypedEvent
+	}{
+		{"empty type", NewEvent("", NewAttribute("key", "value"))},
+		{"non-printable type", NewEvent("trans\x00fer")},
+		{"non-UTF-8 type", NewEvent("\xff")},
+		{"empty key", NewEvent("transfer", NewAttribute("", "value"))},
+		{"non-printable key", NewEvent("transfer", NewAttribute("key\n", "value"))},
+		{"non-UTF-8 key", NewEvent("transfer", NewAttribute("k\xc3", "value"))},
+		{"duplicate key", NewEvent("transfer", NewAttribute("key", "a"), NewAttribute("key", "b").NoIndex())},
+		{"non-UTF-8 value", NewEvent("transfer", NewAttribute("key", "\xff\xfe"))},
+	}
+	for _, tc := range cases {
+		assert.Error(t, tc.ev.Validate(), tc.name)
+	}
+}
+
+func TestTypedEventJSON(t *testing.T) {
+	res := ResponseDeliverTx{
+		ResponseBase: ResponseBase{
+			Events: []Event{
+				NewEvent("transfer",
+					NewAttribute("recipient", "g1recipient"),
+					NewAttribute("amount", "100ugnot").NoIndex(),
+				),
+			},
+		},
+	}
+	bz, err := amino.MarshalJSON(res)
+	require.NoError(t, err)
+	assert.Contains(t, string(bz), `"Events":[{"@type":"/abci.TypedEvent","type":"transfer","attributes":[{"key":"recipient","value":"g1recipient","index":true},{"key":"amount","value":"100ugnot","index":false}]}]`)
+
+	var res2 ResponseDeliverTx
+	require.NoError(t, amino.UnmarshalJSON(bz, &res2))
+	assert.Equal(t, res.Events, res2.Events)
+
+	// binary encoding too.
+	var res3 ResponseDeliverTx
+	require.NoError(t, amino.Unmarshal(amino.MustMarshal(res), &res3))
+	assert.Equal(t, res.Events, res3.Events)
+}
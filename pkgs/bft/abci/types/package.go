@@ -59,15 +59,19 @@ var Package = amino.RegisterPackage(amino.NewPackage(
 		// misc types
 		ConsensusParams{},
 		BlockParams{},
+		EvidenceParams{},
 		ValidatorParams{},
 		ValidatorUpdate{},
 		LastCommitInfo{},
 		VoteInfo{},
+		Evidence{},
 		//Validator{},
 		//Violation{},
 
 		// events
 		EventString(""),
+		TypedEvent{},
+		EventAttribute{},
 
 		// mocks
 		MockHeader{},
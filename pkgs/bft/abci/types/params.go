@@ -1,6 +1,8 @@
 package abci
 
 import (
+	"time"
+
 	"github.com/gnolang/gno/pkgs/amino"
 	"github.com/gnolang/gno/pkgs/crypto/tmhash"
 )
@@ -14,6 +16,14 @@ func (params ValidatorParams) IsValidPubKeyTypeURL(pubKeyTypeURL string) bool {
 	return false
 }
 
+// IsExpired returns whether evidence of the given height and time is too
+// old to be handled at the given height and time. Evidence expires once it
+// is older than both MaxAgeNumBlocks and MaxAgeDuration.
+func (params EvidenceParams) IsExpired(evHeight int64, evTime time.Time, height int64, now time.Time) bool {
+	return height-evHeight > params.MaxAgeNumBlocks &&
+		now.Sub(evTime) > params.MaxAgeDuration
+}
+
 func (params ConsensusParams) Hash() []byte {
 	hasher := tmhash.New()
 	bz := amino.MustMarshal(params)
@@ -29,6 +39,9 @@ func (params ConsensusParams) Update(params2 ConsensusParams) ConsensusParams {
 	if params2.Block != nil {
 		res.Block = amino.DeepCopy(params2.Block).(*BlockParams)
 	}
+	if params2.Evidence != nil {
+		res.Evidence = amino.DeepCopy(params2.Evidence).(*EvidenceParams)
+	}
 	if params2.Validator != nil {
 		res.Validator = amino.DeepCopy(params2.Validator).(*ValidatorParams)
 	}
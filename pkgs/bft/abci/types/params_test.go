@@ -0,0 +1,18 @@
+package abci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvidenceParamsIsExpired(t *testing.T) {
+	params := EvidenceParams{MaxAgeNumBlocks: 10, MaxAgeDuration: time.Hour}
+	evTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// evidence expires once too old both in blocks and in time.
+	assert.False(t, params.IsExpired(100, evTime, 110, evTime.Add(2*time.Hour)))
+	assert.False(t, params.IsExpired(100, evTime, 111, evTime.Add(time.Hour)))
+	assert.True(t, params.IsExpired(100, evTime, 111, evTime.Add(time.Hour+1)))
+}
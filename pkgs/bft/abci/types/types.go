@@ -61,6 +61,8 @@ type RequestBeginBlock struct {
 	Hash           []byte
 	Header         Header
 	LastCommitInfo *LastCommitInfo
+	// Misbehaviours of validators to be punished by the application.
+	ByzantineValidators []Evidence
 	//Violations     []Violation
 }
 
@@ -166,6 +168,8 @@ type ResponseCheckTx struct {
 	ResponseBase
 	GasWanted int64 // nondeterministic
 	GasUsed   int64
+	Sender    string // bech32 address of the first signer, if any
+	Priority  int64  // mempool priority, or -1 to be evicted last
 }
 
 type ResponseDeliverTx struct {
@@ -183,6 +187,7 @@ type ResponseEndBlock struct {
 
 type ResponseCommit struct {
 	ResponseBase
+	RetainHeight int64 // the blocks below may be pruned, or 0 to retain all
 }
 
 //----------------------------------------
@@ -232,6 +237,7 @@ func (err EventString) Event() string {
 // Parameters that need to be negotiated between the app and consensus.
 type ConsensusParams struct {
 	Block     *BlockParams
+	Evidence  *EvidenceParams
 	Validator *ValidatorParams
 }
 
@@ -243,6 +249,11 @@ type BlockParams struct {
 	TimeIotaMS    int64 // must be > 0
 }
 
+type EvidenceParams struct {
+	MaxAgeNumBlocks int64         // must be > 0
+	MaxAgeDuration  time.Duration // must be > 0
+}
+
 type ValidatorParams struct {
 	PubKeyTypeURLs []string
 }
@@ -265,6 +276,22 @@ type VoteInfo struct {
 	SignedLastBlock bool
 }
 
+// Evidence types
+const (
+	EvidenceTypeDuplicateVote = "duplicate/vote"
+)
+
+// unstable
+// Evidence is a misbehaviour of a validator, e.g. signing conflicting votes.
+type Evidence struct {
+	Type             string // e.g. EvidenceTypeDuplicateVote
+	Address          crypto.Address
+	Power            int64 // of the validator at Height
+	Height           int64 // of the misbehaviour
+	Time             time.Time
+	TotalVotingPower int64 // of the validator set at Height
+}
+
 /*
 // unstable
 type Validator struct {
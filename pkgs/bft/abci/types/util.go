@@ -2,6 +2,7 @@ package abci
 
 import (
 	"bytes"
+	"fmt"
 	"sort"
 )
 
@@ -31,6 +32,53 @@ func (v ValidatorUpdates) Swap(i, j int) {
 	v[j] = v1
 }
 
+// Validate returns an error if an update has no pubkey or a negative power,
+// or if a pubkey is updated more than once.
+func (v ValidatorUpdates) Validate() error {
+	seen := make(map[string]struct{}, len(v))
+	for i, vu := range v {
+		if vu.PubKey == nil {
+			return fmt.Errorf("validator update #%d has no pubkey", i)
+		}
+		if vu.Power < 0 {
+			return fmt.Errorf("validator update #%d (%s) has a negative power %d", i, vu.PubKey, vu.Power)
+		}
+		key := string(vu.PubKey.Bytes())
+		if _, dup := seen[key]; dup {
+			return fmt.Errorf("validator update #%d (%s) is a duplicate", i, vu.PubKey)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+// Merge returns the updates of v followed by those of other, e.g. to
+// combine the updates of several end blockers. An update of other for the
+// same pubkey as an update of v is dropped if it is equal, and is an error
+// otherwise.
+func (v ValidatorUpdates) Merge(other ValidatorUpdates) (ValidatorUpdates, error) {
+	res := make(ValidatorUpdates, len(v), len(v)+len(other))
+	copy(res, v)
+	byPubKey := make(map[string]ValidatorUpdate, len(v))
+	for _, vu := range v {
+		if vu.PubKey != nil {
+			byPubKey[string(vu.PubKey.Bytes())] = vu
+		}
+	}
+	for _, vu := range other {
+		if vu.PubKey != nil {
+			if prev, ok := byPubKey[string(vu.PubKey.Bytes())]; ok {
+				if !prev.Equals(vu) {
+					return nil, fmt.Errorf("conflicting validator updates for %s: power %d and %d", vu.PubKey, prev.Power, vu.Power)
+				}
+				continue
+			}
+		}
+		res = append(res, vu)
+	}
+	return res, nil
+}
+
 //----------------------------------------
 // ValidatorUpdate
 
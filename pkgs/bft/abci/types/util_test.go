@@ -0,0 +1,56 @@
+package abci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+)
+
+func newValidatorUpdate(power int64) ValidatorUpdate {
+	pub := ed25519.GenPrivKey().PubKey()
+	return ValidatorUpdate{Address: pub.Address(), PubKey: pub, Power: power}
+}
+
+func TestValidatorUpdatesValidate(t *testing.T) {
+	val1, val2 := newValidatorUpdate(10), newValidatorUpdate(0)
+	assert.NoError(t, ValidatorUpdates{val1, val2}.Validate())
+	assert.NoError(t, ValidatorUpdates(nil).Validate())
+
+	negative := val1
+	negative.Power = -1
+	removed := val1
+	removed.Power = 0
+	cases := []struct {
+		name    string
+		updates ValidatorUpdates
+	}{
+		{"negative power", ValidatorUpdates{val2, negative}},
+		{"duplicate pubkey", ValidatorUpdates{val1, val2, val1}},
+		{"duplicate pubkey with another power", ValidatorUpdates{val1, removed}},
+		{"no pubkey", ValidatorUpdates{{Power: 1}}},
+	}
+	for _, tc := range cases {
+		assert.Error(t, tc.updates.Validate(), tc.name)
+	}
+}
+
+func TestValidatorUpdatesMerge(t *testing.T) {
+	val1, val2, val3 := newValidatorUpdate(10), newValidatorUpdate(0), newValidatorUpdate(5)
+
+	merged, err := ValidatorUpdates{val1, val2}.Merge(ValidatorUpdates{val2, val3})
+	require.NoError(t, err)
+	assert.Equal(t, ValidatorUpdates{val1, val2, val3}, merged)
+	assert.NoError(t, merged.Validate())
+
+	merged, err = ValidatorUpdates(nil).Merge(ValidatorUpdates{val3})
+	require.NoError(t, err)
+	assert.Equal(t, ValidatorUpdates{val3}, merged)
+
+	conflict := val1
+	conflict.Power = 20
+	_, err = ValidatorUpdates{val1, val2}.Merge(ValidatorUpdates{conflict})
+	assert.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/gnolang/gno/pkgs/amino"
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
 	"github.com/gnolang/gno/pkgs/crypto/ed25519"
@@ -26,8 +28,9 @@ var (
 
 func DefaultConsensusParams() abci.ConsensusParams {
 	return abci.ConsensusParams{
-		DefaultBlockParams(),
-		DefaultValidatorParams(),
+		Block:     DefaultBlockParams(),
+		Evidence:  DefaultEvidenceParams(),
+		Validator: DefaultValidatorParams(),
 	}
 }
 
@@ -40,6 +43,13 @@ func DefaultBlockParams() *abci.BlockParams {
 	}
 }
 
+func DefaultEvidenceParams() *abci.EvidenceParams {
+	return &abci.EvidenceParams{
+		MaxAgeNumBlocks: 100000,         // ~27 hours at 1s blocks
+		MaxAgeDuration:  48 * time.Hour, // 2 days
+	}
+}
+
 func DefaultValidatorParams() *abci.ValidatorParams {
 	return &abci.ValidatorParams{[]string{
 		amino.GetTypeURL(ed25519.PubKeyEd25519{}),
@@ -66,6 +76,18 @@ func ValidateConsensusParams(params abci.ConsensusParams) error {
 			params.Block.TimeIotaMS)
 	}
 
+	// evidence params are optional, for chains which predate them.
+	if params.Evidence != nil {
+		if params.Evidence.MaxAgeNumBlocks <= 0 {
+			return errors.New("Evidence.MaxAgeNumBlocks must be greater than 0. Got %d",
+				params.Evidence.MaxAgeNumBlocks)
+		}
+		if params.Evidence.MaxAgeDuration <= 0 {
+			return errors.New("Evidence.MaxAgeDuration must be greater than 0. Got %v",
+				params.Evidence.MaxAgeDuration)
+		}
+	}
+
 	if len(params.Validator.PubKeyTypeURLs) == 0 {
 		return errors.New("len(Validator.PubKeyTypeURLs) must be greater than 0")
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -34,6 +35,11 @@ func TestConsensusParamsValidation(t *testing.T) {
 		9: {makeParams(1, 1024, 0, 10, []string{}), false},
 		// test invalid pubkey type provided
 		10: {makeParams(1, 1024, 0, 10, []string{"potatoes make good pubkeys"}), false},
+		// test evidence params
+		11: {withEvidence(makeParams(1, 1024, 0, 10, valEd25519), 100, time.Hour), true},
+		12: {withEvidence(makeParams(1, 1024, 0, 10, valEd25519), 0, time.Hour), false},
+		13: {withEvidence(makeParams(1, 1024, 0, 10, valEd25519), 100, 0), false},
+		14: {DefaultConsensusParams(), true},
 	}
 	for i, tc := range testCases {
 		if tc.valid {
@@ -62,6 +68,14 @@ func makeParams(
 	}
 }
 
+func withEvidence(params abci.ConsensusParams, maxAgeNumBlocks int64, maxAgeDuration time.Duration) abci.ConsensusParams {
+	params.Evidence = &abci.EvidenceParams{
+		MaxAgeNumBlocks: maxAgeNumBlocks,
+		MaxAgeDuration:  maxAgeDuration,
+	}
+	return params
+}
+
 func TestConsensusParamsHash(t *testing.T) {
 	params := []abci.ConsensusParams{
 		makeParams(4, 1024, 2, 10, valEd25519),
@@ -117,6 +131,14 @@ func TestConsensusParamsUpdate(t *testing.T) {
 			},
 			makeParams(100, 1024, 200, 10, valSecp256k1),
 		},
+		// evidence updates
+		{
+			makeParams(1, 1024, 2, 10, valEd25519),
+			abci.ConsensusParams{
+				Evidence: &abci.EvidenceParams{MaxAgeNumBlocks: 10, MaxAgeDuration: time.Minute},
+			},
+			withEvidence(makeParams(1, 1024, 2, 10, valEd25519), 10, time.Minute),
+		},
 	}
 	for _, tc := range testCases {
 		assert.Equal(t, tc.updatedParams, tc.params.Update(tc.updates))
@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"runtime"
+	"sync"
+)
+
+//----------------------------------------
+// BatchVerifier
+
+// BatchVerifier verifies many (pubkey, message, signature) triples at once.
+// The accept/reject result for every entry must be identical to calling
+// PubKey.VerifyBytes on it.
+type BatchVerifier interface {
+	// Add queues a signature for verification. Entries are indexed in the
+	// order they were added, starting at 0.
+	Add(key PubKey, msg []byte, sig []byte)
+	// VerifyAll verifies every queued entry. It returns true if all entries
+	// are valid, and otherwise false along with the indexes of the invalid
+	// entries in ascending order.
+	VerifyAll() (ok bool, failedIndexes []int)
+}
+
+// NewBatchVerifier returns a BatchVerifier for any PubKey type.
+//
+// Neither the ed25519 nor the secp256k1 primitives used here expose a
+// batched verification equation, so entries are verified one by one with
+// PubKey.VerifyBytes, spread over the available CPUs.
+func NewBatchVerifier() BatchVerifier {
+	return &batchVerifier{}
+}
+
+type batchEntry struct {
+	key PubKey
+	msg []byte
+	sig []byte
+}
+
+type batchVerifier struct {
+	entries []batchEntry
+}
+
+var _ BatchVerifier = (*batchVerifier)(nil)
+
+func (bv *batchVerifier) Add(key PubKey, msg []byte, sig []byte) {
+	bv.entries = append(bv.entries, batchEntry{key, msg, sig})
+}
+
+func (bv *batchVerifier) VerifyAll() (ok bool, failedIndexes []int) {
+	valid := make([]bool, len(bv.entries))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(bv.entries) {
+		workers = len(bv.entries)
+	}
+
+	if workers <= 1 {
+		for i, entry := range bv.entries {
+			valid[i] = entry.key.VerifyBytes(entry.msg, entry.sig)
+		}
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(bv.entries); i += workers {
+					entry := bv.entries[i]
+					valid[i] = entry.key.VerifyBytes(entry.msg, entry.sig)
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+
+	for i, v := range valid {
+		if !v {
+			failedIndexes = append(failedIndexes, i)
+		}
+	}
+	return len(failedIndexes) == 0, failedIndexes
+}
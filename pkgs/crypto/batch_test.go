@@ -0,0 +1,56 @@
+package crypto_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+)
+
+func TestBatchVerifier(t *testing.T) {
+	privs := []crypto.PrivKey{
+		ed25519.GenPrivKey(),
+		secp256k1.GenPrivKey(),
+		ed25519.GenPrivKey(),
+		secp256k1.GenPrivKey(),
+	}
+
+	for _, corrupt := range [][]int{nil, {0}, {1, 3}, {0, 1, 2, 3}} {
+		t.Run(fmt.Sprintf("corrupt %v", corrupt), func(t *testing.T) {
+			bv := crypto.NewBatchVerifier()
+			expected := true
+			for i, priv := range privs {
+				msg := []byte(fmt.Sprintf("message %d", i))
+				sig, err := priv.Sign(msg)
+				require.NoError(t, err)
+				for _, c := range corrupt {
+					if c == i {
+						sig[0] ^= 0xFF
+					}
+				}
+				// accept/reject must match sequential verification.
+				if !priv.PubKey().VerifyBytes(msg, sig) {
+					expected = false
+				}
+				bv.Add(priv.PubKey(), msg, sig)
+			}
+			ok, failed := bv.VerifyAll()
+			require.Equal(t, expected, ok)
+			if len(corrupt) == 0 {
+				require.Nil(t, failed)
+			} else {
+				require.Equal(t, corrupt, failed)
+			}
+		})
+	}
+}
+
+func TestBatchVerifierEmpty(t *testing.T) {
+	ok, failed := crypto.NewBatchVerifier().VerifyAll()
+	require.True(t, ok)
+	require.Nil(t, failed)
+}
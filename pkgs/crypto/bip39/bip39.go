@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Some bitwise operands for working with big.Ints
@@ -34,10 +35,21 @@ func NewEntropy(bitSize int) ([]byte, error) {
 	return entropy, err
 }
 
-// NewMnemonic will return a string consisting of the mnemonic words for
-// the given entropy.
+// NewMnemonic will return a string consisting of the english mnemonic words
+// for the given entropy.
 // If the provide entropy is invalid, an error will be returned.
 func NewMnemonic(entropy []byte) (string, error) {
+	return NewMnemonicWithLanguage(entropy, English)
+}
+
+// NewMnemonicWithLanguage is like NewMnemonic but uses the wordlist of
+// the given language, which must be registered.
+func NewMnemonicWithLanguage(entropy []byte, lang Language) (string, error) {
+	wl := getWordlist(lang)
+	if wl == nil {
+		return "", UnsupportedLanguageError{lang}
+	}
+
 	// Compute some lengths for convenience
 	entropyBitLength := len(entropy) * 8
 	checksumBitLength := entropyBitLength / 32
@@ -74,23 +86,23 @@ func NewMnemonic(entropy []byte) (string, error) {
 		wordBytes := padByteSlice(word.Bytes(), 2)
 
 		// Convert bytes to an index and add that word to the list
-		words[i] = WordList[binary.BigEndian.Uint16(wordBytes)]
+		words[i] = wl.words[binary.BigEndian.Uint16(wordBytes)]
 	}
 
-	return strings.Join(words, " "), nil
+	return strings.Join(words, wl.separator), nil
 }
 
-// MnemonicToByteArray takes a mnemonic string and turns it into a byte array
-// suitable for creating another mnemonic.
-// An error is returned if the mnemonic is invalid.
+// MnemonicToByteArray takes a mnemonic string in any registered language
+// and turns it into a byte array suitable for creating another mnemonic.
+// An error is returned if the mnemonic is invalid, see Validate.
 func MnemonicToByteArray(mnemonic string) ([]byte, error) {
-	if !IsMnemonicValid(mnemonic) {
-		return nil, fmt.Errorf("Invalid mnemonic")
+	_, indexes, err := parseMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
 	}
-	mnemonicSlice := strings.Split(mnemonic, " ")
 
-	bitSize := len(mnemonicSlice) * 11
-	err := validateEntropyWithChecksumBitSize(bitSize)
+	bitSize := len(indexes) * 11
+	err = validateEntropyWithChecksumBitSize(bitSize)
 	if err != nil {
 		return nil, err
 	}
@@ -98,11 +110,7 @@ func MnemonicToByteArray(mnemonic string) ([]byte, error) {
 
 	b := big.NewInt(0)
 	modulo := big.NewInt(2048)
-	for _, v := range mnemonicSlice {
-		index, found := ReverseWordMap[v]
-		if !found {
-			return nil, fmt.Errorf("Word `%v` not found in reverse map", v)
-		}
+	for _, index := range indexes {
 		add := big.NewInt(int64(index))
 		b = b.Mul(b, modulo)
 		b = b.Add(b, add)
@@ -150,14 +158,51 @@ func MnemonicToByteArray(mnemonic string) ([]byte, error) {
 	}
 	for i := range validationHex {
 		if hex[i] != validationHex[i] {
-			return nil, fmt.Errorf("Invalid byte at position %v", i)
+			return nil, ErrInvalidChecksum
 		}
 	}
 	return hex, nil
 }
 
+// Validate returns nil if mnemonic is a valid BIP 39 mnemonic in any
+// registered language. Otherwise it returns a WordCountError, an
+// InvalidWordError for the first unknown word, or ErrInvalidChecksum.
+//
+// The wordlist is detected from the words of the mnemonic, which are
+// NFKD normalized and may be separated by any whitespace.
+func Validate(mnemonic string) error {
+	_, err := MnemonicToByteArray(mnemonic)
+	return err
+}
+
+// parseMnemonic returns the wordlist of mnemonic and the index of each of
+// its words in it.
+func parseMnemonic(mnemonic string) (*wordlist, []int, error) {
+	words := splitMnemonic(mnemonic)
+
+	// The number of words should be 12, 15, 18, 21 or 24
+	numOfWords := len(words)
+	if numOfWords < 12 || numOfWords > 24 || numOfWords%3 != 0 {
+		return nil, nil, WordCountError{numOfWords}
+	}
+
+	wl := detectWordlist(words)
+	if wl == nil {
+		return nil, nil, InvalidWordError{Index: 0, Word: words[0]}
+	}
+	indexes := make([]int, numOfWords)
+	for i, word := range words {
+		index, ok := wl.index[word]
+		if !ok {
+			return nil, nil, InvalidWordError{Index: i, Word: word, Language: wl.lang}
+		}
+		indexes[i] = index
+	}
+	return wl, indexes, nil
+}
+
 // NewSeedWithErrorChecking creates a hashed seed output given the mnemonic string and a password.
-// An error is returned if the mnemonic is not convertible to a byte array.
+// An error is returned if the mnemonic is invalid, see Validate.
 func NewSeedWithErrorChecking(mnemonic string, password string) ([]byte, error) {
 	_, err := MnemonicToByteArray(mnemonic)
 	if err != nil {
@@ -166,9 +211,12 @@ func NewSeedWithErrorChecking(mnemonic string, password string) ([]byte, error)
 	return NewSeed(mnemonic, password), nil
 }
 
-// NewSeed creates a hashed seed output given a provided string and password.
+// NewSeed creates a hashed seed output given a provided string and password,
+// both NFKD normalized as per the BIP 39 spec.
 // No checking is performed to validate that the string provided is a valid mnemonic.
 func NewSeed(mnemonic string, password string) []byte {
+	mnemonic = norm.NFKD.String(mnemonic)
+	password = norm.NFKD.String(password)
 	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+password), 2048, 64, sha512.New)
 }
 
@@ -221,24 +269,8 @@ func validateEntropyWithChecksumBitSize(bitSize int) error {
 // IsMnemonicValid attempts to verify that the provided mnemonic is valid.
 // Validity is determined by both the number of words being appropriate,
 // and that all the words in the mnemonic are present in the word list.
+// Use Validate to also verify the checksum.
 func IsMnemonicValid(mnemonic string) bool {
-	// Create a list of all the words in the mnemonic sentence
-	words := strings.Fields(mnemonic)
-
-	//Get num of words
-	numOfWords := len(words)
-
-	// The number of words should be 12, 15, 18, 21 or 24
-	if numOfWords < 12 || numOfWords > 24 || numOfWords%3 != 0 {
-		return false
-	}
-
-	// Check if all words belong in the wordlist
-	for i := 0; i < numOfWords; i++ {
-		if _, ok := ReverseWordMap[words[i]]; !ok {
-			return false
-		}
-	}
-
-	return true
+	_, _, err := parseMnemonic(mnemonic)
+	return err == nil
 }
@@ -0,0 +1,47 @@
+package bip39
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidChecksum is returned for a mnemonic of known words whose
+// checksum does not match, e.g. because words were swapped or mistyped
+// as other valid words.
+var ErrInvalidChecksum = errors.New("invalid mnemonic: checksum mismatch")
+
+// WordCountError is returned for a mnemonic that does not have 12, 15, 18,
+// 21, or 24 words.
+type WordCountError struct {
+	Count int
+}
+
+func (e WordCountError) Error() string {
+	return fmt.Sprintf("invalid mnemonic: got %d words, expected 12, 15, 18, 21, or 24", e.Count)
+}
+
+// InvalidWordError is returned for the first word of a mnemonic that is
+// not in its wordlist. Language is the detected language of the mnemonic,
+// or empty if none of its words are in any registered wordlist.
+type InvalidWordError struct {
+	Index    int // zero based
+	Word     string
+	Language Language
+}
+
+func (e InvalidWordError) Error() string {
+	if e.Language == "" {
+		return fmt.Sprintf("invalid mnemonic: word #%d %q is not in any known wordlist", e.Index+1, e.Word)
+	}
+	return fmt.Sprintf("invalid mnemonic: word #%d %q is not in the %s wordlist", e.Index+1, e.Word, e.Language)
+}
+
+// UnsupportedLanguageError is returned when generating a mnemonic in a
+// language whose wordlist is not registered.
+type UnsupportedLanguageError struct {
+	Language Language
+}
+
+func (e UnsupportedLanguageError) Error() string {
+	return fmt.Sprintf("unsupported mnemonic language %q: wordlist not registered", e.Language)
+}
@@ -0,0 +1,122 @@
+package bip39
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Language identifies a BIP 39 wordlist, named like the wordlist files of
+// the BIP 39 spec (e.g. english.txt).
+type Language string
+
+const (
+	English            Language = "english"
+	Japanese           Language = "japanese"
+	Spanish            Language = "spanish"
+	ChineseSimplified  Language = "chinese_simplified"
+	ChineseTraditional Language = "chinese_traditional"
+	French             Language = "french"
+	Italian            Language = "italian"
+	Korean             Language = "korean"
+	Czech              Language = "czech"
+	Portuguese         Language = "portuguese"
+)
+
+// wordlist is a registered BIP 39 wordlist.
+type wordlist struct {
+	lang  Language
+	words []string
+	index map[string]int
+	// separator joins the words of generated mnemonics.
+	separator string
+}
+
+// wordlists holds the registered wordlists in registration order, which
+// is also the order of preference when detecting the language of a
+// mnemonic.
+var wordlists []*wordlist
+
+// RegisterWordlist makes the wordlist of lang available for generating and
+// validating mnemonics. words must be the content of the official BIP 39
+// wordlist file for lang: 2048 distinct words, one per line.
+//
+// The English, Japanese, Spanish and Simplified Chinese wordlists are
+// bundled with this package; the other official wordlists can be
+// registered at program start up.
+func RegisterWordlist(lang Language, words string) error {
+	if getWordlist(lang) != nil {
+		return fmt.Errorf("wordlist %q already registered", lang)
+	}
+	list := strings.Split(strings.TrimSpace(words), "\n")
+	if len(list) != 2048 {
+		return fmt.Errorf("wordlist %q must have 2048 words, got %d", lang, len(list))
+	}
+	wl := &wordlist{
+		lang:      lang,
+		words:     make([]string, len(list)),
+		index:     make(map[string]int, len(list)),
+		separator: " ",
+	}
+	if lang == Japanese {
+		// as per the BIP 39 spec, Japanese mnemonics are joined with
+		// ideographic spaces.
+		wl.separator = "\u3000"
+	}
+	for i, word := range list {
+		word = norm.NFKD.String(strings.TrimSpace(word))
+		if word == "" || strings.ContainsAny(word, " \t\u3000") {
+			return fmt.Errorf("wordlist %q: invalid word %q at line %d", lang, list[i], i+1)
+		}
+		if _, dup := wl.index[word]; dup {
+			return fmt.Errorf("wordlist %q: duplicate word %q at line %d", lang, list[i], i+1)
+		}
+		wl.words[i] = word
+		wl.index[word] = i
+	}
+	wordlists = append(wordlists, wl)
+	return nil
+}
+
+// Languages returns the languages of the registered wordlists.
+func Languages() []Language {
+	langs := make([]Language, len(wordlists))
+	for i, wl := range wordlists {
+		langs[i] = wl.lang
+	}
+	return langs
+}
+
+func getWordlist(lang Language) *wordlist {
+	for _, wl := range wordlists {
+		if wl.lang == lang {
+			return wl
+		}
+	}
+	return nil
+}
+
+// detectWordlist returns the registered wordlist that contains the most of
+// the given (normalized) words, or nil if none contains any of them.
+func detectWordlist(words []string) *wordlist {
+	var best *wordlist
+	bestCount := 0
+	for _, wl := range wordlists {
+		count := 0
+		for _, word := range words {
+			if _, ok := wl.index[word]; ok {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = wl, count
+		}
+	}
+	return best
+}
+
+// splitMnemonic splits a mnemonic into its NFKD normalized words.
+func splitMnemonic(mnemonic string) []string {
+	return strings.Fields(norm.NFKD.String(mnemonic))
+}
@@ -0,0 +1,322 @@
+package bip39
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/unicode/norm"
+)
+
+// testLanguage has a synthetic wordlist whose words contain composed
+// characters, to check NFKD normalization and language detection without
+// depending on the wordlists of other languages.
+const testLanguage = Language("test")
+
+func init() {
+	words := make([]string, 2048)
+	for i := range words {
+		words[i] = fmt.Sprintf("pal\u00e9%d", i) // "palé0", ...
+	}
+	if err := RegisterWordlist(testLanguage, strings.Join(words, "\n")); err != nil {
+		panic(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, vector := range testVectors() {
+		assert.NoError(t, Validate(vector.mnemonic), vector.mnemonic)
+	}
+
+	cases := []struct {
+		mnemonic string
+		err      error
+	}{
+		{
+			"abandon abandon abandn abandon abandon abandon abandon abandon abandon abandon abandon about",
+			InvalidWordError{Index: 2, Word: "abandn", Language: English},
+		},
+		{
+			"legal winner thank year wave sausage worth useful legal winner thank yelow",
+			InvalidWordError{Index: 11, Word: "yelow", Language: English},
+		},
+		{
+			"Abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			InvalidWordError{Index: 0, Word: "Abandon", Language: English},
+		},
+		{
+			"foo qux xyzzy foo qux xyzzy foo qux xyzzy foo qux xyzzy",
+			InvalidWordError{Index: 0, Word: "foo"},
+		},
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+			ErrInvalidChecksum,
+		},
+		{
+			"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo",
+			WordCountError{11},
+		},
+		{
+			"",
+			WordCountError{0},
+		},
+	}
+	for _, tc := range cases {
+		err := Validate(tc.mnemonic)
+		assert.Equal(t, tc.err, err, tc.mnemonic)
+	}
+
+	assert.Equal(t,
+		`invalid mnemonic: word #3 "abandn" is not in the english wordlist`,
+		Validate(cases[0].mnemonic).Error())
+	assert.Equal(t,
+		`invalid mnemonic: word #1 "foo" is not in any known wordlist`,
+		Validate(cases[3].mnemonic).Error())
+}
+
+func TestValidateWhitespace(t *testing.T) {
+	mnemonic := "abandon\tabandon abandon\nabandon  abandon abandon abandon abandon abandon abandon\u3000abandon about "
+	assert.NoError(t, Validate(mnemonic))
+}
+
+func TestLanguageDetection(t *testing.T) {
+	entropy, err := hex.DecodeString("7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f")
+	require.NoError(t, err)
+
+	mnemonic, err := NewMnemonicWithLanguage(entropy, testLanguage)
+	require.NoError(t, err)
+	assert.NoError(t, Validate(mnemonic))
+	assert.True(t, IsMnemonicValid(mnemonic))
+
+	// generated words are NFKD normalized, and words with composed
+	// characters are normalized the same way.
+	require.NotContains(t, mnemonic, "\u00e9")
+	composed := strings.ReplaceAll(mnemonic, "e\u0301", "\u00e9")
+	require.NotEqual(t, mnemonic, composed)
+	assert.NoError(t, Validate(composed))
+	assert.Equal(t, NewSeed(mnemonic, "passe\u0301"), NewSeed(composed, "pass\u00e9"))
+
+	// the same entropy round-trips through both languages.
+	b1, err := MnemonicToByteArray(mnemonic)
+	require.NoError(t, err)
+	english, err := NewMnemonic(entropy)
+	require.NoError(t, err)
+	b2, err := MnemonicToByteArray(english)
+	require.NoError(t, err)
+	assert.Equal(t, b1, b2)
+
+	// a stray word of another language is reported against the detected
+	// language.
+	words := strings.Fields(mnemonic)
+	words[5] = "abandon"
+	err = Validate(strings.Join(words, " "))
+	assert.Equal(t, InvalidWordError{Index: 5, Word: "abandon", Language: testLanguage}, err)
+}
+
+func TestNewMnemonicWithLanguage(t *testing.T) {
+	entropy, err := NewEntropy(128)
+	require.NoError(t, err)
+
+	mnemonic, err := NewMnemonicWithLanguage(entropy, English)
+	require.NoError(t, err)
+	mnemonic2, err := NewMnemonic(entropy)
+	require.NoError(t, err)
+	assert.Equal(t, mnemonic, mnemonic2)
+
+	_, err = NewMnemonicWithLanguage(entropy, ChineseTraditional)
+	assert.Equal(t, UnsupportedLanguageError{ChineseTraditional}, err)
+
+	assert.Equal(t, []Language{English, Japanese, Spanish, ChineseSimplified, testLanguage}, Languages())
+}
+
+func TestSeedIdeographicSpace(t *testing.T) {
+	// Japanese mnemonics are joined with ideographic spaces, which NFKD
+	// normalizes to plain spaces.
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	assert.Equal(t,
+		NewSeed(mnemonic, "TREZOR"),
+		NewSeed(strings.ReplaceAll(mnemonic, " ", "\u3000"), "TREZOR"))
+}
+
+func TestRegisterWordlist(t *testing.T) {
+	words := make([]string, 2048)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+
+	assert.Error(t, RegisterWordlist(testLanguage, strings.Join(words, "\n")), "already registered")
+	assert.Error(t, RegisterWordlist("short", strings.Join(words[1:], "\n")))
+	dup := append([]string{}, words...)
+	dup[7] = dup[3]
+	assert.Error(t, RegisterWordlist("dup", strings.Join(dup, "\n")))
+	spaced := append([]string{}, words...)
+	spaced[7] = "two words"
+	assert.Error(t, RegisterWordlist("spaced", strings.Join(spaced, "\n")))
+	assert.Equal(t, []Language{English, Japanese, Spanish, ChineseSimplified, testLanguage}, Languages())
+}
+
+// bundledVectors are test vectors of the bundled wordlists other than
+// English. The first Japanese vector is that of the official Japanese test
+// vectors linked from the BIP 39 spec
+// (https://github.com/bip32JP/bip32JP.github.io/blob/master/test_JP_BIP39.json),
+// whose passphrase checks the NFKD normalization of the seed; the others
+// were computed with an independent implementation of BIP 39.
+func bundledVectors() []struct {
+	lang       Language
+	entropy    string
+	mnemonic   string
+	passphrase string
+	seed       string
+} {
+	return []struct {
+		lang       Language
+		entropy    string
+		mnemonic   string
+		passphrase string
+		seed       string
+	}{
+		{
+			lang:       Japanese,
+			entropy:    "00000000000000000000000000000000",
+			mnemonic:   "あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あおぞら",
+			passphrase: "㍍ガバヴァぱばぐゞちぢ十人十色",
+			seed:       "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55",
+		},
+		{
+			lang:       Japanese,
+			entropy:    "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic:   "そつう　れきだい　ほんやく　わかす　りくつ　ばいか　ろせん　やちん　そつう　れきだい　ほんやく　わかめ",
+			passphrase: "㍍ガバヴァぱばぐゞちぢ十人十色",
+			seed:       "aee025cbe6ca256862f889e48110a6a382365142f7d16f2b9545285b3af64e542143a577e9c144e101a6bdca18f8d97ec3366ebf5b088b1c1af9bc31346e60d9",
+		},
+		{
+			lang:       Japanese,
+			entropy:    "ffffffffffffffffffffffffffffffff",
+			mnemonic:   "われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　ろんぶん",
+			passphrase: "㍍ガバヴァぱばぐゞちぢ十人十色",
+			seed:       "4cd2ef49b479af5e1efbbd1e0bdc117f6a29b1010211df4f78e2ed40082865793e57949236c43b9fe591ec70e5bb4298b8b71dc4b267bb96ed4ed282c8f7761c",
+		},
+		{
+			lang:       Japanese,
+			entropy:    "6610b25967cdcca9d59875f5cb50b0ea75433311869e930b",
+			mnemonic:   "しはつ　たいちょう　ちめいど　ひりつ　ほくろ　こやく　こんかい　いひん　よろしい　さくら　がはく　ふっかつ　こまる　つごう　けぬき　ふすま　ちから　さくし",
+			passphrase: "㍍ガバヴァぱばぐゞちぢ十人十色",
+			seed:       "11aa90d02f9bcb2087cbe3cc1f6c28022467c0ee66623635ad91c6efea5e3a08b1a0657798e297f61b214f4e8d7d7624222daa46cffa995b6d5ba1c48fb3d24c",
+		},
+		{
+			lang:       Japanese,
+			entropy:    "68a79eaca2324873eacc50cb9c6eca8cc68ea5d936f98787c60c7ebc74e6ce7c",
+			mnemonic:   "しやくしょ　くちこみ　どんぶり　けつじょ　おとしもの　くうぐん　どんぶり　たずさわる　ひたむき　みうち　にほん　うわさ　しゃけん　このよ　じどう　ほめる　たいよう　くふう　そんちょう　ろくが　はんこ　せあぶら　くうぐん　そっこう",
+			passphrase: "㍍ガバヴァぱばぐゞちぢ十人十色",
+			seed:       "0aea6cd271d2164a58cb0a180b7a55e423470955da1fa717532a852592e009ba91b6b7c90f51cfb1ff0382a8daa7e9f2b3a35607fb547d522e2516a0ddc861c1",
+		},
+		{
+			lang:       Spanish,
+			entropy:    "00000000000000000000000000000000",
+			mnemonic:   "ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco abierto",
+			passphrase: "TREZOR",
+			seed:       "29a2ee16de47d07025de37e7d9c596869439f9bcd26a702d2bae64db2bf0f68383841c5444b5b3bd39dd720d2ebe59969e110e5955c8e6d32c6c3294fd87439b",
+		},
+		{
+			lang:       Spanish,
+			entropy:    "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic:   "ligero vista talar yogur venta queso yacer trozo ligero vista talar zafiro",
+			passphrase: "TREZOR",
+			seed:       "1580aa5d5d67057b3a0a12253c283b93921851555529d0bbe9634349d641029216f791ddce3527819d44d833a0df3500b15fd8ba4cae7ca24e1464b9167de633",
+		},
+		{
+			lang:       Spanish,
+			entropy:    "ffffffffffffffffffffffffffffffff",
+			mnemonic:   "zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo yodo",
+			passphrase: "TREZOR",
+			seed:       "a9d1f751178872cc53fc5433e9b2a97526448adc4b824cedeadd8a127c2416481345dfbef2bfc78275f3498e40b4e8e2e00560100e543aba3f324e752f032bc9",
+		},
+		{
+			lang:       Spanish,
+			entropy:    "6610b25967cdcca9d59875f5cb50b0ea75433311869e930b",
+			mnemonic:   "gráfico madera muro rutina suelo falso favor añadir variar firma casco semana fácil neón don sección morder fingir",
+			passphrase: "TREZOR",
+			seed:       "5b48222ce814960e3b2f507ba58e96b4fa655f76060943b47c7a1396d431c570849e6f1595add9474934a72110bd3da06824428650be819f8d093e0023fccee6",
+		},
+		{
+			lang:       Spanish,
+			entropy:    "68a79eaca2324873eacc50cb9c6eca8cc68ea5d936f98787c60c7ebc74e6ce7c",
+			mnemonic:   "guion culebra parcela diluir buitre crecer parcela marzo roce tejado picar azafrán guitarra exilio goloso tabla mando curar loco voto reparto insecto crecer lince",
+			passphrase: "TREZOR",
+			seed:       "5dd9ecc2a8f504413ee001e4f27f25ad14533a35b3986b4ad505a9774740d0c0a6bbac6953a1ee47104357f4a5bc4acbc0f71813f9532fa667f3d3b6f2d6dd6d",
+		},
+		{
+			lang:       ChineseSimplified,
+			entropy:    "00000000000000000000000000000000",
+			mnemonic:   "的 的 的 的 的 的 的 的 的 的 的 在",
+			passphrase: "TREZOR",
+			seed:       "7f7c7f91ef81f0fb6a3b95b346c50e6472c1d554f8ba90637bad8afce4a4de87c322c1acafa2f6f5e9a8f9b2d2c40e9d389efdc2adbe4445c21a0939fb39e91f",
+		},
+		{
+			lang:       ChineseSimplified,
+			entropy:    "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic:   "枪 疫 霉 尝 俩 闹 饿 贤 枪 疫 霉 卿",
+			passphrase: "TREZOR",
+			seed:       "816a69d6866891b246b4d33f54d6d2be624470141754396205d039bdd8003949fec4340253dde4c8e11437a181ad992f56d5b976eb9fbe48f4c5e5fec60a27e1",
+		},
+		{
+			lang:       ChineseSimplified,
+			entropy:    "ffffffffffffffffffffffffffffffff",
+			mnemonic:   "歇 歇 歇 歇 歇 歇 歇 歇 歇 歇 歇 逻",
+			passphrase: "TREZOR",
+			seed:       "08ac5d9bed9441013b32bc317aaddeb8310011f219b48239faa4adeeb8b79cb0a3e4d1cb460d2dd37888c0a19bef6edd90ced0fd613d48899eab9ee649d77fcd",
+		},
+		{
+			lang:       ChineseSimplified,
+			entropy:    "6610b25967cdcca9d59875f5cb50b0ea75433311869e930b",
+			mnemonic:   "父 泥 炼 胁 鞋 控 载 政 惨 逐 整 碗 环 惯 案 棒 订 移",
+			passphrase: "TREZOR",
+			seed:       "ff66373b70b72b34842f936bf3bb44d661fdafaee7740d574fed6aa2ef07783cb6111f2862cbd3fc5528e322dfe054557a74a568a1b46c020cb88938e2293ca0",
+		},
+		{
+			lang:       ChineseSimplified,
+			entropy:    "68a79eaca2324873eacc50cb9c6eca8cc68ea5d936f98787c60c7ebc74e6ce7c",
+			mnemonic:   "宁 照 违 材 交 养 违 野 悉 偷 梅 设 贵 帝 鲜 仰 圈 首 荷 钩 隙 抓 养 熟",
+			passphrase: "TREZOR",
+			seed:       "6ba622f907c61e29e44833b08441b7afa84889a48ca90ebf90f585e257662b2c1b0c35ad54088e745c73689921209fdd4b5b8ace5d850e366d7c2042a076e660",
+		},
+	}
+}
+
+func TestBundledWordlists(t *testing.T) {
+	for _, vector := range bundledVectors() {
+		entropy, err := hex.DecodeString(vector.entropy)
+		require.NoError(t, err)
+
+		// the words of generated mnemonics are NFKD normalized, and Japanese
+		// ones are joined with ideographic spaces.
+		mnemonic, err := NewMnemonicWithLanguage(entropy, vector.lang)
+		require.NoError(t, err)
+		sep := " "
+		if vector.lang == Japanese {
+			sep = "\u3000"
+		}
+		words := strings.Split(vector.mnemonic, sep)
+		for i, word := range words {
+			words[i] = norm.NFKD.String(word)
+		}
+		assert.Equal(t, strings.Join(words, sep), mnemonic, vector.mnemonic)
+
+		assert.NoError(t, Validate(vector.mnemonic), vector.mnemonic)
+		b1, err := MnemonicToByteArray(vector.mnemonic)
+		require.NoError(t, err)
+		english, err := NewMnemonic(entropy)
+		require.NoError(t, err)
+		b2, err := MnemonicToByteArray(english)
+		require.NoError(t, err)
+		assert.Equal(t, b2, b1, vector.mnemonic)
+
+		seed, err := NewSeedWithErrorChecking(vector.mnemonic, vector.passphrase)
+		require.NoError(t, err)
+		assert.Equal(t, vector.seed, hex.EncodeToString(seed), vector.mnemonic)
+		assert.Equal(t, vector.seed, hex.EncodeToString(NewSeed(mnemonic, vector.passphrase)), vector.mnemonic)
+	}
+}
@@ -16,13 +16,29 @@ func init() {
 		ReverseWordMap[v] = i
 	}
 
-	// Ensure word list is correct
+	// Ensure the bundled word lists are correct, and register them;
+	// English first, as it is preferred when detecting the language of a
+	// mnemonic.
 	// $ wget https://raw.githubusercontent.com/bitcoin/bips/master/bip-0039/english.txt
 	// $ crc32 english.txt
 	// c1dbd296
-	checksum := crc32.ChecksumIEEE([]byte(englishWordList))
-	if fmt.Sprintf("%x", checksum) != "c1dbd296" {
-		panic("englishWordList checksum invalid")
+	for _, bundled := range []struct {
+		lang     Language
+		words    string
+		checksum string
+	}{
+		{English, englishWordList, "c1dbd296"},
+		{Japanese, japaneseWordList, "acc1419"},
+		{Spanish, spanishWordList, "266e4f3d"},
+		{ChineseSimplified, chineseSimplifiedWordList, "e3721bbf"},
+	} {
+		checksum := crc32.ChecksumIEEE([]byte(bundled.words))
+		if fmt.Sprintf("%x", checksum) != bundled.checksum {
+			panic(fmt.Sprintf("%s wordlist checksum invalid", bundled.lang))
+		}
+		if err := RegisterWordlist(bundled.lang, bundled.words); err != nil {
+			panic(err)
+		}
 	}
 }
 
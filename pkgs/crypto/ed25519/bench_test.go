@@ -24,3 +24,19 @@ func BenchmarkVerification(b *testing.B) {
 	priv := GenPrivKey()
 	benchmarking.BenchmarkVerification(b, priv)
 }
+
+func BenchmarkBatchVerification(b *testing.B) {
+	benchmarking.BenchmarkBatchVerification(b, genPrivKeys(64))
+}
+
+func BenchmarkSequentialVerification(b *testing.B) {
+	benchmarking.BenchmarkSequentialVerification(b, genPrivKeys(64))
+}
+
+func genPrivKeys(n int) []crypto.PrivKey {
+	privs := make([]crypto.PrivKey, n)
+	for i := range privs {
+		privs[i] = GenPrivKey()
+	}
+	return privs
+}
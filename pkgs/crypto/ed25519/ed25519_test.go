@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/crypto/ed25519"
 )
@@ -27,3 +29,20 @@ func TestSignAndValidateEd25519(t *testing.T) {
 
 	assert.False(t, pubKey.VerifyBytes(msg, sig))
 }
+
+func TestAddressAndPubKeyRoundTripEd25519(t *testing.T) {
+	pubKey := ed25519.GenPrivKey().PubKey()
+
+	// pubkey, as found in signatures and accounts
+	var pubKey2 crypto.PubKey
+	require.NoError(t, amino.Unmarshal(pubKey.Bytes(), &pubKey2))
+	assert.Equal(t, pubKey, pubKey2)
+	assert.IsType(t, ed25519.PubKeyEd25519{}, pubKey2)
+
+	// bech32 address
+	addr := pubKey.Address()
+	addr2, err := crypto.AddressFromBech32(addr.String())
+	require.NoError(t, err)
+	assert.Equal(t, addr, addr2)
+	assert.Equal(t, addr, pubKey2.Address())
+}
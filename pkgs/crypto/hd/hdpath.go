@@ -109,12 +109,9 @@ func NewParamsFromPath(path string) (*BIP44Params, error) {
 
 func hardenedInt(field string) (uint32, error) {
 	field = strings.TrimSuffix(field, "'")
-	i, err := strconv.Atoi(field)
+	i, err := strconv.ParseUint(field, 10, 31)
 	if err != nil {
-		return 0, err
-	}
-	if i < 0 {
-		return 0, fmt.Errorf("fields must not be negative. got %d", i)
+		return 0, fmt.Errorf("invalid path field %q: must be an integer between 0 and %d", field, HardenedOffset-1)
 	}
 	return uint32(i), nil
 }
@@ -170,33 +167,66 @@ func ComputeMastersFromSeed(seed []byte) (secret [32]byte, chainCode [32]byte) {
 }
 
 // DerivePrivateKeyForPath derives the private key by following the BIP 32/44 path from privKeyBytes,
-// using the given chainCode.
+// using the given chainCode. The path is parsed with ParseDerivationPath.
 func DerivePrivateKeyForPath(privKeyBytes [32]byte, chainCode [32]byte, path string) ([32]byte, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return [32]byte{}, err
+	}
 	data := privKeyBytes
+	for _, idx := range indexes {
+		harden := idx >= HardenedOffset
+		data, chainCode = derivePrivateKey(data, chainCode, idx&^HardenedOffset, harden)
+	}
+	return data, nil
+}
+
+// HardenedOffset is added to the index of hardened path components.
+const HardenedOffset uint32 = 0x80000000
+
+// ParseDerivationPath parses a BIP 32 path such as "m/44'/118'/0'/0/0" into
+// child indexes, where hardened indexes have HardenedOffset added. The
+// leading "m/" is optional, and "m" alone is the master key. Every other
+// component must be a decimal integer below HardenedOffset, optionally
+// followed by an apostrophe for hardened derivation.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	if path == "" {
+		return nil, fmt.Errorf("invalid BIP 32 path: empty path")
+	}
 	parts := strings.Split(path, "/")
-	for _, part := range parts {
-		// do we have an apostrophe?
-		harden := part[len(part)-1:] == "'"
-		// harden == private derivation, else public derivation:
-		if harden {
-			part = part[:len(part)-1]
-		}
-		idx, err := strconv.Atoi(part)
+	if parts[0] == "m" {
+		parts = parts[1:]
+	}
+	indexes := make([]uint32, len(parts))
+	for i, part := range parts {
+		idx, err := parsePathComponent(part)
 		if err != nil {
-			return [32]byte{}, fmt.Errorf("invalid BIP 32 path: %s", err)
+			return nil, fmt.Errorf("invalid BIP 32 path %q: component %d: %s", path, i+1, err)
 		}
-		if idx < 0 {
-			return [32]byte{}, errors.New("invalid BIP 32 path: index negative ot too large")
+		indexes[i] = idx
+	}
+	return indexes, nil
+}
+
+func parsePathComponent(part string) (uint32, error) {
+	var offset uint32
+	if strings.HasSuffix(part, "'") {
+		part = part[:len(part)-1]
+		offset = HardenedOffset
+	}
+	if part == "" {
+		return 0, errors.New("empty index")
+	}
+	for _, c := range part {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("%q is not a decimal integer", part)
 		}
-		data, chainCode = derivePrivateKey(data, chainCode, uint32(idx), harden)
 	}
-	var derivedKey [32]byte
-	n := copy(derivedKey[:], data[:])
-	if n != 32 || len(data) != 32 {
-		return [32]byte{}, fmt.Errorf("expected a (secp256k1) key of length 32, got length: %v", len(data))
+	idx, err := strconv.ParseUint(part, 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("index %s is too large, max %d", part, HardenedOffset-1)
 	}
-
-	return derivedKey, nil
+	return uint32(idx) + offset, nil
 }
 
 // derivePrivateKey derives the private key with index and chainCode.
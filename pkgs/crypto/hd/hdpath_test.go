@@ -18,7 +18,7 @@ func mnemonicToSeed(mnemonic string) []byte {
 }
 
 // nolint: vet
-func ExampleStringifyPathParams() {
+func Example_stringifyPathParams() {
 	path := NewParams(44, 0, 0, false, 0)
 	fmt.Println(path.String())
 	path = NewParams(44, 33, 7, true, 9)
@@ -106,7 +106,7 @@ func TestParamsFromPath(t *testing.T) {
 }
 
 // nolint: vet
-func ExampleSomeBIP32TestVecs() {
+func Example_someBIP32TestVecs() {
 
 	seed := mnemonicToSeed("barrel original fuel morning among eternal " +
 		"filter ball stove pluck matrix mechanic")
@@ -0,0 +1,32 @@
+package hd
+
+import (
+	"github.com/gnolang/gno/pkgs/crypto/bip39"
+)
+
+// NewMnemonic returns a new random BIP 39 mnemonic with the given bits of
+// entropy, which must be a multiple of 32 between 128 and 256.
+func NewMnemonic(entropyBits int) (string, error) {
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// Derive returns the secp256k1 private key for the BIP 32 path hdPath,
+// e.g. "m/44'/118'/0'/0/0", of the wallet with the given BIP 39 mnemonic
+// and passphrase. The same inputs give the same key as other BIP 32/44
+// wallets.
+func Derive(mnemonic, bip39Passphrase, hdPath string) ([]byte, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, bip39Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	master, ch := ComputeMastersFromSeed(seed)
+	derivedPriv, err := DerivePrivateKeyForPath(master, ch, hdPath)
+	if err != nil {
+		return nil, err
+	}
+	return derivedPriv[:], nil
+}
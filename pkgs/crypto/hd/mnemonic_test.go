@@ -0,0 +1,133 @@
+package hd
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+)
+
+// Test vector 1 of
+// https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki#test-vectors
+func TestBIP32TestVector1(t *testing.T) {
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+	master, ch := ComputeMastersFromSeed(seed)
+	assert.Equal(t, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", hex.EncodeToString(master[:]))
+	assert.Equal(t, "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", hex.EncodeToString(ch[:]))
+
+	cases := []struct {
+		path string
+		priv string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tc := range cases {
+		priv, err := DerivePrivateKeyForPath(master, ch, tc.path)
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, tc.priv, hex.EncodeToString(priv[:]), tc.path)
+	}
+}
+
+func TestDeriveFundraiserAddresses(t *testing.T) {
+	for i, d := range initFundraiserTestVectors(t) {
+		priv, err := Derive(d.Mnemonic, "", "m/44'/118'/0'/0/0")
+		require.NoError(t, err)
+		assert.Equal(t, d.Priv, hex.EncodeToString(priv), "#%d", i)
+
+		var secpPriv secp256k1.PrivKeySecp256k1
+		copy(secpPriv[:], priv)
+		addrB, err := hex.DecodeString(d.Addr)
+		require.NoError(t, err)
+		assert.Equal(t, crypto.AddressFromBytes(addrB), secpPriv.PubKey().Address(), "#%d", i)
+
+		// the leading "m/" is optional.
+		priv2, err := Derive(d.Mnemonic, "", "44'/118'/0'/0/0")
+		require.NoError(t, err)
+		assert.Equal(t, priv, priv2)
+	}
+}
+
+func TestDeriveErrors(t *testing.T) {
+	mnemonic := "measure slogan connect luggage stereo federal stuff stomach stumble security end differ"
+
+	_, err := Derive("measure slogan connect luggage stereo federal stuff stomach stumble security end end", "", "m/44'/118'/0'/0/0")
+	assert.Error(t, err)
+
+	_, err = Derive(mnemonic, "", "m/44'/118'/x/0/0")
+	assert.EqualError(t, err, `invalid BIP 32 path "m/44'/118'/x/0/0": component 3: "x" is not a decimal integer`)
+
+	// a different passphrase gives a different key.
+	priv1, err := Derive(mnemonic, "", "m/44'/118'/0'/0/0")
+	require.NoError(t, err)
+	priv2, err := Derive(mnemonic, "secret", "m/44'/118'/0'/0/0")
+	require.NoError(t, err)
+	assert.NotEqual(t, priv1, priv2)
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	goodCases := []struct {
+		path    string
+		indexes []uint32
+	}{
+		{"m", []uint32{}},
+		{"m/0", []uint32{0}},
+		{"0'", []uint32{HardenedOffset}},
+		{"m/44'/118'/0'/0/0", []uint32{44 + HardenedOffset, 118 + HardenedOffset, HardenedOffset, 0, 0}},
+		{"44'/118'/0'/0/0", []uint32{44 + HardenedOffset, 118 + HardenedOffset, HardenedOffset, 0, 0}},
+		{"m/2147483647'/2147483647", []uint32{0xffffffff, 0x7fffffff}},
+	}
+	for _, tc := range goodCases {
+		indexes, err := ParseDerivationPath(tc.path)
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, tc.indexes, indexes, tc.path)
+	}
+
+	badCases := []struct {
+		path string
+		err  string
+	}{
+		{"", "empty path"},
+		{"m/", "component 1: empty index"},
+		{"m/44'//0", "component 2: empty index"},
+		{"m/44'/'", "component 2: empty index"},
+		{"m/m/0", `component 1: "m" is not a decimal integer`},
+		{"m/-1", `component 1: "-1" is not a decimal integer`},
+		{"m/+1", `component 1: "+1" is not a decimal integer`},
+		{"m/1''", `component 1: "1'" is not a decimal integer`},
+		{"m/1h", `component 1: "1h" is not a decimal integer`},
+		{"m/ 1", `component 1: " 1" is not a decimal integer`},
+		{"m/2147483648", "component 1: index 2147483648 is too large, max 2147483647"},
+		{"m/2147483648'", "component 1: index 2147483648 is too large, max 2147483647"},
+	}
+	for _, tc := range badCases {
+		_, err := ParseDerivationPath(tc.path)
+		require.Error(t, err, tc.path)
+		assert.True(t, strings.HasSuffix(err.Error(), tc.err), "%q: %v", tc.path, err)
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bits)
+		require.NoError(t, err)
+		assert.Len(t, strings.Fields(mnemonic), bits/32*3)
+		_, err = Derive(mnemonic, "", "m/44'/118'/0'/0/0")
+		assert.NoError(t, err)
+	}
+
+	for _, bits := range []int{0, 96, 100, 288} {
+		_, err := NewMnemonic(bits)
+		assert.Error(t, err, "%d", bits)
+	}
+}
@@ -57,6 +57,52 @@ func BenchmarkVerification(b *testing.B, priv crypto.PrivKey) {
 	}
 }
 
+// BenchmarkBatchVerification benchmarks verifying n signatures with a
+// crypto.BatchVerifier, reporting the time per signature. Compare it with
+// BenchmarkSequentialVerification of the same keys.
+func BenchmarkBatchVerification(b *testing.B, privs []crypto.PrivKey) {
+	message, pubs, sigs := signAll(b, privs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(privs) {
+		bv := crypto.NewBatchVerifier()
+		for j := range privs {
+			bv.Add(pubs[j], message, sigs[j])
+		}
+		if ok, _ := bv.VerifyAll(); !ok {
+			b.Fatal("batch verification failed")
+		}
+	}
+}
+
+// BenchmarkSequentialVerification benchmarks verifying n signatures one by
+// one with PubKey.VerifyBytes, reporting the time per signature.
+func BenchmarkSequentialVerification(b *testing.B, privs []crypto.PrivKey) {
+	message, pubs, sigs := signAll(b, privs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(privs) {
+		for j := range privs {
+			if !pubs[j].VerifyBytes(message, sigs[j]) {
+				b.Fatal("verification failed")
+			}
+		}
+	}
+}
+
+// signAll signs a short message with each of privs.
+func signAll(b *testing.B, privs []crypto.PrivKey) ([]byte, []crypto.PubKey, [][]byte) {
+	message := []byte("Hello, world!")
+	pubs := make([]crypto.PubKey, len(privs))
+	sigs := make([][]byte, len(privs))
+	for i, priv := range privs {
+		sig, err := priv.Sign(message)
+		if err != nil {
+			b.Fatal(err)
+		}
+		pubs[i], sigs[i] = priv.PubKey(), sig
+	}
+	return message, pubs, sigs
+}
+
 // Below is the aforementioned license.
 
 // Copyright (c) 2012 The Go Authors. All rights reserved.
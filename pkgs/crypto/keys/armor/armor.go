@@ -1,13 +1,16 @@
 package armor
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"sort"
 
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/crypto/armor"
 	"github.com/gnolang/gno/pkgs/crypto/bcrypt"
 	"github.com/gnolang/gno/pkgs/crypto/keys/keyerror"
+	"github.com/gnolang/gno/pkgs/crypto/xchacha20poly1305"
 	"github.com/gnolang/gno/pkgs/crypto/xsalsa20symmetric"
 	"github.com/gnolang/gno/pkgs/os"
 )
@@ -17,6 +20,11 @@ const (
 	blockTypeKeyInfo        = "TENDERMINT KEY INFO"
 	blockTypePubKey         = "TENDERMINT PUBLIC KEY"
 	bcryptSecurityParameter = 12
+
+	// privKeyArmorVersion is the version of private key armors written by
+	// EncryptArmorPrivKeyWithKDF. Legacy armors have no version header.
+	privKeyArmorVersion     = "1"
+	cipherXChaCha20Poly1305 = "xchacha20poly1305"
 )
 
 //-----------------------------------------------------------------
@@ -72,7 +80,8 @@ func unarmorBytes(armorStr, blockType string) (bz []byte, err error) {
 //-----------------------------------------------------------------
 // encrypt/decrypt with armor
 
-// Encrypt and armor the private key.
+// Encrypt and armor the private key, in the legacy format with bcrypt and
+// xsalsa20. See EncryptArmorPrivKeyWithKDF for the versioned format.
 func EncryptArmorPrivKey(privKey crypto.PrivKey, passphrase string) string {
 	saltBytes, encBytes := encryptPrivKey(privKey, passphrase)
 	header := map[string]string{
@@ -97,25 +106,68 @@ func encryptPrivKey(privKey crypto.PrivKey, passphrase string) (saltBytes []byte
 	return saltBytes, xsalsa20symmetric.EncryptSymmetric(privKeyBytes, key)
 }
 
-// Unarmor and decrypt the private key.
+// EncryptArmorPrivKeyWithKDF encrypts and armors the private key in the
+// versioned format: the key is derived from the passphrase with kdf, whose
+// parameters are recorded in the armor headers, and the private key is
+// sealed with XChaCha20-Poly1305. The headers are authenticated, and a
+// checksum tells corrupt armors apart from wrong passphrases.
+func EncryptArmorPrivKeyWithKDF(privKey crypto.PrivKey, passphrase string, kdf KDFParams) (string, error) {
+	if err := kdf.ValidateBasic(); err != nil {
+		return "", err
+	}
+	salt := crypto.CRandBytes(16)
+	header := kdf.header()
+	header["version"] = privKeyArmorVersion
+	header["salt"] = fmt.Sprintf("%X", salt)
+	header["cipher"] = cipherXChaCha20Poly1305
+
+	key, err := kdf.deriveKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	aead, err := xchacha20poly1305.New(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := crypto.CRandBytes(aead.NonceSize())
+	encBytes := aead.Seal(nonce, nonce, privKey.Bytes(), canonicalHeader(header))
+	header["checksum"] = armorChecksum(header, encBytes)
+	return armor.EncodeArmor(blockTypePrivKey, header, encBytes), nil
+}
+
+// Unarmor and decrypt the private key, in either the legacy or the
+// versioned format. A wrong passphrase returns an error for which
+// keyerror.IsErrWrongPassword is true, and a damaged armor one for which
+// keyerror.IsErrCorruptArmor is true.
 func UnarmorDecryptPrivKey(armorStr string, passphrase string) (crypto.PrivKey, error) {
 	var privKey crypto.PrivKey
 	blockType, header, encBytes, err := armor.DecodeArmor(armorStr)
 	if err != nil {
-		return privKey, err
+		return privKey, keyerror.NewErrCorruptArmor(err.Error())
 	}
 	if blockType != blockTypePrivKey {
 		return privKey, fmt.Errorf("Unrecognized armor type: %v", blockType)
 	}
+	switch header["version"] {
+	case "":
+		return unarmorDecryptLegacyPrivKey(header, encBytes, passphrase)
+	case privKeyArmorVersion:
+		return unarmorDecryptPrivKeyV1(header, encBytes, passphrase)
+	default:
+		return privKey, keyerror.NewErrCorruptArmor(fmt.Sprintf("unrecognized version: %v", header["version"]))
+	}
+}
+
+func unarmorDecryptLegacyPrivKey(header map[string]string, encBytes []byte, passphrase string) (privKey crypto.PrivKey, err error) {
 	if header["kdf"] != "bcrypt" {
-		return privKey, fmt.Errorf("Unrecognized KDF type: %v", header["KDF"])
+		return privKey, fmt.Errorf("Unrecognized KDF type: %v", header["kdf"])
 	}
 	if header["salt"] == "" {
-		return privKey, fmt.Errorf("Missing salt bytes")
+		return privKey, keyerror.NewErrCorruptArmor("missing salt bytes")
 	}
 	saltBytes, err := hex.DecodeString(header["salt"])
 	if err != nil {
-		return privKey, fmt.Errorf("Error decoding salt: %v", err.Error())
+		return privKey, keyerror.NewErrCorruptArmor("decoding salt: " + err.Error())
 	}
 	privKey, err = decryptPrivKey(saltBytes, encBytes, passphrase)
 	return privKey, err
@@ -136,3 +188,63 @@ func decryptPrivKey(saltBytes []byte, encBytes []byte, passphrase string) (privK
 	privKey, err = crypto.PrivKeyFromBytes(privKeyBytes)
 	return privKey, err
 }
+
+func unarmorDecryptPrivKeyV1(header map[string]string, encBytes []byte, passphrase string) (privKey crypto.PrivKey, err error) {
+	checksum := header["checksum"]
+	delete(header, "checksum")
+	if checksum != armorChecksum(header, encBytes) {
+		return privKey, keyerror.NewErrCorruptArmor("checksum mismatch")
+	}
+	if header["cipher"] != cipherXChaCha20Poly1305 {
+		return privKey, fmt.Errorf("Unrecognized cipher: %v", header["cipher"])
+	}
+	kdf, err := kdfFromHeader(header)
+	if err != nil {
+		return privKey, err
+	}
+	salt, err := hex.DecodeString(header["salt"])
+	if err != nil || len(salt) == 0 {
+		return privKey, keyerror.NewErrCorruptArmor("invalid salt")
+	}
+
+	key, err := kdf.deriveKey(passphrase, salt)
+	if err != nil {
+		return privKey, err
+	}
+	aead, err := xchacha20poly1305.New(key)
+	if err != nil {
+		return privKey, err
+	}
+	if len(encBytes) < aead.NonceSize() {
+		return privKey, keyerror.NewErrCorruptArmor("ciphertext too short")
+	}
+	nonce, ciphertext := encBytes[:aead.NonceSize()], encBytes[aead.NonceSize():]
+	privKeyBytes, err := aead.Open(nil, nonce, ciphertext, canonicalHeader(header))
+	if err != nil {
+		// the checksum matched, so the armor is intact.
+		return privKey, keyerror.NewErrWrongPassword()
+	}
+	return crypto.PrivKeyFromBytes(privKeyBytes)
+}
+
+// canonicalHeader returns the armor headers sorted by key, one "key: value"
+// per line.
+func canonicalHeader(header map[string]string) []byte {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\n", k, header[k])
+	}
+	return buf.Bytes()
+}
+
+// armorChecksum is the checksum of the headers and body of a versioned
+// private key armor. It is not keyed, and only detects accidental damage or
+// tampering; the AEAD authenticates both with the passphrase.
+func armorChecksum(header map[string]string, encBytes []byte) string {
+	return fmt.Sprintf("%X", crypto.Sha256(append(canonicalHeader(header), encBytes...)))
+}
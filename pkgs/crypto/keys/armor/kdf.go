@@ -0,0 +1,136 @@
+package armor
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/bcrypt"
+	"github.com/gnolang/gno/pkgs/crypto/keys/keyerror"
+)
+
+const (
+	KDFBcrypt   = "bcrypt"
+	KDFArgon2id = "argon2id"
+
+	// limits on the parameters read from armors, so that a crafted armor
+	// cannot make decryption take forever or exhaust memory. They allow a
+	// few times the cost of DefaultKDFParams and of the legacy bcrypt
+	// armors, but no more.
+	maxBcryptCost    = 16
+	maxArgon2Time    = 10
+	maxArgon2Memory  = 256 * 1024 // 256 MiB
+	maxArgon2Threads = 16
+)
+
+// KDFParams are the key derivation function and its parameters used to
+// encrypt a private key armor. They are recorded in the armor headers, so
+// that armors stay readable when the defaults change.
+type KDFParams struct {
+	Name string // KDFBcrypt or KDFArgon2id
+
+	BcryptCost int
+
+	Argon2Time    uint32
+	Argon2Memory  uint32 // KiB
+	Argon2Threads uint8
+}
+
+// DefaultKDFParams are used for new private key armors, as recommended by
+// RFC 9106 for memory constrained environments.
+var DefaultKDFParams = KDFParams{
+	Name:          KDFArgon2id,
+	Argon2Time:    3,
+	Argon2Memory:  64 * 1024,
+	Argon2Threads: 4,
+}
+
+// ValidateBasic checks that the parameters are within the limits accepted
+// when decrypting.
+func (kdf KDFParams) ValidateBasic() error {
+	switch kdf.Name {
+	case KDFBcrypt:
+		if kdf.BcryptCost < bcrypt.MinCost || kdf.BcryptCost > maxBcryptCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d, got %d",
+				bcrypt.MinCost, maxBcryptCost, kdf.BcryptCost)
+		}
+	case KDFArgon2id:
+		if kdf.Argon2Time < 1 || kdf.Argon2Time > maxArgon2Time {
+			return fmt.Errorf("argon2 time must be between 1 and %d, got %d", maxArgon2Time, kdf.Argon2Time)
+		}
+		if kdf.Argon2Memory < 8*uint32(kdf.Argon2Threads) || kdf.Argon2Memory > maxArgon2Memory {
+			return fmt.Errorf("argon2 memory must be between 8*threads and %d KiB, got %d",
+				maxArgon2Memory, kdf.Argon2Memory)
+		}
+		if kdf.Argon2Threads < 1 || kdf.Argon2Threads > maxArgon2Threads {
+			return fmt.Errorf("argon2 threads must be between 1 and %d, got %d", maxArgon2Threads, kdf.Argon2Threads)
+		}
+	default:
+		return fmt.Errorf("Unrecognized KDF type: %v", kdf.Name)
+	}
+	return nil
+}
+
+func (kdf KDFParams) header() map[string]string {
+	switch kdf.Name {
+	case KDFBcrypt:
+		return map[string]string{
+			"kdf":         KDFBcrypt,
+			"bcrypt-cost": strconv.Itoa(kdf.BcryptCost),
+		}
+	case KDFArgon2id:
+		return map[string]string{
+			"kdf":            KDFArgon2id,
+			"argon2-time":    strconv.FormatUint(uint64(kdf.Argon2Time), 10),
+			"argon2-memory":  strconv.FormatUint(uint64(kdf.Argon2Memory), 10),
+			"argon2-threads": strconv.FormatUint(uint64(kdf.Argon2Threads), 10),
+		}
+	default:
+		panic("should not happen")
+	}
+}
+
+func kdfFromHeader(header map[string]string) (kdf KDFParams, err error) {
+	kdf.Name = header["kdf"]
+	switch kdf.Name {
+	case KDFBcrypt:
+		kdf.BcryptCost, err = strconv.Atoi(header["bcrypt-cost"])
+	case KDFArgon2id:
+		var time, memory, threads uint64
+		if time, err = strconv.ParseUint(header["argon2-time"], 10, 32); err != nil {
+			break
+		}
+		if memory, err = strconv.ParseUint(header["argon2-memory"], 10, 32); err != nil {
+			break
+		}
+		if threads, err = strconv.ParseUint(header["argon2-threads"], 10, 8); err != nil {
+			break
+		}
+		kdf.Argon2Time, kdf.Argon2Memory, kdf.Argon2Threads = uint32(time), uint32(memory), uint8(threads)
+	}
+	if err != nil {
+		return kdf, keyerror.NewErrCorruptArmor("invalid KDF parameters: " + err.Error())
+	}
+	if err := kdf.ValidateBasic(); err != nil {
+		return kdf, keyerror.NewErrCorruptArmor(err.Error())
+	}
+	return kdf, nil
+}
+
+// deriveKey returns the 32 byte encryption key for passphrase.
+func (kdf KDFParams) deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	switch kdf.Name {
+	case KDFBcrypt:
+		key, err := bcrypt.GenerateFromPassword(salt, []byte(passphrase), kdf.BcryptCost)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Sha256(key), nil // get 32 bytes
+	case KDFArgon2id:
+		return argon2.IDKey([]byte(passphrase), salt, kdf.Argon2Time, kdf.Argon2Memory, kdf.Argon2Threads, 32), nil
+	default:
+		return nil, fmt.Errorf("Unrecognized KDF type: %v", kdf.Name)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gnolang/gno/pkgs/command"
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/crypto/bip39"
+	"github.com/gnolang/gno/pkgs/crypto/hd"
 	"github.com/gnolang/gno/pkgs/crypto/keys"
 	"github.com/gnolang/gno/pkgs/crypto/multisig"
 	"github.com/gnolang/gno/pkgs/errors"
@@ -24,11 +25,13 @@ type AddOptions struct {
 	DryRun            bool     `flag:"dryrun" help:"Perform action, but don't add key to local keystore"`
 	Account           uint32   `flag:"account" help:"Account number for HD derivation"`
 	Index             uint32   `flag:"index" description:"Address index number for HD derivation"`
+	Algo              string   `flag:"algo" help:"Signing algorithm of the key (secp256k1|ed25519|secp256r1)"`
 }
 
 var DefaultAddOptions = AddOptions{
 	BaseOptions:       DefaultBaseOptions,
 	MultisigThreshold: 1,
+	Algo:              string(keys.Secp256k1),
 }
 
 // DryRunKeyPass contains the default key password for genesis transactions
@@ -168,18 +171,18 @@ func addApp(cmd *command.Command, args []string, iopts interface{}) error {
 
 	if len(mnemonic) == 0 {
 		// read entropy seed straight from crypto.Rand and convert to mnemonic
-		entropySeed, err := bip39.NewEntropy(mnemonicEntropySize)
-		if err != nil {
-			return err
-		}
-
-		mnemonic, err = bip39.NewMnemonic(entropySeed[:])
+		mnemonic, err = hd.NewMnemonic(mnemonicEntropySize)
 		if err != nil {
 			return err
 		}
 	}
 
-	info, err := kb.CreateAccount(name, mnemonic, bip39Passphrase, encryptPassword, account, index)
+	algo := keys.SigningAlgo(opts.Algo)
+	if algo == "" {
+		algo = keys.Secp256k1
+	}
+	hdPath := hd.NewFundraiserParams(account, crypto.CoinType, index)
+	info, err := kb.CreateAccountWithAlgo(name, mnemonic, bip39Passphrase, encryptPassword, algo, *hdPath)
 	if err != nil {
 		return err
 	}
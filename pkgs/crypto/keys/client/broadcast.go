@@ -7,6 +7,7 @@ import (
 	"github.com/gnolang/gno/pkgs/bft/rpc/client"
 	"github.com/gnolang/gno/pkgs/command"
 	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/sdk"
 	"github.com/gnolang/gno/pkgs/std"
 )
 
@@ -56,7 +57,13 @@ func broadcastApp(cmd *command.Command, args []string, iopts interface{}) error
 	} else if bres.DeliverTx.IsErr() {
 		return errors.New("transaction failed %#v\nlog %s", bres, bres.DeliverTx.Log)
 	} else {
-		cmd.Println(string(bres.DeliverTx.Data))
+		if msgData, err := sdk.ParseMsgData(bres.DeliverTx.Data); err == nil {
+			for _, md := range msgData {
+				cmd.Println(string(md.Data))
+			}
+		} else {
+			cmd.Println(string(bres.DeliverTx.Data))
+		}
 		cmd.Println("OK!")
 		cmd.Println("GAS WANTED:", bres.DeliverTx.GasWanted)
 		cmd.Println("GAS USED:  ", bres.DeliverTx.GasUsed)
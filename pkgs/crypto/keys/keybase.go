@@ -5,13 +5,15 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/gnolang/gno/pkgs/bech32"
 	"github.com/gnolang/gno/pkgs/crypto"
-	"github.com/gnolang/gno/pkgs/crypto/bip39"
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
 	"github.com/gnolang/gno/pkgs/crypto/hd"
 	"github.com/gnolang/gno/pkgs/crypto/keys/armor"
 	"github.com/gnolang/gno/pkgs/crypto/keys/keyerror"
 	"github.com/gnolang/gno/pkgs/crypto/ledger"
 	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1"
 	dbm "github.com/gnolang/gno/pkgs/db"
 	"github.com/gnolang/gno/pkgs/errors"
 )
@@ -56,7 +58,7 @@ const (
 
 var (
 	// ErrUnsupportedSigningAlgo is raised when the caller tries to use a
-	// different signing scheme than secp256k1.
+	// different signing scheme than secp256k1 for a Ledger key.
 	ErrUnsupportedSigningAlgo = errors.New("unsupported signing algo: only secp256k1 is supported")
 
 	// ErrUnsupportedLanguage is raised when the caller tries to use a
@@ -94,12 +96,18 @@ func (kb dbKeybase) CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd str
 }
 
 func (kb dbKeybase) CreateAccountBip44(name, mnemonic, bip39Passphrase, encryptPasswd string, params hd.BIP44Params) (info Info, err error) {
-	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, bip39Passphrase)
+	return kb.CreateAccountWithAlgo(name, mnemonic, bip39Passphrase, encryptPasswd, Secp256k1, params)
+}
+
+// CreateAccountWithAlgo is like CreateAccountBip44 but creates a key of the
+// given signing algorithm.
+func (kb dbKeybase) CreateAccountWithAlgo(name, mnemonic, bip39Passphrase, encryptPasswd string, algo SigningAlgo, params hd.BIP44Params) (info Info, err error) {
+	derivedPriv, err := hd.Derive(mnemonic, bip39Passphrase, params.String())
 	if err != nil {
 		return
 	}
 
-	info, err = kb.persistDerivedKey(seed, encryptPasswd, name, params.String())
+	info, err = kb.persistDerivedKey(derivedPriv, encryptPasswd, name, algo)
 	return
 }
 
@@ -112,14 +120,28 @@ func (kb dbKeybase) CreateLedger(name string, algo SigningAlgo, hrp string, acco
 
 	coinType := crypto.CoinType
 	hdPath := hd.NewFundraiserParams(account, coinType, index)
-	priv, _, err := ledger.NewPrivKeyLedgerSecp256k1(*hdPath, hrp)
+	return kb.createLedger(name, *hdPath, hrp)
+}
+
+// CreateLedgerWithPath creates a new locally-stored reference to the Ledger
+// keypair of path. Only the path and the public key are stored. The address
+// is shown on the device for the user to confirm.
+func (kb dbKeybase) CreateLedgerWithPath(name string, path hd.BIP44Params) (Info, error) {
+	return kb.createLedger(name, path, crypto.Bech32AddrPrefix)
+}
+
+func (kb dbKeybase) createLedger(name string, path hd.BIP44Params, hrp string) (Info, error) {
+	priv, addr, err := ledger.NewPrivKeyLedgerSecp256k1(path, hrp)
 	if err != nil {
 		return nil, err
 	}
 	pub := priv.PubKey()
 
-	// Note: Once Cosmos App v1.3.1 is compulsory, it could be possible to check that pubkey and addr match
-	return kb.writeLedgerKey(name, pub, *hdPath), nil
+	// check that the address shown on the device is the one of the key
+	if expected, err := bech32.ConvertAndEncode(hrp, pub.Address().Bytes()); err != nil || addr != expected {
+		return nil, fmt.Errorf("the address %s shown on the Ledger device does not match its public key", addr)
+	}
+	return kb.writeLedgerKey(name, pub, path), nil
 }
 
 // CreateOffline creates a new reference to an offline keypair. It returns the
@@ -134,17 +156,26 @@ func (kb dbKeybase) CreateMulti(name string, pub crypto.PubKey) (Info, error) {
 	return kb.writeMultisigKey(name, pub), nil
 }
 
-func (kb *dbKeybase) persistDerivedKey(seed []byte, passwd, name, fullHdPath string) (info Info, err error) {
-	// create master key and derive first key:
-	masterPriv, ch := hd.ComputeMastersFromSeed(seed)
-	derivedPriv, err := hd.DerivePrivateKeyForPath(masterPriv, ch, fullHdPath)
-	if err != nil {
-		return
+func (kb *dbKeybase) persistDerivedKey(derivedPriv []byte, passwd, name string, algo SigningAlgo) (info Info, err error) {
+	var priv crypto.PrivKey
+	switch algo {
+	case Secp256k1:
+		var secpPriv secp256k1.PrivKeySecp256k1
+		copy(secpPriv[:], derivedPriv)
+		priv = secpPriv
+	case Ed25519:
+		// BIP32 derivation is only defined for secp256k1, so the ed25519
+		// key is generated from the derived secret instead.
+		priv = ed25519.GenPrivKeyFromSecret(derivedPriv)
+	case Secp256r1:
+		priv = secp256r1.GenPrivKeySecp256r1(derivedPriv)
+	default:
+		return nil, errors.New("unsupported signing algo: %q", algo)
 	}
 
 	// use possibly blank password to encrypt the private
 	// key and store it. User must enforce good passwords.
-	info = kb.writeLocalKey(name, secp256k1.PrivKeySecp256k1(derivedPriv), passwd)
+	info = kb.writeLocalKey(name, priv, passwd)
 	return
 }
 
@@ -308,10 +339,30 @@ func (kb dbKeybase) ExportPrivKey(name string, decryptPassphrase string,
 	return armor.EncryptArmorPrivKey(priv, encryptPassphrase), nil
 }
 
+// ExportPrivKeyArmor returns a private key in the versioned ASCII armor format,
+// encrypted with armor.DefaultKDFParams.
+// It returns an error if the key does not exist or a wrong decryption passphrase is supplied.
+func (kb dbKeybase) ExportPrivKeyArmor(name string, decryptPassphrase string,
+	encryptPassphrase string) (astr string, err error) {
+	priv, err := kb.ExportPrivateKeyObject(name, decryptPassphrase)
+	if err != nil {
+		return "", err
+	}
+
+	return armor.EncryptArmorPrivKeyWithKDF(priv, encryptPassphrase, armor.DefaultKDFParams)
+}
+
 // ImportPrivKey imports a private key in ASCII armor format.
-// It returns an error if a key with the same name exists or a wrong encryption passphrase is
-// supplied.
+// It is the same as ImportPrivKeyArmor.
 func (kb dbKeybase) ImportPrivKey(name string, astr string, passphrase string) error {
+	return kb.ImportPrivKeyArmor(name, astr, passphrase)
+}
+
+// ImportPrivKeyArmor imports a private key in the versioned or the legacy ASCII
+// armor format, and stores it encrypted with passphrase.
+// It returns an error if a key with the same name exists or a wrong encryption passphrase is
+// supplied. A corrupt armor is reported as such, see keyerror.IsErrCorruptArmor.
+func (kb dbKeybase) ImportPrivKeyArmor(name string, astr string, passphrase string) error {
 	if _, err := kb.Get(name); err == nil {
 		return errors.New("Cannot overwrite key " + name)
 	}
@@ -334,7 +385,11 @@ func (kb dbKeybase) Import(name string, astr string) (err error) {
 	if err != nil {
 		return
 	}
-	kb.db.Set(infoKey(name), infoBytes)
+	info, err := readInfo(infoBytes)
+	if err != nil {
+		return
+	}
+	kb.writeInfo(name, info)
 	return nil
 }
 
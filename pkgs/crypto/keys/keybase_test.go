@@ -1,7 +1,10 @@
 package keys
 
 import (
+	"encoding/hex"
+	goerrors "errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,6 +12,13 @@ import (
 
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+	"github.com/gnolang/gno/pkgs/crypto/hd"
+	"github.com/gnolang/gno/pkgs/crypto/keys/armor"
+	"github.com/gnolang/gno/pkgs/crypto/keys/keyerror"
+	"github.com/gnolang/gno/pkgs/crypto/ledger"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1"
+	"github.com/gnolang/gno/pkgs/errors"
 )
 
 func TestCreateAccountInvalidMnemonic(t *testing.T) {
@@ -18,7 +28,7 @@ func TestCreateAccountInvalidMnemonic(t *testing.T) {
 		"malarkey pair crucial catch public canyon evil outer stage ten gym tornado",
 		"", "", 0, 1)
 	assert.Error(t, err)
-	assert.Equal(t, "Invalid mnemonic", err.Error())
+	assert.Equal(t, `invalid mnemonic: word #1 "malarkey" is not in the english wordlist`, err.Error())
 }
 
 func TestCreateLedgerUnsupportedAlgo(t *testing.T) {
@@ -64,6 +74,95 @@ func TestCreateLedger(t *testing.T) {
 	assert.Equal(t, "44'/118'/3'/0/1", path.String())
 }
 
+func TestCreateAccountKnownAddress(t *testing.T) {
+	// from the cosmos fundraiser test vectors, see crypto/hd/test.json.
+	kb := NewInMemory()
+	info, err := kb.CreateAccount("fundraiser",
+		"measure slogan connect luggage stereo federal stuff stomach stumble security end differ",
+		"", "", 0, 0)
+	require.NoError(t, err)
+	addr, err := hex.DecodeString("72e7d6e9cfa899043a0783752a4876423f8effb8")
+	require.NoError(t, err)
+	assert.Equal(t, crypto.AddressFromBytes(addr), info.GetAddress())
+}
+
+func TestCreateAccountWithAlgo(t *testing.T) {
+	kb := NewInMemory()
+	mn := `lounge napkin all odor tilt dove win inject sleep jazz uncover traffic hint require cargo arm rocket round scan bread report squirrel step lake`
+	params := *hd.NewFundraiserParams(0, crypto.CoinType, 0)
+
+	edInfo, err := kb.CreateAccountWithAlgo("ed", mn, "", "1234", Ed25519, params)
+	require.NoError(t, err)
+	require.IsType(t, ed25519.PubKeyEd25519{}, edInfo.GetPubKey())
+	assert.Equal(t, edInfo.GetPubKey().Address(), edInfo.GetAddress())
+
+	secpInfo, err := kb.CreateAccountWithAlgo("secp", mn, "", "1234", Secp256k1, params)
+	require.NoError(t, err)
+	require.IsType(t, secp256k1.PubKeySecp256k1{}, secpInfo.GetPubKey())
+	assert.NotEqual(t, edInfo.GetAddress(), secpInfo.GetAddress())
+
+	r1Info, err := kb.CreateAccountWithAlgo("r1", mn, "", "1234", Secp256r1, params)
+	require.NoError(t, err)
+	require.IsType(t, secp256r1.PubKeySecp256r1{}, r1Info.GetPubKey())
+	assert.Equal(t, r1Info.GetPubKey().Address(), r1Info.GetAddress())
+	assert.NotEqual(t, secpInfo.GetAddress(), r1Info.GetAddress())
+	sig, pub, err := kb.Sign("r1", "1234", []byte("some message"))
+	require.NoError(t, err)
+	assert.Equal(t, r1Info.GetPubKey(), pub)
+	assert.True(t, pub.VerifyBytes([]byte("some message"), sig))
+
+	// the same mnemonic and path always give the same key
+	edInfo2, err := kb.CreateAccountWithAlgo("ed2", mn, "", "1234", Ed25519, params)
+	require.NoError(t, err)
+	assert.Equal(t, edInfo.GetPubKey(), edInfo2.GetPubKey())
+
+	// the stored key can be loaded and used for signing
+	restored, err := kb.GetByAddress(edInfo.GetAddress())
+	require.NoError(t, err)
+	assert.Equal(t, edInfo.GetPubKey(), restored.GetPubKey())
+	msg := []byte("some message")
+	sig, pub, err = kb.Sign("ed", "1234", msg)
+	require.NoError(t, err)
+	assert.Equal(t, edInfo.GetPubKey(), pub)
+	assert.True(t, pub.VerifyBytes(msg, sig))
+
+	_, err = kb.CreateAccountWithAlgo("bad", mn, "", "1234", SigningAlgo("sr25519"), params)
+	assert.Error(t, err)
+}
+
+func TestCreateLedgerWithPath(t *testing.T) {
+	mock := ledger.NewMockTransport(`equip will roof matter pink blind book anxiety banner elbow sun young`)
+	ledger.SetTransportDiscovery(func() (ledger.Transport, error) { return mock, nil })
+	defer ledger.SetTransportDiscovery(nil)
+
+	kb := NewInMemory()
+	path := *hd.NewFundraiserParams(0, crypto.CoinType, 1)
+	info, err := kb.CreateLedgerWithPath("ledger", path)
+	require.NoError(t, err)
+	assert.Equal(t, TypeLedger, info.GetType())
+	assert.Equal(t, []string{info.GetAddress().String()}, mock.Displayed)
+	stored, err := info.GetPath()
+	require.NoError(t, err)
+	assert.Equal(t, path, *stored)
+
+	// only the path and the public key are stored, signing needs the device.
+	msg := []byte("some message")
+	sig, pub, err := kb.Sign("ledger", "", msg)
+	require.NoError(t, err)
+	assert.Equal(t, info.GetPubKey(), pub)
+	assert.NoError(t, kb.Verify("ledger", msg, sig))
+
+	mock.Reject = true
+	_, _, err = kb.Sign("ledger", "", msg)
+	assert.True(t, goerrors.Is(err, ledger.ErrUserRejected), "%v", err)
+	_, err = kb.CreateLedgerWithPath("rejected", path)
+	assert.True(t, goerrors.Is(err, ledger.ErrUserRejected), "%v", err)
+
+	mock.Reject, mock.Disconnected = false, true
+	_, _, err = kb.Sign("ledger", "", msg)
+	assert.True(t, goerrors.Is(err, ledger.ErrDeviceDisconnected), "%v", err)
+}
+
 // TestKeyManagement makes sure we can manipulate these keys well
 func TestKeyManagement(t *testing.T) {
 	// make the storage with reasonable defaults
@@ -302,6 +401,55 @@ func TestExportImportPubKey(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestExportImportPrivKeyArmor(t *testing.T) {
+	src := NewInMemory()
+	dst := NewInMemory()
+	mn := `lounge napkin all odor tilt dove win inject sleep jazz uncover traffic hint require cargo arm rocket round scan bread report squirrel step lake`
+	params := *hd.NewFundraiserParams(0, crypto.CoinType, 0)
+
+	for _, algo := range []SigningAlgo{Secp256k1, Ed25519, Secp256r1} {
+		name := string(algo)
+		info, err := src.CreateAccountWithAlgo(name, mn, "", "1234", algo, params)
+		require.NoError(t, err)
+
+		_, err = src.ExportPrivKeyArmor(name, "wrong", "backup")
+		assert.True(t, keyerror.IsErrWrongPassword(err))
+		astr, err := src.ExportPrivKeyArmor(name, "1234", "backup")
+		require.NoError(t, err)
+
+		err = dst.ImportPrivKeyArmor(name, astr, "wrong")
+		assert.True(t, keyerror.IsErrWrongPassword(errors.Cause(err)), "%v", err)
+		err = dst.ImportPrivKeyArmor(name, strings.Replace(astr, "version: 1", "version: 1\ncomment: x", 1), "backup")
+		assert.True(t, keyerror.IsErrCorruptArmor(errors.Cause(err)), "%v", err)
+		require.NoError(t, dst.ImportPrivKeyArmor(name, astr, "backup"))
+		assert.Error(t, dst.ImportPrivKeyArmor(name, astr, "backup"), "cannot overwrite")
+
+		imported, err := dst.GetByAddress(info.GetAddress())
+		require.NoError(t, err)
+		assert.Equal(t, info.GetPubKey(), imported.GetPubKey())
+		// the imported key is encrypted with the passphrase of the armor.
+		msg := []byte("some message")
+		sig, _, err := dst.Sign(name, "backup", msg)
+		require.NoError(t, err)
+		assert.NoError(t, src.Verify(name, msg, sig))
+
+		pubArmor, err := src.ExportPubKey(name)
+		require.NoError(t, err)
+		require.NoError(t, dst.ImportPubKey(name+"-pub", pubArmor))
+		pubInfo, err := dst.Get(name + "-pub")
+		require.NoError(t, err)
+		assert.Equal(t, info.GetPubKey(), pubInfo.GetPubKey())
+	}
+
+	// legacy armors still import.
+	priv, err := src.ExportPrivateKeyObject(string(Secp256k1), "1234")
+	require.NoError(t, err)
+	require.NoError(t, dst.ImportPrivKeyArmor("legacy", armor.EncryptArmorPrivKey(priv, "old"), "old"))
+	legacy, err := dst.Get("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, priv.PubKey(), legacy.GetPubKey())
+}
+
 // TestAdvancedKeyManagement verifies update, import, export functionality
 func TestAdvancedKeyManagement(t *testing.T) {
 	// make the storage with reasonable defaults
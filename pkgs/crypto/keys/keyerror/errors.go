@@ -7,6 +7,7 @@ import (
 const (
 	codeKeyNotFound   = 1
 	codeWrongPassword = 2
+	codeCorruptArmor  = 3
 )
 
 type keybaseError interface {
@@ -79,3 +80,38 @@ func IsErrWrongPassword(err error) bool {
 	}
 	return false
 }
+
+type errCorruptArmor struct {
+	code   int
+	reason string
+}
+
+func (e errCorruptArmor) Code() int {
+	return e.code
+}
+
+func (e errCorruptArmor) Error() string {
+	return fmt.Sprintf("corrupt armor: %s", e.reason)
+}
+
+// NewErrCorruptArmor returns a standardized error reflecting that an armored key
+// was damaged or tampered with, as opposed to decrypted with a wrong password
+func NewErrCorruptArmor(reason string) error {
+	return errCorruptArmor{
+		code:   codeCorruptArmor,
+		reason: reason,
+	}
+}
+
+// IsErrCorruptArmor returns true if the given error is errCorruptArmor
+func IsErrCorruptArmor(err error) bool {
+	if err == nil {
+		return false
+	}
+	if keyErr, ok := err.(keybaseError); ok {
+		if keyErr.Code() == codeCorruptArmor {
+			return true
+		}
+	}
+	return false
+}
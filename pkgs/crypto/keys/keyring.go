@@ -0,0 +1,223 @@
+package keys
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+// Backend is the storage backend of a keybase.
+type Backend string
+
+const (
+	// BackendDB stores keys in a goleveldb database. Private keys are
+	// encrypted with their own passphrase, but names, addresses, and public
+	// keys are stored in the clear. This is the legacy backend.
+	BackendDB = Backend("db")
+	// BackendFile stores every record in its own file, encrypted with a key
+	// derived from the keyring passphrase.
+	BackendFile = Backend("file")
+	// BackendOS stores records in the credential store of the operating
+	// system, see Keychain.
+	BackendOS = Backend("os")
+	// BackendMemory stores keys in memory, for testing.
+	BackendMemory = Backend("memory")
+)
+
+// PromptFunc asks the user for a passphrase, e.g. for the keyring of the
+// file backend.
+type PromptFunc func(prompt string) (string, error)
+
+// NewKeybase opens the keybase in dir with the given backend. prompt is
+// only used by backends that need a passphrase to open the keyring.
+func NewKeybase(backend Backend, dir string, prompt PromptFunc) (Keybase, error) {
+	switch backend {
+	case BackendDB:
+		return NewLazyDBKeybase(defaultKeyDBName, dir), nil
+	case BackendFile:
+		return NewFileKeybase(dir, prompt)
+	case BackendOS:
+		kc, err := DefaultKeychain()
+		if err != nil {
+			return nil, err
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeychainKeybase(kc, "gno:"+abs), nil
+	case BackendMemory:
+		return NewInMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown keybase backend %q", backend)
+	}
+}
+
+// Migrate copies all keys of src into dst, e.g. from a legacy BackendDB
+// keybase to a BackendFile one. Private keys stay encrypted with their own
+// passphrase. Keys that already exist in dst with the same content are
+// skipped; any other existing key is an error, before anything is copied.
+// It returns the names of the migrated keys.
+func Migrate(src, dst Keybase) (migrated []string, err error) {
+	infos, err := src.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var todo []string
+	for _, info := range infos {
+		name := info.GetName()
+		existing, err := dst.Get(name)
+		if err != nil {
+			todo = append(todo, name)
+			continue
+		}
+		if string(writeInfo(existing)) != string(writeInfo(info)) {
+			return nil, fmt.Errorf("cannot migrate key %s: a different key with that name exists", name)
+		}
+	}
+
+	for _, name := range todo {
+		armor, err := src.Export(name)
+		if err != nil {
+			return migrated, errors.Wrap(err, "exporting key %s", name)
+		}
+		if err := dst.Import(name, armor); err != nil {
+			return migrated, errors.Wrap(err, "importing key %s", name)
+		}
+		migrated = append(migrated, name)
+	}
+	return migrated, nil
+}
+
+//----------------------------------------
+// recordStore
+
+// recordStore is the storage of keybase records for backends other than
+// BackendDB. All methods must be safe for concurrent use.
+type recordStore interface {
+	// get returns nil if the record does not exist.
+	get(key string) ([]byte, error)
+	set(key string, value []byte) error
+	delete(key string) error
+	// keys returns the keys of all records, in any order.
+	keys() ([]string, error)
+	close() error
+}
+
+// storeDB adapts a recordStore to the dbm.DB interface used by dbKeybase.
+// As dbm.DB methods cannot return errors, storage errors panic.
+type storeDB struct {
+	store recordStore
+}
+
+var _ dbm.DB = storeDB{}
+
+func (db storeDB) Get(key []byte) []byte {
+	value, err := db.store.get(string(key))
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+func (db storeDB) Has(key []byte) bool {
+	return db.Get(key) != nil
+}
+
+func (db storeDB) Set(key, value []byte) {
+	if err := db.store.set(string(key), value); err != nil {
+		panic(err)
+	}
+}
+
+func (db storeDB) SetSync(key, value []byte) {
+	db.Set(key, value)
+}
+
+func (db storeDB) Delete(key []byte) {
+	if err := db.store.delete(string(key)); err != nil {
+		panic(err)
+	}
+}
+
+func (db storeDB) DeleteSync(key []byte) {
+	db.Delete(key)
+}
+
+// snapshot returns an in-memory copy of all records.
+func (db storeDB) snapshot() *dbm.MemDB {
+	keys, err := db.store.keys()
+	if err != nil {
+		panic(err)
+	}
+	sort.Strings(keys)
+	mem := dbm.NewMemDB()
+	for _, key := range keys {
+		if value := db.Get([]byte(key)); value != nil {
+			mem.Set([]byte(key), value)
+		}
+	}
+	return mem
+}
+
+// Iterator iterates over a snapshot of the records.
+func (db storeDB) Iterator(start, end []byte) dbm.Iterator {
+	return db.snapshot().Iterator(start, end)
+}
+
+// ReverseIterator iterates over a snapshot of the records.
+func (db storeDB) ReverseIterator(start, end []byte) dbm.Iterator {
+	return db.snapshot().ReverseIterator(start, end)
+}
+
+func (db storeDB) Close() {
+	if err := db.store.close(); err != nil {
+		panic(err)
+	}
+}
+
+func (db storeDB) NewBatch() dbm.Batch {
+	return &storeBatch{db: db}
+}
+
+func (db storeDB) Print() {
+	db.snapshot().Print()
+}
+
+func (db storeDB) Stats() map[string]string {
+	return map[string]string{"database.type": "keybase.storeDB"}
+}
+
+// storeBatch applies its operations in order on Write. Unlike other
+// batches, it is not atomic.
+type storeBatch struct {
+	db  storeDB
+	ops []func()
+}
+
+func (b *storeBatch) Set(key, value []byte) {
+	key, value = append([]byte(nil), key...), append([]byte(nil), value...)
+	b.ops = append(b.ops, func() { b.db.Set(key, value) })
+}
+
+func (b *storeBatch) Delete(key []byte) {
+	key = append([]byte(nil), key...)
+	b.ops = append(b.ops, func() { b.db.Delete(key) })
+}
+
+func (b *storeBatch) Write() {
+	for _, op := range b.ops {
+		op()
+	}
+	b.ops = nil
+}
+
+func (b *storeBatch) WriteSync() {
+	b.Write()
+}
+
+func (b *storeBatch) Close() {}
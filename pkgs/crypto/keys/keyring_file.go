@@ -0,0 +1,374 @@
+package keys
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/armor"
+	"github.com/gnolang/gno/pkgs/crypto/keys/keyerror"
+	"github.com/gnolang/gno/pkgs/crypto/xchacha20poly1305"
+	"github.com/gnolang/gno/pkgs/errors"
+	osm "github.com/gnolang/gno/pkgs/os"
+)
+
+const (
+	fileKeyringMetaName   = "keyring.meta"
+	fileKeyringLockName   = "keyring.lock"
+	fileKeyringRecordExt  = ".rec"
+	fileKeyringRecordType = "GNO KEYRING RECORD"
+	fileKeyringVersion    = 1
+)
+
+// argon2Params are the argon2id parameters used to derive the keyring key
+// from the passphrase.
+type argon2Params struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// defaultArgon2Params are used for new keyrings, as recommended by
+// RFC 9106 for memory constrained environments.
+var defaultArgon2Params = argon2Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// fileKeyringMeta is stored in the clear next to the records.
+type fileKeyringMeta struct {
+	Version int          `json:"version"`
+	KDF     argon2Params `json:"kdf"`
+	Salt    []byte       `json:"salt"`
+	// Check is a known plaintext sealed with the keyring key, to tell a
+	// wrong passphrase apart from corrupted records.
+	Check []byte `json:"check"`
+}
+
+var fileKeyringCheck = []byte("gno keyring")
+
+// fileRecord is the plaintext of a record file.
+type fileRecord struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// NewFileKeybase opens or creates the keyring of BackendFile in dir.
+// prompt is called once to get the passphrase of the keyring.
+func NewFileKeybase(dir string, prompt PromptFunc) (Keybase, error) {
+	store, err := openFileStore(dir, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return NewDBKeybase(storeDB{store}), nil
+}
+
+// fileStore is a recordStore where every record is a file in dir,
+// sealed with XChaCha20-Poly1305 under a key derived from the keyring
+// passphrase with argon2id, and ASCII armored. The file names are keyed
+// hashes of the record keys, so that key names and addresses are not
+// visible without the passphrase. Files are replaced atomically, and
+// writers take an exclusive lock on the keyring.
+type fileStore struct {
+	dir  string
+	key  []byte
+	lock *fileLock
+}
+
+var _ recordStore = (*fileStore)(nil)
+
+func openFileStore(dir string, prompt PromptFunc) (*fileStore, error) {
+	if prompt == nil {
+		return nil, errors.New("the file keybase backend requires a passphrase prompt")
+	}
+	if err := osm.EnsureDir(dir, 0700); err != nil {
+		return nil, err
+	}
+	fs := &fileStore{
+		dir:  dir,
+		lock: newFileLock(filepath.Join(dir, fileKeyringLockName)),
+	}
+
+	meta, err := fs.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		passphrase, err := prompt("Enter a passphrase for the new keyring:")
+		if err != nil {
+			return nil, err
+		}
+		if passphrase == "" {
+			return nil, errors.New("the keyring passphrase must not be empty")
+		}
+		return fs, fs.init(passphrase)
+	}
+
+	passphrase, err := prompt("Enter the keyring passphrase:")
+	if err != nil {
+		return nil, err
+	}
+	return fs, fs.unlock(meta, passphrase)
+}
+
+// init creates the keyring, unless another process did so concurrently,
+// in which case it is unlocked with passphrase.
+func (fs *fileStore) init(passphrase string) error {
+	if err := fs.lock.lock(); err != nil {
+		return err
+	}
+	defer fs.lock.unlock()
+
+	meta, err := fs.readMeta()
+	if err != nil {
+		return err
+	}
+	if meta != nil {
+		return fs.unlock(meta, passphrase)
+	}
+
+	meta = &fileKeyringMeta{
+		Version: fileKeyringVersion,
+		KDF:     defaultArgon2Params,
+		Salt:    crypto.CRandBytes(32),
+	}
+	fs.key = deriveKeyringKey(passphrase, meta)
+	meta.Check, err = seal(fs.key, fileKeyringCheck, nil)
+	if err != nil {
+		return err
+	}
+	bz, err := amino.MarshalJSONIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(fs.dir, fileKeyringMetaName), bz)
+}
+
+func (fs *fileStore) unlock(meta *fileKeyringMeta, passphrase string) error {
+	if meta.Version != fileKeyringVersion {
+		return fmt.Errorf("unsupported keyring version %d", meta.Version)
+	}
+	key := deriveKeyringKey(passphrase, meta)
+	check, err := open(key, meta.Check, nil)
+	if err != nil || !bytes.Equal(check, fileKeyringCheck) {
+		return keyerror.NewErrWrongPassword()
+	}
+	fs.key = key
+	return nil
+}
+
+// readMeta returns nil if the keyring does not exist yet.
+func (fs *fileStore) readMeta() (*fileKeyringMeta, error) {
+	bz, err := ioutil.ReadFile(filepath.Join(fs.dir, fileKeyringMetaName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	meta := new(fileKeyringMeta)
+	if err := amino.UnmarshalJSON(bz, meta); err != nil {
+		return nil, errors.Wrap(err, "reading keyring metadata")
+	}
+	return meta, nil
+}
+
+func deriveKeyringKey(passphrase string, meta *fileKeyringMeta) []byte {
+	kdf := meta.KDF
+	return argon2.IDKey([]byte(passphrase), meta.Salt, kdf.Time, kdf.Memory, kdf.Threads, 32)
+}
+
+// recordPath returns the path of the file of the record with key.
+func (fs *fileStore) recordPath(key string) string {
+	mac := hmac.New(sha256.New, fs.key)
+	mac.Write([]byte(key))
+	return filepath.Join(fs.dir, hex.EncodeToString(mac.Sum(nil))+fileKeyringRecordExt)
+}
+
+func (fs *fileStore) get(key string) ([]byte, error) {
+	record, err := fs.readRecord(fs.recordPath(key))
+	if err != nil || record == nil {
+		return nil, err
+	}
+	if record.Key != key {
+		return nil, fmt.Errorf("keyring record %s does not match its key", fs.recordPath(key))
+	}
+	return record.Value, nil
+}
+
+// readRecord returns nil if the file does not exist.
+func (fs *fileStore) readRecord(path string) (*fileRecord, error) {
+	bz, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	blockType, _, sealed, err := armor.DecodeArmor(string(bz))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading keyring record %s", path)
+	}
+	if blockType != fileKeyringRecordType {
+		return nil, fmt.Errorf("reading keyring record %s: unrecognized armor type %q", path, blockType)
+	}
+	plain, err := open(fs.key, sealed, []byte(filepath.Base(path)))
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting keyring record %s", path)
+	}
+	record := new(fileRecord)
+	if err := amino.Unmarshal(plain, record); err != nil {
+		return nil, errors.Wrap(err, "reading keyring record %s", path)
+	}
+	return record, nil
+}
+
+func (fs *fileStore) set(key string, value []byte) error {
+	path := fs.recordPath(key)
+	plain := amino.MustMarshal(fileRecord{Key: key, Value: value})
+	// the file name is authenticated, so that records cannot be swapped.
+	sealed, err := seal(fs.key, plain, []byte(filepath.Base(path)))
+	if err != nil {
+		return err
+	}
+	armored := armor.EncodeArmor(fileKeyringRecordType, nil, sealed)
+
+	if err := fs.lock.lock(); err != nil {
+		return err
+	}
+	defer fs.lock.unlock()
+	return writeFileAtomic(path, []byte(armored))
+}
+
+func (fs *fileStore) delete(key string) error {
+	if err := fs.lock.lock(); err != nil {
+		return err
+	}
+	defer fs.lock.unlock()
+	err := os.Remove(fs.recordPath(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (fs *fileStore) keys() ([]string, error) {
+	entries, err := ioutil.ReadDir(fs.dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileKeyringRecordExt) {
+			continue
+		}
+		record, err := fs.readRecord(filepath.Join(fs.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if record != nil { // deleted concurrently
+			keys = append(keys, record.Key)
+		}
+	}
+	return keys, nil
+}
+
+func (fs *fileStore) close() error {
+	return nil
+}
+
+// seal encrypts plain with key and a random nonce, which is prepended to
+// the result.
+func seal(key, plain, additionalData []byte) ([]byte, error) {
+	aead, err := xchacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := crypto.CRandBytes(aead.NonceSize())
+	return aead.Seal(nonce, nonce, plain, additionalData), nil
+}
+
+// open decrypts the output of seal.
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := xchacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of
+// path, syncs it, and renames it to path.
+func writeFileAtomic(path string, data []byte) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if err = f.Chmod(0600); err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+//----------------------------------------
+// fileLock
+
+// fileLock is an exclusive lock on a lock file, held by at most one
+// fileLock at a time across goroutines and processes.
+type fileLock struct {
+	path string
+	mtx  sync.Mutex
+	f    *os.File
+}
+
+func newFileLock(path string) *fileLock {
+	return &fileLock{path: path}
+}
+
+func (l *fileLock) lock() error {
+	l.mtx.Lock()
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		l.mtx.Unlock()
+		return err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		l.mtx.Unlock()
+		return errors.Wrap(err, "locking keyring")
+	}
+	l.f = f
+	return nil
+}
+
+func (l *fileLock) unlock() {
+	unlockFile(l.f)
+	l.f.Close()
+	l.f = nil
+	l.mtx.Unlock()
+}
@@ -0,0 +1,125 @@
+package keys
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gnolang/gno/pkgs/amino"
+)
+
+// Keychain is the credential store of the operating system, such as the
+// macOS Keychain, the Secret Service of freedesktop.org, or the Windows
+// Credential Manager. Items are identified by a service and an account
+// name.
+type Keychain interface {
+	// Get returns ErrKeychainItemNotFound if the item does not exist.
+	Get(service, account string) ([]byte, error)
+	// Set creates or replaces an item.
+	Set(service, account string, data []byte) error
+	// Remove returns ErrKeychainItemNotFound if the item does not exist.
+	Remove(service, account string) error
+}
+
+// ErrKeychainItemNotFound is returned by a Keychain for missing items.
+var ErrKeychainItemNotFound = errors.New("keychain item not found")
+
+// keychainIndexAccount is the account of the item listing the keys of all
+// other items of a service, as keychains cannot be listed portably.
+const keychainIndexAccount = "keybase.index"
+
+// NewKeychainKeybase returns a keybase that stores its records as items of
+// the given service in kc.
+func NewKeychainKeybase(kc Keychain, service string) Keybase {
+	return NewDBKeybase(storeDB{&keychainStore{kc: kc, service: service}})
+}
+
+// keychainStore is a recordStore backed by a Keychain. Records are written
+// one item per record, under the record key as account name. Concurrent
+// writers in other processes may lose updates of the index.
+type keychainStore struct {
+	kc      Keychain
+	service string
+	mtx     sync.Mutex
+}
+
+var _ recordStore = (*keychainStore)(nil)
+
+func (ks *keychainStore) get(key string) ([]byte, error) {
+	data, err := ks.kc.Get(ks.service, key)
+	if err == ErrKeychainItemNotFound {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (ks *keychainStore) set(key string, value []byte) error {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+
+	if err := ks.kc.Set(ks.service, key, value); err != nil {
+		return err
+	}
+	index, err := ks.index()
+	if err != nil {
+		return err
+	}
+	for _, k := range index {
+		if k == key {
+			return nil
+		}
+	}
+	return ks.setIndex(append(index, key))
+}
+
+func (ks *keychainStore) delete(key string) error {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+
+	err := ks.kc.Remove(ks.service, key)
+	if err != nil && err != ErrKeychainItemNotFound {
+		return err
+	}
+	index, err := ks.index()
+	if err != nil {
+		return err
+	}
+	for i, k := range index {
+		if k == key {
+			return ks.setIndex(append(index[:i:i], index[i+1:]...))
+		}
+	}
+	return nil
+}
+
+func (ks *keychainStore) keys() ([]string, error) {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+
+	return ks.index()
+}
+
+func (ks *keychainStore) index() ([]string, error) {
+	data, err := ks.kc.Get(ks.service, keychainIndexAccount)
+	if err == ErrKeychainItemNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var index keychainIndex
+	if err := amino.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return index.Keys, nil
+}
+
+func (ks *keychainStore) setIndex(keys []string) error {
+	return ks.kc.Set(ks.service, keychainIndexAccount, amino.MustMarshal(keychainIndex{keys}))
+}
+
+type keychainIndex struct {
+	Keys []string `json:"keys"`
+}
+
+func (ks *keychainStore) close() error {
+	return nil
+}
@@ -0,0 +1,94 @@
+//go:build darwin
+// +build darwin
+
+package keys
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+// DefaultKeychain returns the login keychain of macOS, accessed with the
+// security command.
+func DefaultKeychain() (Keychain, error) {
+	path, err := exec.LookPath("security")
+	if err != nil {
+		return nil, errors.Wrap(err, "the os keybase backend requires the security command")
+	}
+	return macOSKeychain{path}, nil
+}
+
+// errSecItemNotFound is the exit status of the security command for
+// missing items.
+const errSecItemNotFound = 44
+
+// macOSKeychain stores items as base64 encoded generic passwords. Secrets
+// are written through the stdin of an interactive security session and
+// read from stdout, never passed as arguments.
+type macOSKeychain struct {
+	path string
+}
+
+func (kc macOSKeychain) Get(service, account string) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command(kc.path, "find-generic-password", "-s", service, "-a", account, "-w")
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == errSecItemNotFound {
+			return nil, ErrKeychainItemNotFound
+		}
+		return nil, errors.Wrap(err, "security find-generic-password")
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(stdout.String()))
+}
+
+func (kc macOSKeychain) Set(service, account string, data []byte) error {
+	quotedService, err := securityQuote(service)
+	if err != nil {
+		return err
+	}
+	quotedAccount, err := securityQuote(account)
+	if err != nil {
+		return err
+	}
+	// the interactive session does not exit with the status of its
+	// commands, so failures are detected from their error output.
+	var stderr bytes.Buffer
+	cmd := exec.Command(kc.path, "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		quotedService, quotedAccount, base64.StdEncoding.EncodeToString(data)))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, "security add-generic-password: %s", stderr.String())
+	}
+	if stderr.Len() != 0 {
+		return errors.New("security add-generic-password: %s", stderr.String())
+	}
+	return nil
+}
+
+func (kc macOSKeychain) Remove(service, account string) error {
+	cmd := exec.Command(kc.path, "delete-generic-password", "-s", service, "-a", account)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == errSecItemNotFound {
+			return ErrKeychainItemNotFound
+		}
+		return errors.Wrap(err, "security delete-generic-password: %s", out)
+	}
+	return nil
+}
+
+// securityQuote quotes an argument of a command of an interactive security
+// session, which splits its commands on whitespace outside of double
+// quotes. Values that cannot be quoted safely are rejected.
+func securityQuote(s string) (string, error) {
+	if strings.ContainsAny(s, "\"\\\n\r") {
+		return "", fmt.Errorf("invalid keychain item name %q", s)
+	}
+	return `"` + s + `"`, nil
+}
@@ -0,0 +1,16 @@
+//go:build !darwin && !windows && !linux && !freebsd && !openbsd && !netbsd && !dragonfly
+// +build !darwin,!windows,!linux,!freebsd,!openbsd,!netbsd,!dragonfly
+
+package keys
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// DefaultKeychain returns the keychain of the operating system.
+// There is none on this platform; use NewKeychainKeybase with a custom
+// Keychain instead.
+func DefaultKeychain() (Keychain, error) {
+	return nil, fmt.Errorf("the os keybase backend is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,64 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+// +build linux freebsd openbsd netbsd dragonfly
+
+package keys
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os/exec"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+// DefaultKeychain returns the Secret Service keychain (e.g. GNOME Keyring
+// or KWallet), accessed with the secret-tool command of libsecret.
+func DefaultKeychain() (Keychain, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, errors.Wrap(err, "the os keybase backend requires secret-tool (libsecret)")
+	}
+	return secretServiceKeychain{path}, nil
+}
+
+// secretServiceKeychain stores items base64 encoded, as secret-tool reads
+// and prints secrets as text. Secrets are passed through stdin and stdout,
+// never as arguments.
+type secretServiceKeychain struct {
+	path string
+}
+
+func (kc secretServiceKeychain) Get(service, account string) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command(kc.path, "lookup", "service", service, "account", account)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok && stdout.Len() == 0 {
+			return nil, ErrKeychainItemNotFound
+		}
+		return nil, errors.Wrap(err, "secret-tool lookup")
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(stdout.String()))
+}
+
+func (kc secretServiceKeychain) Set(service, account string, data []byte) error {
+	cmd := exec.Command(kc.path, "store", "--label", service+" "+account,
+		"service", service, "account", account)
+	cmd.Stdin = strings.NewReader(base64.StdEncoding.EncodeToString(data))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrap(err, "secret-tool store: %s", out)
+	}
+	return nil
+}
+
+func (kc secretServiceKeychain) Remove(service, account string) error {
+	if _, err := kc.Get(service, account); err != nil {
+		return err
+	}
+	cmd := exec.Command(kc.path, "clear", "service", service, "account", account)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrap(err, "secret-tool clear: %s", out)
+	}
+	return nil
+}
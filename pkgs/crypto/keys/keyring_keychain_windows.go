@@ -0,0 +1,175 @@
+//go:build windows
+// +build windows
+
+package keys
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+// DefaultKeychain returns the Windows Credential Manager.
+func DefaultKeychain() (Keychain, error) {
+	if err := procCredReadW.Find(); err != nil {
+		return nil, errors.Wrap(err, "the os keybase backend requires the Credential Manager")
+	}
+	return wincredKeychain{}, nil
+}
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric          = 1
+	credPersistLocalMachine  = 2
+	credMaxCredentialBlobLen = 5 * 512
+	errorNotFound            = syscall.Errno(1168)
+)
+
+// credential is the CREDENTIALW structure of wincred.h.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// wincredKeychain stores items as generic credentials, whose target name
+// is the service and the account joined by a slash. As credentials hold at
+// most credMaxCredentialBlobLen bytes, larger items are split across the
+// credentials of the target suffixed with #1, #2, ...
+type wincredKeychain struct{}
+
+func (wincredKeychain) Get(service, account string) ([]byte, error) {
+	var data []byte
+	for i := 0; ; i++ {
+		chunk, err := credRead(chunkTarget(service, account, i))
+		if err == ErrKeychainItemNotFound && i > 0 {
+			return data, nil
+		} else if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+}
+
+func (wincredKeychain) Set(service, account string, data []byte) error {
+	i := 0
+	for ; i == 0 || len(data) > 0; i++ {
+		chunk := data
+		if len(chunk) > credMaxCredentialBlobLen {
+			chunk = chunk[:credMaxCredentialBlobLen]
+		}
+		if err := credWrite(chunkTarget(service, account, i), account, chunk); err != nil {
+			return err
+		}
+		data = data[len(chunk):]
+	}
+	// remove the chunks left over by a larger item.
+	for ; ; i++ {
+		err := credDelete(chunkTarget(service, account, i))
+		if err == ErrKeychainItemNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (wincredKeychain) Remove(service, account string) error {
+	for i := 0; ; i++ {
+		err := credDelete(chunkTarget(service, account, i))
+		if err == ErrKeychainItemNotFound && i > 0 {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func chunkTarget(service, account string, i int) string {
+	if i == 0 {
+		return service + "/" + account
+	}
+	return fmt.Sprintf("%s/%s#%d", service, account, i)
+}
+
+func credRead(target string) ([]byte, error) {
+	targetPtr, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return nil, err
+	}
+	var cred *credential
+	ret, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(targetPtr)), credTypeGeneric, 0,
+		uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		if err == errorNotFound {
+			return nil, ErrKeychainItemNotFound
+		}
+		return nil, errors.Wrap(err, "CredRead")
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	data := make([]byte, cred.CredentialBlobSize)
+	if len(data) > 0 {
+		copy(data, (*[credMaxCredentialBlobLen]byte)(unsafe.Pointer(cred.CredentialBlob))[:len(data)])
+	}
+	return data, nil
+}
+
+func credWrite(target, account string, data []byte) error {
+	targetPtr, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         targetPtr,
+		CredentialBlobSize: uint32(len(data)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(data) > 0 {
+		cred.CredentialBlob = &data[0]
+	}
+	ret, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return errors.Wrap(err, "CredWrite")
+	}
+	return nil
+}
+
+func credDelete(target string) error {
+	targetPtr, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	ret, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(targetPtr)), credTypeGeneric, 0)
+	if ret == 0 {
+		if err == errorNotFound {
+			return ErrKeychainItemNotFound
+		}
+		return errors.Wrap(err, "CredDelete")
+	}
+	return nil
+}
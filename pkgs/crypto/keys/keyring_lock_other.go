@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package keys
+
+import (
+	"os"
+)
+
+// On other platforms the keyring is only locked within the process.
+
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) {}
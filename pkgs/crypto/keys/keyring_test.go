@@ -0,0 +1,233 @@
+package keys
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/crypto/keys/keyerror"
+)
+
+const testMnemonic = "measure slogan connect luggage stereo federal stuff stomach stumble security end differ"
+
+func init() {
+	// keep the tests fast.
+	defaultArgon2Params = argon2Params{Time: 1, Memory: 64, Threads: 1}
+}
+
+func staticPrompt(passphrase string) PromptFunc {
+	return func(string) (string, error) { return passphrase, nil }
+}
+
+// memKeychain is a Keychain in memory.
+type memKeychain struct {
+	mtx   sync.Mutex
+	items map[string][]byte
+}
+
+func newMemKeychain() *memKeychain {
+	return &memKeychain{items: make(map[string][]byte)}
+}
+
+func (kc *memKeychain) Get(service, account string) ([]byte, error) {
+	kc.mtx.Lock()
+	defer kc.mtx.Unlock()
+	data, ok := kc.items[service+"/"+account]
+	if !ok {
+		return nil, ErrKeychainItemNotFound
+	}
+	return data, nil
+}
+
+func (kc *memKeychain) Set(service, account string, data []byte) error {
+	kc.mtx.Lock()
+	defer kc.mtx.Unlock()
+	kc.items[service+"/"+account] = append([]byte(nil), data...)
+	return nil
+}
+
+func (kc *memKeychain) Remove(service, account string) error {
+	kc.mtx.Lock()
+	defer kc.mtx.Unlock()
+	if _, ok := kc.items[service+"/"+account]; !ok {
+		return ErrKeychainItemNotFound
+	}
+	delete(kc.items, service+"/"+account)
+	return nil
+}
+
+func testBackends(t *testing.T) map[string]Keybase {
+	t.Helper()
+
+	file, err := NewFileKeybase(t.TempDir(), staticPrompt("keyring"))
+	require.NoError(t, err)
+	return map[string]Keybase{
+		"db":       NewLazyDBKeybase(defaultKeyDBName, t.TempDir()),
+		"file":     file,
+		"keychain": NewKeychainKeybase(newMemKeychain(), "gno:test"),
+		"memory":   NewInMemory(),
+	}
+}
+
+func TestKeybaseBackends(t *testing.T) {
+	for name, kb := range testBackends(t) {
+		kb := kb
+		t.Run(name, func(t *testing.T) {
+			info, err := kb.CreateAccount("alice", testMnemonic, "", "secret", 0, 0)
+			require.NoError(t, err)
+			_, err = kb.CreateAccount("bob", testMnemonic, "", "secret", 0, 1)
+			require.NoError(t, err)
+
+			infos, err := kb.List()
+			require.NoError(t, err)
+			require.Len(t, infos, 2)
+			assert.Equal(t, "alice", infos[0].GetName())
+			assert.Equal(t, "bob", infos[1].GetName())
+
+			byAddr, err := kb.GetByAddress(info.GetAddress())
+			require.NoError(t, err)
+			assert.Equal(t, "alice", byAddr.GetName())
+
+			msg := []byte("hello")
+			sig, pub, err := kb.Sign("alice", "secret", msg)
+			require.NoError(t, err)
+			assert.Equal(t, info.GetPubKey(), pub)
+			assert.NoError(t, kb.Verify("alice", msg, sig))
+			_, _, err = kb.Sign("alice", "wrong", msg)
+			assert.True(t, keyerror.IsErrWrongPassword(err))
+
+			armor, err := kb.Export("alice")
+			require.NoError(t, err)
+			require.NoError(t, kb.Delete("alice", "secret", false))
+			_, err = kb.Get("alice")
+			assert.Error(t, err)
+			_, err = kb.GetByAddress(info.GetAddress())
+			assert.Error(t, err)
+
+			require.NoError(t, kb.Import("alice", armor))
+			byAddr, err = kb.GetByAddress(info.GetAddress())
+			require.NoError(t, err)
+			assert.Equal(t, "alice", byAddr.GetName())
+			_, _, err = kb.Sign("alice", "secret", msg)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFileKeybasePassphrase(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewFileKeybase(dir, staticPrompt(""))
+	assert.Error(t, err)
+
+	kb, err := NewFileKeybase(dir, staticPrompt("keyring"))
+	require.NoError(t, err)
+	info, err := kb.CreateAccount("alice", testMnemonic, "", "secret", 0, 0)
+	require.NoError(t, err)
+
+	_, err = NewFileKeybase(dir, staticPrompt("wrong"))
+	assert.True(t, keyerror.IsErrWrongPassword(err), "%v", err)
+
+	kb, err = NewFileKeybase(dir, staticPrompt("keyring"))
+	require.NoError(t, err)
+	reopened, err := kb.Get("alice")
+	require.NoError(t, err)
+	assert.Equal(t, info.GetAddress(), reopened.GetAddress())
+
+	// neither the name nor the address of the key are stored in the clear.
+	files, err := filepath.Glob(filepath.Join(dir, "*"+fileKeyringRecordExt))
+	require.NoError(t, err)
+	require.Len(t, files, 2) // info and address pointer
+	for _, file := range files {
+		assert.NotContains(t, file, "alice")
+		bz, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(bz), "-----BEGIN "+fileKeyringRecordType))
+		assert.NotContains(t, string(bz), "alice")
+		assert.NotContains(t, string(bz), info.GetAddress().String())
+	}
+}
+
+func TestFileKeybaseConcurrent(t *testing.T) {
+	dir := t.TempDir()
+
+	const n = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			kb, err := NewFileKeybase(dir, staticPrompt("keyring"))
+			if err != nil {
+				errs <- err
+				return
+			}
+			_, err = kb.CreateAccount(fmt.Sprintf("key%d", i), testMnemonic, "", "secret", 0, uint32(i))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	kb, err := NewFileKeybase(dir, staticPrompt("keyring"))
+	require.NoError(t, err)
+	infos, err := kb.List()
+	require.NoError(t, err)
+	assert.Len(t, infos, n)
+}
+
+func TestMigrate(t *testing.T) {
+	src := NewLazyDBKeybase(defaultKeyDBName, t.TempDir())
+	alice, err := src.CreateAccount("alice", testMnemonic, "", "secret", 0, 0)
+	require.NoError(t, err)
+	_, err = src.CreateAccount("bob", testMnemonic, "", "secret", 0, 1)
+	require.NoError(t, err)
+
+	dst, err := NewKeybase(BackendFile, t.TempDir(), staticPrompt("keyring"))
+	require.NoError(t, err)
+	migrated, err := Migrate(src, dst)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, migrated)
+
+	info, err := dst.GetByAddress(alice.GetAddress())
+	require.NoError(t, err)
+	assert.Equal(t, "alice", info.GetName())
+	_, _, err = dst.Sign("bob", "secret", []byte("hello"))
+	assert.NoError(t, err)
+
+	// and on to the OS keychain.
+	kc := NewKeychainKeybase(newMemKeychain(), "gno:test")
+	migrated, err = Migrate(dst, kc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, migrated)
+	sig, _, err := kc.Sign("alice", "secret", []byte("hello"))
+	require.NoError(t, err)
+	assert.NoError(t, src.Verify("alice", []byte("hello"), sig))
+
+	// migrating again is a no-op.
+	migrated, err = Migrate(src, dst)
+	require.NoError(t, err)
+	assert.Empty(t, migrated)
+
+	// a different key with the same name is a conflict.
+	other := NewInMemory()
+	_, err = other.CreateAccount("alice", testMnemonic, "", "secret", 0, 2)
+	require.NoError(t, err)
+	_, err = Migrate(other, dst)
+	assert.Error(t, err)
+}
+
+func TestNewKeybaseUnknownBackend(t *testing.T) {
+	_, err := NewKeybase("foo", t.TempDir(), nil)
+	assert.Error(t, err)
+}
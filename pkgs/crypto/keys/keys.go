@@ -7,6 +7,10 @@ const (
 	// Secp256k1 uses the Bitcoin secp256k1 ECDSA parameters.
 	Secp256k1 = SigningAlgo("secp256k1")
 	// Ed25519 represents the Ed25519 signature system.
-	// It is currently not supported for end-user keys (wallets/ledgers).
+	// It is currently not supported for ledgers.
 	Ed25519 = SigningAlgo("ed25519")
+	// Secp256r1 uses the NIST P-256 ECDSA parameters, as some HSMs and
+	// WebAuthn authenticators only support it.
+	// It is currently not supported for ledgers.
+	Secp256r1 = SigningAlgo("secp256r1")
 )
@@ -105,6 +105,16 @@ func (lkb lazyKeybase) CreateAccountBip44(name, mnemonic, bip39Passwd, encryptPa
 	return NewDBKeybase(db).CreateAccountBip44(name, mnemonic, bip39Passwd, encryptPasswd, params)
 }
 
+func (lkb lazyKeybase) CreateAccountWithAlgo(name, mnemonic, bip39Passwd, encryptPasswd string, algo SigningAlgo, params hd.BIP44Params) (Info, error) {
+	db, err := dbm.NewGoLevelDB(lkb.name, lkb.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return NewDBKeybase(db).CreateAccountWithAlgo(name, mnemonic, bip39Passwd, encryptPasswd, algo, params)
+}
+
 func (lkb lazyKeybase) CreateLedger(name string, algo SigningAlgo, hrp string, account, index uint32) (info Info, err error) {
 	db, err := dbm.NewGoLevelDB(lkb.name, lkb.dir)
 	if err != nil {
@@ -115,6 +125,16 @@ func (lkb lazyKeybase) CreateLedger(name string, algo SigningAlgo, hrp string, a
 	return NewDBKeybase(db).CreateLedger(name, algo, hrp, account, index)
 }
 
+func (lkb lazyKeybase) CreateLedgerWithPath(name string, path hd.BIP44Params) (info Info, err error) {
+	db, err := dbm.NewGoLevelDB(lkb.name, lkb.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return NewDBKeybase(db).CreateLedgerWithPath(name, path)
+}
+
 func (lkb lazyKeybase) CreateOffline(name string, pubkey crypto.PubKey) (info Info, err error) {
 	db, err := dbm.NewGoLevelDB(lkb.name, lkb.dir)
 	if err != nil {
@@ -165,6 +185,16 @@ func (lkb lazyKeybase) ImportPrivKey(name string, armor string, passphrase strin
 	return NewDBKeybase(db).ImportPrivKey(name, armor, passphrase)
 }
 
+func (lkb lazyKeybase) ImportPrivKeyArmor(name string, armor string, passphrase string) error {
+	db, err := dbm.NewGoLevelDB(lkb.name, lkb.dir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return NewDBKeybase(db).ImportPrivKeyArmor(name, armor, passphrase)
+}
+
 func (lkb lazyKeybase) ImportPubKey(name string, armor string) (err error) {
 	db, err := dbm.NewGoLevelDB(lkb.name, lkb.dir)
 	if err != nil {
@@ -217,4 +247,16 @@ func (lkb lazyKeybase) ExportPrivKey(name string, decryptPassphrase string,
 	return NewDBKeybase(db).ExportPrivKey(name, decryptPassphrase, encryptPassphrase)
 }
 
+func (lkb lazyKeybase) ExportPrivKeyArmor(name string, decryptPassphrase string,
+	encryptPassphrase string) (armor string, err error) {
+
+	db, err := dbm.NewGoLevelDB(lkb.name, lkb.dir)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	return NewDBKeybase(db).ExportPrivKeyArmor(name, decryptPassphrase, encryptPassphrase)
+}
+
 func (lkb lazyKeybase) CloseDB() {}
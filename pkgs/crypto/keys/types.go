@@ -29,9 +29,15 @@ type Keybase interface {
 	// Like CreateAccount but from general bip44 params.
 	CreateAccountBip44(name, mnemonic, bip39Passwd, encryptPasswd string, params hd.BIP44Params) (Info, error)
 
+	// Like CreateAccountBip44 but for the given signing algorithm.
+	CreateAccountWithAlgo(name, mnemonic, bip39Passwd, encryptPasswd string, algo SigningAlgo, params hd.BIP44Params) (Info, error)
+
 	// CreateLedger creates, stores, and returns a new Ledger key reference
 	CreateLedger(name string, algo SigningAlgo, hrp string, account, index uint32) (info Info, err error)
 
+	// CreateLedgerWithPath creates, stores, and returns a new Ledger key reference for the given path
+	CreateLedgerWithPath(name string, path hd.BIP44Params) (info Info, err error)
+
 	// CreateOffline creates, stores, and returns a new offline key reference
 	CreateOffline(name string, pubkey crypto.PubKey) (info Info, err error)
 
@@ -42,10 +48,14 @@ type Keybase interface {
 	Update(name, oldpass string, getNewpass func() (string, error)) error
 	Import(name string, armor string) (err error)
 	ImportPrivKey(name, armor, passphrase string) error
+	ImportPrivKeyArmor(name, armor, passphrase string) error
 	ImportPubKey(name string, armor string) (err error)
 	Export(name string) (armor string, err error)
 	ExportPubKey(name string) (armor string, err error)
 	ExportPrivKey(name, decryptPassphrase, encryptPassphrase string) (armor string, err error)
+	// Like ExportPrivKey but in the versioned armor format, which records the
+	// parameters of its key derivation function.
+	ExportPrivKeyArmor(name, decryptPassphrase, encryptPassphrase string) (armor string, err error)
 
 	// ExportPrivateKeyObject *only* works on locally-stored keys. Temporary method until we redo the exporting API
 	ExportPrivateKeyObject(name string, passphrase string) (crypto.PrivKey, error)
@@ -0,0 +1,175 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/hd"
+)
+
+// Transport exchanges APDU commands with a Ledger device.
+type Transport interface {
+	// Exchange sends an APDU command and returns the response, which ends
+	// with a two byte status word. Connection failures are reported as
+	// ErrDeviceDisconnected.
+	Exchange(command []byte) ([]byte, error)
+	Close() error
+}
+
+// SetTransportDiscovery sets the function used to connect to a Ledger
+// device, e.g. to a MockTransport in tests. With a nil fn, Ledger devices
+// are not supported.
+func SetTransportDiscovery(fn func() (Transport, error)) {
+	if fn == nil {
+		discoverLedger = nil
+		return
+	}
+	discoverLedger = func() (LedgerSECP256K1, error) {
+		transport, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return NewCosmosApp(transport), nil
+	}
+}
+
+// APDU commands of the Cosmos app.
+const (
+	claCosmos = 0x55
+
+	insSignSECP256K1    = 0x02
+	insGetAddrSECP256K1 = 0x04
+
+	// P1 of insSignSECP256K1 chunks.
+	p1SignInit = 0x00
+	p1SignAdd  = 0x01
+	p1SignLast = 0x02
+
+	// P1 of insGetAddrSECP256K1.
+	p1AddrNoDisplay = 0x00
+	p1AddrDisplay   = 0x01
+
+	signChunkSize = 250
+	pubKeySize    = 33 // compressed
+)
+
+// cosmosApp talks to the Cosmos app of a Ledger device over a Transport.
+type cosmosApp struct {
+	transport Transport
+}
+
+var _ LedgerSECP256K1 = cosmosApp{}
+
+// NewCosmosApp returns the LedgerSECP256K1 of the Cosmos app of the device
+// connected through transport.
+func NewCosmosApp(transport Transport) LedgerSECP256K1 {
+	return cosmosApp{transport}
+}
+
+func (app cosmosApp) Close() error {
+	return app.transport.Close()
+}
+
+// GetPublicKeySECP256K1 returns the compressed public key for path, without
+// confirmation on the device.
+func (app cosmosApp) GetPublicKeySECP256K1(path []uint32) ([]byte, error) {
+	pubKey, _, err := app.getAddrPubKey(path, crypto.Bech32AddrPrefix, p1AddrNoDisplay)
+	return pubKey, err
+}
+
+// GetAddressPubKeySECP256K1 shows the address for path and hrp on the
+// device, and returns the compressed public key and the address once the
+// user confirms them.
+func (app cosmosApp) GetAddressPubKeySECP256K1(path []uint32, hrp string) ([]byte, string, error) {
+	return app.getAddrPubKey(path, hrp, p1AddrDisplay)
+}
+
+func (app cosmosApp) getAddrPubKey(path []uint32, hrp string, p1 byte) ([]byte, string, error) {
+	bip32, err := serializePath(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(hrp) == 0 || len(hrp) > 83 {
+		return nil, "", fmt.Errorf("ledger: invalid bech32 prefix %q", hrp)
+	}
+	data := append([]byte{byte(len(hrp))}, hrp...)
+	data = append(data, bip32...)
+
+	resp, err := app.exchange(insGetAddrSECP256K1, p1, 0, data)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(resp) < pubKeySize {
+		return nil, "", fmt.Errorf("ledger: invalid public key response of %d bytes", len(resp))
+	}
+	return resp[:pubKeySize], string(resp[pubKeySize:]), nil
+}
+
+// SignSECP256K1 signs msg with the key of path, once the user confirms it on
+// the device. It returns a DER encoded signature of the SHA-256 of msg.
+func (app cosmosApp) SignSECP256K1(path []uint32, msg []byte) ([]byte, error) {
+	bip32, err := serializePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := app.exchange(insSignSECP256K1, p1SignInit, 0, bip32); err != nil {
+		return nil, err
+	}
+	for len(msg) > 0 {
+		chunk, p1 := msg, byte(p1SignLast)
+		if len(chunk) > signChunkSize {
+			chunk, p1 = msg[:signChunkSize], p1SignAdd
+		}
+		msg = msg[len(chunk):]
+
+		resp, err := app.exchange(insSignSECP256K1, p1, 0, chunk)
+		if err != nil {
+			return nil, err
+		}
+		if p1 == p1SignLast {
+			return resp, nil
+		}
+	}
+	return nil, fmt.Errorf("ledger: cannot sign an empty message")
+}
+
+// exchange sends a command to the app, and returns the response data
+// without the status word.
+func (app cosmosApp) exchange(ins, p1, p2 byte, data []byte) ([]byte, error) {
+	if len(data) > 255 {
+		return nil, fmt.Errorf("ledger: APDU data of %d bytes is too long", len(data))
+	}
+	command := append([]byte{claCosmos, ins, p1, p2, byte(len(data))}, data...)
+	resp, err := app.transport.Exchange(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("ledger: invalid response of %d bytes", len(resp))
+	}
+	sw := binary.BigEndian.Uint16(resp[len(resp)-2:])
+	if err := statusError(sw); err != nil {
+		return nil, err
+	}
+	return resp[:len(resp)-2], nil
+}
+
+// serializePath encodes a BIP44 path as expected by the Cosmos app: five
+// little endian components, of which the first three are hardened.
+func serializePath(path []uint32) ([]byte, error) {
+	if len(path) != 5 {
+		return nil, fmt.Errorf("ledger: invalid BIP44 path %v: expected 5 components", path)
+	}
+	bz := make([]byte, 4*len(path))
+	for i, v := range path {
+		if v >= hd.HardenedOffset {
+			return nil, fmt.Errorf("ledger: invalid BIP44 path %v: component %d is too large", path, i)
+		}
+		if i < 3 {
+			v |= hd.HardenedOffset
+		}
+		binary.LittleEndian.PutUint32(bz[4*i:], v)
+	}
+	return bz, nil
+}
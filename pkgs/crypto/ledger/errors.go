@@ -0,0 +1,56 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrDeviceNotFound is returned when no Ledger device is connected.
+	ErrDeviceNotFound = errors.New("ledger: no device found")
+	// ErrDeviceDisconnected is returned when the connection to the device
+	// fails during a request, e.g. because it was unplugged.
+	ErrDeviceDisconnected = errors.New("ledger: device disconnected")
+	// ErrUserRejected is returned when the user rejects a request on the
+	// device, such as signing a transaction or confirming an address.
+	ErrUserRejected = errors.New("ledger: request rejected on the device")
+	// ErrAppNotOpen is returned when the device is connected, but the Cosmos
+	// app is not open.
+	ErrAppNotOpen = errors.New("ledger: the Cosmos app is not open on the device")
+)
+
+// StatusError is returned for a response of the device with an unexpected
+// status word.
+type StatusError struct {
+	Code uint16
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("ledger: request failed with status %#04x", e.Code)
+}
+
+// Status words of the Cosmos app. The application specific words are
+// documented at https://github.com/cosmos/ledger-cosmos/blob/main/docs/APDUSPEC.md
+const (
+	swOK              = 0x9000
+	swUserRejected    = 0x6986
+	swCLANotSupported = 0x6e00 // no app, or another app, is open
+	swAppNotOpen      = 0x6511 // the dashboard is open
+	swINSNotSupported = 0x6d00
+	swDataInvalid     = 0x6984
+	swWrongLength     = 0x6700
+)
+
+// statusError returns the error of a status word, or nil for success.
+func statusError(sw uint16) error {
+	switch sw {
+	case swOK:
+		return nil
+	case swUserRejected:
+		return ErrUserRejected
+	case swCLANotSupported, swAppNotOpen:
+		return ErrAppNotOpen
+	default:
+		return StatusError{sw}
+	}
+}
@@ -0,0 +1,97 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Framing of APDUs in the HID reports of Ledger devices.
+const (
+	hidReportSize = 64
+	hidChannel    = 0x0101
+	hidTagAPDU    = 0x05
+	hidHeaderSize = 5 // channel, tag, sequence index
+)
+
+// hidTransport is a Transport over the HID interface of a Ledger device,
+// such as a hidraw device file.
+type hidTransport struct {
+	dev io.ReadWriteCloser
+	// reportID is prepended to every written report, for devices that
+	// expect one even without numbered reports, like hidraw.
+	reportID bool
+}
+
+var _ Transport = (*hidTransport)(nil)
+
+func newHIDTransport(dev io.ReadWriteCloser, reportID bool) *hidTransport {
+	return &hidTransport{dev: dev, reportID: reportID}
+}
+
+func (t *hidTransport) Exchange(command []byte) ([]byte, error) {
+	for _, report := range wrapAPDU(command) {
+		if t.reportID {
+			report = append([]byte{0x00}, report...)
+		}
+		if _, err := t.dev.Write(report); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDeviceDisconnected, err)
+		}
+	}
+	return unwrapAPDU(t.dev)
+}
+
+func (t *hidTransport) Close() error {
+	return t.dev.Close()
+}
+
+// wrapAPDU splits an APDU command into HID reports. The first report
+// starts with the length of the command.
+func wrapAPDU(command []byte) [][]byte {
+	data := make([]byte, 2+len(command))
+	binary.BigEndian.PutUint16(data, uint16(len(command)))
+	copy(data[2:], command)
+
+	var reports [][]byte
+	for seq := 0; len(data) > 0; seq++ {
+		report := make([]byte, hidReportSize)
+		binary.BigEndian.PutUint16(report[0:], hidChannel)
+		report[2] = hidTagAPDU
+		binary.BigEndian.PutUint16(report[3:], uint16(seq))
+		n := copy(report[hidHeaderSize:], data)
+		data = data[n:]
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// unwrapAPDU reads HID reports from r until a full APDU response is read.
+func unwrapAPDU(r io.Reader) ([]byte, error) {
+	var (
+		resp   []byte
+		length = -1
+		report = make([]byte, hidReportSize)
+	)
+	for seq := 0; length < 0 || len(resp) < length; seq++ {
+		n, err := r.Read(report)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDeviceDisconnected, err)
+		}
+		if n < hidHeaderSize ||
+			binary.BigEndian.Uint16(report[0:]) != hidChannel ||
+			report[2] != hidTagAPDU ||
+			binary.BigEndian.Uint16(report[3:]) != uint16(seq) {
+			return nil, fmt.Errorf("ledger: invalid HID report % x", report[:n])
+		}
+		payload := report[hidHeaderSize:n]
+		if seq == 0 {
+			if len(payload) < 2 {
+				return nil, fmt.Errorf("ledger: invalid HID report % x", report[:n])
+			}
+			length = int(binary.BigEndian.Uint16(payload))
+			payload = payload[2:]
+		}
+		resp = append(resp, payload...)
+	}
+	return resp[:length], nil
+}
@@ -0,0 +1,60 @@
+//go:build ledger
+// +build ledger
+
+package ledger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ledgerVendorID = "00002C97"
+	// the HID interface of the apps starts with a vendor usage page
+	// 0xFFA0, unlike the U2F one.
+	ledgerUsagePage = "\x06\xa0\xff"
+)
+
+func init() {
+	SetTransportDiscovery(discoverHIDRaw)
+}
+
+// discoverHIDRaw opens the hidraw device file of the first connected
+// Ledger device.
+func discoverHIDRaw() (Transport, error) {
+	devices, err := filepath.Glob("/sys/class/hidraw/hidraw*")
+	if err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		uevent, err := ioutil.ReadFile(filepath.Join(dev, "device", "uevent"))
+		if err != nil || !isLedgerUevent(string(uevent)) {
+			continue
+		}
+		desc, err := ioutil.ReadFile(filepath.Join(dev, "device", "report_descriptor"))
+		if err != nil || !bytes.HasPrefix(desc, []byte(ledgerUsagePage)) {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join("/dev", filepath.Base(dev)), os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+		return newHIDTransport(f, true), nil
+	}
+	return nil, ErrDeviceNotFound
+}
+
+// isLedgerUevent returns whether a HID uevent, with a line like
+// "HID_ID=0003:00002C97:00001011", is of a Ledger device.
+func isLedgerUevent(uevent string) bool {
+	for _, line := range strings.Split(uevent, "\n") {
+		if id := strings.TrimPrefix(line, "HID_ID="); id != line {
+			parts := strings.Split(id, ":")
+			return len(parts) == 3 && strings.EqualFold(parts[1], ledgerVendorID)
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package ledger
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -14,6 +15,9 @@ import (
 )
 
 var (
+	// used to normalize signatures to lower-S form.
+	secp256k1halfN = new(big.Int).Rsh(btcec.S256().N, 1)
+
 	// discoverLedger defines a function to be invoked at runtime for discovering
 	// a connected Ledger device.
 	discoverLedger discoverLedgerFn
@@ -113,7 +117,7 @@ func LedgerShowAddress(path hd.BIP44Params, expectedPubKey crypto.PubKey) error
 		return err
 	}
 
-	if pubKey != expectedPubKey {
+	if !pubKey.Equals(expectedPubKey) {
 		return fmt.Errorf("the key's pubkey does not match with the one retrieved from Ledger. Check that the HD path and device are the correct ones")
 	}
 
@@ -122,7 +126,7 @@ func LedgerShowAddress(path hd.BIP44Params, expectedPubKey crypto.PubKey) error
 		return err
 	}
 
-	if pubKey2 != expectedPubKey {
+	if !pubKey2.Equals(expectedPubKey) {
 		return fmt.Errorf("the key's pubkey does not match with the one retrieved from Ledger. Check that the HD path and device are the correct ones")
 	}
 
@@ -167,13 +171,22 @@ func warnIfErrors(f func() error) {
 	}
 }
 
-func convertDERtoBER(signatureDER []byte) ([]byte, error) {
-	sigDER, err := btcec.ParseDERSignature(signatureDER[:], btcec.S256())
+// convertDERtoRS converts a DER signature of the device to the R || S form
+// (in lower-S form) of secp256k1.PrivKeySecp256k1.Sign.
+func convertDERtoRS(signatureDER []byte) ([]byte, error) {
+	sig, err := btcec.ParseDERSignature(signatureDER, btcec.S256())
 	if err != nil {
 		return nil, err
 	}
-	sigBER := btcec.Signature{R: sigDER.R, S: sigDER.S}
-	return sigBER.Serialize(), nil
+	s := sig.S
+	if s.Cmp(secp256k1halfN) > 0 {
+		s = new(big.Int).Sub(btcec.S256().N, s)
+	}
+	rBytes, sBytes := sig.R.Bytes(), s.Bytes()
+	sigBytes := make([]byte, 64)
+	copy(sigBytes[32-len(rBytes):32], rBytes)
+	copy(sigBytes[64-len(sBytes):64], sBytes)
+	return sigBytes, nil
 }
 
 func getLedgerDevice() (LedgerSECP256K1, error) {
@@ -181,12 +194,7 @@ func getLedgerDevice() (LedgerSECP256K1, error) {
 		return nil, errors.New("no Ledger discovery function defined")
 	}
 
-	device, err := discoverLedger()
-	if err != nil {
-		return nil, errors.Wrap(err, "ledger nano S")
-	}
-
-	return device, nil
+	return discoverLedger()
 }
 
 func validateKey(device LedgerSECP256K1, pkl PrivKeyLedgerSecp256k1) error {
@@ -214,12 +222,19 @@ func sign(device LedgerSECP256K1, pkl PrivKeyLedgerSecp256k1, msg []byte) ([]byt
 		return nil, err
 	}
 
-	sig, err := device.SignSECP256K1(pkl.Path.DerivationPath(), msg)
+	sigDER, err := device.SignSECP256K1(pkl.Path.DerivationPath(), msg)
 	if err != nil {
 		return nil, err
 	}
 
-	return convertDERtoBER(sig)
+	sig, err := convertDERtoRS(sigDER)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing signature: %v", err)
+	}
+	if !pkl.CachedPubKey.VerifyBytes(msg, sig) {
+		return nil, fmt.Errorf("the signature of the Ledger device does not verify with the cached key")
+	}
+	return sig, nil
 }
 
 // getPubKeyUnsafe reads the pubkey from a ledger device
@@ -233,7 +248,7 @@ func sign(device LedgerSECP256K1, pkl PrivKeyLedgerSecp256k1, msg []byte) ([]byt
 func getPubKeyUnsafe(device LedgerSECP256K1, path hd.BIP44Params) (crypto.PubKey, error) {
 	publicKey, err := device.GetPublicKeySECP256K1(path.DerivationPath())
 	if err != nil {
-		return nil, fmt.Errorf("please open Cosmos app on the Ledger device - error: %v", err)
+		return nil, fmt.Errorf("please open Cosmos app on the Ledger device - error: %w", err)
 	}
 
 	// re-serialize in the 33-byte compressed format
@@ -257,7 +272,7 @@ func getPubKeyUnsafe(device LedgerSECP256K1, path hd.BIP44Params) (crypto.PubKey
 func getPubKeyAddrSafe(device LedgerSECP256K1, path hd.BIP44Params, hrp string) (crypto.PubKey, string, error) {
 	publicKey, addr, err := device.GetAddressPubKeySECP256K1(path.DerivationPath(), hrp)
 	if err != nil {
-		return nil, "", fmt.Errorf("address %s rejected", addr)
+		return nil, "", fmt.Errorf("address %s rejected: %w", addr, err)
 	}
 
 	// re-serialize in the 33-byte compressed format
@@ -0,0 +1,176 @@
+package ledger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/hd"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+)
+
+const testMnemonic = "equip will roof matter pink blind book anxiety banner elbow sun young"
+
+func useMock(t *testing.T) *MockTransport {
+	t.Helper()
+
+	mock := NewMockTransport(testMnemonic)
+	SetTransportDiscovery(func() (Transport, error) { return mock, nil })
+	t.Cleanup(func() { SetTransportDiscovery(nil) })
+	return mock
+}
+
+func TestSignWithMock(t *testing.T) {
+	mock := useMock(t)
+	path := *hd.NewFundraiserParams(0, crypto.CoinType, 3)
+
+	priv, addr, err := NewPrivKeyLedgerSecp256k1(path, "g")
+	require.NoError(t, err)
+	assert.Equal(t, []string{addr}, mock.Displayed)
+	assert.Equal(t, crypto.AddressToBech32(priv.PubKey().Address()), addr)
+
+	// the key is the one of the mnemonic.
+	derived, err := hd.Derive(testMnemonic, "", path.String())
+	require.NoError(t, err)
+	var expected secp256k1.PrivKeySecp256k1
+	copy(expected[:], derived)
+	assert.Equal(t, expected.PubKey(), priv.PubKey())
+
+	// messages longer than an APDU are sent in chunks.
+	for _, msg := range [][]byte{[]byte("hello"), bytes.Repeat([]byte("x"), 3*signChunkSize+1)} {
+		sig, err := priv.Sign(msg)
+		require.NoError(t, err)
+		assert.Len(t, sig, 64)
+		assert.True(t, priv.PubKey().VerifyBytes(msg, sig))
+	}
+
+	unsafe, err := NewPrivKeyLedgerSecp256k1Unsafe(path)
+	require.NoError(t, err)
+	assert.True(t, priv.Equals(unsafe))
+	assert.Len(t, mock.Displayed, 1)
+
+	assert.NoError(t, LedgerShowAddress(path, priv.PubKey()))
+	assert.Len(t, mock.Displayed, 2)
+	other := *hd.NewFundraiserParams(0, crypto.CoinType, 4)
+	assert.Error(t, LedgerShowAddress(other, priv.PubKey()))
+}
+
+func TestUserRejected(t *testing.T) {
+	mock := useMock(t)
+	path := *hd.NewFundraiserParams(0, crypto.CoinType, 0)
+	priv, _, err := NewPrivKeyLedgerSecp256k1(path, "g")
+	require.NoError(t, err)
+
+	mock.Reject = true
+	_, err = priv.Sign([]byte("hello"))
+	assert.True(t, errors.Is(err, ErrUserRejected), "%v", err)
+	_, _, err = NewPrivKeyLedgerSecp256k1(path, "g")
+	assert.True(t, errors.Is(err, ErrUserRejected), "%v", err)
+
+	// requests without confirmation still work.
+	_, err = NewPrivKeyLedgerSecp256k1Unsafe(path)
+	assert.NoError(t, err)
+}
+
+func TestDeviceDisconnected(t *testing.T) {
+	mock := useMock(t)
+	path := *hd.NewFundraiserParams(0, crypto.CoinType, 0)
+	priv, _, err := NewPrivKeyLedgerSecp256k1(path, "g")
+	require.NoError(t, err)
+
+	mock.Disconnected = true
+	_, err = priv.Sign([]byte("hello"))
+	assert.True(t, errors.Is(err, ErrDeviceDisconnected), "%v", err)
+	_, err = NewPrivKeyLedgerSecp256k1Unsafe(path)
+	assert.True(t, errors.Is(err, ErrDeviceDisconnected), "%v", err)
+
+	SetTransportDiscovery(func() (Transport, error) { return nil, ErrDeviceNotFound })
+	_, err = priv.Sign([]byte("hello"))
+	assert.True(t, errors.Is(err, ErrDeviceNotFound), "%v", err)
+
+	SetTransportDiscovery(nil)
+	_, err = priv.Sign([]byte("hello"))
+	assert.EqualError(t, err, "no Ledger discovery function defined")
+}
+
+type transportFunc func(command []byte) ([]byte, error)
+
+func (f transportFunc) Exchange(command []byte) ([]byte, error) { return f(command) }
+func (f transportFunc) Close() error                             { return nil }
+
+func TestStatusWords(t *testing.T) {
+	path := []uint32{44, 118, 0, 0, 0}
+	for sw, expected := range map[uint16]error{
+		0x6e00: ErrAppNotOpen,
+		0x6511: ErrAppNotOpen,
+		0x6986: ErrUserRejected,
+		0x6a80: StatusError{0x6a80},
+	} {
+		app := NewCosmosApp(transportFunc(func([]byte) ([]byte, error) {
+			return status(sw), nil
+		}))
+		_, err := app.GetPublicKeySECP256K1(path)
+		assert.Equal(t, expected, err)
+	}
+	assert.Equal(t, "ledger: request failed with status 0x6a80", StatusError{0x6a80}.Error())
+}
+
+func TestSerializePath(t *testing.T) {
+	bz, err := serializePath([]uint32{44, 118, 1, 0, 7})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		44, 0, 0, 0x80,
+		118, 0, 0, 0x80,
+		1, 0, 0, 0x80,
+		0, 0, 0, 0,
+		7, 0, 0, 0,
+	}, bz)
+	path, ok := parsePath(bz)
+	assert.True(t, ok)
+	assert.Equal(t, []uint32{44, 118, 1, 0, 7}, path)
+
+	_, err = serializePath([]uint32{44, 118, 0, 0})
+	assert.Error(t, err)
+	_, err = serializePath([]uint32{44, 118, hd.HardenedOffset, 0, 0})
+	assert.Error(t, err)
+}
+
+// hidDevice reads back what was written to it, as HID reports.
+type hidDevice struct {
+	bytes.Buffer
+}
+
+func (d *hidDevice) Close() error { return nil }
+
+func TestHIDFraming(t *testing.T) {
+	for _, n := range []int{0, 1, hidReportSize - hidHeaderSize - 2, hidReportSize, 300} {
+		command := []byte(strings.Repeat("a", n))
+		reports := wrapAPDU(command)
+		for i, report := range reports {
+			assert.Len(t, report, hidReportSize)
+			assert.Equal(t, []byte{0x01, 0x01, 0x05, 0, byte(i)}, report[:hidHeaderSize])
+		}
+
+		dev := new(hidDevice)
+		resp, err := newHIDTransport(dev, false).Exchange(command)
+		require.NoError(t, err, "%d bytes", n)
+		assert.Equal(t, command, resp, "%d bytes", n)
+	}
+
+	// invalid reports
+	dev := new(hidDevice)
+	report := wrapAPDU([]byte("hello"))[0]
+	report[2] = 0x02
+	dev.Write(report)
+	_, err := unwrapAPDU(dev)
+	assert.Error(t, err)
+
+	// a device that stops responding is disconnected.
+	_, err = unwrapAPDU(new(hidDevice))
+	assert.True(t, errors.Is(err, ErrDeviceDisconnected), "%v", err)
+}
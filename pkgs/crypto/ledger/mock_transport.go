@@ -0,0 +1,173 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"github.com/gnolang/gno/pkgs/bech32"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/hd"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+)
+
+// MockTransport is a Transport that emulates the Cosmos app of a Ledger
+// device in memory, with the keys of a BIP 39 mnemonic, so that Ledger
+// flows can be tested without hardware.
+type MockTransport struct {
+	mnemonic string
+
+	mtx sync.Mutex
+	// Reject makes the emulated user reject all requests that need a
+	// confirmation on the device.
+	Reject bool
+	// Disconnected makes all exchanges fail, as if the device was
+	// unplugged.
+	Disconnected bool
+	// Displayed records the addresses shown on the device.
+	Displayed []string
+
+	signPath []uint32
+	signMsg  []byte
+}
+
+var _ Transport = (*MockTransport)(nil)
+
+// NewMockTransport returns a MockTransport with the keys of mnemonic.
+func NewMockTransport(mnemonic string) *MockTransport {
+	return &MockTransport{mnemonic: mnemonic}
+}
+
+// Exchange implements Transport.
+func (m *MockTransport) Exchange(command []byte) ([]byte, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.Disconnected {
+		return nil, fmt.Errorf("%w: mock device unplugged", ErrDeviceDisconnected)
+	}
+	if len(command) < 5 || int(command[4]) != len(command)-5 {
+		return status(swWrongLength), nil
+	}
+	cla, ins, p1, data := command[0], command[1], command[2], command[5:]
+	if cla != claCosmos {
+		return status(swCLANotSupported), nil
+	}
+
+	var (
+		resp []byte
+		sw   uint16
+	)
+	switch ins {
+	case insGetAddrSECP256K1:
+		resp, sw = m.getAddr(p1, data)
+	case insSignSECP256K1:
+		resp, sw = m.sign(p1, data)
+	default:
+		sw = swINSNotSupported
+	}
+	return append(resp, status(sw)...), nil
+}
+
+// Close implements Transport. The mock can be used again after Close.
+func (m *MockTransport) Close() error {
+	return nil
+}
+
+func (m *MockTransport) getAddr(p1 byte, data []byte) ([]byte, uint16) {
+	if len(data) < 1 || len(data) != 1+int(data[0])+20 {
+		return nil, swDataInvalid
+	}
+	hrp := string(data[1 : 1+data[0]])
+	path, ok := parsePath(data[1+data[0]:])
+	if !ok {
+		return nil, swDataInvalid
+	}
+	priv, err := m.privKey(path)
+	if err != nil {
+		return nil, swDataInvalid
+	}
+	pub := priv.PubKey().(secp256k1.PubKeySecp256k1)
+	addr, err := bech32.ConvertAndEncode(hrp, pub.Address().Bytes())
+	if err != nil {
+		return nil, swDataInvalid
+	}
+	if p1 == p1AddrDisplay {
+		if m.Reject {
+			return nil, swUserRejected
+		}
+		m.Displayed = append(m.Displayed, addr)
+	}
+	return append(pub[:], addr...), swOK
+}
+
+func (m *MockTransport) sign(p1 byte, data []byte) ([]byte, uint16) {
+	switch p1 {
+	case p1SignInit:
+		path, ok := parsePath(data)
+		if !ok {
+			return nil, swDataInvalid
+		}
+		m.signPath, m.signMsg = path, nil
+		return nil, swOK
+	case p1SignAdd, p1SignLast:
+		if m.signPath == nil {
+			return nil, swDataInvalid
+		}
+		m.signMsg = append(m.signMsg, data...)
+		if p1 == p1SignAdd {
+			return nil, swOK
+		}
+	default:
+		return nil, swDataInvalid
+	}
+
+	path, msg := m.signPath, m.signMsg
+	m.signPath, m.signMsg = nil, nil
+	if m.Reject {
+		return nil, swUserRejected
+	}
+	priv, err := m.privKey(path)
+	if err != nil {
+		return nil, swDataInvalid
+	}
+	btcPriv, _ := btcec.PrivKeyFromBytes(btcec.S256(), priv[:])
+	sig, err := btcPriv.Sign(crypto.Sha256(msg))
+	if err != nil {
+		return nil, swDataInvalid
+	}
+	return sig.Serialize(), swOK
+}
+
+func (m *MockTransport) privKey(path []uint32) (secp256k1.PrivKeySecp256k1, error) {
+	params := hd.NewParams(path[0], path[1], path[2], path[3] == 1, path[4])
+	derived, err := hd.Derive(m.mnemonic, "", params.String())
+	if err != nil {
+		return secp256k1.PrivKeySecp256k1{}, err
+	}
+	var priv secp256k1.PrivKeySecp256k1
+	copy(priv[:], derived)
+	return priv, nil
+}
+
+// parsePath is the inverse of serializePath.
+func parsePath(bz []byte) ([]uint32, bool) {
+	if len(bz) != 20 {
+		return nil, false
+	}
+	path := make([]uint32, 5)
+	for i := range path {
+		v := binary.LittleEndian.Uint32(bz[4*i:])
+		if (i < 3) != (v >= hd.HardenedOffset) {
+			return nil, false
+		}
+		path[i] = v &^ hd.HardenedOffset
+	}
+	return path, true
+}
+
+func status(sw uint16) []byte {
+	return []byte{byte(sw >> 8), byte(sw)}
+}
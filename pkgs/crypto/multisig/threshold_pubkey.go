@@ -41,6 +41,19 @@ func (pk PubKeyMultisigThreshold) String() string {
 // The multisig uses a bitarray, so multiple signatures for the same key is not
 // a concern.
 func (pk PubKeyMultisigThreshold) VerifyBytes(msg []byte, marshalledSig []byte) bool {
+	bv := crypto.NewBatchVerifier()
+	if !pk.AddToBatch(bv, msg, marshalledSig) {
+		return false
+	}
+	ok, _ := bv.VerifyAll()
+	return ok
+}
+
+// AddToBatch checks the structure of the multisignature like VerifyBytes,
+// and queues the signature of every set bit on bv.
+// Returns false if the multisignature is malformed, in which case
+// VerifyBytes would return false regardless of the queued signatures.
+func (pk PubKeyMultisigThreshold) AddToBatch(bv crypto.BatchVerifier, msg []byte, marshalledSig []byte) bool {
 	var sig Multisignature
 	err := amino.Unmarshal(marshalledSig, &sig)
 	if err != nil {
@@ -63,9 +76,10 @@ func (pk PubKeyMultisigThreshold) VerifyBytes(msg []byte, marshalledSig []byte)
 	sigIndex := 0
 	for i := 0; i < size; i++ {
 		if sig.BitArray.GetIndex(i) {
-			if !pk.PubKeys[i].VerifyBytes(msg, sig.Sigs[sigIndex]) {
+			if sigIndex >= len(sig.Sigs) {
 				return false
 			}
+			bv.Add(pk.PubKeys[i], msg, sig.Sigs[sigIndex])
 			sigIndex++
 		}
 	}
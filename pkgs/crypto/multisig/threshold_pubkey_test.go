@@ -9,6 +9,7 @@ import (
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/crypto/ed25519"
 	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1"
 	"github.com/gnolang/gno/pkgs/amino"
 )
 
@@ -162,10 +163,13 @@ func generatePubKeysAndSignatures(n int, msg []byte) (pubkeys []crypto.PubKey, s
 	signatures = make([][]byte, n)
 	for i := 0; i < n; i++ {
 		var privkey crypto.PrivKey
-		if rand.Int63()%2 == 0 {
+		switch rand.Int63() % 3 {
+		case 0:
 			privkey = ed25519.GenPrivKey()
-		} else {
+		case 1:
 			privkey = secp256k1.GenPrivKey()
+		default:
+			privkey = secp256r1.GenPrivKey()
 		}
 		pubkeys[i] = privkey.PubKey()
 		signatures[i], _ = privkey.Sign(msg)
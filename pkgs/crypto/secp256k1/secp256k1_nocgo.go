@@ -3,7 +3,9 @@
 package secp256k1
 
 import (
+	"container/list"
 	"math/big"
+	"sync"
 
 	secp256k1 "github.com/btcsuite/btcd/btcec"
 
@@ -34,7 +36,7 @@ func (pubKey PubKeySecp256k1) VerifyBytes(msg []byte, sigStr []byte) bool {
 	if len(sigStr) != 64 {
 		return false
 	}
-	pub, err := secp256k1.ParsePubKey(pubKey[:], secp256k1.S256())
+	pub, err := parsePubKeyCached(pubKey)
 	if err != nil {
 		return false
 	}
@@ -68,3 +70,54 @@ func serializeSig(sig *secp256k1.Signature) []byte {
 	copy(sigBytes[64-len(sBytes):64], sBytes)
 	return sigBytes
 }
+
+//----------------------------------------
+// decompressed pubkey cache
+
+// pubKeyCacheSize is the number of decompressed public keys kept in memory,
+// as accounts tend to sign many transactions.
+const pubKeyCacheSize = 4096
+
+var pubKeyCache = struct {
+	mtx     sync.Mutex
+	entries map[PubKeySecp256k1]*list.Element
+	queue   *list.List // of *pubKeyCacheEntry, least recently used first
+}{
+	entries: make(map[PubKeySecp256k1]*list.Element),
+	queue:   list.New(),
+}
+
+type pubKeyCacheEntry struct {
+	key PubKeySecp256k1
+	pub *secp256k1.PublicKey
+}
+
+// parsePubKeyCached decompresses pubKey, or returns the cached result of a
+// previous call. Returned keys must not be modified.
+func parsePubKeyCached(pubKey PubKeySecp256k1) (*secp256k1.PublicKey, error) {
+	c := &pubKeyCache
+	c.mtx.Lock()
+	if elem, ok := c.entries[pubKey]; ok {
+		c.queue.MoveToBack(elem)
+		c.mtx.Unlock()
+		return elem.Value.(*pubKeyCacheEntry).pub, nil
+	}
+	c.mtx.Unlock()
+
+	pub, err := secp256k1.ParsePubKey(pubKey[:], secp256k1.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.entries[pubKey]; !ok {
+		c.entries[pubKey] = c.queue.PushBack(&pubKeyCacheEntry{pubKey, pub})
+		if c.queue.Len() > pubKeyCacheSize {
+			oldest := c.queue.Front()
+			c.queue.Remove(oldest)
+			delete(c.entries, oldest.Value.(*pubKeyCacheEntry).key)
+		}
+	}
+	return pub, nil
+}
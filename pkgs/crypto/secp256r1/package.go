@@ -0,0 +1,14 @@
+package secp256r1
+
+import (
+	"github.com/gnolang/gno/pkgs/amino"
+)
+
+var Package = amino.RegisterPackage(amino.NewPackage(
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1",
+	"tm",
+	amino.GetCallersDirname(),
+).WithDependencies().WithTypes(
+	PubKeySecp256r1{}, "PubKeySecp256r1",
+	PrivKeySecp256r1{}, "PrivKeySecp256r1",
+))
@@ -0,0 +1,250 @@
+package secp256r1
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/ripemd160"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+)
+
+// secp256r1 is also known as P-256 or prime256v1.
+var curve = elliptic.P256()
+
+// used to reject malleable signatures, as for secp256k1.
+var secp256r1halfN = new(big.Int).Rsh(curve.Params().N, 1)
+
+const (
+	// SignatureSize is the size of a signature: the 32 bytes big endian R
+	// and S values, concatenated.
+	SignatureSize = 64
+)
+
+//-------------------------------------
+
+var _ crypto.PrivKey = PrivKeySecp256r1{}
+
+// PrivKeySecp256r1 implements PrivKey. It is the big endian private scalar.
+type PrivKeySecp256r1 [32]byte
+
+// Bytes marshalls the private key using amino encoding.
+func (privKey PrivKeySecp256r1) Bytes() []byte {
+	return amino.MustMarshalAny(privKey)
+}
+
+// Sign creates an ECDSA signature on curve secp256r1, using SHA256 on the
+// msg. The nonce is derived deterministically from the key and the msg as
+// per RFC 6979, so that signing does not depend on the quality of a random
+// source. The returned signature is the 64 bytes R || S, with S in the lower
+// half of the curve order.
+func (privKey PrivKeySecp256r1) Sign(msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	r, s := signRFC6979(privKey, hash[:])
+	if s.Cmp(secp256r1halfN) > 0 {
+		s.Sub(curve.Params().N, s)
+	}
+	sig := make([]byte, SignatureSize)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return sig, nil
+}
+
+// PubKey performs the point-scalar multiplication from the privKey on the
+// generator point to get the pubkey.
+func (privKey PrivKeySecp256r1) PubKey() crypto.PubKey {
+	x, y := curve.ScalarBaseMult(privKey[:])
+	var pubKey PubKeySecp256r1
+	copy(pubKey[:], elliptic.MarshalCompressed(curve, x, y))
+	return pubKey
+}
+
+// Equals - you probably don't need to use this.
+// Runs in constant time based on length of the keys.
+func (privKey PrivKeySecp256r1) Equals(other crypto.PrivKey) bool {
+	if otherSecp, ok := other.(PrivKeySecp256r1); ok {
+		return subtle.ConstantTimeCompare(privKey[:], otherSecp[:]) == 1
+	}
+	return false
+}
+
+// ToECDSA returns the private key as a crypto/ecdsa key.
+func (privKey PrivKeySecp256r1) ToECDSA() *ecdsa.PrivateKey {
+	priv := new(ecdsa.PrivateKey)
+	priv.Curve = curve
+	priv.D = new(big.Int).SetBytes(privKey[:])
+	priv.X, priv.Y = curve.ScalarBaseMult(privKey[:])
+	return priv
+}
+
+// GenPrivKey generates a new ECDSA private key on curve secp256r1.
+// It uses OS randomness to generate the private key.
+func GenPrivKey() PrivKeySecp256r1 {
+	return genPrivKey(crypto.CReader())
+}
+
+// genPrivKey generates a new secp256r1 private key using the provided reader.
+func genPrivKey(rand io.Reader) PrivKeySecp256r1 {
+	var privKeyBytes [32]byte
+	d := new(big.Int)
+	for {
+		privKeyBytes = [32]byte{}
+		_, err := io.ReadFull(rand, privKeyBytes[:])
+		if err != nil {
+			panic(err)
+		}
+
+		d.SetBytes(privKeyBytes[:])
+		// break if we found a valid point (i.e. > 0 and < N == curverOrder)
+		isValidFieldElement := 0 < d.Sign() && d.Cmp(curve.Params().N) < 0
+		if isValidFieldElement {
+			break
+		}
+	}
+
+	return PrivKeySecp256r1(privKeyBytes)
+}
+
+var one = new(big.Int).SetInt64(1)
+
+// GenPrivKeySecp256r1 hashes the secret with SHA2, and uses
+// that 32 byte output to create the private key, as GenPrivKeySecp256k1
+// does for secp256k1.
+//
+// NOTE: secret should be the output of a KDF like bcrypt,
+// if it's derived from user input.
+func GenPrivKeySecp256r1(secret []byte) PrivKeySecp256r1 {
+	secHash := sha256.Sum256(secret)
+	fe := new(big.Int).SetBytes(secHash[:])
+	n := new(big.Int).Sub(curve.Params().N, one)
+	fe.Mod(fe, n)
+	fe.Add(fe, one)
+
+	var privKey32 [32]byte
+	fe.FillBytes(privKey32[:])
+	return PrivKeySecp256r1(privKey32)
+}
+
+// signRFC6979 signs hash with the nonce of RFC 6979 section 3.2, for
+// HMAC-SHA256 and a 256 bits curve order.
+func signRFC6979(privKey PrivKeySecp256r1, hash []byte) (r, s *big.Int) {
+	params := curve.Params()
+	d := new(big.Int).SetBytes(privKey[:])
+	e := new(big.Int).SetBytes(hash)
+	// bits2octets(h1)
+	var h1 [32]byte
+	new(big.Int).Mod(e, params.N).FillBytes(h1[:])
+
+	mac := func(key []byte, data ...[]byte) []byte {
+		h := hmac.New(sha256.New, key)
+		for _, bz := range data {
+			h.Write(bz)
+		}
+		return h.Sum(nil)
+	}
+	v := bytes.Repeat([]byte{0x01}, sha256.Size)
+	k := make([]byte, sha256.Size)
+	k = mac(k, v, []byte{0x00}, privKey[:], h1[:])
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, privKey[:], h1[:])
+	v = mac(k, v)
+
+	for {
+		v = mac(k, v)
+		nonce := new(big.Int).SetBytes(v)
+		if nonce.Sign() > 0 && nonce.Cmp(params.N) < 0 {
+			x, _ := curve.ScalarBaseMult(v)
+			r = new(big.Int).Mod(x, params.N)
+			if r.Sign() != 0 {
+				s = new(big.Int).Mul(r, d)
+				s.Add(s, e)
+				s.Mul(s, new(big.Int).ModInverse(nonce, params.N))
+				s.Mod(s, params.N)
+				if s.Sign() != 0 {
+					return r, s
+				}
+			}
+		}
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
+
+//-------------------------------------
+
+var _ crypto.PubKey = PubKeySecp256r1{}
+
+// PubKeySecp256r1Size is comprised of 32 bytes for one field element
+// (the x-coordinate), plus one byte for the parity of the y-coordinate.
+const PubKeySecp256r1Size = 33
+
+// PubKeySecp256r1 implements crypto.PubKey.
+// It is the compressed form of the pubkey, as in SEC 1: a 0x02 or 0x03 byte
+// for the parity of the y-coordinate, followed with the x-coordinate.
+type PubKeySecp256r1 [PubKeySecp256r1Size]byte
+
+// Address returns a Bitcoin style addresses: RIPEMD160(SHA256(pubkey))
+func (pubKey PubKeySecp256r1) Address() crypto.Address {
+	hasherSHA256 := sha256.New()
+	hasherSHA256.Write(pubKey[:]) // does not error
+	sha := hasherSHA256.Sum(nil)
+
+	hasherRIPEMD160 := ripemd160.New()
+	hasherRIPEMD160.Write(sha) // does not error
+	return crypto.AddressFromBytes(hasherRIPEMD160.Sum(nil))
+}
+
+// Bytes returns the pubkey marshalled with amino encoding.
+func (pubKey PubKeySecp256r1) Bytes() []byte {
+	return amino.MustMarshalAny(pubKey)
+}
+
+// VerifyBytes verifies a signature of the form R || S, as produced by
+// PrivKeySecp256r1.Sign, with crypto/ecdsa. It rejects signatures which are
+// not in lower-S form.
+func (pubKey PubKeySecp256r1) VerifyBytes(msg []byte, sig []byte) bool {
+	if len(sig) != SignatureSize {
+		return false
+	}
+	pub := pubKey.ToECDSA()
+	if pub == nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	// Reject malleable signatures. libsecp256k1 does this check but
+	// crypto/ecdsa doesn't.
+	if s.Cmp(secp256r1halfN) > 0 {
+		return false
+	}
+	hash := sha256.Sum256(msg)
+	return ecdsa.Verify(pub, hash[:], r, s)
+}
+
+// ToECDSA returns the public key as a crypto/ecdsa key, or nil if it is not
+// a valid point of the curve.
+func (pubKey PubKeySecp256r1) ToECDSA() *ecdsa.PublicKey {
+	x, y := elliptic.UnmarshalCompressed(curve, pubKey[:])
+	if x == nil {
+		return nil
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+}
+
+func (pubKey PubKeySecp256r1) String() string {
+	return crypto.PubKeyToBech32(pubKey)
+}
+
+func (pubKey PubKeySecp256r1) Equals(other crypto.PubKey) bool {
+	if otherSecp, ok := other.(PubKeySecp256r1); ok {
+		return bytes.Equal(pubKey[:], otherSecp[:])
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package secp256r1_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1"
+)
+
+func TestSignAndValidateSecp256r1(t *testing.T) {
+	privKey := secp256r1.GenPrivKey()
+	pubKey := privKey.PubKey()
+
+	msg := crypto.CRandBytes(128)
+	sig, err := privKey.Sign(msg)
+	require.Nil(t, err)
+	require.Len(t, sig, secp256r1.SignatureSize)
+
+	assert.True(t, pubKey.VerifyBytes(msg, sig))
+
+	// signing is deterministic.
+	sig2, err := privKey.Sign(msg)
+	require.Nil(t, err)
+	assert.Equal(t, sig, sig2)
+
+	// Mutate the signature, just one bit.
+	sig[3] ^= byte(0x01)
+
+	assert.False(t, pubKey.VerifyBytes(msg, sig))
+}
+
+// Test vector of RFC 6979 A.2.5, for P-256 and SHA-256.
+func TestSignRFC6979Vector(t *testing.T) {
+	var priv secp256r1.PrivKeySecp256r1
+	bz, _ := hex.DecodeString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	copy(priv[:], bz)
+
+	pub := priv.PubKey().(secp256r1.PubKeySecp256r1).ToECDSA()
+	assert.Equal(t, "60fed4ba255a9d31c961eb74c6356d68c049b8923b61fa6ce669622e60f29fb6", hex.EncodeToString(pub.X.Bytes()))
+	assert.Equal(t, "7903fe1008b8bc99a41ae9e95628bc64f2f1b20c2d7e9f5177a3c294d4462299", hex.EncodeToString(pub.Y.Bytes()))
+
+	sig, err := priv.Sign([]byte("sample"))
+	require.NoError(t, err)
+	assert.Equal(t, "efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716", hex.EncodeToString(sig[:32]))
+	// the vector S is in the upper half of the order, so its negation is
+	// returned.
+	s, _ := new(big.Int).SetString("F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8", 16)
+	s.Sub(elliptic.P256().Params().N, s)
+	assert.Equal(t, s.Bytes(), new(big.Int).SetBytes(sig[32:]).Bytes())
+}
+
+func TestCrossVerifyWithStdlib(t *testing.T) {
+	msg := []byte("cross verification")
+	hash := sha256.Sum256(msg)
+
+	// signatures of the key verify with crypto/ecdsa.
+	priv := secp256r1.GenPrivKey()
+	sig, err := priv.Sign(msg)
+	require.NoError(t, err)
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	assert.True(t, ecdsa.Verify(&priv.ToECDSA().PublicKey, hash[:], r, s))
+	assert.Equal(t, priv.ToECDSA().PublicKey, *priv.PubKey().(secp256r1.PubKeySecp256r1).ToECDSA())
+
+	// signatures of crypto/ecdsa verify with the key, once in lower-S form.
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	var pub secp256r1.PubKeySecp256r1
+	copy(pub[:], elliptic.MarshalCompressed(elliptic.P256(), ecPriv.X, ecPriv.Y))
+	for i := 0; i < 10; i++ {
+		r, s, err := ecdsa.Sign(rand.Reader, ecPriv, hash[:])
+		require.NoError(t, err)
+		halfN := new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+		upperS := s.Cmp(halfN) > 0
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		assert.Equal(t, !upperS, pub.VerifyBytes(msg, sig))
+
+		s.Sub(elliptic.P256().Params().N, s)
+		s.FillBytes(sig[32:])
+		assert.Equal(t, upperS, pub.VerifyBytes(msg, sig))
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	priv := secp256r1.GenPrivKey()
+	msg := []byte("hello")
+	sig, err := priv.Sign(msg)
+	require.NoError(t, err)
+	pub := priv.PubKey().(secp256r1.PubKeySecp256r1)
+
+	assert.False(t, pub.VerifyBytes(msg, sig[:63]))
+	assert.False(t, pub.VerifyBytes(msg, append(sig, 0)))
+	assert.False(t, pub.VerifyBytes(msg, make([]byte, 64)))
+	// not a point of the curve.
+	var bad secp256r1.PubKeySecp256r1
+	bad[0] = 0x02
+	for i := 1; i < len(bad); i++ {
+		bad[i] = 0xFF
+	}
+	assert.False(t, bad.VerifyBytes(msg, sig))
+	assert.Nil(t, bad.ToECDSA())
+}
+
+func TestSecp256r1Amino(t *testing.T) {
+	priv := secp256r1.GenPrivKey()
+	pub := priv.PubKey()
+
+	var pub2 crypto.PubKey
+	require.NoError(t, amino.Unmarshal(pub.Bytes(), &pub2))
+	assert.Equal(t, pub, pub2)
+	var priv2 crypto.PrivKey
+	require.NoError(t, amino.Unmarshal(priv.Bytes(), &priv2))
+	assert.Equal(t, priv, priv2)
+
+	// the same bytes as a secp256k1 key do not decode to the same key.
+	r1 := pub.(secp256r1.PubKeySecp256r1)
+	k1 := secp256k1.PubKeySecp256k1(r1)
+	assert.NotEqual(t, pub.Bytes(), k1.Bytes())
+	assert.False(t, pub.Equals(k1))
+	assert.NotEqual(t, pub.String(), k1.String())
+}
+
+func TestGenPrivKeySecp256r1(t *testing.T) {
+	a := secp256r1.GenPrivKeySecp256r1([]byte("secret"))
+	b := secp256r1.GenPrivKeySecp256r1([]byte("secret"))
+	c := secp256r1.GenPrivKeySecp256r1([]byte("other secret"))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.True(t, a.Equals(b))
+	assert.False(t, a.Equals(c))
+	d := new(big.Int).SetBytes(a[:])
+	assert.True(t, d.Sign() > 0 && d.Cmp(elliptic.P256().Params().N) < 0)
+}
@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+)
+
+//----------------------------------------
+// SigCache
+
+// SigCache is a LRU cache of signature verification outcomes, so that the
+// same (pubkey, message, signature) triple is verified once, e.g. when a
+// transaction goes through CheckTx, RecheckTx, and DeliverTx. Entries are
+// keyed by a hash of all three inputs, so that a cached outcome can only be
+// returned for the exact same inputs. A nil *SigCache verifies every
+// signature. It is safe for concurrent use.
+type SigCache struct {
+	mtx     sync.Mutex
+	size    int
+	entries map[[sha256.Size]byte]*list.Element
+	queue   *list.List // of *sigCacheEntry, least recently used first
+}
+
+type sigCacheEntry struct {
+	key   [sha256.Size]byte
+	valid bool
+}
+
+// NewSigCache returns a SigCache of at most size entries, or nil (no
+// caching) if size is not positive.
+func NewSigCache(size int) *SigCache {
+	if size <= 0 {
+		return nil
+	}
+	return &SigCache{
+		size:    size,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		queue:   list.New(),
+	}
+}
+
+// VerifyBytes returns pubKey.VerifyBytes(msg, sig), from the cache if the
+// triple was verified before.
+func (sc *SigCache) VerifyBytes(pubKey PubKey, msg []byte, sig []byte) bool {
+	if sc == nil {
+		return pubKey.VerifyBytes(msg, sig)
+	}
+	key := sigCacheKey(pubKey, msg, sig)
+	if valid, ok := sc.get(key); ok {
+		return valid
+	}
+	valid := pubKey.VerifyBytes(msg, sig)
+	sc.add(key, valid)
+	return valid
+}
+
+// Get returns the cached outcome of verifying the triple, if any.
+func (sc *SigCache) Get(pubKey PubKey, msg []byte, sig []byte) (valid bool, ok bool) {
+	if sc == nil {
+		return false, false
+	}
+	return sc.get(sigCacheKey(pubKey, msg, sig))
+}
+
+// Add caches the outcome of verifying the triple, which must be the result
+// of pubKey.VerifyBytes(msg, sig).
+func (sc *SigCache) Add(pubKey PubKey, msg []byte, sig []byte, valid bool) {
+	if sc == nil {
+		return
+	}
+	sc.add(sigCacheKey(pubKey, msg, sig), valid)
+}
+
+// Len returns the number of cached outcomes.
+func (sc *SigCache) Len() int {
+	if sc == nil {
+		return 0
+	}
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+	return sc.queue.Len()
+}
+
+func (sc *SigCache) get(key [sha256.Size]byte) (valid bool, ok bool) {
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+
+	elem, ok := sc.entries[key]
+	if !ok {
+		return false, false
+	}
+	sc.queue.MoveToBack(elem)
+	return elem.Value.(*sigCacheEntry).valid, true
+}
+
+func (sc *SigCache) add(key [sha256.Size]byte, valid bool) {
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+
+	if elem, ok := sc.entries[key]; ok {
+		sc.queue.MoveToBack(elem)
+		return
+	}
+	sc.entries[key] = sc.queue.PushBack(&sigCacheEntry{key, valid})
+	if sc.queue.Len() > sc.size {
+		oldest := sc.queue.Front()
+		sc.queue.Remove(oldest)
+		delete(sc.entries, oldest.Value.(*sigCacheEntry).key)
+	}
+}
+
+// sigCacheKey hashes the length prefixed encodings of the inputs, so that
+// distinct triples cannot have the same preimage. The pubkey encoding
+// includes its type.
+func sigCacheKey(pubKey PubKey, msg []byte, sig []byte) (key [sha256.Size]byte) {
+	h := sha256.New()
+	var lenBuf [binary.MaxVarintLen64]byte
+	for _, bz := range [][]byte{pubKey.Bytes(), msg, sig} {
+		n := binary.PutUvarint(lenBuf[:], uint64(len(bz)))
+		h.Write(lenBuf[:n])
+		h.Write(bz)
+	}
+	h.Sum(key[:0])
+	return key
+}
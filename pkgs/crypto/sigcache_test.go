@@ -0,0 +1,106 @@
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+)
+
+// countingPubKey counts the signatures it verifies.
+type countingPubKey struct {
+	crypto.PubKey
+	count *int
+}
+
+func (pk countingPubKey) VerifyBytes(msg []byte, sig []byte) bool {
+	*pk.count++
+	return pk.PubKey.VerifyBytes(msg, sig)
+}
+
+func TestSigCache(t *testing.T) {
+	for _, priv := range []crypto.PrivKey{ed25519.GenPrivKey(), secp256k1.GenPrivKey()} {
+		var count int
+		pub := countingPubKey{priv.PubKey(), &count}
+		msg := []byte("hello")
+		sig, err := priv.Sign(msg)
+		require.NoError(t, err)
+
+		cache := crypto.NewSigCache(10)
+		assert.True(t, cache.VerifyBytes(pub, msg, sig))
+		assert.True(t, cache.VerifyBytes(pub, msg, sig))
+		assert.Equal(t, 1, count, "second verification should hit the cache")
+
+		// a mutated signature misses the cache.
+		mutated := append([]byte(nil), sig...)
+		mutated[len(mutated)-1] ^= 0x01
+		_, ok := cache.Get(pub, msg, mutated)
+		assert.False(t, ok)
+		assert.False(t, cache.VerifyBytes(pub, msg, mutated))
+		assert.Equal(t, 2, count)
+		// and so do a mutated message and another key.
+		assert.False(t, cache.VerifyBytes(pub, []byte("hellO"), sig))
+		assert.Equal(t, 3, count)
+		other := countingPubKey{secp256k1.GenPrivKey().PubKey(), &count}
+		assert.False(t, cache.VerifyBytes(other, msg, sig))
+		assert.Equal(t, 4, count)
+
+		// invalid outcomes are cached too.
+		assert.False(t, cache.VerifyBytes(pub, msg, mutated))
+		assert.Equal(t, 4, count)
+		assert.Equal(t, 4, cache.Len())
+	}
+}
+
+func TestSigCacheEviction(t *testing.T) {
+	priv := ed25519.GenPrivKey()
+	var count int
+	pub := countingPubKey{priv.PubKey(), &count}
+	cache := crypto.NewSigCache(2)
+	msgs := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	sigs := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		sigs[i], _ = priv.Sign(msg)
+		cache.Add(pub, msg, sigs[i], true)
+	}
+	assert.Equal(t, 2, cache.Len())
+
+	// the least recently used outcome was evicted.
+	_, ok := cache.Get(pub, msgs[0], sigs[0])
+	assert.False(t, ok)
+	valid, ok := cache.Get(pub, msgs[1], sigs[1])
+	assert.True(t, ok)
+	assert.True(t, valid)
+
+	// msgs[1] was used more recently than msgs[2].
+	cache.Add(pub, msgs[0], sigs[0], true)
+	_, ok = cache.Get(pub, msgs[2], sigs[2])
+	assert.False(t, ok)
+	_, ok = cache.Get(pub, msgs[1], sigs[1])
+	assert.True(t, ok)
+	assert.Equal(t, 0, count)
+}
+
+func TestSigCacheDisabled(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		cache := crypto.NewSigCache(size)
+		require.Nil(t, cache)
+
+		priv := ed25519.GenPrivKey()
+		var count int
+		pub := countingPubKey{priv.PubKey(), &count}
+		msg := []byte("hello")
+		sig, _ := priv.Sign(msg)
+		cache.Add(pub, msg, sig, true)
+		_, ok := cache.Get(pub, msg, sig)
+		assert.False(t, ok)
+		assert.True(t, cache.VerifyBytes(pub, msg, sig))
+		assert.True(t, cache.VerifyBytes(pub, msg, sig))
+		assert.Equal(t, 2, count)
+		assert.Equal(t, 0, cache.Len())
+	}
+}
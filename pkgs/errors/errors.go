@@ -11,21 +11,30 @@ import (
 func Wrap(cause interface{}, format string, args ...interface{}) Error {
 	if causeCmnError, ok := cause.(*cmnError); ok { //nolint:gocritic
 		msg := fmt.Sprintf(format, args...)
-		return causeCmnError.Stacktrace().Trace(1, msg)
+		return causeCmnError.Stacktrace().Trace(1, "%s", msg)
 	} else if cause == nil {
 		return newCmnError(FmtError{format, args}).Stacktrace()
 	} else {
 		// NOTE: causeCmnError is a typed nil here.
 		msg := fmt.Sprintf(format, args...)
-		return newCmnError(cause).Stacktrace().Trace(1, msg)
+		return newCmnError(cause).Stacktrace().Trace(1, "%s", msg)
 	}
 }
 
+// Cause returns the innermost error wrapped by Wrap(), or err if it is not
+// wrapped. The data of wrapped errors which are not errors is not
+// returned.
 func Cause(err error) error {
-	if cerr, ok := err.(*cmnError); ok {
-		return cerr.Data().(error)
-	} else {
-		return err
+	for {
+		cerr, ok := err.(*cmnError)
+		if !ok {
+			return err
+		}
+		cause, ok := cerr.data.(error)
+		if !ok {
+			return err
+		}
+		err = cause
 	}
 }
 
@@ -110,6 +119,13 @@ func (err *cmnError) Trace(offset int, format string, args ...interface{}) Error
 	return err.doTrace(msg, offset)
 }
 
+// Unwrap returns the data of this error if it is an error, e.g. for the
+// standard errors.Is and errors.As, or else nil.
+func (err *cmnError) Unwrap() error {
+	cause, _ := err.data.(error)
+	return cause
+}
+
 // Return the "data" of this error.
 // Data could be used for error handling/switching,
 // or for holding general error/debug information.
@@ -152,6 +168,8 @@ func (err *cmnError) Format(s fmt.State, verb rune) {
 				}
 			}
 			s.Write([]byte("--= /Error =--\n"))
+		} else if s.Flag('+') {
+			err.formatChain(s)
 		} else {
 			// Write msg.
 			s.Write([]byte(fmt.Sprintf("%v", err.data)))
@@ -159,6 +177,31 @@ func (err *cmnError) Format(s fmt.State, verb rune) {
 	}
 }
 
+// formatChain writes the message of the error, the file, line and message
+// of its traces, and its stack trace, followed by those of its data if it
+// is also an Error.
+func (err *cmnError) formatChain(s fmt.State) {
+	fmt.Fprintf(s, "%v", err.data)
+	for _, msgtrace := range err.msgtraces {
+		fmt.Fprintf(s, "\n    %s", msgtrace.String())
+	}
+	if err.stacktrace != nil {
+		s.Write([]byte("\nStack Trace:"))
+		frames := runtime.CallersFrames(err.stacktrace)
+		for {
+			frame, more := frames.Next()
+			fmt.Fprintf(s, "\n    %s\n        %s:%d", frame.Function, frame.File, frame.Line)
+			if !more {
+				break
+			}
+		}
+	}
+	if cause, ok := err.data.(*cmnError); ok {
+		s.Write([]byte("\nCaused by: "))
+		cause.formatChain(s)
+	}
+}
+
 //----------------------------------------
 // stacktrace & msgtraceItem
 
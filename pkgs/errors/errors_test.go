@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	fmt "fmt"
 	"testing"
 
@@ -99,3 +100,35 @@ func TestWrapError(t *testing.T) {
 	var err2 error = Wrap(err1, "another message")
 	assert.Equal(t, err1, err2)
 }
+
+func TestCause(t *testing.T) {
+	cause := stderrors.New("cause")
+	err := Wrap(Wrap(cause, "first"), "second")
+	assert.Equal(t, cause, Cause(err))
+	assert.Equal(t, cause, Cause(cause))
+
+	// nested errors are unwrapped to the innermost.
+	nested := Wrap(NewWithData(Wrap(cause, "inner")), "outer")
+	assert.Equal(t, cause, Cause(nested))
+	assert.True(t, stderrors.Is(nested, cause))
+	assert.True(t, stderrors.Is(fmt.Errorf("std: %w", nested), cause))
+
+	// data which is not an error is not a cause.
+	data := Wrap("something", "message")
+	assert.Equal(t, data, Cause(data))
+	assert.Nil(t, stderrors.Unwrap(data))
+}
+
+func TestFormatChain(t *testing.T) {
+	cause := stderrors.New("cause")
+	err := Wrap(NewWithData(Wrap(cause, "inner %d", 1)), "outer %d", 2)
+
+	// %v is terse.
+	assert.Equal(t, "cause", fmt.Sprintf("%v", err))
+
+	chain := fmt.Sprintf("%+v", err)
+	assert.Regexp(t, `^cause\n`, chain)
+	assert.Regexp(t, `errors/errors_test\.go:[0-9]+ - outer 2\nStack Trace:\n(.*\n)*?    .*errors\.TestFormatChain\n        .*errors/errors_test\.go:[0-9]+\n`, chain)
+	assert.Regexp(t, `\nCaused by: cause\n    .*errors/errors_test\.go:[0-9]+ - inner 1\nStack Trace:\n`, chain)
+	assert.NotContains(t, chain, "--= Error =--")
+}
@@ -0,0 +1,75 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+)
+
+// MultiError is an error which aggregates several errors, e.g. to report all
+// the problems of a batch operation at once.
+type MultiError struct {
+	errs []error
+}
+
+// Join returns an error which aggregates errs, flattening the MultiErrors
+// among them, and skipping the nil ones. It returns nil if there is no
+// error, and the error itself if there is only one.
+func Join(errs ...error) error {
+	var flat []error
+	for _, err := range errs {
+		switch err := err.(type) {
+		case nil:
+			continue
+		case *MultiError:
+			flat = append(flat, err.errs...)
+		default:
+			flat = append(flat, err)
+		}
+	}
+	switch len(flat) {
+	case 0:
+		return nil
+	case 1:
+		return flat[0]
+	default:
+		return &MultiError{errs: flat}
+	}
+}
+
+// Errors returns the aggregated errors.
+func (m *MultiError) Errors() []error {
+	return append([]error(nil), m.errs...)
+}
+
+// Error returns the numbered list of the messages of the errors.
+func (m *MultiError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d errors occurred:", len(m.errs))
+	for i, err := range m.errs {
+		fmt.Fprintf(&sb, "\n  %d. %s", i+1, strings.ReplaceAll(err.Error(), "\n", "\n     "))
+	}
+	return sb.String()
+}
+
+// Is returns whether any of the errors matches target, as with the
+// standard errors.Is.
+func (m *MultiError) Is(target error) bool {
+	for _, err := range m.errs {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors which matches target, as with the
+// standard errors.As.
+func (m *MultiError) As(target interface{}) bool {
+	for _, err := range m.errs {
+		if stderrors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
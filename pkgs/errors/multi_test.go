@@ -0,0 +1,60 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type codeError struct{ code int }
+
+func (e codeError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestJoin(t *testing.T) {
+	err1 := stderrors.New("first")
+	err2 := New("second")
+	err3 := codeError{3}
+
+	// nils are skipped.
+	assert.Nil(t, Join())
+	assert.Nil(t, Join(nil, nil))
+	assert.Equal(t, err1, Join(nil, err1, nil))
+
+	// nested MultiErrors are flattened.
+	err := Join(err1, nil, Join(err2, Join(nil, err3)))
+	multi, ok := err.(*MultiError)
+	if assert.True(t, ok) {
+		assert.Equal(t, []error{err1, err2, err3}, multi.Errors())
+	}
+	assert.Equal(t, "3 errors occurred:\n  1. first\n  2. second\n  3. code 3", err.Error())
+}
+
+func TestMultiErrorMultiline(t *testing.T) {
+	err := Join(stderrors.New("line 1\nline 2"), stderrors.New("other"))
+	assert.Equal(t, "2 errors occurred:\n  1. line 1\n     line 2\n  2. other", err.Error())
+}
+
+func TestMultiErrorIsAs(t *testing.T) {
+	sentinel := stderrors.New("sentinel")
+	err := Join(
+		stderrors.New("first"),
+		Wrap(sentinel, "wrapped"),
+		fmt.Errorf("std wrapped: %w", codeError{3}),
+	)
+	wrapped := fmt.Errorf("batch: %w", err)
+
+	assert.True(t, stderrors.Is(wrapped, sentinel))
+	assert.False(t, stderrors.Is(wrapped, stderrors.New("sentinel")))
+
+	var cerr codeError
+	assert.True(t, stderrors.As(wrapped, &cerr))
+	assert.Equal(t, codeError{3}, cerr)
+
+	var merr *MultiError
+	assert.True(t, stderrors.As(wrapped, &merr))
+	assert.Len(t, merr.Errors(), 3)
+
+	assert.False(t, stderrors.Is(Join(stderrors.New("a"), stderrors.New("b")), sentinel))
+}
@@ -72,6 +72,12 @@ func (tree *MutableTree) String() string {
 	return tree.ndb.String()
 }
 
+// NodeCount returns the number of nodes stored in the database, of all the
+// versions saved and not deleted, e.g. to check that pruning bounds it.
+func (tree *MutableTree) NodeCount() int {
+	return tree.ndb.nodeCount()
+}
+
 // Set sets a key in the working tree. Nil values are not supported.
 func (tree *MutableTree) Set(key, value []byte) bool {
 	orphaned, updated := tree.set(key, value)
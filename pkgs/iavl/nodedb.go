@@ -440,6 +440,15 @@ func (ndb *nodeDB) size() int {
 	return size
 }
 
+// nodeCount returns the number of nodes stored, without decoding them.
+func (ndb *nodeDB) nodeCount() int {
+	count := 0
+	ndb.traversePrefix(nodeKeyFormat.Key(), func(key, value []byte) {
+		count++
+	})
+	return count
+}
+
 func (ndb *nodeDB) traverseNodes(fn func(hash []byte, node *Node)) {
 	nodes := []*Node{}
 
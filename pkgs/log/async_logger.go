@@ -0,0 +1,218 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// DropPolicy is the behavior of an AsyncLogger when its buffer is full.
+type DropPolicy int
+
+const (
+	// DropPolicyBlock blocks the caller until there is room in the buffer.
+	DropPolicyBlock DropPolicy = iota
+	// DropPolicyDropOldest drops the oldest buffered message.
+	DropPolicyDropOldest
+	// DropPolicyDropNewest drops the message being logged.
+	DropPolicyDropNewest
+)
+
+// AsyncLogger is a Logger that passes messages to another one from a
+// goroutine, so that callers do not wait for slow writers.
+type AsyncLogger struct {
+	next  Logger
+	level int32 // LogLevel, accessed atomically
+	queue *asyncQueue
+}
+
+// asyncQueue is the buffer of an AsyncLogger, shared with the derived
+// loggers.
+type asyncQueue struct {
+	policy  DropPolicy
+	records chan asyncRecord
+	done    chan struct{} // closed when the records are drained
+	dropped uint64        // accessed atomically
+
+	closeMtx sync.RWMutex // held for writing to close records
+	closed   bool
+
+	pendingMtx  sync.Mutex
+	pendingCond *sync.Cond
+	pending     int // records not yet logged nor dropped
+}
+
+type asyncRecord struct {
+	logger  Logger
+	level   LogLevel
+	msg     string
+	keyvals []interface{}
+}
+
+var _ Logger = (*AsyncLogger)(nil)
+
+// NewAsyncLogger returns an AsyncLogger which buffers up to bufferSize
+// messages, and passes them in order to next from a single goroutine. When
+// the buffer is full, messages are handled according to policy. The
+// loggers derived with With() share the buffer. Close must be called to
+// stop the goroutine.
+func NewAsyncLogger(next Logger, bufferSize int, policy DropPolicy) *AsyncLogger {
+	if bufferSize <= 0 {
+		panic(fmt.Sprintf("invalid buffer size %d", bufferSize))
+	}
+	switch policy {
+	case DropPolicyBlock, DropPolicyDropOldest, DropPolicyDropNewest:
+	default:
+		panic(fmt.Sprintf("unknown drop policy %d", policy))
+	}
+	q := &asyncQueue{
+		policy:  policy,
+		records: make(chan asyncRecord, bufferSize),
+		done:    make(chan struct{}),
+	}
+	q.pendingCond = sync.NewCond(&q.pendingMtx)
+	go q.run()
+	return &AsyncLogger{
+		next:  next,
+		level: int32(LevelDebug),
+		queue: q,
+	}
+}
+
+func (l *AsyncLogger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+func (l *AsyncLogger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+func (l *AsyncLogger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+}
+
+// With returns a logger which passes the messages to next.With(keyvals...)
+// through the same buffer.
+func (l *AsyncLogger) With(keyvals ...interface{}) Logger {
+	return &AsyncLogger{
+		next:  l.next.With(keyvals...),
+		level: atomic.LoadInt32(&l.level),
+		queue: l.queue,
+	}
+}
+
+// SetLevel sets the level of l, below which messages are dropped before
+// being buffered. It does not change the level of next.
+func (l *AsyncLogger) SetLevel(lvl LogLevel) {
+	atomic.StoreInt32(&l.level, int32(lvl))
+}
+
+// Dropped returns the number of messages dropped because the buffer was
+// full.
+func (l *AsyncLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&l.queue.dropped)
+}
+
+// Flush waits until the messages logged before are passed to next, or
+// dropped.
+func (l *AsyncLogger) Flush() {
+	q := l.queue
+	q.pendingMtx.Lock()
+	for q.pending > 0 {
+		q.pendingCond.Wait()
+	}
+	q.pendingMtx.Unlock()
+}
+
+// Close passes the buffered messages to next, and stops the goroutine.
+// Messages logged after Close are dropped. It is shared with the derived
+// loggers.
+func (l *AsyncLogger) Close() error {
+	q := l.queue
+	q.closeMtx.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.records)
+	}
+	q.closeMtx.Unlock()
+	<-q.done
+	return nil
+}
+
+func (l *AsyncLogger) log(level LogLevel, msg string, keyvals []interface{}) {
+	if level < LogLevel(atomic.LoadInt32(&l.level)) {
+		return
+	}
+	// copy keyvals, which callers may reuse.
+	l.queue.push(asyncRecord{
+		logger:  l.next,
+		level:   level,
+		msg:     msg,
+		keyvals: append([]interface{}(nil), keyvals...),
+	})
+}
+
+// push buffers rec, or drops it per the policy.
+func (q *asyncQueue) push(rec asyncRecord) {
+	q.closeMtx.RLock()
+	defer q.closeMtx.RUnlock()
+	if q.closed {
+		return
+	}
+
+	q.addPending(1)
+	switch q.policy {
+	case DropPolicyBlock:
+		q.records <- rec
+	case DropPolicyDropNewest:
+		select {
+		case q.records <- rec:
+		default:
+			q.drop()
+		}
+	case DropPolicyDropOldest:
+		for {
+			select {
+			case q.records <- rec:
+				return
+			default:
+			}
+			// the goroutine may have taken the oldest one meanwhile.
+			select {
+			case <-q.records:
+				q.drop()
+			default:
+			}
+		}
+	}
+}
+
+func (q *asyncQueue) drop() {
+	atomic.AddUint64(&q.dropped, 1)
+	q.addPending(-1)
+}
+
+func (q *asyncQueue) addPending(delta int) {
+	q.pendingMtx.Lock()
+	q.pending += delta
+	if q.pending == 0 {
+		q.pendingCond.Broadcast()
+	}
+	q.pendingMtx.Unlock()
+}
+
+// run logs the buffered records until the buffer is closed.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for rec := range q.records {
+		switch rec.level {
+		case LevelDebug:
+			rec.logger.Debug(rec.msg, rec.keyvals...)
+		case LevelInfo:
+			rec.logger.Info(rec.msg, rec.keyvals...)
+		default:
+			rec.logger.Error(rec.msg, rec.keyvals...)
+		}
+		q.addPending(-1)
+	}
+}
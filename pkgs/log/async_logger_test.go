@@ -0,0 +1,195 @@
+package log_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gnolang/gno/pkgs/log"
+)
+
+// recordingLogger records the messages, with their keyvals. If started is
+// set, its first message is signaled on started, and blocks until release
+// is closed.
+type recordingLogger struct {
+	mtx     *sync.Mutex
+	msgs    *[]string
+	keyvals []interface{}
+
+	started chan struct{}
+	release chan struct{}
+	once    *sync.Once
+}
+
+func newRecordingLogger(blocking bool) *recordingLogger {
+	l := &recordingLogger{mtx: new(sync.Mutex), msgs: new([]string), once: new(sync.Once)}
+	if blocking {
+		l.started = make(chan struct{})
+		l.release = make(chan struct{})
+	}
+	return l
+}
+
+func (l *recordingLogger) Debug(msg string, keyvals ...interface{}) { l.record(msg, keyvals) }
+func (l *recordingLogger) Info(msg string, keyvals ...interface{})  { l.record(msg, keyvals) }
+func (l *recordingLogger) Error(msg string, keyvals ...interface{}) { l.record(msg, keyvals) }
+func (l *recordingLogger) SetLevel(log.LogLevel)                    {}
+
+func (l *recordingLogger) With(keyvals ...interface{}) log.Logger {
+	l2 := *l
+	l2.keyvals = append(l.keyvals[:len(l.keyvals):len(l.keyvals)], keyvals...)
+	return &l2
+}
+
+func (l *recordingLogger) record(msg string, keyvals []interface{}) {
+	if l.started != nil {
+		l.once.Do(func() {
+			close(l.started)
+			<-l.release
+		})
+	}
+	all := append(l.keyvals[:len(l.keyvals):len(l.keyvals)], keyvals...)
+	if len(all) > 0 {
+		msg += fmt.Sprint(all)
+	}
+	l.mtx.Lock()
+	*l.msgs = append(*l.msgs, msg)
+	l.mtx.Unlock()
+}
+
+func (l *recordingLogger) logged() string {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return strings.Join(*l.msgs, ",")
+}
+
+func TestAsyncLoggerOrder(t *testing.T) {
+	inner := newRecordingLogger(false)
+	logger := log.NewAsyncLogger(inner, 4, log.DropPolicyBlock)
+	defer logger.Close()
+
+	// keyvals are copied, so that callers can reuse them.
+	keyvals := []interface{}{"i", 0}
+	var expected []string
+	for i := 0; i < 100; i++ {
+		keyvals[1] = i
+		logger.Info("msg", keyvals...)
+		expected = append(expected, fmt.Sprintf("msg[i %d]", i))
+	}
+	logger.With("module", "test").Error("derived")
+	expected = append(expected, "derived[module test]")
+
+	logger.Flush()
+	if inner.logged() != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, inner.logged())
+	}
+	if logger.Dropped() != 0 {
+		t.Errorf("expected no dropped messages, got %d", logger.Dropped())
+	}
+}
+
+func TestAsyncLoggerLevel(t *testing.T) {
+	inner := newRecordingLogger(false)
+	logger := log.NewAsyncLogger(inner, 4, log.DropPolicyBlock)
+	defer logger.Close()
+
+	logger.SetLevel(log.LevelInfo)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.With("module", "test").Debug("derived debug")
+	logger.Flush()
+	if inner.logged() != "info" {
+		t.Errorf("expected only info, got %v", inner.logged())
+	}
+}
+
+// fillAsyncLogger returns an AsyncLogger with a buffer of 2, whose inner
+// logger is blocked on message "0", and messages "1" and "2" buffered.
+func fillAsyncLogger(t *testing.T, policy log.DropPolicy) (*log.AsyncLogger, *recordingLogger) {
+	t.Helper()
+	inner := newRecordingLogger(true)
+	logger := log.NewAsyncLogger(inner, 2, policy)
+	logger.Info("0")
+	<-inner.started
+	logger.Info("1")
+	logger.Info("2")
+	return logger, inner
+}
+
+func TestAsyncLoggerDropPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		policy   log.DropPolicy
+		expected string
+	}{
+		{"drop oldest", log.DropPolicyDropOldest, "0,3,4"},
+		{"drop newest", log.DropPolicyDropNewest, "0,1,2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, inner := fillAsyncLogger(t, tc.policy)
+			logger.Info("3")
+			logger.Info("4")
+			if logger.Dropped() != 2 {
+				t.Errorf("expected 2 dropped messages, got %d", logger.Dropped())
+			}
+
+			close(inner.release)
+			logger.Flush()
+			if inner.logged() != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, inner.logged())
+			}
+			logger.Close()
+		})
+	}
+}
+
+func TestAsyncLoggerBlock(t *testing.T) {
+	logger, inner := fillAsyncLogger(t, log.DropPolicyBlock)
+
+	logged := make(chan struct{})
+	go func() {
+		logger.Info("3")
+		close(logged)
+	}()
+	select {
+	case <-logged:
+		t.Fatal("expected Info to block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(inner.release)
+	<-logged
+	logger.Flush()
+	if inner.logged() != "0,1,2,3" {
+		t.Errorf("expected all messages, got %v", inner.logged())
+	}
+	logger.Close()
+}
+
+func TestAsyncLoggerClose(t *testing.T) {
+	logger, inner := fillAsyncLogger(t, log.DropPolicyBlock)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(inner.release)
+	}()
+	// the pending messages are logged before Close returns.
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if inner.logged() != "0,1,2" {
+		t.Errorf("expected pending messages to be logged, got %v", inner.logged())
+	}
+
+	// messages after Close are dropped, and Close can be called again.
+	logger.Info("closed")
+	logger.Flush()
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if inner.logged() != "0,1,2" {
+		t.Errorf("expected no message after Close, got %v", inner.logged())
+	}
+}
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	logKeyCaller = "caller"
+	logKeyStack  = "stack"
+
+	// maxStackDepth is the maximum number of frames of the stack traces of
+	// WithStackTraceOnError.
+	maxStackDepth = 32
+)
+
+// callerLogger is a Logger that annotates messages with their call site,
+// or the stack trace of Error messages.
+type callerLogger struct {
+	next         Logger
+	skip         int
+	stackOnError bool
+}
+
+var _ Logger = (*callerLogger)(nil)
+
+// WithCaller returns a Logger that adds the call site of each message to
+// its keyvals, as "caller" with the file, its directory, and the line,
+// e.g. "sdk/baseapp.go:123". skip is the number of frames to skip, e.g. 1
+// if the returned logger is called through another wrapper.
+func WithCaller(logger Logger, skip int) Logger {
+	return &callerLogger{next: logger, skip: skip}
+}
+
+// WithStackTraceOnError returns a Logger that adds the stack trace of
+// Error messages to their keyvals, as "stack" with the call sites from the
+// innermost, e.g. "sdk/baseapp.go:123 (*BaseApp).Commit <- ...".
+func WithStackTraceOnError(logger Logger) Logger {
+	return &callerLogger{next: logger, stackOnError: true}
+}
+
+func (l *callerLogger) Debug(msg string, keyvals ...interface{}) {
+	if !l.stackOnError {
+		keyvals = append(keyvals, logKeyCaller, caller(l.skip))
+	}
+	l.next.Debug(msg, keyvals...)
+}
+
+func (l *callerLogger) Info(msg string, keyvals ...interface{}) {
+	if !l.stackOnError {
+		keyvals = append(keyvals, logKeyCaller, caller(l.skip))
+	}
+	l.next.Info(msg, keyvals...)
+}
+
+func (l *callerLogger) Error(msg string, keyvals ...interface{}) {
+	if l.stackOnError {
+		keyvals = append(keyvals, logKeyStack, stackTrace(l.skip))
+	} else {
+		keyvals = append(keyvals, logKeyCaller, caller(l.skip))
+	}
+	l.next.Error(msg, keyvals...)
+}
+
+func (l *callerLogger) With(keyvals ...interface{}) Logger {
+	return &callerLogger{
+		next:         l.next.With(keyvals...),
+		skip:         l.skip,
+		stackOnError: l.stackOnError,
+	}
+}
+
+func (l *callerLogger) SetLevel(lvl LogLevel) {
+	l.next.SetLevel(lvl)
+}
+
+// caller returns the call site of the logging method which calls caller,
+// after skipping skip more frames.
+func caller(skip int) string {
+	var pcs [1]uintptr
+	// skip runtime.Callers, caller, and the logging method.
+	if runtime.Callers(skip+3, pcs[:]) == 0 {
+		return "unknown"
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	return shortFileLine(frame)
+}
+
+// stackTrace returns the condensed stack trace from the call site of the
+// logging method which calls stackTrace, after skipping skip more frames.
+func stackTrace(skip int) string {
+	var pcs [maxStackDepth]uintptr
+	// skip runtime.Callers, stackTrace, and the logging method.
+	n := runtime.Callers(skip+3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	var calls []string
+	for {
+		frame, more := frames.Next()
+		if frame.PC != 0 {
+			calls = append(calls, shortFileLine(frame)+" "+shortFunction(frame.Function))
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.Join(calls, " <- ")
+}
+
+// shortFileLine returns "dir/file.go:line" for the frame.
+func shortFileLine(frame runtime.Frame) string {
+	dir, file := filepath.Split(frame.File)
+	return fmt.Sprintf("%s/%s:%d", filepath.Base(dir), file, frame.Line)
+}
+
+// shortFunction returns the function name without its package path, e.g.
+// "(*BaseApp).Commit" for "github.com/gnolang/gno/pkgs/sdk.(*BaseApp).Commit".
+func shortFunction(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+	if i := strings.Index(function, "."); i >= 0 {
+		function = function[i+1:]
+	}
+	return function
+}
@@ -0,0 +1,94 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gnolang/gno/pkgs/log"
+)
+
+// nextLine returns the call site of the line after the one of its caller,
+// as logged by WithCaller.
+func nextLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("log/%s:%d", file[strings.LastIndex(file, "/")+1:], line+1)
+}
+
+func TestWithCaller(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.WithCaller(log.NewJSONLogger(&buf), 0)
+
+	var expected []string
+	expected = append(expected, nextLine())
+	logger.Debug("debug")
+	expected = append(expected, nextLine())
+	logger.Info("info")
+	expected = append(expected, nextLine())
+	logger.With("module", "test").Error("error")
+
+	entries := parseJSONLines(t, &buf)
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if entry["caller"] != expected[i] {
+			t.Errorf("entry %d: expected caller %q, got %v", i, expected[i], entry["caller"])
+		}
+		if _, ok := entry["stack"]; ok {
+			t.Errorf("entry %d: unexpected stack", i)
+		}
+	}
+	if entries[2]["module"] != "test" {
+		t.Errorf("expected module of With(), got %v", entries[2]["module"])
+	}
+}
+
+// logThrough logs through a helper, which WithCaller skips with skip 1.
+func logThrough(logger log.Logger) {
+	logger.Info("info")
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	var buf bytes.Buffer
+	expected := nextLine()
+	logThrough(log.WithCaller(log.NewJSONLogger(&buf), 1))
+
+	entries := parseJSONLines(t, &buf)
+	if entries[0]["caller"] != expected {
+		t.Errorf("expected caller %q, got %v", expected, entries[0]["caller"])
+	}
+}
+
+func TestWithStackTraceOnError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.WithStackTraceOnError(log.NewJSONLogger(&buf))
+
+	logger.Debug("debug")
+	logger.Info("info")
+	expected := nextLine()
+	logger.With("module", "test").Error("error")
+
+	entries := parseJSONLines(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for i, entry := range entries[:2] {
+		if _, ok := entry["stack"]; ok {
+			t.Errorf("entry %d: unexpected stack below Error", i)
+		}
+	}
+	stack, _ := entries[2]["stack"].(string)
+	prefix := expected + " TestWithStackTraceOnError <- "
+	if !strings.HasPrefix(stack, prefix) {
+		t.Errorf("expected stack starting with %q, got %q", prefix, stack)
+	}
+	if !strings.Contains(stack, "testing.go") {
+		t.Errorf("expected stack to contain the test runner, got %q", stack)
+	}
+	if _, ok := entries[2]["caller"]; ok {
+		t.Errorf("unexpected caller")
+	}
+}
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// filter is a Logger that drops the messages below the allowed level. The
+// allowed level can depend on the keyvals of With(), e.g. per module.
+type filter struct {
+	next             Logger
+	allowed          LogLevel
+	initiallyAllowed LogLevel
+	allowedKeyvals   map[keyval]LogLevel
+}
+
+type keyval struct {
+	key   interface{}
+	value interface{}
+}
+
+var _ Logger = (*filter)(nil)
+
+// FilterOption sets an option of a filter.
+type FilterOption func(*filter)
+
+// AllowLevel allows the messages of level lvl and above. It is the default
+// level of the filter, for loggers without keyvals given to
+// AllowLevelWith(). Without it, all messages are allowed.
+func AllowLevel(lvl LogLevel) FilterOption {
+	return func(l *filter) { l.allowed = lvl }
+}
+
+// AllowLevelWith allows the messages of level lvl and above for loggers
+// derived with With(key, value), e.g. AllowLevelWith("module", "sdk/app",
+// LevelDebug).
+func AllowLevelWith(key interface{}, value interface{}, lvl LogLevel) FilterOption {
+	return func(l *filter) { l.allowedKeyvals[keyval{key, value}] = lvl }
+}
+
+// NewFilter returns a Logger that passes the messages allowed by opts to
+// next. The loggers derived from it with With() apply the same options.
+func NewFilter(next Logger, opts ...FilterOption) Logger {
+	l := &filter{
+		next:           next,
+		allowed:        LevelDebug,
+		allowedKeyvals: make(map[keyval]LogLevel),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.initiallyAllowed = l.allowed
+	return l
+}
+
+func (l *filter) Debug(msg string, keyvals ...interface{}) {
+	if l.allowed <= LevelDebug {
+		l.next.Debug(msg, keyvals...)
+	}
+}
+
+func (l *filter) Info(msg string, keyvals ...interface{}) {
+	if l.allowed <= LevelInfo {
+		l.next.Info(msg, keyvals...)
+	}
+}
+
+func (l *filter) Error(msg string, keyvals ...interface{}) {
+	if l.allowed <= LevelError {
+		l.next.Error(msg, keyvals...)
+	}
+}
+
+// With implements Logger. The level of the derived logger is the one of the
+// last of keyvals which matches an AllowLevelWith() option, the level of
+// AllowLevel() if the key matches but not the value, or else the level of
+// l.
+func (l *filter) With(keyvals ...interface{}) Logger {
+	allowed := l.allowed
+	keyMatched := false
+	for i := len(keyvals) - 2; i >= 0; i -= 2 {
+		for kv, lvl := range l.allowedKeyvals {
+			if keyvals[i] != kv.key {
+				continue
+			}
+			keyMatched = true
+			if keyvals[i+1] == kv.value {
+				return l.derive(keyvals, lvl)
+			}
+		}
+	}
+	if keyMatched {
+		allowed = l.initiallyAllowed
+	}
+	return l.derive(keyvals, allowed)
+}
+
+func (l *filter) derive(keyvals []interface{}, allowed LogLevel) *filter {
+	return &filter{
+		next:             l.next.With(keyvals...),
+		allowed:          allowed,
+		initiallyAllowed: l.initiallyAllowed,
+		allowedKeyvals:   l.allowedKeyvals,
+	}
+}
+
+// SetLevel sets the level of l, but not of the loggers derived from it.
+func (l *filter) SetLevel(lvl LogLevel) {
+	l.allowed = lvl
+}
+
+//----------------------------------------
+
+// ParseLogLevel returns a filter of logger for a list of "module:level"
+// pairs, where module "*" sets the default level, e.g.
+// "main:info,sdk/app:debug,*:error". A single level, e.g. "info", sets the
+// default level.
+func ParseLogLevel(lvl string, logger Logger) (Logger, error) {
+	if lvl == "" {
+		return nil, fmt.Errorf("empty log level")
+	}
+	var opts []FilterOption
+	for _, item := range strings.Split(lvl, ",") {
+		module, levelStr := "*", item
+		if i := strings.LastIndex(item, ":"); i >= 0 {
+			module, levelStr = item[:i], item[i+1:]
+		}
+		if module == "" {
+			return nil, fmt.Errorf("invalid log level %q: missing module in %q", lvl, item)
+		}
+		level, err := LevelFromString(levelStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %v", lvl, err)
+		}
+		if module == "*" {
+			opts = append(opts, AllowLevel(level))
+		} else {
+			opts = append(opts, AllowLevelWith("module", module, level))
+		}
+	}
+	return NewFilter(logger, opts...), nil
+}
+
+// LevelFromString returns the level of "debug", "info", or "error".
+func LevelFromString(s string) (LogLevel, error) {
+	switch s {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown level %q, expected debug, info, or error", s)
+	}
+}
@@ -0,0 +1,96 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gnolang/gno/pkgs/log"
+)
+
+// logAll logs a message at each level, and returns the logged messages.
+func logAll(buf *bytes.Buffer, logger log.Logger) []string {
+	buf.Reset()
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Error("error")
+	var msgs []string
+	for _, msg := range []string{"debug", "info", "error"} {
+		if strings.Contains(buf.String(), ".msg "+msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func assertLogged(t *testing.T, name string, got []string, expected ...string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("%s: expected %v to be logged, got %v", name, expected, got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewFilter(log.NewTMLogger(&buf),
+		log.AllowLevel(log.LevelError),
+		log.AllowLevelWith("module", "sdk/app", log.LevelDebug),
+		log.AllowLevelWith("module", "consensus", log.LevelInfo),
+	)
+
+	assertLogged(t, "root", logAll(&buf, logger), "error")
+	assertLogged(t, "sdk/app", logAll(&buf, logger.With("module", "sdk/app")), "debug", "info", "error")
+	assertLogged(t, "consensus", logAll(&buf, logger.With("module", "consensus")), "info", "error")
+	assertLogged(t, "other module", logAll(&buf, logger.With("module", "p2p")), "error")
+	assertLogged(t, "other key", logAll(&buf, logger.With("height", 1)), "error")
+
+	// the last matching keyvals determine the level.
+	assertLogged(t, "both", logAll(&buf, logger.With("module", "consensus", "module", "sdk/app")), "debug", "info", "error")
+
+	// derived loggers inherit the level, unless overridden.
+	app := logger.With("module", "sdk/app")
+	assertLogged(t, "nested", logAll(&buf, app.With("height", 1).With("tx", "abc")), "debug", "info", "error")
+	assertLogged(t, "nested consensus", logAll(&buf, app.With("module", "consensus")), "info", "error")
+	assertLogged(t, "nested other", logAll(&buf, app.With("module", "p2p")), "error")
+
+	// the keyvals are still logged.
+	buf.Reset()
+	app.With("height", 7).Info("hello", "tx", "abc")
+	for _, s := range []string{"hello", "sdk/app", "height 7", "tx abc"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in %q", s, buf.String())
+		}
+	}
+}
+
+func TestFilterSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewFilter(log.NewTMLogger(&buf))
+	assertLogged(t, "default", logAll(&buf, logger), "debug", "info", "error")
+	logger.SetLevel(log.LevelInfo)
+	assertLogged(t, "info", logAll(&buf, logger), "info", "error")
+}
+
+func TestParseLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := log.ParseLogLevel("main:info,sdk/app:debug,*:error", log.NewTMLogger(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertLogged(t, "main", logAll(&buf, logger.With("module", "main")), "info", "error")
+	assertLogged(t, "sdk/app", logAll(&buf, logger.With("module", "sdk/app")), "debug", "info", "error")
+	assertLogged(t, "other", logAll(&buf, logger.With("module", "state")), "error")
+	assertLogged(t, "root", logAll(&buf, logger), "error")
+
+	logger, err = log.ParseLogLevel("info", log.NewTMLogger(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertLogged(t, "single level", logAll(&buf, logger.With("module", "state")), "info", "error")
+
+	for _, lvl := range []string{"", "main:verbose", ":info", "main:info,", "main"} {
+		if _, err := log.ParseLogLevel(lvl, log.NewNopLogger()); err == nil {
+			t.Errorf("expected an error for %q", lvl)
+		}
+	}
+}
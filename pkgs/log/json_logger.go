@@ -0,0 +1,152 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	jsonKeyTime  = "ts"
+	jsonKeyLevel = "level"
+	jsonKeyMsg   = "msg"
+)
+
+// jsonLogger is a Logger that writes each message as a JSON object on its
+// own line, e.g. for log aggregation.
+type jsonLogger struct {
+	level   LogLevel
+	writer  io.Writer
+	keyvals []interface{}
+}
+
+var _ Logger = (*jsonLogger)(nil)
+
+// NewJSONLogger returns a Logger that writes each message to w as a JSON
+// object, with the time ("ts", RFC3339Nano), the level, the message, and
+// the keyvals of With() and of the call. Values are written as JSON if
+// possible, and else formatted with %v. Each message is written in a single
+// call to w, so w can be shared when wrapped with NewSyncWriter.
+func NewJSONLogger(w io.Writer) *jsonLogger {
+	return &jsonLogger{
+		level:  LevelDebug,
+		writer: w,
+	}
+}
+
+func (l *jsonLogger) SetLevel(lvl LogLevel) {
+	l.level = lvl
+}
+
+// Debug logs a message at level Debug.
+func (l *jsonLogger) Debug(msg string, keyvals ...interface{}) {
+	if l.level <= LevelDebug {
+		l.write(LevelDebug, msg, keyvals)
+	}
+}
+
+// Info logs a message at level Info.
+func (l *jsonLogger) Info(msg string, keyvals ...interface{}) {
+	if l.level <= LevelInfo {
+		l.write(LevelInfo, msg, keyvals)
+	}
+}
+
+// Error logs a message at level Error.
+func (l *jsonLogger) Error(msg string, keyvals ...interface{}) {
+	if l.level <= LevelError {
+		l.write(LevelError, msg, keyvals)
+	}
+}
+
+// With returns a new contextual logger with keyvals added to those passed
+// to calls to Info, Debug or Error.
+func (l *jsonLogger) With(keyvals ...interface{}) Logger {
+	return &jsonLogger{
+		level:   l.level,
+		writer:  l.writer,
+		keyvals: append(l.keyvals[:len(l.keyvals):len(l.keyvals)], keyvals...),
+	}
+}
+
+func (l *jsonLogger) write(level LogLevel, msg string, keyvals []interface{}) {
+	fields := map[string]interface{}{
+		jsonKeyTime:  time.Now().UTC().Format(time.RFC3339Nano),
+		jsonKeyLevel: levelName(level),
+		jsonKeyMsg:   msg,
+	}
+	addJSONFields(fields, l.keyvals)
+	addJSONFields(fields, keyvals)
+
+	// all values are marshalable.
+	bz, err := json.Marshal(fields)
+	if err != nil {
+		panic(fmt.Sprintf("unexpected error marshaling log fields: %v", err))
+	}
+	l.writer.Write(append(bz, '\n'))
+}
+
+// addJSONFields adds keyvals to fields. Keys are formatted with %v, and the
+// keys of the fields of every message are prefixed with "_" so that they
+// are not overwritten. A missing last value is "(MISSING)".
+func addJSONFields(fields map[string]interface{}, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		key := jsonValueString(keyvals[i])
+		switch key {
+		case jsonKeyTime, jsonKeyLevel, jsonKeyMsg:
+			key = "_" + key
+		}
+		if i+1 == len(keyvals) {
+			fields[key] = "(MISSING)"
+		} else {
+			fields[key] = jsonValue(keyvals[i+1])
+		}
+	}
+}
+
+// jsonValue returns value as a json.RawMessage, or if it cannot be
+// marshaled, formatted with %v. Errors are written as their message, and
+// fmt.Stringers as their string, unless they are json.Marshalers.
+func jsonValue(value interface{}) (res interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = fmt.Sprintf("(PANIC=%v)", r)
+		}
+	}()
+
+	switch v := value.(type) {
+	case json.Marshaler:
+		// below
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	bz, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return json.RawMessage(bz)
+}
+
+// jsonValueString returns value as a string, formatted with %v.
+func jsonValueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func levelName(level LogLevel) string {
+	switch level {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", level)
+	}
+}
@@ -0,0 +1,142 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gnolang/gno/pkgs/log"
+)
+
+type badMarshaler struct{}
+
+func (badMarshaler) MarshalJSON() ([]byte, error) { return nil, errors.New("cannot marshal") }
+func (badMarshaler) String() string               { return "bad marshaler" }
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("boom") }
+
+func parseJSONLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewJSONLogger(&buf)
+	logger.With("module", "sdk/app").With("height", 7).Info("committed", "hash", "ABCD", "txs", 3)
+	logger.Debug("debug", "err", errors.New("failure"), "dur", time.Second)
+	logger.Error("odd", "key")
+
+	entries := parseJSONLines(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(entries))
+	}
+	expected := []map[string]interface{}{
+		{"level": "info", "msg": "committed", "module": "sdk/app", "height": 7.0, "hash": "ABCD", "txs": 3.0},
+		{"level": "debug", "msg": "debug", "err": "failure", "dur": "1s"},
+		{"level": "error", "msg": "odd", "key": "(MISSING)"},
+	}
+	for i, entry := range entries {
+		ts, ok := entry["ts"].(string)
+		if !ok {
+			t.Errorf("line %d: missing ts", i)
+		} else if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+			t.Errorf("line %d: invalid ts %q: %v", i, ts, err)
+		}
+		delete(entry, "ts")
+		if !jsonEqual(entry, expected[i]) {
+			t.Errorf("line %d: expected %v, got %v", i, expected[i], entry)
+		}
+	}
+}
+
+func TestJSONLoggerValues(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewJSONLogger(&buf)
+	logger.Info("values",
+		"chan", make(chan int),
+		"inf", math.Inf(1),
+		"marshaler", badMarshaler{},
+		"stringer", panicStringer{},
+		"bytes", []byte("hi"),
+		"nil", nil,
+		"struct", struct{ A int }{1},
+		1, "non-string key",
+		"msg", "not the message",
+	)
+
+	entry := parseJSONLines(t, &buf)[0]
+	if entry["msg"] != "values" || entry["_msg"] != "not the message" {
+		t.Errorf("reserved keys must not be overwritten: %v", entry)
+	}
+	if entry["inf"] != "+Inf" {
+		t.Errorf("expected +Inf, got %v", entry["inf"])
+	}
+	if entry["marshaler"] != "bad marshaler" {
+		t.Errorf("expected fallback to %%v, got %v", entry["marshaler"])
+	}
+	if s, _ := entry["stringer"].(string); !strings.Contains(s, "PANIC") {
+		t.Errorf("expected a panic to be rendered, got %v", entry["stringer"])
+	}
+	if s, _ := entry["chan"].(string); !strings.HasPrefix(s, "0x") {
+		t.Errorf("expected a pointer, got %v", entry["chan"])
+	}
+	if entry["bytes"] != "aGk=" || entry["nil"] != nil || entry["1"] != "non-string key" {
+		t.Errorf("unexpected values: %v", entry)
+	}
+	if !jsonEqual(entry["struct"], map[string]interface{}{"A": 1.0}) {
+		t.Errorf("unexpected struct: %v", entry["struct"])
+	}
+}
+
+func TestJSONLoggerLevelAndSync(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewJSONLogger(log.NewSyncWriter(&buf))
+	logger.SetLevel(log.LevelInfo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := logger.With("goroutine", i)
+			for j := 0; j < 10; j++ {
+				child.Debug("dropped")
+				child.Info("kept", "j", j, "padding", strings.Repeat("x", 1000))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// lines are not interleaved.
+	entries := parseJSONLines(t, &buf)
+	if len(entries) != 100 {
+		t.Fatalf("expected 100 lines, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry["msg"] != "kept" {
+			t.Errorf("unexpected line %v", entry)
+		}
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	abz, _ := json.Marshal(a)
+	bbz, _ := json.Marshal(b)
+	return bytes.Equal(abz, bbz)
+}
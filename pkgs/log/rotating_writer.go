@@ -0,0 +1,290 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// rotatingWriter is an io.WriteCloser to a file which is rotated when it
+// gets too large.
+type rotatingWriter struct {
+	mtx        sync.Mutex
+	path       string
+	maxSize    int64         // in bytes, or 0 for no limit
+	maxBackups int           // or 0 for no limit
+	maxAge     time.Duration // or 0 for no limit
+	compress   bool
+	now        func() time.Time
+
+	file     *os.File
+	fileInfo os.FileInfo // of file when opened, to detect deletions
+	size     int64
+}
+
+var _ io.WriteCloser = (*rotatingWriter)(nil)
+
+// NewRotatingWriter returns a writer which appends to the file at path,
+// creating it if needed. Before a write would make the file larger than
+// maxSizeMB megabytes, the file is renamed to a backup named after its
+// rotation time, e.g. "node-2006-01-02T15-04-05.000.log" for "node.log",
+// and a new file is created at path. Backups are gzip-compressed if
+// compress is set, and the oldest ones are removed to keep at most
+// maxBackups of them, none older than maxAgeDays. A zero value means no
+// limit. If the file is removed or renamed by another process, it is
+// created again on the next write.
+//
+// The writer is safe for concurrent use, e.g. wrapped with NewSyncWriter
+// for a logger.
+func NewRotatingWriter(path string, maxSizeMB, maxBackups, maxAgeDays int, compress bool) (io.WriteCloser, error) {
+	if maxSizeMB < 0 || maxBackups < 0 || maxAgeDays < 0 {
+		return nil, fmt.Errorf("invalid rotation limits: size %d MB, %d backups, %d days",
+			maxSizeMB, maxBackups, maxAgeDays)
+	}
+	return newRotatingWriter(path, int64(maxSizeMB)*1024*1024, maxBackups,
+		time.Duration(maxAgeDays)*24*time.Hour, compress)
+}
+
+func newRotatingWriter(path string, maxSize int64, maxBackups int, maxAge time.Duration, compress bool) (*rotatingWriter, error) {
+	w := &rotatingWriter{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		maxAge:     maxAge,
+		compress:   compress,
+		now:        time.Now,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write implements io.Writer. A single write larger than the maximum size
+// is written to a new file.
+func (w *rotatingWriter) Write(p []byte) (n int, err error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.file == nil || w.removed() {
+		if err := w.reopen(); err != nil {
+			return 0, err
+		}
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close implements io.Closer.
+func (w *rotatingWriter) Close() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens or creates the file at path for appending.
+func (w *rotatingWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.fileInfo, w.size = file, info, info.Size()
+	return nil
+}
+
+// reopen closes the file if open, and opens the file at path again.
+func (w *rotatingWriter) reopen() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	return w.open()
+}
+
+// removed returns whether the open file is no longer the one at path.
+func (w *rotatingWriter) removed() bool {
+	info, err := os.Stat(w.path)
+	return err != nil || !os.SameFile(info, w.fileInfo)
+}
+
+// rotate renames the file to a backup, opens a new file, and then
+// compresses and prunes the backups.
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	backup, err := w.backupPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(w.path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	// the new file is open, so that failures below do not lose logs.
+	if w.compress {
+		if err := compressFile(backup); err != nil {
+			return err
+		}
+	}
+	return w.prune()
+}
+
+// backupPath returns the backup path for the current time, or if not
+// after the time of the newest backup, for a millisecond later, so that
+// backups sort by time.
+func (w *rotatingWriter) backupPath() (string, error) {
+	backups, err := w.backups()
+	if err != nil {
+		return "", err
+	}
+	t := w.now().UTC().Truncate(time.Millisecond)
+	if len(backups) > 0 && !t.After(backups[0].time) {
+		t = backups[0].time.Add(time.Millisecond)
+	}
+	dir, prefix, ext := w.backupParts()
+	return filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext), nil
+}
+
+// backupParts returns the directory, the name prefix, and the extension
+// of backups, e.g. "dir", "node-", and ".log" for "dir/node.log".
+func (w *rotatingWriter) backupParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.path)
+	name := filepath.Base(w.path)
+	ext = filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// prune removes the backups beyond maxBackups, and those older than
+// maxAge.
+func (w *rotatingWriter) prune() error {
+	if w.maxBackups == 0 && w.maxAge == 0 {
+		return nil
+	}
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+	cutoff := w.now().Add(-w.maxAge)
+	for i, backup := range backups {
+		if (w.maxBackups > 0 && i >= w.maxBackups) ||
+			(w.maxAge > 0 && backup.time.Before(cutoff)) {
+			if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backups returns the backups, newest first.
+func (w *rotatingWriter) backups() ([]backupFile, error) {
+	dir, prefix, ext := w.backupParts()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backupFile
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, compressSuffix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(ts, ext))
+		if err != nil {
+			continue // not a backup
+		}
+		backups = append(backups, backupFile{filepath.Join(dir, name), t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// compressFile replaces the file at path with a gzip-compressed one.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // the file was removed before rotation
+		}
+		return err
+	}
+	defer src.Close()
+
+	// write to a temporary file, so that only complete files have the
+	// backup name.
+	tmp := path + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(tmp)
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path+compressSuffix); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
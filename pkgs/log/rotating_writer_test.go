@@ -0,0 +1,168 @@
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRotatingWriter returns a rotatingWriter with a stopped clock, so
+// that backups are named after the same time, plus a millisecond each.
+func newTestRotatingWriter(t *testing.T, maxSize int64, maxBackups int, maxAge time.Duration, compress bool) (*rotatingWriter, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	w, err := newRotatingWriter(filepath.Join(dir, "node.log"), maxSize, maxBackups, maxAge, compress)
+	require.NoError(t, err)
+	t.Cleanup(func() { w.Close() })
+	w.now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return w, dir
+}
+
+func dirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	bz, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(bz)
+}
+
+func TestRotatingWriterRotation(t *testing.T) {
+	w, dir := newTestRotatingWriter(t, 10, 0, 0, false)
+
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "this line is too long\n", "line 5\n"} {
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	// a line too long is written alone.
+	assert.Equal(t, []string{
+		"node-2020-01-02T03-04-05.000.log",
+		"node-2020-01-02T03-04-05.001.log",
+		"node-2020-01-02T03-04-05.002.log",
+		"node-2020-01-02T03-04-05.003.log",
+		"node.log",
+	}, dirFiles(t, dir))
+	for name, content := range map[string]string{
+		"node-2020-01-02T03-04-05.000.log": "line 1\n",
+		"node-2020-01-02T03-04-05.001.log": "line 2\n",
+		"node-2020-01-02T03-04-05.002.log": "line 3\n",
+		"node-2020-01-02T03-04-05.003.log": "this line is too long\n",
+		"node.log":                         "line 5\n",
+	} {
+		assert.Equal(t, content, readFile(t, filepath.Join(dir, name)), name)
+	}
+}
+
+func TestRotatingWriterAppends(t *testing.T) {
+	w, dir := newTestRotatingWriter(t, 10, 0, 0, false)
+	_, err := w.Write([]byte("line 1\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// the size of the existing file counts.
+	w, err = newRotatingWriter(filepath.Join(dir, "node.log"), 10, 0, 0, false)
+	require.NoError(t, err)
+	defer w.Close()
+	_, err = w.Write([]byte("line 2\n"))
+	require.NoError(t, err)
+	assert.Len(t, dirFiles(t, dir), 2)
+	assert.Equal(t, "line 2\n", readFile(t, filepath.Join(dir, "node.log")))
+}
+
+func TestRotatingWriterPruning(t *testing.T) {
+	w, dir := newTestRotatingWriter(t, 1, 2, time.Hour, false)
+
+	// old backups, and files which are not backups.
+	for _, name := range []string{
+		"node-2020-01-02T01-00-00.000.log",
+		"node-2020-01-02T01-00-00.000.log.gz",
+		"node-notatime.log",
+		"other.log",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("old"), 0644))
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := w.Write([]byte("x"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{
+		"node-2020-01-02T03-04-05.002.log",
+		"node-2020-01-02T03-04-05.003.log",
+		"node-notatime.log",
+		"node.log",
+		"other.log",
+	}, dirFiles(t, dir))
+}
+
+func TestRotatingWriterCompress(t *testing.T) {
+	w, dir := newTestRotatingWriter(t, 10, 0, 0, true)
+	for _, line := range []string{"line 1\n", "line 2\n"} {
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"node-2020-01-02T03-04-05.000.log.gz", "node.log"}, dirFiles(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "node-2020-01-02T03-04-05.000.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	bz, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\n", string(bz))
+}
+
+func TestRotatingWriterExternalDelete(t *testing.T) {
+	w, dir := newTestRotatingWriter(t, 100, 0, 0, false)
+	path := filepath.Join(dir, "node.log")
+	_, err := w.Write([]byte("line 1\n"))
+	require.NoError(t, err)
+
+	// the file is created again after a delete.
+	require.NoError(t, os.Remove(path))
+	_, err = w.Write([]byte("line 2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "line 2\n", readFile(t, path))
+
+	// and after an external rotation.
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "moved.log")))
+	_, err = w.Write([]byte("line 3\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "line 3\n", readFile(t, path))
+	assert.Equal(t, "line 2\n", readFile(t, filepath.Join(dir, "moved.log")))
+}
+
+func TestNewRotatingWriterLogger(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(filepath.Join(dir, "logs", "node.log"), 1, 3, 7, true)
+	require.NoError(t, err)
+	logger := NewTMLogger(NewSyncWriter(w))
+	logger.Info("hello", "key", "value")
+	require.NoError(t, w.Close())
+	assert.True(t, strings.Contains(readFile(t, filepath.Join(dir, "logs", "node.log")), "hello"))
+
+	_, err = NewRotatingWriter(filepath.Join(dir, "node.log"), -1, 0, 0, false)
+	assert.Error(t, err)
+}
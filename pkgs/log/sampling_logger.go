@@ -0,0 +1,204 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSamplingWindow = time.Second
+	defaultSamplingFirst  = 10
+
+	suppressedMsg = "Suppressed duplicate log messages"
+)
+
+// SamplingOptions are the options of NewSamplingLogger.
+type SamplingOptions struct {
+	// Window is the duration over which messages are counted, or 0 for one
+	// second.
+	Window time.Duration
+	// First is the number of messages of the same key passed per window, or
+	// 0 for 10.
+	First int
+	// Key returns the key by which messages are counted, or if nil, the
+	// level and the message.
+	Key func(level LogLevel, msg string, keyvals []interface{}) string
+	// SampleErrors enables the suppression of Error messages, which are
+	// always passed otherwise.
+	SampleErrors bool
+	// Now returns the current time, or if nil, time.Now.
+	Now func() time.Time
+}
+
+// samplingLogger is a Logger that suppresses the messages of the same key
+// beyond the first ones of a window.
+type samplingLogger struct {
+	next  Logger
+	state *samplingState // shared with the derived loggers
+}
+
+type samplingState struct {
+	opts SamplingOptions
+
+	mtx         sync.Mutex
+	windowStart time.Time
+	counts      map[string]*sampleCount
+}
+
+type sampleCount struct {
+	logger     Logger // the last logger suppressing the message
+	level      LogLevel
+	msg        string
+	count      int
+	suppressed int
+}
+
+var _ Logger = (*samplingLogger)(nil)
+
+// NewSamplingLogger returns a Logger that passes to next the first
+// opts.First messages of each key per opts.Window, e.g. so that an error
+// path hit in a tight loop does not flood the logs. The messages beyond are
+// suppressed, and their count is logged once the window is over, on the
+// next message, as "Suppressed duplicate log messages" with the level
+// ("suppressed_level"), the message ("suppressed_msg"), and the count
+// ("suppressed"). The loggers derived with With() share the counts.
+func NewSamplingLogger(next Logger, opts SamplingOptions) Logger {
+	if opts.Window < 0 || opts.First < 0 {
+		panic(fmt.Sprintf("invalid sampling options: window %v, first %d", opts.Window, opts.First))
+	}
+	if opts.Window == 0 {
+		opts.Window = defaultSamplingWindow
+	}
+	if opts.First == 0 {
+		opts.First = defaultSamplingFirst
+	}
+	if opts.Key == nil {
+		opts.Key = levelMsgKey
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &samplingLogger{
+		next: next,
+		state: &samplingState{
+			opts:   opts,
+			counts: make(map[string]*sampleCount),
+		},
+	}
+}
+
+func levelMsgKey(level LogLevel, msg string, _ []interface{}) string {
+	return fmt.Sprintf("%d:%s", level, msg)
+}
+
+func (l *samplingLogger) Debug(msg string, keyvals ...interface{}) {
+	if l.sample(LevelDebug, msg, keyvals) {
+		l.next.Debug(msg, keyvals...)
+	}
+}
+
+func (l *samplingLogger) Info(msg string, keyvals ...interface{}) {
+	if l.sample(LevelInfo, msg, keyvals) {
+		l.next.Info(msg, keyvals...)
+	}
+}
+
+func (l *samplingLogger) Error(msg string, keyvals ...interface{}) {
+	if !l.state.opts.SampleErrors {
+		l.state.flush()
+		l.next.Error(msg, keyvals...)
+		return
+	}
+	if l.sample(LevelError, msg, keyvals) {
+		l.next.Error(msg, keyvals...)
+	}
+}
+
+func (l *samplingLogger) With(keyvals ...interface{}) Logger {
+	return &samplingLogger{
+		next:  l.next.With(keyvals...),
+		state: l.state,
+	}
+}
+
+func (l *samplingLogger) SetLevel(lvl LogLevel) {
+	l.next.SetLevel(lvl)
+}
+
+// sample counts the message, and returns whether to pass it.
+func (l *samplingLogger) sample(level LogLevel, msg string, keyvals []interface{}) bool {
+	s := l.state
+	key := s.opts.Key(level, msg, keyvals)
+
+	s.mtx.Lock()
+	summaries := s.rollWindow()
+	c, ok := s.counts[key]
+	if !ok {
+		c = &sampleCount{level: level, msg: msg}
+		s.counts[key] = c
+	}
+	c.count++
+	pass := c.count <= s.opts.First
+	if !pass {
+		c.suppressed++
+		c.logger = l.next
+	}
+	s.mtx.Unlock()
+
+	logSummaries(summaries)
+	return pass
+}
+
+// flush logs the summaries of the windows which are over.
+func (s *samplingState) flush() {
+	s.mtx.Lock()
+	summaries := s.rollWindow()
+	s.mtx.Unlock()
+
+	logSummaries(summaries)
+}
+
+// rollWindow starts a new window if the current one is over, and returns
+// the counts of the suppressed messages of the previous one. The caller
+// must hold s.mtx.
+func (s *samplingState) rollWindow() []*sampleCount {
+	now := s.opts.Now()
+	if now.Before(s.windowStart.Add(s.opts.Window)) {
+		return nil
+	}
+	var summaries []*sampleCount
+	for _, c := range s.counts {
+		if c.suppressed > 0 {
+			summaries = append(summaries, c)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].level != summaries[j].level {
+			return summaries[i].level < summaries[j].level
+		}
+		return summaries[i].msg < summaries[j].msg
+	})
+	s.windowStart = now
+	s.counts = make(map[string]*sampleCount)
+	return summaries
+}
+
+func logSummaries(summaries []*sampleCount) {
+	for _, c := range summaries {
+		keyvals := []interface{}{
+			"suppressed_level", levelName(c.level),
+			"suppressed_msg", c.msg,
+			"suppressed", c.suppressed,
+		}
+		switch c.level {
+		case LevelDebug:
+			c.logger.Debug(suppressedMsg, keyvals...)
+		case LevelInfo:
+			c.logger.Info(suppressedMsg, keyvals...)
+		default:
+			c.logger.Error(suppressedMsg, keyvals...)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gnolang/gno/pkgs/log"
+)
+
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time { return c.t }
+
+// msgs returns the messages of the logged entries.
+func msgs(entries []map[string]interface{}) []string {
+	var res []string
+	for _, entry := range entries {
+		res = append(res, fmt.Sprint(entry["msg"]))
+	}
+	return res
+}
+
+func TestSamplingLogger(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &testClock{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	logger := log.NewSamplingLogger(log.NewJSONLogger(&buf), log.SamplingOptions{
+		Window: time.Second,
+		First:  3,
+		Now:    clock.now,
+	})
+
+	for i := 0; i < 100; i++ {
+		logger.Info("loop", "i", i)
+		// distinct messages are unaffected.
+		if i%25 == 0 {
+			logger.Info(fmt.Sprintf("distinct %d", i))
+		}
+	}
+	// the same message at another level is distinct.
+	logger.With("module", "test").Debug("loop")
+
+	entries := parseJSONLines(t, &buf)
+	assertLogged(t, "window", msgs(entries),
+		"loop", "distinct 0", "loop", "loop", "distinct 25", "distinct 50", "distinct 75", "loop")
+	for i, j := range []int{0, 2, 3} {
+		if entries[j]["i"] != float64(i) {
+			t.Errorf("expected the first messages to be passed, got %v", entries[j])
+		}
+	}
+
+	// the summary is logged with the next message after the window.
+	buf.Reset()
+	clock.t = clock.t.Add(time.Second)
+	logger.Info("loop")
+	entries = parseJSONLines(t, &buf)
+	assertLogged(t, "next window", msgs(entries), "Suppressed duplicate log messages", "loop")
+	summary := entries[0]
+	if summary["level"] != "info" || summary["suppressed_level"] != "info" ||
+		summary["suppressed_msg"] != "loop" || summary["suppressed"] != float64(97) {
+		t.Errorf("unexpected summary %v", summary)
+	}
+
+	// nothing was suppressed in the last window.
+	buf.Reset()
+	clock.t = clock.t.Add(time.Second)
+	logger.Info("loop")
+	assertLogged(t, "quiet window", msgs(parseJSONLines(t, &buf)), "loop")
+}
+
+func TestSamplingLoggerErrors(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &testClock{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	opts := log.SamplingOptions{First: 1, Now: clock.now}
+
+	// errors are not suppressed by default.
+	logger := log.NewSamplingLogger(log.NewJSONLogger(&buf), opts)
+	for i := 0; i < 5; i++ {
+		logger.Error("failed")
+	}
+	if entries := parseJSONLines(t, &buf); len(entries) != 5 {
+		t.Errorf("expected 5 errors, got %d", len(entries))
+	}
+
+	buf.Reset()
+	opts.SampleErrors = true
+	logger = log.NewSamplingLogger(log.NewJSONLogger(&buf), opts)
+	for i := 0; i < 5; i++ {
+		logger.Error("failed")
+	}
+	clock.t = clock.t.Add(time.Minute)
+	logger.Error("failed")
+	entries := parseJSONLines(t, &buf)
+	assertLogged(t, "sampled errors", msgs(entries), "failed", "Suppressed duplicate log messages", "failed")
+	if entries[1]["level"] != "error" || entries[1]["suppressed"] != float64(4) {
+		t.Errorf("unexpected summary %v", entries[1])
+	}
+}
+
+func TestSamplingLoggerKey(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewSamplingLogger(log.NewJSONLogger(&buf), log.SamplingOptions{
+		First: 1,
+		// count by message only, regardless of the level.
+		Key: func(_ log.LogLevel, msg string, _ []interface{}) string { return msg },
+	})
+
+	logger.Debug("msg")
+	logger.Info("msg")
+	logger.Info("other")
+	assertLogged(t, "key", msgs(parseJSONLines(t, &buf)), "msg", "other")
+}
@@ -0,0 +1,79 @@
+//go:build zap
+// +build zap
+
+package log
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// zapAdapter is a Logger that writes to a zap.Logger.
+type zapAdapter struct {
+	level  LogLevel
+	logger *zap.Logger
+}
+
+var _ Logger = (*zapAdapter)(nil)
+
+// NewZapAdapter returns a Logger that writes to logger, at zap.DebugLevel,
+// zap.InfoLevel, and zap.ErrorLevel for Debug, Info, and Error. Messages
+// are also filtered by the level of SetLevel, LevelDebug by default, and
+// then by the level of logger. Keys are formatted with %v, values are
+// zap.Any fields, and a missing last value is "(MISSING)".
+//
+// It is only built with the "zap" build tag.
+func NewZapAdapter(logger *zap.Logger) Logger {
+	return &zapAdapter{
+		level:  LevelDebug,
+		logger: logger,
+	}
+}
+
+func (l *zapAdapter) SetLevel(lvl LogLevel) {
+	l.level = lvl
+}
+
+// Debug logs a message at level Debug.
+func (l *zapAdapter) Debug(msg string, keyvals ...interface{}) {
+	if l.level <= LevelDebug {
+		l.logger.Debug(msg, zapFields(keyvals)...)
+	}
+}
+
+// Info logs a message at level Info.
+func (l *zapAdapter) Info(msg string, keyvals ...interface{}) {
+	if l.level <= LevelInfo {
+		l.logger.Info(msg, zapFields(keyvals)...)
+	}
+}
+
+// Error logs a message at level Error.
+func (l *zapAdapter) Error(msg string, keyvals ...interface{}) {
+	if l.level <= LevelError {
+		l.logger.Error(msg, zapFields(keyvals)...)
+	}
+}
+
+// With returns a new contextual logger with keyvals added to those passed
+// to calls to Info, Debug or Error.
+func (l *zapAdapter) With(keyvals ...interface{}) Logger {
+	return &zapAdapter{
+		level:  l.level,
+		logger: l.logger.With(zapFields(keyvals)...),
+	}
+}
+
+func zapFields(keyvals []interface{}) []zap.Field {
+	fields := make([]zap.Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprintf("%v", keyvals[i])
+		if i+1 == len(keyvals) {
+			fields = append(fields, zap.String(key, "(MISSING)"))
+		} else {
+			fields = append(fields, zap.Any(key, keyvals[i+1]))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,73 @@
+//go:build zap
+// +build zap
+
+package log_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/gnolang/gno/pkgs/log"
+)
+
+func TestZapAdapter(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := log.NewZapAdapter(zap.New(core))
+
+	child := logger.With("module", "sdk/app").With("height", 7)
+	child.Debug("debug", "err", errors.New("failure"))
+	child.Info("info", 1, "non-string key")
+	child.Error("error", "odd")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for i, expected := range []struct {
+		level  zapcore.Level
+		msg    string
+		fields map[string]interface{}
+	}{
+		{zapcore.DebugLevel, "debug", map[string]interface{}{"module": "sdk/app", "height": int64(7), "err": "failure"}},
+		{zapcore.InfoLevel, "info", map[string]interface{}{"module": "sdk/app", "height": int64(7), "1": "non-string key"}},
+		{zapcore.ErrorLevel, "error", map[string]interface{}{"module": "sdk/app", "height": int64(7), "odd": "(MISSING)"}},
+	} {
+		entry := entries[i]
+		if entry.Level != expected.level || entry.Message != expected.msg {
+			t.Errorf("entry %d: expected %v %q, got %v %q", i, expected.level, expected.msg, entry.Level, entry.Message)
+		}
+		fields := entry.ContextMap()
+		if len(fields) != len(expected.fields) {
+			t.Errorf("entry %d: expected fields %v, got %v", i, expected.fields, fields)
+		}
+		for k, v := range expected.fields {
+			if fields[k] != v {
+				t.Errorf("entry %d: expected %s=%v, got %v", i, k, v, fields[k])
+			}
+		}
+	}
+}
+
+func TestZapAdapterLevels(t *testing.T) {
+	// the levels of the adapter and of the zap logger both apply.
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := log.NewZapAdapter(zap.New(core))
+	logger.Debug("dropped by zap")
+	logger.Info("info")
+	logger.SetLevel(log.LevelError)
+	logger.Info("dropped by the adapter")
+	logger.With("module", "sdk/app").Info("dropped by the derived adapter")
+	logger.Error("error")
+
+	var msgs []string
+	for _, entry := range logs.AllUntimed() {
+		msgs = append(msgs, entry.Message)
+	}
+	if len(msgs) != 2 || msgs[0] != "info" || msgs[1] != "error" {
+		t.Errorf("expected [info error], got %v", msgs)
+	}
+}
@@ -0,0 +1,93 @@
+//go:build zerolog
+// +build zerolog
+
+package log
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+// zerologAdapter is a Logger that writes to a zerolog.Logger.
+type zerologAdapter struct {
+	level  LogLevel
+	logger zerolog.Logger
+}
+
+var _ Logger = (*zerologAdapter)(nil)
+
+// NewZerologAdapter returns a Logger that writes to logger, at
+// zerolog.DebugLevel, zerolog.InfoLevel, and zerolog.ErrorLevel for Debug,
+// Info, and Error. Messages are also filtered by the level of SetLevel,
+// LevelDebug by default, and then by the level of logger. Keys are
+// formatted with %v, errors are written with AnErr and other values with
+// Interface, and a missing last value is "(MISSING)".
+//
+// It is only built with the "zerolog" build tag.
+func NewZerologAdapter(logger zerolog.Logger) Logger {
+	return &zerologAdapter{
+		level:  LevelDebug,
+		logger: logger,
+	}
+}
+
+func (l *zerologAdapter) SetLevel(lvl LogLevel) {
+	l.level = lvl
+}
+
+// Debug logs a message at level Debug.
+func (l *zerologAdapter) Debug(msg string, keyvals ...interface{}) {
+	if l.level <= LevelDebug {
+		zerologEvent(l.logger.Debug(), keyvals).Msg(msg)
+	}
+}
+
+// Info logs a message at level Info.
+func (l *zerologAdapter) Info(msg string, keyvals ...interface{}) {
+	if l.level <= LevelInfo {
+		zerologEvent(l.logger.Info(), keyvals).Msg(msg)
+	}
+}
+
+// Error logs a message at level Error.
+func (l *zerologAdapter) Error(msg string, keyvals ...interface{}) {
+	if l.level <= LevelError {
+		zerologEvent(l.logger.Error(), keyvals).Msg(msg)
+	}
+}
+
+// With returns a new contextual logger with keyvals added to those passed
+// to calls to Info, Debug or Error.
+func (l *zerologAdapter) With(keyvals ...interface{}) Logger {
+	ctx := l.logger.With()
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprintf("%v", keyvals[i])
+		if i+1 == len(keyvals) {
+			ctx = ctx.Str(key, "(MISSING)")
+		} else if err, ok := keyvals[i+1].(error); ok {
+			ctx = ctx.AnErr(key, err)
+		} else {
+			ctx = ctx.Interface(key, keyvals[i+1])
+		}
+	}
+	return &zerologAdapter{
+		level:  l.level,
+		logger: ctx.Logger(),
+	}
+}
+
+// zerologEvent adds keyvals to ev, which is nil if disabled.
+func zerologEvent(ev *zerolog.Event, keyvals []interface{}) *zerolog.Event {
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprintf("%v", keyvals[i])
+		if i+1 == len(keyvals) {
+			ev = ev.Str(key, "(MISSING)")
+		} else if err, ok := keyvals[i+1].(error); ok {
+			ev = ev.AnErr(key, err)
+		} else {
+			ev = ev.Interface(key, keyvals[i+1])
+		}
+	}
+	return ev
+}
@@ -0,0 +1,57 @@
+//go:build zerolog
+// +build zerolog
+
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gnolang/gno/pkgs/log"
+)
+
+func TestZerologAdapter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewZerologAdapter(zerolog.New(&buf))
+
+	child := logger.With("module", "sdk/app").With("height", 7, "odd")
+	child.Debug("debug", "err", errors.New("failure"))
+	child.Info("info", 1, "non-string key")
+	child.Error("error", "odd")
+
+	entries := parseJSONLines(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for i, expected := range []map[string]interface{}{
+		{"level": "debug", "message": "debug", "module": "sdk/app", "height": 7.0, "odd": "(MISSING)", "err": "failure"},
+		{"level": "info", "message": "info", "module": "sdk/app", "height": 7.0, "odd": "(MISSING)", "1": "non-string key"},
+		{"level": "error", "message": "error", "module": "sdk/app", "height": 7.0, "odd": "(MISSING)"},
+	} {
+		for k, v := range expected {
+			if entries[i][k] != v {
+				t.Errorf("entry %d: expected %s=%v, got %v", i, k, v, entries[i][k])
+			}
+		}
+	}
+}
+
+func TestZerologAdapterLevels(t *testing.T) {
+	// the levels of the adapter and of the zerolog logger both apply.
+	var buf bytes.Buffer
+	logger := log.NewZerologAdapter(zerolog.New(&buf).Level(zerolog.InfoLevel))
+	logger.Debug("dropped by zerolog")
+	logger.Info("info")
+	logger.SetLevel(log.LevelError)
+	logger.Info("dropped by the adapter")
+	logger.With("module", "sdk/app").Info("dropped by the derived adapter")
+	logger.Error("error")
+
+	entries := parseJSONLines(t, &buf)
+	if len(entries) != 2 || entries[0]["message"] != "info" || entries[1]["message"] != "error" {
+		t.Errorf("expected [info error], got %v", entries)
+	}
+}
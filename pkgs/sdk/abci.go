@@ -1,6 +1,9 @@
 package sdk
 
-import abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+import (
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/store"
+)
 
 // InitChainer initializes application state at genesis
 type InitChainer func(ctx Context, req abci.RequestInitChain) abci.ResponseInitChain
@@ -11,8 +14,22 @@ type InitChainer func(ctx Context, req abci.RequestInitChain) abci.ResponseInitC
 // e.g. BFT timestamps rather than block height for any periodic BeginBlock logic
 type BeginBlocker func(ctx Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock
 
+// EvidenceHandler handles the evidence of a validator misbehaviour reported
+// in BeginBlock, e.g. to slash the validator.
+type EvidenceHandler func(ctx Context, ev abci.Evidence)
+
 // EndBlocker runs code after the transactions in a block and return updates to the validator set
 //
 // Note: applications which set create_empty_blocks=false will not have regular block timing and should use
 // e.g. BFT timestamps rather than block height for any periodic EndBlock logic
 type EndBlocker func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock
+
+// CheckStateRefresher runs on the context of the check state each time it
+// is reset to the latest committed state, e.g. to load params or warm caches
+// before the mempool txs are rechecked. Its writes are only seen by CheckTx
+// and are never committed.
+type CheckStateRefresher func(ctx Context)
+
+// WriteSetRecorder receives the write set of the block at height, see
+// SetWriteSetRecorder.
+type WriteSetRecorder func(height int64, ops []store.StoreOp)
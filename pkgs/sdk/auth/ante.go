@@ -1,15 +1,16 @@
 package auth
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 
 	"github.com/gnolang/gno/pkgs/amino"
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/crypto/ed25519"
 	"github.com/gnolang/gno/pkgs/crypto/multisig"
 	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1"
 	"github.com/gnolang/gno/pkgs/sdk"
 	"github.com/gnolang/gno/pkgs/std"
 	"github.com/gnolang/gno/pkgs/store"
@@ -31,10 +32,56 @@ func init() {
 // and also to accept or reject different types of PubKey's. This is where apps can define their own PubKey
 type SignatureVerificationGasConsumer = func(meter store.GasMeter, sig []byte, pubkey crypto.PubKey, params Params) sdk.Result
 
+// AnteOptions holds optional configuration of the AnteHandler.
+type AnteOptions struct {
+	// BatchVerifySigs verifies the signatures of all signers of a tx,
+	// including every subkey signature of multisig signers, in one
+	// crypto.BatchVerifier pass before the signers are processed.
+	// Results (accept/reject, errors, and gas) are identical to verifying
+	// signatures one by one.
+	BatchVerifySigs bool
+	// SigVerifyCacheSize is the number of signature verification outcomes
+	// kept in a crypto.SigCache shared by all calls of the AnteHandler, so
+	// that signatures of a tx are not verified again on RecheckTx and
+	// DeliverTx. Gas is charged as if signatures were verified. Zero
+	// disables the cache.
+	SigVerifyCacheSize int
+	// SimulateSignatureCosts is the gas charged in simulate mode for each
+	// signature, by type of public key, in place of its cryptographic
+	// verification, which is skipped. See SetSimulateSignatureCost.
+	SimulateSignatureCosts map[string]int64
+}
+
+// SetSimulateSignatureCost sets the gas charged in simulate mode for each
+// signature of a public key of type keyType, which is one of "ed25519",
+// "secp256k1", "secp256r1" or "multisig", e.g. as a margin for gas estimates.
+func (opts *AnteOptions) SetSimulateSignatureCost(keyType string, gas int64) {
+	if opts.SimulateSignatureCosts == nil {
+		opts.SimulateSignatureCosts = make(map[string]int64)
+	}
+	opts.SimulateSignatureCosts[keyType] = gas
+}
+
 // NewAnteHandler returns an AnteHandler that checks and increments sequence
 // numbers, checks signatures & account numbers, and deducts fees from the first
 // signer.
 func NewAnteHandler(ak AccountKeeper, bank BankKeeperI, sigGasConsumer SignatureVerificationGasConsumer) sdk.AnteHandler {
+	return NewAnteHandlerWithOptions(ak, bank, sigGasConsumer, AnteOptions{})
+}
+
+// NewAnteHandlerWithOptions is like NewAnteHandler but with the given
+// options.
+//
+// In simulate mode, the AnteHandler runs all its logic, charging the same gas
+// as for delivery, except for the cryptographic verification of signatures,
+// for which it charges the SimulateSignatureCosts of the options instead, and
+// the mempool fees check. Txs without signatures are simulated with
+// placeholder signatures, whose size is charged as if they were included,
+// and the simulated sender of the context, if set, signs them in place of
+// their first signer (see sdk.Context.WithSimulatedSender).
+func NewAnteHandlerWithOptions(ak AccountKeeper, bank BankKeeperI, sigGasConsumer SignatureVerificationGasConsumer, opts AnteOptions) sdk.AnteHandler {
+	sigCache := crypto.NewSigCache(opts.SigVerifyCacheSize)
+
 	return func(
 		ctx sdk.Context, tx std.Tx, simulate bool,
 	) (newCtx sdk.Context, res sdk.Result, abort bool) {
@@ -54,6 +101,12 @@ func NewAnteHandler(ak AccountKeeper, bank BankKeeperI, sigGasConsumer Signature
 
 		newCtx = SetGasMeter(simulate, ctx, tx.Fee.GasWanted)
 
+		// Simulated txs may not be signed yet, so they are given placeholder
+		// signatures.
+		if simulate && len(tx.Signatures) == 0 {
+			tx.Signatures = make([]std.Signature, len(tx.GetSigners()))
+		}
+
 		// AnteHandlers must have their own defer/recover in order for the BaseApp
 		// to know how much gas was used! This is because the GasMeter is created in
 		// the AnteHandler, but if it panics the context won't be set properly in
@@ -85,15 +138,21 @@ func NewAnteHandler(ak AccountKeeper, bank BankKeeperI, sigGasConsumer Signature
 			return newCtx, abciResult(err), true
 		}
 
-		newCtx.GasMeter().ConsumeGas(params.TxSizeCostPerByte*store.Gas(len(newCtx.TxBytes())), "txSize")
+		newCtx.GasMeter().ConsumeGas(params.TxSizeCostPerByte*store.Gas(len(newCtx.TxBytes())), "tx:size")
 
 		if res := ValidateMemo(tx, params); !res.IsOK() {
 			return newCtx, res, true
 		}
 
-		// stdSigs contains the sequence number, account number, and signatures.
-		// When simulating, this would just be a 0-length slice.
-		signerAddrs := tx.GetSigners()
+		// the global minimum gas prices are enforced on delivery, after the
+		// local ones of the node on CheckTx, except for the genesis txs.
+		if ctx.Mode() == sdk.RunTxModeDeliver && ctx.BlockHeight() > 0 {
+			if res := EnsureSufficientGlobalFees(tx.Fee, params); !res.IsOK() {
+				return newCtx, res, true
+			}
+		}
+
+		signerAddrs := txSigners(ctx, tx, simulate)
 		signerAccs := make([]std.Account, len(signerAddrs))
 		isGenesis := ctx.BlockHeight() == 0
 
@@ -102,6 +161,7 @@ func NewAnteHandler(ak AccountKeeper, bank BankKeeperI, sigGasConsumer Signature
 		if !res.IsOK() {
 			return newCtx, res, true
 		}
+		newCtx = newCtx.WithSender(signerAddrs[0])
 
 		// deduct the fees
 		if !tx.Fee.GasFee.IsZero() {
@@ -112,12 +172,26 @@ func NewAnteHandler(ak AccountKeeper, bank BankKeeperI, sigGasConsumer Signature
 
 			// reload the account as fees have been deducted
 			signerAccs[0] = ak.GetAccount(newCtx, signerAccs[0].GetAddress())
+
+			// the fee is charged even if the msgs of the tx fail.
+			if err := newCtx.EventLogger().EmitTypedEvent(EventTypeFee,
+				"payer", signerAddrs[0].String(),
+				"amount", tx.Fee.GasFee.String(),
+			); err != nil {
+				return newCtx, abciResult(std.ErrInternal(err.Error())), true
+			}
 		}
 
 		// stdSigs contains the sequence number, account number, and signatures.
-		// When simulating, this would just be a 0-length slice.
+		// When simulating, they may be placeholders without a signature.
 		stdSigs := tx.GetSignatures()
 
+		// verify all signatures at once if enabled.
+		var batch []batchedSig
+		if opts.BatchVerifySigs && !simulate {
+			batch = batchVerifySigs(newCtx, ak, tx, signerAddrs, isGenesis, sigCache)
+		}
+
 		for i := 0; i < len(stdSigs); i++ {
 			// skip the fee payer, account is cached and fees were deducted already
 			if i != 0 {
@@ -130,10 +204,17 @@ func NewAnteHandler(ak AccountKeeper, bank BankKeeperI, sigGasConsumer Signature
 			// check signature, return account with incremented nonce
 			sacc := signerAccs[i]
 			signBytes := GetSignBytes(newCtx.ChainID(), tx, sacc, isGenesis)
-			signerAccs[i], res = processSig(newCtx, sacc, stdSigs[i], signBytes, simulate, params, sigGasConsumer)
+			verify := sigVerifier(sigCache.VerifyBytes)
+			if batch != nil {
+				verify = batch[i].verify
+			}
+			signerAccs[i], res = processSig(newCtx, sacc, stdSigs[i], signBytes, simulate, params, sigGasConsumer, verify)
 			if !res.IsOK() {
 				return newCtx, res, true
 			}
+			if simulate {
+				consumeSimVerifyGas(newCtx.GasMeter(), signerAccs[i].GetPubKey(), opts)
+			}
 
 			ak.SetAccount(newCtx, signerAccs[i])
 		}
@@ -143,6 +224,24 @@ func NewAnteHandler(ak AccountKeeper, bank BankKeeperI, sigGasConsumer Signature
 	}
 }
 
+// txSigners returns the addresses of the signers of tx, the first of which
+// pays the fee. An unsigned simulated tx is signed by the simulated sender
+// of ctx, if set, in place of its first signer.
+func txSigners(ctx sdk.Context, tx std.Tx, simulate bool) []crypto.Address {
+	signers := tx.GetSigners()
+	sender := ctx.SimulatedSender()
+	if !simulate || sender.IsZero() || len(signers) == 0 {
+		return signers
+	}
+	for _, sig := range tx.Signatures {
+		if len(sig.Signature) != 0 {
+			return signers
+		}
+	}
+	signers[0] = sender
+	return signers
+}
+
 // GetSignerAcc returns an account for a given address that is expected to sign
 // a transaction.
 func GetSignerAcc(ctx sdk.Context, ak AccountKeeper, addr crypto.Address) (std.Account, sdk.Result) {
@@ -189,7 +288,7 @@ func ValidateMemo(tx std.Tx, params Params) sdk.Result {
 // a pubkey, set it.
 func processSig(
 	ctx sdk.Context, acc std.Account, sig std.Signature, signBytes []byte, simulate bool, params Params,
-	sigGasConsumer SignatureVerificationGasConsumer,
+	sigGasConsumer SignatureVerificationGasConsumer, verify sigVerifier,
 ) (updatedAcc std.Account, res sdk.Result) {
 
 	pubKey, res := ProcessPubKey(acc, sig, simulate)
@@ -202,19 +301,20 @@ func processSig(
 		return nil, abciResult(std.ErrInternal("setting PubKey on signer's account"))
 	}
 
-	if simulate {
-		// Simulated txs should not contain a signature and are not required to
+	if simulate && len(sig.Signature) == 0 {
+		// Simulated txs may not contain a signature and are not required to
 		// contain a pubkey, so we must account for tx size of including a
 		// std.Signature (Amino encoding) and simulate gas consumption
-		// (assuming a SECP256k1 simulation key).
-		consumeSimSigGas(ctx.GasMeter(), pubKey, sig, params)
+		// (assuming a SECP256k1 simulation key). The size of included
+		// signatures is already charged with the tx bytes.
+		consumeSimSigGas(ctx.GasMeter(), pubKey, params)
 	}
 
 	if res := sigGasConsumer(ctx.GasMeter(), sig.Signature, pubKey, params); !res.IsOK() {
 		return nil, res
 	}
 
-	if !simulate && !pubKey.VerifyBytes(signBytes, sig.Signature) {
+	if !simulate && !verify(pubKey, signBytes, sig.Signature) {
 		return nil, abciResult(std.ErrUnauthorized("signature verification failed; verify correct account sequence and chain-id"))
 	}
 
@@ -225,11 +325,124 @@ func processSig(
 	return acc, res
 }
 
-func consumeSimSigGas(gasmeter store.GasMeter, pubkey crypto.PubKey, sig std.Signature, params Params) {
-	simSig := std.Signature{PubKey: pubkey}
-	if len(sig.Signature) == 0 {
-		simSig.Signature = simSecp256k1Sig[:]
+// sigVerifier verifies a signature over signBytes.
+type sigVerifier func(pubKey crypto.PubKey, signBytes []byte, sig []byte) bool
+
+func verifySig(pubKey crypto.PubKey, signBytes []byte, sig []byte) bool {
+	return pubKey.VerifyBytes(signBytes, sig)
+}
+
+// batchedSig is the result of verifying a signer's signature in a batch.
+type batchedSig struct {
+	pubKey    crypto.PubKey // nil if not batched
+	signBytes []byte
+	sig       []byte
+	valid     bool
+}
+
+// verify returns the batched result if it was computed for the same inputs,
+// and otherwise verifies the signature directly.
+func (bs batchedSig) verify(pubKey crypto.PubKey, signBytes []byte, sig []byte) bool {
+	if bs.pubKey != nil && bs.pubKey.Equals(pubKey) &&
+		bytes.Equal(bs.signBytes, signBytes) && bytes.Equal(bs.sig, sig) {
+		return bs.valid
+	}
+	return verifySig(pubKey, signBytes, sig)
+}
+
+// batchVerifySigs verifies the signatures of all signers of tx in a single
+// crypto.BatchVerifier pass, and returns one result per signer. Outcomes
+// found in sigCache are not verified again, and new ones are added to it.
+//
+// Accounts are read with an infinite gas meter so that the batch pass does
+// not change gas consumption; the ante handler still reads, charges for, and
+// checks every signer in order as usual, and only uses a batched result if it
+// was computed for the exact same pubkey, sign bytes, and signature.
+func batchVerifySigs(ctx sdk.Context, ak AccountKeeper, tx std.Tx, signerAddrs []crypto.Address, isGenesis bool, sigCache *crypto.SigCache) []batchedSig {
+	ctx = ctx.WithGasMeter(store.NewInfiniteGasMeter())
+	stdSigs := tx.GetSignatures()
+	results := make([]batchedSig, len(stdSigs))
+	// index range of each signer's entries in the batch.
+	starts := make([]int, len(stdSigs))
+	ends := make([]int, len(stdSigs))
+	cached := make([]bool, len(stdSigs))
+	bv := crypto.NewBatchVerifier()
+	n := 0
+	for i := 0; i < len(stdSigs) && i < len(signerAddrs); i++ {
+		acc := ak.GetAccount(ctx, signerAddrs[i])
+		if acc == nil {
+			continue
+		}
+		pubKey, res := ProcessPubKey(acc, stdSigs[i], false)
+		if !res.IsOK() {
+			continue
+		}
+		signBytes := GetSignBytes(ctx.ChainID(), tx, acc, isGenesis)
+		sig := stdSigs[i].Signature
+		if valid, ok := sigCache.Get(pubKey, signBytes, sig); ok {
+			results[i] = batchedSig{
+				pubKey:    pubKey,
+				signBytes: signBytes,
+				sig:       sig,
+				valid:     valid,
+			}
+			cached[i] = true
+			continue
+		}
+		wellFormed := true
+		counter := &countingBatchVerifier{BatchVerifier: bv}
+		if mpk, ok := pubKey.(multisig.PubKeyMultisigThreshold); ok {
+			wellFormed = mpk.AddToBatch(counter, signBytes, sig)
+		} else {
+			counter.Add(pubKey, signBytes, sig)
+		}
+		results[i] = batchedSig{
+			pubKey:    pubKey,
+			signBytes: signBytes,
+			sig:       sig,
+			valid:     wellFormed,
+		}
+		starts[i], ends[i] = n, n+counter.count
+		n += counter.count
+	}
+
+	if ok, failed := bv.VerifyAll(); !ok {
+		for _, idx := range failed {
+			for i := range results {
+				if starts[i] <= idx && idx < ends[i] {
+					results[i].valid = false
+				}
+			}
+		}
+		// the signatures rejected by the batch are verified again one by
+		// one, so that they are rejected only as by PubKey.VerifyBytes.
+		for i, res := range results {
+			if res.pubKey != nil && !cached[i] && !res.valid {
+				results[i].valid = verifySig(res.pubKey, res.signBytes, res.sig)
+			}
+		}
 	}
+	for i, res := range results {
+		if res.pubKey != nil && !cached[i] {
+			sigCache.Add(res.pubKey, res.signBytes, res.sig, res.valid)
+		}
+	}
+	return results
+}
+
+// countingBatchVerifier counts the entries added through it.
+type countingBatchVerifier struct {
+	crypto.BatchVerifier
+	count int
+}
+
+func (cbv *countingBatchVerifier) Add(key crypto.PubKey, msg []byte, sig []byte) {
+	cbv.BatchVerifier.Add(key, msg, sig)
+	cbv.count++
+}
+
+func consumeSimSigGas(gasmeter store.GasMeter, pubkey crypto.PubKey, params Params) {
+	simSig := std.Signature{PubKey: pubkey, Signature: simSecp256k1Sig[:]}
 
 	sigBz := amino.MustMarshalSized(simSig)
 	cost := store.Gas(len(sigBz) + 6)
@@ -240,7 +453,26 @@ func consumeSimSigGas(gasmeter store.GasMeter, pubkey crypto.PubKey, sig std.Sig
 		cost *= params.TxSigLimit
 	}
 
-	gasmeter.ConsumeGas(params.TxSizeCostPerByte*cost, "txSize")
+	gasmeter.ConsumeGas(params.TxSizeCostPerByte*cost, "tx:size")
+}
+
+// consumeSimVerifyGas charges the simulated cost of verifying a signature of
+// pubkey, in place of the verification which is skipped in simulate mode.
+func consumeSimVerifyGas(gasmeter store.GasMeter, pubkey crypto.PubKey, opts AnteOptions) {
+	var keyType string
+	switch pubkey.(type) {
+	case ed25519.PubKeyEd25519:
+		keyType = "ed25519"
+	case secp256k1.PubKeySecp256k1:
+		keyType = "secp256k1"
+	case secp256r1.PubKeySecp256r1:
+		keyType = "secp256r1"
+	case multisig.PubKeyMultisigThreshold:
+		keyType = "multisig"
+	}
+	if cost := opts.SimulateSignatureCosts[keyType]; cost > 0 {
+		gasmeter.ConsumeGas(cost, "sig:simulate")
+	}
 }
 
 // ProcessPubKey verifies that the given account address matches that of the
@@ -285,11 +517,15 @@ func DefaultSigVerificationGasConsumer(
 ) sdk.Result {
 	switch pubkey := pubkey.(type) {
 	case ed25519.PubKeyEd25519:
-		meter.ConsumeGas(params.SigVerifyCostED25519, "ante verify: ed25519")
-		return abciResult(std.ErrInvalidPubKey("ED25519 public keys are unsupported"))
+		meter.ConsumeGas(params.SigVerifyCostED25519, "sig:ed25519")
+		return sdk.Result{}
 
 	case secp256k1.PubKeySecp256k1:
-		meter.ConsumeGas(params.SigVerifyCostSecp256k1, "ante verify: secp256k1")
+		meter.ConsumeGas(params.SigVerifyCostSecp256k1, "sig:secp256k1")
+		return sdk.Result{}
+
+	case secp256r1.PubKeySecp256r1:
+		meter.ConsumeGas(params.SigVerifyCostSecp256r1, "sig:secp256r1")
 		return sdk.Result{}
 
 	case multisig.PubKeyMultisigThreshold:
@@ -323,15 +559,15 @@ func consumeMultisignatureVerificationGas(meter store.GasMeter,
 // NOTE: We could use the CoinKeeper (in addition to the AccountKeeper, because
 // the CoinKeeper doesn't give us accounts), but it seems easier to do this.
 func DeductFees(bank BankKeeperI, ctx sdk.Context, acc std.Account, fees std.Coins) sdk.Result {
-	coins := acc.GetCoins()
+	// the locked coins of vesting accounts cannot pay fees.
+	coins := std.SpendableCoins(acc, ctx.BlockTime())
 
 	if !fees.IsValid() {
 		return abciResult(std.ErrInsufficientFee(fmt.Sprintf("invalid fee amount: %s", fees)))
 	}
 
 	// verify the account has enough funds to pay for fees
-	diff := coins.SubUnsafe(fees)
-	if !diff.IsValid() {
+	if _, ok := coins.SafeSub(fees); !ok {
 		return abciResult(std.ErrInsufficientFunds(
 			fmt.Sprintf("insufficient funds to pay for fees; %s < %s", coins, fees),
 		))
@@ -349,34 +585,55 @@ func DeductFees(bank BankKeeperI, ctx sdk.Context, acc std.Account, fees std.Coi
 // enough fees to cover a proposer's minimum fees. A result object is returned
 // indicating success or failure.
 //
+// When there are minimum gas prices in several denominations, the fee must
+// satisfy the one of its own denomination, and the others are ignored: e.g.
+// with "0.01atom,0.5photon", a fee in atom must pay 0.01atom per unit of gas,
+// a fee in photon 0.5photon, and a fee in any other denomination is rejected.
+//
 // Contract: This should only be called during CheckTx as it cannot be part of
 // consensus.
 func EnsureSufficientMempoolFees(ctx sdk.Context, fee std.Fee) sdk.Result {
-	minGasPrices := ctx.MinGasPrices()
+	return ensureSufficientFees(fee, ctx.MinGasPrices(), "")
+}
+
+// EnsureSufficientGlobalFees verifies that the fee of a delivered tx covers
+// the GlobalMinGasPrices of the params, as EnsureSufficientMempoolFees does
+// for the minimum gas prices of the node. Unlike these, the global minimum
+// gas prices are part of consensus, so all the validators agree on the txs
+// which pay too little.
+func EnsureSufficientGlobalFees(fee std.Fee, params Params) sdk.Result {
+	return ensureSufficientFees(fee, params.GlobalMinGasPrices, " for the global minimum gas prices")
+}
+
+// ensureSufficientFees verifies that fee covers the minGasPrices of its
+// denomination, if any, as EnsureSufficientMempoolFees. The errors of
+// insufficient fees end with which.
+func ensureSufficientFees(fee std.Fee, minGasPrices std.DecCoins, which string) sdk.Result {
 	if len(minGasPrices) == 0 {
 		// no minimum gas price (not recommended)
 		// TODO: allow for selective filtering of 0 fee txs.
 		return sdk.Result{}
 	} else {
 
-		fgw := big.NewInt(fee.GasWanted)
-		fga := big.NewInt(fee.GasFee.Amount)
 		fgd := fee.GasFee.Denom
 
 		for _, gp := range minGasPrices {
-			gpg := big.NewInt(gp.Gas)
-			gpa := big.NewInt(gp.Price.Amount)
-			gpd := gp.Price.Denom
-
-			if fgd == gpd {
-				prod1 := big.NewInt(0).Mul(fga, gpg) // fee amount * price gas
-				prod2 := big.NewInt(0).Mul(fgw, gpa) // fee gas * price amount
-				if prod1.Cmp(prod2) >= 0 {
+			if fgd == gp.Denom {
+				// required fee is ceil(price per gas * gas wanted).
+				required, ok := std.GasFee(gp, fee.GasWanted)
+				if !ok {
+					return abciResult(std.ErrInsufficientFee(
+						fmt.Sprintf(
+							"insufficient fees%s; required fee overflows at gas price %q", which, gp,
+						),
+					))
+				}
+				if fee.GasFee.Amount >= required.Amount {
 					return sdk.Result{}
 				} else {
 					return abciResult(std.ErrInsufficientFee(
 						fmt.Sprintf(
-							"insufficient fees; got: %q required: %q", fee.GasFee, gp,
+							"insufficient fees%s; got: %q required: %q", which, fee.GasFee, required,
 						),
 					))
 				}
@@ -386,7 +643,7 @@ func EnsureSufficientMempoolFees(ctx sdk.Context, fee std.Fee) sdk.Result {
 
 	return abciResult(std.ErrInsufficientFee(
 		fmt.Sprintf(
-			"insufficient fees; got: %q required (one of): %q", fee.GasFee, minGasPrices,
+			"insufficient fees%s; got: %q required (one of): %q", which, fee.GasFee, minGasPrices,
 		),
 	))
 }
@@ -16,10 +16,15 @@ import (
 	"github.com/gnolang/gno/pkgs/crypto/ed25519"
 	"github.com/gnolang/gno/pkgs/crypto/multisig"
 	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/log"
 	"github.com/gnolang/gno/pkgs/sdk"
 	tu "github.com/gnolang/gno/pkgs/sdk/testutils"
 	"github.com/gnolang/gno/pkgs/std"
 	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/iavl"
 )
 
 // run the tx through the anteHandler and ensure its valid
@@ -609,8 +614,9 @@ func TestConsumeSignatureVerificationGas(t *testing.T) {
 		gasConsumed int64
 		shouldErr   bool
 	}{
-		{"PubKeyEd25519", args{store.NewInfiniteGasMeter(), nil, ed25519.GenPrivKey().PubKey(), params}, DefaultSigVerifyCostED25519, true},
+		{"PubKeyEd25519", args{store.NewInfiniteGasMeter(), nil, ed25519.GenPrivKey().PubKey(), params}, DefaultSigVerifyCostED25519, false},
 		{"PubKeySecp256k1", args{store.NewInfiniteGasMeter(), nil, secp256k1.GenPrivKey().PubKey(), params}, DefaultSigVerifyCostSecp256k1, false},
+		{"PubKeySecp256r1", args{store.NewInfiniteGasMeter(), nil, secp256r1.GenPrivKey().PubKey(), params}, DefaultSigVerifyCostSecp256r1, false},
 		{"Multisig", args{store.NewInfiniteGasMeter(), amino.MustMarshal(multisignature1), multisigKey1, params}, expectedCost1, false},
 		{"unknown key", args{store.NewInfiniteGasMeter(), nil, nil, params}, 0, true},
 	}
@@ -734,9 +740,9 @@ func TestEnsureSufficientMempoolFees(t *testing.T) {
 	// setup
 	env := setupTestEnv()
 	ctx := env.ctx.WithMinGasPrices(
-		[]std.GasPrice{
-			std.GasPrice{Gas: 100000, Price: std.Coin{Denom: "photino", Amount: 5}},
-			std.GasPrice{Gas: 100000, Price: std.Coin{Denom: "stake", Amount: 1}},
+		std.DecCoins{
+			std.NewDecCoin("photino", std.MustParseDec("0.00005")),
+			std.NewDecCoin("stake", std.MustParseDec("0.00001")),
 		},
 	)
 
@@ -751,6 +757,9 @@ func TestEnsureSufficientMempoolFees(t *testing.T) {
 		{std.NewFee(200000, std.NewCoin("photino", 10)), true},
 		{std.NewFee(200000, std.NewCoin("stake", 2)), true},
 		{std.NewFee(200000, std.NewCoin("atom", 5)), false},
+		{std.NewFee(200001, std.NewCoin("photino", 10)), false},
+		{std.NewFee(200001, std.NewCoin("photino", 11)), true},
+		{std.NewFee(1<<62, std.NewCoin("stake", 1<<62)), true},
 	}
 
 	for i, tc := range testCases {
@@ -760,6 +769,31 @@ func TestEnsureSufficientMempoolFees(t *testing.T) {
 			"unexpected result; tc #%d, input: %v, log: %v", i, tc.input, res.Log,
 		)
 	}
+
+	// a fee satisfies the minimum gas prices if it satisfies the one of its
+	// own denomination, whatever the others.
+	minGasPrices, err := std.ParseGasPrices("0.5photon,0.01atom")
+	require.NoError(t, err)
+	ctx = env.ctx.WithMinGasPrices(minGasPrices)
+	policyCases := []struct {
+		input      std.Fee
+		expectedOK bool
+	}{
+		{std.NewFee(100, std.NewCoin("atom", 1)), true},
+		{std.NewFee(100, std.NewCoin("photon", 50)), true},
+		{std.NewFee(100, std.NewCoin("photon", 49)), false},
+		// more than the atom minimum, but in photon.
+		{std.NewFee(100, std.NewCoin("photon", 1)), false},
+		{std.NewFee(100, std.NewCoin("stake", 1000)), false},
+	}
+
+	for i, tc := range policyCases {
+		res := EnsureSufficientMempoolFees(ctx, tc.input)
+		require.Equal(
+			t, tc.expectedOK, res.IsOK(),
+			"unexpected result; tc #%d, input: %v, log: %v", i, tc.input, res.Log,
+		)
+	}
 }
 
 // Test custom SignatureVerificationGasConsumer
@@ -807,3 +841,296 @@ func TestCustomSignatureVerificationGasConsumer(t *testing.T) {
 	tx = tu.NewTestTx(ctx.ChainID(), msgs, privs, accnums, seqs, fee)
 	checkValidTx(t, anteHandler, ctx, tx, false)
 }
+
+// Test that batched signature verification accepts and rejects exactly the
+// same txs as sequential verification, with the same errors and gas.
+func TestAnteHandlerBatchVerifySigs(t *testing.T) {
+	env := setupTestEnv()
+	ctx := env.ctx
+	seqHandler := NewAnteHandler(env.acck, env.bank, DefaultSigVerificationGasConsumer)
+	batchHandler := NewAnteHandlerWithOptions(env.acck, env.bank, DefaultSigVerificationGasConsumer,
+		AnteOptions{BatchVerifySigs: true})
+
+	priv1, _, addr1 := tu.KeyTestPubAddr()
+	priv2, _, addr2 := tu.KeyTestPubAddr()
+	priv3, _, addr3 := tu.KeyTestPubAddr()
+	for _, addr := range []crypto.Address{addr1, addr2, addr3} {
+		acc := env.acck.NewAccountWithAddress(ctx, addr)
+		acc.SetCoins(tu.NewTestCoins())
+		env.acck.SetAccount(ctx, acc)
+	}
+
+	// a 2 of 3 multisig account.
+	mprivs := []crypto.PrivKey{secp256k1.GenPrivKey(), secp256k1.GenPrivKey(), secp256k1.GenPrivKey()}
+	mpubs := []crypto.PubKey{mprivs[0].PubKey(), mprivs[1].PubKey(), mprivs[2].PubKey()}
+	mpub := multisig.NewPubKeyMultisigThreshold(2, mpubs)
+	maddr := mpub.Address()
+	macc := env.acck.NewAccountWithAddress(ctx, maddr)
+	macc.SetCoins(tu.NewTestCoins())
+	env.acck.SetAccount(ctx, macc)
+
+	fee := tu.NewTestFee()
+	msgs := []std.Msg{tu.NewTestMsg(addr1, addr2, addr3)}
+	mmsgs := []std.Msg{tu.NewTestMsg(maddr, addr1)}
+
+	newMultisigTx := func(signers []int, corrupt bool) std.Tx {
+		signBytes := std.SignBytes(ctx.ChainID(), 3, 0, fee, mmsgs, "")
+		msig := multisig.NewMultisig(len(mpubs))
+		for _, i := range signers {
+			sig, err := mprivs[i].Sign(signBytes)
+			require.NoError(t, err)
+			if corrupt {
+				sig[0] ^= 0xFF
+			}
+			require.NoError(t, msig.AddSignatureFromPubKey(sig, mpubs[i], mpubs))
+		}
+		tx := tu.NewTestTx(ctx.ChainID(), mmsgs, []crypto.PrivKey{priv1, priv1}, []uint64{3, 0}, []uint64{0, 0}, fee)
+		tx.Signatures[0] = std.Signature{PubKey: mpub, Signature: amino.MustMarshal(msig)}
+		return tx
+	}
+
+	cases := []struct {
+		name string
+		tx   std.Tx
+	}{
+		{"all valid", tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv2, priv3}, []uint64{0, 1, 2}, []uint64{0, 0, 0}, fee)},
+		{"wrong signer", tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv1, priv3}, []uint64{0, 1, 2}, []uint64{0, 0, 0}, fee)},
+		{"wrong account number", tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv2, priv3}, []uint64{0, 1, 5}, []uint64{0, 0, 0}, fee)},
+		{"wrong sequence", tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv2, priv3}, []uint64{0, 1, 2}, []uint64{0, 1, 0}, fee)},
+		{"multisig valid", newMultisigTx([]int{0, 2}, false)},
+		{"multisig all signers", newMultisigTx([]int{0, 1, 2}, false)},
+		{"multisig below threshold", newMultisigTx([]int{1}, false)},
+		{"multisig bad signatures", newMultisigTx([]int{0, 1}, true)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			seqCtx := ctx.WithMultiStore(ctx.MultiStore().MultiCacheWrap())
+			batchCtx := ctx.WithMultiStore(ctx.MultiStore().MultiCacheWrap())
+			seqNewCtx, seqRes, seqAbort := seqHandler(seqCtx, tc.tx, false)
+			batchNewCtx, batchRes, batchAbort := batchHandler(batchCtx, tc.tx, false)
+
+			require.Equal(t, seqAbort, batchAbort)
+			require.Equal(t, seqRes.IsOK(), batchRes.IsOK())
+			require.Equal(t, reflect.TypeOf(sdk.ABCIError(seqRes.Error)), reflect.TypeOf(sdk.ABCIError(batchRes.Error)))
+			require.Equal(t, seqNewCtx.GasMeter().GasConsumed(), batchNewCtx.GasMeter().GasConsumed())
+		})
+	}
+}
+
+// Test that cached signature verification accepts and rejects exactly the
+// same txs as uncached verification, with the same errors and gas, also when
+// the same tx is checked again.
+func TestAnteHandlerSigVerifyCache(t *testing.T) {
+	env := setupTestEnv()
+	ctx := env.ctx
+	seqHandler := NewAnteHandler(env.acck, env.bank, DefaultSigVerificationGasConsumer)
+	cachedHandlers := map[string]sdk.AnteHandler{
+		"sequential": NewAnteHandlerWithOptions(env.acck, env.bank, DefaultSigVerificationGasConsumer,
+			AnteOptions{SigVerifyCacheSize: 100}),
+		"batch": NewAnteHandlerWithOptions(env.acck, env.bank, DefaultSigVerificationGasConsumer,
+			AnteOptions{BatchVerifySigs: true, SigVerifyCacheSize: 100}),
+	}
+
+	priv1, _, addr1 := tu.KeyTestPubAddr()
+	priv2, _, addr2 := tu.KeyTestPubAddr()
+	for _, addr := range []crypto.Address{addr1, addr2} {
+		acc := env.acck.NewAccountWithAddress(ctx, addr)
+		acc.SetCoins(tu.NewTestCoins())
+		env.acck.SetAccount(ctx, acc)
+	}
+
+	fee := tu.NewTestFee()
+	msgs := []std.Msg{tu.NewTestMsg(addr1, addr2)}
+	valid := tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv2}, []uint64{0, 1}, []uint64{0, 0}, fee)
+	// the valid tx with a mutated signature.
+	mutated := tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv2}, []uint64{0, 1}, []uint64{0, 0}, fee)
+	mutated.Signatures[1].Signature = append([]byte(nil), mutated.Signatures[1].Signature...)
+	mutated.Signatures[1].Signature[0] ^= 0x01
+	txs := []std.Tx{
+		valid,
+		mutated,
+		tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv1}, []uint64{0, 1}, []uint64{0, 0}, fee),
+		tu.NewTestTx(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv2}, []uint64{0, 1}, []uint64{0, 1}, fee),
+	}
+
+	for name, cachedHandler := range cachedHandlers {
+		t.Run(name, func(t *testing.T) {
+			// each tx is checked twice, the second time from the cache.
+			for round := 0; round < 2; round++ {
+				for i, tx := range txs {
+					seqCtx := ctx.WithMultiStore(ctx.MultiStore().MultiCacheWrap())
+					cachedCtx := ctx.WithMultiStore(ctx.MultiStore().MultiCacheWrap())
+					seqNewCtx, seqRes, seqAbort := seqHandler(seqCtx, tx, false)
+					cachedNewCtx, cachedRes, cachedAbort := cachedHandler(cachedCtx, tx, false)
+
+					require.Equal(t, seqAbort, cachedAbort, "tx %d", i)
+					require.Equal(t, seqRes.IsOK(), cachedRes.IsOK(), "tx %d", i)
+					require.Equal(t, reflect.TypeOf(sdk.ABCIError(seqRes.Error)), reflect.TypeOf(sdk.ABCIError(cachedRes.Error)), "tx %d", i)
+					require.Equal(t, seqNewCtx.GasMeter().GasConsumed(), cachedNewCtx.GasMeter().GasConsumed(), "tx %d", i)
+				}
+			}
+		})
+	}
+}
+
+// Test that simulation charges the same gas as delivery, except for the
+// simulated cost of signature verification, and that it runs the fee
+// deduction and sequence checks of unsigned txs.
+func TestAnteHandlerSimulateGas(t *testing.T) {
+	env := setupTestEnv()
+	ctx := env.ctx
+	opts := AnteOptions{}
+	opts.SetSimulateSignatureCost("secp256k1", 50)
+	anteHandler := NewAnteHandlerWithOptions(env.acck, env.bank, DefaultSigVerificationGasConsumer, opts)
+
+	priv1, _, addr1 := tu.KeyTestPubAddr()
+	priv2, _, addr2 := tu.KeyTestPubAddr()
+	for _, addr := range []crypto.Address{addr1, addr2} {
+		acc := env.acck.NewAccountWithAddress(ctx, addr)
+		acc.SetCoins(tu.NewTestCoins())
+		env.acck.SetAccount(ctx, acc)
+	}
+	msgs := []std.Msg{tu.NewTestMsg(addr1, addr2)}
+	run := func(tx std.Tx, simulate bool) (sdk.Context, sdk.Result) {
+		runCtx := ctx.WithMultiStore(ctx.MultiStore().MultiCacheWrap()).WithTxBytes(amino.MustMarshal(tx))
+		newCtx, res, _ := anteHandler(runCtx, tx, simulate)
+		return newCtx, res
+	}
+
+	// a fully signed tx.
+	tx := tu.NewTestTxWithMemo(ctx.ChainID(), msgs, []crypto.PrivKey{priv1, priv2}, []uint64{0, 1}, []uint64{0, 0}, tu.NewTestFee(), "memo")
+	deliverCtx, res := run(tx, false)
+	require.True(t, res.IsOK(), res.Log)
+	simCtx, res := run(tx, true)
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, deliverCtx.GasMeter().GasConsumed()+2*50, simCtx.GasMeter().GasConsumed())
+
+	// an unsigned tx is charged at least as much, with its fee deducted and
+	// its sequences incremented.
+	unsigned := tx
+	unsigned.Signatures = nil
+	simCtx, res = run(unsigned, true)
+	require.True(t, res.IsOK(), res.Log)
+	require.GreaterOrEqual(t, simCtx.GasMeter().GasConsumed(), deliverCtx.GasMeter().GasConsumed()+2*50)
+	acc1 := env.acck.GetAccount(simCtx, addr1)
+	require.Equal(t, uint64(1), acc1.GetSequence())
+	require.Equal(t, tu.NewTestCoins().Sub(std.Coins{tu.NewTestFee().GasFee}), acc1.GetCoins())
+	require.Equal(t, uint64(1), env.acck.GetAccount(simCtx, addr2).GetSequence())
+
+	// the memo is still checked.
+	unsigned.Memo = strings.Repeat("01234567890", 99000)
+	_, res = run(unsigned, true)
+	require.Equal(t, reflect.TypeOf(std.MemoTooLargeError{}), reflect.TypeOf(sdk.ABCIError(res.Error)), res.Log)
+}
+
+// The simulated sender signs unsigned txs in place of their first signer,
+// in simulate mode only.
+func TestAnteHandlerSimulatedSender(t *testing.T) {
+	env := setupTestEnv()
+	ctx := env.ctx
+	anteHandler := NewAnteHandler(env.acck, env.bank, DefaultSigVerificationGasConsumer)
+
+	_, _, addr1 := tu.KeyTestPubAddr()
+	_, _, unknown := tu.KeyTestPubAddr()
+	acc := env.acck.NewAccountWithAddress(ctx, addr1)
+	acc.SetCoins(tu.NewTestCoins())
+	env.acck.SetAccount(ctx, acc)
+	tx := std.Tx{Msgs: []std.Msg{tu.NewTestMsg(unknown)}, Fee: tu.NewTestFee()}
+	run := func(mode sdk.RunTxMode, simulate bool) (sdk.Context, sdk.Result) {
+		runCtx := ctx.WithMultiStore(ctx.MultiStore().MultiCacheWrap()).WithTxBytes(amino.MustMarshal(tx)).
+			WithMode(mode).WithSimulatedSender(addr1)
+		newCtx, res, _ := anteHandler(runCtx, tx, simulate)
+		return newCtx, res
+	}
+
+	simCtx, res := run(sdk.RunTxModeSimulate, true)
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, addr1, simCtx.Sender())
+	acc1 := env.acck.GetAccount(simCtx, addr1)
+	require.Equal(t, uint64(1), acc1.GetSequence())
+	require.Equal(t, tu.NewTestCoins().Sub(std.Coins{tu.NewTestFee().GasFee}), acc1.GetCoins())
+
+	// the sender is ignored outside of simulations.
+	for _, mode := range []sdk.RunTxMode{sdk.RunTxModeCheck, sdk.RunTxModeDeliver} {
+		_, res = run(mode, true)
+		require.Equal(t, reflect.TypeOf(std.UnknownAddressError{}), reflect.TypeOf(sdk.ABCIError(res.Error)), res.Log)
+	}
+}
+
+// Benchmark a recheck-heavy workload: the same txs are checked again and
+// again against the same state, as on RecheckTx after every block.
+func BenchmarkAnteHandlerRecheck(b *testing.B) {
+	for _, size := range []int{0, 1000} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			env := setupTestEnv()
+			ctx := env.ctx.WithMode(sdk.RunTxModeCheck)
+			anteHandler := NewAnteHandlerWithOptions(env.acck, env.bank, DefaultSigVerificationGasConsumer,
+				AnteOptions{SigVerifyCacheSize: size})
+
+			txs := make([]std.Tx, 10)
+			for i := range txs {
+				priv, _, addr := tu.KeyTestPubAddr()
+				acc := env.acck.NewAccountWithAddress(ctx, addr)
+				acc.SetCoins(tu.NewTestCoins())
+				env.acck.SetAccount(ctx, acc)
+				txs[i] = tu.NewTestTx(ctx.ChainID(), []std.Msg{tu.NewTestMsg(addr)},
+					[]crypto.PrivKey{priv}, []uint64{acc.GetAccountNumber()}, []uint64{0}, tu.NewTestFee())
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tx := txs[i%len(txs)]
+				checkCtx := ctx.WithMultiStore(ctx.MultiStore().MultiCacheWrap())
+				if _, res, abort := anteHandler(checkCtx, tx, false); abort {
+					b.Fatal(res.Log)
+				}
+			}
+		})
+	}
+}
+
+// The signatures of txs are verified for the chain ID of InitChain after a
+// restart of the app.
+func TestAnteHandlerChainIDAfterRestart(t *testing.T) {
+	db := dbm.NewMemDB()
+	baseKey := store.NewKVStoreKey("base")
+	mainKey := store.NewKVStoreKey("main")
+	priv, _, addr := tu.KeyTestPubAddr()
+
+	newApp := func() *sdk.BaseApp {
+		app := sdk.NewBaseApp("test", log.NewNopLogger(), db, baseKey, mainKey)
+		app.MountStoreWithDB(baseKey, dbadapter.StoreConstructor, nil)
+		app.MountStoreWithDB(mainKey, iavl.StoreConstructor, nil)
+		acck := NewAccountKeeper(mainKey, std.ProtoBaseAccount)
+		app.SetInitChainer(func(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
+			acc := acck.NewAccountWithAddress(ctx, addr)
+			acc.SetCoins(tu.NewTestCoins())
+			acck.SetAccount(ctx, acc)
+			return abci.ResponseInitChain{}
+		})
+		anteHandler := NewAnteHandler(acck, NewDummyBankKeeper(acck), DefaultSigVerificationGasConsumer)
+		app.SetAnteHandler(func(ctx sdk.Context, tx std.Tx, simulate bool) (sdk.Context, sdk.Result, bool) {
+			return anteHandler(ctx.WithValue(AuthParamsContextKey{}, DefaultParams()), tx, simulate)
+		})
+		app.Router().AddRoute(tu.CounterRoute, tu.CounterHandler{Key: mainKey})
+		require.NoError(t, app.LoadLatestVersion())
+		return app
+	}
+
+	chain := tu.NewTestChain(newApp(), "test-chain")
+	chain.InitChain(abci.RequestInitChain{})
+	chain.RunBlock()
+
+	app := newApp()
+	require.Equal(t, "test-chain", app.ChainID())
+	checkTx := func(chainID string) abci.ResponseCheckTx {
+		tx := tu.NewTestTx(chainID, []std.Msg{tu.NewTestMsg(addr)},
+			[]crypto.PrivKey{priv}, []uint64{0}, []uint64{0}, tu.NewTestFee())
+		return app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(tx)})
+	}
+	res := checkTx("other-chain")
+	require.IsType(t, std.UnauthorizedError{}, res.Error, res.Log)
+	res = checkTx("test-chain")
+	require.True(t, res.IsOK(), res.Log)
+}
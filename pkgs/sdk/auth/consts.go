@@ -2,6 +2,7 @@ package auth
 
 import (
 	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/sdk"
 )
 
 const (
@@ -24,6 +25,10 @@ const (
 	GlobalAccountNumberKey = "globalAccountNumber"
 )
 
+// EventTypeFee is the type of the event emitted by the ante handler when it
+// deducts the fee of a tx, with the attributes "payer" and "amount".
+const EventTypeFee = "fee"
+
 // AddressStoreKey turn an address to key used to get it from the account store
 func AddressStoreKey(addr crypto.Address) []byte {
 	return append([]byte(AddressStoreKeyPrefix), addr.Bytes()...)
@@ -35,7 +40,7 @@ var feeCollector crypto.Address
 
 func FeeCollectorAddress() crypto.Address {
 	if feeCollector.IsZero() {
-		feeCollector = crypto.AddressFromPreimage([]byte(FeeCollectorName))
+		feeCollector = sdk.DeriveModuleAddress(FeeCollectorName)
 	}
 	return feeCollector
 }
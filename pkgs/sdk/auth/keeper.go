@@ -18,6 +18,14 @@ type AccountKeeper struct {
 
 	// The prototypical Account constructor.
 	proto func() std.Account
+
+	// The accounts of the modules, by address, see RegisterModuleAccount.
+	modules map[crypto.Address]moduleRegistration
+}
+
+type moduleRegistration struct {
+	name    string
+	blocked bool
 }
 
 // NewAccountKeeper returns a new AccountKeeper that uses go-amino to
@@ -28,9 +36,40 @@ func NewAccountKeeper(
 ) AccountKeeper {
 
 	return AccountKeeper{
-		key:   key,
-		proto: proto,
+		key:     key,
+		proto:   proto,
+		modules: make(map[crypto.Address]moduleRegistration),
+	}
+}
+
+// RegisterModuleAccount registers the account of the module name, of the
+// address derived from name, and returns the address. The account of a
+// blocked module cannot send or receive coins with msgs, e.g. MsgSend, but
+// only with the transfers of the keepers. It panics if name is empty or
+// already registered.
+func (ak AccountKeeper) RegisterModuleAccount(name string, blocked bool) crypto.Address {
+	if name == "" {
+		panic("empty module account name")
 	}
+	addr := sdk.DeriveModuleAddress(name)
+	if _, ok := ak.modules[addr]; ok {
+		panic(fmt.Sprintf("module account %q already registered", name))
+	}
+	ak.modules[addr] = moduleRegistration{name: name, blocked: blocked}
+	return addr
+}
+
+// ModuleAccountName returns the name of the module of the account of addr,
+// and whether it is the account of a registered module.
+func (ak AccountKeeper) ModuleAccountName(addr crypto.Address) (string, bool) {
+	ma, ok := ak.modules[addr]
+	return ma.name, ok
+}
+
+// IsBlockedAddress returns whether addr is the account of a registered
+// module which is blocked.
+func (ak AccountKeeper) IsBlockedAddress(addr crypto.Address) bool {
+	return ak.modules[addr].blocked
 }
 
 // Logger returns a module-specific logger.
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/sdk"
 )
 
 func TestAccountMapperGetSet(t *testing.T) {
@@ -67,3 +68,29 @@ func TestAccountMapperRemoveAccount(t *testing.T) {
 	require.NotNil(t, acc2)
 	require.Equal(t, accSeq2, acc2.GetSequence())
 }
+
+func TestModuleAccounts(t *testing.T) {
+	env := setupTestEnv()
+
+	// addresses are derived from the names, as the fee collector's.
+	require.Equal(t, FeeCollectorAddress(), sdk.DeriveModuleAddress(FeeCollectorName))
+	require.Equal(t, sdk.DeriveModuleAddress("escrow"), sdk.DeriveModuleAddress("escrow"))
+	require.NotEqual(t, sdk.DeriveModuleAddress("escrow"), sdk.DeriveModuleAddress("escrow2"))
+
+	escrow := env.acck.RegisterModuleAccount("escrow", true)
+	require.Equal(t, sdk.DeriveModuleAddress("escrow"), escrow)
+	collector := env.acck.RegisterModuleAccount(FeeCollectorName, false)
+	require.Panics(t, func() { env.acck.RegisterModuleAccount("escrow", false) })
+	require.Panics(t, func() { env.acck.RegisterModuleAccount("", false) })
+
+	name, ok := env.acck.ModuleAccountName(escrow)
+	require.True(t, ok)
+	require.Equal(t, "escrow", name)
+	require.True(t, env.acck.IsBlockedAddress(escrow))
+	require.False(t, env.acck.IsBlockedAddress(collector))
+
+	user := crypto.AddressFromPreimage([]byte("some-address"))
+	_, ok = env.acck.ModuleAccountName(user)
+	require.False(t, ok)
+	require.False(t, env.acck.IsBlockedAddress(user))
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/std"
 )
 
 type AuthParamsContextKey struct{}
@@ -16,6 +17,7 @@ const (
 	DefaultTxSizeCostPerByte      int64 = 10
 	DefaultSigVerifyCostED25519   int64 = 590
 	DefaultSigVerifyCostSecp256k1 int64 = 1000
+	DefaultSigVerifyCostSecp256r1 int64 = 2000
 )
 
 // Params defines the parameters for the auth module.
@@ -25,11 +27,37 @@ type Params struct {
 	TxSizeCostPerByte      int64 `json:"tx_size_cost_per_byte" yaml:"tx_size_cost_per_byte"`
 	SigVerifyCostED25519   int64 `json:"sig_verify_cost_ed25519" yaml:"sig_verify_cost_ed25519"`
 	SigVerifyCostSecp256k1 int64 `json:"sig_verify_cost_secp256k1" yaml:"sig_verify_cost_secp256k1"`
+	SigVerifyCostSecp256r1 int64 `json:"sig_verify_cost_secp256r1" yaml:"sig_verify_cost_secp256r1"`
+
+	// GlobalMinGasPrices are the minimum gas prices of the fees of the
+	// delivered txs, as the local minimum gas prices of the nodes for the
+	// checked txs. Empty by default. See EnsureSufficientGlobalFees.
+	GlobalMinGasPrices std.DecCoins `json:"global_min_gas_prices" yaml:"global_min_gas_prices"`
+}
+
+// ParamGlobalMinGasPrices is the key of the param of the
+// GlobalMinGasPrices, of type std.DecCoins, in a params subspace of auth.
+// Apps read it at the beginning of each block, so that its changes take
+// effect in the next block.
+const ParamGlobalMinGasPrices = "global_min_gas_prices"
+
+// ValidateGlobalMinGasPrices returns an error if value, the std.DecCoins of
+// the param ParamGlobalMinGasPrices, are not sorted by denomination with
+// positive amounts.
+func ValidateGlobalMinGasPrices(value interface{}) error {
+	prices, ok := value.(std.DecCoins)
+	if !ok {
+		return fmt.Errorf("global min gas prices of type %T", value)
+	}
+	if !prices.IsValid() {
+		return fmt.Errorf("invalid global min gas prices %s: they must be positive, sorted by denomination", prices)
+	}
+	return nil
 }
 
 // NewParams creates a new Params object
 func NewParams(maxMemoBytes, txSigLimit, txSizeCostPerByte,
-	sigVerifyCostED25519, sigVerifyCostSecp256k1 int64) Params {
+	sigVerifyCostED25519, sigVerifyCostSecp256k1, sigVerifyCostSecp256r1 int64) Params {
 
 	return Params{
 		MaxMemoBytes:           maxMemoBytes,
@@ -37,6 +65,7 @@ func NewParams(maxMemoBytes, txSigLimit, txSizeCostPerByte,
 		TxSizeCostPerByte:      txSizeCostPerByte,
 		SigVerifyCostED25519:   sigVerifyCostED25519,
 		SigVerifyCostSecp256k1: sigVerifyCostSecp256k1,
+		SigVerifyCostSecp256r1: sigVerifyCostSecp256r1,
 	}
 }
 
@@ -53,6 +82,7 @@ func DefaultParams() Params {
 		TxSizeCostPerByte:      DefaultTxSizeCostPerByte,
 		SigVerifyCostED25519:   DefaultSigVerifyCostED25519,
 		SigVerifyCostSecp256k1: DefaultSigVerifyCostSecp256k1,
+		SigVerifyCostSecp256r1: DefaultSigVerifyCostSecp256r1,
 	}
 }
 
@@ -65,5 +95,7 @@ func (p Params) String() string {
 	sb.WriteString(fmt.Sprintf("TxSizeCostPerByte: %d\n", p.TxSizeCostPerByte))
 	sb.WriteString(fmt.Sprintf("SigVerifyCostED25519: %d\n", p.SigVerifyCostED25519))
 	sb.WriteString(fmt.Sprintf("SigVerifyCostSecp256k1: %d\n", p.SigVerifyCostSecp256k1))
+	sb.WriteString(fmt.Sprintf("SigVerifyCostSecp256r1: %d\n", p.SigVerifyCostSecp256r1))
+	sb.WriteString(fmt.Sprintf("GlobalMinGasPrices: %s\n", p.GlobalMinGasPrices))
 	return sb.String()
 }
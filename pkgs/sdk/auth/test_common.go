@@ -48,7 +48,7 @@ func (ma moduleAccount) GetPermissions() []string {
 func setupTestEnv() testEnv {
 	db := dbm.NewMemDB()
 
-	authCapKey := store.NewStoreKey("authCapKey")
+	authCapKey := store.NewKVStoreKey("authCapKey")
 
 	ms := store.NewCommitMultiStore(db)
 	ms.MountStoreWithDB(authCapKey, iavl.StoreConstructor, db)
@@ -83,8 +83,8 @@ func (bank DummyBankKeeper) SendCoins(ctx sdk.Context, fromAddr crypto.Address,
 		toAcc = bank.acck.NewAccountWithAddress(ctx, toAddr)
 	}
 
-	newFromCoins := fromAcc.GetCoins().SubUnsafe(amt)
-	if !newFromCoins.IsValid() {
+	newFromCoins, ok := fromAcc.GetCoins().SafeSub(amt)
+	if !ok {
 		return std.ErrInsufficientCoins(fromAcc.GetCoins().String())
 	}
 	newToCoins := toAcc.GetCoins().Add(amt)
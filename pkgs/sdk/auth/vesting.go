@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// VestingSchedule is the genesis state of a vesting account: its Coins
+// vest linearly from StartTime to EndTime, or all at EndTime if StartTime
+// is zero.
+type VestingSchedule struct {
+	Address   crypto.Address `json:"address"`
+	Coins     std.Coins      `json:"coins"`
+	StartTime time.Time      `json:"start_time"`
+	EndTime   time.Time      `json:"end_time"`
+}
+
+// account returns the vesting account of the schedule, of base.
+func (vs VestingSchedule) account(base std.BaseAccount) std.VestingAccount {
+	if vs.StartTime.IsZero() {
+		return std.NewDelayedVestingAccount(base, vs.Coins, vs.EndTime)
+	}
+	return std.NewContinuousVestingAccount(base, vs.Coins, vs.StartTime, vs.EndTime)
+}
+
+// ValidateBasic returns an error if the address is zero, or the vesting
+// account of the schedule is invalid.
+func (vs VestingSchedule) ValidateBasic() error {
+	if vs.Address.IsZero() {
+		return fmt.Errorf("zero vesting address")
+	}
+	return vs.account(std.BaseAccount{Address: vs.Address}).Validate()
+}
+
+// VestingGenesis is the sdk.GenesisModule of the vesting accounts, whose
+// genesis state is the amino JSON list of their VestingSchedules. The
+// accounts are created with the coins of their schedules, or converted to
+// vesting accounts, with the coins added, if they exist. Coins added to
+// them afterwards, e.g. by the InitChainer, are not locked.
+type VestingGenesis struct {
+	acck AccountKeeper
+}
+
+var _ sdk.GenesisModule = VestingGenesis{}
+
+// NewVestingGenesis returns the genesis module of the vesting accounts of
+// acck.
+func NewVestingGenesis(acck AccountKeeper) VestingGenesis {
+	return VestingGenesis{acck: acck}
+}
+
+func decodeVestingSchedules(state json.RawMessage) ([]VestingSchedule, error) {
+	var schedules []VestingSchedule
+	if state == nil {
+		return nil, nil
+	}
+	err := amino.UnmarshalJSON(state, &schedules)
+	return schedules, err
+}
+
+// ValidateGenesis implements sdk.GenesisModule. An address can have only
+// one schedule.
+func (vg VestingGenesis) ValidateGenesis(state json.RawMessage) error {
+	schedules, err := decodeVestingSchedules(state)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	seen := make(map[crypto.Address]bool, len(schedules))
+	for i, vs := range schedules {
+		if err := vs.ValidateBasic(); err != nil {
+			errs = append(errs, fmt.Errorf("vesting[%d]: %w", i, err))
+		}
+		if seen[vs.Address] {
+			errs = append(errs, fmt.Errorf("vesting[%d]: duplicate address %s", i, vs.Address))
+		}
+		seen[vs.Address] = true
+	}
+	return errors.Join(errs...)
+}
+
+// InitGenesis implements sdk.GenesisModule.
+func (vg VestingGenesis) InitGenesis(ctx sdk.Context, state json.RawMessage) {
+	schedules, err := decodeVestingSchedules(state)
+	if err != nil {
+		panic(err)
+	}
+	for _, vs := range schedules {
+		base := std.BaseAccount{Address: vs.Address}
+		if acc := vg.acck.GetAccount(ctx, vs.Address); acc != nil {
+			base.Coins = acc.GetCoins()
+			base.PubKey = acc.GetPubKey()
+			base.AccountNumber = acc.GetAccountNumber()
+			base.Sequence = acc.GetSequence()
+		} else {
+			base.AccountNumber = vg.acck.GetNextAccountNumber(ctx)
+		}
+		base.Coins = base.Coins.Add(vs.Coins)
+		vg.acck.SetAccount(ctx, vs.account(base))
+	}
+}
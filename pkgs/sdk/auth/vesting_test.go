@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
+	tu "github.com/gnolang/gno/pkgs/sdk/testutils"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// Fees are paid with the vested coins only.
+func TestAnteHandlerFeesVesting(t *testing.T) {
+	env := setupTestEnv()
+	anteHandler := NewAnteHandler(env.acck, env.bank, DefaultSigVerificationGasConsumer)
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Hour)
+
+	priv1, _, addr1 := tu.KeyTestPubAddr()
+	base := std.NewBaseAccountWithAddress(addr1)
+	base.Coins = std.NewCoins(std.NewCoin("atom", 300))
+	env.acck.SetAccount(env.ctx, std.NewContinuousVestingAccount(
+		base, std.NewCoins(std.NewCoin("atom", 300)), start, end))
+
+	fee := tu.NewTestFee() // 150atom
+	tx := tu.NewTestTx(env.ctx.ChainID(), []std.Msg{tu.NewTestMsg(addr1)},
+		[]crypto.PrivKey{priv1}, []uint64{0}, []uint64{0}, fee)
+
+	// 149atom are vested.
+	ctx := env.ctx.WithBlockHeader(&bft.Header{Height: 1, ChainID: "test-chain-id", Time: start.Add(2980 * time.Minute)})
+	checkInvalidTx(t, anteHandler, ctx, tx, false, std.InsufficientFundsError{})
+
+	// 150atom are vested.
+	ctx = env.ctx.WithBlockHeader(&bft.Header{Height: 1, ChainID: "test-chain-id", Time: start.Add(50 * time.Hour)})
+	checkValidTx(t, anteHandler, ctx, tx, false)
+	acc := env.acck.GetAccount(ctx, addr1)
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 150)), acc.GetCoins())
+	require.True(t, std.SpendableCoins(acc, ctx.BlockTime()).IsZero())
+}
+
+func TestVestingGenesis(t *testing.T) {
+	env := setupTestEnv()
+	vg := NewVestingGenesis(env.acck)
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	addr1 := crypto.AddressFromPreimage([]byte("addr1"))
+	addr2 := crypto.AddressFromPreimage([]byte("addr2"))
+	schedules := []VestingSchedule{
+		{Address: addr1, Coins: std.NewCoins(std.NewCoin("atom", 100)), StartTime: start, EndTime: start.Add(time.Hour)},
+		{Address: addr2, Coins: std.NewCoins(std.NewCoin("atom", 50)), EndTime: start},
+	}
+	state := json.RawMessage(amino.MustMarshalJSON(schedules))
+	require.NoError(t, vg.ValidateGenesis(state))
+
+	// an existing account keeps its coins and account number, unlocked.
+	acc2 := env.acck.NewAccountWithAddress(env.ctx, addr2)
+	acc2.SetCoins(std.NewCoins(std.NewCoin("atom", 10)))
+	env.acck.SetAccount(env.ctx, acc2)
+	vg.InitGenesis(env.ctx, state)
+
+	cva, ok := env.acck.GetAccount(env.ctx, addr1).(*std.ContinuousVestingAccount)
+	require.True(t, ok)
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 100)), cva.GetCoins())
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 50)), cva.SpendableCoins(start.Add(30*time.Minute)))
+	require.Equal(t, uint64(1), cva.GetAccountNumber())
+
+	dva, ok := env.acck.GetAccount(env.ctx, addr2).(*std.DelayedVestingAccount)
+	require.True(t, ok)
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 60)), dva.GetCoins())
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 10)), dva.SpendableCoins(start.Add(-time.Second)))
+	require.Equal(t, acc2.GetAccountNumber(), dva.GetAccountNumber())
+
+	// all the invalid schedules are reported.
+	err := vg.ValidateGenesis(json.RawMessage(amino.MustMarshalJSON([]VestingSchedule{
+		schedules[0],
+		{Address: addr1, Coins: std.NewCoins(std.NewCoin("atom", 1)), EndTime: start},
+		{Address: addr2, Coins: std.NewCoins(std.NewCoin("atom", 1)), StartTime: start, EndTime: start},
+	})))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "vesting[1]: duplicate address")
+	require.Contains(t, err.Error(), "vesting[2]: vesting end time")
+	require.NoError(t, vg.ValidateGenesis(nil))
+}
@@ -0,0 +1,315 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+	"github.com/gnolang/gno/pkgs/crypto/multisig"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/crypto/secp256r1"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/log"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/sdk/auth"
+	tu "github.com/gnolang/gno/pkgs/sdk/testutils"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/iavl"
+)
+
+const testChainID = "test-chain-id"
+
+// newTestApp returns a BaseApp with the auth ante handler and the bank
+// handler, where every address in genesis starts with 10000atom.
+func newTestApp(t testing.TB, genesis []crypto.Address) (*sdk.BaseApp, auth.AccountKeeper, BankKeeper) {
+	db := dbm.NewMemDB()
+	mainKey := store.NewKVStoreKey("main")
+	baseKey := store.NewKVStoreKey("base")
+
+	app := sdk.NewBaseApp("test", log.NewNopLogger(), db, baseKey, mainKey)
+	app.MountStoreWithDB(mainKey, iavl.StoreConstructor, db)
+	app.MountStoreWithDB(baseKey, dbadapter.StoreConstructor, db)
+
+	acck := auth.NewAccountKeeper(mainKey, std.ProtoBaseAccount)
+	bank := NewBankKeeper(acck)
+
+	app.SetInitChainer(func(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
+		for _, addr := range genesis {
+			acc := acck.NewAccountWithAddress(ctx, addr)
+			acck.SetAccount(ctx, acc)
+			require.NoError(t, bank.SetCoins(ctx, addr, std.NewCoins(std.NewCoin("atom", 10000))))
+		}
+		return abci.ResponseInitChain{}
+	})
+	anteHandler := auth.NewAnteHandler(acck, bank, auth.DefaultSigVerificationGasConsumer)
+	app.SetAnteHandler(func(ctx sdk.Context, tx std.Tx, simulate bool) (sdk.Context, sdk.Result, bool) {
+		ctx = ctx.WithValue(auth.AuthParamsContextKey{}, auth.DefaultParams())
+		return anteHandler(ctx, tx, simulate)
+	})
+	app.Router().AddRoute("bank", NewHandler(bank))
+	require.NoError(t, app.LoadLatestVersion())
+
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: testChainID, Height: 1}})
+	return app, acck, bank
+}
+
+func deliverTx(t *testing.T, app *sdk.BaseApp, tx std.Tx) abci.ResponseDeliverTx {
+	return app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(tx)})
+}
+
+func TestDeliverTxEd25519(t *testing.T) {
+	priv := ed25519.GenPrivKey()
+	addr := priv.PubKey().Address()
+	_, _, to := tu.KeyTestPubAddr()
+
+	app, acck, bank := newTestApp(t, []crypto.Address{addr})
+
+	fee := std.NewFee(100000, std.NewCoin("atom", 10))
+	msgs := []std.Msg{NewMsgSend(addr, to, std.NewCoins(std.NewCoin("atom", 100)))}
+	signBytes := std.SignBytes(testChainID, 0, 0, fee, msgs, "")
+	sig, err := priv.Sign(signBytes)
+	require.NoError(t, err)
+	tx := std.NewTx(msgs, fee, []std.Signature{{PubKey: priv.PubKey(), Signature: sig}}, "")
+
+	res := deliverTx(t, app, tx)
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	ctx := app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1})
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 10000-100-10)), bank.GetCoins(ctx, addr))
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 100)), bank.GetCoins(ctx, to))
+	acc := acck.GetAccount(ctx, addr)
+	require.Equal(t, priv.PubKey(), acc.GetPubKey())
+	require.Equal(t, uint64(1), acc.GetSequence())
+
+	// a replayed tx is rejected
+	res = deliverTx(t, app, tx)
+	require.False(t, res.IsOK())
+}
+
+func TestCheckTxSender(t *testing.T) {
+	priv1, priv2 := secp256k1.GenPrivKey(), ed25519.GenPrivKey()
+	addr1, addr2 := priv1.PubKey().Address(), priv2.PubKey().Address()
+	app, _, _ := newTestApp(t, []crypto.Address{addr1, addr2})
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	// the sender is the first signer.
+	fee := std.NewFee(100000, std.NewCoin("atom", 10))
+	msgs := []std.Msg{NewMsgSend(addr2, addr1, std.NewCoins(std.NewCoin("atom", 1))), NewMsgSend(addr1, addr2, std.NewCoins(std.NewCoin("atom", 1)))}
+	var sigs []std.Signature
+	for i, priv := range []crypto.PrivKey{priv2, priv1} {
+		sig, err := priv.Sign(std.SignBytes(testChainID, uint64(1-i), 0, fee, msgs, ""))
+		require.NoError(t, err)
+		sigs = append(sigs, std.Signature{PubKey: priv.PubKey(), Signature: sig})
+	}
+	tx := std.NewTx(msgs, fee, sigs, "")
+	res := app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(tx)})
+	require.True(t, res.IsOK(), "%v", res.Log)
+	require.Equal(t, addr2.String(), res.Sender)
+
+	// rejected txs have no sender.
+	res = app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(tx)})
+	require.False(t, res.IsOK())
+	require.Empty(t, res.Sender)
+}
+
+func TestDeliverTxSecp256r1(t *testing.T) {
+	priv := secp256r1.GenPrivKey()
+	addr := priv.PubKey().Address()
+	_, _, to := tu.KeyTestPubAddr()
+
+	app, acck, bank := newTestApp(t, []crypto.Address{addr})
+
+	fee := std.NewFee(100000, std.NewCoin("atom", 10))
+	msgs := []std.Msg{NewMsgSend(addr, to, std.NewCoins(std.NewCoin("atom", 100)))}
+	signBytes := std.SignBytes(testChainID, 0, 0, fee, msgs, "")
+	sig, err := priv.Sign(signBytes)
+	require.NoError(t, err)
+	tx := std.NewTx(msgs, fee, []std.Signature{{PubKey: priv.PubKey(), Signature: sig}}, "")
+
+	res := deliverTx(t, app, tx)
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	ctx := app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1})
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 10000-100-10)), bank.GetCoins(ctx, addr))
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 100)), bank.GetCoins(ctx, to))
+	acc := acck.GetAccount(ctx, addr)
+	require.Equal(t, priv.PubKey(), acc.GetPubKey())
+	require.Equal(t, uint64(1), acc.GetSequence())
+
+	// the second tx of the account, signed by a secp256r1 key of a
+	// multisig with a secp256k1 key.
+	pubs := []crypto.PubKey{priv.PubKey(), secp256k1.GenPrivKey().PubKey()}
+	msPub := multisig.NewPubKeyMultisigThreshold(1, pubs)
+	msAddr := msPub.Address()
+	msgs = []std.Msg{NewMsgSend(addr, msAddr, std.NewCoins(std.NewCoin("atom", 1000)))}
+	signBytes = std.SignBytes(testChainID, 0, 1, fee, msgs, "")
+	sig, err = priv.Sign(signBytes)
+	require.NoError(t, err)
+	res = deliverTx(t, app, std.NewTx(msgs, fee, []std.Signature{{PubKey: priv.PubKey(), Signature: sig}}, ""))
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	msgs = []std.Msg{NewMsgSend(msAddr, to, std.NewCoins(std.NewCoin("atom", 100)))}
+	msAcc := acck.GetAccount(app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1}), msAddr)
+	signBytes = std.SignBytes(testChainID, msAcc.GetAccountNumber(), 0, fee, msgs, "")
+	sig, err = priv.Sign(signBytes)
+	require.NoError(t, err)
+	msSig := multisig.NewMultisig(len(pubs))
+	require.NoError(t, msSig.AddSignatureFromPubKey(sig, pubs[0], pubs))
+	res = deliverTx(t, app, std.NewTx(msgs, fee, []std.Signature{{PubKey: msPub, Signature: amino.MustMarshal(msSig)}}, ""))
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	ctx = app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1})
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 1000-100-10)), bank.GetCoins(ctx, msAddr))
+}
+
+func TestDeliverTxMixedMultisig(t *testing.T) {
+	privs := []crypto.PrivKey{
+		ed25519.GenPrivKey(),
+		secp256k1.GenPrivKey(),
+		ed25519.GenPrivKey(),
+	}
+	pubs := make([]crypto.PubKey, len(privs))
+	for i, priv := range privs {
+		pubs[i] = priv.PubKey()
+	}
+	msPub := multisig.NewPubKeyMultisigThreshold(2, pubs)
+	addr := msPub.Address()
+	_, _, to := tu.KeyTestPubAddr()
+
+	app, _, bank := newTestApp(t, []crypto.Address{addr})
+
+	fee := std.NewFee(100000, std.NewCoin("atom", 10))
+	msgs := []std.Msg{NewMsgSend(addr, to, std.NewCoins(std.NewCoin("atom", 100)))}
+	signBytes := std.SignBytes(testChainID, 0, 0, fee, msgs, "")
+
+	// one ed25519 and one secp256k1 signature meet the threshold.
+	msSig := multisig.NewMultisig(len(pubs))
+	for _, i := range []int{0, 1} {
+		sig, err := privs[i].Sign(signBytes)
+		require.NoError(t, err)
+		require.NoError(t, msSig.AddSignatureFromPubKey(sig, pubs[i], pubs))
+	}
+	tx := std.NewTx(msgs, fee, []std.Signature{{PubKey: msPub, Signature: amino.MustMarshal(msSig)}}, "")
+
+	res := deliverTx(t, app, tx)
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	ctx := app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1})
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 10000-100-10)), bank.GetCoins(ctx, addr))
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 100)), bank.GetCoins(ctx, to))
+}
+
+func TestDeliverTxSignDataRejected(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	addr := priv.PubKey().Address()
+
+	app, acck, _ := newTestApp(t, []crypto.Address{addr})
+
+	// the offline signed tx, and a tx with a MsgSignData that is otherwise
+	// valid, are both rejected.
+	data := []byte("I control this address")
+	sig, err := priv.Sign(std.SignDataBytes(addr, data))
+	require.NoError(t, err)
+	require.NoError(t, std.VerifyArbitraryData(addr, priv.PubKey(), data, sig))
+	offline := std.SignDataTx(addr, data)
+	offline.Signatures = []std.Signature{{PubKey: priv.PubKey(), Signature: sig}}
+
+	fee := std.NewFee(100000, std.NewCoin("atom", 10))
+	msgs := []std.Msg{std.NewMsgSignData(addr, data)}
+	sig, err = priv.Sign(std.SignBytes(testChainID, 0, 0, fee, msgs, ""))
+	require.NoError(t, err)
+	online := std.NewTx(msgs, fee, []std.Signature{{PubKey: priv.PubKey(), Signature: sig}}, "")
+
+	for _, tx := range []std.Tx{offline, online} {
+		res := app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(tx)})
+		require.IsType(t, std.UnknownRequestError{}, res.Error, "%v", res.Log)
+		res2 := deliverTx(t, app, tx)
+		require.IsType(t, std.UnknownRequestError{}, res2.Error, "%v", res2.Log)
+	}
+
+	// no fee was charged and the sequence did not change.
+	ctx := app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1})
+	acc := acck.GetAccount(ctx, addr)
+	require.Equal(t, uint64(0), acc.GetSequence())
+	require.Equal(t, std.NewCoins(std.NewCoin("atom", 10000)), acc.GetCoins())
+}
+
+// The unsigned simulation of msgs with a sender estimates at least the gas
+// of the simulation of the tx of the msgs signed by the sender.
+func TestSimulateUnsigned(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	addr := priv.PubKey().Address()
+	_, _, to := tu.KeyTestPubAddr()
+	app, _, _ := newTestApp(t, []crypto.Address{addr})
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	simulate := func(path string, data []byte) sdk.Result {
+		t.Helper()
+		qres := app.Query(abci.RequestQuery{Path: path, Data: data})
+		require.True(t, qres.IsOK(), qres.Log)
+		var res sdk.Result
+		require.NoError(t, amino.Unmarshal(qres.Value, &res))
+		return res
+	}
+
+	fee := std.NewFee(100000, std.NewCoin("atom", 10))
+	msgs := []std.Msg{NewMsgSend(addr, to, std.NewCoins(std.NewCoin("atom", 100)))}
+	sig, err := priv.Sign(std.SignBytes(testChainID, 0, 0, fee, msgs, "memo"))
+	require.NoError(t, err)
+	signed := std.NewTx(msgs, fee, []std.Signature{{PubKey: priv.PubKey(), Signature: sig}}, "memo")
+	signedRes := simulate("/.app/simulate", amino.MustMarshal(signed))
+	require.True(t, signedRes.IsOK(), signedRes.Log)
+
+	req := sdk.SimulateUnsignedRequest{Msgs: msgs, Fee: fee, Memo: "memo", Sender: addr}
+	res := simulate("/.app/simulate_unsigned", amino.MustMarshal(req))
+	require.True(t, res.IsOK(), res.Log)
+	require.GreaterOrEqual(t, res.GasUsed, signedRes.GasUsed)
+	require.LessOrEqual(t, res.GasUsed, signedRes.GasUsed+100)
+
+	// the sender signs in place of the signer of the msgs, e.g. an address
+	// without an account.
+	_, _, unknown := tu.KeyTestPubAddr()
+	req.Msgs = []std.Msg{NewMsgSend(unknown, to, std.NewCoins(std.NewCoin("atom", 100)))}
+	req.Sender = to
+	res = simulate("/.app/simulate_unsigned", amino.MustMarshal(req))
+	require.IsType(t, std.UnknownAddressError{}, res.Error, "%v", res.Log)
+	req.Sender = addr
+	res = simulate("/.app/simulate_unsigned", amino.MustMarshal(req))
+	require.IsType(t, std.InsufficientCoinsError{}, res.Error, "%v", res.Log)
+
+	// a sender is required.
+	req.Sender = crypto.Address{}
+	qres := app.Query(abci.RequestQuery{Path: "/.app/simulate_unsigned", Data: amino.MustMarshal(req)})
+	require.IsType(t, std.InvalidAddressError{}, qres.Error, "%v", qres.Log)
+}
+
+func BenchmarkReplaySend(b *testing.B) {
+	privs := make([]crypto.PrivKey, 10)
+	addrs := make([]crypto.Address, len(privs))
+	accNums := make([]uint64, len(privs))
+	for i := range privs {
+		privs[i] = ed25519.GenPrivKey()
+		addrs[i] = privs[i].PubKey().Address()
+		accNums[i] = uint64(i)
+	}
+	app, _, _ := newTestApp(b, addrs)
+
+	fee := std.NewFee(100000, std.NewCoin("atom", 1))
+	tu.BlockReplayer{
+		App:         app,
+		Generate:    NewSendTxGenerator(testChainID, privs, accNums, std.NewCoins(std.NewCoin("atom", 1)), fee),
+		Blocks:      5,
+		TxsPerBlock: 50,
+		ChainID:     testChainID,
+	}.Benchmark(b)
+}
@@ -15,15 +15,16 @@ import (
 )
 
 type testEnv struct {
-	ctx  sdk.Context
-	bank BankKeeper
-	acck auth.AccountKeeper
+	ctx      sdk.Context
+	bank     BankKeeper
+	acck     auth.AccountKeeper
+	metadata MetadataKeeper
 }
 
 func setupTestEnv() testEnv {
 	db := dbm.NewMemDB()
 
-	authCapKey := store.NewStoreKey("authCapKey")
+	authCapKey := store.NewKVStoreKey("authCapKey")
 
 	ms := store.NewCommitMultiStore(db)
 	ms.MountStoreWithDB(authCapKey, iavl.StoreConstructor, db)
@@ -36,5 +37,7 @@ func setupTestEnv() testEnv {
 
 	bank := NewBankKeeper(acck)
 
-	return testEnv{ctx: ctx, bank: bank, acck: acck}
+	metadata := NewMetadataKeeper(authCapKey)
+
+	return testEnv{ctx: ctx, bank: bank, acck: acck, metadata: metadata}
 }
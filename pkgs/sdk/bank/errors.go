@@ -14,12 +14,16 @@ func (_ abciError) AssertABCIError() {}
 type NoInputsError struct{ abciError }
 type NoOutputsError struct{ abciError }
 type InputOutputMismatchError struct{ abciError }
+type BlockedAddressError struct{ abciError }
 
 func (e NoInputsError) Error() string  { return "no inputs in send transaction" }
 func (e NoOutputsError) Error() string { return "no outputs in send transaction" }
 func (e InputOutputMismatchError) Error() string {
 	return "sum inputs != sum outputs in send transaction"
 }
+func (e BlockedAddressError) Error() string {
+	return "blocked module account in send transaction"
+}
 
 func ErrNoInputs() error {
 	return errors.Wrap(NoInputsError{}, "")
@@ -30,3 +34,6 @@ func ErrNoOutputs() error {
 func ErrInputOutputMismatch() error {
 	return errors.Wrap(InputOutputMismatchError{}, "")
 }
+func ErrBlockedAddress(msg string) error {
+	return errors.Wrap(BlockedAddressError{}, msg)
+}
@@ -1,6 +1,7 @@
 package bank
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -12,7 +13,8 @@ import (
 )
 
 type bankHandler struct {
-	bank BankKeeper
+	bank     BankKeeper
+	metadata *MetadataKeeper
 }
 
 // NewHandler returns a handler for "bank" type messages.
@@ -22,6 +24,13 @@ func NewHandler(bank BankKeeper) bankHandler {
 	}
 }
 
+// WithDenomMetadata returns the handler, serving the denom metadata of mk
+// on the "denom_metadata" query path.
+func (bh bankHandler) WithDenomMetadata(mk MetadataKeeper) bankHandler {
+	bh.metadata = &mk
+	return bh
+}
+
 func (bh bankHandler) Process(ctx sdk.Context, msg std.Msg) sdk.Result {
 	switch msg := msg.(type) {
 	case MsgSend:
@@ -42,10 +51,13 @@ func (bh bankHandler) handleMsgSend(ctx sdk.Context, msg MsgSend) sdk.Result {
 		if !bh.bank.GetSendEnabled(ctx) {
 			return abciResult(ErrSendDisabled())
 		}
-		if bh.bank.BlacklistedAddr(msg.ToAddress) {
-			return std.ErrUnauthorized(fmt.Sprintf("%s is not allowed to receive transactions", msg.ToAddress)).Result()
-		}
 	*/
+	for _, addr := range []crypto.Address{msg.FromAddress, msg.ToAddress} {
+		if bh.bank.BlockedAddr(addr) {
+			return abciResult(ErrBlockedAddress(
+				fmt.Sprintf("%s is not allowed to send or receive coins", addr)))
+		}
+	}
 
 	err := bh.bank.SendCoins(ctx, msg.FromAddress, msg.ToAddress, msg.Amount)
 	if err != nil {
@@ -71,12 +83,19 @@ func (bh bankHandler) handleMsgMultiSend(ctx sdk.Context, msg MsgMultiSend) sdk.
 		if !k.GetSendEnabled(ctx) {
 			return abciResult(std.ErrSendDisabled())
 		}
-		for _, out := range msg.Outputs {
-			if bh.bank.BlacklistedAddr(out.Address) {
-				return abciResult(std.ErrUnauthorized(fmt.Sprintf("%s is not allowed to receive transactions", out.Address)))
-			}
-		}
 	*/
+	for _, in := range msg.Inputs {
+		if bh.bank.BlockedAddr(in.Address) {
+			return abciResult(ErrBlockedAddress(
+				fmt.Sprintf("%s is not allowed to send coins", in.Address)))
+		}
+	}
+	for _, out := range msg.Outputs {
+		if bh.bank.BlockedAddr(out.Address) {
+			return abciResult(ErrBlockedAddress(
+				fmt.Sprintf("%s is not allowed to receive coins", out.Address)))
+		}
+	}
 
 	err := bh.bank.InputOutputCoins(ctx, msg.Inputs, msg.Outputs)
 	if err != nil {
@@ -101,15 +120,40 @@ func (bh bankHandler) handleMsgMultiSend(ctx sdk.Context, msg MsgMultiSend) sdk.
 // query balance path
 const QueryBalance = "balances"
 
+// query denom metadata path
+const QueryDenomMetadata = "denom_metadata"
+
+// MaxDenomMetadataPageLimit is the max number of metadata of a page of the
+// denom metadata query, and the default one.
+const MaxDenomMetadataPageLimit = 100
+
+// DenomMetadataPageRequest is the JSON request data of the denom metadata
+// query: the page from the base denom Start, of at most Limit metadata.
+type DenomMetadataPageRequest struct {
+	Start string `json:"start"`
+	Limit int    `json:"limit"`
+}
+
+// DenomMetadataPage is the JSON response of the denom metadata query. Next
+// is the start of the next page, or empty if it is the last page.
+type DenomMetadataPage struct {
+	Metadata []Metadata `json:"metadata"`
+	Next     string     `json:"next"`
+}
+
 func (bh bankHandler) Query(ctx sdk.Context, req abci.RequestQuery) (res abci.ResponseQuery) {
 	switch secondPart(req.Path) {
 	case QueryBalance:
 		return bh.queryBalance(ctx, req)
-	default:
-		res = sdk.ABCIResponseQueryFromError(
-			std.ErrUnknownRequest("unknown bank query endpoint"))
-		return
+	case QueryDenomMetadata:
+		if bh.metadata == nil {
+			break
+		}
+		return bh.queryDenomMetadata(ctx, req)
 	}
+	res = sdk.ABCIResponseQueryFromError(
+		std.ErrUnknownRequest("unknown bank query endpoint"))
+	return
 }
 
 // queryBalance fetch an account's balance for the supplied height.
@@ -136,6 +180,41 @@ func (bh bankHandler) queryBalance(ctx sdk.Context, req abci.RequestQuery) (res
 	return
 }
 
+// queryDenomMetadata returns a page of the denom metadata, in the order of
+// their base denoms.
+func (bh bankHandler) queryDenomMetadata(ctx sdk.Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+	var page DenomMetadataPageRequest
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &page); err != nil {
+			res = sdk.ABCIResponseQueryFromError(
+				std.ErrUnknownRequest(fmt.Sprintf("invalid page request: %v", err)))
+			return
+		}
+	}
+	if page.Limit <= 0 || page.Limit > MaxDenomMetadataPageLimit {
+		page.Limit = MaxDenomMetadataPageLimit
+	}
+
+	resp := DenomMetadataPage{Metadata: []Metadata{}}
+	bh.metadata.IterateDenomMetadata(ctx, page.Start, func(md Metadata) bool {
+		if len(resp.Metadata) == page.Limit {
+			resp.Next = md.Base
+			return true
+		}
+		resp.Metadata = append(resp.Metadata, md)
+		return false
+	})
+	bz, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		res = sdk.ABCIResponseQueryFromError(
+			std.ErrInternal(fmt.Sprintf("could not marshal result to JSON: %s", err.Error())))
+		return
+	}
+
+	res.Data = bz
+	return
+}
+
 //----------------------------------------
 // misc
 
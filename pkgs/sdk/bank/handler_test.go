@@ -60,3 +60,46 @@ func TestQuerierRouteNotFound(t *testing.T) {
 	res := h.Query(env.ctx, req)
 	require.Error(t, res.Error)
 }
+
+func TestBlockedModuleAccount(t *testing.T) {
+	env := setupTestEnv()
+	h := NewHandler(env.bank)
+	escrow := env.acck.RegisterModuleAccount("escrow", true)
+	pool := env.acck.RegisterModuleAccount("pool", false)
+	_, _, user := tu.KeyTestPubAddr()
+	coins := std.NewCoins(std.NewCoin("foo", 10))
+
+	// genesis pre-funds the module account, as any account.
+	genesisCtx := env.ctx.WithBlockHeader(&bft.Header{ChainID: "test-chain-id"})
+	require.NoError(t, env.bank.SetCoins(genesisCtx, escrow, coins))
+	require.NoError(t, env.bank.SetCoins(genesisCtx, user, coins))
+
+	// msgs cannot send to or from a blocked module account.
+	for _, msg := range []std.Msg{
+		NewMsgSend(user, escrow, coins),
+		NewMsgSend(escrow, user, coins),
+		MsgMultiSend{
+			Inputs:  []Input{NewInput(user, coins)},
+			Outputs: []Output{NewOutput(escrow, coins)},
+		},
+		MsgMultiSend{
+			Inputs:  []Input{NewInput(escrow, coins)},
+			Outputs: []Output{NewOutput(user, coins)},
+		},
+	} {
+		res := h.Process(env.ctx, msg)
+		require.IsType(t, BlockedAddressError{}, res.Error, "%v", res.Log)
+	}
+	require.Equal(t, coins, env.bank.GetCoins(env.ctx, escrow))
+	require.Equal(t, coins, env.bank.GetCoins(env.ctx, user))
+
+	// but they can to a module account which is not blocked.
+	res := h.Process(env.ctx, NewMsgSend(user, pool, std.NewCoins(std.NewCoin("foo", 3))))
+	require.Nil(t, res.Error, res.Log)
+
+	// and the keeper can move funds of a blocked module account.
+	require.NoError(t, env.bank.SendCoins(env.ctx, escrow, user, std.NewCoins(std.NewCoin("foo", 4))))
+	require.NoError(t, env.bank.SendCoins(env.ctx, user, escrow, std.NewCoins(std.NewCoin("foo", 1))))
+	require.Equal(t, std.NewCoins(std.NewCoin("foo", 7)), env.bank.GetCoins(env.ctx, escrow))
+	require.Equal(t, std.NewCoins(std.NewCoin("foo", 3)), env.bank.GetCoins(env.ctx, pool))
+}
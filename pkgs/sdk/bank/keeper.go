@@ -41,6 +41,13 @@ func NewBankKeeper(acck auth.AccountKeeper) BankKeeper {
 	}
 }
 
+// BlockedAddr returns whether addr is the account of a blocked module, see
+// auth.AccountKeeper.RegisterModuleAccount, which msgs cannot send coins to
+// or from. The transfers of the keeper are not blocked.
+func (bank BankKeeper) BlockedAddr(addr crypto.Address) bool {
+	return bank.acck.IsBlockedAddress(addr)
+}
+
 // InputOutputCoins handles a list of inputs and outputs
 func (bank BankKeeper) InputOutputCoins(ctx sdk.Context, inputs []Input, outputs []Output) error {
 	// Safety check ensuring that when sending coins the bank must maintain the
@@ -113,28 +120,30 @@ func (bank BankKeeper) SendCoins(ctx sdk.Context, fromAddr crypto.Address, toAdd
 	return nil
 }
 
-// SubtractCoins subtracts amt from the coins at the addr.
-//
-// CONTRACT: If the account is a vesting account, the amount has to be spendable.
+// SubtractCoins subtracts amt from the coins at the addr. If the account is
+// a vesting account, amt must be of its coins which are spendable at the
+// block time.
 func (bank BankKeeper) SubtractCoins(ctx sdk.Context, addr crypto.Address, amt std.Coins) (std.Coins, error) {
 
 	if !amt.IsValid() {
 		return nil, std.ErrInvalidCoins(amt.String())
 	}
 
-	oldCoins := std.NewCoins()
+	oldCoins, spendable := std.NewCoins(), std.NewCoins()
 	acc := bank.acck.GetAccount(ctx, addr)
 	if acc != nil {
 		oldCoins = acc.GetCoins()
+		spendable = std.SpendableCoins(acc, ctx.BlockTime())
 	}
 
-	newCoins := oldCoins.SubUnsafe(amt)
-	if !newCoins.IsValid() {
+	// the locked coins of vesting accounts cannot be spent.
+	if _, ok := spendable.SafeSub(amt); !ok {
 		err := std.ErrInsufficientCoins(
-			fmt.Sprintf("insufficient account funds; %s < %s", oldCoins, amt),
+			fmt.Sprintf("insufficient account funds; %s < %s", spendable, amt),
 		)
 		return nil, err
 	}
+	newCoins, _ := oldCoins.SafeSub(amt)
 	err := bank.SetCoins(ctx, addr, newCoins)
 
 	return newCoins, err
@@ -148,11 +157,10 @@ func (bank BankKeeper) AddCoins(ctx sdk.Context, addr crypto.Address, amt std.Co
 	}
 
 	oldCoins := bank.GetCoins(ctx, addr)
-	newCoins := oldCoins.Add(amt)
-
-	if !newCoins.IsValid() {
-		return amt, std.ErrInsufficientCoins(
-			fmt.Sprintf("insufficient account funds; %s < %s", oldCoins, amt),
+	newCoins, ok := oldCoins.SafeAdd(amt)
+	if !ok {
+		return amt, std.ErrInvalidCoins(
+			fmt.Sprintf("invalid account funds; %s + %s", oldCoins, amt),
 		)
 	}
 
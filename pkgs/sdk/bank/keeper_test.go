@@ -2,9 +2,11 @@ package bank
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	bft "github.com/gnolang/gno/pkgs/bft/types"
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/sdk"
 	"github.com/gnolang/gno/pkgs/std"
@@ -154,3 +156,41 @@ func TestViewKeeper(t *testing.T) {
 	require.False(t, view.HasCoins(ctx, addr, std.NewCoins(std.NewCoin("foocoin", 15))))
 	require.False(t, view.HasCoins(ctx, addr, std.NewCoins(std.NewCoin("barcoin", 5))))
 }
+
+func TestBankKeeperVesting(t *testing.T) {
+	env := setupTestEnv()
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	atHeader := func(t time.Time) sdk.Context {
+		return env.ctx.WithBlockHeader(&bft.Header{ChainID: "test-chain-id", Height: 1, Time: t})
+	}
+
+	addr := crypto.AddressFromPreimage([]byte("vesting"))
+	addr2 := crypto.AddressFromPreimage([]byte("addr2"))
+	base := std.NewBaseAccountWithAddress(addr)
+	base.Coins = std.NewCoins(std.NewCoin("foocoin", 100))
+	env.acck.SetAccount(env.ctx, std.NewContinuousVestingAccount(
+		base, std.NewCoins(std.NewCoin("foocoin", 100)), start, end))
+	foo := func(amount int64) std.Coins { return std.NewCoins(std.NewCoin("foocoin", amount)) }
+
+	// nothing is spendable at the start, the whole balance is displayed.
+	_, err := env.bank.SubtractCoins(atHeader(start), addr, foo(1))
+	require.Error(t, err)
+	require.Equal(t, foo(100), env.bank.GetCoins(env.ctx, addr))
+
+	// exactly the vested coins can be spent.
+	ctx := atHeader(start.Add(3 * time.Hour))
+	require.Error(t, env.bank.SendCoins(ctx, addr, addr2, foo(31)))
+	require.NoError(t, env.bank.SendCoins(ctx, addr, addr2, foo(30)))
+	require.Error(t, env.bank.SendCoins(ctx, addr, addr2, foo(1)))
+	require.Equal(t, foo(70), env.bank.GetCoins(env.ctx, addr))
+
+	// received coins are spendable.
+	require.NoError(t, env.bank.SendCoins(ctx, addr2, addr, foo(5)))
+	require.NoError(t, env.bank.SendCoins(ctx, addr, addr2, foo(5)))
+
+	// all is spendable at the end.
+	ctx = atHeader(end)
+	require.NoError(t, env.bank.SendCoins(ctx, addr, addr2, foo(70)))
+	require.True(t, env.bank.GetCoins(env.ctx, addr).IsZero())
+}
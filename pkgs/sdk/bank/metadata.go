@@ -0,0 +1,155 @@
+package bank
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// DenomMetadataStoreKeyPrefix is the prefix of the keys of the denom
+// metadata, by base denom, in the store of the MetadataKeeper.
+const DenomMetadataStoreKeyPrefix = "/bank/metadata/"
+
+// MaxDenomExponent is the max exponent of a display denom.
+const MaxDenomExponent = 18
+
+// Metadata describes a denom for display, e.g. the base denom "ugnot" is
+// displayed as "GNOT", of exponent 6: 1 GNOT is 10^6 ugnot.
+type Metadata struct {
+	Base        string `json:"base"`
+	Display     string `json:"display"`
+	Exponent    uint32 `json:"exponent"`
+	Description string `json:"description"`
+}
+
+// ValidateBasic returns an error if the base denom is invalid, the display
+// denom is empty or contains spaces, or the exponent is over
+// MaxDenomExponent.
+func (md Metadata) ValidateBasic() error {
+	if !(std.Coin{Denom: md.Base}).IsValid() {
+		return fmt.Errorf("invalid base denom %q", md.Base)
+	}
+	if md.Display == "" || strings.ContainsAny(md.Display, " \t\n") {
+		return fmt.Errorf("invalid display denom %q of %s", md.Display, md.Base)
+	}
+	if md.Exponent > MaxDenomExponent {
+		return fmt.Errorf("exponent %d of %s is over %d", md.Exponent, md.Base, MaxDenomExponent)
+	}
+	return nil
+}
+
+// ValidateDenomMetadata returns the errors of all the invalid metadata of
+// mds, and of the base denoms which are not unique.
+func ValidateDenomMetadata(mds []Metadata) error {
+	var errs []error
+	seen := make(map[string]bool, len(mds))
+	for i, md := range mds {
+		if err := md.ValidateBasic(); err != nil {
+			errs = append(errs, fmt.Errorf("metadata[%d]: %w", i, err))
+		}
+		if seen[md.Base] {
+			errs = append(errs, fmt.Errorf("metadata[%d]: duplicate base denom %s", i, md.Base))
+		}
+		seen[md.Base] = true
+	}
+	return errors.Join(errs...)
+}
+
+// MetadataKeeper manages the registry of denom metadata, by base denom, in
+// the store of key. It is the sdk.GenesisModule of the registry, whose
+// genesis state is the amino JSON list of the metadata.
+type MetadataKeeper struct {
+	key store.StoreKey
+}
+
+var _ sdk.GenesisModule = MetadataKeeper{}
+
+// NewMetadataKeeper returns a MetadataKeeper of the metadata in the store of
+// key.
+func NewMetadataKeeper(key store.StoreKey) MetadataKeeper {
+	return MetadataKeeper{key: key}
+}
+
+func metadataKey(base string) []byte {
+	return []byte(DenomMetadataStoreKeyPrefix + base)
+}
+
+// SetDenomMetadata sets the metadata of the base denom of md, replacing the
+// previous one, if any, or returns an error if md is invalid.
+func (mk MetadataKeeper) SetDenomMetadata(ctx sdk.Context, md Metadata) error {
+	if err := md.ValidateBasic(); err != nil {
+		return err
+	}
+	sdk.SetValue(ctx.Store(mk.key), nil, metadataKey(md.Base), md)
+	return nil
+}
+
+// GetDenomMetadata returns the metadata of the base denom, and whether it
+// is registered.
+func (mk MetadataKeeper) GetDenomMetadata(ctx sdk.Context, base string) (md Metadata, ok bool) {
+	ok = sdk.GetValue(ctx.Store(mk.key), nil, metadataKey(base), &md)
+	return md, ok
+}
+
+// IterateDenomMetadata calls process on the metadata in the order of their
+// base denoms, from start, if not empty, until it returns true.
+func (mk MetadataKeeper) IterateDenomMetadata(ctx sdk.Context, start string, process func(Metadata) (stop bool)) {
+	stor := ctx.Store(mk.key)
+	iter := stor.Iterator(metadataKey(start), store.PrefixEndBytes([]byte(DenomMetadataStoreKeyPrefix)))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var md Metadata
+		sdk.MustGetValue(stor, nil, iter.Key(), &md)
+		if process(md) {
+			return
+		}
+	}
+}
+
+// GetAllDenomMetadata returns all the metadata, in the order of their base
+// denoms.
+func (mk MetadataKeeper) GetAllDenomMetadata(ctx sdk.Context) []Metadata {
+	mds := []Metadata{}
+	mk.IterateDenomMetadata(ctx, "", func(md Metadata) bool {
+		mds = append(mds, md)
+		return false
+	})
+	return mds
+}
+
+// ValidateGenesis implements sdk.GenesisModule.
+func (mk MetadataKeeper) ValidateGenesis(state json.RawMessage) error {
+	if state == nil {
+		return nil
+	}
+	var mds []Metadata
+	if err := amino.UnmarshalJSON(state, &mds); err != nil {
+		return err
+	}
+	return ValidateDenomMetadata(mds)
+}
+
+// InitGenesis implements sdk.GenesisModule.
+func (mk MetadataKeeper) InitGenesis(ctx sdk.Context, state json.RawMessage) {
+	if state == nil {
+		return
+	}
+	var mds []Metadata
+	amino.MustUnmarshalJSON(state, &mds)
+	for _, md := range mds {
+		if err := mk.SetDenomMetadata(ctx, md); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ExportGenesis returns the genesis state of the registry, for InitGenesis.
+func (mk MetadataKeeper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	return amino.MustMarshalJSON(mk.GetAllDenomMetadata(ctx))
+}
@@ -0,0 +1,106 @@
+package bank
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+)
+
+var testMetadata = []Metadata{
+	{Base: "ugnot", Display: "GNOT", Exponent: 6, Description: "The native token of gno.land"},
+	{Base: "atom", Display: "ATOM", Exponent: 0},
+	{Base: "wei", Display: "ETH", Exponent: 18},
+}
+
+func TestDenomMetadata(t *testing.T) {
+	env := setupTestEnv()
+
+	_, ok := env.metadata.GetDenomMetadata(env.ctx, "ugnot")
+	require.False(t, ok)
+	require.NoError(t, env.metadata.SetDenomMetadata(env.ctx, testMetadata[0]))
+	md, ok := env.metadata.GetDenomMetadata(env.ctx, "ugnot")
+	require.True(t, ok)
+	require.Equal(t, testMetadata[0], md)
+
+	for _, md := range []Metadata{
+		{Base: "UGNOT", Display: "GNOT"},
+		{Base: "ugnot", Display: ""},
+		{Base: "ugnot", Display: "G NOT"},
+		{Base: "ugnot", Display: "GNOT", Exponent: 19},
+	} {
+		require.Error(t, env.metadata.SetDenomMetadata(env.ctx, md), "%+v", md)
+	}
+	md, _ = env.metadata.GetDenomMetadata(env.ctx, "ugnot")
+	require.Equal(t, testMetadata[0], md)
+}
+
+func TestDenomMetadataGenesis(t *testing.T) {
+	env := setupTestEnv()
+	state, err := json.Marshal(testMetadata)
+	require.NoError(t, err)
+	require.NoError(t, env.metadata.ValidateGenesis(state))
+	env.metadata.InitGenesis(env.ctx, state)
+
+	// exported in the order of the base denoms, and imported again.
+	exported := env.metadata.ExportGenesis(env.ctx)
+	require.JSONEq(t, `[
+		{"base": "atom", "display": "ATOM", "exponent": 0, "description": ""},
+		{"base": "ugnot", "display": "GNOT", "exponent": 6, "description": "The native token of gno.land"},
+		{"base": "wei", "display": "ETH", "exponent": 18, "description": ""}
+	]`, string(exported))
+	env2 := setupTestEnv()
+	require.NoError(t, env2.metadata.ValidateGenesis(exported))
+	env2.metadata.InitGenesis(env2.ctx, exported)
+	require.Equal(t, exported, env2.metadata.ExportGenesis(env2.ctx))
+
+	// duplicate base denoms are rejected, with all the other errors.
+	err = env.metadata.ValidateGenesis(json.RawMessage(`[
+		{"base": "ugnot", "display": "GNOT", "exponent": 6},
+		{"base": "ugnot", "display": "MGNOT", "exponent": 3},
+		{"base": "wei", "display": "ETH", "exponent": 42}
+	]`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "metadata[1]: duplicate base denom ugnot")
+	require.Contains(t, err.Error(), "metadata[2]: exponent 42 of wei is over 18")
+	require.Error(t, env.metadata.ValidateGenesis(json.RawMessage(`{}`)))
+	require.NoError(t, env.metadata.ValidateGenesis(nil))
+}
+
+func TestQueryDenomMetadata(t *testing.T) {
+	env := setupTestEnv()
+	for _, md := range testMetadata {
+		require.NoError(t, env.metadata.SetDenomMetadata(env.ctx, md))
+	}
+
+	// without metadata keeper, the path is unknown.
+	path := fmt.Sprintf("bank/%s", QueryDenomMetadata)
+	res := NewHandler(env.bank).Query(env.ctx, abci.RequestQuery{Path: path})
+	require.Error(t, res.Error)
+
+	h := NewHandler(env.bank).WithDenomMetadata(env.metadata)
+	query := func(data string) DenomMetadataPage {
+		res := h.Query(env.ctx, abci.RequestQuery{Path: path, Data: []byte(data)})
+		require.Nil(t, res.Error, res.Log)
+		var page DenomMetadataPage
+		require.NoError(t, json.Unmarshal(res.Data, &page))
+		return page
+	}
+
+	page := query("")
+	require.Equal(t, []Metadata{testMetadata[1], testMetadata[0], testMetadata[2]}, page.Metadata)
+	require.Equal(t, "", page.Next)
+
+	page = query(`{"limit": 2}`)
+	require.Equal(t, []Metadata{testMetadata[1], testMetadata[0]}, page.Metadata)
+	require.Equal(t, "wei", page.Next)
+	page = query(`{"start": "wei", "limit": 2}`)
+	require.Equal(t, []Metadata{testMetadata[2]}, page.Metadata)
+	require.Equal(t, "", page.Next)
+
+	res = h.Query(env.ctx, abci.RequestQuery{Path: path, Data: []byte("{")})
+	require.Error(t, res.Error)
+}
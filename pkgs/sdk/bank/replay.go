@@ -0,0 +1,30 @@
+package bank
+
+import (
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// NewSendTxGenerator returns a generator of signed txs for chainID, each
+// sending amount from one of privs to the next one, in turn, paying fee,
+// e.g. for a testutils.BlockReplayer. accNums are the account numbers of
+// privs, whose sequences start at 0. The generator must be called once per
+// delivered tx, in order, since it increments the sequences of the senders.
+func NewSendTxGenerator(chainID string, privs []crypto.PrivKey, accNums []uint64, amount std.Coins, fee std.Fee) func(height int64, i int) std.Tx {
+	if len(privs) == 0 || len(privs) != len(accNums) {
+		panic("invalid senders")
+	}
+	seqs := make([]uint64, len(privs))
+	next := 0
+	return func(height int64, i int) std.Tx {
+		from, to := privs[next], privs[(next+1)%len(privs)]
+		msgs := []std.Msg{NewMsgSend(from.PubKey().Address(), to.PubKey().Address(), amount)}
+		sig, err := from.Sign(std.SignBytes(chainID, accNums[next], seqs[next], fee, msgs, ""))
+		if err != nil {
+			panic(err)
+		}
+		seqs[next]++
+		next = (next + 1) % len(privs)
+		return std.NewTx(msgs, fee, []std.Signature{{PubKey: from.PubKey(), Signature: sig}}, "")
+	}
+}
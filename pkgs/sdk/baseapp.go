@@ -1,26 +1,37 @@
 package sdk
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
 	"runtime/debug"
 	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/gnolang/gno/pkgs/amino"
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
 	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
 	dbm "github.com/gnolang/gno/pkgs/db"
 	"github.com/gnolang/gno/pkgs/errors"
 	"github.com/gnolang/gno/pkgs/log"
 	"github.com/gnolang/gno/pkgs/std"
 	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/mem"
+	"github.com/gnolang/gno/pkgs/store/transient"
 )
 
 // Key to store the consensus params in the main store.
 var mainConsensusParamsKey = []byte("consensus_params")
 var mainLastHeaderKey = []byte("last_header")
+var mainChainIDKey = []byte("chain_id")
+var mainValidatorsKey = []byte("validators")
 
 // BaseApp reflects the ABCI application implementation.
 type BaseApp struct {
@@ -31,14 +42,24 @@ type BaseApp struct {
 	cms    store.CommitMultiStore // Main (uncached) state
 	router Router                 // handle any kind of message
 
+	// chain ID of InitChain, also set upon LoadVersion or LoadLatestVersion
+	chainID string
+
 	// set upon LoadVersion or LoadLatestVersion.
 	baseKey store.StoreKey // Base Store in cms (raw db, not hashed)
 	mainKey store.StoreKey // Main Store in cms (e.g. iavl, merkle-ized)
 
-	anteHandler  AnteHandler  // ante handler for fee and auth
-	initChainer  InitChainer  // initialize state with validators and state blob
-	beginBlocker BeginBlocker // logic to run before any txs
-	endBlocker   EndBlocker   // logic to run after all txs, and to determine valset changes
+	txDecoder       TxDecoder       // decoder of the txs of CheckTx and DeliverTx
+	txEncoder       TxEncoder       // encoder of txs, as txDecoder decodes them
+	anteHandler     AnteHandler     // ante handler for fee and auth
+	initChainer     InitChainer     // initialize state with validators and state blob
+	beginBlocker    BeginBlocker    // logic to run before any txs
+	evidenceHandler EvidenceHandler // logic to run on evidence of misbehaviour, after the BeginBlocker
+	endBlocker      EndBlocker      // logic to run after all txs, and to determine valset changes
+
+	moduleEndBlockers []EndBlocker // logic of the modules to run before the endBlocker, see SetModuleEndBlockers
+
+	checkStateRefresher CheckStateRefresher // logic to run on the check state when reset
 
 	// --------------------
 	// Volatile state
@@ -49,13 +70,27 @@ type BaseApp struct {
 	deliverState *state          // for DeliverTx
 	voteInfos    []abci.VoteInfo // absent validators from begin block
 
+	// queryState is the *queryState of the last committed block, which
+	// queries are served from. It is swapped atomically on Commit, so that
+	// queries need no lock. See method setQueryState.
+	queryState atomic.Value
+
+	// validator set after the updates of InitChain and EndBlock, by pubkey
+	// bytes, or nil if unknown (e.g. for a chain started before it was
+	// stored). Only used to validate updates.
+	validators map[string]abci.ValidatorUpdate
+
+	// policy on the validator updates of EndBlock, or nil to accept all
+	// valid updates, see SetValidatorUpdatePolicy
+	validatorUpdatePolicy *UpdatePolicy
+
 	// consensus params
 	// TODO: Move this in the future to baseapp param store on main store.
 	consensusParams *abci.ConsensusParams
 
 	// The minimum gas prices a validator is willing to accept for processing a
 	// transaction. This is mainly used for DoS and spam prevention.
-	minGasPrices []GasPrice
+	minGasPrices DecCoins
 
 	// flag for sealing options and parameters to a BaseApp
 	sealed bool
@@ -66,8 +101,101 @@ type BaseApp struct {
 	// minimum block time (in Unix seconds) at which to halt the chain and gracefully shutdown
 	haltTime uint64
 
+	// maximum drift of block times ahead of the local clock, or zero to not
+	// validate block times
+	maxBlockTimeDrift time.Duration
+
 	// application's version string
 	appVersion string
+
+	// whether to return the details of internal errors in tx results, e.g.
+	// for devnets, instead of only logging them
+	debugErrors bool
+
+	// whether tx results have the gas used by category
+	gasBreakdown bool
+
+	// whether the ante handler sets the gas meter of txs, see SetTxGasMeter
+	noTxGasMeter bool
+
+	// invariants registered by the modules, asserted by the
+	// ".app/invariants" query
+	invariants InvariantRoutes
+
+	// feature gates, which are not modified once sealed, see
+	// RegisterFeature
+	features Features
+
+	// modules with a genesis state, in the order of registration, see
+	// RegisterGenesisModule
+	genesisModules []genesisModule
+
+	// called on Commit with the write set of the block, see
+	// SetWriteSetRecorder
+	writeSetRecorder WriteSetRecorder
+	// writes of the block directly to the multistore, e.g. of the
+	// consensus params, when recording
+	directWrites []store.StoreOp
+
+	// the limits of Query, or nil
+	queryLimits *queryLimits
+
+	// called with the reports of the panics of InitChain, BeginBlock and
+	// EndBlock, see SetCrashReporter
+	crashReporter CrashReporter
+
+	// max duration of the handler of a msg, or 0 for none, see
+	// SetHandlerDeadline
+	handlerDeadline time.Duration
+	// deterministic bound of the handlers of msgs, or nil, see
+	// SetExecutionMeter
+	executionMeter ExecutionMeter
+
+	// whether the data of tx results is the concatenation of the data of
+	// the msg results, see SetLegacyMsgData
+	legacyMsgData bool
+
+	// whether DeliverTx runs txs twice, see SetDeterminismCheck
+	determinismCheck bool
+
+	// whether the tx encoder or decoder were set, so are validated on seal
+	txCodecSet bool
+
+	// handlers of the panics of txs, see AddRecoveryHandler
+	recoveryHandlers []RecoveryHandler
+
+	// whether Simulate runs on the check state instead of the last
+	// committed state, see SetSimulateFromCommitted
+	simulateFromCheckState bool
+
+	// cache of the CheckTx responses, see SetCheckTxCache
+	checkTxCache *checkTxCache
+	// whether ValidateBasic runs again on txs which passed it in CheckTx,
+	// see SetAlwaysValidateBasic
+	alwaysValidateBasic bool
+
+	// minimum number of recent blocks to retain, or 0 to retain all, see
+	// SetMinRetainBlocks
+	minRetainBlocks int64
+
+	// whether LoadLatestVersion skips VerifyIntegrity, see
+	// SetSkipIntegrityCheck
+	skipIntegrityCheck bool
+
+	// journal of the events of the blocks, see SetEventJournal
+	eventJournal *eventJournal
+
+	// keys of the mounted stores, in mount order
+	storeKeys []store.StoreKey
+	// DBs of the mounted stores, if not the default, closed by Close
+	storeDBs  []dbm.DB
+	dbsClosed bool
+
+	// diagnostics listener, see SetDiagnosticsListener
+	diagnostics        *diagnostics
+	diagnosticsSources map[string]DiagnosticsSource
+	txCounters         txCounters
+	routeStats         routeStats
 }
 
 var _ abci.Application = (*BaseApp)(nil)
@@ -82,13 +210,16 @@ func NewBaseApp(
 ) *BaseApp {
 
 	app := &BaseApp{
-		logger:  logger,
-		name:    name,
-		db:      db,
-		cms:     store.NewCommitMultiStore(db),
-		router:  NewRouter(),
-		baseKey: baseKey,
-		mainKey: mainKey,
+		logger:    logger,
+		name:      name,
+		db:        db,
+		cms:       store.NewCommitMultiStore(db),
+		router:    NewRouter(),
+		baseKey:   baseKey,
+		mainKey:   mainKey,
+		txDecoder: DefaultTxDecoder,
+		txEncoder: DefaultTxEncoder,
+		features:  make(Features),
 	}
 	for _, option := range options {
 		option(app)
@@ -97,11 +228,81 @@ func NewBaseApp(
 	return app
 }
 
+// InvariantRegistry returns the registry of the invariants asserted by the
+// ".app/invariants" query, e.g. for RegisterInvariants of the modules.
+func (app *BaseApp) InvariantRegistry() InvariantRegistry {
+	return &app.invariants
+}
+
+// TxDecoder returns the decoder of the txs of the BaseApp.
+func (app *BaseApp) TxDecoder() TxDecoder {
+	return app.txDecoder
+}
+
+// TxEncoder returns the encoder of txs of the BaseApp, as its TxDecoder
+// decodes them, e.g. to deliver txs directly with the bytes the node would
+// have.
+func (app *BaseApp) TxEncoder() TxEncoder {
+	return app.txEncoder
+}
+
+// probeTx is the tx the tx encoder and decoder are checked to round-trip.
+var probeTx = Tx{
+	Fee:  std.NewFee(1, std.NewCoin("probe", 1)),
+	Memo: "probe",
+}
+
+// validateTxCodec returns an error if the tx encoder and decoder are not
+// the default ones, and do not round-trip probeTx.
+func (app *BaseApp) validateTxCodec() error {
+	if !app.txCodecSet {
+		return nil
+	}
+	bz, err := app.txEncoder(probeTx)
+	if err != nil {
+		return fmt.Errorf("tx encoder failed to encode the probe tx: %w", err)
+	}
+	tx, err := app.txDecoder(bz)
+	if err != nil {
+		return fmt.Errorf("tx decoder failed to decode the encoded probe tx: %w", err)
+	}
+	bz2, err := app.txEncoder(tx)
+	if err != nil || !bytes.Equal(bz, bz2) {
+		return errors.New("tx encoder and decoder do not round-trip the probe tx")
+	}
+	return nil
+}
+
 // Name returns the name of the BaseApp.
 func (app *BaseApp) Name() string {
 	return app.name
 }
 
+// ChainID returns the chain ID of InitChain, which is persisted, or "" if
+// InitChain was not called yet.
+func (app *BaseApp) ChainID() string {
+	return app.chainID
+}
+
+// maxChainIDLength is the max length of chain IDs, within the one of the
+// genesis of tendermint.
+const maxChainIDLength = 48
+
+// ValidateChainID returns an error if chainID is empty, longer than 48
+// bytes, or has characters other than ASCII letters, digits, '.', '-' and
+// '_'.
+func ValidateChainID(chainID string) error {
+	switch {
+	case chainID == "":
+		return errors.New("chain ID cannot be empty")
+	case len(chainID) > maxChainIDLength:
+		return fmt.Errorf("chain ID %q is longer than %d bytes", chainID, maxChainIDLength)
+	case !isChainID(chainID):
+		return fmt.Errorf("chain ID %q has characters other than letters, digits, '.', '-' and '_'", chainID)
+	}
+	return nil
+}
+
 // AppVersion returns the application's version string.
 func (app *BaseApp) AppVersion() string {
 	return app.appVersion
@@ -114,31 +315,96 @@ func (app *BaseApp) Logger() log.Logger {
 
 // MountStoreWithDB mounts a store to the provided key in the BaseApp
 // multistore, using a specified DB.
+//
+// A store in a DB of its own, other than the DB of the app, can be loaded
+// at any version committed, as its DB records the latest version, and is
+// written durably before each version is committed. The DB is closed by
+// Close. See OpenStoreDBs.
+//
+// The key must be of a persistent store; the other kinds of stores are
+// mounted with MountTransientStore and MountMemoryStore.
 func (app *BaseApp) MountStoreWithDB(key store.StoreKey, cons store.CommitStoreConstructor, db dbm.DB) {
+	checkMountKind(key)
 	app.cms.MountStoreWithDB(key, cons, db)
+	app.storeKeys = append(app.storeKeys, key)
+	if db != nil {
+		app.storeDBs = append(app.storeDBs, db)
+	}
 }
 
 // MountStore mounts a store to the provided key in the BaseApp multistore,
-// using the default DB.
+// using the default DB. The key must be of a persistent store, as for
+// MountStoreWithDB.
 func (app *BaseApp) MountStore(key store.StoreKey, cons store.CommitStoreConstructor) {
+	checkMountKind(key)
 	app.cms.MountStoreWithDB(key, cons, nil)
+	app.storeKeys = append(app.storeKeys, key)
+}
+
+// MountTransientStore mounts a transient store, whose writes are discarded
+// on Commit, to key. It is not committed in the app hash, nor queryable.
+func (app *BaseApp) MountTransientStore(key *store.TransientStoreKey) {
+	app.cms.MountStoreWithDB(key, transient.StoreConstructor, nil)
+	app.storeKeys = append(app.storeKeys, key)
+}
+
+// MountMemoryStore mounts a memory store, whose writes are kept in memory
+// across blocks but not across restarts, to key. It is not committed in
+// the app hash, nor queryable.
+func (app *BaseApp) MountMemoryStore(key *store.MemoryStoreKey) {
+	app.cms.MountStoreWithDB(key, mem.StoreConstructor, nil)
+	app.storeKeys = append(app.storeKeys, key)
+}
+
+// checkMountKind panics if key is not of a persistent store.
+func checkMountKind(key store.StoreKey) {
+	var mount string
+	switch store.KindOf(key) {
+	case store.StoreKindKV:
+		return
+	case store.StoreKindTransient:
+		mount = "MountTransientStore"
+	case store.StoreKindMemory:
+		mount = "MountMemoryStore"
+	}
+	panic(fmt.Sprintf("cannot mount store %s of a %s key with a constructor; use %s",
+		key.Name(), store.KindOf(key), mount))
 }
 
 // LoadLatestVersion loads the latest application version. It will panic if
 // called more than once on a running BaseApp.
 // This, or LoadVersion() MUST be called even after first init.
 func (app *BaseApp) LoadLatestVersion() error {
+	app.keepQueryStateVersion()
 	err := app.cms.LoadLatestVersion()
 	if err != nil {
 		return err
 	}
+	if !app.skipIntegrityCheck {
+		if err := app.VerifyIntegrity(); err != nil {
+			return err
+		}
+	}
 	return app.initFromMainStore()
 }
 
+// VerifyIntegrity checks that the last committed state of the multistore
+// is consistent, i.e. that its stores have the hashes which its commit
+// info records, and that they recombine to the app hash which it records.
+// LoadLatestVersion runs it, unless SetSkipIntegrityCheck, so that a
+// corrupted DB fails at startup rather than forks the node later.
+func (app *BaseApp) VerifyIntegrity() error {
+	if err := app.cms.VerifyIntegrity(); err != nil {
+		return fmt.Errorf("integrity check of the DB failed: %w", err)
+	}
+	return nil
+}
+
 // LoadVersion loads the BaseApp application version. It will panic if called
 // more than once on a running baseapp.
 // This, or LoadLatestVersion() MUST be called even after first init.
 func (app *BaseApp) LoadVersion(version int64) error {
+	app.keepQueryStateVersion()
 	err := app.cms.LoadVersion(version)
 	if err != nil {
 		return err
@@ -146,6 +412,20 @@ func (app *BaseApp) LoadVersion(version int64) error {
 	return app.initFromMainStore()
 }
 
+// keepQueryStateVersion sets the pruning options to keep at least one
+// recent version, so that the version of the query state is only pruned
+// once the next one has replaced it, and not while being queried. The
+// override is logged, see SetPruningOptions.
+func (app *BaseApp) keepQueryStateVersion() {
+	sopts := app.cms.GetStoreOptions()
+	if sopts.KeepRecent == 0 {
+		app.logger.Info("Keeping one recent version for the queries of the last block",
+			"keepRecent", sopts.KeepRecent, "keepEvery", sopts.KeepEvery)
+		sopts.KeepRecent = 1
+		app.cms.SetStoreOptions(sopts)
+	}
+}
+
 // LastCommitID returns the last CommitID of the multistore.
 func (app *BaseApp) LastCommitID() store.CommitID {
 	return app.cms.LastCommitID()
@@ -171,17 +451,13 @@ func (app *BaseApp) initFromMainStore() error {
 	// nil, it will be saved later during InitChain.
 	//
 	// TODO: assert that InitChain hasn't yet been called.
-	consensusParamsBz := mainStore.Get(mainConsensusParamsKey)
-	if consensusParamsBz != nil {
-		var consensusParams = &abci.ConsensusParams{}
-		err := amino.Unmarshal(consensusParamsBz, consensusParams)
-		if err != nil {
-			panic(err)
-		}
-
+	if consensusParams := app.loadConsensusParams(); consensusParams != nil {
 		app.setConsensusParams(consensusParams)
 	}
 
+	// Load the chain ID of InitChain from the main store, if any.
+	app.chainID = string(mainStore.Get(mainChainIDKey))
+
 	// Load the consensus header from the main store.
 	// This is needed to setCheckState with the right chainID etc.
 	lastHeaderBz := baseStore.Get(mainLastHeaderKey)
@@ -191,16 +467,47 @@ func (app *BaseApp) initFromMainStore() error {
 		if err != nil {
 			panic(err)
 		}
+		if lastHeader.ChainID == "" {
+			lastHeader.ChainID = app.chainID
+		}
 		app.setCheckState(lastHeader)
+		app.setQueryState(lastHeader)
+	} else if app.chainID != "" {
+		header := &bft.Header{ChainID: app.chainID}
+		app.setCheckState(header)
+		app.setQueryState(header)
 	}
+
+	// Load the validator set from the main store.
+	validatorsBz := mainStore.Get(mainValidatorsKey)
+	if validatorsBz != nil {
+		var validators []abci.ValidatorUpdate
+		err := amino.Unmarshal(validatorsBz, &validators)
+		if err != nil {
+			panic(err)
+		}
+		app.validators = make(map[string]abci.ValidatorUpdate, len(validators))
+		app.applyValidatorUpdates(validators)
+	}
+	app.snapshotDiagnostics()
+
+	if err := app.validateTxCodec(); err != nil {
+		return err
+	}
+
 	// Done.
 	app.Seal()
 
 	return nil
 }
 
-func (app *BaseApp) setMinGasPrices(gasPrices []GasPrice) {
+// setMinGasPrices sets the minimum gas prices, also on the check state if
+// any. They are local policy, so the deliver state never has any.
+func (app *BaseApp) setMinGasPrices(gasPrices DecCoins) {
 	app.minGasPrices = gasPrices
+	if app.checkState != nil {
+		app.checkState.ctx = app.checkState.ctx.WithMinGasPrices(gasPrices)
+	}
 }
 
 func (app *BaseApp) setHaltHeight(haltHeight uint64) {
@@ -211,6 +518,94 @@ func (app *BaseApp) setHaltTime(haltTime uint64) {
 	app.haltTime = haltTime
 }
 
+func (app *BaseApp) setDebugErrors(debug bool) {
+	app.debugErrors = debug
+}
+
+func (app *BaseApp) setGasBreakdown(enabled bool) {
+	app.gasBreakdown = enabled
+}
+
+func (app *BaseApp) setAlwaysValidateBasic(always bool) {
+	app.alwaysValidateBasic = always
+}
+
+func (app *BaseApp) setCheckTxCache(size int, ttl time.Duration) {
+	if size == 0 {
+		app.checkTxCache = nil
+		return
+	}
+	app.checkTxCache = newCheckTxCache(size, ttl)
+}
+
+func (app *BaseApp) setQueryLimits(maxResponseBytes int, maxConcurrent int, perPathLimits map[string]int) {
+	ql := newQueryLimits(maxResponseBytes, maxConcurrent, perPathLimits)
+	if app.queryLimits != nil {
+		ql.maxSteps, ql.perPathSteps = app.queryLimits.maxSteps, app.queryLimits.perPathSteps
+	}
+	app.queryLimits = ql
+}
+
+func (app *BaseApp) setQueryStepLimits(maxSteps int, perPathSteps map[string]int) {
+	if app.queryLimits == nil {
+		app.queryLimits = newQueryLimits(0, 0, nil)
+	}
+	app.queryLimits.maxSteps = maxSteps
+	app.queryLimits.perPathSteps = newPathLimits(perPathSteps)
+}
+
+func (app *BaseApp) setLegacyMsgData(enabled bool) {
+	app.legacyMsgData = enabled
+}
+
+func (app *BaseApp) setDeterminismCheck(enabled bool) {
+	app.determinismCheck = enabled
+}
+
+func (app *BaseApp) setSimulateFromCommitted(enabled bool) {
+	app.simulateFromCheckState = !enabled
+}
+
+func (app *BaseApp) setSkipIntegrityCheck(skip bool) {
+	app.skipIntegrityCheck = skip
+}
+
+func (app *BaseApp) setEventJournal(db dbm.DB, retainBlocks int64) {
+	app.eventJournal = &eventJournal{db: db, retainBlocks: retainBlocks}
+}
+
+func (app *BaseApp) setMinRetainBlocks(minRetainBlocks int64) {
+	app.minRetainBlocks = minRetainBlocks
+}
+
+func (app *BaseApp) setValidatorUpdatePolicy(policy UpdatePolicy) {
+	app.validatorUpdatePolicy = &policy
+}
+
+func (app *BaseApp) setCrashReporter(reporter CrashReporter) {
+	app.crashReporter = reporter
+}
+
+func (app *BaseApp) setHandlerDeadline(deadline time.Duration) {
+	app.handlerDeadline = deadline
+	if deadline > 0 {
+		app.logger.Info("Local node protection enabled: simulated msg handlers time out",
+			"deadline", deadline)
+	}
+}
+
+func (app *BaseApp) setExecutionMeter(meter ExecutionMeter) {
+	app.executionMeter = meter
+}
+
+func (app *BaseApp) setTxGasMeter(enabled bool) {
+	app.noTxGasMeter = !enabled
+}
+
+func (app *BaseApp) setBlockTimeValidation(maxDrift time.Duration) {
+	app.maxBlockTimeDrift = maxDrift
+}
+
 // Router returns the router of the BaseApp.
 func (app *BaseApp) Router() Router {
 	if app.sealed {
@@ -228,14 +623,31 @@ func (app *BaseApp) Seal() { app.sealed = true }
 func (app *BaseApp) IsSealed() bool { return app.sealed }
 
 // setCheckState sets checkState with the cached multistore and
-// the context wrapping it.
-// It is called by InitChain() and Commit()
+// the context wrapping it, and runs the CheckStateRefresher on it.
+// It is called by InitChain(), Commit() and RefreshCheckState().
 func (app *BaseApp) setCheckState(header abci.Header) {
 	ms := app.cms.MultiCacheWrap()
 	app.checkState = &state{
 		ms:  ms,
-		ctx: NewContext(RunTxModeCheck, ms, header, app.logger).WithMinGasPrices(app.minGasPrices),
+		ctx: NewContext(RunTxModeCheck, ms, header, app.logger).
+			WithMinGasPrices(app.minGasPrices).
+			WithConsensusParams(app.consensusParams).
+			WithFeatures(app.features),
+	}
+	if app.checkStateRefresher != nil {
+		app.checkStateRefresher(app.checkState.ctx)
+	}
+}
+
+// RefreshCheckState resets the check state to the latest committed state,
+// discarding the writes of CheckTx since, and runs the CheckStateRefresher
+// on it. Like Commit, it must not be called concurrently with CheckTx.
+func (app *BaseApp) RefreshCheckState() {
+	// Cached successes may fail against the reset state.
+	if app.checkTxCache != nil {
+		app.checkTxCache.resetSuccesses()
 	}
+	app.setCheckState(app.checkState.ctx.BlockHeader())
 }
 
 // setDeliverState sets deliverState with the cached multistore and
@@ -243,19 +655,58 @@ func (app *BaseApp) setCheckState(header abci.Header) {
 // It is called by InitChain() and BeginBlock(),
 // and deliverState is set nil on Commit().
 func (app *BaseApp) setDeliverState(header abci.Header) {
-	ms := app.cms.MultiCacheWrap()
+	var ms store.MultiStore
+	if app.writeSetRecorder != nil {
+		ms = app.cms.MultiCacheWrapWithRecording()
+	} else {
+		ms = app.cms.MultiCacheWrap()
+	}
 	app.deliverState = &state{
 		ms:  ms,
-		ctx: NewContext(RunTxModeDeliver, ms, header, app.logger),
+		ctx: NewContext(RunTxModeDeliver, ms, header, app.logger).
+			WithConsensusParams(app.consensusParams).
+			WithFeatures(app.features),
 	}
 }
 
+// setQueryState sets the query state to the last committed version of the
+// multistore, with header.
+// It is called on initialization, by InitChain() and Commit().
+func (app *BaseApp) setQueryState(header abci.Header) {
+	app.queryState.Store(&queryState{
+		height:          app.cms.LastCommitID().Version,
+		header:          header,
+		consensusParams: app.consensusParams,
+	})
+}
+
+// getQueryState returns the query state, which is empty before
+// initialization.
+func (app *BaseApp) getQueryState() *queryState {
+	if qs, ok := app.queryState.Load().(*queryState); ok {
+		return qs
+	}
+	return &queryState{}
+}
+
 // setConsensusParams memoizes the consensus params.
 func (app *BaseApp) setConsensusParams(consensusParams *abci.ConsensusParams) {
 	app.consensusParams = consensusParams
 }
 
-// setConsensusParams stores the consensus params to the main store.
+// GetConsensusParams returns a copy of the consensus params of ctx, or of
+// the latest consensus params if ctx has none.
+func (app *BaseApp) GetConsensusParams(ctx Context) *abci.ConsensusParams {
+	if params := ctx.ConsensusParams(); params != nil {
+		return params
+	}
+	if app.consensusParams == nil {
+		return nil
+	}
+	return amino.DeepCopy(app.consensusParams).(*abci.ConsensusParams)
+}
+
+// storeConsensusParams stores the consensus params to the main store.
 func (app *BaseApp) storeConsensusParams(consensusParams *abci.ConsensusParams) {
 	consensusParamsBz, err := amino.Marshal(consensusParams)
 	if err != nil {
@@ -263,6 +714,30 @@ func (app *BaseApp) storeConsensusParams(consensusParams *abci.ConsensusParams)
 	}
 	mainStore := app.cms.GetStore(app.mainKey)
 	mainStore.Set(mainConsensusParamsKey, consensusParamsBz)
+	if app.writeSetRecorder != nil {
+		app.directWrites = append(app.directWrites, store.StoreOp{
+			Store: app.mainKey.Name(),
+			Type:  store.StoreOpSet,
+			Key:   mainConsensusParamsKey,
+			Value: consensusParamsBz,
+		})
+	}
+}
+
+// loadConsensusParams returns the consensus params stored in the main
+// store, or nil if there are none.
+func (app *BaseApp) loadConsensusParams() *abci.ConsensusParams {
+	mainStore := app.cms.GetStore(app.mainKey)
+	consensusParamsBz := mainStore.Get(mainConsensusParamsKey)
+	if consensusParamsBz == nil {
+		return nil
+	}
+	var consensusParams = &abci.ConsensusParams{}
+	err := amino.Unmarshal(consensusParamsBz, consensusParams)
+	if err != nil {
+		panic(err)
+	}
+	return consensusParams
 }
 
 // getMaximumBlockGas gets the maximum gas from the consensus params. It panics
@@ -309,26 +784,70 @@ func (app *BaseApp) SetOption(req abci.RequestSetOption) (res abci.ResponseSetOp
 // InitChain implements the ABCI interface. It runs the initialization logic
 // directly on the CommitMultiStore.
 func (app *BaseApp) InitChain(req abci.RequestInitChain) (res abci.ResponseInitChain) {
+	defer app.recoverInitChainCrash(req)
+
+	// validate the genesis states of all the modules before writing any,
+	// and report all the invalid ones: a bad genesis is unrecoverable. The
+	// states of a genesis stream are validated as they are read, and written
+	// only once they all are valid and the stream matches its hash.
+	streamRef, err := genesisStreamRefOf(req.AppState)
+	if err != nil {
+		panic(fmt.Sprintf("invalid genesis state: %v", err))
+	}
+	var genesisStates map[string]json.RawMessage
+	if streamRef == nil {
+		genesisStates, err = app.validateGenesis(req.AppState)
+		if err != nil {
+			panic(fmt.Sprintf("invalid genesis state: %v", err))
+		}
+	}
+
 	// stash the consensus params in the cms main store and memoize
 	if req.ConsensusParams != nil {
 		app.setConsensusParams(req.ConsensusParams)
 		app.storeConsensusParams(req.ConsensusParams)
 	}
 
+	// the chain ID is in the sign bytes of txs, so it must be valid.
+	if err := ValidateChainID(req.ChainID); err != nil {
+		panic(err)
+	}
+	app.chainID = req.ChainID
+
 	initHeader := &bft.Header{ChainID: req.ChainID, Time: req.Time}
 
 	// initialize the deliver state and check state with a correct header
 	app.setDeliverState(initHeader)
 	app.setCheckState(initHeader)
+	app.setQueryState(initHeader)
 
-	if app.initChainer == nil {
-		return
+	// persist the chain ID with the genesis state, for restarts, unless the
+	// stores are not loaded yet.
+	if app.sealed {
+		app.deliverState.ctx.Store(app.mainKey).Set(mainChainIDKey, []byte(req.ChainID))
 	}
 
 	// add block gas meter for any genesis transactions (allow infinite gas)
 	app.deliverState.ctx = app.deliverState.ctx.
 		WithBlockGasMeter(store.NewInfiniteGasMeter())
 
+	if streamRef != nil {
+		if err := app.initGenesisStream(app.deliverState.ctx, *streamRef); err != nil {
+			panic(fmt.Sprintf("invalid genesis state: %v", err))
+		}
+	} else {
+		app.initGenesis(app.deliverState.ctx, genesisStates)
+	}
+
+	app.validators = make(map[string]abci.ValidatorUpdate, len(req.Validators))
+	if app.initChainer == nil {
+		app.applyValidatorUpdates(req.Validators)
+		if app.sealed {
+			app.storeValidators()
+		}
+		return
+	}
+
 	res = app.initChainer(app.deliverState.ctx, req)
 
 	// sanity check
@@ -346,124 +865,210 @@ func (app *BaseApp) InitChain(req abci.RequestInitChain) (res abci.ResponseInitC
 			}
 		}
 	}
+	if len(res.Validators) > 0 {
+		app.applyValidatorUpdates(res.Validators)
+	} else {
+		app.applyValidatorUpdates(req.Validators)
+	}
+	if app.sealed {
+		app.storeValidators()
+	}
 
 	// NOTE: We don't commit, but BeginBlock for block 1 starts from this
 	// deliverState.
 	return
 }
 
-// Splits a string path using the delimiter '/'.
-// e.g. "this/is/funny" becomes []string{"this", "is", "funny"}
-func splitPath(requestPath string) (path []string) {
-	path = strings.Split(requestPath, "/")
-	// first element is empty string
-	if len(path) > 0 && path[0] == "" {
-		path = path[1:]
-	}
-	return path
-}
-
 // Query implements the ABCI interface. It delegates to CommitMultiStore if it
 // implements Queryable.
+//
+// Queries are served from the last committed state, and are safe to call
+// concurrently with the other ABCI methods.
 func (app *BaseApp) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
-	path := splitPath(req.Path)
-	if len(path) == 0 {
-		msg := "no query path provided"
-		res.Error = ABCIError(std.ErrUnknownRequest(msg))
-		return
+	path, err := ParseQueryPath(req.Path)
+	if err != nil {
+		return ABCIResponseQueryFromError(err)
 	}
 
-	switch path[0] {
+	if ql := app.queryLimits; ql != nil {
+		if !ql.acquire() {
+			return ABCIResponseQueryFromError(std.ErrTooManyQueries("too many concurrent queries; retry later"))
+		}
+		defer ql.release()
+		defer func() {
+			if err := ql.check(path, res); err != nil {
+				res = ABCIResponseQueryFromError(err)
+			}
+		}()
+	}
+
+	for {
+		qs := app.getQueryState()
+		res = app.query(qs, path, req)
+		// the version of qs may be pruned by a concurrent commit while
+		// being queried, in which case the query is retried on the next
+		// one.
+		if res.IsOK() || req.Height != 0 || app.getQueryState() == qs {
+			return res
+		}
+	}
+}
+
+// query serves the query on the state qs, recovering from panics, e.g. of
+// stores whose version was pruned meanwhile.
+func (app *BaseApp) query(qs *queryState, path QueryPath, req abci.RequestQuery) (res abci.ResponseQuery) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = ABCIResponseQueryFromError(std.ErrInternal(fmt.Sprintf("query panicked: %v", r)))
+		}
+	}()
+
+	// queries are served from the committed state of the requested height,
+	// or of the last committed one by default, which is the height of the
+	// response, unless the state of another height is used.
+	if req.Height < 0 || req.Height > qs.height {
+		return ABCIResponseQueryFromError(std.ErrUnknownRequest(fmt.Sprintf(
+			"cannot query height %d; latest height: %d", req.Height, qs.height)))
+	}
+	if req.Height == 0 {
+		req.Height = qs.height
+	}
+
+	switch path.Namespace {
 	// "/.app", "/.store" prefix for special application queries
-	case ".app":
-		return handleQueryApp(app, path, req)
+	case QueryNamespaceApp:
+		return handleQueryApp(app, qs, path, req)
 
-	case ".store":
-		return handleQueryStore(app, path, req)
+	case QueryNamespaceStore:
+		return handleQueryStore(app, qs, path, req)
 
 	// default router queries
 	default:
-		return handleQueryCustom(app, path, req)
+		return handleQueryCustom(app, qs, path, req)
 	}
-
-	msg := "unknown query path " + req.Path
-	res.Error = ABCIError(std.ErrUnknownRequest(msg))
-	return
 }
 
-func handleQueryApp(app *BaseApp, path []string, req abci.RequestQuery) (res abci.ResponseQuery) {
-	if len(path) >= 2 {
-		var result Result
+func handleQueryApp(app *BaseApp, qs *queryState, path QueryPath, req abci.RequestQuery) (res abci.ResponseQuery) {
+	if len(path.Rest) != 1 {
+		return ABCIResponseQueryFromError(unknownQueryPathError(req.Path))
+	}
 
-		switch path[1] {
-		case "simulate":
-			txBytes := req.Data
-			var tx Tx
-			err := amino.Unmarshal(txBytes, &tx)
-			if err != nil {
-				res.Error = ABCIError(std.ErrTxDecode(err.Error()))
-			} else {
-				result = app.Simulate(txBytes, tx)
-			}
-			res.Height = req.Height
-			res.Value = amino.MustMarshal(result)
-			return res
-		case "version":
-			res.Height = req.Height
-			res.Value = []byte(app.appVersion)
-			return res
-		default:
-			res.Error = ABCIError(std.ErrUnknownRequest(fmt.Sprintf("Unknown query: %s", path)))
-			return
+	switch path.Rest[0] {
+	case "simulate":
+		var result Result
+		txBytes := req.Data
+		tx, err := app.txDecoder(txBytes)
+		if err != nil {
+			res.Error = ABCIError(std.ErrTxDecode(err.Error()))
+		} else {
+			result = app.redactResult(txBytes, app.simulateQuery(qs, txBytes, tx, crypto.Address{}))
 		}
-	} else {
-		res.Error = ABCIError(std.ErrUnknownRequest(fmt.Sprintf("Unknown query: %s", path)))
-		return
+		res.Height = qs.height
+		res.Value = amino.MustMarshal(result)
+		return res
+	case "simulate_unsigned":
+		var result Result
+		var sreq SimulateUnsignedRequest
+		if err := amino.Unmarshal(req.Data, &sreq); err != nil {
+			res.Error = ABCIError(std.ErrTxDecode(err.Error()))
+		} else if sreq.Sender.IsZero() {
+			res.Error = ABCIError(std.ErrInvalidAddress("missing sender"))
+		} else {
+			tx := sreq.Tx()
+			txBytes := amino.MustMarshal(tx)
+			result = app.redactResult(txBytes, app.simulateQuery(qs, txBytes, tx, sreq.Sender))
+		}
+		res.Height = qs.height
+		res.Value = amino.MustMarshal(result)
+		return res
+	case "version":
+		res.Height = qs.height
+		res.Value = []byte(app.appVersion)
+		return res
+	case "chain_id":
+		res.Height = qs.height
+		res.Value = []byte(qs.header.GetChainID())
+		return res
+	case "features":
+		bz, err := json.Marshal(app.features)
+		if err != nil {
+			return ABCIResponseQueryFromError(std.ErrInternal(err.Error()))
+		}
+		res.Height = qs.height
+		res.Value = bz
+		return res
+	case "commit_info":
+		// the commit info of the height, or of the last committed one.
+		cInfo, err := app.cms.CommitInfo(req.Height)
+		if err != nil {
+			return ABCIResponseQueryFromError(std.ErrInternal(err.Error()))
+		}
+		res.Height = req.Height
+		res.Value = amino.MustMarshalJSON(cInfo)
+		return res
+	case "route_stats":
+		bz, err := json.Marshal(app.RouteStats())
+		if err != nil {
+			return ABCIResponseQueryFromError(std.ErrInternal(err.Error()))
+		}
+		res.Height = qs.height
+		res.Value = bz
+		return res
+	case "invariants":
+		// assert the invariants on the last committed state, discarding
+		// any write.
+		ctx, err := app.queryContext(qs, RunTxModeCheck)
+		if err != nil {
+			return ABCIResponseQueryFromError(err)
+		}
+		if err := app.invariants.AssertInvariants(ctx); err != nil {
+			return ABCIResponseQueryFromError(err)
+		}
+		res.Height = qs.height
+		return res
+	default:
+		return ABCIResponseQueryFromError(unknownQueryPathError(req.Path))
 	}
 }
 
-func handleQueryStore(app *BaseApp, path []string, req abci.RequestQuery) (res abci.ResponseQuery) {
-	// "/store" prefix for store queries
-	queryable, ok := app.cms.(store.Queryable)
-	if !ok {
-		msg := "multistore doesn't support queries"
-		res.Error = ABCIError(std.ErrUnknownRequest(msg))
-		return
-	}
-
-	req.Path = "/" + strings.Join(path[1:], "/")
-
-	// when a client did not provide a query height, manually inject the latest
-	if req.Height == 0 {
-		req.Height = app.LastBlockHeight()
-	}
+func handleQueryStore(app *BaseApp, qs *queryState, path QueryPath, req abci.RequestQuery) (res abci.ResponseQuery) {
+	// the multistore routes on "/<store>/<subpath>".
+	req.Path = "/" + strings.Join(path.Segments()[1:], "/")
 
 	if req.Height <= 1 && req.Prove {
 		res.Error = ABCIError(std.ErrInternal("cannot query with proof when height <= 1; please provide a valid height"))
 		return
 	}
 
+	// "/store" prefix for store queries, on the stores of the height, which
+	// are not changed by commits.
+	queryable, err := app.cms.QueryableWithVersion(req.Height)
+	if err != nil {
+		res.Error = ABCIError(std.ErrInternal(
+			fmt.Sprintf("failed to load state at height %d; %s", req.Height, err),
+		))
+		return
+	}
+
+	// the proofs must be of the state of the height, to be verified against
+	// its app hash.
 	resp := queryable.Query(req)
+	if resp.Height != req.Height && resp.IsOK() {
+		return ABCIResponseQueryFromError(std.ErrInternal(fmt.Sprintf(
+			"query of height %d was served from height %d", req.Height, resp.Height)))
+	}
 	resp.Height = req.Height
 	return resp
 }
 
-func handleQueryCustom(app *BaseApp, path []string, req abci.RequestQuery) (res abci.ResponseQuery) {
-	if len(path) < 1 || path[0] == "" {
-		res.Error = ABCIError(std.ErrUnknownRequest("No route for custom query specified"))
-		return
-	}
-
-	handler := app.router.Route(path[0])
+func handleQueryCustom(app *BaseApp, qs *queryState, path QueryPath, req abci.RequestQuery) (res abci.ResponseQuery) {
+	handler := app.router.Route(path.Namespace)
 	if handler == nil {
-		res.Error = ABCIError(std.ErrUnknownRequest(fmt.Sprintf("no custom handler found for route %s", path[0])))
-		return
+		return ABCIResponseQueryFromError(unknownQueryPathError(req.Path))
 	}
 
-	// when a client did not provide a query height, manually inject the latest
-	if req.Height == 0 {
-		req.Height = app.LastBlockHeight()
-	}
+	// handlers route on "<route>/<subpath>", without leading slash.
+	req.Path = strings.Join(path.Segments(), "/")
 
 	if req.Height <= 1 && req.Prove {
 		res.Error = ABCIError(std.ErrInternal("cannot query with proof when height <= 1; please provide a valid height"))
@@ -475,20 +1080,83 @@ func handleQueryCustom(app *BaseApp, path []string, req abci.RequestQuery) (res
 		res.Error = ABCIError(std.ErrInternal(
 			fmt.Sprintf(
 				"failed to load state at height %d; %s (latest height: %d)",
-				req.Height, err, app.LastBlockHeight(),
+				req.Height, err, qs.height,
 			),
 		))
 		return
 	}
 
-	// cache wrap the commit-multistore for safety
-	ctx := NewContext(RunTxModeCheck, cacheMS, app.checkState.ctx.BlockHeader(), app.logger).WithMinGasPrices(app.minGasPrices)
+	// cache wrap the commit-multistore for safety, and make the stores of
+	// the context read-only, as query handlers must not write.
+	ctx := NewContext(RunTxModeCheck, cacheMS, qs.header, app.logger).
+		WithMinGasPrices(app.minGasPrices).
+		WithFeatures(app.features).
+		ReadOnly()
+
+	// limit the iterations of the handler, e.g. of a range of adversarial
+	// parameters, as queries have no gas.
+	if ql := app.queryLimits; ql != nil {
+		if max := ql.maxStepsOf(path); max > 0 {
+			ctx = ctx.withStepBudget(newStepBudget(max))
+			defer func() {
+				if r := recover(); r != nil {
+					exceeded, ok := r.(stepBudgetExceeded)
+					if !ok {
+						panic(r)
+					}
+					res = ABCIResponseQueryFromError(std.ErrQueryTooExpensive(fmt.Sprintf(
+						"query exceeded the budget of %d iterator steps for %s; query a narrower range",
+						exceeded.max, path)))
+				}
+			}()
+		}
+	}
 
 	// Passes the query to the handler.
 	res = handler.Query(ctx, req)
+	res.Height = req.Height
 	return
 }
 
+// queryContext returns a context of mode on a cache of the state of qs,
+// whose writes are discarded.
+func (app *BaseApp) queryContext(qs *queryState, mode RunTxMode) (Context, error) {
+	cacheMS, err := app.cms.MultiImmutableCacheWrapWithVersion(qs.height)
+	if err != nil {
+		return Context{}, errors.Wrap(err, "failed to load state at height %d", qs.height)
+	}
+	ctx := NewContext(mode, cacheMS, qs.header, app.logger).
+		WithMinGasPrices(app.minGasPrices).
+		WithConsensusParams(qs.consensusParams).
+		WithFeatures(app.features)
+	return ctx, nil
+}
+
+// simulateQuery simulates tx on a branch of the state qs, concurrently with
+// the other ABCI calls, for the simulate queries and Simulate. If sender is
+// set, it signs the unsigned tx in place of the signers of its msgs.
+func (app *BaseApp) simulateQuery(qs *queryState, txBytes []byte, tx Tx, sender crypto.Address) (result Result) {
+	for {
+		ctx, err := app.queryContext(qs, RunTxModeSimulate)
+		if err != nil {
+			result = ABCIResultFromError(err)
+		} else {
+			ctx = ctx.WithTxBytes(txBytes).WithSimulatedSender(sender)
+			if app.gasBreakdown {
+				ctx = ctx.WithGasBreakdown(true)
+			}
+			result = app.runTxWithContext(ctx, RunTxModeSimulate, txBytes, tx)
+		}
+		// as in Query, retry on the next state if the version of qs was
+		// pruned meanwhile, which panics are internal errors of.
+		next := app.getQueryState()
+		if !isInternalError(result.Error) || next == qs {
+			return result
+		}
+		qs = next
+	}
+}
+
 func (app *BaseApp) validateHeight(req abci.RequestBeginBlock) error {
 	if req.Header.GetHeight() < 1 {
 		return fmt.Errorf("invalid height: %d", req.Header.GetHeight())
@@ -502,11 +1170,44 @@ func (app *BaseApp) validateHeight(req abci.RequestBeginBlock) error {
 	return nil
 }
 
+// validateBlockTime returns an error if block times are validated, and the
+// block time is not after the time of the last committed block, or is more
+// than maxBlockTimeDrift ahead of the local clock.
+func (app *BaseApp) validateBlockTime(req abci.RequestBeginBlock) error {
+	if app.maxBlockTimeDrift == 0 {
+		return nil
+	}
+	blockTime := req.Header.GetTime()
+
+	// the check state header is the one of the last committed block, as
+	// persisted on Commit, or the genesis header after InitChain.
+	if app.checkState != nil {
+		last := app.checkState.ctx.BlockHeader()
+		if last.GetHeight() > 0 && !blockTime.After(last.GetTime()) {
+			return fmt.Errorf("invalid block time: %v; must be after the last block time %v",
+				blockTime, last.GetTime())
+		}
+	}
+
+	if now := time.Now(); blockTime.Sub(now) > app.maxBlockTimeDrift {
+		return fmt.Errorf("invalid block time: %v; more than %v ahead of the local time %v",
+			blockTime, app.maxBlockTimeDrift, now)
+	}
+
+	return nil
+}
+
 // BeginBlock implements the ABCI application interface.
 func (app *BaseApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBeginBlock) {
+	defer app.recoverBlockCrash("BeginBlock", req.Header)
+
 	if err := app.validateHeight(req); err != nil {
 		panic(err)
 	}
+	// an invalid block time means that consensus is broken.
+	if err := app.validateBlockTime(req); err != nil {
+		panic(err)
+	}
 
 	// Initialize the DeliverTx state. If this is the first block, it should
 	// already be initialized in InitChain. Otherwise app.deliverState will be
@@ -518,8 +1219,20 @@ func (app *BaseApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBeg
 		// by InitChain. Context is now updated with Header information.
 		app.deliverState.ctx = app.deliverState.ctx.
 			WithBlockHeader(req.Header)
+		// txs delivered by the InitChainer, e.g. genesis txs, are not
+		// in the block.
+		app.deliverState.txCount = 0
 	}
 
+	// the block is subject to the stored consensus params, which include
+	// the updates of the previous EndBlock, e.g. of the max block gas.
+	if consensusParams := app.loadConsensusParams(); consensusParams != nil {
+		app.setConsensusParams(consensusParams)
+	}
+	app.deliverState.ctx = app.deliverState.ctx.
+		WithConsensusParams(app.consensusParams).
+		WithBlockSeed(computeBlockSeed(app.LastCommitID().Hash, req.Header))
+
 	// add block gas meter
 	var gasMeter store.GasMeter
 	if maxGas := app.getMaximumBlockGas(); maxGas > 0 {
@@ -534,42 +1247,115 @@ func (app *BaseApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBeg
 		res = app.beginBlocker(app.deliverState.ctx, req)
 	}
 
+	// handle evidence on the deliver state, so that writes are committed
+	// with the block.
+	if app.evidenceHandler != nil {
+		for _, ev := range dedupEvidence(req.ByzantineValidators) {
+			app.evidenceHandler(app.deliverState.ctx, ev)
+		}
+	}
+
 	// set the signed validators for addition to context in deliverTx
 	if req.LastCommitInfo != nil {
 		app.voteInfos = req.LastCommitInfo.Votes
 	}
+	if app.eventJournal != nil {
+		app.eventJournal.beginBlock(req.Header, res)
+	}
 	return
 }
 
+// dedupEvidence returns the evidence without duplicates, that is with
+// the same validator, height, and type as a previous one.
+func dedupEvidence(evidence []abci.Evidence) []abci.Evidence {
+	type evidenceKey struct {
+		address crypto.Address
+		height  int64
+		typ     string
+	}
+	seen := make(map[evidenceKey]struct{}, len(evidence))
+	res := make([]abci.Evidence, 0, len(evidence))
+	for _, ev := range evidence {
+		key := evidenceKey{ev.Address, ev.Height, ev.Type}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, ev)
+	}
+	return res
+}
+
 // CheckTx implements the ABCI interface. It runs the "basic checks" to see
 // whether or not a transaction can possibly be executed, first decoding and then
 // the ante handler (which checks signatures/fees/ValidateBasic).
 //
 // NOTE:CheckTx does not run the actual Msg handler function(s).
 func (app *BaseApp) CheckTx(req abci.RequestCheckTx) (res abci.ResponseCheckTx) {
-	var tx Tx
-	err := amino.Unmarshal(req.Tx, &tx)
+	// whether the tx fails regardless of the state, and whether its msgs
+	// pass ValidateBasic.
+	var stateless, validBasic bool
+
+	// rechecks are run against the state of a new block, so they are not
+	// cached.
+	if app.checkTxCache != nil && req.Type == abci.CheckTxTypeNew {
+		key := sha256.Sum256(req.Tx)
+		if cached, ok := app.checkTxCache.get(key); ok {
+			app.txCounters.count(RunTxModeCheck, false)
+			return cached
+		}
+		defer func() { app.checkTxCache.add(key, res, stateless, validBasic) }()
+	}
+
+	tx, err := app.txDecoder(req.Tx)
 	if err != nil {
 		res.Error = ABCIError(std.ErrTxDecode(err.Error()))
+		app.txCounters.count(RunTxModeCheck, false)
+		stateless = true
 		return
 	} else {
-		result := app.runTx(RunTxModeCheck, req.Tx, tx)
+		result := app.redactResult(req.Tx, app.runTx(RunTxModeCheck, req.Tx, tx))
+		app.txCounters.count(RunTxModeCheck, result.IsOK())
+		stateless = !result.IsOK() && validateBasicTxMsgs(tx.GetMsgs()) != nil
+		validBasic = !stateless
 		res.ResponseBase = result.ResponseBase
 		res.GasWanted = result.GasWanted
 		res.GasUsed = result.GasUsed
+		if !result.Sender.IsZero() {
+			res.Sender = result.Sender.String()
+		}
+		res.Priority = result.Priority
 		return
 	}
 }
 
 // DeliverTx implements the ABCI interface.
 func (app *BaseApp) DeliverTx(req abci.RequestDeliverTx) (res abci.ResponseDeliverTx) {
-	var tx Tx
-	err := amino.Unmarshal(req.Tx, &tx)
+	if app.eventJournal != nil {
+		defer func() { app.eventJournal.deliverTx(req.Tx, res) }()
+	}
+	tx, err := app.txDecoder(req.Tx)
 	if err != nil {
 		res.Error = ABCIError(std.ErrTxDecode(err.Error()))
+		app.txCounters.count(RunTxModeDeliver, false)
+		// the tx is still in the block, so it has an index.
+		app.deliverState.txCount++
 		return
 	} else {
-		result := app.runTx(RunTxModeDeliver, req.Tx, tx)
+		var result Result
+		if app.determinismCheck {
+			result = app.runTxCheckingDeterminism(req.Tx, tx)
+		} else {
+			result = app.runTx(RunTxModeDeliver, req.Tx, tx)
+		}
+		result = app.redactResult(req.Tx, result)
+		app.txCounters.count(RunTxModeDeliver, result.IsOK())
+		err := result.EmitTypedEvent(EventTypeTx,
+			"index", strconv.Itoa(app.deliverState.txCount-1),
+		)
+		if err != nil {
+			panic(err)
+		}
 		res.ResponseBase = result.ResponseBase
 		res.GasWanted = result.GasWanted
 		res.GasUsed = result.GasUsed
@@ -577,6 +1363,83 @@ func (app *BaseApp) DeliverTx(req abci.RequestDeliverTx) (res abci.ResponseDeliv
 	}
 }
 
+// gasUsages returns the gas used by category of breakdown, sorted by
+// category, or nil if breakdown is nil.
+func gasUsages(breakdown map[string]store.Gas) []GasUsage {
+	if breakdown == nil {
+		return nil
+	}
+	usages := make([]GasUsage, 0, len(breakdown))
+	for category, gas := range breakdown {
+		usages = append(usages, GasUsage{category, gas})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Category < usages[j].Category
+	})
+	return usages
+}
+
+// handleRecovery returns the result of the first recovery handler handling
+// the panic value r, if any.
+func (app *BaseApp) handleRecovery(r interface{}) (Result, bool) {
+	for _, handler := range app.recoveryHandlers {
+		if res, ok := handler(r); ok {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// redactResult returns result with its error replaced by std.InternalError
+// if it is an internal or untyped error, e.g. of a panic or a StringError,
+// whatever the debug errors setting, as the errors of DeliverTx are part of
+// the LastResultsHash. Unless debug errors are set, its log is replaced too,
+// by "internal error" with the codespace and code of the error and the tx
+// hash, so that file paths or sensitive data are not returned to clients;
+// the error and log are logged with the tx hash instead. Typed errors are
+// returned as is.
+func (app *BaseApp) redactResult(txBytes []byte, result Result) Result {
+	if !isInternalError(result.Error) {
+		return result
+	}
+	err := result.Error
+	result.Error = std.InternalError{}
+	if app.debugErrors {
+		if result.Log == "" {
+			result.Log = err.Error()
+		}
+		return result
+	}
+	codespace, code, _ := std.ABCIInfo(err, false)
+	hash := fmt.Sprintf("%X", bft.Tx(txBytes).Hash())
+	app.logger.Error("Internal error in tx", "tx", hash, "err", err, "log", result.Log)
+	result.Log = fmt.Sprintf("internal error (codespace %s, code %d), see the node logs for tx %s",
+		codespace, code, hash)
+	return result
+}
+
+// isInternalError returns whether err is an internal error, or an error
+// which is not registered, e.g. from ABCIError for any error.
+func isInternalError(err abci.Error) bool {
+	if err == nil {
+		return false
+	}
+	codespace, code, _ := std.ABCIInfo(err, false)
+	return codespace == std.CodespaceSDK && code == std.CodeInternal
+}
+
+// validatedBasic returns whether the msgs of the tx of txBytes passed
+// ValidateBasic in a CheckTx of the same bytes, which is in the CheckTx
+// cache, so that it need not run again on recheck and delivery. Txs which
+// were not checked, or are simulated, are always validated, as are all txs
+// with SetAlwaysValidateBasic.
+func (app *BaseApp) validatedBasic(mode RunTxMode, txBytes []byte) bool {
+	if app.checkTxCache == nil || app.alwaysValidateBasic || mode == RunTxModeSimulate || len(txBytes) == 0 {
+		return false
+	}
+	return app.checkTxCache.validatedBasic(sha256.Sum256(txBytes))
+}
+
 // validateBasicTxMsgs executes basic validator calls for messages.
 func validateBasicTxMsgs(msgs []Msg) error {
 	if msgs == nil || len(msgs) == 0 {
@@ -584,6 +1447,11 @@ func validateBasicTxMsgs(msgs []Msg) error {
 	}
 
 	for _, msg := range msgs {
+		// MsgSignData is only signed offline, and must never be processed.
+		if _, ok := msg.(std.MsgSignData); ok {
+			return std.ErrUnknownRequest("MsgSignData is for offline signing only and cannot be broadcast")
+		}
+
 		// Validate the Msg.
 		err := msg.ValidateBasic()
 		if err != nil {
@@ -602,6 +1470,17 @@ func (app *BaseApp) getContextForTx(mode RunTxMode, txBytes []byte) (ctx Context
 		WithVoteInfos(app.voteInfos).
 		WithConsensusParams(app.consensusParams)
 
+	// each delivered tx gets the next index in the block.
+	if mode == RunTxModeDeliver {
+		st := app.getState(mode)
+		ctx = ctx.WithTxIndex(st.txCount)
+		st.txCount++
+	}
+
+	if app.gasBreakdown {
+		ctx = ctx.WithGasBreakdown(true)
+	}
+
 	if mode == RunTxModeSimulate {
 		ctx, _ = ctx.CacheContext()
 	}
@@ -609,11 +1488,33 @@ func (app *BaseApp) getContextForTx(mode RunTxMode, txBytes []byte) (ctx Context
 	return
 }
 
+// processMsg processes the delivered msg of route with handler, and adds it
+// to the route stats, including if it panics, e.g. out of gas. When the tx
+// runs twice to check its determinism, the msg is only added by the run
+// whose result is kept, see runTxCheckingDeterminism.
+func (app *BaseApp) processMsg(ctx Context, handler Handler, route string, msg Msg) (result Result) {
+	start, gasBefore := time.Now(), ctx.GasMeter().GasConsumed()
+	ok := false
+	defer func() {
+		stat := routeStat{route, msg.Type(), ok,
+			uint64(ctx.GasMeter().GasConsumed() - gasBefore), time.Since(start)}
+		if tracer, traced := ctx.Value(writeTracerKey{}).(*writeTracer); traced {
+			tracer.stats = append(tracer.stats, stat)
+			return
+		}
+		app.routeStats.add(stat)
+	}()
+	result = app.runHandler(ctx, handler, msg)
+	ok = result.IsOK()
+	return result
+}
+
 /// runMsgs iterates through all the messages and executes them.
 func (app *BaseApp) runMsgs(ctx Context, msgs []Msg, mode RunTxMode) (result Result) {
 	msgLogs := make([]string, 0, len(msgs))
 
-	data := make([]byte, 0, len(msgs))
+	var legacyData []byte
+	msgData := make([]MsgData, 0, len(msgs))
 	err := error(nil)
 	events := []Event{}
 
@@ -629,35 +1530,57 @@ func (app *BaseApp) runMsgs(ctx Context, msgs []Msg, mode RunTxMode) (result Res
 
 		var msgResult Result
 		ctx = ctx.WithEventLogger(NewEventLogger())
+		if tracer, ok := ctx.Value(writeTracerKey{}).(*writeTracer); ok {
+			tracer.route = msgRoute
+		}
 
 		// run the message!
 		// skip actual execution for CheckTx mode
-		if mode != RunTxModeCheck {
-			msgResult = handler.Process(ctx, msg)
+		if mode == RunTxModeDeliver {
+			msgResult = app.processMsg(ctx, handler, msgRoute, msg)
+		} else if mode != RunTxModeCheck {
+			msgResult = app.runHandler(ctx, handler, msg)
 		}
 
-		// Each message result's Data must be length prefixed in order to separate
-		// each result.
-		data = append(data, msgResult.Data...)
+		// The data of each message result is framed, so that clients can
+		// attribute it to the message.
+		numEvents := len(events)
 		events = append(events, msgResult.Events...)
-		// TODO append msgevent from ctx. XXX XXX
+		events = append(events, ctx.EventLogger().Events()...)
+		if app.legacyMsgData {
+			legacyData = append(legacyData, msgResult.Data...)
+		} else {
+			msgData = append(msgData, MsgData{
+				Route:     msgRoute,
+				Type:      msg.Type(),
+				Data:      msgResult.Data,
+				NumEvents: len(events) - numEvents,
+			})
+		}
 
 		// stop execution and return on first failed message
 		if !msgResult.IsOK() {
 			msgLogs = append(msgLogs,
-				fmt.Sprintf("msg:%d,success:%v,log:%s,events:%v",
-					i, false, msgResult.Log, events))
+				fmt.Sprintf("msg:%d,success:%v,log:%s,events:%s",
+					i, false, msgResult.Log, eventsIndexJSON(events)))
 			err = msgResult.Error
 			break
 		}
 
 		msgLogs = append(msgLogs,
-			fmt.Sprintf("msg:%d,success:%v,log:%s,events:%v",
-				i, true, msgResult.Log, events))
+			fmt.Sprintf("msg:%d,success:%v,log:%s,events:%s",
+				i, true, msgResult.Log, eventsIndexJSON(events)))
 	}
 
 	result.Error = ABCIError(err)
-	result.Data = data
+	switch {
+	case mode == RunTxModeCheck:
+		// no message was run.
+	case app.legacyMsgData:
+		result.Data = legacyData
+	default:
+		result.Data = amino.MustMarshal(msgData)
+	}
 	result.Log = strings.Join(msgLogs, "\n")
 	result.GasUsed = ctx.GasMeter().GasConsumed()
 	result.Events = events
@@ -685,24 +1608,74 @@ func (app *BaseApp) cacheTxContext(ctx Context, txBytes []byte) (
 	return ctx.WithMultiStore(msCache), msCache
 }
 
+// checkBlockGasExceeded emits an EventTypeBlockGasExceeded event on the
+// result of the first tx of a block, with a warning in the logs, if the tx
+// alone uses more than the max block gas, e.g. after it was lowered, as such
+// a tx cannot be in any block.
+func (app *BaseApp) checkBlockGasExceeded(ctx Context, result *Result) {
+	maxGas := ctx.BlockGasMeter().Limit()
+	gasUsed := ctx.GasMeter().GasConsumed()
+	if maxGas <= 0 || gasUsed <= maxGas {
+		return
+	}
+	app.logger.Info("First tx of the block exceeds the max block gas",
+		"height", ctx.BlockHeight(), "gasUsed", gasUsed, "maxGas", maxGas)
+	err := result.EmitTypedEvent(EventTypeBlockGasExceeded,
+		"gas_used", strconv.FormatInt(gasUsed, 10),
+		"max_gas", strconv.FormatInt(maxGas, 10),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
 // runTx processes a transaction. The transactions is processed via an
 // anteHandler. The provided txBytes may be nil in some cases, eg. in tests. For
 // further details on transaction execution, reference the BaseApp SDK
 // documentation.
 func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result) {
-	// NOTE: GasWanted should be returned by the AnteHandler. GasUsed is
-	// determined by the GasMeter. We need access to the context to get the gas
+	return app.runTxWithContext(app.getContextForTx(mode, txBytes), mode, txBytes, tx)
+}
+
+// runTxWithContext processes a transaction like runTx, with ctx as the
+// context for the tx.
+func (app *BaseApp) runTxWithContext(ctx Context, mode RunTxMode, txBytes []byte, tx Tx) (result Result) {
+	// NOTE: GasWanted is the one of the tx fee, unless returned by the
+	// AnteHandler. GasUsed is determined by the GasMeter. We need access to the context to get the gas
 	// meter so we initialize upfront.
 	var gasWanted int64
 
-	ctx := app.getContextForTx(mode, txBytes)
 	ms := ctx.MultiStore()
+
+	// Unless disabled, limit the gas to the gas wanted by the tx, except for
+	// simulations and in the genesis block, where the gas is only metered.
+	txGasLimit := int64(-1)
+	if !app.noTxGasMeter {
+		gasWanted = tx.Fee.GasWanted
+		if mode != RunTxModeSimulate && ctx.BlockHeight() > 0 {
+			txGasLimit = gasWanted
+			if txGasLimit < 0 {
+				txGasLimit = 0
+			}
+		}
+	}
+	// the AnteHandler may boost or lower the priority of the fee.
+	if mode == RunTxModeCheck {
+		ctx = ctx.WithPriority(DefaultTxPriority(tx.Fee))
+	}
 	if mode == RunTxModeDeliver {
-		gasleft := ctx.BlockGasMeter().Remaining()
+		gasleft := ctx.BlockGasRemaining()
+		if txGasLimit >= 0 && txGasLimit < gasleft {
+			gasleft = txGasLimit
+		}
 		ctx = ctx.WithGasMeter(store.NewPassthroughGasMeter(
 			ctx.GasMeter(),
 			gasleft,
 		))
+	} else if txGasLimit >= 0 {
+		ctx = ctx.WithGasMeter(store.NewGasMeter(txGasLimit))
+	} else if !app.noTxGasMeter {
+		ctx = ctx.WithGasMeter(store.NewInfiniteGasMeter())
 	}
 
 	// only run the tx if there is block gas remaining
@@ -716,6 +1689,12 @@ func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result)
 		startingGas = ctx.BlockGasMeter().GasConsumed()
 	}
 
+	// NOTE: This must be deferred before the recovery below, to see the
+	// final result.
+	if mode == RunTxModeDeliver && startingGas == 0 {
+		defer func() { app.checkBlockGasExceeded(ctx, &result) }()
+	}
+
 	defer func() {
 		if r := recover(); r != nil {
 			switch ex := r.(type) {
@@ -730,11 +1709,17 @@ func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result)
 				result.Log = log
 				result.GasWanted = gasWanted
 				result.GasUsed = ctx.GasMeter().GasConsumed()
+				result.GasBreakdown = gasUsages(ctx.GasBreakdown())
 				return
 			default:
-				log := fmt.Sprintf("recovered: %v\nstack:\n%v", r, string(debug.Stack()))
-				result.Error = ABCIError(std.ErrInternal(log))
-				result.Log = log
+				if res, ok := app.handleRecovery(r); ok {
+					result.Error = res.Error
+					result.Log = res.Log
+				} else {
+					log := fmt.Sprintf("recovered: %v\nstack:\n%v", r, string(debug.Stack()))
+					result.Error = ABCIError(std.ErrInternal(log))
+					result.Log = log
+				}
 				result.GasWanted = gasWanted
 				result.GasUsed = ctx.GasMeter().GasConsumed()
 				return
@@ -743,6 +1728,7 @@ func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result)
 		// Whether AnteHandler panics or not.
 		result.GasWanted = gasWanted
 		result.GasUsed = ctx.GasMeter().GasConsumed()
+		result.GasBreakdown = gasUsages(ctx.GasBreakdown())
 	}()
 
 	// If BlockGasMeter() panics it will be caught by the above recover and will
@@ -764,11 +1750,16 @@ func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result)
 	}()
 
 	var msgs = tx.GetMsgs()
-	if err := validateBasicTxMsgs(msgs); err != nil {
-		result.Error = ABCIError(err)
-		return
+	if !app.validatedBasic(mode, txBytes) {
+		if err := validateBasicTxMsgs(msgs); err != nil {
+			result.Error = ABCIError(err)
+			return
+		}
 	}
 
+	// the events of the ante handler are in the result of the tx whatever
+	// the outcome of the msgs, as its writes, e.g. the fee deduction, are.
+	var anteEvents []Event
 	if app.anteHandler != nil {
 		var anteCtx Context
 		var msCache store.MultiStore
@@ -784,6 +1775,7 @@ func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result)
 		// benefits, but it'll be more difficult to get
 		// right.
 		anteCtx, msCache = app.cacheTxContext(ctx, txBytes)
+		anteCtx = anteCtx.WithEventLogger(NewEventLogger())
 		// Call AnteHandler.
 		// NOTE: It is the responsibility of the anteHandler
 		// to use something like passthroughGasMeter to
@@ -805,15 +1797,48 @@ func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result)
 			// Revert cache wrapping of multistore.
 			ctx = newCtx.WithMultiStore(ms)
 			msCache.MultiWrite()
-			gasWanted = result.GasWanted
+			if result.GasWanted != 0 {
+				gasWanted = result.GasWanted
+			}
+			anteEvents = append(anteEvents, result.Events...)
+			anteEvents = markAnteEvents(append(anteEvents, newCtx.EventLogger().Events()...))
 		}
 	}
+	// also if a msg panics.
+	result.Events = anteEvents
 
 	// Create a new context based off of the existing context with a cache wrapped
 	// multi-store in case message processing fails.
 	runMsgCtx, msCache := app.cacheTxContext(ctx, txBytes)
+	written := false
+	if mode == RunTxModeDeliver {
+		// the OnTxEnd funcs are called even if a msg panics. If the tx runs
+		// on a branch of the deliver state, they are passed on to the
+		// OnTxEnd funcs of ctx, as the writes are only written to the block
+		// state if the branch is, see runTxCheckingDeterminism.
+		var txEnd []func(written bool)
+		runMsgCtx = runMsgCtx.withTxEnd(&txEnd)
+		defer func() {
+			if ctx.txEnd != nil {
+				msgsWritten := written
+				ctx.OnTxEnd(func(written bool) {
+					endTx(txEnd, msgsWritten && written)
+				})
+				return
+			}
+			endTx(txEnd, written)
+		}()
+	}
 	result = app.runMsgs(runMsgCtx, msgs, mode)
+	// the events of the msgs are dropped with their writes if they fail.
+	if result.IsOK() {
+		result.Events = append(anteEvents, result.Events...)
+	} else {
+		result.Events = anteEvents
+	}
 	result.GasWanted = gasWanted
+	result.Sender = ctx.Sender()
+	result.Priority = normalizePriority(ctx.Priority())
 
 	// Safety check: don't write the cache state unless we're in DeliverTx.
 	if mode != RunTxModeDeliver {
@@ -823,20 +1848,171 @@ func (app *BaseApp) runTx(mode RunTxMode, txBytes []byte, tx Tx) (result Result)
 	// only update state if all messages pass
 	if result.IsOK() {
 		msCache.MultiWrite()
+		written = true
 	}
 
 	return result
 }
 
-// EndBlock implements the ABCI interface.
+// EndBlock implements the ABCI interface. It merges the responses of the
+// module end blockers and of the end blocker, see SetModuleEndBlockers.
 func (app *BaseApp) EndBlock(req abci.RequestEndBlock) (res abci.ResponseEndBlock) {
-	if app.endBlocker != nil {
-		res = app.endBlocker(app.deliverState.ctx, req)
+	var header abci.Header
+	if app.deliverState != nil {
+		header = app.deliverState.ctx.BlockHeader()
 	}
+	defer app.recoverBlockCrash("EndBlock", header)
+
+	res = app.runEndBlockers(req)
 
+	// invalid updates would halt the chain in consensus, so this is an app
+	// bug.
+	if err := app.validateValidatorUpdates(res.ValidatorUpdates); err != nil {
+		panic(fmt.Sprintf("invalid validator updates at height %d: %v", req.Height, err))
+	}
+	// updates breaching the policy of the chain are all rejected, as if
+	// the end blocker returned none.
+	if app.validatorUpdatePolicy != nil && app.validators != nil {
+		err := ValidateValidatorUpdates(app.validatorSet(), res.ValidatorUpdates, *app.validatorUpdatePolicy)
+		if err != nil {
+			app.logger.Error("Rejected validator updates", "height", req.Height, "err", err)
+			res.ValidatorUpdates = nil
+		}
+	}
+	app.applyValidatorUpdates(res.ValidatorUpdates)
+	if len(res.ValidatorUpdates) > 0 {
+		app.storeValidators()
+	}
+
+	// consensus applies the updates from the next block on.
+	if res.ConsensusParams != nil {
+		var params abci.ConsensusParams
+		if app.consensusParams != nil {
+			params = *app.consensusParams
+		}
+		params = params.Update(*res.ConsensusParams)
+		app.setConsensusParams(&params)
+		app.storeConsensusParams(&params)
+	}
+
+	if app.eventJournal != nil {
+		app.eventJournal.endBlock(res)
+	}
 	return
 }
 
+// runEndBlockers runs the module end blockers, then the end blocker, and
+// merges their responses as documented by SetModuleEndBlockers. Invalid or
+// conflicting validator updates are an app bug, and panic.
+func (app *BaseApp) runEndBlockers(req abci.RequestEndBlock) (res abci.ResponseEndBlock) {
+	endBlockers := app.moduleEndBlockers
+	if app.endBlocker != nil {
+		endBlockers = append(endBlockers[:len(endBlockers):len(endBlockers)], app.endBlocker)
+	}
+	for i, endBlocker := range endBlockers {
+		eres := endBlocker(app.deliverState.ctx, req)
+		// duplicates within a response are checked before merging, which
+		// drops equal updates.
+		if err := abci.ValidatorUpdates(eres.ValidatorUpdates).Validate(); err != nil {
+			panic(fmt.Sprintf("invalid validator updates of end blocker #%d at height %d: %v", i, req.Height, err))
+		}
+		if i == 0 {
+			res = eres
+			continue
+		}
+		updates, err := abci.ValidatorUpdates(res.ValidatorUpdates).Merge(eres.ValidatorUpdates)
+		if err != nil {
+			panic(fmt.Sprintf("invalid validator updates of end blocker #%d at height %d: %v", i, req.Height, err))
+		}
+		res.ValidatorUpdates = updates
+		if eres.ConsensusParams != nil {
+			var params abci.ConsensusParams
+			if res.ConsensusParams != nil {
+				params = *res.ConsensusParams
+			}
+			params = params.Update(*eres.ConsensusParams)
+			res.ConsensusParams = &params
+		}
+		res.Events = append(res.Events, eres.Events...)
+	}
+	return res
+}
+
+// validateValidatorUpdates returns an error if the updates are not valid,
+// use a pubkey type not allowed by the consensus params, or remove a
+// validator which is not in the validator set.
+func (app *BaseApp) validateValidatorUpdates(updates abci.ValidatorUpdates) error {
+	if err := updates.Validate(); err != nil {
+		return err
+	}
+	if app.consensusParams != nil && app.consensusParams.Validator != nil {
+		for _, vu := range updates {
+			if vu.Power == 0 {
+				continue // removals need not be checked.
+			}
+			typeURL := amino.GetTypeURL(vu.PubKey)
+			if !app.consensusParams.Validator.IsValidPubKeyTypeURL(typeURL) {
+				return fmt.Errorf("validator %s has pubkey type %s, which is not allowed by the consensus params",
+					vu.PubKey, typeURL)
+			}
+		}
+	}
+	if app.validators == nil {
+		return nil
+	}
+	for _, vu := range updates {
+		if _, ok := app.validators[string(vu.PubKey.Bytes())]; vu.Power == 0 && !ok {
+			return fmt.Errorf("removal of validator %s which was never added", vu.PubKey)
+		}
+	}
+	return nil
+}
+
+// applyValidatorUpdates applies valid updates to the validator set, if
+// known.
+func (app *BaseApp) applyValidatorUpdates(updates []abci.ValidatorUpdate) {
+	if app.validators == nil {
+		return
+	}
+	for _, vu := range updates {
+		key := string(vu.PubKey.Bytes())
+		if vu.Power == 0 {
+			delete(app.validators, key)
+		} else {
+			app.validators[key] = vu
+		}
+	}
+}
+
+// validatorSet returns the validator set, sorted, or nil if unknown.
+func (app *BaseApp) validatorSet() abci.ValidatorUpdates {
+	if app.validators == nil {
+		return nil
+	}
+	validators := make(abci.ValidatorUpdates, 0, len(app.validators))
+	for _, vu := range app.validators {
+		validators = append(validators, vu)
+	}
+	sort.Sort(validators)
+	return validators
+}
+
+// storeValidators stores the validator set, if known, to the main store of
+// the deliver state, so that it is committed with the block which updated
+// it, and in the app hash. An empty set is not stored, and unknown after a
+// restart.
+func (app *BaseApp) storeValidators() {
+	if app.validators == nil || app.deliverState == nil {
+		return
+	}
+	mainStore := app.deliverState.ctx.Store(app.mainKey)
+	if len(app.validators) == 0 {
+		mainStore.Delete(mainValidatorsKey)
+		return
+	}
+	mainStore.Set(mainValidatorsKey, amino.MustMarshal([]abci.ValidatorUpdate(app.validatorSet())))
+}
+
 // Commit implements the ABCI interface. It will commit all state that exists in
 // the deliver state's multi-store and includes the resulting commit ID in the
 // returned abci.ResponseCommit. Commit will set the check state based on the
@@ -869,8 +2045,18 @@ func (app *BaseApp) Commit() (res abci.ResponseCommit) {
 	// The write to the DeliverTx state writes all state transitions to the root
 	// MultiStore (app.cms) so when Commit() is called is persists those values.
 	app.deliverState.ms.MultiWrite()
+	// the events are journaled first, so that a crash before the state is
+	// committed replays the block, rather than loses its events.
+	if app.eventJournal != nil {
+		app.eventJournal.commit()
+	}
 	commitID := app.cms.Commit()
 	app.logger.Debug("Commit synced", "commit", fmt.Sprintf("%X", commitID))
+	if app.writeSetRecorder != nil {
+		writeSet := append(app.directWrites, app.deliverState.ms.(store.RecordingMultiStore).WriteSet()...)
+		app.directWrites = nil
+		app.writeSetRecorder(header.GetHeight(), writeSet)
+	}
 
 	// Save this header.
 	baseStore := app.cms.GetStore(app.baseKey)
@@ -881,20 +2067,59 @@ func (app *BaseApp) Commit() (res abci.ResponseCommit) {
 	headerBz := amino.MustMarshal(header)
 	baseStore.Set(mainLastHeaderKey, headerBz)
 
+	// Cached successes may fail against the new state.
+	if app.checkTxCache != nil {
+		app.checkTxCache.resetSuccesses()
+	}
+
 	// Reset the Check state to the latest committed.
 	//
 	// NOTE: This is safe because Tendermint holds a lock on the mempool for
 	// Commit. Use the header from this latest block.
 	app.setCheckState(header)
 
+	// Serve the queries from the latest committed state.
+	app.setQueryState(header)
+	app.snapshotDiagnostics()
+
 	// empty/reset the deliver state
 	app.deliverState = nil
 
 	// return.
 	res.Data = commitID.Hash
+	res.RetainHeight = app.getRetainHeight(commitID.Version)
 	return
 }
 
+// getRetainHeight returns the height below which blocks may be pruned after
+// the commit of height, or 0 to retain all blocks. See SetMinRetainBlocks.
+func (app *BaseApp) getRetainHeight(height int64) int64 {
+	if app.minRetainBlocks == 0 {
+		return 0
+	}
+	opts := app.cms.GetStoreOptions()
+	if opts.KeepEvery == 1 {
+		// all states are kept, so are the blocks to replay from them.
+		return 0
+	}
+
+	retainHeight := height - app.minRetainBlocks + 1
+	// the blocks after the oldest recent state are needed to replay from it,
+	if oldest := height - opts.KeepRecent; oldest < retainHeight {
+		retainHeight = oldest
+	}
+	// and those after the last waypoint to state-sync from it.
+	if opts.KeepEvery > 1 {
+		if waypoint := height - height%opts.KeepEvery; waypoint < retainHeight {
+			retainHeight = waypoint
+		}
+	}
+	if retainHeight < 1 {
+		return 0
+	}
+	return retainHeight
+}
+
 // halt attempts to gracefully shutdown the node via SIGINT and SIGTERM falling
 // back on os.Exit if both fail.
 func (app *BaseApp) halt() {
@@ -917,9 +2142,11 @@ func (app *BaseApp) halt() {
 	os.Exit(0)
 }
 
-// TODO implement cleanup
+// Close shuts the diagnostics listener down, if it is started, and closes
+// the DB of the app, the DBs of the stores and the DB of the event journal,
+// once each.
 func (app *BaseApp) Close() error {
-	return nil // XXX
+	return errors.Join(app.closeDiagnostics(), app.closeDBs())
 }
 
 // ----------------------------------------------------------------------------
@@ -928,6 +2155,17 @@ func (app *BaseApp) Close() error {
 type state struct {
 	ms  store.MultiStore
 	ctx Context
+
+	// number of txs delivered so far in the block, only for deliverState.
+	txCount int
+}
+
+// queryState is the state of a committed block, which is not changed by
+// later blocks.
+type queryState struct {
+	height          int64 // version of the multistore
+	header          abci.Header
+	consensusParams *abci.ConsensusParams
 }
 
 func (st *state) MultiCacheWrap() store.MultiStore {
@@ -3,10 +3,18 @@ package sdk
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"os"
 	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -15,17 +23,20 @@ import (
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
 	bft "github.com/gnolang/gno/pkgs/bft/types"
 	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
 	dbm "github.com/gnolang/gno/pkgs/db"
 	"github.com/gnolang/gno/pkgs/log"
 	"github.com/gnolang/gno/pkgs/std"
 	"github.com/gnolang/gno/pkgs/store/dbadapter"
 	"github.com/gnolang/gno/pkgs/store/iavl"
+	"github.com/gnolang/gno/pkgs/store/rootmulti"
 	store "github.com/gnolang/gno/pkgs/store/types"
 )
 
 var (
-	baseKey = store.NewStoreKey("base") // in all test apps
-	mainKey = store.NewStoreKey("main") // in all test apps
+	baseKey = store.NewKVStoreKey("base") // in all test apps
+	mainKey = store.NewKVStoreKey("main") // in all test apps
 )
 
 func defaultLogger() log.Logger {
@@ -109,6 +120,46 @@ func TestLoadVersion(t *testing.T) {
 	testLoadVersionHelper(t, app, int64(2), commitID2)
 }
 
+// Commit returns the height below which blocks may be pruned, which retains
+// the blocks to replay from the states kept by the pruning options.
+func TestCommitRetainHeight(t *testing.T) {
+	cases := []struct {
+		name            string
+		pruning         store.PruningOptions
+		minRetainBlocks int64
+		retainHeights   []int64 // by height, from 1
+	}{
+		{"no minimum", store.NewPruningOptions(2, 0), 0,
+			[]int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"prune nothing", store.PruneNothing, 3,
+			[]int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"minimum", store.NewPruningOptions(1, 0), 3,
+			[]int64{0, 0, 1, 2, 3, 4, 5, 6, 7, 8}},
+		{"keep recent", store.NewPruningOptions(5, 0), 3,
+			[]int64{0, 0, 0, 0, 0, 1, 2, 3, 4, 5}},
+		// the keep recent of 0 is raised to 1 to serve queries.
+		{"prune everything", store.PruneEverything, 1,
+			[]int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"waypoints", store.NewPruningOptions(1, 4), 2,
+			[]int64{0, 0, 0, 3, 4, 4, 4, 7, 8, 8}},
+		{"waypoints and keep recent", store.NewPruningOptions(3, 4), 1,
+			[]int64{0, 0, 0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := setupBaseApp(t, SetPruningOptions(tc.pruning), SetMinRetainBlocks(tc.minRetainBlocks))
+			app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+			for i, retainHeight := range tc.retainHeights {
+				header := &bft.Header{ChainID: "test-chain", Height: int64(i + 1)}
+				app.BeginBlock(abci.RequestBeginBlock{Header: header})
+				app.EndBlock(abci.RequestEndBlock{})
+				res := app.Commit()
+				require.Equal(t, retainHeight, res.RetainHeight, "height %d", header.Height)
+			}
+		})
+	}
+}
+
 func TestAppVersionSetterGetter(t *testing.T) {
 	pruningOpt := SetPruningOptions(store.PruneSyncable)
 	name := t.Name()
@@ -116,18 +167,50 @@ func TestAppVersionSetterGetter(t *testing.T) {
 	app := newBaseApp(name, db, pruningOpt)
 
 	require.Equal(t, "", app.AppVersion())
-	res := app.Query(abci.RequestQuery{Path: ".app/version"})
+	res := app.Query(abci.RequestQuery{Path: "/.app/version"})
 	require.True(t, res.IsOK())
 	require.Equal(t, "", string(res.Value))
 
 	versionString := "1.0.0"
 	app.SetAppVersion(versionString)
 	require.Equal(t, versionString, app.AppVersion())
-	res = app.Query(abci.RequestQuery{Path: ".app/version"})
+	res = app.Query(abci.RequestQuery{Path: "/.app/version"})
 	require.True(t, res.IsOK())
 	require.Equal(t, versionString, string(res.Value))
 }
 
+func TestQueryCommitInfo(t *testing.T) {
+	app := setupBaseApp(t)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	for height := int64(1); height <= 2; height++ {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		app.EndBlock(abci.RequestEndBlock{})
+		app.Commit()
+	}
+
+	for _, height := range []int64{0, 1} {
+		res := app.Query(abci.RequestQuery{Path: "/.app/commit_info", Height: height})
+		require.True(t, res.IsOK(), res.Log)
+		var cInfo store.CommitInfo
+		require.NoError(t, amino.UnmarshalJSON(res.Value, &cInfo))
+		if height == 0 {
+			height = 2
+		}
+		require.Equal(t, height, res.Height)
+		require.Equal(t, height, cInfo.Version)
+		require.Len(t, cInfo.StoreInfos, 2)
+		for _, si := range cInfo.StoreInfos {
+			require.Equal(t, int64(1), si.CreatedHeight)
+			stored, ok := app.cms.StoreInfo(si.Name)
+			require.True(t, ok)
+			require.Equal(t, int64(1), stored.CreatedHeight)
+		}
+	}
+
+	res := app.Query(abci.RequestQuery{Path: "/.app/commit_info", Height: 3})
+	require.False(t, res.IsOK())
+}
+
 func TestLoadVersionInvalid(t *testing.T) {
 	pruningOpt := SetPruningOptions(store.PruneSyncable)
 	name := t.Name()
@@ -159,6 +242,115 @@ func TestLoadVersionInvalid(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestStoreKinds(t *testing.T) {
+	tkey, mkey := store.NewTransientStoreKey("transient"), store.NewMemoryStoreKey("memory")
+	app := newBaseApp(t.Name(), dbm.NewMemDB())
+	require.PanicsWithValue(t, "cannot mount store transient of a transient key with a constructor; use MountTransientStore", func() {
+		app.MountStore(tkey, iavl.StoreConstructor)
+	})
+	app.MountTransientStore(tkey)
+	app.MountMemoryStore(mkey)
+	require.NoError(t, app.LoadLatestVersion())
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	ctx := app.deliverState.ctx
+	k, v := []byte("key"), []byte("value")
+	ctx.KVStore(mainKey).Set(k, v)
+	ctx.TransientStore(tkey).Set(k, v)
+	ctx.MemoryStore(mkey).Set(k, v)
+	require.PanicsWithValue(t, "Context.KVStore of store transient, which is a transient store; use Context.TransientStore", func() {
+		ctx.KVStore(tkey)
+	})
+	require.PanicsWithValue(t, "Context.TransientStore of store main, which is a kv store; use Context.KVStore", func() {
+		ctx.TransientStore(mainKey)
+	})
+	require.PanicsWithValue(t, "Context.MemoryStore of store transient, which is a transient store; use Context.TransientStore", func() {
+		ctx.MemoryStore(tkey)
+	})
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	ctx = app.checkState.ctx
+	require.Equal(t, v, ctx.KVStore(mainKey).Get(k))
+	require.Nil(t, ctx.TransientStore(tkey).Get(k))
+	require.Equal(t, v, ctx.MemoryStore(mkey).Get(k))
+
+	// only the persistent stores are queryable.
+	res := app.Query(abci.RequestQuery{Path: "/.store/main/key", Data: k})
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, v, res.Value)
+	for _, name := range []string{"transient", "memory"} {
+		res := app.Query(abci.RequestQuery{Path: "/.store/" + name + "/key", Data: k})
+		require.False(t, res.IsOK())
+		require.Contains(t, res.Error.Error(), "no such store: "+name)
+	}
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	name := t.Name()
+	db := dbm.NewMemDB()
+	app := newBaseApp(name, db)
+	require.NoError(t, app.LoadLatestVersion())
+	for height := int64(1); height <= 2; height++ {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		app.Commit()
+	}
+	require.NoError(t, app.VerifyIntegrity())
+
+	// flip a bit of the app hash recorded in the commit info of the last
+	// version, which it ends with.
+	cInfoKey := []byte("s/2")
+	cInfoBytes := append([]byte{}, db.Get(cInfoKey)...)
+	cInfoBytes[len(cInfoBytes)-1] ^= 1
+	db.Set(cInfoKey, cInfoBytes)
+
+	app = newBaseApp(name, db)
+	err := app.LoadLatestVersion()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "integrity check of the DB failed: commit info of version 2: "+
+		"the hashes of its stores recombine to app hash")
+
+	// unless skipped.
+	app = newBaseApp(name, db, SetSkipIntegrityCheck(true))
+	require.NoError(t, app.LoadLatestVersion())
+	require.Equal(t, int64(2), app.LastBlockHeight())
+	require.Error(t, app.VerifyIntegrity())
+}
+
+func TestCommitHashVersion(t *testing.T) {
+	name := t.Name()
+	db := dbm.NewMemDB()
+	app := newBaseApp(name, db, SetCommitHashVersion(2, store.CommitHashSortedSHA256))
+	require.NoError(t, app.LoadLatestVersion())
+	plain := newBaseApp(name, dbm.NewMemDB())
+	require.NoError(t, plain.LoadLatestVersion())
+	commit := func(app *BaseApp, height int64) []byte {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		return app.Commit().Data
+	}
+
+	// the app hashes are the same until the switch.
+	require.Equal(t, commit(plain, 1), commit(app, 1))
+	require.NotEqual(t, commit(plain, 2), commit(app, 2))
+
+	// and the switch is kept after a restart without the option.
+	app = newBaseApp(name, db)
+	require.NoError(t, app.LoadLatestVersion())
+	commit(app, 3)
+	for height, version := range []store.CommitHashVersion{1: store.CommitHashSimpleMerkle, 2: store.CommitHashSortedSHA256, 3: store.CommitHashSortedSHA256} {
+		if height == 0 {
+			continue
+		}
+		cInfo, err := app.cms.CommitInfo(int64(height))
+		require.NoError(t, err)
+		require.Equal(t, version, cInfo.HashVersion, "height %d", height)
+	}
+
+	require.Panics(t, func() { SetCommitHashVersion(0, store.CommitHashSortedSHA256) })
+	require.Panics(t, func() { SetCommitHashVersion(2, store.CommitHashVersion(9)) })
+}
+
 func testLoadVersionHelper(t *testing.T, app *BaseApp, expectedHeight int64, expectedID store.CommitID) {
 	lastHeight := app.LastBlockHeight()
 	lastID := app.LastCommitID()
@@ -234,6 +426,57 @@ func TestSetMinGasPrices(t *testing.T) {
 	require.Equal(t, minGasPrices, app.minGasPrices)
 }
 
+func TestMinGasPricesContext(t *testing.T) {
+	minGasPrices, err := ParseGasPrices("5000stake/10gas")
+	require.NoError(t, err)
+
+	type seen struct {
+		minGasPrices DecCoins
+		isCheckTx    bool
+	}
+	var got []seen
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			got = append(got, seen{ctx.MinGasPrices(), ctx.IsCheckTx()})
+			return ctx, Result{}, false
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			return Result{}
+		}))
+	}
+	app := setupBaseApp(t, SetMinGasPrices("5000stake/10gas"), anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	// min gas prices are local policy, so only check contexts have them.
+	require.Equal(t, minGasPrices, app.checkState.ctx.MinGasPrices())
+	require.Nil(t, app.deliverState.ctx.MinGasPrices())
+
+	tx := newTxCounter(0, 0)
+	require.True(t, app.Check(tx).IsOK())
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	require.True(t, app.Deliver(tx).IsOK())
+	require.True(t, app.Simulate(nil, tx).IsOK())
+	require.Equal(t, []seen{
+		{minGasPrices, true},
+		{nil, false},
+		{minGasPrices, false},
+	}, got)
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+	require.Equal(t, minGasPrices, app.checkState.ctx.MinGasPrices())
+
+	// the check state is refreshed when the prices change.
+	otherPrices, err := ParseGasPrices("1stake/1gas")
+	require.NoError(t, err)
+	app.setMinGasPrices(otherPrices)
+	require.Equal(t, otherPrices, app.checkState.ctx.MinGasPrices())
+	got = nil
+	require.True(t, app.Check(newTxCounter(1, 0)).IsOK())
+	require.Equal(t, []seen{{otherPrices, true}}, got)
+}
+
 func TestInitChainer(t *testing.T) {
 	name := t.Name()
 	// keep the db and logger ourselves so
@@ -250,7 +493,7 @@ func TestInitChainer(t *testing.T) {
 	}
 
 	query := abci.RequestQuery{
-		Path: ".store/main/key",
+		Path: "/.store/main/key",
 		Data: key,
 	}
 
@@ -301,6 +544,38 @@ func TestInitChainer(t *testing.T) {
 	require.Equal(t, value, res.Value)
 }
 
+// The chain ID of InitChain is validated, and persisted for the contexts
+// of the app after a restart.
+func TestChainID(t *testing.T) {
+	for _, chainID := range []string{"", "chain id", "chain/id", strings.Repeat("a", 49)} {
+		require.Error(t, ValidateChainID(chainID), chainID)
+	}
+	for _, chainID := range []string{"test-chain", "test_chain.1", strings.Repeat("a", 48)} {
+		require.NoError(t, ValidateChainID(chainID), chainID)
+	}
+
+	name := t.Name()
+	db := dbm.NewMemDB()
+	app := newBaseApp(name, db)
+	require.Nil(t, app.LoadLatestVersion())
+	require.Panics(t, func() { app.InitChain(abci.RequestInitChain{ChainID: "bad chain"}) })
+	require.Equal(t, "", app.ChainID())
+
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	require.Equal(t, "test-chain", app.ChainID())
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	app = newBaseApp(name, db)
+	require.Nil(t, app.LoadLatestVersion())
+	require.Equal(t, "test-chain", app.ChainID())
+	require.Equal(t, "test-chain", app.checkState.ctx.ChainID())
+	res := app.Query(abci.RequestQuery{Path: "/.app/chain_id"})
+	require.True(t, res.IsOK())
+	require.Equal(t, "test-chain", string(res.Value))
+}
+
 type testTxData struct {
 	FailOnAnte bool
 	Counter    int64
@@ -349,6 +624,10 @@ const (
 	routeMsgCounter2 = "msgCounter2"
 )
 
+// msgCounterValidations counts the calls of msgCounter.ValidateBasic, which
+// may run concurrently, e.g. in queries, so it is accessed atomically.
+var msgCounterValidations int64
+
 // ValidateBasic() fails on negative counters.
 // Otherwise it's up to the handlers
 type msgCounter struct {
@@ -362,12 +641,16 @@ func (msg msgCounter) Type() string                 { return "counter1" }
 func (msg msgCounter) GetSignBytes() []byte         { return nil }
 func (msg msgCounter) GetSigners() []crypto.Address { return nil }
 func (msg msgCounter) ValidateBasic() error {
+	atomic.AddInt64(&msgCounterValidations, 1)
 	if msg.Counter >= 0 {
 		return nil
 	}
 	return std.ErrInvalidSequence("counter should be a non-negative integer.")
 }
 
+// testTxGasWanted is the gas wanted by the test txs, enough for any test.
+const testTxGasWanted = 1 << 40
+
 // txInt: used as counter in incrementing counter tests,
 // or as how much gas will be consumed in antehandler
 // (depending on anteHandler used in tests)
@@ -376,7 +659,7 @@ func newTxCounter(txInt int64, msgInts ...int64) std.Tx {
 	for _, msgInt := range msgInts {
 		msgs = append(msgs, msgCounter{msgInt, false})
 	}
-	tx := std.Tx{Msgs: msgs}
+	tx := std.Tx{Msgs: msgs, Fee: std.Fee{GasWanted: testTxGasWanted}}
 	setCounter(&tx, txInt)
 	setFailOnHandler(&tx, false)
 	return tx
@@ -533,6 +816,8 @@ func TestCheckTx(t *testing.T) {
 		require.NoError(t, err)
 		r := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
 		assert.True(t, r.IsOK(), fmt.Sprintf("%v", r))
+		// counter txs have no signers.
+		assert.Empty(t, r.Sender)
 	}
 
 	checkStateStore := app.checkState.ctx.Store(mainKey)
@@ -552,110 +837,919 @@ func TestCheckTx(t *testing.T) {
 	require.Nil(t, storedBytes)
 }
 
-// Test that successive DeliverTx can see each others' effects
-// on the store, both within and across blocks.
-func TestDeliverTx(t *testing.T) {
-	// test increments in the ante
-	anteKey := []byte("ante-key")
-	anteOpt := func(bapp *BaseApp) { bapp.SetAnteHandler(anteHandlerTxTest(t, mainKey, anteKey)) }
+// The CheckStateRefresher runs on each reset of the check state, and its
+// writes are seen by CheckTx but never committed.
+func TestCheckStateRefresher(t *testing.T) {
+	markerKey := []byte("marker-key")
+	var refreshes int64
+	refresherOpt := func(bapp *BaseApp) {
+		bapp.SetCheckStateRefresher(func(ctx Context) {
+			require.True(t, ctx.IsCheckTx())
+			refreshes++
+			setIntOnStore(ctx.Store(mainKey), markerKey, refreshes)
+		})
+	}
+	var seen []int64
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			if ctx.IsCheckTx() {
+				seen = append(seen, getIntFromStore(ctx.Store(mainKey), markerKey))
+			} else {
+				require.Nil(t, ctx.Store(mainKey).Get(markerKey))
+			}
+			return ctx, Result{}, false
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result { return Result{} }))
+	}
 
-	// test increments in the handler
-	deliverKey := []byte("deliver-key")
+	app := setupBaseApp(t, refresherOpt, anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	checkTx := func() {
+		txBytes, err := amino.Marshal(newTxCounter(0, 0))
+		require.NoError(t, err)
+		res := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+		require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	}
+	checkTx()
+
+	header := &bft.Header{ChainID: "test-chain", Height: 1}
+	app.BeginBlock(abci.RequestBeginBlock{Header: header})
+	res := app.Deliver(newTxCounter(0, 0))
+	require.True(t, res.IsOK(), res.Log)
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	checkTx()
+
+	app.RefreshCheckState()
+	checkTx()
+	require.Equal(t, []int64{1, 2, 3}, seen)
+
+	// the marker was never committed.
+	require.Nil(t, app.cms.GetStore(mainKey).Get(markerKey))
+}
+
+// Events of the handler result and of the context event logger are returned
+// by DeliverTx.
+func TestDeliverTxEvents(t *testing.T) {
 	routerOpt := func(bapp *BaseApp) {
-		bapp.Router().AddRoute(routeMsgCounter, newMsgCounterHandler(t, mainKey, deliverKey))
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			counter := fmt.Sprintf("%d", msg.(msgCounter).Counter)
+			require.NoError(t, ctx.EventLogger().EmitTypedEvent("logged", "counter", counter))
+			var res Result
+			require.NoError(t, res.EmitTypedEvent("returned", "counter", counter))
+			return res
+		}))
+	}
+
+	app := setupBaseApp(t, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	tx := newTxCounter(0, 1, 2)
+	txBytes, err := amino.Marshal(tx)
+	require.NoError(t, err)
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	require.Equal(t, []abci.Event{
+		abci.NewEvent("returned", abci.NewAttribute("counter", "1")),
+		abci.NewEvent("logged", abci.NewAttribute("counter", "1")),
+		abci.NewEvent("returned", abci.NewAttribute("counter", "2")),
+		abci.NewEvent("logged", abci.NewAttribute("counter", "2")),
+		abci.NewEvent(EventTypeTx, abci.NewAttribute("index", "0")),
+	}, res.Events)
+}
+
+// The events of the ante handler, e.g. of the fee deduction, are in the
+// result of a tx even if its msgs fail, unlike the events of the msgs, and
+// the gas used includes the gas of the ante handler.
+func TestDeliverTxAnteEvents(t *testing.T) {
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			ctx.GasMeter().ConsumeGas(100, "ante")
+			require.NoError(t, ctx.EventLogger().EmitTypedEvent("fee", "amount", "1atom"))
+			require.NoError(t, res.EmitTypedEvent("returned"))
+			return ctx, res, false
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) (res Result) {
+			ctx.GasMeter().ConsumeGas(50, "msg")
+			require.NoError(t, ctx.EventLogger().EmitTypedEvent("msg"))
+			if msg.(msgCounter).FailOnHandler {
+				res.Error = ABCIError(std.ErrInternal("message handler failure"))
+			}
+			return res
+		}))
+	}
+
+	app := setupBaseApp(t, anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	anteEvents := []abci.Event{
+		abci.NewEvent("returned", abci.NewAttribute(AttributeKeyAnte, "true")),
+		abci.NewEvent("fee", abci.NewAttribute("amount", "1atom"), abci.NewAttribute(AttributeKeyAnte, "true")),
+	}
+
+	tx := newTxCounter(0, 0)
+	txBytes := amino.MustMarshal(tx)
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	require.Equal(t, append(anteEvents,
+		abci.NewEvent("msg"),
+		abci.NewEvent(EventTypeTx, abci.NewAttribute("index", "0")),
+	), res.Events)
+	require.Equal(t, int64(150), res.GasUsed)
+	// the ante events are not attributed to the msgs.
+	txr := NewTxResponseFromDeliver(1, txBytes, res)
+	require.Equal(t, []Event{abci.NewEvent("msg")}, txr.Logs[0].Events)
+
+	tx = newTxCounter(1, 0)
+	setFailOnHandler(&tx, true)
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(tx)})
+	require.False(t, res.IsOK(), fmt.Sprintf("%v", res))
+	require.Equal(t, append(anteEvents,
+		abci.NewEvent(EventTypeTx, abci.NewAttribute("index", "1")),
+	), res.Events)
+	require.Equal(t, int64(150), res.GasUsed)
+}
+
+// Delivered txs see their index in the block, which is also emitted in an
+// event, and CheckTx has none.
+func TestDeliverTxIndex(t *testing.T) {
+	var indexes, counts []int
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			indexes = append(indexes, ctx.TxIndex())
+			counts = append(counts, ctx.BlockTxCount())
+			return Result{}
+		}))
+	}
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			if ctx.IsCheckTx() {
+				require.Equal(t, -1, ctx.TxIndex())
+				require.Equal(t, -1, ctx.BlockTxCount())
+			}
+			return ctx, Result{}, false
+		})
+	}
+
+	app := setupBaseApp(t, anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	for height := int64(1); height <= 2; height++ {
+		indexes, counts = nil, nil
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		for i := 0; i < 3; i++ {
+			txBytes, err := amino.Marshal(newTxCounter(int64(i), 0))
+			require.NoError(t, err)
+			res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+			require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+			require.Equal(t, []abci.Event{
+				abci.NewEvent(EventTypeTx, abci.NewAttribute("index", strconv.Itoa(i))),
+			}, res.Events)
+
+			res2 := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+			require.True(t, res2.IsOK(), fmt.Sprintf("%v", res2))
+		}
+		app.EndBlock(abci.RequestEndBlock{})
+		app.Commit()
+
+		require.Equal(t, []int{0, 1, 2}, indexes)
+		require.Equal(t, []int{1, 2, 3}, counts)
+	}
+}
+
+// The block seed is the same on apps fed the same blocks, for the whole
+// block, and changes every block.
+func TestBlockSeed(t *testing.T) {
+	newApp := func(seeds *[][32]byte) *BaseApp {
+		routerOpt := func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+				*seeds = append(*seeds, ctx.BlockSeed())
+				return Result{}
+			}))
+		}
+		anteOpt := func(bapp *BaseApp) {
+			bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+				if ctx.IsCheckTx() {
+					require.Equal(t, [32]byte{}, ctx.BlockSeed())
+				}
+				return ctx, Result{}, false
+			})
+		}
+		app := setupBaseApp(t, anteOpt, routerOpt)
+		app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+		return app
+	}
+	var seeds1, seeds2 [][32]byte
+	app1, app2 := newApp(&seeds1), newApp(&seeds2)
+
+	proposer := crypto.AddressFromPreimage([]byte("proposer"))
+	start := time.Unix(1600000000, 0)
+	for height := int64(1); height <= 3; height++ {
+		header := &bft.Header{ChainID: "test-chain", Height: height, Time: start.Add(time.Duration(height) * time.Second), ProposerAddress: proposer}
+		for _, app := range []*BaseApp{app1, app2} {
+			app.BeginBlock(abci.RequestBeginBlock{Header: header})
+			for i := 0; i < 2; i++ {
+				txBytes, err := amino.Marshal(newTxCounter(int64(i), 0))
+				require.NoError(t, err)
+				res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+				require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+				res2 := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+				require.True(t, res2.IsOK(), fmt.Sprintf("%v", res2))
+			}
+			app.EndBlock(abci.RequestEndBlock{})
+			app.Commit()
+		}
+	}
+
+	require.Equal(t, seeds1, seeds2)
+	require.Len(t, seeds1, 6)
+	for i := 0; i < len(seeds1); i += 2 {
+		require.NotEqual(t, [32]byte{}, seeds1[i])
+		require.Equal(t, seeds1[i], seeds1[i+1])
+		if i > 0 {
+			require.NotEqual(t, seeds1[i-1], seeds1[i])
+		}
+	}
+
+	// the seed depends on the proposer.
+	header := &bft.Header{ChainID: "test-chain", Height: 4, Time: start.Add(4 * time.Second), ProposerAddress: proposer}
+	seed := computeBlockSeed(app1.LastCommitID().Hash, header)
+	header.ProposerAddress = crypto.AddressFromPreimage([]byte("other"))
+	require.NotEqual(t, seed, computeBlockSeed(app1.LastCommitID().Hash, header))
+}
+
+func TestNewDeterministicRand(t *testing.T) {
+	seed := []byte("seed")
+	r1, r2 := NewDeterministicRand(seed, []byte("bank")), NewDeterministicRand(seed, []byte("bank"))
+	r3 := NewDeterministicRand(seed, []byte("auth"))
+	// the length of the seed is hashed, so that seed||salt is unambiguous.
+	r4 := NewDeterministicRand([]byte("seedb"), []byte("ank"))
+	for i := 0; i < 10; i++ {
+		n := r1.Int63()
+		require.Equal(t, n, r2.Int63())
+		require.NotEqual(t, n, r3.Int63())
+		require.NotEqual(t, n, r4.Int63())
+	}
+}
+
+// Evidence of BeginBlock is handled once per validator, height, and type,
+// after the BeginBlocker, and its writes are seen by txs and committed.
+func TestBeginBlockEvidence(t *testing.T) {
+	slashedKey := []byte("slashed")
+	var handled []abci.Evidence
+	options := []func(*BaseApp){
+		func(bapp *BaseApp) {
+			bapp.SetBeginBlocker(func(ctx Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+				require.Empty(t, handled, "evidence handled before the BeginBlocker")
+				return abci.ResponseBeginBlock{}
+			})
+		},
+		func(bapp *BaseApp) {
+			bapp.SetEvidenceHandler(func(ctx Context, ev abci.Evidence) {
+				handled = append(handled, ev)
+				store := ctx.Store(mainKey)
+				store.Set(slashedKey, append(store.Get(slashedKey), ev.Address.Bytes()...))
+			})
+		},
+		func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+				return Result{ResponseBase: abci.ResponseBase{Data: ctx.Store(mainKey).Get(slashedKey)}}
+			}))
+		},
+	}
+	app := setupBaseApp(t, options...)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	val1 := crypto.AddressFromPreimage([]byte("val1"))
+	val2 := crypto.AddressFromPreimage([]byte("val2"))
+	evidence := []abci.Evidence{
+		{Type: abci.EvidenceTypeDuplicateVote, Address: val1, Height: 1, Power: 10},
+		{Type: abci.EvidenceTypeDuplicateVote, Address: val1, Height: 1, Power: 10},
+		{Type: "other", Address: val1, Height: 1},
+		{Type: abci.EvidenceTypeDuplicateVote, Address: val1, Height: 2},
+		{Type: abci.EvidenceTypeDuplicateVote, Address: val2, Height: 1},
+	}
+	app.BeginBlock(abci.RequestBeginBlock{
+		Header:              &bft.Header{ChainID: "test-chain", Height: 1},
+		ByzantineValidators: evidence,
+	})
+	require.Equal(t, []abci.Evidence{evidence[0], evidence[2], evidence[3], evidence[4]}, handled)
+	expected := append(append(append(val1.Bytes(), val1.Bytes()...), val1.Bytes()...), val2.Bytes()...)
+
+	// the writes are seen by txs of the block.
+	txBytes, err := amino.Marshal(newTxCounter(0, 0))
+	require.NoError(t, err)
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	msgData, err := ParseMsgData(res.Data)
+	require.NoError(t, err)
+	require.Equal(t, expected, msgData[0].Data)
+
+	// and committed with the block.
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	qres := app.Query(abci.RequestQuery{Path: "/.store/main/key", Data: slashedKey})
+	require.Equal(t, expected, qres.Value)
+}
+
+// Validator updates of EndBlock are validated against the validator set of
+// InitChain and previous blocks, which is kept across restarts.
+func TestEndBlockValidatorUpdates(t *testing.T) {
+	newUpdate := func(power int64) abci.ValidatorUpdate {
+		pub := ed25519.GenPrivKey().PubKey()
+		return abci.ValidatorUpdate{Address: pub.Address(), PubKey: pub, Power: power}
+	}
+	withPower := func(vu abci.ValidatorUpdate, power int64) abci.ValidatorUpdate {
+		vu.Power = power
+		return vu
+	}
+	genesisVal, val1, val2 := newUpdate(10), newUpdate(5), newUpdate(7)
+
+	var updates []abci.ValidatorUpdate
+	endBlockerOpt := func(bapp *BaseApp) {
+		bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+			return abci.ResponseEndBlock{ValidatorUpdates: updates}
+		})
+	}
+	db := dbm.NewMemDB()
+	app := newBaseApp(t.Name(), db, endBlockerOpt)
+	require.NoError(t, app.LoadLatestVersion())
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain", Validators: []abci.ValidatorUpdate{genesisVal}})
+
+	endBlock := func(height int64) {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+	}
+	invalid := map[string][]abci.ValidatorUpdate{
+		"negative power":             {withPower(val1, -1)},
+		"duplicate pubkey":           {val1, withPower(val1, 6)},
+		"removal of never added":     {withPower(val2, 0)},
+		"removal of genesis, twice":  {withPower(genesisVal, 0), withPower(genesisVal, 0)},
+		"missing pubkey":             {{Power: 1}},
+		"valid then duplicate again": {val1, val2, val1},
+	}
+	for name, invalidUpdates := range invalid {
+		updates = invalidUpdates
+		require.Panics(t, func() { endBlock(1) }, name)
+		app.deliverState = nil
+	}
+
+	// the validator set is updated from the genesis one.
+	updates = []abci.ValidatorUpdate{val1, withPower(genesisVal, 0)}
+	endBlock(1)
+	updates = []abci.ValidatorUpdate{withPower(val1, 6), val2}
+	endBlock(2)
+
+	// and reloaded on restart.
+	app = newBaseApp(t.Name(), db, endBlockerOpt)
+	require.NoError(t, app.LoadLatestVersion())
+	updates = []abci.ValidatorUpdate{withPower(genesisVal, 0)}
+	require.Panics(t, func() { endBlock(3) })
+	app.deliverState = nil
+	updates = []abci.ValidatorUpdate{withPower(val1, 0), withPower(val2, 0)}
+	endBlock(3)
+	updates = []abci.ValidatorUpdate{withPower(val2, 0)}
+	require.Panics(t, func() { endBlock(4) })
+}
+
+// The responses of the module end blockers and of the end blocker are
+// merged in that order, and conflicting validator updates panic.
+func TestEndBlockMergeModuleEndBlockers(t *testing.T) {
+	newUpdate := func(power int64) abci.ValidatorUpdate {
+		pub := ed25519.GenPrivKey().PubKey()
+		return abci.ValidatorUpdate{Address: pub.Address(), PubKey: pub, Power: power}
+	}
+	withPower := func(vu abci.ValidatorUpdate, power int64) abci.ValidatorUpdate {
+		vu.Power = power
+		return vu
+	}
+	genesisVal, val1, val2, val3 := newUpdate(10), newUpdate(5), newUpdate(7), newUpdate(3)
+
+	var responses [3]abci.ResponseEndBlock
+	endBlocker := func(i int) EndBlocker {
+		return func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+			return responses[i]
+		}
+	}
+	app := setupBaseApp(t, func(bapp *BaseApp) {
+		bapp.SetModuleEndBlockers(endBlocker(0), endBlocker(1))
+		bapp.SetEndBlocker(endBlocker(2))
+	})
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain", Validators: []abci.ValidatorUpdate{genesisVal}})
+	endBlock := func(height int64) abci.ResponseEndBlock {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		res := app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+		return res
+	}
+	event := func(s string) abci.Event { return abci.EventString(s) }
+
+	responses = [3]abci.ResponseEndBlock{
+		{
+			ValidatorUpdates: []abci.ValidatorUpdate{val1, val2},
+			ConsensusParams:  &abci.ConsensusParams{Block: &abci.BlockParams{MaxTxBytes: 1000}},
+			Events:           []abci.Event{event("module 0")},
+		},
+		{
+			// the equal update of val2 is dropped.
+			ValidatorUpdates: []abci.ValidatorUpdate{val2, val3},
+			ConsensusParams:  &abci.ConsensusParams{Block: &abci.BlockParams{MaxTxBytes: 2000}},
+			Events:           []abci.Event{event("module 1")},
+		},
+		{
+			ValidatorUpdates: []abci.ValidatorUpdate{withPower(genesisVal, 0)},
+			Events:           []abci.Event{event("legacy")},
+		},
+	}
+	res := endBlock(1)
+	require.Equal(t, []abci.ValidatorUpdate{val1, val2, val3, withPower(genesisVal, 0)}, res.ValidatorUpdates)
+	require.Equal(t, &abci.ConsensusParams{Block: &abci.BlockParams{MaxTxBytes: 2000}}, res.ConsensusParams)
+	require.Equal(t, []abci.Event{event("module 0"), event("module 1"), event("legacy")}, res.Events)
+	require.ElementsMatch(t, abci.ValidatorUpdates{val1, val2, val3}, app.validatorSet())
+
+	// the module end blockers may run without a legacy one.
+	responses = [3]abci.ResponseEndBlock{
+		{ValidatorUpdates: []abci.ValidatorUpdate{withPower(val1, 6)}},
+		{},
+		{},
+	}
+	require.Equal(t, []abci.ValidatorUpdate{withPower(val1, 6)}, endBlock(2).ValidatorUpdates)
+
+	invalid := map[string][3]abci.ResponseEndBlock{
+		"conflicting powers": {
+			{ValidatorUpdates: []abci.ValidatorUpdate{withPower(val1, 7)}},
+			{},
+			{ValidatorUpdates: []abci.ValidatorUpdate{withPower(val1, 8)}},
+		},
+		"duplicate within a response": {
+			{ValidatorUpdates: []abci.ValidatorUpdate{val2}},
+			{ValidatorUpdates: []abci.ValidatorUpdate{val2, val2}},
+			{},
+		},
+		"removal then update": {
+			{},
+			{ValidatorUpdates: []abci.ValidatorUpdate{withPower(val3, 0)}},
+			{ValidatorUpdates: []abci.ValidatorUpdate{val3}},
+		},
+	}
+	for name, invalidResponses := range invalid {
+		responses = invalidResponses
+		require.Panics(t, func() { endBlock(3) }, name)
+		app.deliverState = nil
+	}
+}
+
+// Validator updates breaching the update policy are all rejected, and the
+// validator set is kept; there is no policy by default.
+func TestValidatorUpdatePolicy(t *testing.T) {
+	newUpdate := func(power int64) abci.ValidatorUpdate {
+		pub := ed25519.GenPrivKey().PubKey()
+		return abci.ValidatorUpdate{Address: pub.Address(), PubKey: pub, Power: power}
+	}
+	genesisVal, val1 := newUpdate(10), newUpdate(5)
+
+	var updates []abci.ValidatorUpdate
+	endBlockerOpt := func(bapp *BaseApp) {
+		bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+			return abci.ResponseEndBlock{ValidatorUpdates: updates}
+		})
+	}
+	endBlock := func(app *BaseApp, height int64) abci.ResponseEndBlock {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		res := app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+		return res
+	}
+	initChain := func(app *BaseApp) {
+		app.InitChain(abci.RequestInitChain{ChainID: "test-chain", Validators: []abci.ValidatorUpdate{genesisVal}})
+	}
+
+	// no policy by default.
+	app := setupBaseApp(t, endBlockerOpt)
+	initChain(app)
+	updates = []abci.ValidatorUpdate{newUpdate(1000)}
+	require.Equal(t, updates, endBlock(app, 1).ValidatorUpdates)
+
+	policy := UpdatePolicy{MaxPowerChange: 10, MaxValidatorShare: 80, MinPower: 2}
+	app = setupBaseApp(t, endBlockerOpt, SetValidatorUpdatePolicy(policy))
+	initChain(app)
+	updates = []abci.ValidatorUpdate{val1, newUpdate(1)}
+	require.Empty(t, endBlock(app, 1).ValidatorUpdates)
+	require.Equal(t, abci.ValidatorUpdates{genesisVal}, app.validatorSet())
+
+	// the rejected val1 can still be added on its own.
+	updates = []abci.ValidatorUpdate{val1}
+	require.Equal(t, updates, endBlock(app, 2).ValidatorUpdates)
+	require.Len(t, app.validatorSet(), 2)
+
+	// removals are checked against the policy too: val1 has 5 of 15.
+	updates = []abci.ValidatorUpdate{{Address: val1.Address, PubKey: val1.PubKey}}
+	require.Empty(t, endBlock(app, 3).ValidatorUpdates)
+	require.Len(t, app.validatorSet(), 2)
+
+	require.Panics(t, func() { SetValidatorUpdatePolicy(UpdatePolicy{MaxValidatorShare: 200}) })
+}
+
+// The validator set is committed with the block which updated it, in the
+// main store, and loaded from it on restart.
+func TestValidatorSetRestart(t *testing.T) {
+	newUpdate := func(power int64) abci.ValidatorUpdate {
+		pub := ed25519.GenPrivKey().PubKey()
+		return abci.ValidatorUpdate{Address: pub.Address(), PubKey: pub, Power: power}
+	}
+	genesisVal, val1, val2 := newUpdate(10), newUpdate(5), newUpdate(7)
+
+	var updates []abci.ValidatorUpdate
+	endBlockerOpt := func(bapp *BaseApp) {
+		bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+			return abci.ResponseEndBlock{ValidatorUpdates: updates}
+		})
+	}
+	db := dbm.NewMemDB()
+	app := newBaseApp(t.Name(), db, endBlockerOpt)
+	require.NoError(t, app.LoadLatestVersion())
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain", Validators: []abci.ValidatorUpdate{genesisVal}})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	hash := app.Commit().Data
+
+	updates = []abci.ValidatorUpdate{val1}
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 2}})
+	app.EndBlock(abci.RequestEndBlock{Height: 2})
+	require.NotEqual(t, hash, app.Commit().Data)
+
+	// the updates of a block which is not committed are lost.
+	updates = []abci.ValidatorUpdate{val2}
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 3}})
+	app.EndBlock(abci.RequestEndBlock{Height: 3})
+	require.Len(t, app.validatorSet(), 3)
+
+	app = newBaseApp(t.Name(), db, endBlockerOpt)
+	require.NoError(t, app.LoadLatestVersion())
+	require.ElementsMatch(t, abci.ValidatorUpdates{genesisVal, val1}, app.validatorSet())
+}
+
+func TestConsensusParamsPubKeyTypes(t *testing.T) {
+	var (
+		updates []abci.ValidatorUpdate
+		params  *abci.ConsensusParams
+		evAge   *abci.EvidenceParams
+	)
+	endBlockerOpt := func(bapp *BaseApp) {
+		bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+			evAge = bapp.GetConsensusParams(ctx).Evidence
+			return abci.ResponseEndBlock{ValidatorUpdates: updates, ConsensusParams: params}
+		})
+	}
+	db := dbm.NewMemDB()
+	app := newBaseApp(t.Name(), db, endBlockerOpt)
+	require.NoError(t, app.LoadLatestVersion())
+	ed25519Only := &abci.ValidatorParams{PubKeyTypeURLs: []string{amino.GetTypeURL(ed25519.PubKeyEd25519{})}}
+	app.InitChain(abci.RequestInitChain{
+		ChainID: "test-chain",
+		ConsensusParams: &abci.ConsensusParams{
+			Evidence:  &abci.EvidenceParams{MaxAgeNumBlocks: 10, MaxAgeDuration: time.Hour},
+			Validator: ed25519Only,
+		},
+	})
+
+	endBlock := func(height int64) {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+	}
+	edPub := ed25519.GenPrivKey().PubKey()
+	secpPub := secp256k1.GenPrivKey().PubKey()
+
+	// an ed25519-only chain rejects secp256k1 validators.
+	updates = []abci.ValidatorUpdate{{Address: secpPub.Address(), PubKey: secpPub, Power: 1}}
+	require.Panics(t, func() { endBlock(1) })
+	app.deliverState = nil
+	updates = []abci.ValidatorUpdate{{Address: edPub.Address(), PubKey: edPub, Power: 1}}
+	endBlock(1)
+	require.Equal(t, &abci.EvidenceParams{MaxAgeNumBlocks: 10, MaxAgeDuration: time.Hour}, evAge)
+
+	// params are persisted, and updated from EndBlock.
+	app = newBaseApp(t.Name(), db, endBlockerOpt)
+	require.NoError(t, app.LoadLatestVersion())
+	require.Equal(t, ed25519Only, app.GetConsensusParams(app.checkState.ctx).Validator)
+	updates = nil
+	params = &abci.ConsensusParams{Validator: &abci.ValidatorParams{
+		PubKeyTypeURLs: []string{amino.GetTypeURL(secp256k1.PubKeySecp256k1{})},
+	}}
+	endBlock(2)
+	params = nil
+	updates = []abci.ValidatorUpdate{{Address: edPub.Address(), PubKey: edPub, Power: 2}}
+	require.Panics(t, func() { endBlock(3) })
+	app.deliverState = nil
+	updates = []abci.ValidatorUpdate{{Address: secpPub.Address(), PubKey: secpPub, Power: 1}}
+	endBlock(3)
+	require.Equal(t, &abci.EvidenceParams{MaxAgeNumBlocks: 10, MaxAgeDuration: time.Hour}, evAge)
+}
+
+func TestBeginBlockTimeValidation(t *testing.T) {
+	db := dbm.NewMemDB()
+	app := newBaseApp(t.Name(), db, SetBlockTimeValidation(time.Minute))
+	require.NoError(t, app.LoadLatestVersion())
+	genesisTime := time.Now().Add(-time.Hour).UTC()
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain", Time: genesisTime})
+
+	beginBlock := func(height int64, blockTime time.Time) {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height, Time: blockTime}})
+	}
+	commitBlock := func(height int64, blockTime time.Time) {
+		beginBlock(height, blockTime)
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+	}
+
+	// the first block may have the genesis time.
+	commitBlock(1, genesisTime)
+	require.Panics(t, func() { beginBlock(2, genesisTime) }, "equal time")
+	require.Panics(t, func() { beginBlock(2, genesisTime.Add(-time.Second)) }, "backwards time")
+	commitBlock(2, genesisTime.Add(time.Second))
+
+	// the last block time is checked after a restart.
+	app = newBaseApp(t.Name(), db, SetBlockTimeValidation(time.Minute))
+	require.NoError(t, app.LoadLatestVersion())
+	require.Panics(t, func() { beginBlock(3, genesisTime.Add(time.Second)) }, "equal time after restart")
+	require.Panics(t, func() { beginBlock(3, time.Now().Add(2*time.Minute)) }, "forward drift")
+	require.Panics(t, func() { beginBlock(3, time.Date(2106, 2, 7, 6, 28, 15, 0, time.UTC)) }, "far future")
+	commitBlock(3, time.Now().Add(30*time.Second))
+
+	// without validation, any time is accepted.
+	app = newBaseApp(t.Name(), db)
+	require.NoError(t, app.LoadLatestVersion())
+	commitBlock(4, genesisTime)
+}
+
+// Number of messages doesn't matter to CheckTx.
+func TestMultiMsgCheckTx(t *testing.T) {
+	// TODO: ensure we get the same results
+	// with one message or many
+}
+
+// The data of a multi-msg tx is the data of each msg, framed.
+func TestMultiMsgData(t *testing.T) {
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			return Result{ResponseBase: abci.ResponseBase{Data: []byte(fmt.Sprintf("one%d", msg.(msgCounter).Counter))}}
+		}))
+		bapp.Router().AddRoute(routeMsgCounter2, newTestHandler(func(ctx Context, msg Msg) Result {
+			return Result{ResponseBase: abci.ResponseBase{Data: []byte("two")}}
+		}))
+	}
+	tx := newTxCounter(0, 1, 2)
+	tx.Msgs = append(tx.Msgs, msgCounter2{0})
+	txBytes, err := amino.Marshal(tx)
+	require.NoError(t, err)
+
+	app := setupBaseApp(t, routerOpt)
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	msgData, err := ParseMsgData(res.Data)
+	require.NoError(t, err)
+	require.Equal(t, []MsgData{
+		{Route: routeMsgCounter, Type: "counter1", Data: []byte("one1")},
+		{Route: routeMsgCounter, Type: "counter1", Data: []byte("one2")},
+		{Route: routeMsgCounter2, Type: "counter2", Data: []byte("two")},
+	}, msgData)
+
+	// the legacy data is concatenated.
+	app = setupBaseApp(t, routerOpt, SetLegacyMsgData(true))
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	require.Equal(t, []byte("one1one2two"), res.Data)
+}
+
+// The msgs delivered are counted by route and type, with their gas and
+// failures, and msgs of CheckTx and simulations are not.
+func TestRouteStats(t *testing.T) {
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			ctx.GasMeter().ConsumeGas(msg.(msgCounter).Counter, "counter1")
+			if msg.(msgCounter).FailOnHandler {
+				return ABCIResultFromError(std.ErrInternal("message handler failure"))
+			}
+			return Result{}
+		}))
+		bapp.Router().AddRoute(routeMsgCounter2, newTestHandler(func(ctx Context, msg Msg) Result {
+			ctx.GasMeter().ConsumeGas(100, "counter2")
+			return Result{}
+		}))
 	}
-
-	app := setupBaseApp(t, anteOpt, routerOpt)
+	app := setupBaseApp(t, routerOpt)
 	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	txs := []Tx{newTxCounter(0, 10, 20), newTxCounter(1, 30), newTxCounter(2, 40, 50)}
+	txs[1].Msgs = append(txs[1].Msgs, msgCounter2{0}, msgCounter2{1})
+	// the second msg fails, and the third is not run.
+	setFailOnHandler(&txs[2], true)
+	txs[2].Msgs = append(txs[2].Msgs, msgCounter2{0})
+	for i, tx := range txs {
+		res := app.Deliver(tx)
+		require.Equal(t, i < 2, res.IsOK(), fmt.Sprintf("%v", res))
+		require.Equal(t, i < 2, app.Simulate(nil, tx).IsOK())
+		res = app.Check(tx)
+		require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	}
 
-	nBlocks := 3
-	txPerHeight := 5
-
-	for blockN := 0; blockN < nBlocks; blockN++ {
-		header := &bft.Header{ChainID: "test-chain", Height: int64(blockN) + 1}
-		app.BeginBlock(abci.RequestBeginBlock{Header: header})
-
-		for i := 0; i < txPerHeight; i++ {
-			counter := int64(blockN*txPerHeight + i)
-			tx := newTxCounter(counter, counter)
-
-			txBytes, err := amino.Marshal(tx)
-			require.NoError(t, err)
+	stats := app.RouteStats()
+	require.Len(t, stats, 2)
+	for i := range stats {
+		require.True(t, stats[i].Time > 0)
+		stats[i].Time = 0
+	}
+	require.Equal(t, []RouteStats{
+		{Route: routeMsgCounter, Type: "counter1", Count: 4, Failures: 1, GasUsed: 10 + 20 + 30 + 40},
+		{Route: routeMsgCounter2, Type: "counter2", Count: 2, GasUsed: 200},
+	}, stats)
+
+	res := app.Query(abci.RequestQuery{Path: "/.app/route_stats"})
+	require.True(t, res.IsOK(), res.Log)
+	var queried []RouteStats
+	require.NoError(t, json.Unmarshal(res.Value, &queried))
+	require.Len(t, queried, 2)
+	require.Equal(t, uint64(4), queried[0].Count)
+	require.Equal(t, uint64(200), queried[1].GasUsed)
+}
 
-			res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
-			require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+// Simulate runs on the last committed state by default, so its results do
+// not depend on the txs checked meanwhile, even concurrently.
+func TestSimulateFromCommitted(t *testing.T) {
+	counterKey := []byte("counter-key")
+	setup := func(t *testing.T, options ...func(*BaseApp)) *BaseApp {
+		t.Helper()
+		// the gas used by a tx is the number of txs before it in the state.
+		anteOpt := func(bapp *BaseApp) {
+			bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+				store := ctx.Store(mainKey)
+				counter := getIntFromStore(store, counterKey)
+				ctx.GasMeter().ConsumeGas(counter*1000, "counter")
+				setIntOnStore(store, counterKey, counter+1)
+				return ctx, res, false
+			})
 		}
-
+		routerOpt := func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+				return Result{}
+			}))
+		}
+		app := setupBaseApp(t, append([]func(*BaseApp){anteOpt, routerOpt}, options...)...)
+		app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+		header := &bft.Header{ChainID: "test-chain", Height: 1}
+		app.BeginBlock(abci.RequestBeginBlock{Header: header})
+		res := app.Deliver(newTxCounter(0, 0))
+		require.True(t, res.IsOK(), res.Log)
 		app.EndBlock(abci.RequestEndBlock{})
 		app.Commit()
+		return app
 	}
+	tx := newTxCounter(0, 0)
+	txBytes := amino.MustMarshal(tx)
+
+	t.Run("committed", func(t *testing.T) {
+		app := setup(t)
+		want := app.Simulate(txBytes, tx)
+		require.True(t, want.IsOK(), want.Log)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				res := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+				require.True(t, res.IsOK(), res.Log)
+			}
+		}()
+		gasUsed := make([][]int64, 4)
+		for i := range gasUsed {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					res := app.Simulate(txBytes, tx)
+					gasUsed[i] = append(gasUsed[i], res.GasUsed)
+				}
+			}(i)
+		}
+		wg.Wait()
+		for _, used := range gasUsed {
+			for _, gas := range used {
+				require.Equal(t, want.GasUsed, gas)
+			}
+		}
+		// the checked txs are in the check state.
+		res := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+		require.True(t, res.GasUsed > want.GasUsed+100*1000)
+	})
+
+	t.Run("check state", func(t *testing.T) {
+		app := setup(t, SetSimulateFromCommitted(false))
+		first := app.Simulate(txBytes, tx)
+		require.True(t, first.IsOK(), first.Log)
+		res := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+		require.True(t, res.IsOK(), res.Log)
+		// the checked tx is seen by the simulations.
+		require.Equal(t, first.GasUsed+1000, app.Simulate(txBytes, tx).GasUsed)
+	})
 }
 
-// Number of messages doesn't matter to CheckTx.
-func TestMultiMsgCheckTx(t *testing.T) {
-	// TODO: ensure we get the same results
-	// with one message or many
+func jsonTxDecoder(txBytes []byte) (tx Tx, err error) {
+	err = amino.UnmarshalJSON(txBytes, &tx)
+	return tx, err
 }
 
-// One call to DeliverTx should process all the messages, in order.
-func TestMultiMsgDeliverTx(t *testing.T) {
-	// increment the tx counter
-	anteKey := []byte("ante-key")
-	anteOpt := func(bapp *BaseApp) { bapp.SetAnteHandler(anteHandlerTxTest(t, mainKey, anteKey)) }
+func jsonTxEncoder(tx Tx) ([]byte, error) {
+	return amino.MarshalJSON(tx)
+}
 
-	// increment the msg counter
-	deliverKey := []byte("deliver-key")
-	deliverKey2 := []byte("deliver-key2")
+// The tx encoder encodes the txs delivered directly as the tx decoder of
+// DeliverTx decodes them, so that they have the same hash.
+func TestTxEncoder(t *testing.T) {
+	var hashes [][]byte
+	codecOpt := func(bapp *BaseApp) {
+		bapp.SetTxDecoder(jsonTxDecoder)
+		bapp.SetTxEncoder(jsonTxEncoder)
+	}
 	routerOpt := func(bapp *BaseApp) {
-		bapp.Router().AddRoute(routeMsgCounter, newMsgCounterHandler(t, mainKey, deliverKey))
-		bapp.Router().AddRoute(routeMsgCounter2, newMsgCounterHandler(t, mainKey, deliverKey2))
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			hashes = append(hashes, ctx.TxHash())
+			return Result{}
+		}))
 	}
+	app := setupBaseApp(t, codecOpt, routerOpt)
+	require.Panics(t, func() { app.SetTxEncoder(DefaultTxEncoder) })
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
 
-	app := setupBaseApp(t, anteOpt, routerOpt)
-
-	// run a multi-msg tx
-	// with all msgs the same route
-
-	header := &bft.Header{ChainID: "test-chain", Height: 1}
-	app.BeginBlock(abci.RequestBeginBlock{Header: header})
-	tx := newTxCounter(0, 0, 1, 2)
-	txBytes, err := amino.Marshal(tx)
+	tx := newTxCounter(0, 0)
+	txBytes, err := app.TxEncoder()(tx)
 	require.NoError(t, err)
-	res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
-	require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
-
-	store := app.deliverState.ctx.Store(mainKey)
-
-	// tx counter only incremented once
-	txCounter := getIntFromStore(store, anteKey)
-	require.Equal(t, int64(1), txCounter)
-
-	// msg counter incremented three times
-	msgCounter := getIntFromStore(store, deliverKey)
-	require.Equal(t, int64(3), msgCounter)
-
-	// replace the second message with a msgCounter2
-
-	tx = newTxCounter(1, 3)
-	tx.Msgs = append(tx.Msgs, msgCounter2{0})
-	tx.Msgs = append(tx.Msgs, msgCounter2{1})
-	txBytes, err = amino.Marshal(tx)
+	require.True(t, json.Valid(txBytes))
+	decoded, err := app.TxDecoder()(txBytes)
 	require.NoError(t, err)
-	res = app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
-	require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+	require.Equal(t, tx, decoded)
 
-	store = app.deliverState.ctx.Store(mainKey)
-
-	// tx counter only incremented once
-	txCounter = getIntFromStore(store, anteKey)
-	require.Equal(t, int64(2), txCounter)
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	require.True(t, res.IsOK(), res.Log)
+	// amino bytes do not decode.
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(tx)})
+	require.Equal(t, std.TxDecodeError{}, res.Error)
+
+	result := app.Deliver(tx)
+	require.True(t, result.IsOK(), result.Log)
+	require.Equal(t, [][]byte{bft.Tx(txBytes).Hash(), bft.Tx(txBytes).Hash()}, hashes)
+}
 
-	// original counter increments by one
-	// new counter increments by two
-	msgCounter = getIntFromStore(store, deliverKey)
-	require.Equal(t, int64(4), msgCounter)
-	msgCounter2 := getIntFromStore(store, deliverKey2)
-	require.Equal(t, int64(2), msgCounter2)
+// A tx encoder and decoder which do not round-trip fail to load.
+func TestTxCodecValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		decoder TxDecoder
+		encoder TxEncoder
+		err     string
+	}{
+		{"json decoder", jsonTxDecoder, nil, "failed to decode"},
+		{"json encoder", nil, jsonTxEncoder, "failed to decode"},
+		{"lossy decoder", func(txBytes []byte) (Tx, error) {
+			tx, err := DefaultTxDecoder(txBytes)
+			tx.Memo = ""
+			return tx, err
+		}, nil, "do not round-trip"},
+		{"failing encoder", nil, func(tx Tx) ([]byte, error) {
+			return nil, fmt.Errorf("cannot encode")
+		}, "failed to encode"},
+		{"defaults", DefaultTxDecoder, DefaultTxEncoder, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newBaseApp(t.Name(), dbm.NewMemDB(), func(bapp *BaseApp) {
+				if tc.decoder != nil {
+					bapp.SetTxDecoder(tc.decoder)
+				}
+				if tc.encoder != nil {
+					bapp.SetTxEncoder(tc.encoder)
+				}
+			})
+			err := app.LoadLatestVersion()
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
 }
 
 // Interleave calls to Check and Deliver and ensure
@@ -712,7 +1806,7 @@ func TestSimulateTx(t *testing.T) {
 
 		// simulate by calling Query with encoded tx
 		query := abci.RequestQuery{
-			Path: ".app/simulate",
+			Path: "/.app/simulate",
 			Data: txBytes,
 		}
 		queryResult := app.Query(query)
@@ -747,9 +1841,9 @@ func TestRunInvalidTransaction(t *testing.T) {
 	// Transaction with no messages
 	{
 		emptyTx := std.Tx{}
-		err := app.Deliver(emptyTx)
-		_, ok := err.Error.(std.UnknownRequestError)
-		require.True(t, ok)
+		res := app.Deliver(emptyTx)
+		require.True(t, ErrorIs(res, std.UnknownRequestError{}))
+		require.False(t, ErrorIs(res, std.InvalidSequenceError{}))
 	}
 
 	// Transaction where ValidateBasic fails
@@ -772,8 +1866,7 @@ func TestRunInvalidTransaction(t *testing.T) {
 			tx := testCase.tx
 			res := app.Deliver(tx)
 			if testCase.fail {
-				_, ok := res.Error.(std.InvalidSequenceError)
-				require.True(t, ok)
+				require.True(t, ErrorIs(res, std.InvalidSequenceError{}))
 			} else {
 				require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
 			}
@@ -783,25 +1876,23 @@ func TestRunInvalidTransaction(t *testing.T) {
 	// Transaction with no known route
 	{
 		unknownRouteTx := std.Tx{Msgs: []Msg{msgNoRoute{}}}
-		err := app.Deliver(unknownRouteTx)
-		_, ok := err.Error.(std.UnknownRequestError)
-		require.True(t, ok)
+		res := app.Deliver(unknownRouteTx)
+		require.True(t, ErrorIs(res, std.UnknownRequestError{}))
 
 		unknownRouteTx = std.Tx{Msgs: []Msg{msgCounter{}, msgNoRoute{}}}
-		err = app.Deliver(unknownRouteTx)
-		_, ok = err.Error.(std.UnknownRequestError)
-		require.True(t, ok)
+		res = app.Deliver(unknownRouteTx)
+		require.True(t, ErrorIs(res, std.UnknownRequestError{}))
 	}
 
 	// Transaction with an unregistered message
 	{
 		txBytes := []byte{0xFF, 0xFF, 0xFF}
 		res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
-		_, ok := res.Error.(std.TxDecodeError)
-		require.True(t, ok)
+		require.True(t, goerrors.Is(res.Error, std.TxDecodeError{}))
 	}
 }
 
+// Test that transactions exceeding gas limits fail
 // Test that transactions exceeding gas limits fail
 func TestTxGasLimits(t *testing.T) {
 	gasGranted := int64(10)
@@ -871,10 +1962,88 @@ func TestTxGasLimits(t *testing.T) {
 		if !tc.fail {
 			require.True(t, res.IsOK(), fmt.Sprintf("%d: %v, %v", i, tc, res))
 		} else {
-			_, ok := res.Error.(std.OutOfGasError)
-			require.True(t, ok, fmt.Sprintf("%d: %v, %v", i, tc, res))
+			require.True(t, ErrorIs(res, std.OutOfGasError{}), fmt.Sprintf("%d: %v, %v", i, tc, res))
+		}
+	}
+}
+
+// Test that the gas is limited to the gas wanted by the tx fee, without the
+// ante handler setting the gas meter.
+func TestTxGasMeter(t *testing.T) {
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			ctx.GasMeter().ConsumeGas(getCounter(tx), "counter-ante")
+			return ctx, Result{}, false
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			ctx.GasMeter().ConsumeGas(msg.(msgCounter).Counter, "counter-handler")
+			return Result{}
+		}))
+	}
+	newTx := func(gasWanted int64, txInt int64, msgInts ...int64) std.Tx {
+		tx := newTxCounter(txInt, msgInts...)
+		tx.Fee.GasWanted = gasWanted
+		return tx
+	}
+
+	app := setupBaseApp(t, anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	header := &bft.Header{ChainID: "test-chain", Height: 1}
+	app.BeginBlock(abci.RequestBeginBlock{Header: header})
+
+	testCases := []struct {
+		tx       std.Tx
+		gasUsed  int64
+		fail     bool
+		location string
+	}{
+		{newTx(10, 0, 0), 0, false, ""},
+		{newTx(10, 1, 1), 2, false, ""},
+		{newTx(10, 9, 1), 10, false, ""},
+		{newTx(10, 0, 5, 1, 1, 1, 1, 1), 10, false, ""},
+		{newTx(0, 0, 0), 0, false, ""},
+
+		{newTx(10, 9, 2), 11, true, "counter-handler"},
+		{newTx(10, 11, 0), 11, true, "counter-ante"},
+		{newTx(10, 0, 5, 11), 16, true, "counter-handler"},
+		{newTx(0, 1, 0), 1, true, "counter-ante"},
+		{newTx(-1, 1, 0), 1, true, "counter-ante"},
+	}
+
+	for i, tc := range testCases {
+		res := app.Deliver(tc.tx)
+		require.Equal(t, tc.tx.Fee.GasWanted, res.GasWanted, "%d: %v", i, res)
+		require.Equal(t, tc.gasUsed, res.GasUsed, "%d: %v", i, res)
+		if !tc.fail {
+			require.True(t, res.IsOK(), "%d: %v", i, res)
+		} else {
+			require.True(t, ErrorIs(res, std.OutOfGasError{}), "%d: %v", i, res)
+			require.Contains(t, res.Log, "location: "+tc.location, "%d", i)
 		}
 	}
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	// the gas is limited in CheckTx too.
+	res := app.Check(newTx(10, 11, 0))
+	require.True(t, ErrorIs(res, std.OutOfGasError{}), res.Log)
+	require.Equal(t, int64(11), res.GasUsed)
+
+	// simulations are not limited, but still metered.
+	res = app.Simulate(nil, newTx(10, 9, 2))
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, int64(11), res.GasUsed)
+
+	// the gas is not limited if the ante handler is to set the gas meter.
+	app = setupBaseApp(t, anteOpt, routerOpt, SetTxGasMeter(false))
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: header})
+	res = app.Deliver(newTx(10, 9, 2))
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, int64(11), res.GasUsed)
+	require.Equal(t, int64(0), res.GasWanted)
 }
 
 // Test that transactions exceeding gas limits fail
@@ -952,8 +2121,7 @@ func TestMaxBlockGasLimits(t *testing.T) {
 
 			// check for failed transactions
 			if tc.fail && (j+1) > tc.failAfterDeliver {
-				_, ok := res.Error.(std.OutOfGasError)
-				require.True(t, ok, fmt.Sprintf("%d: %v, %v", i, tc, res))
+				require.True(t, ErrorIs(res, std.OutOfGasError{}), fmt.Sprintf("%d: %v, %v", i, tc, res))
 				require.True(t, ctx.BlockGasMeter().IsOutOfGas())
 			} else {
 				// check gas used and wanted
@@ -968,6 +2136,74 @@ func TestMaxBlockGasLimits(t *testing.T) {
 	}
 }
 
+// Test that a change of the max block gas applies from the next block on.
+func TestMaxBlockGasUpdate(t *testing.T) {
+	var maxGasUpdate int64
+	opts := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			ctx.GasMeter().ConsumeGas(msg.(msgCounter).Counter, "counter-handler")
+			return Result{}
+		}))
+		bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) (res abci.ResponseEndBlock) {
+			if maxGasUpdate != 0 {
+				res.ConsensusParams = &abci.ConsensusParams{
+					Block: &abci.BlockParams{MaxGas: maxGasUpdate},
+				}
+				maxGasUpdate = 0
+			}
+			return
+		})
+	}
+
+	app := setupBaseApp(t, opts)
+	app.InitChain(abci.RequestInitChain{
+		ChainID: "test-chain",
+		ConsensusParams: &abci.ConsensusParams{
+			Block: &abci.BlockParams{MaxGas: 100},
+		},
+	})
+	runBlock := func(txs ...std.Tx) (results []Result, remaining int64) {
+		header := &bft.Header{ChainID: "test-chain", Height: app.LastBlockHeight() + 1}
+		app.BeginBlock(abci.RequestBeginBlock{Header: header})
+		for _, tx := range txs {
+			results = append(results, app.Deliver(tx))
+		}
+		remaining = app.deliverState.ctx.BlockGasRemaining()
+		app.EndBlock(abci.RequestEndBlock{Height: header.Height})
+		app.Commit()
+		return
+	}
+
+	// the max block gas is lowered at the end of the block.
+	maxGasUpdate = 20
+	results, remaining := runBlock(newTxCounter(0, 30), newTxCounter(0, 30))
+	require.True(t, results[0].IsOK(), results[0].Log)
+	require.True(t, results[1].IsOK(), results[1].Log)
+	require.Equal(t, int64(40), remaining)
+
+	// and enforced from the next block on.
+	results, remaining = runBlock(newTxCounter(0, 15), newTxCounter(0, 15))
+	require.True(t, results[0].IsOK(), results[0].Log)
+	require.True(t, ErrorIs(results[1], std.OutOfGasError{}), results[1].Log)
+	require.Equal(t, int64(0), remaining)
+	require.Empty(t, results[0].Events)
+	require.Empty(t, results[1].Events)
+
+	// the first tx of a block exceeding the max block gas alone is reported.
+	results, _ = runBlock(newTxCounter(0, 30))
+	require.True(t, ErrorIs(results[0], std.OutOfGasError{}), results[0].Log)
+	require.Equal(t, []Event{abci.NewEvent(EventTypeBlockGasExceeded,
+		abci.NewAttribute("gas_used", "30"),
+		abci.NewAttribute("max_gas", "20"),
+	)}, results[0].Events)
+
+	// the max block gas is the stored one, whatever the cached one.
+	app.setConsensusParams(&abci.ConsensusParams{Block: &abci.BlockParams{MaxGas: 100}})
+	results, _ = runBlock(newTxCounter(0, 15), newTxCounter(0, 15))
+	require.True(t, results[0].IsOK(), results[0].Log)
+	require.True(t, ErrorIs(results[1], std.OutOfGasError{}), results[1].Log)
+}
+
 func TestBaseAppAnteHandler(t *testing.T) {
 	anteKey := []byte("ante-key")
 	anteOpt := func(bapp *BaseApp) {
@@ -1127,7 +2363,7 @@ func TestQuery(t *testing.T) {
 	// and the final "/key" says to use the data as the
 	// key in the given Store ...
 	query := abci.RequestQuery{
-		Path: ".store/main/key",
+		Path: "/.store/main/key",
 		Data: key,
 	}
 	tx := newTxCounter(0, 0)
@@ -1157,6 +2393,229 @@ func TestQuery(t *testing.T) {
 	require.Equal(t, value, res.Value)
 }
 
+// The height of query responses is the height of the committed state which
+// served them, and the proofs are of the state of that height.
+func TestQueryHeight(t *testing.T) {
+	key := []byte("key")
+	var height int64
+	app := setupBaseApp(t, SetPruningOptions(store.PruneNothing), func(bapp *BaseApp) {
+		bapp.SetBeginBlocker(func(ctx Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+			ctx.Store(mainKey).Set(key, []byte(fmt.Sprintf("value%d", height)))
+			return abci.ResponseBeginBlock{}
+		})
+		bapp.Router().AddRoute(routeMsgCounter, testHandler{
+			query: func(ctx Context, req abci.RequestQuery) abci.ResponseQuery {
+				return abci.ResponseQuery{Value: ctx.Store(mainKey).Get(key)}
+			},
+		})
+	})
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	appHashes := make(map[int64][]byte)
+	for height = 1; height <= 3; height++ {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		appHashes[height] = app.Commit().Data
+	}
+
+	cases := []struct {
+		path   string
+		height int64
+		// expected height of the response, or 0 for an error.
+		resHeight int64
+		value     string
+	}{
+		{"/.store/main/key", 0, 3, "value3"},
+		{"/.store/main/key", 3, 3, "value3"},
+		{"/.store/main/key", 2, 2, "value2"},
+		{"/.store/main/key", 4, 0, ""},
+		{"/.store/main/key", -1, 0, ""},
+		{"/" + routeMsgCounter, 0, 3, "value3"},
+		{"/" + routeMsgCounter, 1, 1, "value1"},
+		{"/" + routeMsgCounter, 4, 0, ""},
+		{"/.app/chain_id", 0, 3, "test-chain"},
+		{"/.app/chain_id", 2, 3, "test-chain"}, // served from the last state.
+		{"/.app/chain_id", 4, 0, ""},
+		{"/.app/commit_info", 0, 3, ""},
+		{"/.app/commit_info", 2, 2, ""},
+	}
+	for _, c := range cases {
+		name := fmt.Sprintf("%s at height %d", c.path, c.height)
+		res := app.Query(abci.RequestQuery{Path: c.path, Data: key, Height: c.height})
+		if c.resHeight == 0 {
+			require.False(t, res.IsOK(), name)
+			continue
+		}
+		require.True(t, res.IsOK(), "%s: %s", name, res.Log)
+		require.Equal(t, c.resHeight, res.Height, name)
+		if c.value != "" {
+			require.Equal(t, c.value, string(res.Value), name)
+		}
+	}
+
+	// the proof of a height is verified against its app hash only.
+	prt := rootmulti.DefaultProofRuntime()
+	res := app.Query(abci.RequestQuery{Path: "/.store/main/key", Data: key, Height: 2, Prove: true})
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, int64(2), res.Height)
+	require.NoError(t, prt.VerifyValue(res.Proof, appHashes[2], "/main/key", []byte("value2")))
+	require.Error(t, prt.VerifyValue(res.Proof, appHashes[1], "/main/key", []byte("value2")))
+	require.Error(t, prt.VerifyValue(res.Proof, appHashes[3], "/main/key", []byte("value2")))
+}
+
+// Custom query handlers get a read-only context, whose writes panic, which
+// is converted into a query error and changes neither the committed state
+// nor the check state.
+func TestQueryReadOnly(t *testing.T) {
+	key, value := []byte("hello"), []byte("goodbye")
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, testHandler{
+			process: func(ctx Context, msg Msg) Result {
+				require.False(t, ctx.IsReadOnly())
+				ctx.Store(mainKey).Set(key, value)
+				return Result{}
+			},
+			query: func(ctx Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+				require.True(t, ctx.IsReadOnly())
+				st := ctx.Store(mainKey)
+				res.Value = st.Get(key)
+				require.True(t, st.Has(key))
+				iter := st.Iterator(nil, nil)
+				require.True(t, iter.Valid())
+				iter.Close()
+				switch req.Path {
+				case routeMsgCounter + "/set":
+					st.Set(key, []byte("poison"))
+				case routeMsgCounter + "/delete":
+					st.Delete(key)
+				}
+				return res
+			},
+		})
+	}
+	app := setupBaseApp(t, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	resTx := app.Deliver(newTxCounter(0, 0))
+	require.True(t, resTx.IsOK(), fmt.Sprintf("%v", resTx))
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	res := app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter + "/get"})
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, value, res.Value)
+
+	for _, op := range []string{"set", "delete"} {
+		res = app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter + "/" + op})
+		require.False(t, res.IsOK())
+		require.Contains(t, res.Log, "read-only context")
+	}
+
+	require.Equal(t, value, app.checkState.ctx.Store(mainKey).Get(key))
+	res = app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter + "/get"})
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, value, res.Value)
+}
+
+// Test that queries are served concurrently with commits, from the state of
+// the last committed block. Run with -race.
+func TestQueryConcurrentWithCommit(t *testing.T) {
+	key := []byte("height")
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, testHandler{
+			process: func(ctx Context, msg Msg) Result {
+				setIntOnStore(ctx.Store(mainKey), key, ctx.BlockHeight())
+				return Result{}
+			},
+			query: func(ctx Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+				// the stored height, and the height of the header.
+				var value bytes.Buffer
+				value.Write(i2b(getIntFromStore(ctx.Store(mainKey), key)))
+				value.Write(i2b(ctx.BlockHeight()))
+				res.Value = value.Bytes()
+				res.Height = req.Height
+				return
+			},
+		})
+	}
+	app := setupBaseApp(t, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	const numBlocks = 20
+	const numQueriers = 8
+
+	var committed sync.Map // height -> true
+	runBlock := func() {
+		height := app.LastBlockHeight() + 1
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		res := app.Deliver(newTxCounter(0, 0))
+		require.True(t, res.IsOK(), res.Log)
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+		committed.Store(height, true)
+	}
+	runBlock()
+
+	txBytes, err := amino.Marshal(newTxCounter(0, 0))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	var numQueries int64 // accessed atomically
+	heights := make([][]int64, numQueriers)
+	for i := 0; i < numQueriers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				res := app.Query(abci.RequestQuery{Path: "/.store/main/key", Data: key})
+				if stored, _ := binary.Varint(res.Value); !res.IsOK() || stored != res.Height {
+					t.Errorf("store query at height %d: %v %X", res.Height, res.Log, res.Value)
+					return
+				}
+				heights[i] = append(heights[i], res.Height)
+
+				res = app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter + "/height"})
+				if !res.IsOK() || !bytes.Equal(res.Value, append(i2b(res.Height), i2b(res.Height)...)) {
+					t.Errorf("custom query at height %d: %v %X", res.Height, res.Log, res.Value)
+					return
+				}
+				heights[i] = append(heights[i], res.Height)
+
+				res = app.Query(abci.RequestQuery{Path: "/.app/simulate", Data: txBytes})
+				if !res.IsOK() {
+					t.Errorf("simulate query: %v", res.Log)
+					return
+				}
+				atomic.AddInt64(&numQueries, 1)
+			}
+		}(i)
+	}
+
+	// let queries run during each block.
+	for h := 0; h < numBlocks && !t.Failed(); h++ {
+		for target := atomic.LoadInt64(&numQueries) + numQueriers; atomic.LoadInt64(&numQueries) < target && !t.Failed(); {
+			runtime.Gosched()
+		}
+		runBlock()
+	}
+	close(done)
+	wg.Wait()
+
+	for i := range heights {
+		require.NotEmpty(t, heights[i])
+		for _, height := range heights[i] {
+			_, ok := committed.Load(height)
+			require.True(t, ok, "height %d was not committed", height)
+		}
+	}
+}
+
 func TestGetMaximumBlockGas(t *testing.T) {
 	app := setupBaseApp(t)
 
@@ -1187,3 +2646,301 @@ var Package = amino.RegisterPackage(amino.NewPackage(
 		msgCounter2{},
 		msgCounterHandler{},
 	))
+
+func TestRedactInternalErrors(t *testing.T) {
+	setup := func(t *testing.T, debug bool) (*BaseApp, *bytes.Buffer) {
+		t.Helper()
+		var buf bytes.Buffer
+		loggerOpt := func(bapp *BaseApp) { bapp.logger = log.NewTMLogger(log.NewSyncWriter(&buf)) }
+		anteOpt := func(bapp *BaseApp) {
+			bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+				newCtx = ctx
+				if getCounter(tx) < 0 {
+					res = ABCIResultFromError(std.ErrUnauthorized("negative counter"))
+					abort = true
+				}
+				return
+			})
+		}
+		routerOpt := func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+				if msg.(msgCounter).Counter == 1 {
+					// an untyped error, as a StringError.
+					return ABCIResultFromError(goerrors.New("secret string"))
+				}
+				panic("secret detail")
+			}))
+		}
+		app := setupBaseApp(t, loggerOpt, anteOpt, routerOpt, SetDebugErrors(debug))
+		app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+		return app, &buf
+	}
+	txBytes := func(tx std.Tx) []byte {
+		bz, err := amino.Marshal(tx)
+		require.NoError(t, err)
+		return bz
+	}
+	panicking := txBytes(newTxCounter(0, 0))
+	hash := fmt.Sprintf("%X", bft.Tx(panicking).Hash())
+	untyped := txBytes(newTxCounter(0, 1))
+	untypedHash := fmt.Sprintf("%X", bft.Tx(untyped).Hash())
+
+	// the results of both are hashed in the LastResultsHash, and must not
+	// depend on the debug errors setting of the node.
+	var resultsBytes [2][][]byte
+
+	t.Run("redacted", func(t *testing.T) {
+		app, buf := setup(t, false)
+		res := app.DeliverTx(abci.RequestDeliverTx{Tx: panicking})
+		require.Equal(t, std.InternalError{}, res.Error)
+		require.Equal(t, "internal error (codespace sdk, code 1), see the node logs for tx "+hash, res.Log)
+		resultsBytes[0] = append(resultsBytes[0], bft.NewResultFromResponse(res).Bytes())
+
+		res = app.DeliverTx(abci.RequestDeliverTx{Tx: untyped})
+		require.Equal(t, std.InternalError{}, res.Error)
+		require.Equal(t, "internal error (codespace sdk, code 1), see the node logs for tx "+untypedHash, res.Log)
+		resultsBytes[0] = append(resultsBytes[0], bft.NewResultFromResponse(res).Bytes())
+		require.Contains(t, buf.String(), "secret string")
+
+		// the details are logged with the tx hash.
+		require.Contains(t, buf.String(), "Internal error in tx")
+		require.Contains(t, buf.String(), hash)
+		require.Contains(t, buf.String(), "secret detail")
+
+		checkRes := app.CheckTx(abci.RequestCheckTx{Tx: txBytes(newTxCounter(0, 0))})
+		require.True(t, checkRes.IsOK(), checkRes.Log) // handlers do not run in CheckTx
+
+		// typed errors are untouched.
+		unauthorized := txBytes(newTxCounter(-1, 0))
+		res = app.DeliverTx(abci.RequestDeliverTx{Tx: unauthorized})
+		require.Equal(t, std.UnauthorizedError{}, res.Error)
+		require.Contains(t, res.Log, "negative counter")
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		app, buf := setup(t, true)
+		res := app.DeliverTx(abci.RequestDeliverTx{Tx: panicking})
+		require.Equal(t, std.InternalError{}, res.Error)
+		require.Contains(t, res.Log, "recovered: secret detail")
+		require.Contains(t, res.Log, "stack:")
+		resultsBytes[1] = append(resultsBytes[1], bft.NewResultFromResponse(res).Bytes())
+
+		res = app.DeliverTx(abci.RequestDeliverTx{Tx: untyped})
+		require.Equal(t, std.InternalError{}, res.Error)
+		require.Contains(t, res.Log, "secret string")
+		resultsBytes[1] = append(resultsBytes[1], bft.NewResultFromResponse(res).Bytes())
+		require.NotContains(t, buf.String(), "Internal error in tx")
+	})
+
+	require.Equal(t, resultsBytes[0], resultsBytes[1])
+}
+
+type testPanic struct{ detail string }
+
+func TestRecoveryHandlers(t *testing.T) {
+	var calls []string
+	handler := func(name string, handles bool) RecoveryHandler {
+		return func(r interface{}) (res Result, ok bool) {
+			calls = append(calls, name)
+			tp, ok := r.(testPanic)
+			if !ok || !handles {
+				return res, false
+			}
+			res.Error = ABCIError(std.ErrUnauthorized(tp.detail))
+			res.Log = name + ": " + tp.detail
+			return res, true
+		}
+	}
+	app := setupBaseApp(t, func(bapp *BaseApp) {
+		bapp.AddRecoveryHandler(handler("first", false))
+		bapp.AddRecoveryHandler(handler("second", true))
+		bapp.AddRecoveryHandler(handler("third", true))
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			if msg.(msgCounter).Counter == 0 {
+				panic(testPanic{"detail"})
+			}
+			panic("other")
+		}))
+	}, SetDebugErrors(true))
+	require.Panics(t, func() { app.AddRecoveryHandler(handler("sealed", true)) })
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	// the handlers are tried in order, until one handles the panic.
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, 0))})
+	require.Equal(t, std.UnauthorizedError{}, res.Error)
+	require.Equal(t, "second: detail", res.Log)
+	require.Equal(t, []string{"first", "second"}, calls)
+	require.Equal(t, int64(testTxGasWanted), res.GasWanted)
+
+	// panics no handler handles are recovered as before.
+	calls = nil
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(1, 1))})
+	require.Equal(t, std.InternalError{}, res.Error)
+	require.Contains(t, res.Log, "recovered: other")
+	require.Equal(t, []string{"first", "second", "third"}, calls)
+}
+
+func TestQueryInvariants(t *testing.T) {
+	invariant := func(name string, broken bool) Invariant {
+		return func(ctx Context) (string, bool) {
+			return FormatInvariant("test", name, "details of "+name), broken
+		}
+	}
+	app := setupBaseApp(t)
+	app.InvariantRegistry().RegisterRoute("test", "ok", invariant("ok", false))
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.Commit()
+
+	query := abci.RequestQuery{Path: "/.app/invariants"}
+	res := app.Query(query)
+	require.True(t, res.IsOK(), res.Log)
+
+	// all the broken invariants are reported.
+	app.InvariantRegistry().RegisterRoute("test", "first", invariant("first", true))
+	app.InvariantRegistry().RegisterRoute("test", "second", invariant("second", true))
+	res = app.Query(query)
+	require.False(t, res.IsOK())
+	require.Equal(t, "2 errors occurred:\n"+
+		"  1. test: first invariant\n     details of first\n"+
+		"  2. test: second invariant\n     details of second", res.Log)
+}
+
+func TestGasBreakdown(t *testing.T) {
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			newCtx = ctx.WithGasMeter(store.NewGasMeter(100000))
+			newCtx.GasMeter().ConsumeGas(10, "sig:test")
+			newCtx.GasMeter().ConsumeGas(getCounter(tx), "uncategorized")
+			return
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			ctx.Store(mainKey).Set([]byte("key"), []byte("value"))
+			return Result{}
+		}))
+	}
+
+	// there is no breakdown by default.
+	app := setupBaseApp(t, anteOpt, routerOpt)
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res := app.Deliver(newTxCounter(1, 0))
+	require.True(t, res.IsOK(), res.Log)
+	require.Nil(t, res.GasBreakdown)
+
+	app = setupBaseApp(t, anteOpt, routerOpt, SetGasBreakdown(true))
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res = app.Deliver(newTxCounter(1, 0))
+	require.True(t, res.IsOK(), res.Log)
+	checkBreakdown := func(res Result) {
+		t.Helper()
+		require.Len(t, res.GasBreakdown, 3)
+		require.Equal(t, GasUsage{"other", 1}, res.GasBreakdown[0])
+		require.Equal(t, GasUsage{"sig", 10}, res.GasBreakdown[1])
+		require.Equal(t, "store", res.GasBreakdown[2].Category)
+		total := int64(0)
+		for _, usage := range res.GasBreakdown {
+			total += usage.Gas
+		}
+		require.Equal(t, res.GasUsed, total)
+	}
+	checkBreakdown(res)
+
+	// the breakdown is in the result of the simulate query.
+	txBytes, err := amino.Marshal(newTxCounter(1, 0))
+	require.NoError(t, err)
+	qres := app.Query(abci.RequestQuery{Path: "/.app/simulate", Data: txBytes})
+	require.True(t, qres.IsOK(), qres.Log)
+	var simRes Result
+	require.NoError(t, amino.Unmarshal(qres.Value, &simRes))
+	checkBreakdown(simRes)
+}
+
+// The write sets of the blocks are recorded deterministically, and
+// replaying them on a fresh store yields the same app hashes.
+func TestWriteSetRecorder(t *testing.T) {
+	anteKey := []byte("ante-key")
+	deliverKey := []byte("deliver-key")
+	endKey := []byte("end-key")
+	type block struct {
+		height int64
+		ops    []store.StoreOp
+	}
+	runChain := func() (blocks []block, hashes [][]byte) {
+		opts := func(bapp *BaseApp) {
+			bapp.SetAnteHandler(anteHandlerTxTest(t, mainKey, anteKey))
+			bapp.Router().AddRoute(routeMsgCounter, newMsgCounterHandler(t, mainKey, deliverKey))
+			bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) (res abci.ResponseEndBlock) {
+				switch req.Height {
+				case 1:
+					ctx.Store(mainKey).Set(endKey, []byte("end"))
+				case 2:
+					ctx.Store(mainKey).Delete(endKey)
+					res.ConsensusParams = &abci.ConsensusParams{Block: &abci.BlockParams{MaxGas: 1 << 40}}
+				}
+				return
+			})
+			bapp.SetWriteSetRecorder(func(height int64, ops []store.StoreOp) {
+				blocks = append(blocks, block{height, ops})
+			})
+		}
+		app := setupBaseApp(t, opts)
+		app.InitChain(abci.RequestInitChain{
+			ChainID:         "test-chain",
+			ConsensusParams: &abci.ConsensusParams{Block: &abci.BlockParams{MaxGas: 1 << 50}},
+		})
+		counter := int64(0)
+		for height := int64(1); height <= 3; height++ {
+			app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+			for i := 0; i < 3; i++ {
+				res := app.Deliver(newTxCounter(counter, counter))
+				require.True(t, res.IsOK(), res.Log)
+				counter++
+			}
+			app.EndBlock(abci.RequestEndBlock{Height: height})
+			hashes = append(hashes, app.Commit().Data)
+		}
+		return
+	}
+
+	blocks, hashes := runChain()
+	require.Len(t, blocks, 3)
+	for i, b := range blocks {
+		require.Equal(t, int64(i+1), b.height)
+		require.NotEmpty(t, b.ops)
+	}
+	// the consensus params of InitChain are in the first write set, and
+	// the ones of EndBlock in the second one, with the deletion.
+	require.Equal(t, mainConsensusParamsKey, blocks[0].ops[0].Key)
+	require.Equal(t, mainConsensusParamsKey, blocks[1].ops[0].Key)
+	require.Contains(t, blocks[1].ops, store.StoreOp{Store: "main", Type: store.StoreOpDelete, Key: endKey})
+
+	// the recording is deterministic.
+	blocks2, _ := runChain()
+	for i := range blocks {
+		require.Equal(t, blocks[i].ops, blocks2[i].ops)
+		require.Empty(t, store.DiffWriteSets(blocks[i].ops, blocks2[i].ops))
+	}
+
+	// replaying the write sets yields the same app hashes.
+	replay := setupBaseApp(t)
+	for i, b := range blocks {
+		ms := replay.cms.MultiCacheWrap()
+		require.NoError(t, store.ApplyWriteSet(ms, []store.StoreKey{baseKey, mainKey}, b.ops))
+		ms.MultiWrite()
+		require.Equal(t, hashes[i], replay.cms.Commit().Hash, "block %d", b.height)
+	}
+
+	// a diverging chain has discrepancies.
+	blocks3, hashes3 := runChain()
+	require.Equal(t, hashes, hashes3)
+	diverging := append([]store.StoreOp(nil), blocks3[2].ops...)
+	diverging[len(diverging)-1].Value = []byte("diverged")
+	diffs := store.DiffWriteSets(blocks[2].ops, diverging)
+	require.Len(t, diffs, 1)
+	require.Equal(t, blocks[2].ops[len(diverging)-1].Key, diffs[0].Key)
+	require.Equal(t, []byte("diverged"), diffs[0].B.Value)
+}
@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+)
+
+// computeBlockSeed returns the seed of the block of header, following the
+// state of lastAppHash:
+//
+//	sha256(lastAppHash || time || height || proposer address)
+//
+// where the time is in nanoseconds since the epoch and the time and height
+// are 8 byte big-endian integers. The proposer address is empty if header
+// is not a *bft.Header.
+func computeBlockSeed(lastAppHash []byte, header abci.Header) [32]byte {
+	var buf [8]byte
+	h := sha256.New()
+	h.Write(lastAppHash)
+	binary.BigEndian.PutUint64(buf[:], uint64(header.GetTime().UnixNano()))
+	h.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(header.GetHeight()))
+	h.Write(buf[:])
+	if bh, ok := header.(*bft.Header); ok {
+		h.Write(bh.ProposerAddress[:])
+	}
+	var seed [32]byte
+	copy(seed[:], h.Sum(nil))
+	return seed
+}
+
+// NewDeterministicRand returns a pseudo-random generator seeded with seed,
+// e.g. of Context.BlockSeed, and salt, so that modules using different
+// salts, e.g. their names, derive independent streams from the same seed.
+// All the nodes derive the same stream from the same seed and salt.
+//
+// The stream is NOT unpredictable: it is known to anyone knowing the seed,
+// and the seed of a block is known to its proposer in advance and can be
+// influenced by it, e.g. by choosing the block time. It must not be used
+// where the outcome is worth manipulating, e.g. for lotteries or for the
+// selection of validators.
+func NewDeterministicRand(seed, salt []byte) *rand.Rand {
+	var buf [8]byte
+	h := sha256.New()
+	binary.BigEndian.PutUint64(buf[:], uint64(len(seed)))
+	h.Write(buf[:])
+	h.Write(seed)
+	h.Write(salt)
+	sum := h.Sum(nil)
+	return rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+}
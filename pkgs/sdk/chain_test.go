@@ -0,0 +1,107 @@
+package sdk_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/sdk/testutils"
+)
+
+// queryInt returns the counter of key in the main store, as committed.
+func queryInt(chain *testutils.TestChain, key []byte) int64 {
+	bz := chain.QueryStore(sdk.MainKey.Name(), key)
+	if len(bz) == 0 {
+		return 0
+	}
+	i, n := binary.Varint(bz)
+	if n <= 0 {
+		panic(fmt.Sprintf("invalid counter %X", bz))
+	}
+	return i
+}
+
+// Test that successive DeliverTx can see each others' effects
+// on the store, both within and across blocks.
+func TestDeliverTx(t *testing.T) {
+	// test increments in the ante
+	anteKey := []byte("ante-key")
+	anteOpt := func(bapp *sdk.BaseApp) { bapp.SetAnteHandler(sdk.AnteHandlerTxTest(t, sdk.MainKey, anteKey)) }
+
+	// test increments in the handler
+	deliverKey := []byte("deliver-key")
+	routerOpt := func(bapp *sdk.BaseApp) {
+		bapp.Router().AddRoute(sdk.RouteMsgCounter, sdk.NewMsgCounterHandler(t, sdk.MainKey, deliverKey))
+	}
+
+	app := sdk.SetupBaseApp(t, anteOpt, routerOpt)
+	chain := testutils.NewTestChain(app, "test-chain")
+	chain.InitChain(abci.RequestInitChain{})
+
+	nBlocks := 3
+	txPerHeight := 5
+
+	for blockN := 0; blockN < nBlocks; blockN++ {
+		txs := make([][]byte, txPerHeight)
+		for i := range txs {
+			counter := int64(blockN*txPerHeight + i)
+			txs[i] = amino.MustMarshal(sdk.NewTxCounter(counter, counter))
+		}
+		for _, res := range chain.RunBlock(txs...) {
+			require.True(t, res.IsOK(), fmt.Sprintf("%v", res))
+		}
+	}
+	require.Equal(t, int64(nBlocks*txPerHeight), queryInt(chain, anteKey))
+	require.Equal(t, int64(nBlocks*txPerHeight), queryInt(chain, deliverKey))
+}
+
+// One call to DeliverTx should process all the messages, in order.
+func TestMultiMsgDeliverTx(t *testing.T) {
+	// increment the tx counter
+	anteKey := []byte("ante-key")
+	anteOpt := func(bapp *sdk.BaseApp) { bapp.SetAnteHandler(sdk.AnteHandlerTxTest(t, sdk.MainKey, anteKey)) }
+
+	// increment the msg counter
+	deliverKey := []byte("deliver-key")
+	deliverKey2 := []byte("deliver-key2")
+	routerOpt := func(bapp *sdk.BaseApp) {
+		bapp.Router().AddRoute(sdk.RouteMsgCounter, sdk.NewMsgCounterHandler(t, sdk.MainKey, deliverKey))
+		bapp.Router().AddRoute(sdk.RouteMsgCounter2, sdk.NewMsgCounterHandler(t, sdk.MainKey, deliverKey2))
+	}
+
+	app := sdk.SetupBaseApp(t, anteOpt, routerOpt)
+	chain := testutils.NewTestChain(app, "test-chain")
+	chain.InitChain(abci.RequestInitChain{})
+
+	// run a multi-msg tx
+	// with all msgs the same route
+	res := chain.RunBlock(amino.MustMarshal(sdk.NewTxCounter(0, 0, 1, 2)))
+	require.True(t, res[0].IsOK(), fmt.Sprintf("%v", res[0]))
+
+	// tx counter only incremented once
+	require.Equal(t, int64(1), queryInt(chain, anteKey))
+
+	// msg counter incremented three times
+	require.Equal(t, int64(3), queryInt(chain, deliverKey))
+
+	// replace the second message with a msgCounter2
+
+	tx := sdk.NewTxCounter(1, 3)
+	tx.Msgs = append(tx.Msgs, sdk.MsgCounter2{0})
+	tx.Msgs = append(tx.Msgs, sdk.MsgCounter2{1})
+	res = chain.RunBlock(amino.MustMarshal(tx))
+	require.True(t, res[0].IsOK(), fmt.Sprintf("%v", res[0]))
+
+	// tx counter only incremented once
+	require.Equal(t, int64(2), queryInt(chain, anteKey))
+
+	// original counter increments by one
+	// new counter increments by two
+	require.Equal(t, int64(4), queryInt(chain, deliverKey))
+	require.Equal(t, int64(2), queryInt(chain, deliverKey2))
+}
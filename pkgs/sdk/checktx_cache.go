@@ -0,0 +1,135 @@
+package sdk
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// checkTxCache is a LRU cache of the responses of CheckTx, keyed by the hash
+// of the tx bytes, so that rebroadcast txs are not decoded and verified
+// again. Only the successes, and the failures which do not depend on the
+// state, are cached. It also records the txs whose msgs passed
+// ValidateBasic, which need not run again on the same bytes. It is safe for
+// concurrent use.
+type checkTxCache struct {
+	mtx     sync.Mutex
+	size    int
+	ttl     time.Duration // 0 for no expiry
+	now     func() time.Time
+	entries map[[sha256.Size]byte]*list.Element
+	queue   *list.List // of *checkTxCacheEntry, least recently used first
+}
+
+type checkTxCacheEntry struct {
+	key        [sha256.Size]byte
+	res        abci.ResponseCheckTx
+	hasRes     bool // false if only validBasic is cached
+	validBasic bool // whether the msgs passed ValidateBasic
+	added      time.Time
+}
+
+func newCheckTxCache(size int, ttl time.Duration) *checkTxCache {
+	return &checkTxCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		queue:   list.New(),
+	}
+}
+
+// get returns the response to a tx with the same bytes as a cached one: the
+// cached response for failures, or a TxInCacheError for successes.
+func (c *checkTxCache) get(key [sha256.Size]byte) (res abci.ResponseCheckTx, ok bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entry := c.lookup(key)
+	if entry == nil || !entry.hasRes {
+		return res, false
+	}
+	if !entry.res.IsOK() {
+		return entry.res, true
+	}
+	res.Error = ABCIError(std.ErrTxInCache("tx already in cache"))
+	return res, true
+}
+
+// validatedBasic returns whether the msgs of the tx passed ValidateBasic.
+func (c *checkTxCache) validatedBasic(key [sha256.Size]byte) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entry := c.lookup(key)
+	return entry != nil && entry.validBasic
+}
+
+// lookup returns the entry of key, as most recently used, or nil if there
+// is none or it expired. The mutex must be held.
+func (c *checkTxCache) lookup(key [sha256.Size]byte) *checkTxCacheEntry {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*checkTxCacheEntry)
+	if c.ttl > 0 && c.now().Sub(entry.added) >= c.ttl {
+		c.remove(elem)
+		return nil
+	}
+	c.queue.MoveToBack(elem)
+	return entry
+}
+
+// add caches res, if it is a success, or a failure which does not depend
+// on the state, and whether the msgs of the tx passed ValidateBasic.
+func (c *checkTxCache) add(key [sha256.Size]byte, res abci.ResponseCheckTx, stateless, validBasic bool) {
+	hasRes := res.IsOK() || stateless
+	if !hasRes && !validBasic {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &checkTxCacheEntry{key: key, validBasic: validBasic, added: c.now()}
+	if hasRes {
+		entry.res, entry.hasRes = res, true
+	}
+	c.entries[key] = c.queue.PushBack(entry)
+	if c.queue.Len() > c.size {
+		c.remove(c.queue.Front())
+	}
+}
+
+// resetSuccesses removes the responses of the successes, which may fail
+// against the state of a new block, e.g. because their sequence was used.
+// That their msgs passed ValidateBasic is kept, e.g. for DeliverTx.
+func (c *checkTxCache) resetSuccesses() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for elem := c.queue.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*checkTxCacheEntry)
+		if entry.hasRes && entry.res.IsOK() {
+			entry.res, entry.hasRes = abci.ResponseCheckTx{}, false
+		}
+	}
+}
+
+func (c *checkTxCache) len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.queue.Len()
+}
+
+func (c *checkTxCache) remove(elem *list.Element) {
+	c.queue.Remove(elem)
+	delete(c.entries, elem.Value.(*checkTxCacheEntry).key)
+}
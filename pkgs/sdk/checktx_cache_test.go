@@ -0,0 +1,167 @@
+package sdk
+
+import (
+	"crypto/sha256"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+func TestCheckTxCache(t *testing.T) {
+	// the ante handler accepts the tx counters in sequence.
+	seqKey := []byte("seq")
+	var anteCalls int
+	opts := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx std.Tx, simulate bool) (Context, Result, bool) {
+			anteCalls++
+			var res Result
+			seq := getIntFromStore(ctx.Store(mainKey), seqKey)
+			if getCounter(tx) != seq {
+				res.Error = ABCIError(std.ErrInvalidSequence("unexpected counter"))
+				return ctx, res, true
+			}
+			setIntOnStore(ctx.Store(mainKey), seqKey, seq+1)
+			return ctx, res, false
+		})
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result { return Result{} }))
+	}
+	app := setupBaseApp(t, SetCheckTxCache(10, time.Minute), opts)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	checkTx := func(tx std.Tx) abci.ResponseCheckTx {
+		return app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(tx)})
+	}
+
+	// a rebroadcast success is rejected from the cache.
+	res := checkTx(newTxCounter(0, 0))
+	require.True(t, res.IsOK(), res.Log)
+	res = checkTx(newTxCounter(0, 0))
+	require.True(t, ErrorIs(Result{ResponseBase: res.ResponseBase}, std.TxInCacheError{}), res.Log)
+	require.Equal(t, 1, anteCalls)
+
+	// rechecks are not cached.
+	res = app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(newTxCounter(0, 0)), Type: abci.CheckTxTypeRecheck})
+	require.True(t, ErrorIs(Result{ResponseBase: res.ResponseBase}, std.InvalidSequenceError{}), res.Log)
+	require.Equal(t, 2, anteCalls)
+
+	// failures depending on the state are not cached.
+	for i := 0; i < 2; i++ {
+		res = checkTx(newTxCounter(5, 0))
+		require.True(t, ErrorIs(Result{ResponseBase: res.ResponseBase}, std.InvalidSequenceError{}), res.Log)
+	}
+	require.Equal(t, 4, anteCalls)
+
+	// stateless failures are cached with their response.
+	invalid := newTxCounter(1, -1)
+	first := checkTx(invalid)
+	require.False(t, first.IsOK())
+	require.Equal(t, first, checkTx(invalid))
+	undecodable := app.CheckTx(abci.RequestCheckTx{Tx: []byte("invalid")})
+	require.Equal(t, undecodable, app.CheckTx(abci.RequestCheckTx{Tx: []byte("invalid")}))
+	// the failure depending on the state is only recorded as valid.
+	require.Equal(t, 4, app.checkTxCache.len())
+
+	// DeliverTx is never short-circuited.
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	dres := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, 0))})
+	require.True(t, dres.IsOK(), dres.Log)
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+
+	// the successes are removed on Commit, and checked against the new
+	// state.
+	anteCalls = 0
+	app.Commit()
+	require.Equal(t, 4, app.checkTxCache.len())
+	_, ok := app.checkTxCache.get(sha256.Sum256(amino.MustMarshal(newTxCounter(0, 0))))
+	require.False(t, ok)
+	res = checkTx(newTxCounter(0, 0))
+	require.True(t, ErrorIs(Result{ResponseBase: res.ResponseBase}, std.InvalidSequenceError{}), res.Log)
+	require.Equal(t, 1, anteCalls)
+	require.Equal(t, first, checkTx(invalid))
+
+	// entries expire after the ttl.
+	now := time.Now()
+	app.checkTxCache.now = func() time.Time { return now }
+	res = checkTx(newTxCounter(1, 0))
+	require.True(t, res.IsOK(), res.Log)
+	_, ok = app.checkTxCache.get(sha256.Sum256(amino.MustMarshal(newTxCounter(1, 0))))
+	require.True(t, ok)
+	now = now.Add(time.Minute)
+	_, ok = app.checkTxCache.get(sha256.Sum256(amino.MustMarshal(newTxCounter(1, 0))))
+	require.False(t, ok)
+}
+
+func TestCheckTxCacheSize(t *testing.T) {
+	c := newCheckTxCache(2, 0)
+	failure := abci.ResponseCheckTx{}
+	failure.Error = ABCIError(std.ErrTxDecode("invalid"))
+	keys := [][sha256.Size]byte{{1}, {2}, {3}}
+	for _, key := range keys {
+		c.add(key, failure, true, false)
+	}
+	require.Equal(t, 2, c.len())
+	_, ok := c.get(keys[0])
+	require.False(t, ok, "the least recently used entry is evicted")
+	_, ok = c.get(keys[2])
+	require.True(t, ok)
+
+	// failures depending on the state are not cached.
+	c.add([sha256.Size]byte{4}, failure, false, false)
+	_, ok = c.get([sha256.Size]byte{4})
+	require.False(t, ok)
+}
+
+func TestValidateBasicCache(t *testing.T) {
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result { return Result{} }))
+	}
+	newApp := func(opts ...func(*BaseApp)) *BaseApp {
+		app := setupBaseApp(t, append(opts, routerOpt)...)
+		app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+		return app
+	}
+	app := newApp(SetCheckTxCache(10, time.Minute))
+	uncached := newApp()
+	valid := amino.MustMarshal(newTxCounter(0, 0, 1))
+	invalid := amino.MustMarshal(newTxCounter(1, 1, -1))
+	unchecked := amino.MustMarshal(newTxCounter(2, 2))
+
+	// the msgs of a checked tx are not validated again on recheck and
+	// delivery, even after Commit.
+	atomic.StoreInt64(&msgCounterValidations, 0)
+	require.True(t, app.CheckTx(abci.RequestCheckTx{Tx: valid}).IsOK())
+	require.Equal(t, int64(2), atomic.LoadInt64(&msgCounterValidations))
+	res := app.CheckTx(abci.RequestCheckTx{Tx: valid, Type: abci.CheckTxTypeRecheck})
+	require.True(t, res.IsOK(), res.Log)
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 2}})
+	dres := app.DeliverTx(abci.RequestDeliverTx{Tx: valid})
+	require.Equal(t, int64(2), atomic.LoadInt64(&msgCounterValidations))
+	require.Equal(t, uncached.DeliverTx(abci.RequestDeliverTx{Tx: valid}), dres)
+
+	// txs which failed ValidateBasic, or were not checked, are validated.
+	require.False(t, app.CheckTx(abci.RequestCheckTx{Tx: invalid}).IsOK())
+	atomic.StoreInt64(&msgCounterValidations, 0)
+	dres = app.DeliverTx(abci.RequestDeliverTx{Tx: invalid})
+	require.True(t, ErrorIs(Result{ResponseBase: dres.ResponseBase}, std.InvalidSequenceError{}), dres.Log)
+	require.Equal(t, int64(2), atomic.LoadInt64(&msgCounterValidations))
+	dres = app.DeliverTx(abci.RequestDeliverTx{Tx: unchecked})
+	require.True(t, dres.IsOK(), dres.Log)
+	require.Equal(t, int64(3), atomic.LoadInt64(&msgCounterValidations))
+
+	// unless SetAlwaysValidateBasic.
+	app = newApp(SetCheckTxCache(10, time.Minute), SetAlwaysValidateBasic(true))
+	require.True(t, app.CheckTx(abci.RequestCheckTx{Tx: valid}).IsOK())
+	atomic.StoreInt64(&msgCounterValidations, 0)
+	dres = app.DeliverTx(abci.RequestDeliverTx{Tx: valid})
+	require.True(t, dres.IsOK(), dres.Log)
+	require.Equal(t, int64(2), atomic.LoadInt64(&msgCounterValidations))
+}
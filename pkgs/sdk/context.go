@@ -2,10 +2,14 @@ package sdk
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/gnolang/gno/pkgs/amino"
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/log"
 	"github.com/gnolang/gno/pkgs/store"
 	"github.com/gnolang/gno/pkgs/store/gas"
@@ -30,9 +34,19 @@ type Context struct {
 	voteInfo      []abci.VoteInfo
 	gasMeter      store.GasMeter // XXX make passthroughGasMeter w/ blockGasMeter?
 	blockGasMeter store.GasMeter
-	minGasPrices  []GasPrice
+	minGasPrices  DecCoins
 	consParams    *abci.ConsensusParams
 	eventLogger   *EventLogger
+	sender        crypto.Address
+	simSender     crypto.Address // signer of unsigned simulated txs
+	priority      int64          // priority of the checked tx
+	gasBreakdown  bool // whether gas meters are categorizing
+	txIndex       int  // index of the tx in the block, or -1
+	blockSeed     [32]byte
+	readOnly      bool        // whether Store returns read-only stores
+	steps         *stepBudget // iterator steps of the stores, if limited
+	features      Features
+	txEnd         *[]func(written bool)
 }
 
 // Proposed rename, not done to avoid API breakage
@@ -46,13 +60,60 @@ func (c Context) BlockHeight() int64            { return c.header.GetHeight() }
 func (c Context) BlockTime() time.Time          { return c.header.GetTime() }
 func (c Context) ChainID() string               { return c.chainID }
 func (c Context) TxBytes() []byte               { return c.txBytes }
+func (c Context) TxHash() []byte                { return txHash(c.txBytes) }
 func (c Context) Logger() log.Logger            { return c.logger }
 func (c Context) VoteInfos() []abci.VoteInfo    { return c.voteInfo }
 func (c Context) GasMeter() store.GasMeter      { return c.gasMeter }
 func (c Context) BlockGasMeter() store.GasMeter { return c.blockGasMeter }
 func (c Context) IsCheckTx() bool               { return c.mode == RunTxModeCheck }
-func (c Context) MinGasPrices() []GasPrice      { return c.minGasPrices }
+func (c Context) MinGasPrices() DecCoins        { return c.minGasPrices }
 func (c Context) EventLogger() *EventLogger     { return c.eventLogger }
+func (c Context) Sender() crypto.Address        { return c.sender }
+
+// TxIndex returns the index of the tx in the block, from 0, or -1 if the
+// context is not of a delivered tx, e.g. in CheckTx.
+func (c Context) TxIndex() int { return c.txIndex }
+
+// BlockTxCount returns the number of txs delivered in the block so far,
+// including the current one, or -1 if the context is not of a delivered tx.
+func (c Context) BlockTxCount() int {
+	if c.txIndex < 0 {
+		return -1
+	}
+	return c.txIndex + 1
+}
+
+// BlockSeed returns the seed of the block, derived in BeginBlock from the
+// last app hash and the header, and the same for the whole block and on all
+// the nodes. It is zero outside of blocks, e.g. in CheckTx. It is meant for
+// pseudo-randomness all the nodes agree on, e.g. with NewDeterministicRand.
+//
+// The seed is predictable: the proposer of the block knows it in advance
+// and can influence it, e.g. by choosing the block time, so it must not be
+// used where the outcome is worth manipulating.
+func (c Context) BlockSeed() [32]byte { return c.blockSeed }
+
+// IsFeatureActive returns whether the feature gate name, registered with
+// BaseApp.RegisterFeature, is active in the block of the context. Outside
+// of DeliverTx and blocks, e.g. in CheckTx, it is whether it is active in
+// the next block, which the txs are delivered in at the earliest, so that
+// checks agree with deliveries. Unknown features are not active, and logged
+// at the debug level.
+func (c Context) IsFeatureActive(name string) bool {
+	activationHeight, ok := c.features[name]
+	if !ok {
+		c.logger.Debug("Unknown feature", "feature", name)
+		return false
+	}
+	height := c.BlockHeight()
+	if c.mode != RunTxModeDeliver {
+		height++
+	}
+	return height >= activationHeight
+}
+
+// IsReadOnly returns whether the stores of Store are read-only.
+func (c Context) IsReadOnly() bool { return c.readOnly }
 
 // clone the header before returning
 func (c Context) BlockHeader() abci.Header {
@@ -60,7 +121,11 @@ func (c Context) BlockHeader() abci.Header {
 	return *msg
 }
 
+// clone the consensus params before returning, if any
 func (c Context) ConsensusParams() *abci.ConsensusParams {
+	if c.consParams == nil {
+		return nil
+	}
 	return amino.DeepCopy(c.consParams).(*abci.ConsensusParams)
 }
 
@@ -79,6 +144,7 @@ func NewContext(mode RunTxMode, ms store.MultiStore, header abci.Header, logger
 		gasMeter:     store.NewInfiniteGasMeter(),
 		minGasPrices: nil,
 		eventLogger:  NewEventLogger(),
+		txIndex:      -1,
 	}
 }
 
@@ -112,6 +178,18 @@ func (c Context) WithTxBytes(txBytes []byte) Context {
 	return c
 }
 
+// WithTxIndex sets the index of the tx in the block, or -1 if the context
+// is not of a delivered tx.
+func (c Context) WithTxIndex(txIndex int) Context {
+	c.txIndex = txIndex
+	return c
+}
+
+func (c Context) WithBlockSeed(seed [32]byte) Context {
+	c.blockSeed = seed
+	return c
+}
+
 func (c Context) WithLogger(logger log.Logger) Context {
 	c.logger = logger
 	return c
@@ -122,17 +200,47 @@ func (c Context) WithVoteInfos(voteInfo []abci.VoteInfo) Context {
 	return c
 }
 
+// WithGasMeter sets the gas meter, wrapped in a categorizing gas meter if
+// the gas breakdown is enabled.
 func (c Context) WithGasMeter(meter store.GasMeter) Context {
+	if c.gasBreakdown && meter != nil {
+		meter = store.NewCategorizingGasMeter(meter)
+	}
 	c.gasMeter = meter
 	return c
 }
 
+// WithGasBreakdown sets whether the gas consumed is also summed by
+// category, for GasBreakdown, including with the gas meters set later.
+func (c Context) WithGasBreakdown(enabled bool) Context {
+	c.gasBreakdown = enabled
+	return c.WithGasMeter(c.gasMeter)
+}
+
+// GasBreakdown returns the gas consumed by category, or nil if the gas
+// breakdown is not enabled.
+func (c Context) GasBreakdown() map[string]store.Gas {
+	if cmeter, ok := c.gasMeter.(store.CategorizingGasMeter); ok {
+		return cmeter.GasBreakdown()
+	}
+	return nil
+}
+
+// BlockGasRemaining returns the gas remaining in the block, or
+// math.MaxInt64 if the block gas is not limited.
+func (c Context) BlockGasRemaining() int64 {
+	if c.blockGasMeter == nil {
+		return math.MaxInt64
+	}
+	return c.blockGasMeter.Remaining()
+}
+
 func (c Context) WithBlockGasMeter(meter store.GasMeter) Context {
 	c.blockGasMeter = meter
 	return c
 }
 
-func (c Context) WithMinGasPrices(gasPrices []GasPrice) Context {
+func (c Context) WithMinGasPrices(gasPrices DecCoins) Context {
 	c.minGasPrices = gasPrices
 	return c
 }
@@ -142,11 +250,55 @@ func (c Context) WithConsensusParams(params *abci.ConsensusParams) Context {
 	return c
 }
 
+func (c Context) WithFeatures(features Features) Context {
+	c.features = features
+	return c
+}
+
 func (c Context) WithEventLogger(em *EventLogger) Context {
 	c.eventLogger = em
 	return c
 }
 
+// WithSender sets the address of the first signer of the tx, as resolved by
+// the AnteHandler. It is returned in ResponseCheckTx.Sender.
+func (c Context) WithSender(sender crypto.Address) Context {
+	c.sender = sender
+	return c
+}
+
+// WithPriority sets the priority of the checked tx, e.g. to boost it over
+// the txs of higher fees, or PriorityEvictLast. Before the AnteHandler, it
+// is the DefaultTxPriority of the fee of the tx, and after, it is returned
+// in ResponseCheckTx.Priority. Other negative priorities are returned as 0.
+func (c Context) WithPriority(priority int64) Context {
+	c.priority = priority
+	return c
+}
+
+// Priority returns the priority of the checked tx, see WithPriority.
+func (c Context) Priority() int64 {
+	return c.priority
+}
+
+// WithSimulatedSender sets the address which signs an unsigned simulated tx
+// in place of the signers of its msgs, unless it is zero. See
+// SimulatedSender.
+func (c Context) WithSimulatedSender(sender crypto.Address) Context {
+	c.simSender = sender
+	return c
+}
+
+// SimulatedSender returns the address set by WithSimulatedSender, or the
+// zero address if the context is not of a simulation, so that it can never
+// sign a tx whose state changes are written.
+func (c Context) SimulatedSender() crypto.Address {
+	if c.mode != RunTxModeSimulate {
+		return crypto.Address{}
+	}
+	return c.simSender
+}
+
 // WithValue is deprecated, provided for backwards compatibility
 // Please use
 //     ctx = ctx.WithContext(context.WithValue(ctx.Context(), key, false))
@@ -173,8 +325,91 @@ func (c Context) Value(key interface{}) interface{} {
 // ----------------------------------------------------------------------------
 
 // Store fetches a Store from the MultiStore, but wrapped for gas calculation.
+// If the context is read-only, the store panics on writes.
 func (c Context) Store(key store.StoreKey) store.Store {
-	return gas.New(c.MultiStore().GetStore(key), c.GasMeter(), store.DefaultGasConfig())
+	var st store.Store = gas.New(c.MultiStore().GetStore(key), c.GasMeter(), store.DefaultGasConfig())
+	if c.steps != nil {
+		st = budgetStore{st, c.steps}
+	}
+	if c.readOnly {
+		return readOnlyStore{st}
+	}
+	return st
+}
+
+// KVStore returns the store of key, as Store, and panics if key is not of a
+// persistent store.
+func (c Context) KVStore(key store.StoreKey) store.Store {
+	checkStoreKind(key, store.StoreKindKV)
+	return c.Store(key)
+}
+
+// TransientStore returns the store of key, as Store, and panics if key is
+// not of a transient store.
+func (c Context) TransientStore(key store.StoreKey) store.Store {
+	checkStoreKind(key, store.StoreKindTransient)
+	return c.Store(key)
+}
+
+// MemoryStore returns the store of key, as Store, and panics if key is not
+// of a memory store.
+func (c Context) MemoryStore(key store.StoreKey) store.Store {
+	checkStoreKind(key, store.StoreKindMemory)
+	return c.Store(key)
+}
+
+// storeGetters are the names of the getters of the stores of each kind.
+var storeGetters = map[store.StoreKind]string{
+	store.StoreKindKV:        "KVStore",
+	store.StoreKindTransient: "TransientStore",
+	store.StoreKindMemory:    "MemoryStore",
+}
+
+// checkStoreKind panics if key is not of a store of kind.
+func checkStoreKind(key store.StoreKey, kind store.StoreKind) {
+	if actual := store.KindOf(key); actual != kind {
+		panic(fmt.Sprintf("Context.%s of store %s, which is a %s store; use Context.%s",
+			storeGetters[kind], key.Name(), actual, storeGetters[actual]))
+	}
+}
+
+// OnTxEnd registers fn to be called when the msgs of the delivered tx of the
+// context are done, with whether their writes were written to the block
+// state, or discarded, e.g. as a msg failed or panicked. It is for modules
+// which keep state in memory across txs, e.g. caches, to drop the state of
+// discarded txs. fn is not called if the context is not of the msgs of a
+// delivered tx.
+func (c Context) OnTxEnd(fn func(written bool)) {
+	if c.txEnd != nil {
+		*c.txEnd = append(*c.txEnd, fn)
+	}
+}
+
+func (c Context) withTxEnd(txEnd *[]func(written bool)) Context {
+	c.txEnd = txEnd
+	return c
+}
+
+// endTx calls the OnTxEnd funcs of txEnd with written.
+func endTx(txEnd []func(written bool), written bool) {
+	for _, fn := range txEnd {
+		fn(written)
+	}
+}
+
+// ReadOnly returns a copy of the context whose Store returns stores that
+// panic on Set and Delete, e.g. for query handlers, which must not write.
+// The MultiStore of the context is unchanged.
+func (c Context) ReadOnly() Context {
+	c.readOnly = true
+	return c
+}
+
+// withStepBudget returns a copy of the context whose Store returns stores
+// whose iterators consume steps of budget, e.g. for untrusted queries.
+func (c Context) withStepBudget(budget *stepBudget) Context {
+	c.steps = budget
+	return c
 }
 
 // CacheContext returns a new Context with the multi-store cached and a new
@@ -190,3 +425,12 @@ func (c Context) CacheContext() (cc Context, writeCache func()) {
 func (c Context) IsZero() bool {
 	return c.ms == nil
 }
+
+// txHash returns the hash of the tx of txBytes, as of the node, or nil if
+// there are none, e.g. for txs delivered in InitChain.
+func txHash(txBytes []byte) []byte {
+	if txBytes == nil {
+		return nil
+	}
+	return bft.Tx(txBytes).Hash()
+}
@@ -0,0 +1,108 @@
+package sdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// CrashReport is the report of a panic of InitChain, BeginBlock or
+// EndBlock, which is an unrecoverable consensus failure, for operators.
+type CrashReport struct {
+	Method       string         // the ABCI method which panicked
+	ChainID      string         // the chain ID of the block
+	Height       int64          // the height of the block
+	Time         time.Time      // the time of the header of the block
+	LastCommitID store.CommitID // the last committed state
+	Panic        string         // the panic value
+	Stack        string         // the stack of the panic
+}
+
+// String returns the report, with the stack after the fields.
+func (cr CrashReport) String() string {
+	return fmt.Sprintf(`%s panicked: %s
+  ChainID:      %s
+  Height:       %d
+  Time:         %s
+  LastCommitID: %d/%X
+
+%s`,
+		cr.Method, cr.Panic, cr.ChainID, cr.Height, cr.Time.UTC().Format(time.RFC3339Nano),
+		cr.LastCommitID.Version, cr.LastCommitID.Hash, cr.Stack)
+}
+
+// CrashReporter is called with the report of a panic of InitChain,
+// BeginBlock or EndBlock, before the panic propagates, see
+// SetCrashReporter.
+type CrashReporter func(CrashReport)
+
+// NewCrashFileReporter returns a CrashReporter which writes the reports to
+// new files of dir, e.g. the DB directory, named after the height and the
+// time of the crash. Failures to write are printed to stderr, as the
+// process is crashing.
+func NewCrashFileReporter(dir string) CrashReporter {
+	return func(cr CrashReport) {
+		name := fmt.Sprintf("crash-%d-%s.log", cr.Height, time.Now().UTC().Format("20060102T150405.000000000Z"))
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(dir, 0o700)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(cr.String()), 0o600)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot write crash report %s: %v\n%s\n", path, err, cr)
+		}
+	}
+}
+
+// crash reports the panic r of method, of the block of height, which is a
+// consensus failure, and panics again with r.
+func (app *BaseApp) crash(r interface{}, method string, chainID string, height int64, blockTime time.Time) {
+	cr := CrashReport{
+		Method:       method,
+		ChainID:      chainID,
+		Height:       height,
+		Time:         blockTime,
+		LastCommitID: app.LastCommitID(),
+		Panic:        fmt.Sprintf("%v", r),
+		Stack:        string(debug.Stack()),
+	}
+	app.logger.Error("Consensus failure",
+		"method", cr.Method,
+		"chainID", cr.ChainID,
+		"height", cr.Height,
+		"time", cr.Time,
+		"lastCommitID", fmt.Sprintf("%d/%X", cr.LastCommitID.Version, cr.LastCommitID.Hash),
+		"panic", cr.Panic,
+		"stack", cr.Stack)
+	if app.crashReporter != nil {
+		app.crashReporter(cr)
+	}
+	panic(r)
+}
+
+// recoverInitChainCrash is deferred by InitChain to report its panics.
+func (app *BaseApp) recoverInitChainCrash(req abci.RequestInitChain) {
+	if r := recover(); r != nil {
+		app.crash(r, "InitChain", req.ChainID, 0, req.Time)
+	}
+}
+
+// recoverBlockCrash is deferred by BeginBlock and EndBlock, method, to
+// report their panics, of the block of header, which may be nil.
+func (app *BaseApp) recoverBlockCrash(method string, header abci.Header) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if header == nil {
+		app.crash(r, method, "", 0, time.Time{})
+		return
+	}
+	app.crash(r, method, header.GetChainID(), header.GetHeight(), header.GetTime())
+}
@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+)
+
+func panickingBeginBlocker(ctx Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+	if ctx.BlockHeight() == 2 {
+		panic("boom")
+	}
+	return abci.ResponseBeginBlock{}
+}
+
+func TestCrashReport(t *testing.T) {
+	dir := t.TempDir()
+	var reports []CrashReport
+	app := setupBaseApp(t,
+		func(app *BaseApp) { app.SetBeginBlocker(panickingBeginBlocker) },
+		SetCrashReporter(func(cr CrashReport) {
+			reports = append(reports, cr)
+			NewCrashFileReporter(dir)(cr)
+		}),
+	)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain", Time: start})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1, Time: start.Add(time.Second)}})
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+	require.Empty(t, reports)
+	lastCommitID := app.LastCommitID()
+
+	// the panic still propagates, after the report.
+	header := &bft.Header{ChainID: "test-chain", Height: 2, Time: start.Add(2 * time.Second)}
+	require.PanicsWithValue(t, "boom", func() {
+		app.BeginBlock(abci.RequestBeginBlock{Header: header})
+	})
+	require.Len(t, reports, 1)
+	cr := reports[0]
+	require.Equal(t, "BeginBlock", cr.Method)
+	require.Equal(t, "test-chain", cr.ChainID)
+	require.Equal(t, int64(2), cr.Height)
+	require.True(t, header.Time.Equal(cr.Time))
+	require.Equal(t, lastCommitID, cr.LastCommitID)
+	require.Equal(t, "boom", cr.Panic)
+	require.Contains(t, cr.Stack, "panickingBeginBlocker")
+
+	// the file of the report is in dir.
+	files, err := filepath.Glob(filepath.Join(dir, "crash-2-*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	bz, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Equal(t, cr.String(), string(bz))
+	require.True(t, strings.HasPrefix(string(bz), "BeginBlock panicked: boom"))
+}
+
+func TestCrashReportInitChain(t *testing.T) {
+	var reports []CrashReport
+	app := setupBaseApp(t,
+		func(app *BaseApp) {
+			app.SetInitChainer(func(ctx Context, req abci.RequestInitChain) abci.ResponseInitChain {
+				panic("bad genesis")
+			})
+		},
+		SetCrashReporter(func(cr CrashReport) { reports = append(reports, cr) }),
+	)
+
+	require.PanicsWithValue(t, "bad genesis", func() {
+		app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	})
+	require.Len(t, reports, 1)
+	require.Equal(t, "InitChain", reports[0].Method)
+	require.Equal(t, "test-chain", reports[0].ChainID)
+	require.Equal(t, "bad genesis", reports[0].Panic)
+}
@@ -0,0 +1,75 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+// OpenStoreDBs opens a DB of backend in rootDir for each of names, e.g. one
+// per mounted store, to mount with MountStoreWithDB, so that the stores do
+// not share the compactions of a single DB. On error, the DBs opened are
+// closed.
+func OpenStoreDBs(rootDir string, names []string, backend dbm.BackendType) (dbs map[string]dbm.DB, err error) {
+	dbs = make(map[string]dbm.DB, len(names))
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot open store DB: %v", r)
+		}
+		if err != nil {
+			for _, db := range dbs {
+				db.Close()
+			}
+			dbs = nil
+		}
+	}()
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("invalid store DB name %q", name)
+		}
+		if _, ok := dbs[name]; ok {
+			return nil, fmt.Errorf("duplicate store DB name %q", name)
+		}
+		// NewDB panics on error.
+		dbs[name] = dbm.NewDB(name, backend, rootDir)
+	}
+	return dbs, nil
+}
+
+// closeDBs closes the DB of the app, the DBs of the stores and the DB of
+// the event journal, once each.
+func (app *BaseApp) closeDBs() error {
+	if app.dbsClosed {
+		return nil
+	}
+	app.dbsClosed = true
+	var errs []error
+	seen := make(map[dbm.DB]bool)
+	dbs := append([]dbm.DB{app.db}, app.storeDBs...)
+	if app.eventJournal != nil {
+		dbs = append(dbs, app.eventJournal.db)
+	}
+	for _, db := range dbs {
+		if db == nil || seen[db] {
+			continue
+		}
+		seen[db] = true
+		if err := closeDB(db); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeDB closes db, or returns its panic as an error.
+func closeDB(db dbm.DB) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot close DB: %v", r)
+		}
+	}()
+	db.Close()
+	return nil
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/iavl"
+	store "github.com/gnolang/gno/pkgs/store/types"
+)
+
+var otherKey = store.NewKVStoreKey("other")
+
+// newTwoDBApp returns an app whose store "other" is in a DB of its own.
+func newTwoDBApp(t *testing.T, dir string) *BaseApp {
+	t.Helper()
+	dbs, err := OpenStoreDBs(dir, []string{"app", "other"}, dbm.GoLevelDBBackend)
+	require.NoError(t, err)
+	app := NewBaseApp(t.Name(), defaultLogger(), dbs["app"], baseKey, mainKey,
+		SetPruningOptions(store.PruneNothing))
+	app.MountStoreWithDB(baseKey, dbadapter.StoreConstructor, nil)
+	app.MountStoreWithDB(mainKey, iavl.StoreConstructor, nil)
+	app.MountStoreWithDB(otherKey, iavl.StoreConstructor, dbs["other"])
+	return app
+}
+
+// commitBlock commits a block of height, which writes the height in the
+// main and the other stores.
+func commitBlock(app *BaseApp, height int64) []byte {
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+	value := amino.MustMarshal(height)
+	app.deliverState.ctx.Store(mainKey).Set([]byte("height"), value)
+	app.deliverState.ctx.Store(otherKey).Set([]byte("height"), value)
+	app.EndBlock(abci.RequestEndBlock{Height: height})
+	return app.Commit().Data
+}
+
+func otherHeight(t *testing.T, app *BaseApp) int64 {
+	t.Helper()
+	var height int64
+	bz := app.cms.GetStore(otherKey).Get([]byte("height"))
+	require.NoError(t, amino.Unmarshal(bz, &height))
+	return height
+}
+
+func TestStoreDBs(t *testing.T) {
+	dir := t.TempDir()
+	app := newTwoDBApp(t, dir)
+	require.NoError(t, app.LoadLatestVersion())
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	var hashes [][]byte
+	for height := int64(1); height <= 3; height++ {
+		hashes = append(hashes, commitBlock(app, height))
+	}
+	require.NoError(t, app.Close())
+	// the DBs are closed once.
+	require.NoError(t, app.Close())
+
+	// restart.
+	app = newTwoDBApp(t, dir)
+	require.NoError(t, app.LoadLatestVersion())
+	require.Equal(t, int64(3), app.LastBlockHeight())
+	require.Equal(t, hashes[2], app.LastCommitID().Hash)
+	require.Equal(t, int64(3), otherHeight(t, app))
+
+	// rollback: the other DB, ahead, is loaded at the version, and the
+	// block is committed again with the same app hash.
+	require.NoError(t, app.LoadVersion(2))
+	require.Equal(t, hashes[1], app.LastCommitID().Hash)
+	require.Equal(t, int64(2), otherHeight(t, app))
+	require.Equal(t, hashes[2], commitBlock(app, 3))
+	commitBlock(app, 4)
+	require.NoError(t, app.Close())
+
+	// the other DB cannot be behind the app DB.
+	dbs, err := OpenStoreDBs(dir, []string{"other"}, dbm.GoLevelDBBackend)
+	require.NoError(t, err)
+	dbs["other"].SetSync([]byte("s/latest"), amino.MustMarshalSized(int64(2)))
+	dbs["other"].Close()
+	app = newTwoDBApp(t, dir)
+	err = app.LoadLatestVersion()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "its DB is at version 2, before version 4")
+	require.NoError(t, app.Close())
+}
+
+func TestOpenStoreDBs(t *testing.T) {
+	dir := t.TempDir()
+	_, err := OpenStoreDBs(dir, []string{"a", "a"}, dbm.MemDBBackend)
+	require.Error(t, err)
+	_, err = OpenStoreDBs(dir, []string{"a/b"}, dbm.MemDBBackend)
+	require.Error(t, err)
+	_, err = OpenStoreDBs(dir, []string{"a"}, "unknown")
+	require.Error(t, err)
+	dbs, err := OpenStoreDBs(dir, []string{"a", "b"}, dbm.MemDBBackend)
+	require.NoError(t, err)
+	require.Len(t, dbs, 2)
+}
@@ -0,0 +1,190 @@
+package sdk
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// runTxCheckingDeterminism runs a tx of DeliverTx twice, on two branches of
+// the deliver state, and writes the second one if the two executions write
+// to the stores in the same order and emit the same events. Otherwise, it
+// fails the tx, without state changes, with an InternalError reporting the
+// first divergence. Either way, the result is that of the second execution,
+// and only its msgs are added to the route stats. The OnTxEnd funcs of the
+// first execution are called with false before the second one runs, and
+// those of the second one with whether its branch is written.
+func (app *BaseApp) runTxCheckingDeterminism(txBytes []byte, tx Tx) (result Result) {
+	ctx := app.getContextForTx(RunTxModeDeliver, txBytes)
+
+	first := &writeTracer{route: "ante"}
+	var firstEnd []func(written bool)
+	branch := ctx.MultiStore().MultiCacheWrap()
+	firstCtx := ctx.
+		WithMultiStore(traceMultiStore{branch, first}).
+		WithBlockGasMeter(copyGasMeter(ctx.BlockGasMeter())).
+		WithValue(writeTracerKey{}, first).
+		withTxEnd(&firstEnd)
+	firstResult := app.runTxWithContext(firstCtx, RunTxModeDeliver, txBytes, tx)
+	endTx(firstEnd, false)
+
+	second := &writeTracer{route: "ante"}
+	var secondEnd []func(written bool)
+	written := false
+	defer func() { endTx(secondEnd, written) }()
+	branch = ctx.MultiStore().MultiCacheWrap()
+	secondCtx := ctx.
+		WithMultiStore(traceMultiStore{branch, second}).
+		WithValue(writeTracerKey{}, second).
+		withTxEnd(&secondEnd)
+	result = app.runTxWithContext(secondCtx, RunTxModeDeliver, txBytes, tx)
+	for _, stat := range second.stats {
+		app.routeStats.add(stat)
+	}
+
+	diag := first.diff(second)
+	if diag == "" && !reflect.DeepEqual(firstResult.Events, result.Events) {
+		diag = fmt.Sprintf("nondeterministic events: %v, then %v", firstResult.Events, result.Events)
+	}
+	if diag != "" {
+		app.logger.Error("nondeterministic tx", "diagnostic", diag)
+		result.Error = ABCIError(std.ErrInternal(diag))
+		result.Log = diag
+		result.Events = nil
+		return result
+	}
+	branch.MultiWrite()
+	written = true
+	return result
+}
+
+// copyGasMeter returns a gas meter with the same limit and consumption as
+// meter, but which does not share its consumption.
+func copyGasMeter(meter store.GasMeter) store.GasMeter {
+	var copied store.GasMeter
+	if meter.Limit() == 0 {
+		copied = store.NewInfiniteGasMeter()
+	} else {
+		copied = store.NewGasMeter(meter.Limit())
+	}
+	copied.ConsumeGas(meter.GasConsumedToLimit(), "copy")
+	return copied
+}
+
+// writeTracerKey is the context key of the writeTracer of a tx, whose route
+// is set by runMsgs.
+type writeTracerKey struct{}
+
+// writeTracer records the writes of a tx to the stores, in the order of
+// the calls, with the route of the msg being run, or "ante". It also holds
+// the route stats of the msgs run, until the execution is kept.
+type writeTracer struct {
+	route  string
+	writes []tracedWrite
+	stats  []routeStat
+}
+
+type tracedWrite struct {
+	route string
+	op    store.StoreOp
+}
+
+// diff returns a diagnostic of the first divergence between the writes of
+// two executions of a tx, or an empty string if there is none.
+func (wt *writeTracer) diff(other *writeTracer) string {
+	a, b := wt.writes, other.writes
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].route == b[i].route && reflect.DeepEqual(a[i].op, b[i].op) {
+			continue
+		}
+		return fmt.Sprintf("handler %q wrote keys in nondeterministic order: write %d is %v, was %v",
+			b[i].route, i, b[i].op, a[i].op)
+	}
+	switch {
+	case len(a) < len(b):
+		return fmt.Sprintf("handler %q wrote keys in nondeterministic order: extra write %d is %v",
+			b[len(a)].route, len(a), b[len(a)].op)
+	case len(a) > len(b):
+		return fmt.Sprintf("handler %q wrote keys in nondeterministic order: missing write %d, was %v",
+			a[len(b)].route, len(b), a[len(b)].op)
+	}
+	return ""
+}
+
+func (wt *writeTracer) record(op store.StoreOp) {
+	wt.writes = append(wt.writes, tracedWrite{wt.route, op})
+}
+
+// traceMultiStore is a MultiStore whose stores, and their cache-wraps,
+// record their writes to a writeTracer.
+type traceMultiStore struct {
+	parent store.MultiStore
+	tracer *writeTracer
+}
+
+var _ store.MultiStore = traceMultiStore{}
+
+func (tms traceMultiStore) GetStore(key store.StoreKey) store.Store {
+	return &traceStore{tms.parent.GetStore(key), key.Name(), tms.tracer}
+}
+
+func (tms traceMultiStore) MultiCacheWrap() store.MultiStore {
+	return traceMultiStore{tms.parent.MultiCacheWrap(), tms.tracer}
+}
+
+func (tms traceMultiStore) MultiWrite() {
+	tms.parent.MultiWrite()
+}
+
+type traceStore struct {
+	parent store.Store
+	name   string
+	tracer *writeTracer
+}
+
+var _ store.Store = (*traceStore)(nil)
+
+func (ts *traceStore) Get(key []byte) []byte {
+	return ts.parent.Get(key)
+}
+
+func (ts *traceStore) Has(key []byte) bool {
+	return ts.parent.Has(key)
+}
+
+func (ts *traceStore) Set(key, value []byte) {
+	ts.parent.Set(key, value)
+	ts.tracer.record(store.StoreOp{
+		Store: ts.name,
+		Type:  store.StoreOpSet,
+		Key:   append([]byte(nil), key...),
+		Value: append([]byte(nil), value...),
+	})
+}
+
+func (ts *traceStore) Delete(key []byte) {
+	ts.parent.Delete(key)
+	ts.tracer.record(store.StoreOp{
+		Store: ts.name,
+		Type:  store.StoreOpDelete,
+		Key:   append([]byte(nil), key...),
+	})
+}
+
+func (ts *traceStore) Iterator(start, end []byte) store.Iterator {
+	return ts.parent.Iterator(start, end)
+}
+
+func (ts *traceStore) ReverseIterator(start, end []byte) store.Iterator {
+	return ts.parent.ReverseIterator(start, end)
+}
+
+func (ts *traceStore) CacheWrap() store.Store {
+	return &traceStore{ts.parent.CacheWrap(), ts.name, ts.tracer}
+}
+
+func (ts *traceStore) Write() {
+	ts.parent.Write()
+}
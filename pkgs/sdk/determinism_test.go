@@ -0,0 +1,154 @@
+package sdk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+func TestDeterminismCheck(t *testing.T) {
+	balances := make(map[string]int64)
+	for i := 0; i < 20; i++ {
+		balances[fmt.Sprintf("acc%02d", i)] = int64(i)
+	}
+	// the order of the handler: ranging over the map, reversed on every
+	// execution, or sorted.
+	order, executions := "map", 0
+	opts := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			store := ctx.Store(mainKey)
+			setIntOnStore(store, []byte("runs"), getIntFromStore(store, []byte("runs"))+1)
+			switch order {
+			case "map":
+				for key, value := range balances {
+					setIntOnStore(store, []byte(key), value)
+				}
+			case "reversed":
+				keys := SortedKeys(balances).([]string)
+				executions++
+				for i := range keys {
+					if executions%2 == 0 {
+						i = len(keys) - 1 - i
+					}
+					setIntOnStore(store, []byte(keys[i]), balances[keys[i]])
+				}
+			case "sorted":
+				IterateSorted(balances, func(key, value interface{}) bool {
+					setIntOnStore(store, []byte(key.(string)), value.(int64))
+					return false
+				})
+			}
+			return Result{}
+		}))
+	}
+	app := setupBaseApp(t, SetDeterminismCheck(true), SetDebugErrors(true), opts)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	deliver := func(tx std.Tx) Result {
+		res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(tx)})
+		return Result{ResponseBase: res.ResponseBase}
+	}
+
+	// the handler ranging over the map is caught, unless both executions
+	// happen to range in the same order.
+	var res Result
+	for i := 0; i < 10; i++ {
+		res = deliver(newTxCounter(0, 0))
+		if !res.IsOK() {
+			break
+		}
+	}
+	require.True(t, ErrorIs(res, std.InternalError{}), res.Log)
+	require.Regexp(t, `handler "msgCounter" wrote keys in nondeterministic order: write \d+ is set main/616363`, res.Log)
+
+	// the diagnostic reports the first divergent write.
+	order = "reversed"
+	res = deliver(newTxCounter(0, 0))
+	require.True(t, ErrorIs(res, std.InternalError{}), res.Log)
+	require.Equal(t, `handler "msgCounter" wrote keys in nondeterministic order: `+
+		`write 1 is set main/6163633139=26, was set main/6163633030=00`, res.Log)
+
+	// the sorted iteration is deterministic, and the first execution is
+	// discarded.
+	store := app.deliverState.ctx.Store(mainKey)
+	runs := getIntFromStore(store, []byte("runs"))
+	order = "sorted"
+	res = deliver(newTxCounter(0, 0))
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, runs+1, getIntFromStore(store, []byte("runs")))
+	require.Equal(t, int64(7), getIntFromStore(store, []byte("acc07")))
+}
+
+// The msgs of a tx run twice to check its determinism are counted once in
+// the route stats.
+func TestDeterminismCheckRouteStats(t *testing.T) {
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			ctx.GasMeter().ConsumeGas(msg.(msgCounter).Counter, "counter1")
+			return Result{}
+		}))
+	}
+	app := setupBaseApp(t, SetDeterminismCheck(true), routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	for i, tx := range []std.Tx{newTxCounter(0, 10, 20), newTxCounter(1, 30)} {
+		res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(tx)})
+		require.True(t, res.IsOK(), "tx %d: %s", i, res.Log)
+	}
+
+	stats := app.RouteStats()
+	require.Len(t, stats, 1)
+	require.Equal(t, uint64(3), stats[0].Count)
+	require.Equal(t, uint64(10+20+30), stats[0].GasUsed)
+}
+
+// The OnTxEnd funcs of the first execution of a tx run twice to check its
+// determinism are called with false, as its branch is discarded, before the
+// second execution runs.
+func TestDeterminismCheckOnTxEnd(t *testing.T) {
+	var calls []string
+	executions := 0
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			executions++
+			execution := executions
+			ctx.OnTxEnd(func(written bool) {
+				calls = append(calls, fmt.Sprintf("%d:%v", execution, written))
+			})
+			switch msg.(msgCounter).Counter {
+			case 1:
+				return ABCIResultFromError(std.ErrUnauthorized("fail"))
+			case 2:
+				// nondeterministic.
+				setIntOnStore(ctx.Store(mainKey), []byte(fmt.Sprintf("key%d", execution)), 1)
+			}
+			return Result{}
+		}))
+	}
+	app := setupBaseApp(t, SetDeterminismCheck(true), routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	for _, tc := range []struct {
+		counter int64
+		ok      bool
+		calls   []string
+	}{
+		{0, true, []string{"1:false", "2:true"}},
+		{1, false, []string{"3:false", "4:false"}},
+		{2, false, []string{"5:false", "6:false"}},
+	} {
+		calls = nil
+		res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, tc.counter))})
+		require.Equal(t, tc.ok, res.IsOK(), res.Log)
+		require.Equal(t, tc.calls, calls)
+	}
+}
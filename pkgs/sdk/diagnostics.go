@@ -0,0 +1,226 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDiagnosticsHost is the host of the diagnostics listener when its
+// address has none, e.g. ":6060", so that it is not exposed by default.
+const DefaultDiagnosticsHost = "localhost"
+
+// DiagnosticsSource returns the stats of a component of the app, e.g. of
+// the gno store, served as JSON by the diagnostics listener. It is called
+// on Commit, from the consensus goroutine, and may return nil if there are
+// no stats yet.
+type DiagnosticsSource func() interface{}
+
+// AppDiagnostics is the state of a BaseApp, served at /debug/app.
+type AppDiagnostics struct {
+	Name          string
+	Version       string
+	Height        int64 // of the last committed block
+	StoreVersions []StoreVersion
+
+	CheckTxs         uint64
+	RejectedCheckTxs uint64
+	DeliverTxs       uint64
+	FailedDeliverTxs uint64
+}
+
+// StoreVersion is the last commit of a store of the multistore.
+type StoreVersion struct {
+	Name    string
+	Version int64
+	Hash    []byte
+}
+
+// txCounters are the counters of the txs run by a BaseApp, accessed
+// atomically.
+type txCounters struct {
+	checkTxs         uint64
+	rejectedCheckTxs uint64
+	deliverTxs       uint64
+	failedDeliverTxs uint64
+}
+
+func (c *txCounters) count(mode RunTxMode, ok bool) {
+	switch mode {
+	case RunTxModeCheck:
+		atomic.AddUint64(&c.checkTxs, 1)
+		if !ok {
+			atomic.AddUint64(&c.rejectedCheckTxs, 1)
+		}
+	case RunTxModeDeliver:
+		atomic.AddUint64(&c.deliverTxs, 1)
+		if !ok {
+			atomic.AddUint64(&c.failedDeliverTxs, 1)
+		}
+	}
+}
+
+// diagnostics serves the pprof profiles and the snapshots of the state of
+// a BaseApp over HTTP.
+type diagnostics struct {
+	listener net.Listener
+	server   *http.Server
+
+	mtx       sync.RWMutex
+	app       *AppDiagnostics
+	sources   map[string]interface{} // snapshots of the sources by name
+	closeOnce sync.Once
+}
+
+// startDiagnostics starts the diagnostics listener of the app on addr.
+func (app *BaseApp) startDiagnostics(addr string) error {
+	if app.diagnostics != nil {
+		return errors.New("diagnostics listener already started")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid diagnostics address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = DefaultDiagnosticsHost
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+
+	d := &diagnostics{listener: ln}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/app", d.serveApp)
+	mux.HandleFunc("/debug/", d.serveSource)
+	d.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	app.diagnostics = d
+
+	go func() {
+		if err := d.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			app.logger.Error("diagnostics listener stopped", "err", err)
+		}
+	}()
+	app.logger.Info("started diagnostics listener", "addr", ln.Addr().String())
+	return nil
+}
+
+// DiagnosticsAddr returns the address of the diagnostics listener, or an
+// empty string if it is not started.
+func (app *BaseApp) DiagnosticsAddr() string {
+	if app.diagnostics == nil {
+		return ""
+	}
+	return app.diagnostics.listener.Addr().String()
+}
+
+// SetDiagnosticsSource sets the source of the stats served at
+// /debug/<name> by the diagnostics listener, e.g. "gno_store".
+func (app *BaseApp) SetDiagnosticsSource(name string, source DiagnosticsSource) {
+	if app.sealed {
+		panic("SetDiagnosticsSource() on sealed BaseApp")
+	}
+	if name == "" || name == "app" || name == "pprof" {
+		panic(fmt.Sprintf("invalid diagnostics source name %q", name))
+	}
+	if app.diagnosticsSources == nil {
+		app.diagnosticsSources = make(map[string]DiagnosticsSource)
+	}
+	app.diagnosticsSources[name] = source
+}
+
+// snapshotDiagnostics updates the state served by the diagnostics
+// listener, if it is started. It must be called from the consensus
+// goroutine, so that the sources are not run concurrently with the txs.
+func (app *BaseApp) snapshotDiagnostics() {
+	d := app.diagnostics
+	if d == nil {
+		return
+	}
+	state := &AppDiagnostics{
+		Name:             app.name,
+		Version:          app.appVersion,
+		Height:           app.LastBlockHeight(),
+		CheckTxs:         atomic.LoadUint64(&app.txCounters.checkTxs),
+		RejectedCheckTxs: atomic.LoadUint64(&app.txCounters.rejectedCheckTxs),
+		DeliverTxs:       atomic.LoadUint64(&app.txCounters.deliverTxs),
+		FailedDeliverTxs: atomic.LoadUint64(&app.txCounters.failedDeliverTxs),
+	}
+	for _, key := range app.storeKeys {
+		cid := app.cms.GetCommitStore(key).LastCommitID()
+		state.StoreVersions = append(state.StoreVersions, StoreVersion{
+			Name:    key.Name(),
+			Version: cid.Version,
+			Hash:    cid.Hash,
+		})
+	}
+	sources := make(map[string]interface{}, len(app.diagnosticsSources))
+	for name, source := range app.diagnosticsSources {
+		if stats := source(); stats != nil {
+			sources[name] = stats
+		}
+	}
+
+	d.mtx.Lock()
+	d.app, d.sources = state, sources
+	d.mtx.Unlock()
+}
+
+// closeDiagnostics shuts the diagnostics listener down, if it is started.
+func (app *BaseApp) closeDiagnostics() (err error) {
+	d := app.diagnostics
+	if d == nil {
+		return nil
+	}
+	d.closeOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = d.server.Shutdown(ctx)
+	})
+	return err
+}
+
+func (d *diagnostics) serveApp(w http.ResponseWriter, r *http.Request) {
+	d.mtx.RLock()
+	state := d.app
+	d.mtx.RUnlock()
+	if state == nil {
+		http.Error(w, "app not loaded", http.StatusServiceUnavailable)
+		return
+	}
+	writeDiagnostics(w, state)
+}
+
+func (d *diagnostics) serveSource(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path[len("/debug/"):]
+	d.mtx.RLock()
+	stats, ok := d.sources[name]
+	d.mtx.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeDiagnostics(w, stats)
+}
+
+func writeDiagnostics(w http.ResponseWriter, v interface{}) {
+	bz, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bz) //nolint:errcheck
+}
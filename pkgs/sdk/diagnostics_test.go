@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+)
+
+func getDiagnostics(t *testing.T, app *BaseApp, path string) (int, []byte) {
+	t.Helper()
+	res, err := http.Get("http://" + app.DiagnosticsAddr() + path)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	bz, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, bz
+}
+
+func TestDiagnosticsListener(t *testing.T) {
+	var gnoStoreStats interface{}
+	opts := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result { return Result{} }))
+		bapp.SetDiagnosticsSource("gno_store", func() interface{} { return gnoStoreStats })
+	}
+	app := setupBaseApp(t, SetDiagnosticsListener("localhost:0"), opts)
+	defer app.Close()
+	require.Contains(t, app.DiagnosticsAddr(), "127.0.0.1:")
+
+	// the listener cannot be started twice.
+	require.Panics(t, func() { SetDiagnosticsListener("localhost:0")(app) })
+
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, 0))})
+	require.True(t, res.IsOK(), res.Log)
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: []byte("invalid")})
+	require.False(t, res.IsOK())
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	gnoStoreStats = map[string]int{"CachedObjects": 3}
+	app.Commit()
+	chk := app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(newTxCounter(1, 0))})
+	require.True(t, chk.IsOK(), chk.Log)
+
+	code, bz := getDiagnostics(t, app, "/debug/app")
+	require.Equal(t, http.StatusOK, code, string(bz))
+	var state AppDiagnostics
+	require.NoError(t, json.Unmarshal(bz, &state))
+	require.Equal(t, t.Name(), state.Name)
+	require.Equal(t, int64(1), state.Height)
+	require.Len(t, state.StoreVersions, 2)
+	require.Equal(t, "base", state.StoreVersions[0].Name)
+	require.Equal(t, "main", state.StoreVersions[1].Name)
+	require.Equal(t, int64(1), state.StoreVersions[1].Version)
+	require.Equal(t, uint64(2), state.DeliverTxs)
+	require.Equal(t, uint64(1), state.FailedDeliverTxs)
+	// the state is a snapshot of the last commit.
+	require.Equal(t, uint64(0), state.CheckTxs)
+
+	code, bz = getDiagnostics(t, app, "/debug/gno_store")
+	require.Equal(t, http.StatusOK, code, string(bz))
+	require.JSONEq(t, `{"CachedObjects": 3}`, string(bz))
+
+	code, _ = getDiagnostics(t, app, "/debug/unknown")
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, bz = getDiagnostics(t, app, "/debug/pprof/")
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, string(bz), "heap")
+
+	// the listener is shut down on Close.
+	require.NoError(t, app.Close())
+	_, err := http.Get("http://" + app.DiagnosticsAddr() + "/debug/app")
+	require.Error(t, err)
+}
@@ -0,0 +1,159 @@
+package sdk
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/crypto/tmhash"
+	dbm "github.com/gnolang/gno/pkgs/db"
+)
+
+// eventJournalPrefix is the prefix of the keys of the blocks in the event
+// journal, followed by their big-endian height.
+var eventJournalPrefix = []byte("events/")
+
+// BlockEvents is the entry of a block in the event journal, see
+// SetEventJournal. The events and results are in canonical JSON, see
+// ResponseIndexJSON.
+type BlockEvents struct {
+	Height           int64           `json:"height"`
+	Time             time.Time       `json:"time"`
+	BeginBlockEvents json.RawMessage `json:"begin_block_events"`
+	Txs              []TxEvents      `json:"txs"`
+	EndBlockEvents   json.RawMessage `json:"end_block_events"`
+}
+
+// TxEvents is the result of a tx of a block in the event journal, in the
+// order of the block.
+type TxEvents struct {
+	Hash   []byte          `json:"hash"`
+	Result json.RawMessage `json:"result"`
+}
+
+// eventJournal writes the BlockEvents of the blocks to a DB, see
+// SetEventJournal.
+type eventJournal struct {
+	db           dbm.DB
+	retainBlocks int64
+	block        *BlockEvents // of the block being delivered
+}
+
+func eventJournalKey(height int64) []byte {
+	key := make([]byte, len(eventJournalPrefix)+8)
+	copy(key, eventJournalPrefix)
+	binary.BigEndian.PutUint64(key[len(eventJournalPrefix):], uint64(height))
+	return key
+}
+
+// beginBlock starts the entry of the block of header.
+func (j *eventJournal) beginBlock(header abci.Header, res abci.ResponseBeginBlock) {
+	j.block = &BlockEvents{
+		Height:           header.GetHeight(),
+		Time:             header.GetTime(),
+		BeginBlockEvents: json.RawMessage(eventsIndexJSON(res.Events)),
+		Txs:              []TxEvents{},
+	}
+}
+
+// deliverTx adds the result of tx to the entry of the block.
+func (j *eventJournal) deliverTx(tx []byte, res abci.ResponseDeliverTx) {
+	if j.block == nil {
+		return // a tx of InitChain, which is in no block.
+	}
+	j.block.Txs = append(j.block.Txs, TxEvents{
+		Hash:   tmhash.Sum(tx),
+		Result: ResponseIndexJSON(res.ResponseBase, res.GasWanted, res.GasUsed),
+	})
+}
+
+// endBlock adds the events of EndBlock to the entry of the block.
+func (j *eventJournal) endBlock(res abci.ResponseEndBlock) {
+	if j.block == nil {
+		return
+	}
+	j.block.EndBlockEvents = json.RawMessage(eventsIndexJSON(res.Events))
+}
+
+// commit durably writes the entry of the block, and prunes the entries
+// which are out of the retention window. It is called before the state of
+// the block is committed, so that the entry is not lost by a crash: the
+// block is then replayed, and its entry written again.
+func (j *eventJournal) commit() {
+	block := j.block
+	j.block = nil
+	if block == nil {
+		return
+	}
+	if block.EndBlockEvents == nil {
+		block.EndBlockEvents = json.RawMessage("[]")
+	}
+	bz, err := json.Marshal(block)
+	if err != nil {
+		panic(err)
+	}
+	j.db.SetSync(eventJournalKey(block.Height), bz)
+
+	if j.retainBlocks == 0 || block.Height <= j.retainBlocks {
+		return
+	}
+	// the entries below the window, which are usually just one.
+	end := eventJournalKey(block.Height - j.retainBlocks + 1)
+	var pruned [][]byte
+	itr := j.db.Iterator(eventJournalPrefix, end)
+	for ; itr.Valid(); itr.Next() {
+		pruned = append(pruned, itr.Key())
+	}
+	itr.Close()
+	for _, key := range pruned {
+		j.db.Delete(key)
+	}
+}
+
+// ReadEventJournal calls fn with the entries of the event journal, from
+// the block at fromHeight on, or from the oldest entry if fromHeight is 0,
+// in order of height, until fn returns false. The entries are written on
+// Commit, so that a consumer which stores the height after that of the
+// last entry it processed, and reads from it after a restart, gets each
+// block at least once. An error is returned if the event journal is not
+// enabled, if an entry is corrupted, or if the entry of fromHeight is
+// pruned, so that the consumer would miss blocks. It can be called
+// concurrently with the blocks.
+func (app *BaseApp) ReadEventJournal(fromHeight int64, fn func(BlockEvents) bool) error {
+	if app.eventJournal == nil {
+		return fmt.Errorf("event journal is not enabled, see SetEventJournal")
+	}
+	if fromHeight < 0 {
+		return fmt.Errorf("invalid event journal height %d", fromHeight)
+	}
+	db := app.eventJournal.db
+	if fromHeight > 0 {
+		itr := dbm.IteratePrefix(db, eventJournalPrefix)
+		oldest := int64(0)
+		if itr.Valid() {
+			oldest = int64(binary.BigEndian.Uint64(itr.Key()[len(eventJournalPrefix):]))
+		}
+		itr.Close()
+		if oldest > fromHeight {
+			return fmt.Errorf("events of height %d are pruned from the event journal, which starts at height %d",
+				fromHeight, oldest)
+		}
+	}
+
+	itr := db.Iterator(eventJournalKey(fromHeight), eventJournalKey(math.MaxInt64))
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		var block BlockEvents
+		if err := json.Unmarshal(itr.Value(), &block); err != nil {
+			height := int64(binary.BigEndian.Uint64(itr.Key()[len(eventJournalPrefix):]))
+			return fmt.Errorf("corrupted events of height %d in the event journal: %v", height, err)
+		}
+		if !fn(block) {
+			return nil
+		}
+	}
+	return nil
+}
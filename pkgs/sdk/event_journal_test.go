@@ -0,0 +1,110 @@
+package sdk
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto/tmhash"
+	dbm "github.com/gnolang/gno/pkgs/db"
+)
+
+func TestEventJournal(t *testing.T) {
+	db, journalDB := dbm.NewMemDB(), dbm.NewMemDB()
+	newApp := func() *BaseApp {
+		app := newBaseApp(t.Name(), db, SetEventJournal(journalDB, 4), func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+				return Result{}
+			}))
+			bapp.SetBeginBlocker(func(ctx Context, req abci.RequestBeginBlock) (res abci.ResponseBeginBlock) {
+				res.Events = []abci.Event{abci.TypedEvent{Type: "begin", Attributes: []abci.EventAttribute{
+					{Key: "height", Value: strconv.FormatInt(req.Header.GetHeight(), 10)},
+				}}}
+				return
+			})
+			bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) (res abci.ResponseEndBlock) {
+				res.Events = []abci.Event{abci.TypedEvent{Type: "end"}}
+				return
+			})
+		})
+		require.NoError(t, app.LoadLatestVersion())
+		return app
+	}
+	// the block of height h has h-1 txs, and a tx which cannot be decoded.
+	runBlock := func(app *BaseApp, height int64) {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		for i := int64(0); i < height-1; i++ {
+			app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(i, i))})
+		}
+		app.DeliverTx(abci.RequestDeliverTx{Tx: []byte("bad")})
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+	}
+	read := func(app *BaseApp, fromHeight int64) []BlockEvents {
+		var blocks []BlockEvents
+		require.NoError(t, app.ReadEventJournal(fromHeight, func(block BlockEvents) bool {
+			blocks = append(blocks, block)
+			return true
+		}))
+		return blocks
+	}
+
+	app := newApp()
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	for height := int64(1); height <= 3; height++ {
+		runBlock(app, height)
+	}
+	blocks := read(app, 0)
+	require.Len(t, blocks, 3)
+	block := blocks[2]
+	require.Equal(t, int64(3), block.Height)
+	require.JSONEq(t, `[{"type": "begin", "attributes": [{"key": "height", "value": "3", "index": false}]}]`,
+		string(block.BeginBlockEvents))
+	require.JSONEq(t, `[{"type": "end", "attributes": []}]`, string(block.EndBlockEvents))
+	require.Len(t, block.Txs, 3)
+	require.Equal(t, tmhash.Sum(amino.MustMarshal(newTxCounter(1, 1))), block.Txs[1].Hash)
+	var result struct {
+		Code   uint32            `json:"code"`
+		Events []json.RawMessage `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(block.Txs[1].Result, &result))
+	require.Equal(t, uint32(0), result.Code)
+	require.NotEmpty(t, result.Events)
+	require.NoError(t, json.Unmarshal(block.Txs[2].Result, &result))
+	require.NotEqual(t, uint32(0), result.Code)
+
+	// a consumer stops after the block of height 2, and resumes after a
+	// restart from height 3, without gaps.
+	var cursor int64
+	require.NoError(t, app.ReadEventJournal(1, func(block BlockEvents) bool {
+		cursor = block.Height + 1
+		return block.Height < 2
+	}))
+	require.Equal(t, int64(3), cursor)
+
+	app = newApp()
+	require.Equal(t, int64(3), app.LastBlockHeight())
+	for height := int64(4); height <= 6; height++ {
+		runBlock(app, height)
+	}
+	blocks = read(app, cursor)
+	require.Len(t, blocks, 4)
+	for i, block := range blocks {
+		require.Equal(t, cursor+int64(i), block.Height)
+		require.Len(t, block.Txs, int(block.Height))
+	}
+
+	// the blocks out of the last 4 are pruned.
+	require.Equal(t, int64(3), read(app, 0)[0].Height)
+	err := app.ReadEventJournal(2, func(BlockEvents) bool { return true })
+	require.EqualError(t, err, "events of height 2 are pruned from the event journal, which starts at height 3")
+
+	// the journal is disabled by default.
+	err = setupBaseApp(t).ReadEventJournal(0, func(BlockEvents) bool { return true })
+	require.Error(t, err)
+}
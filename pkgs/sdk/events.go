@@ -1,6 +1,8 @@
 package sdk
 
 import (
+	"fmt"
+
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
 )
 
@@ -30,8 +32,95 @@ func (em *EventLogger) EmitEvents(events []Event) {
 	em.events = append(em.events, events...)
 }
 
+// EmitTypedEvent stores an abci.TypedEvent of type typ with indexed
+// attributes from the key/value pairs kv. See NewTypedEvent.
+func (em *EventLogger) EmitTypedEvent(typ string, kv ...string) error {
+	ev, err := NewTypedEvent(typ, kv...)
+	if err != nil {
+		return err
+	}
+	em.EmitEvent(ev)
+	return nil
+}
+
 // ----------------------------------------------------------------------------
 // Event
 // ----------------------------------------------------------------------------
 
 type Event = abci.Event
+
+// EventTypeBlockGasExceeded is the type of the event emitted by a tx which
+// alone uses more than the max block gas, with the attributes "gas_used" and
+// "max_gas".
+const EventTypeBlockGasExceeded = "block_gas_exceeded"
+
+// EventTypeTx is the type of the event emitted by each tx delivered in a
+// block, with the attribute "index" of the tx in the block.
+const EventTypeTx = "tx"
+
+// AttributeKeyAnte is the key of the attribute, of value "true", which marks
+// the typed events of the ante handler in the result of a tx, which are
+// before the events of its msgs, and are kept if the msgs fail.
+const AttributeKeyAnte = "ante"
+
+// markAnteEvents adds the AttributeKeyAnte attribute to the typed events of
+// events, in place.
+func markAnteEvents(events []Event) []Event {
+	for i, ev := range events {
+		if tev, ok := ev.(abci.TypedEvent); ok {
+			attrs := make([]abci.EventAttribute, 0, len(tev.Attributes)+1)
+			attrs = append(attrs, tev.Attributes...)
+			tev.Attributes = append(attrs, abci.NewAttribute(AttributeKeyAnte, "true"))
+			events[i] = tev
+		}
+	}
+	return events
+}
+
+// isAnteEvent returns whether ev is a typed event of the ante handler, see
+// AttributeKeyAnte.
+func isAnteEvent(ev Event) bool {
+	tev, ok := ev.(abci.TypedEvent)
+	if !ok {
+		return false
+	}
+	for _, attr := range tev.Attributes {
+		if attr.Key == AttributeKeyAnte {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTypedEvent returns an abci.TypedEvent of type typ with indexed
+// attributes from the key/value pairs kv, e.g.
+//
+//	NewTypedEvent("transfer", "sender", from.String(), "amount", amt.String())
+//
+// It returns an error if kv has an odd number of elements or if the event
+// is not valid.
+func NewTypedEvent(typ string, kv ...string) (abci.TypedEvent, error) {
+	if len(kv)%2 != 0 {
+		return abci.TypedEvent{}, fmt.Errorf("event %s: odd number of attribute keys and values", typ)
+	}
+	var attrs []abci.EventAttribute
+	for i := 0; i < len(kv); i += 2 {
+		attrs = append(attrs, abci.NewAttribute(kv[i], kv[i+1]))
+	}
+	ev := abci.NewEvent(typ, attrs...)
+	if err := ev.Validate(); err != nil {
+		return abci.TypedEvent{}, err
+	}
+	return ev, nil
+}
+
+// EmitTypedEvent appends an abci.TypedEvent to the events of the result.
+// See NewTypedEvent.
+func (res *Result) EmitTypedEvent(typ string, kv ...string) error {
+	ev, err := NewTypedEvent(typ, kv...)
+	if err != nil {
+		return err
+	}
+	res.Events = append(res.Events, ev)
+	return nil
+}
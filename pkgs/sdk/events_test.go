@@ -0,0 +1,39 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+)
+
+func TestNewTypedEvent(t *testing.T) {
+	ev, err := NewTypedEvent("transfer", "sender", "g1sender", "amount", "10ugnot")
+	require.NoError(t, err)
+	assert.Equal(t, abci.NewEvent("transfer",
+		abci.NewAttribute("sender", "g1sender"),
+		abci.NewAttribute("amount", "10ugnot"),
+	), ev)
+
+	_, err = NewTypedEvent("transfer", "sender")
+	assert.Error(t, err)
+	_, err = NewTypedEvent("", "sender", "g1sender")
+	assert.Error(t, err)
+	_, err = NewTypedEvent("transfer", "sender", "a", "sender", "b")
+	assert.Error(t, err)
+}
+
+func TestEmitTypedEvent(t *testing.T) {
+	em := NewEventLogger()
+	require.NoError(t, em.EmitTypedEvent("a", "k", "v"))
+	assert.Error(t, em.EmitTypedEvent("b", "k"))
+	require.NoError(t, em.EmitTypedEvent("c"))
+	assert.Equal(t, []Event{abci.NewEvent("a", abci.NewAttribute("k", "v")), abci.NewEvent("c")}, em.Events())
+
+	var res Result
+	require.NoError(t, res.EmitTypedEvent("a", "k", "v"))
+	assert.Error(t, res.EmitTypedEvent("b", "k\x00", "v"))
+	assert.Equal(t, []Event{abci.NewEvent("a", abci.NewAttribute("k", "v"))}, res.Events)
+}
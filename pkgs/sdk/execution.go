@@ -0,0 +1,109 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// ExecutionMeter bounds the execution of the handlers of msgs
+// deterministically, e.g. a VM which counts the instructions it executes.
+// Unlike the handler deadline, it is safe for consensus, as all the nodes
+// stop a msg at the same instruction. See SetExecutionMeter.
+type ExecutionMeter interface {
+	// MeterMsg returns the context of the handler of msg, e.g. with the
+	// max number of instructions of the VM, over which the handler fails
+	// with std.ErrExecutionLimit.
+	MeterMsg(ctx Context, msg Msg) Context
+}
+
+// runHandler processes msg with handler, bounded by the execution meter, if
+// set, and by the handler deadline, if set, in CheckTx and Simulate only.
+func (app *BaseApp) runHandler(ctx Context, handler Handler, msg Msg) Result {
+	if app.executionMeter != nil {
+		ctx = app.executionMeter.MeterMsg(ctx, msg)
+	}
+	switch mode := ctx.Mode(); {
+	case app.handlerDeadline <= 0:
+		return processHandler(ctx, handler, msg)
+	case mode == RunTxModeCheck || mode == RunTxModeSimulate:
+		return processHandlerWithDeadline(ctx, handler, msg, app.handlerDeadline)
+	default:
+		// wall-clock timeouts differ between nodes, so delivered msgs
+		// never time out.
+		return processHandler(ctx, handler, msg)
+	}
+}
+
+// handlerOutcome is the result of a handler run by
+// processHandlerWithDeadline, or its panic.
+type handlerOutcome struct {
+	result   Result
+	panicked bool
+	panic    interface{}
+}
+
+// processHandlerWithDeadline processes msg with handler on a goroutine, and
+// fails with std.ErrHandlerTimeout if it does not return within deadline.
+// The panics of the handler are propagated. The goroutine runs on its own
+// cache branch of the stores, gas meter and event logger, which are merged
+// into those of ctx once it returns: the goroutine of a handler which times
+// out cannot be stopped, so it is abandoned, with all it could write.
+func processHandlerWithDeadline(ctx Context, handler Handler, msg Msg, deadline time.Duration) Result {
+	hctx, writeCache := ctx.CacheContext()
+	hctx = hctx.WithGasMeter(store.NewGasMeter(ctx.GasMeter().Remaining()))
+	done := make(chan handlerOutcome, 1)
+	go func() {
+		var out handlerOutcome
+		out.panicked = true // unless the handler returns, e.g. panic(nil)
+		defer func() {
+			if out.panicked {
+				out.panic = recover()
+			}
+			done <- out
+		}()
+		out.result = processHandler(hctx, handler, msg)
+		out.panicked = false
+	}()
+
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+	select {
+	case out := <-done:
+		// the gas is consumed first, so that a handler out of gas
+		// panics as out of gas of ctx.
+		consumeHandlerGas(ctx.GasMeter(), hctx.GasMeter())
+		if out.panicked {
+			panic(out.panic)
+		}
+		writeCache()
+		ctx.EventLogger().EmitEvents(hctx.EventLogger().Events())
+		return out.result
+	case <-timer.C:
+		return Result{ResponseBase: abci.ResponseBase{Error: ABCIError(std.ErrHandlerTimeout(
+			fmt.Sprintf("handler of %s/%s did not return within %s", msg.Route(), msg.Type(), deadline)))}}
+	}
+}
+
+// consumeHandlerGas consumes from meter the gas consumed from the meter of
+// a handler, by category if it sums them.
+func consumeHandlerGas(meter, handlerMeter store.GasMeter) {
+	cmeter, ok := handlerMeter.(store.CategorizingGasMeter)
+	if !ok {
+		meter.ConsumeGas(handlerMeter.GasConsumed(), "handler")
+		return
+	}
+	breakdown := cmeter.GasBreakdown()
+	categories := make([]string, 0, len(breakdown))
+	for category := range breakdown {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		meter.ConsumeGas(breakdown[category], category+":handler")
+	}
+}
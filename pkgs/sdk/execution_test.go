@@ -0,0 +1,168 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// stuckHandler writes the counter of the msg, consumes gas and emits an
+// event. If the counter is 0, it blocks until release is closed, does it
+// all again, and closes finished.
+func stuckHandler(release, finished chan struct{}) Handler {
+	return newTestHandler(func(ctx Context, msg Msg) Result {
+		counter := msg.(msgCounter).Counter
+		run := func() {
+			ctx.Store(mainKey).Set([]byte("written"), []byte{byte(counter)})
+			ctx.GasMeter().ConsumeGas(100, "stuck")
+			ctx.EventLogger().EmitEvent(abci.EventString("stuck"))
+		}
+		run()
+		if counter == 0 {
+			<-release
+			run()
+			close(finished)
+		}
+		return Result{}
+	})
+}
+
+func TestHandlerDeadline(t *testing.T) {
+	release, finished := make(chan struct{}), make(chan struct{})
+	handler := stuckHandler(release, finished)
+	app := setupBaseApp(t,
+		SetHandlerDeadline(10*time.Millisecond),
+		func(bapp *BaseApp) { bapp.Router().AddRoute(routeMsgCounter, handler) },
+	)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	// the simulation of the stuck handler times out.
+	res := app.Simulate(nil, newTxCounter(0, 0))
+	require.False(t, res.IsOK())
+	require.IsType(t, std.HandlerTimeoutError{}, res.Error)
+	res = app.Simulate(nil, newTxCounter(0, 1))
+	require.True(t, res.IsOK(), res.Log)
+	require.Contains(t, res.Events, abci.EventString("stuck"))
+
+	// the abandoned handler writes to its own branch and gas meter.
+	ctx := app.deliverState.ctx.WithMode(RunTxModeSimulate).
+		WithGasMeter(store.NewGasMeter(100000)).
+		WithEventLogger(NewEventLogger())
+	res = processHandlerWithDeadline(ctx, handler, msgCounter{Counter: 0}, 10*time.Millisecond)
+	require.IsType(t, std.HandlerTimeoutError{}, res.Error)
+	close(release)
+	<-finished
+	require.Zero(t, ctx.GasMeter().GasConsumed())
+	require.Nil(t, ctx.Store(mainKey).Get([]byte("written")))
+	require.Empty(t, ctx.EventLogger().Events())
+
+	// the writes, gas and events of the handlers which return in time
+	// are kept, as without deadline.
+	cctx, _ := ctx.CacheContext()
+	cctx = cctx.WithGasMeter(store.NewGasMeter(100000))
+	processHandler(cctx, handler, msgCounter{Counter: 1})
+	gas := cctx.GasMeter().GasConsumed()
+	ctx = ctx.WithGasMeter(store.NewGasMeter(100000))
+	res = processHandlerWithDeadline(ctx, handler, msgCounter{Counter: 1}, time.Second)
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, gas, ctx.GasMeter().GasConsumed())
+	require.Equal(t, []byte{1}, ctx.Store(mainKey).Get([]byte("written")))
+	require.Equal(t, []abci.Event{abci.EventString("stuck")}, ctx.EventLogger().Events())
+
+	// the handlers out of gas panic out of gas of the context.
+	ctx = ctx.WithGasMeter(store.NewGasMeter(gas - 1))
+	require.PanicsWithValue(t, store.OutOfGasException{Descriptor: "handler"}, func() {
+		processHandlerWithDeadline(ctx, handler, msgCounter{Counter: 1}, time.Second)
+	})
+
+	// the panics of the handlers are propagated.
+	app2 := setupBaseApp(t,
+		SetHandlerDeadline(time.Second),
+		func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+				panic("handler panic")
+			}))
+		},
+	)
+	app2.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	res = app2.Simulate(nil, newTxCounter(0, 1))
+	require.False(t, res.IsOK())
+	require.IsType(t, std.InternalError{}, res.Error)
+}
+
+// Delivered msgs never time out, as timeouts differ between nodes.
+func TestHandlerDeadlineDeliverTx(t *testing.T) {
+	app := setupBaseApp(t,
+		SetHandlerDeadline(time.Millisecond),
+		func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+				time.Sleep(20 * time.Millisecond)
+				ctx.Store(mainKey).Set([]byte("written"), []byte{1})
+				return Result{}
+			}))
+		},
+	)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, 1))})
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, []byte{1}, app.deliverState.ctx.Store(mainKey).Get([]byte("written")))
+}
+
+func TestHandlerDeadlineDefault(t *testing.T) {
+	var slept bool
+	app := setupBaseApp(t, func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			time.Sleep(20 * time.Millisecond)
+			slept = true
+			return Result{}
+		}))
+	})
+	require.Zero(t, app.handlerDeadline)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, 1))})
+	require.True(t, res.IsOK(), res.Log)
+	require.True(t, slept)
+}
+
+type instructionLimitKey struct{}
+
+// testExecutionMeter sets the instruction limit of each msg in its context.
+type testExecutionMeter struct{ limit int64 }
+
+func (m testExecutionMeter) MeterMsg(ctx Context, msg Msg) Context {
+	return ctx.WithValue(instructionLimitKey{}, m.limit)
+}
+
+func TestExecutionMeter(t *testing.T) {
+	app := setupBaseApp(t,
+		SetExecutionMeter(testExecutionMeter{limit: 3}),
+		func(bapp *BaseApp) {
+			bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) (res Result) {
+				// the "VM" runs one instruction per unit of the counter.
+				if msg.(msgCounter).Counter > ctx.Value(instructionLimitKey{}).(int64) {
+					res.Error = ABCIError(std.ErrExecutionLimit("too many instructions"))
+				}
+				return res
+			}))
+		},
+	)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, 3))})
+	require.True(t, res.IsOK(), res.Log)
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(1, 4))})
+	require.False(t, res.IsOK())
+	require.IsType(t, std.ExecutionLimitError{}, res.Error)
+}
@@ -0,0 +1,20 @@
+package sdk
+
+// The test helpers of this package, exported for the tests of package
+// sdk_test, which may import the packages importing this one, e.g. to run
+// the test apps with a testutils.TestChain.
+
+var (
+	MainKey              = mainKey
+	SetupBaseApp         = setupBaseApp
+	AnteHandlerTxTest    = anteHandlerTxTest
+	NewMsgCounterHandler = newMsgCounterHandler
+	NewTxCounter         = newTxCounter
+)
+
+const (
+	RouteMsgCounter  = routeMsgCounter
+	RouteMsgCounter2 = routeMsgCounter2
+)
+
+type MsgCounter2 = msgCounter2
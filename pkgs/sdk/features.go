@@ -0,0 +1,39 @@
+package sdk
+
+import (
+	"fmt"
+)
+
+// Features are the feature gates of an app, by name: the heights of the
+// blocks from which features are active, e.g. changes of the behavior of
+// handlers which all the nodes must apply from the same block.
+type Features map[string]int64
+
+// RegisterFeature registers the feature gate name, active from the block of
+// activationHeight on, see Context.IsFeatureActive. Features are registered
+// at the construction of the app, e.g. from the genesis, and cannot be
+// registered twice.
+func (app *BaseApp) RegisterFeature(name string, activationHeight int64) {
+	if app.sealed {
+		panic("RegisterFeature() on sealed BaseApp")
+	}
+	if name == "" {
+		panic("feature name cannot be empty")
+	}
+	if activationHeight < 0 {
+		panic(fmt.Sprintf("invalid activation height %d of feature %s", activationHeight, name))
+	}
+	if _, ok := app.features[name]; ok {
+		panic(fmt.Sprintf("feature %s is already registered", name))
+	}
+	app.features[name] = activationHeight
+}
+
+// Features returns the feature gates registered with RegisterFeature.
+func (app *BaseApp) Features() Features {
+	features := make(Features, len(app.features))
+	for name, height := range app.features {
+		features[name] = height
+	}
+	return features
+}
@@ -0,0 +1,56 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	dbm "github.com/gnolang/gno/pkgs/db"
+)
+
+// The ante handler, e.g. of a new fee rule, changes its behavior from the
+// activation height of a feature, in CheckTx as in DeliverTx.
+func TestFeatures(t *testing.T) {
+	app := newBaseApp(t.Name(), dbm.NewMemDB())
+	app.RegisterFeature("new-rule", 3)
+	app.RegisterFeature("genesis-rule", 0)
+	require.Panics(t, func() { app.RegisterFeature("new-rule", 4) })
+	require.Panics(t, func() { app.RegisterFeature("", 4) })
+	require.Panics(t, func() { app.RegisterFeature("negative", -1) })
+	active := make(map[RunTxMode]bool)
+	app.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (Context, Result, bool) {
+		require.True(t, ctx.IsFeatureActive("genesis-rule"))
+		require.False(t, ctx.IsFeatureActive("unknown-rule"))
+		active[ctx.Mode()] = ctx.IsFeatureActive("new-rule")
+		return ctx, Result{}, false
+	})
+	app.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+		return Result{}
+	}))
+	require.NoError(t, app.LoadLatestVersion())
+	require.Panics(t, func() { app.RegisterFeature("late-rule", 10) })
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	txBytes := amino.MustMarshal(newTxCounter(0, 0))
+	for height := int64(1); height <= 4; height++ {
+		checkRes := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+		require.True(t, checkRes.IsOK(), checkRes.Log)
+
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		deliverRes := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+		require.True(t, deliverRes.IsOK(), deliverRes.Log)
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+
+		require.Equal(t, height >= 3, active[RunTxModeDeliver], "height %d", height)
+		require.Equal(t, height >= 3, active[RunTxModeCheck], "height %d", height)
+	}
+
+	res := app.Query(abci.RequestQuery{Path: "/.app/features"})
+	require.True(t, res.IsOK(), res.Log)
+	require.JSONEq(t, `{"genesis-rule": 0, "new-rule": 3}`, string(res.Value))
+	require.Equal(t, Features{"genesis-rule": 0, "new-rule": 3}, app.Features())
+}
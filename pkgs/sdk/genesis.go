@@ -0,0 +1,98 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+// GenesisModule is a module with a genesis state: the value of its name in
+// the app state of InitChain, a JSON object of the states of the modules.
+type GenesisModule interface {
+	// ValidateGenesis returns an error if the genesis state of the module
+	// is invalid. It must not write state. The state is nil if the app
+	// state has none for the module.
+	ValidateGenesis(state json.RawMessage) error
+
+	// InitGenesis writes the genesis state of the module, which is valid.
+	InitGenesis(ctx Context, state json.RawMessage)
+}
+
+type genesisModule struct {
+	name string
+	GenesisModule
+}
+
+// RegisterGenesisModule registers the genesis state of the module name. In
+// InitChain, the genesis states of all the registered modules are
+// validated, and only if all are valid are they written, in the order of
+// registration, before the InitChainer runs.
+func (app *BaseApp) RegisterGenesisModule(name string, module GenesisModule) {
+	if app.sealed {
+		panic("RegisterGenesisModule() on sealed BaseApp")
+	}
+	if name == "" {
+		panic("genesis module name cannot be empty")
+	}
+	for _, gm := range app.genesisModules {
+		if gm.name == name {
+			panic(fmt.Sprintf("genesis module %s is already registered", name))
+		}
+	}
+	app.genesisModules = append(app.genesisModules, genesisModule{name, module})
+}
+
+// validateGenesis returns the genesis states of the registered modules in
+// appState, or the errors of all the invalid ones.
+func (app *BaseApp) validateGenesis(appState interface{}) (map[string]json.RawMessage, error) {
+	if len(app.genesisModules) == 0 {
+		return nil, nil
+	}
+	states, err := genesisStates(appState)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, gm := range app.genesisModules {
+		if err := gm.ValidateGenesis(states[gm.name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", gm.name, err))
+		}
+	}
+	return states, errors.Join(errs...)
+}
+
+// initGenesis writes the genesis states of the registered modules.
+func (app *BaseApp) initGenesis(ctx Context, states map[string]json.RawMessage) {
+	for _, gm := range app.genesisModules {
+		gm.InitGenesis(ctx, states[gm.name])
+	}
+}
+
+// genesisStates returns the states of the modules in appState, which is
+// either JSON or a value encoded to a JSON object with amino, e.g. the
+// genesis state of the app.
+func genesisStates(appState interface{}) (map[string]json.RawMessage, error) {
+	var bz []byte
+	switch appState := appState.(type) {
+	case nil:
+		return nil, nil
+	case map[string]json.RawMessage:
+		return appState, nil
+	case json.RawMessage:
+		bz = appState
+	case []byte:
+		bz = appState
+	default:
+		var err error
+		if bz, err = amino.MarshalJSON(appState); err != nil {
+			return nil, fmt.Errorf("cannot encode app state: %w", err)
+		}
+	}
+	var states map[string]json.RawMessage
+	if err := json.Unmarshal(bz, &states); err != nil {
+		return nil, fmt.Errorf("app state is not a JSON object: %w", err)
+	}
+	return states, nil
+}
@@ -0,0 +1,308 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// GenesisStreamer is a GenesisModule which can read its genesis state from
+// a stream, so that the genesis states larger than memory can be imported.
+// See GenesisStreamKey.
+type GenesisStreamer interface {
+	GenesisModule
+
+	// ValidateGenesisStream validates the genesis state of the module,
+	// read from dec, which is at the start of its JSON value. It must read
+	// the whole value, and return an error if it is invalid.
+	ValidateGenesisStream(dec *json.Decoder) error
+
+	// InitGenesisStream writes the genesis state of the module, read from
+	// dec as by ValidateGenesisStream, which validated it. It must read the
+	// whole value, and return an error if it cannot.
+	InitGenesisStream(ctx Context, dec *json.Decoder) error
+}
+
+// GenesisStreamKey is the key of the app state of InitChain which refers to
+// a file of the genesis states of the modules, read as a stream rather than
+// in memory: the app state is then
+//
+//	{"stream": {"path": "<path of the file>", "sha256": "<hex of its SHA-256>"}}
+//
+// so that the genesis commits to the content of the file, and the file is
+// a JSON object of the genesis states of the modules by name, as the app
+// state otherwise.
+//
+// The file is read twice. The first read checks its hash and validates
+// the states, so that InitChain fails without writing any if one is
+// invalid, and the second one writes them. The states of the
+// GenesisStreamers are read with ValidateGenesisStream, then
+// InitGenesisStream, and the others are read in memory, one at a time. The
+// states are in the order of registration of the modules, so that they are
+// written in the same order as from the app state in memory. The writes
+// are written from the block state to the stores every
+// genesisStreamBatchSize writes rather than at Commit, so that the block
+// state does not hold them all; the IAVL stores still hold their new
+// nodes in memory until Commit. The InitChainer still gets the app state
+// of the reference.
+const GenesisStreamKey = "stream"
+
+// genesisStreamBatchSize is the number of writes of a genesis stream after
+// which the block state is written to the stores.
+const genesisStreamBatchSize = 10000
+
+// maxGenesisStreamRef is the max size of the app states which are checked
+// for a reference to a genesis stream.
+const maxGenesisStreamRef = 4096
+
+// genesisStreamRef is the reference to a genesis stream, see
+// GenesisStreamKey.
+type genesisStreamRef struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"` // hex
+}
+
+// genesisStreamRefOf returns the reference to a genesis stream of appState,
+// or nil if it is not one, see GenesisStreamKey. The app states whose only
+// key is GenesisStreamKey are references, which are invalid if they are
+// not of the path and hash of a file.
+func genesisStreamRefOf(appState interface{}) (*genesisStreamRef, error) {
+	var bz []byte
+	switch appState := appState.(type) {
+	case map[string]json.RawMessage:
+		bz, ok := appState[GenesisStreamKey]
+		if len(appState) != 1 || !ok {
+			return nil, nil
+		}
+		var ref genesisStreamRef
+		dec := json.NewDecoder(bytes.NewReader(bz))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&ref); err != nil {
+			return nil, fmt.Errorf("invalid genesis stream reference: %w", err)
+		}
+		if ref.Path == "" {
+			return nil, fmt.Errorf("invalid genesis stream reference: no path")
+		}
+		if hash, err := hex.DecodeString(ref.SHA256); err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("invalid genesis stream reference: sha256 %q is not the hex of a SHA-256", ref.SHA256)
+		}
+		return &ref, nil
+	case json.RawMessage:
+		bz = appState
+	case []byte:
+		bz = appState
+	default:
+		return nil, nil
+	}
+	if len(bz) > maxGenesisStreamRef {
+		return nil, nil
+	}
+	var ref map[string]json.RawMessage
+	if json.Unmarshal(bz, &ref) != nil {
+		return nil, nil
+	}
+	return genesisStreamRefOf(ref)
+}
+
+// initGenesisStream validates, then writes, the genesis states of the
+// registered modules from the file of ref, see GenesisStreamKey.
+func (app *BaseApp) initGenesisStream(ctx Context, ref genesisStreamRef) error {
+	if err := app.readGenesisFile(ctx, ref, false); err != nil {
+		return err
+	}
+	bctx := ctx.WithMultiStore(&batchMultiStore{parent: ctx.MultiStore(), size: genesisStreamBatchSize})
+	return app.readGenesisFile(bctx, ref, true)
+}
+
+// readGenesisFile reads the genesis states of the file of ref, and checks
+// its hash, see readGenesisStream.
+func (app *BaseApp) readGenesisFile(ctx Context, ref genesisStreamRef, write bool) error {
+	f, err := os.Open(ref.Path)
+	if err != nil {
+		return fmt.Errorf("cannot open genesis stream: %w", err)
+	}
+	defer f.Close()
+	hash := sha256.New()
+	r := bufio.NewReaderSize(io.TeeReader(f, hash), 1<<20)
+	err = app.readGenesisStream(ctx, r, write)
+	// the rest of the file is hashed too, and a file which is not that of
+	// the genesis fails first, as its states are not those of the chain.
+	if _, rerr := io.Copy(io.Discard, r); rerr != nil {
+		return fmt.Errorf("cannot read genesis stream: %w", rerr)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != strings.ToLower(ref.SHA256) {
+		return fmt.Errorf("genesis stream %s does not match its sha256: got %s, want %s", ref.Path, sum, ref.SHA256)
+	}
+	return err
+}
+
+// readGenesisStream reads the genesis states of the registered modules
+// from r, see GenesisStreamKey, and validates them, or writes them with
+// ctx if write.
+func (app *BaseApp) readGenesisStream(ctx Context, r io.Reader, write bool) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return fmt.Errorf("genesis stream is not a JSON object: %w", err)
+	}
+	index := make(map[string]int, len(app.genesisModules))
+	for i, gm := range app.genesisModules {
+		index[gm.name] = i
+	}
+	next := 0 // the next module to read
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string) // the keys of objects are strings.
+		i, ok := index[name]
+		if !ok {
+			// the states of unknown modules are ignored.
+			if err := skipValue(dec); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		if i < next {
+			return fmt.Errorf("%s: genesis state out of the order of registration of the modules, or repeated", name)
+		}
+		// the modules without a state are read with none.
+		for ; next < i; next++ {
+			if err := readGenesisModule(ctx, app.genesisModules[next], nil, write); err != nil {
+				return err
+			}
+		}
+		if err := readGenesisModule(ctx, app.genesisModules[i], dec, write); err != nil {
+			return err
+		}
+		next++
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("genesis stream has data after its JSON object")
+	}
+	for ; next < len(app.genesisModules); next++ {
+		if err := readGenesisModule(ctx, app.genesisModules[next], nil, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readGenesisModule reads the genesis state of gm from dec, or none if dec
+// is nil, and validates it, or writes it with ctx if write.
+func readGenesisModule(ctx Context, gm genesisModule, dec *json.Decoder, write bool) error {
+	var err error
+	if streamer, ok := gm.GenesisModule.(GenesisStreamer); ok && dec != nil {
+		if write {
+			err = streamer.InitGenesisStream(ctx, dec)
+		} else {
+			err = streamer.ValidateGenesisStream(dec)
+		}
+	} else {
+		var state json.RawMessage
+		if dec != nil {
+			err = dec.Decode(&state)
+		}
+		if err == nil && write {
+			gm.InitGenesis(ctx, state)
+		} else if err == nil {
+			err = gm.ValidateGenesis(state)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", gm.name, err)
+	}
+	return nil
+}
+
+// batchMultiStore is a MultiStore whose stores write the cache-wrapped
+// multi-store parent to its stores every size writes, so that it does not
+// hold them all. The writes to its cache-wraps are not counted, and its
+// stores must not be written while being iterated.
+type batchMultiStore struct {
+	parent store.MultiStore
+	size   int
+	writes int
+}
+
+var _ store.MultiStore = (*batchMultiStore)(nil)
+
+func (bms *batchMultiStore) GetStore(key store.StoreKey) store.Store {
+	return batchStore{bms.parent.GetStore(key), bms}
+}
+
+func (bms *batchMultiStore) MultiCacheWrap() store.MultiStore {
+	return bms.parent.MultiCacheWrap()
+}
+
+func (bms *batchMultiStore) MultiWrite() {
+	bms.parent.MultiWrite()
+	bms.writes = 0
+}
+
+// wrote counts a write, and writes parent every size writes.
+func (bms *batchMultiStore) wrote() {
+	bms.writes++
+	if bms.writes >= bms.size {
+		bms.MultiWrite()
+	}
+}
+
+type batchStore struct {
+	store.Store
+	bms *batchMultiStore
+}
+
+func (bs batchStore) Set(key, value []byte) {
+	bs.Store.Set(key, value)
+	bs.bms.wrote()
+}
+
+func (bs batchStore) Delete(key []byte) {
+	bs.Store.Delete(key)
+	bs.bms.wrote()
+}
+
+// expectDelim reads the delimiter delim from dec.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %s, got %v", delim, tok)
+	}
+	return nil
+}
+
+// skipValue reads the next JSON value from dec, token by token, so that
+// large values are not held in memory.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package sdk
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	store "github.com/gnolang/gno/pkgs/store/types"
+)
+
+// digestGenesis is a streaming genesis module whose genesis state is an
+// array of non-empty strings, which it writes one per key of its store,
+// with their number and digest, or none.
+type digestGenesis struct {
+	key    store.StoreKey
+	prefix string
+}
+
+func (gm digestGenesis) ValidateGenesis(state json.RawMessage) error {
+	if state == nil {
+		return nil
+	}
+	var entries []string
+	if err := json.Unmarshal(state, &entries); err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		if entry == "" {
+			return fmt.Errorf("empty entry %d", i)
+		}
+	}
+	return nil
+}
+
+func (gm digestGenesis) InitGenesis(ctx Context, state json.RawMessage) {
+	if state == nil {
+		return
+	}
+	var entries []string
+	if err := json.Unmarshal(state, &entries); err != nil {
+		panic(err)
+	}
+	d := sha256.New()
+	for i, entry := range entries {
+		gm.writeEntry(ctx, i, entry)
+		d.Write([]byte(entry))
+	}
+	gm.write(ctx, len(entries), d.Sum(nil))
+}
+
+func (gm digestGenesis) ValidateGenesisStream(dec *json.Decoder) error {
+	return gm.readStream(dec, nil)
+}
+
+func (gm digestGenesis) InitGenesisStream(ctx Context, dec *json.Decoder) error {
+	return gm.readStream(dec, &ctx)
+}
+
+// readStream reads the entries of the genesis state from dec, and writes
+// them with ctx, if not nil.
+func (gm digestGenesis) readStream(dec *json.Decoder, ctx *Context) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	d := sha256.New()
+	n := 0
+	for ; dec.More(); n++ {
+		var entry string
+		if err := dec.Decode(&entry); err != nil {
+			return err
+		}
+		if entry == "" {
+			return fmt.Errorf("empty entry %d", n)
+		}
+		if ctx != nil {
+			gm.writeEntry(*ctx, n, entry)
+		}
+		d.Write([]byte(entry))
+	}
+	if err := expectDelim(dec, ']'); err != nil {
+		return err
+	}
+	if ctx != nil {
+		gm.write(*ctx, n, d.Sum(nil))
+	}
+	return nil
+}
+
+func (gm digestGenesis) writeEntry(ctx Context, i int, entry string) {
+	ctx.Store(gm.key).Set([]byte(fmt.Sprintf("%sentry/%08d", gm.prefix, i)), []byte(entry))
+}
+
+func (gm digestGenesis) write(ctx Context, n int, digest []byte) {
+	var count [8]byte
+	binary.BigEndian.PutUint64(count[:], uint64(n))
+	ctx.Store(gm.key).Set([]byte(gm.prefix+"count"), count[:])
+	ctx.Store(gm.key).Set([]byte(gm.prefix+"digest"), digest)
+}
+
+func newGenesisStreamApp(t *testing.T) *BaseApp {
+	t.Helper()
+	app := newBaseApp(t.Name(), dbm.NewMemDB())
+	app.RegisterGenesisModule("auth", kvGenesis{"auth/"})
+	app.RegisterGenesisModule("bank", digestGenesis{mainKey, "bank/"})
+	app.RegisterGenesisModule("vm", kvGenesis{"vm/"})
+	require.NoError(t, app.LoadLatestVersion())
+	return app
+}
+
+// writeGenesisStream writes the genesis stream of the sections to a file,
+// and returns the app state which refers to it.
+func writeGenesisStream(t *testing.T, sections ...string) json.RawMessage {
+	t.Helper()
+	return writeGenesisFile(t, "{"+strings.Join(sections, ",")+"}")
+}
+
+// writeGenesisFile writes content to a file, and returns the app state
+// which refers to it.
+func writeGenesisFile(t *testing.T, content string) json.RawMessage {
+	t.Helper()
+	return genesisStreamAppState(t, writeGenesisPath(t, content))
+}
+
+// writeGenesisPath writes content to a file, and returns its path.
+func writeGenesisPath(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// genesisStreamAppState returns the app state which refers to the genesis
+// stream of the file at path.
+func genesisStreamAppState(t *testing.T, path string) json.RawMessage {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	require.NoError(t, err)
+	return genesisStreamRefJSON(t, path, hex.EncodeToString(h.Sum(nil)))
+}
+
+// genesisStreamRefJSON returns the app state which refers to the genesis
+// stream at path, of the given hash.
+func genesisStreamRefJSON(t *testing.T, path, hash string) json.RawMessage {
+	t.Helper()
+	ref, err := json.Marshal(map[string]genesisStreamRef{
+		GenesisStreamKey: {Path: path, SHA256: hash},
+	})
+	require.NoError(t, err)
+	return ref
+}
+
+// initChainError returns the panic of InitChain with appState, or nil.
+func initChainError(app *BaseApp, appState interface{}) (err interface{}) {
+	defer func() { err = recover() }()
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain", AppState: appState})
+	return nil
+}
+
+func TestGenesisStreamRef(t *testing.T) {
+	hash := strings.Repeat("ab", sha256.Size)
+	expected := &genesisStreamRef{Path: "genesis.json", SHA256: hash}
+	ref, err := genesisStreamRefOf(json.RawMessage(`{"stream": {"path": "genesis.json", "sha256": "` + hash + `"}}`))
+	require.NoError(t, err)
+	require.Equal(t, expected, ref)
+	ref, err = genesisStreamRefOf(map[string]json.RawMessage{
+		"stream": json.RawMessage(`{"path": "genesis.json", "sha256": "` + hash + `"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, ref)
+
+	for _, appState := range []interface{}{
+		nil,
+		json.RawMessage(`{"stream": {"path": "genesis.json"}, "bank": []}`),
+		json.RawMessage(`{"bank": "genesis.json"}`),
+		json.RawMessage(`["stream"]`),
+		struct{ Stream string }{"genesis.json"},
+	} {
+		ref, err := genesisStreamRefOf(appState)
+		require.NoError(t, err, "%v", appState)
+		require.Nil(t, ref, "%v", appState)
+	}
+
+	for _, appState := range []json.RawMessage{
+		json.RawMessage(`{"stream": "genesis.json"}`),
+		json.RawMessage(`{"stream": ["a"]}`),
+		json.RawMessage(`{"stream": {"sha256": "` + hash + `"}}`),
+		json.RawMessage(`{"stream": {"path": "genesis.json"}}`),
+		json.RawMessage(`{"stream": {"path": "genesis.json", "sha256": "abab"}}`),
+		json.RawMessage(`{"stream": {"path": "genesis.json", "sha256": "` + strings.Repeat("x", 2*sha256.Size) + `"}}`),
+		json.RawMessage(`{"stream": {"path": "genesis.json", "sha256": "` + hash + `", "size": 1}}`),
+	} {
+		_, err := genesisStreamRefOf(appState)
+		require.Error(t, err, "%s", appState)
+	}
+}
+
+func TestGenesisStreamMatchesBuffered(t *testing.T) {
+	entries := make([]string, 1000)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("entry %d", i)
+	}
+	bz, err := json.Marshal(entries)
+	require.NoError(t, err)
+	// the auth module has no state, the vm module reads its state in
+	// memory, and the state of the unknown module is ignored.
+	sections := []string{
+		`"bank": ` + string(bz),
+		`"gov": {"proposals": [[{"a": 1}], "}"]}`,
+		`"vm": {"c": "3", "d": "4"}`,
+	}
+
+	buffered := newGenesisStreamApp(t)
+	buffered.InitChain(abci.RequestInitChain{
+		ChainID:  "test-chain",
+		AppState: json.RawMessage("{" + strings.Join(sections, ",") + "}"),
+	})
+	buffered.Commit()
+
+	streamed := newGenesisStreamApp(t)
+	streamed.InitChain(abci.RequestInitChain{
+		ChainID:  "test-chain",
+		AppState: writeGenesisStream(t, sections...),
+	})
+	streamed.Commit()
+
+	st := streamed.cms.GetStore(mainKey)
+	require.Equal(t, []byte("3"), st.Get([]byte("vm/c")))
+	require.Equal(t, uint64(len(entries)), binary.BigEndian.Uint64(st.Get([]byte("bank/count"))))
+	require.Equal(t, buffered.LastCommitID(), streamed.LastCommitID())
+}
+
+func TestGenesisStreamErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		appState json.RawMessage
+		err      string
+	}{
+		{"invalid reference", json.RawMessage(`{"stream": "genesis.json"}`), "invalid genesis stream reference"},
+		{"missing file", genesisStreamRefJSON(t, "/nonexistent/genesis.json", strings.Repeat("00", sha256.Size)), "cannot open genesis stream"},
+		{"hash mismatch", genesisStreamRefJSON(t, writeGenesisPath(t, `{"vm": {"c": "3"}}`), strings.Repeat("00", sha256.Size)), "does not match its sha256"},
+		{"hash mismatch of an invalid stream", genesisStreamRefJSON(t, writeGenesisPath(t, `{"vm": {"c": ""}}`), strings.Repeat("00", sha256.Size)), "does not match its sha256"},
+		{"not an object", writeGenesisFile(t, `["bank"]`), "genesis stream is not a JSON object"},
+		{"trailing data", writeGenesisFile(t, `{} {}`), "genesis stream has data after its JSON object"},
+		{"invalid streamed state", writeGenesisStream(t, `"bank": ["a", ""]`), "bank: empty entry 1"},
+		{"invalid buffered state", writeGenesisStream(t, `"vm": {"c": ""}`), "vm: empty value of key c"},
+		{"out of order", writeGenesisStream(t, `"vm": {}`, `"bank": []`), "bank: genesis state out of the order"},
+		{"repeated", writeGenesisStream(t, `"vm": {}`, `"vm": {}`), "vm: genesis state out of the order"},
+		{"truncated", writeGenesisFile(t, `{"bank": ["a"`), "bank: "},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newGenesisStreamApp(t)
+			err := initChainError(app, tc.appState)
+			require.NotNil(t, err)
+			require.Contains(t, err, "invalid genesis state: ")
+			require.Contains(t, err, tc.err)
+		})
+	}
+}
+
+func TestGenesisStreamWritesNothingOnError(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		appState json.RawMessage
+	}{
+		{"invalid later state", writeGenesisStream(t, `"auth": {"a": "1"}`, `"vm": {"c": ""}`)},
+		{"hash mismatch", genesisStreamRefJSON(t, writeGenesisPath(t, `{"auth": {"a": "1"}}`), strings.Repeat("00", sha256.Size))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newGenesisStreamApp(t)
+			require.NotNil(t, initChainError(app, tc.appState))
+			require.Nil(t, app.deliverState.ctx.Store(mainKey).Get([]byte("auth/a")))
+		})
+	}
+}
+
+func TestGenesisStreamBoundedMemory(t *testing.T) {
+	size := 256 << 20 // bytes of the genesis stream
+	if testing.Short() {
+		size = 16 << 20
+	}
+	const maxHeapGrowth = 64 << 20
+
+	// write a genesis stream of size bytes, of entries of 100 bytes.
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := bufio.NewWriter(f)
+	entry := strings.Repeat("x", 100-len(`"",`)-8)
+	n := 0
+	w.WriteString(`{"bank": [`)
+	for written := 0; written < size; written += 100 {
+		if n > 0 {
+			w.WriteByte(',')
+		}
+		fmt.Fprintf(w, `"%s%08d"`, entry, n)
+		n++
+	}
+	w.WriteString(`]}`)
+	require.NoError(t, w.Flush())
+	require.NoError(t, f.Close())
+
+	// the entries are written one per key, to a store on disk which does
+	// not hold its writes in memory until Commit.
+	appState := genesisStreamAppState(t, path)
+	db := dbm.NewDB("app", dbm.GoLevelDBBackend, t.TempDir())
+	defer db.Close()
+	entriesKey := store.NewKVStoreKey("entries")
+	app := newBaseApp(t.Name(), db)
+	app.MountStoreWithDB(entriesKey, dbadapter.StoreConstructor, nil)
+	app.RegisterGenesisModule("bank", digestGenesis{entriesKey, "bank/"})
+	require.NoError(t, app.LoadLatestVersion())
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	baseHeap := ms.HeapAlloc
+
+	// sample the heap while the genesis is read.
+	var peakHeap uint64
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		var ms runtime.MemStats
+		for {
+			runtime.ReadMemStats(&ms)
+			if ms.HeapAlloc > atomic.LoadUint64(&peakHeap) {
+				atomic.StoreUint64(&peakHeap, ms.HeapAlloc)
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+	app.InitChain(abci.RequestInitChain{
+		ChainID:  "test-chain",
+		AppState: appState,
+	})
+	close(done)
+	<-sampled
+	app.Commit()
+
+	st := app.cms.GetStore(entriesKey)
+	require.Equal(t, uint64(n), binary.BigEndian.Uint64(st.Get([]byte("bank/count"))))
+	require.Equal(t, []byte(fmt.Sprintf("%s%08d", entry, n-1)), st.Get([]byte(fmt.Sprintf("bank/entry/%08d", n-1))))
+	growth := atomic.LoadUint64(&peakHeap) - baseHeap
+	if atomic.LoadUint64(&peakHeap) < baseHeap {
+		growth = 0
+	}
+	require.Less(t, growth, uint64(maxHeapGrowth), "heap grew by %d bytes reading a genesis of %d bytes", growth, size)
+}
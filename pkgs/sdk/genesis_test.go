@@ -0,0 +1,101 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	dbm "github.com/gnolang/gno/pkgs/db"
+)
+
+// kvGenesis is the genesis module of a key value store: its genesis state
+// is an object of the values by key, which must not be empty, or none.
+type kvGenesis struct {
+	prefix string
+}
+
+func (gm kvGenesis) ValidateGenesis(state json.RawMessage) error {
+	if state == nil {
+		return nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal(state, &values); err != nil {
+		return err
+	}
+	for key, value := range values {
+		if value == "" {
+			return fmt.Errorf("empty value of key %s", key)
+		}
+	}
+	return nil
+}
+
+func (gm kvGenesis) InitGenesis(ctx Context, state json.RawMessage) {
+	if state == nil {
+		return
+	}
+	var values map[string]string
+	if err := json.Unmarshal(state, &values); err != nil {
+		panic(err)
+	}
+	for _, key := range SortedKeys(values).([]string) {
+		ctx.Store(mainKey).Set([]byte(gm.prefix+key), []byte(values[key]))
+	}
+}
+
+func TestGenesisModules(t *testing.T) {
+	newApp := func() *BaseApp {
+		app := newBaseApp(t.Name(), dbm.NewMemDB())
+		app.RegisterGenesisModule("bank", kvGenesis{"bank/"})
+		app.RegisterGenesisModule("vm", kvGenesis{"vm/"})
+		require.Panics(t, func() { app.RegisterGenesisModule("vm", kvGenesis{"vm/"}) })
+		require.Panics(t, func() { app.RegisterGenesisModule("", kvGenesis{}) })
+		app.SetInitChainer(func(ctx Context, req abci.RequestInitChain) abci.ResponseInitChain {
+			// the genesis states of the modules are written first.
+			require.Equal(t, []byte("1"), ctx.Store(mainKey).Get([]byte("bank/a")))
+			ctx.Store(mainKey).Set([]byte("init"), []byte("chainer"))
+			return abci.ResponseInitChain{}
+		})
+		require.NoError(t, app.LoadLatestVersion())
+		require.Panics(t, func() { app.RegisterGenesisModule("late", kvGenesis{}) })
+		return app
+	}
+
+	app := newApp()
+	app.InitChain(abci.RequestInitChain{
+		ChainID:  "test-chain",
+		AppState: json.RawMessage(`{"bank": {"a": "1", "b": "2"}, "vm": {"c": "3"}}`),
+	})
+	app.Commit()
+	st := app.cms.GetStore(mainKey)
+	require.Equal(t, []byte("1"), st.Get([]byte("bank/a")))
+	require.Equal(t, []byte("2"), st.Get([]byte("bank/b")))
+	require.Equal(t, []byte("3"), st.Get([]byte("vm/c")))
+	require.Equal(t, []byte("chainer"), st.Get([]byte("init")))
+
+	// with errors in both modules, both are reported, and nothing is
+	// written, not even by the valid entries.
+	app = newApp()
+	lastCommitID := app.LastCommitID()
+	err := func() (err interface{}) {
+		defer func() { err = recover() }()
+		app.InitChain(abci.RequestInitChain{
+			ChainID:  "test-chain",
+			AppState: json.RawMessage(`{"bank": {"a": "1", "b": ""}, "vm": ["c"]}`),
+		})
+		return nil
+	}()
+	require.NotNil(t, err)
+	require.Contains(t, err, "bank: empty value of key b")
+	require.Contains(t, err, "vm: json: cannot unmarshal array")
+	require.Nil(t, app.deliverState)
+	require.Equal(t, "", app.ChainID())
+	require.Equal(t, lastCommitID, app.LastCommitID())
+	st = app.cms.GetStore(mainKey)
+	for _, key := range []string{"bank/a", "init", string(mainChainIDKey)} {
+		require.Nil(t, st.Get([]byte(key)), key)
+	}
+}
@@ -1,28 +1,56 @@
 package sdk
 
 import (
+	goerrors "errors"
 	"fmt"
 	"regexp"
 
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/std"
 )
 
 var isAlphaNumeric = regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString
 
-// nolint - Mostly for testing
+var isChainID = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`).MatchString
+
+// DeriveModuleAddress returns the address of the account of the module
+// name, e.g. a fee collector or an escrow pool, which is the hash of the
+// name: it is stable, and no one holds a key for it.
+func DeriveModuleAddress(name string) crypto.Address {
+	return crypto.AddressFromPreimage([]byte(name))
+}
+
+// Check checks tx as CheckTx does, with the bytes of the tx encoder, but
+// with no cache. Mostly for testing.
 func (app *BaseApp) Check(tx Tx) (result Result) {
-	return app.runTx(RunTxModeCheck, nil, tx)
+	txBytes, err := app.txEncoder(tx)
+	if err != nil {
+		return ABCIResultFromError(std.ErrTxDecode(err.Error()))
+	}
+	return app.runTx(RunTxModeCheck, txBytes, tx)
 }
 
-// nolint - full tx execution
+// Simulate runs tx and discards its writes, e.g. to estimate its gas. By
+// default, it runs on a branch of the last committed state, so it does not
+// see the txs checked since, and is stable while CheckTx runs; see
+// SetSimulateFromCommitted.
 func (app *BaseApp) Simulate(txBytes []byte, tx Tx) (result Result) {
-	return app.runTx(RunTxModeSimulate, txBytes, tx)
+	if app.simulateFromCheckState {
+		return app.runTx(RunTxModeSimulate, txBytes, tx)
+	}
+	return app.simulateQuery(app.getQueryState(), txBytes, tx, crypto.Address{})
 }
 
-// nolint
+// Deliver delivers tx as DeliverTx does, with the bytes of the tx encoder.
+// Mostly for testing.
 func (app *BaseApp) Deliver(tx Tx) (result Result) {
-	return app.runTx(RunTxModeDeliver, nil, tx)
+	txBytes, err := app.txEncoder(tx)
+	if err != nil {
+		return ABCIResultFromError(std.ErrTxDecode(err.Error()))
+	}
+	return app.runTx(RunTxModeDeliver, txBytes, tx)
 }
 
 // Context with current {check, deliver}State of the app
@@ -30,33 +58,51 @@ func (app *BaseApp) Deliver(tx Tx) (result Result) {
 func (app *BaseApp) NewContext(mode RunTxMode, header abci.Header) Context {
 	if mode == RunTxModeCheck {
 		return NewContext(mode, app.checkState.ms, header, app.logger).
-			WithMinGasPrices(app.minGasPrices)
+			WithMinGasPrices(app.minGasPrices).
+			WithFeatures(app.features)
 	}
 
-	return NewContext(mode, app.deliverState.ms, header, app.logger)
+	return NewContext(mode, app.deliverState.ms, header, app.logger).
+		WithFeatures(app.features)
 }
 
+// ABCIError returns the innermost abci.Error wrapped by err, e.g. with
+// errors.Wrap() or fmt.Errorf("%w"), or else the message of err. A
+// std.CodedError is returned as is, so that clients get its codespace and
+// code.
 func ABCIError(err error) abci.Error {
 	if err == nil {
 		return nil
 	}
 	err = errors.Cause(err) // unwrap
-	abcierr, ok := err.(abci.Error)
-	if !ok {
-		return abci.StringError(err.Error())
-	} else {
+	var abcierr abci.Error
+	if goerrors.As(err, &abcierr) {
 		return abcierr
 	}
+	return abci.StringError(err.Error())
+}
+
+// ErrorIs returns whether the error of res matches target, as with the
+// standard errors.Is, e.g. ErrorIs(res, std.OutOfGasError{}).
+func ErrorIs(res Result, target error) bool {
+	if res.Error == nil {
+		return false
+	}
+	return goerrors.Is(res.Error, target)
 }
 
+// ABCIResultFromError returns a Result for err, whose log is err with its
+// traces and stack, formatted with %+v.
 func ABCIResultFromError(err error) (res Result) {
 	res.Error = ABCIError(err)
-	res.Log = fmt.Sprintf("%#v", err)
+	res.Log = fmt.Sprintf("%+v", err)
 	return
 }
 
+// ABCIResponseQueryFromError returns a ResponseQuery for err, whose log is
+// err with its traces and stack, formatted with %+v.
 func ABCIResponseQueryFromError(err error) (res abci.ResponseQuery) {
 	res.Error = ABCIError(err)
-	res.Log = fmt.Sprintf("%#v", err)
+	res.Log = fmt.Sprintf("%+v", err)
 	return
 }
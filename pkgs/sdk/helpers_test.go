@@ -0,0 +1,76 @@
+package sdk
+
+import (
+	goerrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+func TestABCIErrorWrapped(t *testing.T) {
+	err := std.ErrUnauthorized("not signer")
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"std", err},
+		{"wrapped", errors.Wrap(err, "handling msg %d", 1)},
+		{"wrapped twice", errors.Wrap(errors.Wrap(err, "inner"), "outer")},
+		{"nested", errors.Wrap(errors.NewWithData(errors.Wrap(err, "inner")), "outer")},
+		{"std wrapped", fmt.Errorf("handling msg: %w", errors.Wrap(err, "inner"))},
+	} {
+		assert.Equal(t, std.UnauthorizedError{}, ABCIError(tc.err), tc.name)
+		assert.Equal(t, std.UnauthorizedError{}, ABCIResultFromError(tc.err).Error, tc.name)
+	}
+
+	// other errors are kept as their message.
+	assert.Equal(t, abci.StringError("failed"), ABCIError(errors.Wrap(fmt.Errorf("failed"), "outer")))
+	assert.Nil(t, ABCIError(nil))
+}
+
+func TestABCIResultFromErrorLog(t *testing.T) {
+	res := ABCIResultFromError(errors.Wrap(std.ErrUnauthorized("not signer"), "handling msg %d", 1))
+	assert.Contains(t, res.Log, "not signer")
+	assert.Contains(t, res.Log, "handling msg 1")
+	assert.Contains(t, res.Log, "Stack Trace:")
+	assert.Contains(t, res.Log, "sdk.TestABCIResultFromErrorLog")
+}
+
+func TestErrorIs(t *testing.T) {
+	err := std.ErrOutOfGas("no gas left")
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"std", err},
+		{"wrapped", errors.Wrap(err, "handling msg %d", 1)},
+		{"nested", errors.Wrap(errors.NewWithData(errors.Wrap(err, "inner")), "outer")},
+		{"std wrapped", fmt.Errorf("handling msg: %w", errors.Wrap(err, "inner"))},
+	} {
+		assert.True(t, goerrors.Is(tc.err, std.OutOfGasError{}), tc.name)
+		assert.False(t, goerrors.Is(tc.err, std.GasOverflowError{}), tc.name)
+		res := ABCIResultFromError(tc.err)
+		assert.True(t, ErrorIs(res, std.OutOfGasError{}), tc.name)
+		assert.False(t, ErrorIs(res, std.InternalError{}), tc.name)
+	}
+
+	var ok Result
+	assert.False(t, ErrorIs(ok, std.OutOfGasError{}))
+	assert.False(t, ErrorIs(ABCIResultFromError(goerrors.New("other")), std.OutOfGasError{}))
+}
+
+func TestABCIErrorCoded(t *testing.T) {
+	std.Register("sdktest", 1, "sdk test failure")
+	res := ABCIResultFromError(errors.Wrap(std.NewError("sdktest", 1, "msg"), "outer"))
+	assert.Equal(t, std.CodedError{Codespace: "sdktest", Code: 1}, res.Error)
+
+	codespace, code, log := std.ABCIInfo(res.Error, false)
+	assert.Equal(t, "sdktest", codespace)
+	assert.Equal(t, uint32(1), code)
+	assert.Equal(t, "sdk test failure", log)
+}
@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// indexResult is the canonical JSON rendering of a tx result, see
+// ResponseIndexJSON.
+type indexResult struct {
+	Code      uint32            `json:"code"`
+	Codespace string            `json:"codespace"`
+	Log       string            `json:"log"`
+	GasWanted int64             `json:"gas_wanted"`
+	GasUsed   int64             `json:"gas_used"`
+	MsgData   []indexMsgData    `json:"msg_data"`
+	Data      []byte            `json:"data"`
+	Events    []json.RawMessage `json:"events"`
+}
+
+type indexMsgData struct {
+	Route     string `json:"route"`
+	Type      string `json:"type"`
+	Data      []byte `json:"data"`
+	NumEvents int    `json:"num_events"`
+}
+
+type indexEvent struct {
+	Type       string                `json:"type"`
+	Attributes []abci.EventAttribute `json:"attributes"`
+}
+
+// MarshalIndexJSON returns the canonical JSON rendering of the result, for
+// tx indexers and other external consumers. See ResponseIndexJSON.
+func (res Result) MarshalIndexJSON() []byte {
+	return ResponseIndexJSON(res.ResponseBase, res.GasWanted, res.GasUsed)
+}
+
+// ResponseIndexJSON returns the canonical JSON rendering of the response of
+// a tx, e.g. of DeliverTx, of gasWanted and gasUsed: an object of, in
+// order, the code and codespace of its error (0 and "" on success), its
+// log, its gas, its framed msg data (see MsgData) or else its raw data, and
+// its events. The attributes of the typed events are sorted by key, and
+// the other events are in amino JSON, or null if they cannot be encoded.
+// The rendering does not depend on the Go formatting of values, so it is
+// the same across versions.
+func ResponseIndexJSON(res abci.ResponseBase, gasWanted, gasUsed int64) []byte {
+	ir := indexResult{
+		Log:       res.Log,
+		GasWanted: gasWanted,
+		GasUsed:   gasUsed,
+		Events:    indexEvents(res.Events),
+	}
+	if res.Error != nil {
+		ir.Codespace, ir.Code, _ = std.ABCIInfo(res.Error, false)
+	}
+	if msgData, err := ParseMsgData(res.Data); err == nil && len(res.Data) > 0 {
+		ir.MsgData = make([]indexMsgData, len(msgData))
+		for i, md := range msgData {
+			ir.MsgData[i] = indexMsgData{md.Route, md.Type, md.Data, md.NumEvents}
+		}
+	} else {
+		ir.Data = res.Data
+	}
+	bz, err := json.Marshal(ir)
+	if err != nil {
+		panic(err) // the values are all encodable.
+	}
+	return bz
+}
+
+// indexEvents returns the canonical JSON renderings of events, which are
+// never nil.
+func indexEvents(events []Event) []json.RawMessage {
+	rendered := make([]json.RawMessage, len(events))
+	for i, ev := range events {
+		rendered[i] = indexEventJSON(ev)
+	}
+	return rendered
+}
+
+// indexEventJSON returns the canonical JSON rendering of ev.
+func indexEventJSON(ev Event) json.RawMessage {
+	tev, ok := ev.(abci.TypedEvent)
+	if !ok {
+		bz, err := amino.MarshalJSONAny(ev)
+		if err != nil {
+			return json.RawMessage("null")
+		}
+		return bz
+	}
+	attrs := make([]abci.EventAttribute, len(tev.Attributes))
+	copy(attrs, tev.Attributes)
+	sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	bz, err := json.Marshal(indexEvent{tev.Type, attrs})
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+// eventsIndexJSON returns the canonical JSON rendering of a list of events,
+// e.g. for the logs of msgs.
+func eventsIndexJSON(events []Event) string {
+	bz, err := json.Marshal(indexEvents(events))
+	if err != nil {
+		panic(err)
+	}
+	return string(bz)
+}
@@ -0,0 +1,108 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+func TestResultIndexJSON(t *testing.T) {
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			newCtx = ctx.WithGasMeter(store.NewGasMeter(100))
+			return newCtx, Result{GasWanted: 100}, false
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			counter := msg.(msgCounter).Counter
+			ctx.GasMeter().ConsumeGas(counter*10, "test")
+			switch counter {
+			case 0:
+				return Result{ResponseBase: abci.ResponseBase{Error: ABCIError(std.ErrUnauthorized("zero counter")), Log: "zero counter"}}
+			case 7:
+				panic([]int{7})
+			}
+			// the attributes are sorted by key.
+			ctx.EventLogger().EmitEvent(abci.NewEvent("counter",
+				abci.NewAttribute("value", string(rune('0'+counter))),
+				abci.NewAttribute("counter", "true").NoIndex(),
+			))
+			return Result{ResponseBase: abci.ResponseBase{Data: []byte{byte(counter)}}}
+		}))
+	}
+	app := setupBaseApp(t, anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	cases := []struct {
+		name   string
+		tx     std.Tx
+		golden string
+	}{
+		{"success", newTxCounter(0, 1, 2), `{
+			"code": 0, "codespace": "",
+			"log": "msg:0,success:true,log:,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"true\",\"index\":false},{\"key\":\"value\",\"value\":\"1\",\"index\":true}]}]\nmsg:1,success:true,log:,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"true\",\"index\":false},{\"key\":\"value\",\"value\":\"1\",\"index\":true}]},{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"true\",\"index\":false},{\"key\":\"value\",\"value\":\"2\",\"index\":true}]}]",
+			"gas_wanted": 100, "gas_used": 30,
+			"msg_data": [
+				{"route": "msgCounter", "type": "counter1", "data": "AQ==", "num_events": 1},
+				{"route": "msgCounter", "type": "counter1", "data": "Ag==", "num_events": 1}
+			],
+			"data": null,
+			"events": [
+				{"type": "counter", "attributes": [{"key": "counter", "value": "true", "index": false}, {"key": "value", "value": "1", "index": true}]},
+				{"type": "counter", "attributes": [{"key": "counter", "value": "true", "index": false}, {"key": "value", "value": "2", "index": true}]},
+				{"type": "tx", "attributes": [{"key": "index", "value": "0", "index": true}]}
+			]
+		}`},
+		{"typed error", newTxCounter(1, 1, 0, 2), `{
+			"code": 4, "codespace": "sdk",
+			"log": "msg:0,success:true,log:,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"true\",\"index\":false},{\"key\":\"value\",\"value\":\"1\",\"index\":true}]}]\nmsg:1,success:false,log:zero counter,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"true\",\"index\":false},{\"key\":\"value\",\"value\":\"1\",\"index\":true}]}]",
+			"gas_wanted": 100, "gas_used": 10,
+			"msg_data": [
+				{"route": "msgCounter", "type": "counter1", "data": "AQ==", "num_events": 1},
+				{"route": "msgCounter", "type": "counter1", "data": null, "num_events": 0}
+			],
+			"data": null,
+			"events": [{"type": "tx", "attributes": [{"key": "index", "value": "1", "index": true}]}]
+		}`},
+		{"out of gas", newTxCounter(2, 9, 9), `{
+			"code": 12, "codespace": "sdk",
+			"log": "out of gas, gasWanted: 100, gasUsed: 180 location: test",
+			"gas_wanted": 100, "gas_used": 180,
+			"msg_data": null, "data": null,
+			"events": [{"type": "tx", "attributes": [{"key": "index", "value": "2", "index": true}]}]
+		}`},
+		{"panic redacted", newTxCounter(3, 7), `{
+			"code": 1, "codespace": "sdk",
+			"log": "internal error (codespace sdk, code 1), see the node logs for tx 48533CA60988C97CE5C4A239C7CE7F17DD9D7646C12AF1306C34BF26EB38858A",
+			"gas_wanted": 100, "gas_used": 70,
+			"msg_data": null, "data": null,
+			"events": [{"type": "tx", "attributes": [{"key": "index", "value": "3", "index": true}]}]
+		}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(tc.tx)})
+			bz := ResponseIndexJSON(res.ResponseBase, res.GasWanted, res.GasUsed)
+			require.JSONEq(t, tc.golden, string(bz))
+			// the rendering is canonical.
+			require.Equal(t, bz, Result{ResponseBase: res.ResponseBase, GasWanted: res.GasWanted, GasUsed: res.GasUsed}.MarshalIndexJSON())
+		})
+	}
+
+	// the data of apps with SetLegacyMsgData, and the events which are not
+	// typed, are rendered as they are.
+	res := Result{ResponseBase: abci.ResponseBase{Data: []byte("raw"), Events: []Event{abci.EventString("legacy")}}}
+	require.JSONEq(t, `{
+		"code": 0, "codespace": "", "log": "", "gas_wanted": 0, "gas_used": 0,
+		"msg_data": null, "data": "cmF3",
+		"events": [{"@type": "/abci.EventString", "value": "legacy"}]
+	}`, string(res.MarshalIndexJSON()))
+}
@@ -1,6 +1,11 @@
 package sdk
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/errors"
+)
 
 // An Invariant is a function which tests a particular invariant.
 // The invariant returns a descriptive message about what happened
@@ -20,3 +25,40 @@ type InvariantRegistry interface {
 func FormatInvariant(module, name, msg string) string {
 	return fmt.Sprintf("%s: %s invariant\n%s\n", module, name, msg)
 }
+
+// InvariantRoute is an invariant registered by a module.
+type InvariantRoute struct {
+	ModuleName string
+	Route      string
+	Invar      Invariant
+}
+
+// InvariantRoutes is an InvariantRegistry which keeps the registered
+// invariants in order.
+type InvariantRoutes struct {
+	routes []InvariantRoute
+}
+
+var _ InvariantRegistry = (*InvariantRoutes)(nil)
+
+// RegisterRoute implements InvariantRegistry.
+func (ir *InvariantRoutes) RegisterRoute(moduleName, route string, invar Invariant) {
+	ir.routes = append(ir.routes, InvariantRoute{moduleName, route, invar})
+}
+
+// Routes returns the registered invariants.
+func (ir *InvariantRoutes) Routes() []InvariantRoute {
+	return append([]InvariantRoute(nil), ir.routes...)
+}
+
+// AssertInvariants runs all the invariants, and returns the messages of the
+// broken ones as a single error, or nil if none is broken.
+func (ir *InvariantRoutes) AssertInvariants(ctx Context) error {
+	var errs []error
+	for _, route := range ir.routes {
+		if msg, broken := route.Invar(ctx); broken {
+			errs = append(errs, errors.New("%s", strings.TrimSpace(msg)))
+		}
+	}
+	return errors.Join(errs...)
+}
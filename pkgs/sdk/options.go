@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"fmt"
+	"time"
 
 	dbm "github.com/gnolang/gno/pkgs/db"
 	"github.com/gnolang/gno/pkgs/store"
@@ -10,12 +11,18 @@ import (
 // File for storing in-package BaseApp optional functions,
 // for options that need access to non-exported fields of the BaseApp
 
-// SetStoreOptions sets store options on the multistore associated with the app
+// SetStoreOptions sets store options on the multistore associated with the app.
+// A KeepRecent of 0 is raised to 1 when the app is loaded, see
+// SetPruningOptions.
 func SetStoreOptions(opts store.StoreOptions) func(*BaseApp) {
 	return func(bap *BaseApp) { bap.cms.SetStoreOptions(opts) }
 }
 
-// SetPruningOptions sets pruning options on the multistore associated with the app
+// SetPruningOptions sets pruning options on the multistore associated with the app.
+// A KeepRecent of 0 is raised to 1 when the app is loaded, and logged, so
+// that queries running on the last committed version while the next one
+// is committed do not read a pruned version: store.PruneEverything then
+// keeps the version before the last too.
 func SetPruningOptions(opts store.PruningOptions) func(*BaseApp) {
 	return func(bap *BaseApp) {
 		sopts := bap.cms.GetStoreOptions()
@@ -44,6 +51,236 @@ func SetHaltTime(haltTime uint64) func(*BaseApp) {
 	return func(bap *BaseApp) { bap.setHaltTime(haltTime) }
 }
 
+// SetBlockTimeValidation returns a BaseApp option function that makes
+// BeginBlock panic on block times which are not after the time of the last
+// block, or more than maxDrift ahead of the local clock. A zero maxDrift
+// disables the validation.
+func SetBlockTimeValidation(maxDrift time.Duration) func(*BaseApp) {
+	if maxDrift < 0 {
+		panic(fmt.Sprintf("invalid maximum block time drift: %v", maxDrift))
+	}
+	return func(bap *BaseApp) { bap.setBlockTimeValidation(maxDrift) }
+}
+
+// SetDebugErrors returns a BaseApp option function that sets whether the
+// details of internal errors, e.g. panic messages and stacks, are returned in
+// the logs of tx results, e.g. for devnets. By default, they are only logged.
+// The errors of tx results do not depend on it, see BaseApp.DeliverTx.
+func SetDebugErrors(debug bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setDebugErrors(debug) }
+}
+
+// SetGasBreakdown returns a BaseApp option function that sets whether tx
+// results, including the simulate query, have the gas used by category of
+// gas descriptors, e.g. "store" for "store:ReadFlat". See
+// store.GasCategory.
+func SetGasBreakdown(enabled bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setGasBreakdown(enabled) }
+}
+
+// SetTxGasMeter returns a BaseApp option function that sets whether the
+// gas meter of txs is limited to the gas wanted by their fee before the ante
+// handler runs, which is the default. Apps whose ante handler sets the gas
+// meter itself may disable it.
+func SetTxGasMeter(enabled bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setTxGasMeter(enabled) }
+}
+
+// SetDiagnosticsListener returns a BaseApp option function that starts an
+// HTTP listener on addr, serving the pprof profiles at /debug/pprof/, the
+// state of the app at /debug/app, and the stats of the diagnostics sources,
+// e.g. /debug/gno_store. The state is updated on Commit. An address without
+// host, e.g. ":6060", listens on DefaultDiagnosticsHost. The listener is
+// shut down by Close.
+func SetDiagnosticsListener(addr string) func(*BaseApp) {
+	return func(bap *BaseApp) {
+		if err := bap.startDiagnostics(addr); err != nil {
+			panic(fmt.Sprintf("cannot start diagnostics listener: %v", err))
+		}
+	}
+}
+
+// SetQueryLimits returns a BaseApp option function that limits the queries
+// of Query, e.g. of public RPC nodes. Responses larger than maxResponseBytes
+// fail with a ResponseTooLargeError, and queries beyond maxConcurrent ones
+// running at once with a TooManyQueriesError, which may be retried. The
+// maximum response size of the paths matching the patterns of perPathLimits
+// overrides maxResponseBytes, e.g. "/.store/*/subspace" for the subspace
+// queries of all stores, where "*" matches any segment and the longest
+// matching pattern applies. Zero disables a limit. Internal callers, e.g.
+// Simulate, are not limited. See SetQueryStepLimits for the iterations of
+// custom queries.
+func SetQueryLimits(maxResponseBytes int, maxConcurrent int, perPathLimits map[string]int) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setQueryLimits(maxResponseBytes, maxConcurrent, perPathLimits) }
+}
+
+// SetQueryStepLimits returns a BaseApp option function that limits the
+// iterations of the custom queries of Query, routed to the query handlers
+// of the modules, which have no gas: each iterator creation and Next
+// consumes a step, and queries exceeding maxSteps fail with a
+// QueryTooExpensiveError rather than running unbounded. The step budget of
+// the paths matching the patterns of perPathSteps overrides maxSteps, as
+// with SetQueryLimits. Zero disables a limit. CheckTx and DeliverTx, which
+// have gas, are not limited.
+func SetQueryStepLimits(maxSteps int, perPathSteps map[string]int) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setQueryStepLimits(maxSteps, perPathSteps) }
+}
+
+// SetLegacyMsgData returns a BaseApp option function that sets whether the
+// data of tx results is the concatenation of the data of the results of
+// their msgs, as it used to be, instead of their MsgData. See
+// ParseMsgData.
+func SetLegacyMsgData(enabled bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setLegacyMsgData(enabled) }
+}
+
+// SetDeterminismCheck returns a BaseApp option function that sets whether
+// DeliverTx runs each tx twice, first on a discarded branch of the state,
+// and fails it with an InternalError reporting the first divergence if the
+// two executions write to the stores in a different order, e.g. because a
+// handler ranges over a map (see IterateSorted), or emit different events.
+// It is meant for tests and devnets: it doubles the cost of the txs, and
+// handlers which keep state outside of the stores are run twice.
+func SetDeterminismCheck(enabled bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setDeterminismCheck(enabled) }
+}
+
+// SetSimulateFromCommitted returns a BaseApp option function that sets
+// whether Simulate runs txs on a new branch of the last committed state,
+// which is the default, or on a branch of the check state, as it used to.
+// From the committed state, simulations do not see the txs checked since
+// the last block, e.g. the previous txs of the same account, but their
+// results do not depend on the txs checked meanwhile, and they do not race
+// with CheckTx.
+func SetSimulateFromCommitted(enabled bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setSimulateFromCommitted(enabled) }
+}
+
+// SetCheckTxCache returns a BaseApp option function that makes CheckTx
+// cache its responses to new txs by hash of the tx bytes, in a LRU cache of
+// size entries which expire after ttl, or never if ttl is 0. A tx with the
+// same bytes as a cached one which failed regardless of the state, e.g. to
+// decode, gets the same response, and one with the same bytes as a cached
+// one which succeeded gets a TxInCacheError. The successes are removed on
+// Commit. Rechecks and DeliverTx are never cached, but the msgs of txs
+// which passed ValidateBasic in CheckTx are not validated again by them,
+// see SetAlwaysValidateBasic. A zero size disables the cache, which is the
+// default.
+func SetCheckTxCache(size int, ttl time.Duration) func(*BaseApp) {
+	if size < 0 || ttl < 0 {
+		panic(fmt.Sprintf("invalid CheckTx cache size %d or ttl %v", size, ttl))
+	}
+	return func(bap *BaseApp) { bap.setCheckTxCache(size, ttl) }
+}
+
+// SetAlwaysValidateBasic returns a BaseApp option function that makes
+// rechecks and DeliverTx run ValidateBasic on the msgs of all txs, including
+// those which passed it in a CheckTx of the same bytes in the CheckTx cache,
+// e.g. for operators wary of msgs whose ValidateBasic is not a pure
+// function of their bytes.
+func SetAlwaysValidateBasic(always bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setAlwaysValidateBasic(always) }
+}
+
+// SetMinRetainBlocks returns a BaseApp option function that sets the
+// minimum number of recent blocks to retain, from which Commit returns the
+// height below which blocks may be pruned. It is lowered so as to retain
+// the blocks after the oldest recent state kept by the pruning options, and
+// after the last state-sync waypoint (see store.PruningOptions.KeepEvery),
+// and is 0 if all states are kept. Zero, the default, retains all blocks.
+func SetMinRetainBlocks(minRetainBlocks int64) func(*BaseApp) {
+	if minRetainBlocks < 0 {
+		panic(fmt.Sprintf("invalid minimum retain blocks: %d", minRetainBlocks))
+	}
+	return func(bap *BaseApp) { bap.setMinRetainBlocks(minRetainBlocks) }
+}
+
+// SetValidatorUpdatePolicy returns a BaseApp option function that makes
+// EndBlock enforce policy on the validator updates of the end blocker, see
+// ValidateValidatorUpdates: updates which breach it are all rejected and
+// logged, and the validator set is left as is. The policy is not enforced
+// if the validator set is unknown, e.g. for a chain started before it was
+// stored.
+func SetValidatorUpdatePolicy(policy UpdatePolicy) func(*BaseApp) {
+	if err := policy.ValidateBasic(); err != nil {
+		panic(fmt.Sprintf("invalid validator update policy: %v", err))
+	}
+	return func(bap *BaseApp) { bap.setValidatorUpdatePolicy(policy) }
+}
+
+// SetCrashReporter returns a BaseApp option function that makes panics of
+// InitChain, BeginBlock and EndBlock, which are consensus failures, call
+// reporter with their CrashReport, e.g. a NewCrashFileReporter, before they
+// propagate. The reports are logged regardless.
+func SetCrashReporter(reporter CrashReporter) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setCrashReporter(reporter) }
+}
+
+// SetHandlerDeadline returns a BaseApp option function that enables the
+// local node protection mode: the handler of each simulated msg runs on a
+// goroutine, and the simulation fails with std.ErrHandlerTimeout if the
+// handler does not return within deadline, e.g. stuck in a loop which uses
+// no gas. The goroutine of the handler is abandoned, still running, on its
+// own cache branch of the stores and gas meter.
+//
+// As wall-clock timeouts differ between nodes, delivered msgs never time
+// out: their execution is bounded by an ExecutionMeter instead, see
+// SetExecutionMeter. Zero, the default, runs the handlers without a
+// deadline.
+func SetHandlerDeadline(deadline time.Duration) func(*BaseApp) {
+	if deadline < 0 {
+		panic(fmt.Sprintf("invalid handler deadline: %s", deadline))
+	}
+	return func(bap *BaseApp) { bap.setHandlerDeadline(deadline) }
+}
+
+// SetExecutionMeter returns a BaseApp option function that makes the
+// handler of each delivered or simulated msg run in the context returned
+// by meter, which bounds its execution deterministically, e.g. with the
+// max number of instructions of a VM.
+func SetExecutionMeter(meter ExecutionMeter) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setExecutionMeter(meter) }
+}
+
+// SetEventJournal returns a BaseApp option function that makes Commit
+// write the events and tx results of each block to an event journal in db,
+// read with ReadEventJournal, e.g. by indexers which resume from their own
+// cursor after a restart. The entries of the blocks older than the last
+// retainBlocks are pruned, or none if it is 0. The journal is disabled by
+// default. The app closes db on Close.
+func SetEventJournal(db dbm.DB, retainBlocks int64) func(*BaseApp) {
+	if db == nil {
+		panic("nil event journal DB")
+	}
+	if retainBlocks < 0 {
+		panic(fmt.Sprintf("invalid event journal retain blocks: %d", retainBlocks))
+	}
+	return func(bap *BaseApp) { bap.setEventJournal(db, retainBlocks) }
+}
+
+// SetSkipIntegrityCheck returns a BaseApp option function that sets
+// whether LoadLatestVersion skips VerifyIntegrity, e.g. for tools which
+// inspect a corrupted DB. It is run by default.
+func SetSkipIntegrityCheck(skip bool) func(*BaseApp) {
+	return func(bap *BaseApp) { bap.setSkipIntegrityCheck(skip) }
+}
+
+// SetCommitHashVersion returns a BaseApp option function that switches the
+// app hash of the blocks from height on to the algorithm of version, e.g.
+// at the height of an upgrade agreed by the validators. The version of each
+// block is recorded with it, and the blocks after height keep it without
+// the option, so that the nodes which sync from genesis, or restart after
+// height, compute the same app hashes. See store.CommitHashVersion.
+func SetCommitHashVersion(height int64, version store.CommitHashVersion) func(*BaseApp) {
+	if height < 1 {
+		panic(fmt.Sprintf("invalid commit hash version height: %d", height))
+	}
+	if err := version.Validate(); err != nil {
+		panic(err)
+	}
+	return func(bap *BaseApp) { bap.cms.SetCommitHashVersion(height, version) }
+}
+
 func (app *BaseApp) SetName(name string) {
 	if app.sealed {
 		panic("SetName() on sealed BaseApp")
@@ -87,6 +324,15 @@ func (app *BaseApp) SetBeginBlocker(beginBlocker BeginBlocker) {
 	app.beginBlocker = beginBlocker
 }
 
+// SetEvidenceHandler sets the handler called in BeginBlock, after the
+// BeginBlocker, for each evidence of RequestBeginBlock.ByzantineValidators.
+func (app *BaseApp) SetEvidenceHandler(evidenceHandler EvidenceHandler) {
+	if app.sealed {
+		panic("SetEvidenceHandler() on sealed BaseApp")
+	}
+	app.evidenceHandler = evidenceHandler
+}
+
 func (app *BaseApp) SetEndBlocker(endBlocker EndBlocker) {
 	if app.sealed {
 		panic("SetEndBlocker() on sealed BaseApp")
@@ -94,9 +340,86 @@ func (app *BaseApp) SetEndBlocker(endBlocker EndBlocker) {
 	app.endBlocker = endBlocker
 }
 
+// SetModuleEndBlockers sets the end blockers of the modules of the app,
+// which run in order before the EndBlocker set with SetEndBlocker, if any.
+// Their responses are merged in that order:
+//
+//   - the validator updates are appended; an update of a validator which an
+//     earlier end blocker updated with another power is a conflict, which
+//     panics, and an equal one is dropped;
+//   - the consensus params updates are applied, so the last end blocker
+//     which sets a subparam wins;
+//   - the events are appended.
+func (app *BaseApp) SetModuleEndBlockers(endBlockers ...EndBlocker) {
+	if app.sealed {
+		panic("SetModuleEndBlockers() on sealed BaseApp")
+	}
+	app.moduleEndBlockers = endBlockers
+}
+
+// SetWriteSetRecorder sets the function called on Commit with the ops
+// written to the multistore by the block, i.e. by InitChain for the first
+// block, BeginBlock, the txs and EndBlock, in a deterministic order: the
+// consensus params first, then by store name and key. Replaying them on
+// the state of the previous block yields the same app hash. The writes of
+// the BaseApp to the base store on Commit, which is not merkleized, are not
+// included. Recording is disabled by default.
+func (app *BaseApp) SetWriteSetRecorder(recorder WriteSetRecorder) {
+	if app.sealed {
+		panic("SetWriteSetRecorder() on sealed BaseApp")
+	}
+	app.writeSetRecorder = recorder
+}
+
+// SetCheckStateRefresher sets the function run on the check state each time
+// it is reset, i.e. on initialization, InitChain, Commit, and
+// RefreshCheckState.
+func (app *BaseApp) SetCheckStateRefresher(refresher CheckStateRefresher) {
+	if app.sealed {
+		panic("SetCheckStateRefresher() on sealed BaseApp")
+	}
+	app.checkStateRefresher = refresher
+}
+
+// SetTxDecoder sets the decoder of the txs of CheckTx, DeliverTx and the
+// simulate query, which is DefaultTxDecoder by default. If the decoder or
+// the encoder is set, they must round-trip a probe tx when the BaseApp is
+// loaded. See SetTxEncoder.
+func (app *BaseApp) SetTxDecoder(txDecoder TxDecoder) {
+	if app.sealed {
+		panic("SetTxDecoder() on sealed BaseApp")
+	}
+	app.txDecoder = txDecoder
+	app.txCodecSet = true
+}
+
+// SetTxEncoder sets the encoder of txs, as the tx decoder decodes them,
+// which is DefaultTxEncoder by default. It encodes the txs of Check and
+// Deliver, so that their context has the bytes and hash the node would
+// have. See SetTxDecoder.
+func (app *BaseApp) SetTxEncoder(txEncoder TxEncoder) {
+	if app.sealed {
+		panic("SetTxEncoder() on sealed BaseApp")
+	}
+	app.txEncoder = txEncoder
+	app.txCodecSet = true
+}
+
 func (app *BaseApp) SetAnteHandler(ah AnteHandler) {
 	if app.sealed {
 		panic("SetAnteHandler() on sealed BaseApp")
 	}
 	app.anteHandler = ah
 }
+
+// AddRecoveryHandler adds a handler of the panics of txs, tried in the
+// order added before the default recovery, which fails the tx with an
+// internal error of the panic value and stack. The error and log of the
+// result of the handler are those of the tx, and internal errors are
+// redacted as any other, see SetDebugErrors.
+func (app *BaseApp) AddRecoveryHandler(handler RecoveryHandler) {
+	if app.sealed {
+		panic("AddRecoveryHandler() on sealed BaseApp")
+	}
+	app.recoveryHandlers = append(app.recoveryHandlers, handler)
+}
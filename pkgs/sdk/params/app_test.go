@@ -0,0 +1,279 @@
+package params
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/log"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/sdk/auth"
+	"github.com/gnolang/gno/pkgs/sdk/bank"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/iavl"
+)
+
+const testChainID = "test-chain-id"
+
+// newTestApp returns a BaseApp with the auth ante handler, the bank handler
+// and the params handler of authority, where every address in genesis
+// starts with 10000atom. The max memo bytes of the ante handler is the
+// param "max_memo_bytes" of the subspace "auth", if set, and its global min
+// gas prices are the param auth.ParamGlobalMinGasPrices at the beginning of
+// the block.
+func newTestApp(t *testing.T, authority crypto.Address, genesis []crypto.Address) (*sdk.BaseApp, Subspace) {
+	return newTestAppWithState(t, authority, genesis, nil)
+}
+
+// newTestAppWithState is as newTestApp, with the app state of InitChain,
+// where the genesis params of the subspace "auth" are under "auth".
+func newTestAppWithState(t *testing.T, authority crypto.Address, genesis []crypto.Address, appState interface{}) (*sdk.BaseApp, Subspace) {
+	db := dbm.NewMemDB()
+	mainKey := store.NewKVStoreKey("main")
+	baseKey := store.NewKVStoreKey("base")
+
+	app := sdk.NewBaseApp("test", log.NewNopLogger(), db, baseKey, mainKey)
+	app.MountStoreWithDB(mainKey, iavl.StoreConstructor, db)
+	app.MountStoreWithDB(baseKey, dbadapter.StoreConstructor, db)
+
+	acck := auth.NewAccountKeeper(mainKey, std.ProtoBaseAccount)
+	bankk := bank.NewBankKeeper(acck)
+	paramsk := NewParamsKeeper(mainKey, authority)
+	authSpace := paramsk.Subspace("auth", NewKeyTable().
+		RegisterParam("max_memo_bytes", int64(0), func(value interface{}) error {
+			if value.(int64) < 0 {
+				return errors.New("negative max memo bytes")
+			}
+			return nil
+		}).
+		RegisterParam(auth.ParamGlobalMinGasPrices, std.DecCoins{}, auth.ValidateGlobalMinGasPrices))
+	app.RegisterGenesisModule("auth", authSpace)
+
+	// the changes of the global min gas prices take effect in the next
+	// block.
+	var globalMinGasPrices std.DecCoins
+	app.SetBeginBlocker(func(ctx sdk.Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+		globalMinGasPrices = nil
+		authSpace.Get(ctx, auth.ParamGlobalMinGasPrices, &globalMinGasPrices)
+		return abci.ResponseBeginBlock{}
+	})
+
+	app.SetInitChainer(func(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
+		for _, addr := range genesis {
+			acc := acck.NewAccountWithAddress(ctx, addr)
+			acck.SetAccount(ctx, acc)
+			require.NoError(t, bankk.SetCoins(ctx, addr, std.NewCoins(std.NewCoin("atom", 10000))))
+		}
+		return abci.ResponseInitChain{}
+	})
+	anteHandler := auth.NewAnteHandler(acck, bankk, auth.DefaultSigVerificationGasConsumer)
+	app.SetAnteHandler(func(ctx sdk.Context, tx std.Tx, simulate bool) (sdk.Context, sdk.Result, bool) {
+		params := auth.DefaultParams()
+		authSpace.Get(ctx, "max_memo_bytes", &params.MaxMemoBytes)
+		params.GlobalMinGasPrices = globalMinGasPrices
+		ctx = ctx.WithValue(auth.AuthParamsContextKey{}, params)
+		return anteHandler(ctx, tx, simulate)
+	})
+	app.Router().AddRoute("bank", bank.NewHandler(bankk))
+	app.Router().AddRoute("params", NewHandler(paramsk))
+	require.NoError(t, app.LoadLatestVersion())
+
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID, AppState: appState})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: testChainID, Height: 1}})
+	return app, authSpace
+}
+
+// deliverMsgs delivers a tx of msgs and memo signed by priv, the account
+// number of which is accnum, with a fee of 1atom.
+func deliverMsgs(t *testing.T, app *sdk.BaseApp, priv crypto.PrivKey, accnum, seq uint64, memo string, msgs ...std.Msg) abci.ResponseDeliverTx {
+	fee := std.NewFee(100000, std.NewCoin("atom", 1))
+	return app.DeliverTx(abci.RequestDeliverTx{Tx: signTx(t, priv, accnum, seq, fee, memo, msgs...)})
+}
+
+// signTx returns the bytes of a tx of fee, msgs and memo signed by priv.
+func signTx(t *testing.T, priv crypto.PrivKey, accnum, seq uint64, fee std.Fee, memo string, msgs ...std.Msg) []byte {
+	sig, err := priv.Sign(std.SignBytes(testChainID, accnum, seq, fee, msgs, memo))
+	require.NoError(t, err)
+	tx := std.NewTx(msgs, fee, []std.Signature{{PubKey: priv.PubKey(), Signature: sig}}, memo)
+	return amino.MustMarshal(tx)
+}
+
+func maxMemoBytesChange(authority crypto.Address, value interface{}) MsgParamChange {
+	return NewMsgParamChange(authority, "auth", "max_memo_bytes", amino.MustMarshalJSON(value))
+}
+
+func TestParamChangeMaxMemoBytes(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	authority := priv.PubKey().Address()
+	app, authSpace := newTestApp(t, authority, []crypto.Address{authority})
+	to := secp256k1.GenPrivKey().PubKey().Address()
+	send := bank.NewMsgSend(authority, to, std.NewCoins(std.NewCoin("atom", 1)))
+	memo := strings.Repeat("m", 20)
+
+	// the default limit allows the memo.
+	res := deliverMsgs(t, app, priv, 0, 0, memo, send)
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	res = deliverMsgs(t, app, priv, 0, 1, "", maxMemoBytesChange(authority, int64(10)))
+	require.True(t, res.IsOK(), "%v", res.Log)
+	require.Equal(t, []abci.Event{abci.NewEvent(auth.EventTypeFee,
+		abci.NewAttribute("payer", authority.String()),
+		abci.NewAttribute("amount", "1atom"),
+		abci.NewAttribute(sdk.AttributeKeyAnte, "true"),
+	), abci.NewEvent(EventTypeParamChange,
+		abci.NewAttribute("subspace", "auth"),
+		abci.NewAttribute("key", "max_memo_bytes"),
+		abci.NewAttribute("old_value", ""),
+		abci.NewAttribute("new_value", `"10"`),
+	), abci.NewEvent(sdk.EventTypeTx, abci.NewAttribute("index", "1"))}, res.Events)
+
+	// the next tx is rejected by the new limit.
+	res = deliverMsgs(t, app, priv, 0, 2, memo, send)
+	require.False(t, res.IsOK())
+	require.IsType(t, std.MemoTooLargeError{}, res.Error)
+	res = deliverMsgs(t, app, priv, 0, 2, memo[:10], send)
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	res = deliverMsgs(t, app, priv, 0, 3, "", maxMemoBytesChange(authority, int64(20)))
+	require.True(t, res.IsOK(), "%v", res.Log)
+	require.Equal(t, `"10"`, res.Events[1].(abci.TypedEvent).Attributes[2].Value)
+	res = deliverMsgs(t, app, priv, 0, 4, memo, send)
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	ctx := app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1})
+	var maxMemoBytes int64
+	require.True(t, authSpace.Get(ctx, "max_memo_bytes", &maxMemoBytes))
+	require.Equal(t, int64(20), maxMemoBytes)
+}
+
+func TestParamChangeRejected(t *testing.T) {
+	priv, other := secp256k1.GenPrivKey(), secp256k1.GenPrivKey()
+	authority, otherAddr := priv.PubKey().Address(), other.PubKey().Address()
+	app, authSpace := newTestApp(t, authority, []crypto.Address{authority, otherAddr})
+
+	res := deliverMsgs(t, app, priv, 0, 0, "", maxMemoBytesChange(authority, int64(10)))
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	cases := []struct {
+		name    string
+		priv    crypto.PrivKey
+		accnum  uint64
+		msgs    []std.Msg
+		errType interface{}
+	}{
+		{"not the authority", other, 1, []std.Msg{maxMemoBytesChange(otherAddr, int64(5))}, std.UnauthorizedError{}},
+		{"unknown subspace", priv, 0, []std.Msg{NewMsgParamChange(authority, "bank", "max_memo_bytes", []byte(`"5"`))}, UnknownParamError{}},
+		{"unknown key", priv, 0, []std.Msg{NewMsgParamChange(authority, "auth", "max_memo_chars", []byte(`"5"`))}, UnknownParamError{}},
+		{"type mismatch", priv, 0, []std.Msg{NewMsgParamChange(authority, "auth", "max_memo_bytes", []byte(`{"a":1}`))}, InvalidParamError{}},
+		{"invalid value", priv, 0, []std.Msg{maxMemoBytesChange(authority, int64(-1))}, InvalidParamError{}},
+		{"no partial writes", priv, 0, []std.Msg{
+			maxMemoBytesChange(authority, int64(5)),
+			NewMsgParamChange(authority, "auth", "max_memo_bytes", []byte(`"x"`)),
+		}, InvalidParamError{}},
+	}
+	seqs := map[uint64]uint64{0: 1}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := deliverMsgs(t, app, tc.priv, tc.accnum, seqs[tc.accnum], "", tc.msgs...)
+			require.False(t, res.IsOK())
+			require.IsType(t, tc.errType, res.Error, "%v", res.Log)
+
+			ctx := app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 1})
+			var maxMemoBytes int64
+			require.True(t, authSpace.Get(ctx, "max_memo_bytes", &maxMemoBytes))
+			require.Equal(t, int64(10), maxMemoBytes)
+		})
+		seqs[tc.accnum]++
+	}
+}
+
+func TestParamQuery(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	authority := priv.PubKey().Address()
+	app, _ := newTestApp(t, authority, []crypto.Address{authority})
+
+	res := deliverMsgs(t, app, priv, 0, 0, "", maxMemoBytesChange(authority, int64(10)))
+	require.True(t, res.IsOK(), "%v", res.Log)
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	qres := app.Query(abci.RequestQuery{Path: "/params/auth/max_memo_bytes"})
+	require.Nil(t, qres.Error, "%v", qres.Log)
+	require.Equal(t, `"10"`, string(qres.Data))
+
+	qres = app.Query(abci.RequestQuery{Path: "/params/auth/unknown"})
+	require.IsType(t, UnknownParamError{}, qres.Error)
+}
+
+// The global min gas prices of genesis reject on delivery the txs accepted
+// by a node without min gas prices, and their changes take effect in the
+// next block.
+func TestGlobalMinGasPrices(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	authority := priv.PubKey().Address()
+	prices, err := std.ParseGasPrices("1atom/1000gas")
+	require.NoError(t, err)
+	appState := json.RawMessage(fmt.Sprintf(`{"auth": {%q: %s}}`,
+		auth.ParamGlobalMinGasPrices, amino.MustMarshalJSON(prices)))
+	app, authSpace := newTestAppWithState(t, authority, []crypto.Address{authority}, appState)
+	to := secp256k1.GenPrivKey().PubKey().Address()
+	send := bank.NewMsgSend(authority, to, std.NewCoins(std.NewCoin("atom", 1)))
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: testChainID, Height: 2}})
+
+	cheap := std.NewFee(100000, std.NewCoin("atom", 1))
+	tx := signTx(t, priv, 0, 0, cheap, "", send)
+	cres := app.CheckTx(abci.RequestCheckTx{Tx: tx})
+	require.True(t, cres.IsOK(), "%v", cres.Log)
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+	require.IsType(t, std.InsufficientFeeError{}, res.Error, "%v", res.Log)
+	require.Contains(t, res.Log, "global minimum gas prices")
+
+	// the fee is checked against the gas wanted, in the denom of the fee.
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: signTx(t, priv, 0, 0, std.NewFee(50000, std.NewCoin("atom", 50)), "", send)})
+	require.True(t, res.IsOK(), "%v", res.Log)
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: signTx(t, priv, 0, 1, std.NewFee(50000, std.NewCoin("photon", 50)), "", send)})
+	require.IsType(t, std.InsufficientFeeError{}, res.Error, "%v", res.Log)
+
+	// the floor is lowered, from the next block.
+	lower, err := std.ParseGasPrices("1atom/100000gas")
+	require.NoError(t, err)
+	change := NewMsgParamChange(authority, "auth", auth.ParamGlobalMinGasPrices, amino.MustMarshalJSON(lower))
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: signTx(t, priv, 0, 1, std.NewFee(100000, std.NewCoin("atom", 100)), "", change)})
+	require.True(t, res.IsOK(), "%v", res.Log)
+	tx = signTx(t, priv, 0, 2, cheap, "", send)
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+	require.IsType(t, std.InsufficientFeeError{}, res.Error, "%v", res.Log)
+
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: testChainID, Height: 3}})
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+	require.True(t, res.IsOK(), "%v", res.Log)
+
+	// invalid prices are rejected.
+	invalid := NewMsgParamChange(authority, "auth", auth.ParamGlobalMinGasPrices, []byte(`[{"denom":"atom","amount":"-1"}]`))
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: signTx(t, priv, 0, 3, cheap, "", invalid)})
+	require.IsType(t, InvalidParamError{}, res.Error, "%v", res.Log)
+
+	// the params are exported for the genesis, which is validated.
+	ctx := app.NewContext(sdk.RunTxModeDeliver, &bft.Header{ChainID: testChainID, Height: 3})
+	exported := authSpace.ExportGenesis(ctx)
+	require.JSONEq(t, fmt.Sprintf(`{%q: %s}`, auth.ParamGlobalMinGasPrices, amino.MustMarshalJSON(lower)), string(exported))
+	require.NoError(t, authSpace.ValidateGenesis(exported))
+	require.Error(t, authSpace.ValidateGenesis(json.RawMessage(`{"global_min_gas_prices": [{"denom":"atom","amount":"0"}]}`)))
+	require.Error(t, authSpace.ValidateGenesis(json.RawMessage(`{"unknown": "1"}`)))
+}
@@ -0,0 +1,17 @@
+package params
+
+const (
+	// module name
+	ModuleName = "params"
+
+	// RouterKey is the route of the params msgs
+	RouterKey = ModuleName
+
+	// StoreKeyPrefix is the prefix of the keys of the params in the store
+	StoreKeyPrefix = "/params/"
+)
+
+// EventTypeParamChange is the type of the event emitted by a param change,
+// with the attributes "subspace", "key", "old_value" and "new_value", where
+// the values are JSON, and the old value is empty if it was not set.
+const EventTypeParamChange = "param_change"
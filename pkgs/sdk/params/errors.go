@@ -0,0 +1,31 @@
+package params
+
+import (
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// for convenience:
+type abciError struct{}
+
+func (_ abciError) AssertABCIError() {}
+
+// declare all params errors.
+// NOTE: these are meant to be used in conjunction with pkgs/errors.
+type UnknownParamError struct{ abciError }
+type InvalidParamError struct{ abciError }
+
+func (e UnknownParamError) Error() string { return "unknown param" }
+func (e InvalidParamError) Error() string { return "invalid param value" }
+
+func init() {
+	std.RegisterError(ModuleName, 1, UnknownParamError{})
+	std.RegisterError(ModuleName, 2, InvalidParamError{})
+}
+
+func ErrUnknownParam(msg string) error {
+	return errors.Wrap(UnknownParamError{}, msg)
+}
+func ErrInvalidParam(msg string) error {
+	return errors.Wrap(InvalidParamError{}, msg)
+}
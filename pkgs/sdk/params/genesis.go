@@ -0,0 +1,77 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gnolang/gno/pkgs/sdk"
+)
+
+var _ sdk.GenesisModule = Subspace{}
+
+// genesisParams decodes state, a JSON object of the JSON values of params
+// of the subspace by key, and returns the values by key, in the order of
+// the keys.
+func (s Subspace) genesisParams(state json.RawMessage) (keys []string, values map[string]interface{}, err error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(state, &raw); err != nil {
+		return nil, nil, fmt.Errorf("params of subspace %q: %w", s.name, err)
+	}
+	values = make(map[string]interface{}, len(raw))
+	for key, bz := range raw {
+		value, err := s.decodeJSON(key, bz)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.table.validateValue(key, value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values[key] = value
+	}
+	sort.Strings(keys)
+	return keys, values, nil
+}
+
+// ValidateGenesis implements sdk.GenesisModule. The genesis state of the
+// subspace is a JSON object of the values of its params by key.
+func (s Subspace) ValidateGenesis(state json.RawMessage) error {
+	if state == nil {
+		return nil
+	}
+	_, _, err := s.genesisParams(state)
+	return err
+}
+
+// InitGenesis implements sdk.GenesisModule.
+func (s Subspace) InitGenesis(ctx sdk.Context, state json.RawMessage) {
+	if state == nil {
+		return
+	}
+	keys, values, err := s.genesisParams(state)
+	if err != nil {
+		panic(err)
+	}
+	for _, key := range keys {
+		if err := s.Set(ctx, key, values[key]); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ExportGenesis returns the genesis state of the params of the subspace
+// which are set, for InitGenesis.
+func (s Subspace) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	state := make(map[string]json.RawMessage)
+	for key := range s.table.params {
+		if bz := s.getJSON(ctx, key); bz != nil {
+			state[key] = bz
+		}
+	}
+	bz, err := json.Marshal(state)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
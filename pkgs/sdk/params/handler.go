@@ -0,0 +1,99 @@
+package params
+
+import (
+	"fmt"
+	"strings"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+type paramsHandler struct {
+	params ParamsKeeper
+}
+
+// NewHandler returns a handler for "params" type messages.
+func NewHandler(params ParamsKeeper) paramsHandler {
+	return paramsHandler{
+		params: params,
+	}
+}
+
+func (ph paramsHandler) Process(ctx sdk.Context, msg std.Msg) sdk.Result {
+	switch msg := msg.(type) {
+	case MsgParamChange:
+		return ph.handleMsgParamChange(ctx, msg)
+
+	default:
+		errMsg := fmt.Sprintf("unrecognized params message type: %T", msg)
+		return abciResult(std.ErrUnknownRequest(errMsg))
+	}
+}
+
+// Handle MsgParamChange.
+// The value is decoded and validated before anything is written, so that a
+// rejected change leaves the params unchanged.
+func (ph paramsHandler) handleMsgParamChange(ctx sdk.Context, msg MsgParamChange) sdk.Result {
+	if msg.Authority != ph.params.Authority() {
+		return abciResult(std.ErrUnauthorized(
+			fmt.Sprintf("%s is not the params authority", msg.Authority)))
+	}
+	space, ok := ph.params.GetSubspace(msg.Subspace)
+	if !ok {
+		return abciResult(ErrUnknownParam(fmt.Sprintf("subspace %q", msg.Subspace)))
+	}
+	value, err := space.decodeJSON(msg.Key, msg.Value)
+	if err != nil {
+		return abciResult(err)
+	}
+	if err := space.table.validateValue(msg.Key, value); err != nil {
+		return abciResult(err)
+	}
+
+	oldValue := space.getJSON(ctx, msg.Key)
+	if err := space.Set(ctx, msg.Key, value); err != nil {
+		return abciResult(err)
+	}
+	newValue := space.getJSON(ctx, msg.Key)
+
+	res := sdk.Result{}
+	if err := res.EmitTypedEvent(EventTypeParamChange,
+		"subspace", msg.Subspace,
+		"key", msg.Key,
+		"old_value", string(oldValue),
+		"new_value", string(newValue),
+	); err != nil {
+		return abciResult(std.ErrInternal(err.Error()))
+	}
+	return res
+}
+
+//----------------------------------------
+// Query
+
+// query the JSON of a param, of path "params/<subspace>/<key>".
+func (ph paramsHandler) Query(ctx sdk.Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+	parts := strings.Split(req.Path, "/")
+	if len(parts) != 3 {
+		res = sdk.ABCIResponseQueryFromError(
+			std.ErrUnknownRequest("unknown params query endpoint"))
+		return
+	}
+	space, ok := ph.params.GetSubspace(parts[1])
+	if !ok || !space.table.Has(parts[2]) {
+		res = sdk.ABCIResponseQueryFromError(
+			ErrUnknownParam(fmt.Sprintf("param %s/%s", parts[1], parts[2])))
+		return
+	}
+
+	res.Data = space.getJSON(ctx, parts[2])
+	return
+}
+
+//----------------------------------------
+// misc
+
+func abciResult(err error) sdk.Result {
+	return sdk.ABCIResultFromError(err)
+}
@@ -0,0 +1,139 @@
+package params
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/prefix"
+)
+
+// ParamsKeeper manages the subspaces of params, stored under StoreKeyPrefix
+// of a store, and the address of the authority allowed to change them with
+// MsgParamChange.
+type ParamsKeeper struct {
+	key       store.StoreKey
+	authority crypto.Address
+	spaces    map[string]Subspace
+}
+
+// NewParamsKeeper returns a ParamsKeeper of the params in the store of key,
+// which can be changed by authority.
+func NewParamsKeeper(key store.StoreKey, authority crypto.Address) ParamsKeeper {
+	return ParamsKeeper{
+		key:       key,
+		authority: authority,
+		spaces:    make(map[string]Subspace),
+	}
+}
+
+// Authority returns the address allowed to change the params.
+func (pk ParamsKeeper) Authority() crypto.Address {
+	return pk.authority
+}
+
+// Subspace registers and returns the subspace name of the params declared in
+// table. It panics if name is empty, contains '/', or is already registered.
+func (pk ParamsKeeper) Subspace(name string, table KeyTable) Subspace {
+	if name == "" {
+		panic("empty subspace name")
+	}
+	for _, c := range name {
+		if c == '/' {
+			panic(fmt.Sprintf("invalid subspace name %q", name))
+		}
+	}
+	if _, ok := pk.spaces[name]; ok {
+		panic(fmt.Sprintf("subspace %q already registered", name))
+	}
+	space := Subspace{
+		key:   pk.key,
+		name:  name,
+		table: table,
+	}
+	pk.spaces[name] = space
+	return space
+}
+
+// GetSubspace returns the subspace name, and whether it is registered.
+func (pk ParamsKeeper) GetSubspace(name string) (Subspace, bool) {
+	space, ok := pk.spaces[name]
+	return space, ok
+}
+
+// Subspace is a namespace of params, of the keys and types declared by its
+// KeyTable. The values are stored with their amino encoding.
+type Subspace struct {
+	key   store.StoreKey
+	name  string
+	table KeyTable
+}
+
+// Name returns the name of the subspace.
+func (s Subspace) Name() string {
+	return s.name
+}
+
+// KeyTable returns the KeyTable of the subspace.
+func (s Subspace) KeyTable() KeyTable {
+	return s.table
+}
+
+func (s Subspace) store(ctx sdk.Context) store.Store {
+	return prefix.New(ctx.Store(s.key), []byte(StoreKeyPrefix+s.name+"/"))
+}
+
+// Get decodes the value of key into ptr, a pointer to a value of the type of
+// key, and returns true, or returns false if the param is not set. It panics
+// if key is not registered.
+func (s Subspace) Get(ctx sdk.Context, key string, ptr interface{}) bool {
+	s.checkKey(key)
+	return sdk.GetValue(s.store(ctx), nil, []byte(key), ptr)
+}
+
+// Has returns whether the param key is set.
+func (s Subspace) Has(ctx sdk.Context, key string) bool {
+	s.checkKey(key)
+	return s.store(ctx).Has([]byte(key))
+}
+
+// Set sets the param key to value, after checking it against the KeyTable,
+// and returns an error if it is not of the type of key or is not valid.
+func (s Subspace) Set(ctx sdk.Context, key string, value interface{}) error {
+	if err := s.table.validateValue(key, value); err != nil {
+		return err
+	}
+	sdk.SetValue(s.store(ctx), nil, []byte(key), value)
+	return nil
+}
+
+// getJSON returns the JSON of the value of key, or nil if it is not set.
+func (s Subspace) getJSON(ctx sdk.Context, key string) []byte {
+	ptr := reflect.New(s.table.params[key].typ)
+	if !s.Get(ctx, key, ptr.Interface()) {
+		return nil
+	}
+	return amino.MustMarshalJSON(ptr.Elem().Interface())
+}
+
+// decodeJSON decodes bz into a value of the type of key.
+func (s Subspace) decodeJSON(key string, bz []byte) (interface{}, error) {
+	attr, ok := s.table.params[key]
+	if !ok {
+		return nil, ErrUnknownParam(fmt.Sprintf("param key %q of subspace %q", key, s.name))
+	}
+	ptr := reflect.New(attr.typ)
+	if err := amino.UnmarshalJSON(bz, ptr.Interface()); err != nil {
+		return nil, ErrInvalidParam(fmt.Sprintf("param key %q is of type %v: %v", key, attr.typ, err))
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+func (s Subspace) checkKey(key string) {
+	if !s.table.Has(key) {
+		panic(fmt.Sprintf("param key %q not registered in subspace %q", key, s.name))
+	}
+}
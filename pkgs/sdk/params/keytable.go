@@ -0,0 +1,66 @@
+package params
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// KeyTable declares the params of a subspace: the type of the value of each
+// key, and how to validate it.
+type KeyTable struct {
+	params map[string]paramAttr
+}
+
+type paramAttr struct {
+	typ      reflect.Type
+	validate func(value interface{}) error
+}
+
+// NewKeyTable returns an empty KeyTable.
+func NewKeyTable() KeyTable {
+	return KeyTable{
+		params: make(map[string]paramAttr),
+	}
+}
+
+// RegisterParam declares the param key, of the type of proto, e.g.
+// int64(0), and returns the table. validate, if not nil, is called with the
+// values of the param, of the type of proto, before they are set. It panics
+// if key is empty or already registered.
+func (t KeyTable) RegisterParam(key string, proto interface{}, validate func(value interface{}) error) KeyTable {
+	if key == "" {
+		panic("empty param key")
+	}
+	if _, ok := t.params[key]; ok {
+		panic(fmt.Sprintf("param key %q already registered", key))
+	}
+	if proto == nil {
+		panic(fmt.Sprintf("nil type of param key %q", key))
+	}
+	t.params[key] = paramAttr{reflect.TypeOf(proto), validate}
+	return t
+}
+
+// Has returns whether key is registered.
+func (t KeyTable) Has(key string) bool {
+	_, ok := t.params[key]
+	return ok
+}
+
+// validateValue returns an error if value is not of the type of key or is
+// not valid.
+func (t KeyTable) validateValue(key string, value interface{}) error {
+	attr, ok := t.params[key]
+	if !ok {
+		return ErrUnknownParam(fmt.Sprintf("param key %q", key))
+	}
+	if reflect.TypeOf(value) != attr.typ {
+		return ErrInvalidParam(fmt.Sprintf("param key %q is of type %v, not %T", key, attr.typ, value))
+	}
+	if attr.validate != nil {
+		if err := attr.validate(value); err != nil {
+			return ErrInvalidParam(fmt.Sprintf("param key %q: %v", key, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package params
+
+import (
+	"encoding/json"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// MsgParamChange - sets the param Key of Subspace to Value, the amino JSON
+// of a value of the type of the param. It must be signed by the authority
+// of the ParamsKeeper.
+type MsgParamChange struct {
+	Authority crypto.Address  `json:"authority" yaml:"authority"`
+	Subspace  string          `json:"subspace" yaml:"subspace"`
+	Key       string          `json:"key" yaml:"key"`
+	Value     json.RawMessage `json:"value" yaml:"value"`
+}
+
+var _ std.Msg = MsgParamChange{}
+
+// NewMsgParamChange - construct a param change msg.
+func NewMsgParamChange(authority crypto.Address, subspace, key string, value json.RawMessage) MsgParamChange {
+	return MsgParamChange{Authority: authority, Subspace: subspace, Key: key, Value: value}
+}
+
+// Route Implements Msg.
+func (msg MsgParamChange) Route() string { return RouterKey }
+
+// Type Implements Msg.
+func (msg MsgParamChange) Type() string { return "param_change" }
+
+// ValidateBasic Implements Msg.
+func (msg MsgParamChange) ValidateBasic() error {
+	if msg.Authority.IsZero() {
+		return std.ErrInvalidAddress("missing authority address")
+	}
+	if msg.Subspace == "" {
+		return ErrUnknownParam("missing subspace")
+	}
+	if msg.Key == "" {
+		return ErrUnknownParam("missing param key")
+	}
+	if !json.Valid(msg.Value) {
+		return ErrInvalidParam("param value is not valid JSON")
+	}
+	return nil
+}
+
+// GetSignBytes Implements Msg.
+func (msg MsgParamChange) GetSignBytes() []byte {
+	return std.MustSortJSON(amino.MustMarshalJSON(msg))
+}
+
+// GetSigners Implements Msg.
+func (msg MsgParamChange) GetSigners() []crypto.Address {
+	return []crypto.Address{msg.Authority}
+}
@@ -0,0 +1,15 @@
+package params
+
+import (
+	"github.com/gnolang/gno/pkgs/amino"
+)
+
+var Package = amino.RegisterPackage(amino.NewPackage(
+	"github.com/gnolang/gno/pkgs/sdk/params",
+	"params",
+	amino.GetCallersDirname(),
+).WithDependencies().WithTypes(
+	UnknownParamError{}, "UnknownParamError",
+	InvalidParamError{}, "InvalidParamError",
+	MsgParamChange{}, "MsgParamChange",
+))
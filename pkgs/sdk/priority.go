@@ -0,0 +1,41 @@
+package sdk
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// PriorityEvictLast is the priority of the checked txs which mempools must
+// evict after all the others when full, whatever their fees, e.g. oracle
+// votes. It is the only negative priority: see Context.WithPriority.
+const PriorityEvictLast int64 = -1
+
+// priorityPrecision is the number of units of priority per unit of gas
+// price, so that prices below 1 per unit of gas are still ordered.
+const priorityPrecision = 1_000_000
+
+// DefaultTxPriority returns the priority of a checked tx of fee before the
+// AnteHandler, its gas price, i.e. its fee amount per unit of gas wanted,
+// in millionths, capped to math.MaxInt64. It is 0 if the tx wants no gas.
+func DefaultTxPriority(fee std.Fee) int64 {
+	if fee.GasWanted <= 0 || fee.GasFee.Amount <= 0 {
+		return 0
+	}
+	p := new(big.Int).Mul(big.NewInt(fee.GasFee.Amount), big.NewInt(priorityPrecision))
+	p.Quo(p, big.NewInt(fee.GasWanted))
+	if !p.IsInt64() {
+		return math.MaxInt64
+	}
+	return p.Int64()
+}
+
+// normalizePriority returns p, or 0 if p is negative but not
+// PriorityEvictLast.
+func normalizePriority(p int64) int64 {
+	if p < 0 && p != PriorityEvictLast {
+		return 0
+	}
+	return p
+}
@@ -0,0 +1,68 @@
+package sdk
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+func TestDefaultTxPriority(t *testing.T) {
+	require.Equal(t, int64(0), DefaultTxPriority(std.NewFee(0, std.NewCoin("atom", 10))))
+	require.Equal(t, int64(0), DefaultTxPriority(std.NewFee(100, std.NewCoin("atom", 0))))
+	require.Equal(t, int64(100_000), DefaultTxPriority(std.NewFee(100, std.NewCoin("atom", 10))))
+	require.Equal(t, int64(1), DefaultTxPriority(std.NewFee(1_000_000, std.NewCoin("atom", 1))))
+	require.Equal(t, int64(math.MaxInt64), DefaultTxPriority(std.NewFee(1, std.NewCoin("atom", math.MaxInt64))))
+}
+
+// The AnteHandler boosts the priority of the txs of msgCounter2s over those
+// of higher fees, and the txs of memo "evict last" are evicted last.
+func TestCheckTxPriority(t *testing.T) {
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (Context, Result, bool) {
+			if _, ok := tx.GetMsgs()[0].(msgCounter2); ok {
+				ctx = ctx.WithPriority(math.MaxInt64)
+			}
+			switch tx.Memo {
+			case "evict last":
+				ctx = ctx.WithPriority(PriorityEvictLast)
+			case "negative":
+				ctx = ctx.WithPriority(-5)
+			}
+			return ctx, Result{}, false
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		noop := newTestHandler(func(ctx Context, msg Msg) Result { return Result{} })
+		bapp.Router().AddRoute(routeMsgCounter, noop)
+		bapp.Router().AddRoute(routeMsgCounter2, noop)
+	}
+	app := setupBaseApp(t, anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	checkTx := func(msg Msg, amount int64, memo string) int64 {
+		t.Helper()
+		tx := std.Tx{Msgs: []Msg{msg}, Fee: std.NewFee(1000, std.NewCoin("atom", amount)), Memo: memo}
+		res := app.CheckTx(abci.RequestCheckTx{Tx: amino.MustMarshal(tx)})
+		require.True(t, res.IsOK(), res.Log)
+		return res.Priority
+	}
+	cheap := checkTx(msgCounter{Counter: 1}, 10, "")
+	expensive := checkTx(msgCounter{Counter: 2}, 1000, "")
+	boosted := checkTx(msgCounter2{Counter: 1}, 10, "")
+	require.Equal(t, DefaultTxPriority(std.NewFee(1000, std.NewCoin("atom", 10))), cheap)
+	require.Less(t, cheap, expensive)
+	require.Less(t, expensive, boosted)
+
+	require.Equal(t, PriorityEvictLast, checkTx(msgCounter{Counter: 3}, 1000, "evict last"))
+	// other negative priorities are 0.
+	require.Equal(t, int64(0), checkTx(msgCounter{Counter: 4}, 1000, "negative"))
+}
@@ -0,0 +1,201 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/cache"
+)
+
+// queryLimits are the limits of the queries of Query, see SetQueryLimits
+// and SetQueryStepLimits.
+type queryLimits struct {
+	maxResponseBytes int
+	perPath          []pathLimit // longest patterns first
+	slots            chan struct{}
+
+	maxSteps     int
+	perPathSteps []pathLimit // longest patterns first
+}
+
+// pathLimit is the limit of the queries whose path matches pattern.
+type pathLimit struct {
+	pattern []string
+	limit   int
+}
+
+func newQueryLimits(maxResponseBytes int, maxConcurrent int, perPathLimits map[string]int) *queryLimits {
+	ql := &queryLimits{
+		maxResponseBytes: maxResponseBytes,
+		perPath:          newPathLimits(perPathLimits),
+	}
+	if maxConcurrent > 0 {
+		ql.slots = make(chan struct{}, maxConcurrent)
+	}
+	return ql
+}
+
+// newPathLimits returns the limits of the patterns of perPathLimits, in the
+// order in which they apply.
+func newPathLimits(perPathLimits map[string]int) []pathLimit {
+	var pls []pathLimit
+	for pattern, limit := range perPathLimits {
+		if !strings.HasPrefix(pattern, "/") {
+			panic(fmt.Sprintf("invalid query path pattern %q", pattern))
+		}
+		pls = append(pls, pathLimit{strings.Split(pattern[1:], "/"), limit})
+	}
+	// the most specific pattern applies, and patterns of the same length
+	// are ordered for determinism.
+	sort.Slice(pls, func(i, j int) bool {
+		a, b := pls[i].pattern, pls[j].pattern
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return strings.Join(a, "/") < strings.Join(b, "/")
+	})
+	return pls
+}
+
+// limitOf returns the limit of the first of pls matching path, or def if
+// none does.
+func limitOf(pls []pathLimit, path QueryPath, def int) int {
+	for _, pl := range pls {
+		if pl.matches(path) {
+			return pl.limit
+		}
+	}
+	return def
+}
+
+// matches returns whether the pattern is a prefix of the segments of path,
+// where a "*" segment of the pattern matches any segment.
+func (pl pathLimit) matches(path QueryPath) bool {
+	segments := path.Segments()
+	if len(pl.pattern) > len(segments) {
+		return false
+	}
+	for i, segment := range pl.pattern {
+		if segment != "*" && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// acquire returns whether the query may run now, in which case release
+// must be called when it is done. Queries beyond the maximum concurrency
+// are shed rather than queued, so that a flood of queries cannot pile up.
+func (ql *queryLimits) acquire() bool {
+	if ql.slots == nil {
+		return true
+	}
+	select {
+	case ql.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ql *queryLimits) release() {
+	if ql.slots != nil {
+		<-ql.slots
+	}
+}
+
+// maxResponseBytesOf returns the maximum response size of the queries of
+// path, or 0 if there is none.
+func (ql *queryLimits) maxResponseBytesOf(path QueryPath) int {
+	return limitOf(ql.perPath, path, ql.maxResponseBytes)
+}
+
+// maxStepsOf returns the step budget of the queries of path, or 0 if there
+// is none.
+func (ql *queryLimits) maxStepsOf(path QueryPath) int {
+	return limitOf(ql.perPathSteps, path, ql.maxSteps)
+}
+
+// check returns an error if res exceeds the maximum response size of path.
+func (ql *queryLimits) check(path QueryPath, res abci.ResponseQuery) error {
+	max := ql.maxResponseBytesOf(path)
+	if max <= 0 {
+		return nil
+	}
+	size := len(res.Key) + len(res.Value)
+	if res.Proof != nil {
+		for _, op := range res.Proof.Ops {
+			size += len(op.Type) + len(op.Key) + len(op.Data)
+		}
+	}
+	if size > max {
+		return std.ErrResponseTooLarge(fmt.Sprintf(
+			"response of %d bytes exceeds the limit of %d bytes for %s; query a narrower range, e.g. by paginating",
+			size, max, path))
+	}
+	return nil
+}
+
+// stepBudget is the budget of iterator steps of a query, see
+// SetQueryStepLimits.
+type stepBudget struct {
+	max  int
+	left int
+}
+
+func newStepBudget(max int) *stepBudget {
+	return &stepBudget{max: max, left: max}
+}
+
+// stepBudgetExceeded is the panic value of a query exceeding its budget.
+type stepBudgetExceeded struct {
+	max int
+}
+
+// consume consumes a step, and panics with a stepBudgetExceeded if there
+// is none left.
+func (sb *stepBudget) consume() {
+	if sb.left == 0 {
+		panic(stepBudgetExceeded{sb.max})
+	}
+	sb.left--
+}
+
+// budgetStore is the store of a context with a step budget, whose
+// iterators consume a step when they are created, and on each Next.
+type budgetStore struct {
+	store.Store
+	budget *stepBudget
+}
+
+// Implements Store.
+func (bs budgetStore) Iterator(start, end []byte) store.Iterator {
+	bs.budget.consume()
+	return budgetIterator{bs.Store.Iterator(start, end), bs.budget}
+}
+
+// Implements Store.
+func (bs budgetStore) ReverseIterator(start, end []byte) store.Iterator {
+	bs.budget.consume()
+	return budgetIterator{bs.Store.ReverseIterator(start, end), bs.budget}
+}
+
+// Implements Store.
+func (bs budgetStore) CacheWrap() store.Store {
+	return cache.New(bs)
+}
+
+type budgetIterator struct {
+	store.Iterator
+	budget *stepBudget
+}
+
+// Implements Iterator.
+func (bi budgetIterator) Next() {
+	bi.budget.consume()
+	bi.Iterator.Next()
+}
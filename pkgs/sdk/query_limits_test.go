@@ -0,0 +1,155 @@
+package sdk
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+func TestQueryResponseLimits(t *testing.T) {
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			store := ctx.Store(mainKey)
+			for i := 0; i < 100; i++ {
+				store.Set([]byte(fmt.Sprintf("key%03d", i)), make([]byte, 100))
+			}
+			return Result{}
+		}))
+	}
+	limitsOpt := SetQueryLimits(2000, 0, map[string]int{"/.store/*/subspace": 1500})
+	app := setupBaseApp(t, routerOpt, limitsOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res := app.Deliver(newTxCounter(0, 0))
+	require.True(t, res.IsOK(), res.Log)
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	// a large subspace query is rejected, a narrower one is served.
+	qres := app.Query(abci.RequestQuery{Path: "/.store/main/subspace", Data: []byte("key")})
+	require.True(t, ErrorIs(Result{ResponseBase: qres.ResponseBase}, std.ResponseTooLargeError{}), qres.Log)
+	require.Contains(t, qres.Log, "exceeds the limit of 1500 bytes")
+	qres = app.Query(abci.RequestQuery{Path: "/.store/main/subspace", Data: []byte("key00")})
+	require.True(t, qres.IsOK(), qres.Log)
+
+	// single key gets are only limited by the default.
+	qres = app.Query(abci.RequestQuery{Path: "/.store/main/key", Data: []byte("key000")})
+	require.True(t, qres.IsOK(), qres.Log)
+	require.Len(t, qres.Value, 100)
+}
+
+func TestQueryConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, testHandler{
+			process: func(ctx Context, msg Msg) Result { return Result{} },
+			query: func(ctx Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+				started <- struct{}{}
+				<-unblock
+				return
+			},
+		})
+	}
+	app := setupBaseApp(t, routerOpt, SetQueryLimits(0, 2, nil))
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	query := abci.RequestQuery{Path: "/" + routeMsgCounter}
+
+	// two queries run, and a third one is shed.
+	var wg sync.WaitGroup
+	results := make([]abci.ResponseQuery, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = app.Query(query)
+		}(i)
+		<-started
+	}
+	qres := app.Query(query)
+	require.True(t, ErrorIs(Result{ResponseBase: qres.ResponseBase}, std.TooManyQueriesError{}), qres.Log)
+
+	// internal callers are not limited.
+	require.True(t, app.Simulate(nil, newTxCounter(0, 0)).IsOK())
+
+	close(unblock)
+	wg.Wait()
+	for _, res := range results {
+		require.True(t, res.IsOK(), res.Log)
+	}
+
+	// the query can be retried.
+	go func() { <-started }()
+	qres = app.Query(query)
+	require.True(t, qres.IsOK(), qres.Log)
+}
+
+func TestQueryStepLimits(t *testing.T) {
+	// the handler writes 1000 keys, and its queries scan them 1000 times,
+	// i.e. 1M keys, or the number of times of the request data.
+	scan := func(ctx Context, times int) int {
+		n := 0
+		for i := 0; i < times; i++ {
+			iter := ctx.Store(mainKey).Iterator([]byte("key"), []byte("kez"))
+			for ; iter.Valid(); iter.Next() {
+				n++
+			}
+			iter.Close()
+		}
+		return n
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, testHandler{
+			process: func(ctx Context, msg Msg) Result {
+				store := ctx.Store(mainKey)
+				for i := 0; i < 1000; i++ {
+					store.Set([]byte(fmt.Sprintf("key%03d", i)), []byte{1})
+				}
+				// the delivered txs are not limited.
+				require.Equal(t, 2000, scan(ctx, 2))
+				return Result{}
+			},
+			query: func(ctx Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+				times := 1000
+				if len(req.Data) > 0 {
+					times = int(req.Data[0])
+				}
+				res.Data = []byte(fmt.Sprint(scan(ctx, times)))
+				return
+			},
+		})
+	}
+	stepsOpt := SetQueryStepLimits(5000, map[string]int{"/" + routeMsgCounter + "/cheap": 10})
+	app := setupBaseApp(t, routerOpt, stepsOpt, SetQueryLimits(0, 1, nil))
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+	res := app.Deliver(newTxCounter(0, 0))
+	require.True(t, res.IsOK(), res.Log)
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	// the scan of 1M keys is cut off at the budget.
+	qres := app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter})
+	require.True(t, ErrorIs(Result{ResponseBase: qres.ResponseBase}, std.QueryTooExpensiveError{}), qres.Log)
+	require.Contains(t, qres.Log, "exceeded the budget of 5000 iterator steps")
+
+	// a scan within the budget is served, each scan taking 1001 steps.
+	qres = app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter, Data: []byte{4}})
+	require.True(t, qres.IsOK(), qres.Log)
+	require.Equal(t, "4000", string(qres.Data))
+
+	// the budget of a path overrides the default.
+	qres = app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter + "/cheap", Data: []byte{1}})
+	require.True(t, ErrorIs(Result{ResponseBase: qres.ResponseBase}, std.QueryTooExpensiveError{}), qres.Log)
+	require.Contains(t, qres.Log, "exceeded the budget of 10 iterator steps")
+
+	// the aborted queries released their slot.
+	qres = app.Query(abci.RequestQuery{Path: "/" + routeMsgCounter, Data: []byte{1}})
+	require.True(t, qres.IsOK(), qres.Log)
+}
@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+const (
+	// QueryNamespaceApp is the namespace of the queries handled by BaseApp,
+	// e.g. "/.app/simulate" and "/.app/version".
+	QueryNamespaceApp = ".app"
+	// QueryNamespaceStore is the namespace of the raw store queries, e.g.
+	// "/.store/main/key".
+	QueryNamespaceStore = ".store"
+)
+
+// QueryPath is a parsed ABCI query path. The namespace is the first segment
+// of the path, and is either QueryNamespaceApp, QueryNamespaceStore, or the
+// route of a custom query handler. The store name is only set for store
+// queries.
+type QueryPath struct {
+	Namespace string
+	StoreName string
+	Rest      []string
+}
+
+// SplitABCIQueryPath splits a query path into its URL-unescaped segments,
+// e.g. "/bank/balances/g1..." becomes []string{"bank", "balances", "g1..."}.
+// It returns nil if the path is invalid, see ParseQueryPath.
+func SplitABCIQueryPath(path string) []string {
+	segments, _ := splitQueryPath(path)
+	return segments
+}
+
+// ParseQueryPath parses a query path. The path must start with a slash,
+// must not have empty segments (so no double or trailing slash), and its
+// segments must be valid URL-escaped strings. Store queries must name a
+// store. The returned error is a std.UnknownRequestError that echoes the
+// path.
+func ParseQueryPath(path string) (QueryPath, error) {
+	segments, reason := splitQueryPath(path)
+	if segments == nil {
+		return QueryPath{}, invalidQueryPathError(path, reason)
+	}
+	qp := QueryPath{Namespace: segments[0], Rest: segments[1:]}
+	if qp.Namespace == QueryNamespaceStore {
+		if len(qp.Rest) == 0 {
+			return QueryPath{}, invalidQueryPathError(path, "missing store name")
+		}
+		qp.StoreName, qp.Rest = qp.Rest[0], qp.Rest[1:]
+	}
+	return qp, nil
+}
+
+// Segments returns the unescaped segments of the path.
+func (qp QueryPath) Segments() []string {
+	segments := []string{qp.Namespace}
+	if qp.StoreName != "" {
+		segments = append(segments, qp.StoreName)
+	}
+	return append(segments, qp.Rest...)
+}
+
+// String returns the path with escaped segments, as accepted by
+// ParseQueryPath.
+func (qp QueryPath) String() string {
+	segments := qp.Segments()
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// splitQueryPath returns the unescaped segments of path, or nil and the
+// reason why path is invalid.
+func splitQueryPath(path string) ([]string, string) {
+	if path == "" {
+		return nil, "empty path"
+	}
+	if path[0] != '/' {
+		return nil, "missing leading slash"
+	}
+	segments := strings.Split(path[1:], "/")
+	for i, segment := range segments {
+		if segment == "" {
+			return nil, "empty segment"
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, "invalid escape in segment " + segment
+		}
+		// handlers split paths on slashes again.
+		if strings.Contains(unescaped, "/") {
+			return nil, "escaped slash in segment " + segment
+		}
+		segments[i] = unescaped
+	}
+	return segments, ""
+}
+
+// NOTE: paths are not passed as formats to std.ErrUnknownRequest, as they
+// may contain escapes.
+func invalidQueryPathError(path string, reason string) error {
+	return errors.Wrap(std.UnknownRequestError{}, "invalid query path %q: %s", path, reason)
+}
+
+func unknownQueryPathError(path string) error {
+	return errors.Wrap(std.UnknownRequestError{}, "unknown query path %q", path)
+}
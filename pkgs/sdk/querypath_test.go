@@ -0,0 +1,98 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+func TestParseQueryPath(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected QueryPath
+	}{
+		{"/.app/version", QueryPath{Namespace: ".app", Rest: []string{"version"}}},
+		{"/.store/main/key", QueryPath{Namespace: ".store", StoreName: "main", Rest: []string{"key"}}},
+		{"/.store/main", QueryPath{Namespace: ".store", StoreName: "main", Rest: []string{}}},
+		{"/bank/balances/g1abc", QueryPath{Namespace: "bank", Rest: []string{"balances", "g1abc"}}},
+		{"/bank", QueryPath{Namespace: "bank", Rest: []string{}}},
+		{"/vm/qeval%20x", QueryPath{Namespace: "vm", Rest: []string{"qeval x"}}},
+	}
+	for _, tc := range cases {
+		qp, err := ParseQueryPath(tc.path)
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, tc.expected, qp, tc.path)
+
+		// String is the inverse of ParseQueryPath.
+		again, err := ParseQueryPath(qp.String())
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, qp, again, tc.path)
+		assert.Equal(t, qp.Segments(), SplitABCIQueryPath(tc.path), tc.path)
+	}
+}
+
+var malformedQueryPaths = []string{
+	"",
+	"/",
+	"//",
+	".app/version",
+	"bank/balances",
+	"/.app//version",
+	"//.app/version",
+	"/.app/version/",
+	"/.store/",
+	"/bank/balances/%zz",
+	"/bank/balances/%",
+	"/bank/a%2Fb",
+}
+
+func TestParseQueryPathMalformed(t *testing.T) {
+	for _, path := range malformedQueryPaths {
+		assert.Nil(t, SplitABCIQueryPath(path), "%q", path)
+		_, err := ParseQueryPath(path)
+		require.Error(t, err, "%q", path)
+		_, ok := errors.Cause(err).(std.UnknownRequestError)
+		assert.True(t, ok, "%q: %v", path, err)
+		assert.Contains(t, fmt.Sprintf("%#v", err), "invalid query path "+strconv.Quote(path), "%q", path)
+	}
+
+	// store queries must name a store.
+	assert.Equal(t, []string{".store"}, SplitABCIQueryPath("/.store"))
+	_, err := ParseQueryPath("/.store")
+	assert.Error(t, err)
+}
+
+func TestQueryMalformedPath(t *testing.T) {
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			return Result{}
+		}))
+	}
+	app := setupBaseApp(t, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	paths := append([]string{
+		"/.app/unknown",
+		"/.app/version/extra",
+		"/.app",
+		"/.store",
+		"/unknown/route",
+	}, malformedQueryPaths...)
+	for _, path := range paths {
+		var res abci.ResponseQuery
+		require.NotPanics(t, func() {
+			res = app.Query(abci.RequestQuery{Path: path})
+		}, "%q", path)
+		require.NotNil(t, res.Error, "%q", path)
+		_, ok := res.Error.(std.UnknownRequestError)
+		assert.True(t, ok, "%q: %v", path, res.Error)
+		assert.Contains(t, res.Log, "query path "+strconv.Quote(path), "%q", path)
+	}
+}
@@ -0,0 +1,99 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// queueCursorPrefix is the prefix of the keys of the cursors of the queues
+// processed by ProcessQueueBounded, followed by the prefix of the queue.
+var queueCursorPrefix = []byte("queue_cursor/")
+
+// EventTypeProcessQueue is the type of the events of ProcessQueueBounded.
+const EventTypeProcessQueue = "process_queue"
+
+// ProcessQueueBounded processes the entries of the queue of the keys under
+// prefix in st, in order of key, by calling fn with each, and returns the
+// number of entries processed. It processes at most maxItems entries per
+// call, e.g. in each EndBlock, so that a backlog does not blow the time of
+// a block: it persists in st a cursor after the last entry processed, from
+// which the next call resumes, until the end of the queue, after which the
+// next call starts over from its first entry.
+//
+// fn may delete the entry it processes, and write other entries. It
+// returns done to stop before maxItems, e.g. at the first entry which is
+// not due yet, which is then the first processed by the next call, or an
+// error, which is returned, without processing more entries.
+//
+// An event of EventTypeProcessQueue is emitted, with the prefix in hex,
+// the number of the entries processed, and that of the entries which
+// remain after them, counted up to maxItems so that counting is bounded.
+func ProcessQueueBounded(ctx Context, st store.Store, prefix []byte, maxItems int, fn func(k, v []byte) (done bool, err error)) (processed int, err error) {
+	if maxItems < 1 {
+		panic(fmt.Sprintf("invalid max items %d of queue", maxItems))
+	}
+	cursorKey := append(append([]byte(nil), queueCursorPrefix...), prefix...)
+	if bytes.HasPrefix(cursorKey, prefix) {
+		panic(fmt.Sprintf("queue prefix %q covers the key of its cursor", prefix))
+	}
+
+	// the entries are read first, as stores cannot be written while
+	// iterated.
+	start := prefix
+	if cursor := st.Get(cursorKey); cursor != nil {
+		start = append(append([]byte(nil), cursor...), 0) // the key after it
+	}
+	end := store.PrefixEndBytes(prefix)
+	var keys, values [][]byte
+	iter := st.Iterator(start, end)
+	for ; iter.Valid() && len(keys) < maxItems; iter.Next() {
+		keys = append(keys, append([]byte(nil), iter.Key()...))
+		values = append(values, append([]byte(nil), iter.Value()...))
+	}
+	atEnd := !iter.Valid()
+	iter.Close()
+
+	stopped := false
+	for i, key := range keys {
+		done, err := fn(key, values[i])
+		if err != nil {
+			return processed, err
+		}
+		if done {
+			stopped = true
+			break
+		}
+		processed++
+	}
+
+	// the cursor is after the last entry processed, until the end.
+	var remainingFrom []byte
+	switch {
+	case atEnd && !stopped:
+		st.Delete(cursorKey)
+	case processed > 0:
+		st.Set(cursorKey, keys[processed-1])
+		remainingFrom = append(append([]byte(nil), keys[processed-1]...), 0)
+	default:
+		remainingFrom = start
+	}
+	remaining := 0
+	if remainingFrom != nil {
+		iter := st.Iterator(remainingFrom, end)
+		for ; iter.Valid() && remaining < maxItems; iter.Next() {
+			remaining++
+		}
+		iter.Close()
+	}
+
+	err = ctx.EventLogger().EmitTypedEvent(EventTypeProcessQueue,
+		"prefix", hex.EncodeToString(prefix),
+		"processed", strconv.Itoa(processed),
+		"remaining", strconv.Itoa(remaining),
+	)
+	return processed, err
+}
@@ -0,0 +1,163 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	dbm "github.com/gnolang/gno/pkgs/db"
+)
+
+// processQueueEvent returns the processed and remaining counts of the
+// event of ProcessQueueBounded in events.
+func processQueueEvent(t *testing.T, events []Event) (processed, remaining int) {
+	t.Helper()
+	for _, ev := range events {
+		tev, ok := ev.(abci.TypedEvent)
+		if !ok || tev.Type != EventTypeProcessQueue {
+			continue
+		}
+		attrs := make(map[string]string)
+		for _, attr := range tev.Attributes {
+			attrs[attr.Key] = attr.Value
+		}
+		processed, err := strconv.Atoi(attrs["processed"])
+		require.NoError(t, err)
+		remaining, err := strconv.Atoi(attrs["remaining"])
+		require.NoError(t, err)
+		return processed, remaining
+	}
+	t.Fatal("no process queue event")
+	return 0, 0
+}
+
+func TestProcessQueueBounded(t *testing.T) {
+	const items, maxItems = 10000, 100
+	queuePrefix := []byte("queue/")
+	queueKey := func(i int) []byte { return []byte(fmt.Sprintf("queue/%05d", i)) }
+	countKey := func(key []byte) []byte { return append([]byte("count/"), key...) }
+
+	db := dbm.NewMemDB()
+	newApp := func() *BaseApp {
+		app := newBaseApp(t.Name(), db, func(bapp *BaseApp) {
+			bapp.SetInitChainer(func(ctx Context, req abci.RequestInitChain) abci.ResponseInitChain {
+				for i := 0; i < items; i++ {
+					ctx.Store(mainKey).Set(queueKey(i), []byte{1})
+				}
+				return abci.ResponseInitChain{}
+			})
+			// each entry is counted each time it is processed.
+			bapp.SetEndBlocker(func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+				ctx = ctx.WithEventLogger(NewEventLogger())
+				st := ctx.Store(mainKey)
+				_, err := ProcessQueueBounded(ctx, st, queuePrefix, maxItems, func(k, v []byte) (bool, error) {
+					var count byte
+					if bz := st.Get(countKey(k)); bz != nil {
+						count = bz[0]
+					}
+					st.Set(countKey(k), []byte{count + 1})
+					return false, nil
+				})
+				require.NoError(t, err)
+				return abci.ResponseEndBlock{Events: ctx.EventLogger().Events()}
+			})
+		})
+		require.NoError(t, app.LoadLatestVersion())
+		return app
+	}
+	runBlock := func(app *BaseApp) abci.ResponseEndBlock {
+		height := app.LastBlockHeight() + 1
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		res := app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+		return res
+	}
+	cursor := func(app *BaseApp) []byte {
+		return app.cms.GetStore(mainKey).Get(append(append([]byte(nil), queueCursorPrefix...), queuePrefix...))
+	}
+
+	app := newApp()
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	for block := 1; block <= 50; block++ {
+		processed, remaining := processQueueEvent(t, runBlock(app).Events)
+		require.Equal(t, maxItems, processed)
+		require.Equal(t, maxItems, remaining)
+	}
+	require.Equal(t, queueKey(50*maxItems-1), cursor(app))
+
+	// the next blocks resume from the cursor after a restart.
+	app = newApp()
+	require.Equal(t, queueKey(50*maxItems-1), cursor(app))
+	for block := 51; block <= 100; block++ {
+		processed, remaining := processQueueEvent(t, runBlock(app).Events)
+		require.Equal(t, maxItems, processed)
+		if block < 100 {
+			require.Equal(t, maxItems, remaining)
+		} else {
+			require.Equal(t, 0, remaining)
+		}
+	}
+	// the queue is processed once, in 100 blocks.
+	require.Nil(t, cursor(app))
+	st := app.cms.GetStore(mainKey)
+	for i := 0; i < items; i++ {
+		require.Equal(t, []byte{1}, st.Get(countKey(queueKey(i))), "entry %d", i)
+	}
+
+	// and then again from the start.
+	runBlock(app)
+	require.Equal(t, queueKey(maxItems-1), cursor(app))
+	require.Equal(t, []byte{2}, st.Get(countKey(queueKey(0))))
+	require.Equal(t, []byte{1}, st.Get(countKey(queueKey(maxItems))))
+}
+
+func TestProcessQueueBoundedStops(t *testing.T) {
+	app := setupBaseApp(t)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	ctx := app.deliverState.ctx
+	st := ctx.Store(mainKey)
+	for i := 0; i < 10; i++ {
+		st.Set([]byte(fmt.Sprintf("queue/%d", i)), []byte(strconv.Itoa(i)))
+	}
+
+	// the entries which are not due stop the processing, and are processed
+	// first by the next call.
+	var seen []string
+	due := 3
+	fn := func(k, v []byte) (bool, error) {
+		i, _ := strconv.Atoi(string(v))
+		if i >= due {
+			return true, nil
+		}
+		seen = append(seen, string(v))
+		st.Delete(k)
+		return false, nil
+	}
+	processed, err := ProcessQueueBounded(ctx, st, []byte("queue/"), 5, fn)
+	require.NoError(t, err)
+	require.Equal(t, 3, processed)
+	processed, err = ProcessQueueBounded(ctx, st, []byte("queue/"), 5, fn)
+	require.NoError(t, err)
+	require.Equal(t, 0, processed)
+	due = 10
+	processed, err = ProcessQueueBounded(ctx, st, []byte("queue/"), 5, fn)
+	require.NoError(t, err)
+	require.Equal(t, 5, processed)
+	require.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7"}, seen)
+
+	// errors are returned.
+	errFailed := errors.New("failed")
+	_, err = ProcessQueueBounded(ctx, st, []byte("queue/"), 5, func(k, v []byte) (bool, error) {
+		return false, errFailed
+	})
+	require.Equal(t, errFailed, err)
+
+	require.Panics(t, func() { ProcessQueueBounded(ctx, st, []byte("queue/"), 0, fn) })
+	require.Panics(t, func() { ProcessQueueBounded(ctx, st, []byte("queue_"), 1, fn) })
+	require.Panics(t, func() { ProcessQueueBounded(ctx, st, nil, 1, fn) })
+}
@@ -0,0 +1,56 @@
+package sdk
+
+import (
+	"fmt"
+
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/cache"
+)
+
+// readOnlyStore is the store of a read-only context, which panics on
+// writes.
+type readOnlyStore struct {
+	parent store.Store
+}
+
+var _ store.Store = readOnlyStore{}
+
+// Implements Store.
+func (ros readOnlyStore) Get(key []byte) []byte {
+	return ros.parent.Get(key)
+}
+
+// Implements Store.
+func (ros readOnlyStore) Has(key []byte) bool {
+	return ros.parent.Has(key)
+}
+
+// Implements Store.
+func (ros readOnlyStore) Set(key, value []byte) {
+	panic(fmt.Sprintf("cannot set key %X: read-only context", key))
+}
+
+// Implements Store.
+func (ros readOnlyStore) Delete(key []byte) {
+	panic(fmt.Sprintf("cannot delete key %X: read-only context", key))
+}
+
+// Implements Store.
+func (ros readOnlyStore) Iterator(start, end []byte) store.Iterator {
+	return ros.parent.Iterator(start, end)
+}
+
+// Implements Store.
+func (ros readOnlyStore) ReverseIterator(start, end []byte) store.Iterator {
+	return ros.parent.ReverseIterator(start, end)
+}
+
+// Implements Store.
+func (ros readOnlyStore) CacheWrap() store.Store {
+	return cache.New(ros)
+}
+
+// Implements Store.
+func (ros readOnlyStore) Write() {
+	panic("cannot write: read-only context")
+}
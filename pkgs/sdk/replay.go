@@ -0,0 +1,167 @@
+package sdk
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// Keys of the replay window in its store: the height of each tx by hash,
+// and the txs of each height, to prune them.
+var (
+	replayTxPrefix     = []byte("replay/tx/")
+	replayHeightPrefix = []byte("replay/height/")
+)
+
+// ReplayWindow protects against replays of txs which are not protected by
+// sequence numbers, e.g. unsigned system txs, by hash of the tx bytes. The
+// txs delivered in the last blocks of the window are recorded in a store
+// of the consensus state, so that all validators agree on it, and a tx
+// whose hash is recorded is rejected.
+//
+// AnteHandler wraps the ante handler of the app to reject and record txs,
+// and EndBlocker its end blocker to prune the txs which expire.
+type ReplayWindow struct {
+	key      store.StoreKey
+	blocks   int64
+	maxPrune int // max txs pruned per block, or 0 for all
+}
+
+// NewReplayWindow returns a ReplayWindow recording txs in the store of key,
+// e.g. the main store, for blocks blocks: a tx delivered at height h is
+// rejected up to height h+blocks-1.
+func NewReplayWindow(key store.StoreKey, blocks int64) ReplayWindow {
+	if blocks < 1 {
+		panic(fmt.Sprintf("invalid replay window of %d blocks", blocks))
+	}
+	return ReplayWindow{key: key, blocks: blocks}
+}
+
+// WithMaxPrune returns the window with at most maxTxs txs pruned per
+// block, with ProcessQueueBounded, so that the expiry of a backlog of txs
+// does not blow the time of a block. The txs which expired but are not
+// pruned yet are still rejected, i.e. for longer than the window.
+func (w ReplayWindow) WithMaxPrune(maxTxs int) ReplayWindow {
+	if maxTxs < 1 {
+		panic(fmt.Sprintf("invalid max pruned txs %d of replay window", maxTxs))
+	}
+	w.maxPrune = maxTxs
+	return w
+}
+
+// Blocks returns the number of blocks of the window.
+func (w ReplayWindow) Blocks() int64 {
+	return w.blocks
+}
+
+// Has returns whether the tx of hash was delivered in the window.
+func (w ReplayWindow) Has(ctx Context, hash []byte) bool {
+	return ctx.Store(w.key).Has(replayKey(replayTxPrefix, hash))
+}
+
+// AnteHandler returns an AnteHandler which rejects txs delivered in the
+// window with a TxInCacheError, in all modes, before running next, if not
+// nil, and records the txs next accepts in DeliverTx. Txs which fail in
+// next are not recorded, so they can be delivered again.
+func (w ReplayWindow) AnteHandler(next AnteHandler) AnteHandler {
+	return func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+		hash := ctx.TxHash()
+		if w.Has(ctx, hash) {
+			res.Error = ABCIError(std.ErrTxInCache(
+				fmt.Sprintf("tx %X was delivered in the last %d blocks", hash, w.blocks)))
+			return ctx, res, true
+		}
+
+		newCtx = ctx
+		if next != nil {
+			newCtx, res, abort = next(ctx, tx, simulate)
+			if abort {
+				return newCtx, res, abort
+			}
+		}
+		if newCtx.Mode() == RunTxModeDeliver {
+			w.record(newCtx, hash)
+		}
+		return newCtx, res, false
+	}
+}
+
+// EndBlocker returns an EndBlocker which prunes the txs which expire after
+// the block, and then runs next, if not nil. The events of the pruning are
+// before those of next.
+func (w ReplayWindow) EndBlocker(next EndBlocker) EndBlocker {
+	return func(ctx Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+		pruneCtx := ctx.WithEventLogger(NewEventLogger())
+		w.Prune(pruneCtx)
+		var res abci.ResponseEndBlock
+		if next != nil {
+			res = next(ctx, req)
+		}
+		if events := pruneCtx.EventLogger().Events(); len(events) > 0 {
+			res.Events = append(events, res.Events...)
+		}
+		return res
+	}
+}
+
+// Prune deletes the txs which expire after the block of ctx, i.e. which are
+// not in the window of the next block, or the first of them up to the max
+// of WithMaxPrune.
+func (w ReplayWindow) Prune(ctx Context) {
+	expiry := ctx.BlockHeight() + 1 - w.blocks
+	if expiry < 1 {
+		return
+	}
+	st := ctx.Store(w.key)
+	if w.maxPrune > 0 {
+		_, err := ProcessQueueBounded(ctx, st, replayHeightPrefix, w.maxPrune, func(key, _ []byte) (bool, error) {
+			if int64(binary.BigEndian.Uint64(key[len(replayHeightPrefix):])) > expiry {
+				return true, nil // the txs of the window, from here on.
+			}
+			st.Delete(replayKey(replayTxPrefix, key[len(replayHeightPrefix)+8:]))
+			st.Delete(key)
+			return false, nil
+		})
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+	end := replayKey(replayHeightPrefix, heightKey(expiry+1))
+	var keys [][]byte
+	iter := st.Iterator(replayHeightPrefix, end)
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, append([]byte(nil), iter.Key()...))
+	}
+	iter.Close()
+	for _, key := range keys {
+		hash := key[len(replayHeightPrefix)+8:]
+		st.Delete(replayKey(replayTxPrefix, hash))
+		st.Delete(key)
+	}
+}
+
+func (w ReplayWindow) record(ctx Context, hash []byte) {
+	st := ctx.Store(w.key)
+	height := heightKey(ctx.BlockHeight())
+	st.Set(replayKey(replayTxPrefix, hash), height)
+	st.Set(replayKey(replayHeightPrefix, height, hash), []byte{})
+}
+
+func replayKey(prefix []byte, parts ...[]byte) []byte {
+	key := append([]byte(nil), prefix...)
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+// heightKey returns height in big endian, so that keys sort by height.
+func heightKey(height int64) []byte {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, uint64(height))
+	return bz
+}
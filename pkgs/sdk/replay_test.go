@@ -0,0 +1,169 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+func TestReplayWindow(t *testing.T) {
+	window := NewReplayWindow(mainKey, 3)
+	anteHandler := func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+		if getFailOnAnte(tx) {
+			res.Error = ABCIError(std.ErrInternal("ante handler failure"))
+			return ctx, res, true
+		}
+		return ctx, res, false
+	}
+	app := setupBaseApp(t, func(bapp *BaseApp) {
+		bapp.SetAnteHandler(window.AnteHandler(anteHandler))
+		bapp.SetEndBlocker(window.EndBlocker(nil))
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			return Result{}
+		}))
+	})
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+
+	height := int64(0)
+	beginBlock := func() {
+		height++
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+	}
+	endBlock := func() {
+		app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+	}
+	counter := int64(0)
+	newTxBytes := func() []byte {
+		tx := newTxCounter(counter, counter)
+		counter++
+		return amino.MustMarshal(tx)
+	}
+	deliver := func(txBytes []byte) abci.ResponseDeliverTx {
+		return app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	}
+	requireReplay := func(err abci.Error) {
+		require.IsType(t, std.TxInCacheError{}, err)
+	}
+
+	// replay in the same block.
+	beginBlock()
+	txBytes := newTxBytes()
+	require.True(t, deliver(txBytes).IsOK())
+	requireReplay(deliver(txBytes).Error)
+	endBlock()
+
+	// replays in the later blocks of the window, also rejected by CheckTx.
+	for i := 0; i < 2; i++ {
+		requireReplay(app.CheckTx(abci.RequestCheckTx{Tx: txBytes}).Error)
+		beginBlock()
+		requireReplay(deliver(txBytes).Error)
+		endBlock()
+	}
+
+	// after expiry, the tx can be delivered again.
+	beginBlock()
+	require.True(t, deliver(txBytes).IsOK())
+	endBlock()
+
+	// txs failing in the ante handler are not recorded.
+	tx := newTxCounter(counter, counter)
+	setFailOnAnte(&tx, true)
+	failing := amino.MustMarshal(tx)
+	beginBlock()
+	require.IsType(t, std.InternalError{}, deliver(failing).Error)
+	require.IsType(t, std.InternalError{}, deliver(failing).Error)
+	endBlock()
+
+	// pruning keeps the records of the window only.
+	beginBlock()
+	ctx := app.deliverState.ctx
+	txBytes = newTxBytes()
+	require.False(t, window.Has(ctx, bft.Tx(txBytes).Hash()))
+	require.True(t, deliver(txBytes).IsOK())
+	require.True(t, window.Has(ctx, bft.Tx(txBytes).Hash()))
+	endBlock()
+	for i := 0; i < 3; i++ {
+		beginBlock()
+		endBlock()
+	}
+	beginBlock()
+	iter := app.deliverState.ctx.Store(mainKey).Iterator(replayTxPrefix, nil)
+	require.False(t, iter.Valid())
+	iter.Close()
+	endBlock()
+
+	require.Panics(t, func() { NewReplayWindow(mainKey, 0) })
+}
+
+func TestReplayWindowMaxPrune(t *testing.T) {
+	window := NewReplayWindow(mainKey, 1).WithMaxPrune(2)
+	app := setupBaseApp(t, func(bapp *BaseApp) {
+		bapp.SetAnteHandler(window.AnteHandler(nil))
+		bapp.SetEndBlocker(window.EndBlocker(nil))
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			return Result{}
+		}))
+	})
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	recorded := func() int {
+		n := 0
+		iter := app.cms.GetStore(mainKey).Iterator(replayTxPrefix, store.PrefixEndBytes(replayTxPrefix))
+		for ; iter.Valid(); iter.Next() {
+			n++
+		}
+		iter.Close()
+		return n
+	}
+	runBlock := func(height int64, txs ...[]byte) abci.ResponseEndBlock {
+		app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+		for _, tx := range txs {
+			require.True(t, app.DeliverTx(abci.RequestDeliverTx{Tx: tx}).IsOK())
+		}
+		res := app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+		return res
+	}
+
+	var txs [][]byte
+	for i := int64(0); i < 5; i++ {
+		txs = append(txs, amino.MustMarshal(newTxCounter(i, i)))
+	}
+	// the 5 txs expire after the block, and are pruned 2 per block.
+	res := runBlock(1, txs...)
+	processed, remaining := processQueueEvent(t, res.Events)
+	require.Equal(t, 2, processed)
+	require.Equal(t, 2, remaining) // counted up to the max.
+	require.Equal(t, 3, recorded())
+
+	// the expired txs which are not pruned yet are still rejected.
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 2}})
+	var pruned, unpruned [][]byte
+	for _, tx := range txs {
+		if window.Has(app.deliverState.ctx, bft.Tx(tx).Hash()) {
+			unpruned = append(unpruned, tx)
+		} else {
+			pruned = append(pruned, tx)
+		}
+	}
+	require.Len(t, unpruned, 3)
+	require.IsType(t, std.TxInCacheError{}, app.DeliverTx(abci.RequestDeliverTx{Tx: unpruned[0]}).Error)
+	require.True(t, app.DeliverTx(abci.RequestDeliverTx{Tx: pruned[0]}).IsOK())
+	app.EndBlock(abci.RequestEndBlock{Height: 2})
+	app.Commit()
+	require.Equal(t, 2, recorded())
+
+	// the tx of block 2 is pruned with the last one of block 1.
+	processed, remaining = processQueueEvent(t, runBlock(3).Events)
+	require.Equal(t, 2, processed)
+	require.Equal(t, 0, remaining)
+	require.Equal(t, 0, recorded())
+
+	require.Panics(t, func() { window.WithMaxPrune(0) })
+}
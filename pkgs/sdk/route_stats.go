@@ -0,0 +1,101 @@
+package sdk
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RouteStats are the stats of the delivered msgs of a route and type since
+// the app started, served at /.app/route_stats. They are not persisted.
+type RouteStats struct {
+	Route    string
+	Type     string
+	Count    uint64        // of the msgs run
+	Failures uint64        // of the msgs which failed
+	GasUsed  uint64        // by the msgs, excluding the AnteHandler
+	Time     time.Duration // of the msgs, wall-clock
+}
+
+type routeStatsKey struct {
+	route, typ string
+}
+
+// routeCounters are the counters of a route and type, accessed atomically.
+type routeCounters struct {
+	count    uint64
+	failures uint64
+	gasUsed  uint64
+	nanos    uint64
+}
+
+// routeStats are the counters of the routes and types, added by DeliverTx
+// and read concurrently by queries.
+type routeStats struct {
+	mtx      sync.RWMutex
+	counters map[routeStatsKey]*routeCounters
+}
+
+// routeStat is the stat of a msg run.
+type routeStat struct {
+	route, typ string
+	ok         bool
+	gasUsed    uint64
+	elapsed    time.Duration
+}
+
+// add counts a msg run.
+func (rs *routeStats) add(stat routeStat) {
+	key := routeStatsKey{stat.route, stat.typ}
+	rs.mtx.RLock()
+	c := rs.counters[key]
+	rs.mtx.RUnlock()
+	if c == nil {
+		rs.mtx.Lock()
+		if rs.counters == nil {
+			rs.counters = make(map[routeStatsKey]*routeCounters)
+		}
+		if c = rs.counters[key]; c == nil {
+			c = &routeCounters{}
+			rs.counters[key] = c
+		}
+		rs.mtx.Unlock()
+	}
+	atomic.AddUint64(&c.count, 1)
+	if !stat.ok {
+		atomic.AddUint64(&c.failures, 1)
+	}
+	atomic.AddUint64(&c.gasUsed, stat.gasUsed)
+	atomic.AddUint64(&c.nanos, uint64(stat.elapsed))
+}
+
+// snapshot returns the stats, sorted by route and type.
+func (rs *routeStats) snapshot() []RouteStats {
+	rs.mtx.RLock()
+	stats := make([]RouteStats, 0, len(rs.counters))
+	for key, c := range rs.counters {
+		stats = append(stats, RouteStats{
+			Route:    key.route,
+			Type:     key.typ,
+			Count:    atomic.LoadUint64(&c.count),
+			Failures: atomic.LoadUint64(&c.failures),
+			GasUsed:  atomic.LoadUint64(&c.gasUsed),
+			Time:     time.Duration(atomic.LoadUint64(&c.nanos)),
+		})
+	}
+	rs.mtx.RUnlock()
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Route != stats[j].Route {
+			return stats[i].Route < stats[j].Route
+		}
+		return stats[i].Type < stats[j].Type
+	})
+	return stats
+}
+
+// RouteStats returns the stats of the msgs delivered since the app started,
+// by route and type, sorted. It can be called concurrently with DeliverTx.
+func (app *BaseApp) RouteStats() []RouteStats {
+	return app.routeStats.snapshot()
+}
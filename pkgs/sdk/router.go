@@ -2,10 +2,17 @@ package sdk
 
 import (
 	"fmt"
+	"reflect"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/std"
 )
 
 type router struct {
-	routes map[string]Handler
+	handlers    map[string]Handler             // as added
+	middlewares map[string][]HandlerMiddleware // of each route
+	uses        []HandlerMiddleware            // of all routes
+	routes      map[string]Handler             // wrapped
 }
 
 var _ Router = NewRouter()
@@ -13,25 +20,154 @@ var _ Router = NewRouter()
 // NewRouter returns a reference to a new router.
 func NewRouter() *router { // nolint: golint
 	return &router{
-		routes: make(map[string]Handler),
+		handlers:    make(map[string]Handler),
+		middlewares: make(map[string][]HandlerMiddleware),
+		routes:      make(map[string]Handler),
 	}
 }
 
 // AddRoute adds a route path to the router with a given handler. The route must
 // be alphanumeric.
 func (rtr *router) AddRoute(path string, h Handler) Router {
+	return rtr.AddRouteWithMiddleware(path, h)
+}
+
+// AddRouteWithMiddleware adds a route path to the router with a given
+// handler, wrapped by mw. The route must be alphanumeric.
+func (rtr *router) AddRouteWithMiddleware(path string, h Handler, mw ...HandlerMiddleware) Router {
 	if !isAlphaNumeric(path) {
 		panic("route expressions can only contain alphanumeric characters")
 	}
-	if rtr.routes[path] != nil {
+	if rtr.handlers[path] != nil {
 		panic(fmt.Sprintf("route %s has already been initialized", path))
 	}
 
-	rtr.routes[path] = h
+	rtr.handlers[path] = h
+	rtr.middlewares[path] = mw
+	rtr.routes[path] = rtr.wrap(path)
+	return rtr
+}
+
+// Use wraps the handlers of all the routes, including the ones added later,
+// with mw.
+//
+// The middleware is applied in order of registration, the first being the
+// outermost: with Use(a, b) and then Use(c), the Process of a route calls
+// a, which calls b, then c, then the middleware of the route, in order, and
+// then its handler; the same for Query. Middleware which must see every msg
+// and query of the routes, e.g. a circuit breaker, must be the first.
+// Middleware does not see the txs rejected before routing, e.g. by the
+// AnteHandler.
+func (rtr *router) Use(mw ...HandlerMiddleware) Router {
+	rtr.uses = append(rtr.uses, mw...)
+	for path := range rtr.handlers {
+		rtr.routes[path] = rtr.wrap(path)
+	}
 	return rtr
 }
 
+// wrap returns the handler of path, wrapped by its middleware and then by
+// the middleware of Use.
+func (rtr *router) wrap(path string) Handler {
+	h := rtr.handlers[path]
+	mws := rtr.middlewares[path]
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	for i := len(rtr.uses) - 1; i >= 0; i-- {
+		h = rtr.uses[i](h)
+	}
+	return h
+}
+
 // Route returns a handler for a given route path.
 func (rtr *router) Route(path string) Handler {
 	return rtr.routes[path]
 }
+
+// processHandler processes msg with handler, with ProcessTyped if it is a
+// TypedHandler, whose response is then the data of the result.
+func processHandler(ctx Context, handler Handler, msg Msg) Result {
+	th, ok := handler.(TypedHandler)
+	if !ok {
+		return handler.Process(ctx, msg)
+	}
+	resp, res := th.ProcessTyped(ctx, msg)
+	if resp != nil && res.IsOK() {
+		res.Data = amino.MustMarshal(resp)
+	}
+	return res
+}
+
+// InvokeHandler processes msg with the handler of its route in router, and
+// sets the response to msg in resp, which must be a pointer, e.g. for a
+// module to call the handler of another. The response of a TypedHandler is
+// set as is, or the value it points to is, without encoding; for other
+// handlers, the data of the result is amino decoded into resp. A nil resp
+// discards the response.
+//
+// The msg is only processed by the handler, as by Process: it is not
+// validated, nor authenticated by the AnteHandler. The error of a failed
+// result is returned, wrapped with its log.
+func InvokeHandler(router Router, ctx Context, msg Msg, resp interface{}) error {
+	handler := router.Route(msg.Route())
+	if handler == nil {
+		return std.ErrUnknownRequest("unrecognized message type: " + msg.Route())
+	}
+
+	th, ok := handler.(TypedHandler)
+	if !ok {
+		res := handler.Process(ctx, msg)
+		if err := resultError(res); err != nil {
+			return err
+		}
+		if resp == nil || len(res.Data) == 0 {
+			return nil
+		}
+		return amino.Unmarshal(res.Data, resp)
+	}
+
+	value, res := th.ProcessTyped(ctx, msg)
+	if err := resultError(res); err != nil {
+		return err
+	}
+	if resp == nil {
+		return nil
+	}
+	return setResponse(resp, value)
+}
+
+// resultError returns the error of res, wrapped with its log, or nil.
+func resultError(res Result) error {
+	switch {
+	case res.IsOK():
+		return nil
+	case res.Log == "":
+		return res.Error
+	default:
+		return fmt.Errorf("%w: %s", res.Error, res.Log)
+	}
+}
+
+// setResponse sets value, or the value it points to, in the pointer resp.
+func setResponse(resp interface{}, value interface{}) error {
+	rv := reflect.ValueOf(resp)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("response must be a non-nil pointer, got %T", resp)
+	}
+	elem := rv.Elem()
+	if value == nil {
+		elem.Set(reflect.Zero(elem.Type()))
+		return nil
+	}
+	vv := reflect.ValueOf(value)
+	switch {
+	case vv.Type().AssignableTo(elem.Type()):
+		elem.Set(vv)
+	case vv.Kind() == reflect.Ptr && !vv.IsNil() && vv.Elem().Type().AssignableTo(elem.Type()):
+		elem.Set(vv.Elem())
+	default:
+		return fmt.Errorf("response of type %T cannot be set in %T", value, resp)
+	}
+	return nil
+}
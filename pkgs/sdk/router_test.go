@@ -1,11 +1,15 @@
 package sdk
 
 import (
+	goerrors "errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/gnolang/gno/pkgs/amino"
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
 )
 
 type nopTestHandler struct{}
@@ -35,3 +39,144 @@ func TestRouter(t *testing.T) {
 		rtr.AddRoute("testRoute", nopTestHandler{})
 	})
 }
+
+type recordingHandler struct {
+	name  string
+	log   *[]string
+	inner Handler
+}
+
+func (rh recordingHandler) Process(ctx Context, msg Msg) Result {
+	*rh.log = append(*rh.log, rh.name+">")
+	res := rh.inner.Process(ctx, msg)
+	*rh.log = append(*rh.log, "<"+rh.name)
+	res.Log += rh.name
+	return res
+}
+
+func (rh recordingHandler) Query(ctx Context, req abci.RequestQuery) abci.ResponseQuery {
+	*rh.log = append(*rh.log, rh.name+">")
+	res := rh.inner.Query(ctx, req)
+	*rh.log = append(*rh.log, "<"+rh.name)
+	res.Log += rh.name
+	return res
+}
+
+func recordingMiddleware(name string, log *[]string) HandlerMiddleware {
+	return func(next Handler) Handler {
+		return recordingHandler{name, log, next}
+	}
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	var log []string
+	rtr := NewRouter()
+	rtr.AddRoute("first", nopTestHandler{})
+	rtr.Use(recordingMiddleware("a", &log), recordingMiddleware("b", &log))
+	rtr.AddRouteWithMiddleware("second", nopTestHandler{}, recordingMiddleware("x", &log), recordingMiddleware("y", &log))
+	rtr.Use(recordingMiddleware("c", &log))
+
+	require.Panics(t, func() {
+		rtr.AddRouteWithMiddleware("second", nopTestHandler{})
+	})
+	require.Nil(t, rtr.Route("third"))
+
+	// the middleware of Use applies to the routes added before and after.
+	res := rtr.Route("first").Process(Context{}, nil)
+	require.Equal(t, "cba", res.Log)
+	require.Equal(t, []string{"a>", "b>", "c>", "<c", "<b", "<a"}, log)
+
+	// the middleware of the route is inside.
+	log = nil
+	res = rtr.Route("second").Process(Context{}, nil)
+	require.Equal(t, "yxcba", res.Log)
+	require.Equal(t, []string{"a>", "b>", "c>", "x>", "y>", "<y", "<x", "<c", "<b", "<a"}, log)
+
+	log = nil
+	qres := rtr.Route("second").Query(Context{}, abci.RequestQuery{})
+	require.Equal(t, "yxcba", qres.Log)
+	require.Equal(t, []string{"a>", "b>", "c>", "x>", "y>", "<y", "<x", "<c", "<b", "<a"}, log)
+}
+
+type counterResponse struct {
+	Counter int64
+}
+
+// typedCounterHandler responds to msgCounters with the next counter.
+type typedCounterHandler struct {
+	nopTestHandler
+}
+
+func (h typedCounterHandler) ProcessTyped(_ Context, msg Msg) (interface{}, Result) {
+	m := msg.(msgCounter)
+	if m.FailOnHandler {
+		return nil, Result{ResponseBase: abci.ResponseBase{
+			Error: ABCIError(std.ErrInternal("message handler failure")),
+			Log:   "counter failure",
+		}}
+	}
+	return counterResponse{m.Counter + 1}, Result{}
+}
+
+// untypedCounterHandler is typedCounterHandler without ProcessTyped.
+type untypedCounterHandler struct {
+	nopTestHandler
+}
+
+func (h untypedCounterHandler) Process(ctx Context, msg Msg) Result {
+	resp, res := typedCounterHandler{}.ProcessTyped(ctx, msg)
+	if res.IsOK() {
+		res.Data = amino.MustMarshal(resp)
+	}
+	return res
+}
+
+func TestInvokeHandler(t *testing.T) {
+	for name, handler := range map[string]Handler{
+		"typed":   typedCounterHandler{},
+		"untyped": untypedCounterHandler{},
+	} {
+		rtr := NewRouter().AddRoute(routeMsgCounter, handler)
+
+		var resp counterResponse
+		require.NoError(t, InvokeHandler(rtr, Context{}, msgCounter{Counter: 1}, &resp), name)
+		require.Equal(t, counterResponse{2}, resp, name)
+		require.NoError(t, InvokeHandler(rtr, Context{}, msgCounter{Counter: 1}, nil), name)
+
+		err := InvokeHandler(rtr, Context{}, msgCounter{Counter: 1, FailOnHandler: true}, &resp)
+		require.True(t, goerrors.Is(err, std.InternalError{}), name)
+		require.Contains(t, err.Error(), "counter failure", name)
+
+		err = InvokeHandler(rtr, Context{}, msgCounter2{Counter: 1}, &resp)
+		require.True(t, goerrors.Is(err, std.UnknownRequestError{}), name)
+	}
+
+	// typed responses are set without encoding, so they must be assignable.
+	rtr := NewRouter().AddRoute(routeMsgCounter, typedCounterHandler{})
+	var str string
+	require.Error(t, InvokeHandler(rtr, Context{}, msgCounter{Counter: 1}, &str))
+	var resp interface{}
+	require.NoError(t, InvokeHandler(rtr, Context{}, msgCounter{Counter: 1}, &resp))
+	require.Equal(t, counterResponse{2}, resp)
+}
+
+// The responses of typed handlers are encoded in the data of the msgs of
+// DeliverTx.
+func TestTypedHandlerMsgData(t *testing.T) {
+	app := setupBaseApp(t, func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, typedCounterHandler{})
+	})
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(0, 1, 2))})
+	require.True(t, res.IsOK(), res.Log)
+	msgData, err := ParseMsgData(res.Data)
+	require.NoError(t, err)
+	require.Len(t, msgData, 2)
+	for i, md := range msgData {
+		var resp counterResponse
+		require.NoError(t, amino.Unmarshal(md.Data, &resp))
+		require.Equal(t, counterResponse{int64(i) + 2}, resp)
+	}
+}
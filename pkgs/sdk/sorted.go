@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Go randomizes the iteration order of maps, so handlers which write to the
+// stores or emit events while ranging over a map are not deterministic.
+// SortedKeys and IterateSorted iterate over maps in the order of their keys
+// instead.
+
+// SortedKeys returns the keys of the map m in ascending order, as a slice of
+// the key type of m, e.g. []string for a map[string]V. The keys must be
+// strings, integers, floats, or byte arrays, e.g. crypto.Address. It panics
+// if m is not such a map.
+func SortedKeys(m interface{}) interface{} {
+	rv := reflect.ValueOf(m)
+	keys := sortedMapKeys(rv)
+	slice := reflect.MakeSlice(reflect.SliceOf(rv.Type().Key()), len(keys), len(keys))
+	for i, key := range keys {
+		slice.Index(i).Set(key)
+	}
+	return slice.Interface()
+}
+
+// IterateSorted calls fn with the entries of the map m in ascending order
+// of keys, until it returns true. The keys are as for SortedKeys.
+func IterateSorted(m interface{}, fn func(key, value interface{}) (stop bool)) {
+	rv := reflect.ValueOf(m)
+	for _, key := range sortedMapKeys(rv) {
+		if fn(key.Interface(), rv.MapIndex(key).Interface()) {
+			return
+		}
+	}
+}
+
+func sortedMapKeys(rv reflect.Value) []reflect.Value {
+	if rv.Kind() != reflect.Map {
+		panic(fmt.Sprintf("expected a map, got %v", rv.Type()))
+	}
+	keys := rv.MapKeys()
+	var less func(a, b reflect.Value) bool
+	switch kt := rv.Type().Key(); kt.Kind() {
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	case reflect.Array:
+		if kt.Elem().Kind() != reflect.Uint8 {
+			panic(fmt.Sprintf("unsupported map key type %v", kt))
+		}
+		less = func(a, b reflect.Value) bool { return bytes.Compare(arrayBytes(a), arrayBytes(b)) < 0 }
+	default:
+		panic(fmt.Sprintf("unsupported map key type %v", kt))
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
+
+// arrayBytes returns the bytes of a byte array, which map keys cannot
+// slice since they are not addressable.
+func arrayBytes(rv reflect.Value) []byte {
+	bz := make([]byte, rv.Len())
+	reflect.Copy(reflect.ValueOf(bz), rv)
+	return bz
+}
@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/crypto"
+)
+
+func TestSortedKeys(t *testing.T) {
+	require.Equal(t, []string{"a", "b", "c"}, SortedKeys(map[string]int{"c": 3, "a": 1, "b": 2}))
+	require.Equal(t, []int64{-2, 0, 5}, SortedKeys(map[int64]bool{5: true, -2: true, 0: false}))
+	require.Equal(t, []uint8{1, 2}, SortedKeys(map[uint8]string{2: "", 1: ""}))
+	require.Empty(t, SortedKeys(map[string]int{}))
+
+	addr1, addr2 := crypto.Address{1}, crypto.Address{0, 2}
+	require.Equal(t, []crypto.Address{addr2, addr1}, SortedKeys(map[crypto.Address]int{addr1: 1, addr2: 2}))
+
+	require.Panics(t, func() { SortedKeys([]string{"a"}) })
+	require.Panics(t, func() { SortedKeys(map[[2]int]int{}) })
+	require.Panics(t, func() { SortedKeys(map[interface{}]int{}) })
+}
+
+func TestIterateSorted(t *testing.T) {
+	m := map[string]int{"c": 3, "a": 1, "b": 2}
+	var keys []string
+	var sum int
+	IterateSorted(m, func(key, value interface{}) bool {
+		keys = append(keys, key.(string))
+		sum += value.(int)
+		return key == "b"
+	})
+	require.Equal(t, []string{"a", "b"}, keys)
+	require.Equal(t, 3, sum)
+}
@@ -0,0 +1,149 @@
+package testutils
+
+import (
+	"fmt"
+	"time"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/sdk"
+)
+
+// GenesisTime is the time of InitChain and of the first block of the
+// TestChains.
+var GenesisTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultBlockInterval is the time between the blocks of the TestChains.
+const DefaultBlockInterval = 5 * time.Second
+
+// TestChain runs the blocks of an app in process, as a node would, for
+// integration tests and tools: it builds the headers of the blocks, and
+// begins, ends and commits them. The app must be loaded.
+//
+//	chain := testutils.NewTestChain(app, "test-chain")
+//	chain.InitChain(abci.RequestInitChain{})
+//	res := chain.RunBlock(tx1, tx2)
+//	chain.AdvanceTime(time.Hour)
+//	value := chain.QueryStore("main", key)
+//
+// The misuses of a TestChain panic with an error which says how to fix
+// them.
+type TestChain struct {
+	App           *sdk.BaseApp
+	ChainID       string
+	Proposer      crypto.Address // proposer of the blocks
+	BlockInterval time.Duration  // time between blocks
+
+	time time.Time // time of the next block
+}
+
+// NewTestChain returns a TestChain of the loaded app for chainID, whose next
+// block is at GenesisTime, proposed by TestAddress("proposer").
+func NewTestChain(app *sdk.BaseApp, chainID string) *TestChain {
+	if app == nil {
+		panic("testutils: NewTestChain of a nil app")
+	}
+	if err := sdk.ValidateChainID(chainID); err != nil {
+		panic(fmt.Sprintf("testutils: NewTestChain: %v", err))
+	}
+	return &TestChain{
+		App:           app,
+		ChainID:       chainID,
+		Proposer:      TestAddress("proposer"),
+		BlockInterval: DefaultBlockInterval,
+		time:          GenesisTime,
+	}
+}
+
+// Time returns the time of the next block.
+func (c *TestChain) Time() time.Time {
+	return c.time
+}
+
+// Height returns the height of the next block.
+func (c *TestChain) Height() int64 {
+	return c.App.LastBlockHeight() + 1
+}
+
+// InitChain initializes the app with req, for the chain ID of c and the
+// time of the next block if they are not set.
+func (c *TestChain) InitChain(req abci.RequestInitChain) abci.ResponseInitChain {
+	if chainID := c.App.ChainID(); chainID != "" {
+		panic(fmt.Sprintf("testutils: InitChain of app of chain %q which is already initialized; "+
+			"run its blocks with RunBlock", chainID))
+	}
+	if req.ChainID == "" {
+		req.ChainID = c.ChainID
+	} else if req.ChainID != c.ChainID {
+		panic(fmt.Sprintf("testutils: InitChain of chain %q on a TestChain of chain %q", req.ChainID, c.ChainID))
+	}
+	if req.Time.IsZero() {
+		req.Time = c.time
+	}
+	return c.App.InitChain(req)
+}
+
+// Header returns the header of the next block.
+func (c *TestChain) Header() *bft.Header {
+	return &bft.Header{
+		ChainID:         c.ChainID,
+		Height:          c.Height(),
+		Time:            c.time,
+		ProposerAddress: c.Proposer,
+	}
+}
+
+// RunBlock runs and commits the next block, of txs, and returns the
+// responses of its txs. The time of the block after it is BlockInterval
+// later.
+func (c *TestChain) RunBlock(txs ...[]byte) []abci.ResponseDeliverTx {
+	c.checkInitialized("RunBlock")
+	header := c.Header()
+	header.NumTxs = int64(len(txs))
+
+	c.App.BeginBlock(abci.RequestBeginBlock{Header: header})
+	responses := make([]abci.ResponseDeliverTx, len(txs))
+	for i, tx := range txs {
+		responses[i] = c.App.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+	}
+	c.App.EndBlock(abci.RequestEndBlock{Height: header.Height})
+	c.App.Commit()
+
+	c.time = c.time.Add(c.BlockInterval)
+	return responses
+}
+
+// AdvanceTime moves the time of the next block d later.
+func (c *TestChain) AdvanceTime(d time.Duration) {
+	if d < 0 {
+		panic(fmt.Sprintf("testutils: AdvanceTime of negative duration %s; the time of blocks cannot go back", d))
+	}
+	c.time = c.time.Add(d)
+}
+
+// QueryStore returns the value of key in the store of storeName, in the
+// last committed state, or nil if it is not set.
+func (c *TestChain) QueryStore(storeName string, key []byte) []byte {
+	c.checkInitialized("QueryStore")
+	res := c.App.Query(abci.RequestQuery{
+		Path:   fmt.Sprintf("/.store/%s/key", storeName),
+		Data:   key,
+		Height: c.App.LastBlockHeight(),
+	})
+	if !res.IsOK() {
+		panic(fmt.Sprintf("testutils: QueryStore of store %q: %s; is the store mounted?", storeName, res.Log))
+	}
+	return res.Value
+}
+
+// checkInitialized panics if the app is not initialized for the chain of c.
+func (c *TestChain) checkInitialized(method string) {
+	switch chainID := c.App.ChainID(); chainID {
+	case "":
+		panic(fmt.Sprintf("testutils: %s before InitChain; call TestChain.InitChain first", method))
+	case c.ChainID:
+	default:
+		panic(fmt.Sprintf("testutils: %s of app of chain %q on a TestChain of chain %q", method, chainID, c.ChainID))
+	}
+}
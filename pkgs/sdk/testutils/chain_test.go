@@ -0,0 +1,45 @@
+package testutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+func TestTestChain(t *testing.T) {
+	app, _ := loadCounterApp(t)
+	chain := NewTestChain(app, replayChainID)
+	require.PanicsWithValue(t, "testutils: RunBlock before InitChain; call TestChain.InitChain first",
+		func() { chain.RunBlock() })
+	chain.InitChain(abci.RequestInitChain{})
+	require.Panics(t, func() { chain.InitChain(abci.RequestInitChain{}) })
+
+	tx := amino.MustMarshal(std.NewTx([]std.Msg{NewTestMsg(TestAddress("signer"))}, NewTestFee(), nil, ""))
+	for height := int64(1); height <= 3; height++ {
+		res := chain.RunBlock(tx, tx)
+		require.Len(t, res, 2)
+		require.True(t, res[0].IsOK(), res[0].Log)
+	}
+	require.Equal(t, int64(3), app.LastBlockHeight())
+	require.Equal(t, uint64(6), decodeCounter(chain.QueryStore("main", counterKey)))
+	require.Nil(t, chain.QueryStore("main", []byte("unknown")))
+	require.Panics(t, func() { chain.QueryStore("unknown", counterKey) })
+
+	// the headers follow the committed blocks.
+	require.Equal(t, GenesisTime.Add(3*DefaultBlockInterval), chain.Time())
+	chain.AdvanceTime(time.Hour)
+	header := chain.Header()
+	require.Equal(t, int64(4), header.Height)
+	require.Equal(t, GenesisTime.Add(3*DefaultBlockInterval+time.Hour), header.Time)
+	require.Equal(t, TestAddress("proposer"), header.ProposerAddress)
+	require.Panics(t, func() { chain.AdvanceTime(-time.Second) })
+
+	// the chain ID must be the one of the app.
+	other := NewTestChain(app, "other-chain")
+	require.Panics(t, func() { other.RunBlock() })
+}
@@ -0,0 +1,222 @@
+package testutils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// TxGenerator returns the tx at index i of the block at height.
+type TxGenerator func(height int64, i int) std.Tx
+
+// BlockReplayer replays generated blocks of txs on an app, e.g. to
+// benchmark its handlers. The app must be loaded, and initialized with
+// InitChain for ChainID.
+type BlockReplayer struct {
+	App         *sdk.BaseApp
+	Generate    TxGenerator
+	Blocks      int // blocks per run
+	TxsPerBlock int
+	ChainID     string
+}
+
+// ReplayStats are the statistics of a BlockReplayer run. The durations of
+// the phases are the totals of the blocks.
+type ReplayStats struct {
+	Blocks     int
+	Txs        int
+	FailedTxs  int
+	GasUsed    int64
+	Allocs     uint64 // heap allocations of the phases
+	AllocBytes uint64
+
+	BeginBlock time.Duration
+	DeliverTx  time.Duration
+	EndBlock   time.Duration
+	Commit     time.Duration
+}
+
+// Duration returns the total duration of the phases.
+func (s ReplayStats) Duration() time.Duration {
+	return s.BeginBlock + s.DeliverTx + s.EndBlock + s.Commit
+}
+
+// TxsPerSecond returns the throughput of the run in txs.
+func (s ReplayStats) TxsPerSecond() float64 {
+	return perSecond(float64(s.Txs), s.Duration())
+}
+
+// GasPerSecond returns the throughput of the run in gas.
+func (s ReplayStats) GasPerSecond() float64 {
+	return perSecond(float64(s.GasUsed), s.Duration())
+}
+
+func perSecond(n float64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return n / d.Seconds()
+}
+
+func (s ReplayStats) String() string {
+	return fmt.Sprintf("%d blocks, %d txs (%d failed), %d gas in %v: %.0f txs/s, %.0f gas/s, "+
+		"%d allocs (%d B); begin %v, deliver %v, end %v, commit %v",
+		s.Blocks, s.Txs, s.FailedTxs, s.GasUsed, s.Duration(), s.TxsPerSecond(), s.GasPerSecond(),
+		s.Allocs, s.AllocBytes, s.BeginBlock, s.DeliverTx, s.EndBlock, s.Commit)
+}
+
+// ReportMetrics reports the throughputs and the per-block latency of the
+// phases as metrics of b.
+func (s ReplayStats) ReportMetrics(b *testing.B) {
+	b.ReportMetric(s.TxsPerSecond(), "txs/s")
+	b.ReportMetric(s.GasPerSecond(), "gas/s")
+	if s.Blocks == 0 {
+		return
+	}
+	blocks := float64(s.Blocks)
+	b.ReportMetric(float64(s.BeginBlock.Nanoseconds())/blocks, "begin-ns/block")
+	b.ReportMetric(float64(s.DeliverTx.Nanoseconds())/blocks, "deliver-ns/block")
+	b.ReportMetric(float64(s.EndBlock.Nanoseconds())/blocks, "end-ns/block")
+	b.ReportMetric(float64(s.Commit.Nanoseconds())/blocks, "commit-ns/block")
+}
+
+// add adds the stats of another run to s.
+func (s *ReplayStats) add(o ReplayStats) {
+	s.Blocks += o.Blocks
+	s.Txs += o.Txs
+	s.FailedTxs += o.FailedTxs
+	s.GasUsed += o.GasUsed
+	s.Allocs += o.Allocs
+	s.AllocBytes += o.AllocBytes
+	s.BeginBlock += o.BeginBlock
+	s.DeliverTx += o.DeliverTx
+	s.EndBlock += o.EndBlock
+	s.Commit += o.Commit
+}
+
+// Run replays Blocks blocks of TxsPerBlock txs, following the last
+// committed block of the app. The txs are generated and encoded before each
+// block, outside of the measures. Failed txs are counted, and do not stop
+// the run.
+func (r BlockReplayer) Run() ReplayStats {
+	var stats ReplayStats
+	var mem runtime.MemStats
+	txs := make([][]byte, r.TxsPerBlock)
+	for b := 0; b < r.Blocks; b++ {
+		height := r.App.LastBlockHeight() + 1
+		for i := range txs {
+			txs[i] = amino.MustMarshal(r.Generate(height, i))
+		}
+		header := &bft.Header{ChainID: r.ChainID, Height: height, Time: time.Now()}
+
+		runtime.ReadMemStats(&mem)
+		mallocs, bytes := mem.Mallocs, mem.TotalAlloc
+
+		start := time.Now()
+		r.App.BeginBlock(abci.RequestBeginBlock{Header: header})
+		stats.BeginBlock += time.Since(start)
+
+		start = time.Now()
+		for _, tx := range txs {
+			res := r.App.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+			if !res.IsOK() {
+				stats.FailedTxs++
+			}
+			stats.GasUsed += res.GasUsed
+		}
+		stats.DeliverTx += time.Since(start)
+
+		start = time.Now()
+		r.App.EndBlock(abci.RequestEndBlock{Height: height})
+		stats.EndBlock += time.Since(start)
+
+		start = time.Now()
+		r.App.Commit()
+		stats.Commit += time.Since(start)
+
+		runtime.ReadMemStats(&mem)
+		stats.Allocs += mem.Mallocs - mallocs
+		stats.AllocBytes += mem.TotalAlloc - bytes
+		stats.Blocks++
+		stats.Txs += len(txs)
+	}
+	return stats
+}
+
+// Benchmark runs r b.N times, and reports the metrics of all the runs.
+func (r BlockReplayer) Benchmark(b *testing.B) {
+	b.Helper()
+	var stats ReplayStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stats.add(r.Run())
+	}
+	b.StopTimer()
+	if stats.FailedTxs > 0 {
+		b.Fatalf("replay: %v", stats)
+	}
+	stats.ReportMetrics(b)
+}
+
+//----------------------------------------
+// Counter txs
+
+// CounterRoute is the route of TestMsgs, handled by a CounterHandler.
+const CounterRoute = "TestMsg"
+
+var counterKey = []byte("counter")
+
+// CounterTxs returns a TxGenerator of txs with a single TestMsg signed by
+// signer, to be handled by a CounterHandler. The txs are not signed, so the
+// app must not verify signatures.
+func CounterTxs(signer crypto.Address) TxGenerator {
+	msgs := []std.Msg{NewTestMsg(signer)}
+	return func(height int64, i int) std.Tx {
+		return std.NewTx(msgs, NewTestFee(), nil, "")
+	}
+}
+
+// CounterHandler is a handler of TestMsgs which increments a counter in
+// the store of its key, and answers queries with the counter.
+type CounterHandler struct {
+	Key store.StoreKey
+}
+
+var _ sdk.Handler = CounterHandler{}
+
+func (h CounterHandler) Process(ctx sdk.Context, msg std.Msg) sdk.Result {
+	if _, ok := msg.(*TestMsg); !ok {
+		return sdk.ABCIResultFromError(std.ErrUnknownRequest(fmt.Sprintf("unexpected msg type %T", msg)))
+	}
+	stor := ctx.Store(h.Key)
+	stor.Set(counterKey, encodeCounter(decodeCounter(stor.Get(counterKey))+1))
+	return sdk.Result{}
+}
+
+func (h CounterHandler) Query(ctx sdk.Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+	res.Value = ctx.Store(h.Key).Get(counterKey)
+	return res
+}
+
+func encodeCounter(n uint64) []byte {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, n)
+	return bz
+}
+
+func decodeCounter(bz []byte) uint64 {
+	if len(bz) == 0 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(bz)
+}
@@ -0,0 +1,77 @@
+package testutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/log"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/iavl"
+)
+
+const replayChainID = "replay-chain"
+
+func newCounterApp(t testing.TB) (*sdk.BaseApp, store.StoreKey) {
+	app, mainKey := loadCounterApp(t)
+	app.InitChain(abci.RequestInitChain{ChainID: replayChainID})
+	return app, mainKey
+}
+
+// loadCounterApp returns a loaded app with a CounterHandler of the store
+// "main", which is not initialized.
+func loadCounterApp(t testing.TB) (*sdk.BaseApp, store.StoreKey) {
+	db := dbm.NewMemDB()
+	mainKey := store.NewKVStoreKey("main")
+	baseKey := store.NewKVStoreKey("base")
+
+	app := sdk.NewBaseApp("replay", log.NewNopLogger(), db, baseKey, mainKey)
+	app.MountStoreWithDB(mainKey, iavl.StoreConstructor, db)
+	app.MountStoreWithDB(baseKey, dbadapter.StoreConstructor, db)
+	app.Router().AddRoute(CounterRoute, CounterHandler{Key: mainKey})
+	require.NoError(t, app.LoadLatestVersion())
+	return app, mainKey
+}
+
+func TestBlockReplayer(t *testing.T) {
+	app, _ := newCounterApp(t)
+	r := BlockReplayer{
+		App:         app,
+		Generate:    CounterTxs(TestAddress("signer")),
+		Blocks:      3,
+		TxsPerBlock: 4,
+		ChainID:     replayChainID,
+	}
+	stats := r.Run()
+	require.Equal(t, 3, stats.Blocks)
+	require.Equal(t, 12, stats.Txs)
+	require.Equal(t, 0, stats.FailedTxs)
+	require.True(t, stats.GasUsed > 0)
+	require.True(t, stats.Allocs > 0)
+	require.True(t, stats.TxsPerSecond() > 0)
+	require.Equal(t, int64(3), app.LastBlockHeight())
+
+	res := app.Query(abci.RequestQuery{Path: "/" + CounterRoute})
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, uint64(12), decodeCounter(res.Value))
+
+	// a run follows the last committed block.
+	stats = r.Run()
+	require.Equal(t, 0, stats.FailedTxs)
+	require.Equal(t, int64(6), app.LastBlockHeight())
+}
+
+func BenchmarkReplayCounter(b *testing.B) {
+	app, _ := newCounterApp(b)
+	BlockReplayer{
+		App:         app,
+		Generate:    CounterTxs(TestAddress("signer")),
+		Blocks:      10,
+		TxsPerBlock: 100,
+		ChainID:     replayChainID,
+	}.Benchmark(b)
+}
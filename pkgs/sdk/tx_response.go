@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"fmt"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+// TxResponse is the result of a tx for clients, e.g. of a broadcast, which
+// servers can encode with amino JSON or encoding/json.
+type TxResponse struct {
+	Height    int64    `json:"height"` // 0 for CheckTx
+	TxHash    string   `json:"txhash"`
+	Code      uint32   `json:"code"` // 0 for success
+	Codespace string   `json:"codespace,omitempty"`
+	Data      []byte   `json:"data,omitempty"`
+	RawLog    string   `json:"raw_log,omitempty"`
+	Logs      []MsgLog `json:"logs,omitempty"`
+	GasWanted int64    `json:"gas_wanted"`
+	GasUsed   int64    `json:"gas_used"`
+	Events    []Event  `json:"events,omitempty"`
+}
+
+// MsgLog is the result of a msg of a tx, from the MsgData of the tx result.
+type MsgLog struct {
+	MsgIndex int     `json:"msg_index"`
+	Success  bool    `json:"success"`
+	Route    string  `json:"route"`
+	Type     string  `json:"type"`
+	Data     []byte  `json:"data,omitempty"`
+	Events   []Event `json:"events,omitempty"`
+}
+
+// NewTxResponseFromCheck returns the TxResponse of the CheckTx of txBytes.
+func NewTxResponseFromCheck(txBytes []byte, res abci.ResponseCheckTx) TxResponse {
+	return newTxResponse(0, txBytes, res.ResponseBase, res.GasWanted, res.GasUsed)
+}
+
+// NewTxResponseFromDeliver returns the TxResponse of the DeliverTx of
+// txBytes in the block of height.
+func NewTxResponseFromDeliver(height int64, txBytes []byte, res abci.ResponseDeliverTx) TxResponse {
+	return newTxResponse(height, txBytes, res.ResponseBase, res.GasWanted, res.GasUsed)
+}
+
+func newTxResponse(height int64, txBytes []byte, res abci.ResponseBase, gasWanted, gasUsed int64) TxResponse {
+	txr := TxResponse{
+		Height:    height,
+		TxHash:    fmt.Sprintf("%X", bft.Tx(txBytes).Hash()),
+		Data:      res.Data,
+		RawLog:    res.Log,
+		GasWanted: gasWanted,
+		GasUsed:   gasUsed,
+		Events:    res.Events,
+	}
+	if res.Error != nil {
+		txr.Codespace, txr.Code, _ = std.ABCIInfo(res.Error, false)
+	}
+	txr.Logs = msgLogs(res)
+	return txr
+}
+
+// msgLogs returns the MsgLog of the msgs of res, or nil if its data is not
+// framed, e.g. of CheckTx or of an app with SetLegacyMsgData.
+func msgLogs(res abci.ResponseBase) []MsgLog {
+	if len(res.Data) == 0 {
+		return nil
+	}
+	msgData, err := ParseMsgData(res.Data)
+	if err != nil {
+		return nil
+	}
+	logs := make([]MsgLog, len(msgData))
+	// the events of the msgs follow those of the ante handler, and are
+	// dropped if a msg fails.
+	events := res.Events
+	for len(events) > 0 && isAnteEvent(events[0]) {
+		events = events[1:]
+	}
+	for i, md := range msgData {
+		if res.Error != nil {
+			md.NumEvents = 0
+		}
+		if md.NumEvents < 0 || md.NumEvents > len(events) {
+			return nil
+		}
+		logs[i] = MsgLog{
+			MsgIndex: i,
+			// the msgs are run until the first failure.
+			Success: res.Error == nil || i < len(msgData)-1,
+			Route:   md.Route,
+			Type:    md.Type,
+			Data:    md.Data,
+			Events:  events[:md.NumEvents:md.NumEvents],
+		}
+		if md.NumEvents == 0 {
+			logs[i].Events = nil
+		}
+		events = events[md.NumEvents:]
+	}
+	return logs
+}
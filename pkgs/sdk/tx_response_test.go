@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+func TestTxResponse(t *testing.T) {
+	anteOpt := func(bapp *BaseApp) {
+		bapp.SetAnteHandler(func(ctx Context, tx Tx, simulate bool) (newCtx Context, res Result, abort bool) {
+			newCtx = ctx.WithGasMeter(store.NewGasMeter(100))
+			return newCtx, Result{GasWanted: 100}, false
+		})
+	}
+	routerOpt := func(bapp *BaseApp) {
+		bapp.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			counter := msg.(msgCounter).Counter
+			ctx.GasMeter().ConsumeGas(counter*10, "test")
+			if counter == 0 {
+				return Result{ResponseBase: abci.ResponseBase{Error: ABCIError(std.ErrUnauthorized("zero counter")), Log: "zero counter"}}
+			}
+			ctx.EventLogger().EmitEvent(abci.NewEvent("counter", abci.NewAttribute("counter", string(rune('0'+counter)))))
+			return Result{ResponseBase: abci.ResponseBase{Data: []byte{byte(counter)}}}
+		}))
+	}
+	app := setupBaseApp(t, anteOpt, routerOpt)
+	app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: 1}})
+
+	cases := []struct {
+		name   string
+		tx     std.Tx
+		golden string
+	}{
+		{"success", newTxCounter(0, 1, 2), `{
+			"height": 1,
+			"txhash": "354BEF68E8CB8F2EBB05C1DC4B61D4AB020CDA7C1CAABCDE668A1E84B8BBFF8F",
+			"code": 0,
+			"data": "ChsKCm1zZ0NvdW50ZXISCGNvdW50ZXIxGgEBIAIKGwoKbXNnQ291bnRlchIIY291bnRlcjEaAQIgAg==",
+			"raw_log": "msg:0,success:true,log:,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"1\",\"index\":true}]}]\nmsg:1,success:true,log:,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"1\",\"index\":true}]},{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"2\",\"index\":true}]}]",
+			"logs": [
+				{"msg_index": 0, "success": true, "route": "msgCounter", "type": "counter1", "data": "AQ==",
+					"events": [{"type": "counter", "attributes": [{"key": "counter", "value": "1", "index": true}]}]},
+				{"msg_index": 1, "success": true, "route": "msgCounter", "type": "counter1", "data": "Ag==",
+					"events": [{"type": "counter", "attributes": [{"key": "counter", "value": "2", "index": true}]}]}
+			],
+			"gas_wanted": 100,
+			"gas_used": 30,
+			"events": [
+				{"type": "counter", "attributes": [{"key": "counter", "value": "1", "index": true}]},
+				{"type": "counter", "attributes": [{"key": "counter", "value": "2", "index": true}]},
+				{"type": "tx", "attributes": [{"key": "index", "value": "0", "index": true}]}
+			]
+		}`},
+		{"failure", newTxCounter(1, 1, 0, 2), `{
+			"height": 1,
+			"txhash": "6FF6CA0788DA12E18ECF1D7D98C3853D639F31A44BBFA5C2127ACAC8B66E7E25",
+			"code": 4,
+			"codespace": "sdk",
+			"data": "ChsKCm1zZ0NvdW50ZXISCGNvdW50ZXIxGgEBIAIKFgoKbXNnQ291bnRlchIIY291bnRlcjE=",
+			"raw_log": "msg:0,success:true,log:,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"1\",\"index\":true}]}]\nmsg:1,success:false,log:zero counter,events:[{\"type\":\"counter\",\"attributes\":[{\"key\":\"counter\",\"value\":\"1\",\"index\":true}]}]",
+			"logs": [
+				{"msg_index": 0, "success": true, "route": "msgCounter", "type": "counter1", "data": "AQ=="},
+				{"msg_index": 1, "success": false, "route": "msgCounter", "type": "counter1"}
+			],
+			"gas_wanted": 100,
+			"gas_used": 10,
+			"events": [
+				{"type": "tx", "attributes": [{"key": "index", "value": "1", "index": true}]}
+			]
+		}`},
+		{"out of gas", newTxCounter(2, 9, 9), `{
+			"height": 1,
+			"txhash": "049F37A741ECA421E8D4D67781902F7108847D3BBECBE012209A348A5EB4E871",
+			"code": 12,
+			"codespace": "sdk",
+			"raw_log": "out of gas, gasWanted: 100, gasUsed: 180 location: test",
+			"gas_wanted": 100,
+			"gas_used": 180,
+			"events": [{"type": "tx", "attributes": [{"key": "index", "value": "2", "index": true}]}]
+		}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			txBytes := amino.MustMarshal(tc.tx)
+			res := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+			txr := NewTxResponseFromDeliver(1, txBytes, res)
+			bz, err := json.Marshal(txr)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.golden, string(bz))
+
+			// amino JSON encodes the same fields, with the types of events.
+			abz, err := amino.MarshalJSON(txr)
+			require.NoError(t, err)
+			var decoded TxResponse
+			require.NoError(t, amino.UnmarshalJSON(abz, &decoded))
+			require.Equal(t, txr, decoded)
+		})
+	}
+
+	// msgs are not run on CheckTx.
+	txBytes := amino.MustMarshal(newTxCounter(3, 1))
+	txr := NewTxResponseFromCheck(txBytes, app.CheckTx(abci.RequestCheckTx{Tx: txBytes}))
+	require.True(t, txr.Code == 0 && txr.Height == 0, txr.RawLog)
+	require.Equal(t, int64(100), txr.GasWanted)
+	require.Nil(t, txr.Data)
+	require.Nil(t, txr.Logs)
+}
@@ -1,16 +1,28 @@
 package sdk
 
 import (
+	"github.com/gnolang/gno/pkgs/amino"
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/std"
 )
 
 // Router provides handlers for each transaction type.
 type Router interface {
 	AddRoute(r string, h Handler) Router
+	// AddRouteWithMiddleware adds a route whose handler is wrapped by mw,
+	// inside the middleware of Use.
+	AddRouteWithMiddleware(r string, h Handler, mw ...HandlerMiddleware) Router
+	// Use wraps the handlers of all the routes, including the ones added
+	// later, with mw.
+	Use(mw ...HandlerMiddleware) Router
 	Route(path string) Handler
 }
 
+// HandlerMiddleware wraps the Process and Query of a Handler, e.g. to record
+// metrics, restrict msgs, or add events to results.
+type HandlerMiddleware func(next Handler) Handler
+
 // A Handler handles processing messages and answering queries
 // for a particular application concern.
 type Handler interface {
@@ -20,22 +32,108 @@ type Handler interface {
 	Query(ctx Context, req abci.RequestQuery) abci.ResponseQuery
 }
 
+// TypedHandler is a Handler which also returns the responses to msgs as
+// values, so that modules in the same process can call each other with
+// InvokeHandler and get the responses without encoding them.
+//
+// For the msgs of txs, ProcessTyped is called instead of Process, and the
+// data of the result is the amino encoding of the response, if not nil.
+// Middleware which is not a TypedHandler hides ProcessTyped, and Process is
+// called instead.
+type TypedHandler interface {
+	Handler
+	// ProcessTyped is as Process, but also returns the response to msg.
+	ProcessTyped(ctx Context, msg Msg) (resp interface{}, res Result)
+}
+
 // Result is the union of ResponseDeliverTx and ResponseCheckTx plus events.
 type Result struct {
 	abci.ResponseBase
-	GasWanted int64
-	GasUsed   int64
+	GasWanted    int64
+	GasUsed      int64
+	Sender       crypto.Address // see Context.WithSender
+	Priority     int64          // see Context.WithPriority
+	GasBreakdown []GasUsage     // not part of consensus, see SetGasBreakdown
+}
+
+// GasUsage is the gas used by a category of operations, e.g. "store".
+type GasUsage struct {
+	Category string
+	Gas      int64
+}
+
+// MsgData is the data returned by the handler of a msg of a tx. The data of
+// a tx result is the amino encoding of the MsgData of its msgs, in order,
+// unless SetLegacyMsgData is set.
+type MsgData struct {
+	Route     string
+	Type      string
+	Data      []byte
+	NumEvents int // of the events of the tx result, emitted by the msg
+}
+
+// ParseMsgData returns the MsgData of the msgs of a tx, from the data of its
+// result.
+func ParseMsgData(data []byte) ([]MsgData, error) {
+	var msgData []MsgData
+	if err := amino.Unmarshal(data, &msgData); err != nil {
+		return nil, err
+	}
+	return msgData, nil
 }
 
 // AnteHandler authenticates transactions, before their internal messages are handled.
 type AnteHandler func(ctx Context, tx Tx, simulate bool) (newCtx Context, result Result, abort bool)
 
+// TxDecoder decodes the bytes of a tx, as in CheckTx and DeliverTx. See
+// BaseApp.SetTxDecoder.
+type TxDecoder func(txBytes []byte) (Tx, error)
+
+// TxEncoder encodes a tx, as TxDecoder decodes it. See
+// BaseApp.SetTxEncoder.
+type TxEncoder func(tx Tx) ([]byte, error)
+
+// DefaultTxDecoder decodes txs with amino.
+func DefaultTxDecoder(txBytes []byte) (tx Tx, err error) {
+	err = amino.Unmarshal(txBytes, &tx)
+	return tx, err
+}
+
+// DefaultTxEncoder encodes txs with amino.
+func DefaultTxEncoder(tx Tx) ([]byte, error) {
+	return amino.Marshal(tx)
+}
+
+// SimulateUnsignedRequest is the amino-encoded data of the query
+// "/app/simulate_unsigned", which simulates an unsigned tx of Msgs, Fee and
+// Memo, e.g. to estimate its gas before it is signed. Sender signs it in
+// place of the signers of the msgs, i.e. it pays the fee and its sequence
+// is incremented; the gas of its signature is charged as if it were signed.
+type SimulateUnsignedRequest struct {
+	Msgs   []Msg
+	Fee    std.Fee
+	Memo   string
+	Sender crypto.Address
+}
+
+// Tx returns the unsigned tx of req.
+func (req SimulateUnsignedRequest) Tx() Tx {
+	return Tx{Msgs: req.Msgs, Fee: req.Fee, Memo: req.Memo}
+}
+
+// RecoveryHandler returns the result, with its error and log, of a tx for
+// the value r of a panic in the tx, and whether it handles r. See
+// BaseApp.AddRecoveryHandler.
+type RecoveryHandler func(r interface{}) (result Result, ok bool)
+
 // Exports from std.
 type Msg = std.Msg
 type Tx = std.Tx
 type Coin = std.Coin
 type Coins = std.Coins
-type GasPrice = std.GasPrice
+type Dec = std.Dec
+type DecCoin = std.DecCoin
+type DecCoins = std.DecCoins
 
 var ParseGasPrice = std.ParseGasPrice
 var ParseGasPrices = std.ParseGasPrices
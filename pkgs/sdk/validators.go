@@ -0,0 +1,97 @@
+package sdk
+
+import (
+	"fmt"
+	"math/bits"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+)
+
+// UpdatePolicy is a policy of a chain on the validator updates of EndBlock,
+// beyond their validity. A zero field imposes nothing, so the zero
+// UpdatePolicy accepts all updates.
+type UpdatePolicy struct {
+	// MaxPowerChange is the max sum of the changes of power of the updated
+	// validators in a block, the powers of added and removed ones included.
+	MaxPowerChange int64
+
+	// MaxValidatorShare is the max share, in percent, of the total power of
+	// the updated validator set which a validator may have.
+	MaxValidatorShare int64
+
+	// MinPower is the min power of the validators: updates must set at
+	// least MinPower, or 0 to remove the validator.
+	MinPower int64
+}
+
+// ValidateBasic returns an error if a field of the policy is negative, or
+// MaxValidatorShare is over 100.
+func (policy UpdatePolicy) ValidateBasic() error {
+	switch {
+	case policy.MaxPowerChange < 0:
+		return fmt.Errorf("negative max power change %d", policy.MaxPowerChange)
+	case policy.MaxValidatorShare < 0 || policy.MaxValidatorShare > 100:
+		return fmt.Errorf("max validator share %d%% is not within 0%% and 100%%", policy.MaxValidatorShare)
+	case policy.MinPower < 0:
+		return fmt.Errorf("negative min power %d", policy.MinPower)
+	}
+	return nil
+}
+
+// ValidateValidatorUpdates returns an error if the updates of the validator
+// set prev, which are valid, do not comply with policy. The powers of prev
+// are not checked against MinPower, and the shares are checked only if
+// there are updates, so that updates need not fix a set which does not
+// comply all at once, but the validator set after updates must comply with
+// MaxValidatorShare.
+func ValidateValidatorUpdates(prev abci.ValidatorUpdates, updates []abci.ValidatorUpdate, policy UpdatePolicy) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	powers := make(map[string]int64, len(prev)+len(updates))
+	for _, vu := range prev {
+		powers[string(vu.PubKey.Bytes())] = vu.Power
+	}
+	var change uint64
+	for _, vu := range updates {
+		key := string(vu.PubKey.Bytes())
+		if vu.Power != 0 && vu.Power < policy.MinPower {
+			return fmt.Errorf("validator %s has power %d, under the min power %d",
+				vu.PubKey, vu.Power, policy.MinPower)
+		}
+		if diff := vu.Power - powers[key]; diff < 0 {
+			change += uint64(-diff)
+		} else {
+			change += uint64(diff)
+		}
+		if vu.Power == 0 {
+			delete(powers, key)
+		} else {
+			powers[key] = vu.Power
+		}
+	}
+	if policy.MaxPowerChange > 0 && change > uint64(policy.MaxPowerChange) {
+		return fmt.Errorf("validator updates change the power by %d, over the max change %d",
+			change, policy.MaxPowerChange)
+	}
+
+	if policy.MaxValidatorShare == 0 {
+		return nil
+	}
+	var total uint64
+	for _, power := range powers {
+		total += uint64(power)
+	}
+	// in order, for the same error on all nodes.
+	for _, key := range SortedKeys(powers).([]string) {
+		// power/total > share/100, without overflows.
+		hi1, lo1 := bits.Mul64(uint64(powers[key]), 100)
+		hi2, lo2 := bits.Mul64(total, uint64(policy.MaxValidatorShare))
+		if hi1 > hi2 || hi1 == hi2 && lo1 > lo2 {
+			return fmt.Errorf("validator %X would have power %d of %d, over the max share %d%%",
+				[]byte(key), powers[key], total, policy.MaxValidatorShare)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+)
+
+func TestValidateValidatorUpdates(t *testing.T) {
+	newUpdate := func(power int64) abci.ValidatorUpdate {
+		pub := ed25519.GenPrivKey().PubKey()
+		return abci.ValidatorUpdate{Address: pub.Address(), PubKey: pub, Power: power}
+	}
+	withPower := func(vu abci.ValidatorUpdate, power int64) abci.ValidatorUpdate {
+		vu.Power = power
+		return vu
+	}
+	val1, val2, val3 := newUpdate(40), newUpdate(30), newUpdate(30)
+	prev := abci.ValidatorUpdates{val1, val2, val3}
+
+	cases := []struct {
+		name    string
+		updates []abci.ValidatorUpdate
+		policy  UpdatePolicy
+		valid   bool
+	}{
+		{"zero policy", []abci.ValidatorUpdate{withPower(val1, 1000), newUpdate(1)}, UpdatePolicy{}, true},
+		{"no updates", nil, UpdatePolicy{MaxValidatorShare: 10, MinPower: 1000}, true},
+		{"change within cap", []abci.ValidatorUpdate{withPower(val1, 30), withPower(val2, 40)}, UpdatePolicy{MaxPowerChange: 20}, true},
+		{"change over cap", []abci.ValidatorUpdate{withPower(val1, 30), withPower(val2, 41)}, UpdatePolicy{MaxPowerChange: 20}, false},
+		{"addition over cap", []abci.ValidatorUpdate{newUpdate(21)}, UpdatePolicy{MaxPowerChange: 20}, false},
+		{"removal within cap", []abci.ValidatorUpdate{withPower(val2, 0)}, UpdatePolicy{MaxPowerChange: 30}, true},
+		{"removal over cap", []abci.ValidatorUpdate{withPower(val2, 0)}, UpdatePolicy{MaxPowerChange: 29}, false},
+		{"share at max", []abci.ValidatorUpdate{withPower(val1, 60)}, UpdatePolicy{MaxValidatorShare: 50}, true},
+		{"share over max", []abci.ValidatorUpdate{withPower(val1, 61)}, UpdatePolicy{MaxValidatorShare: 50}, false},
+		{"share over max by removal", []abci.ValidatorUpdate{withPower(val3, 0)}, UpdatePolicy{MaxValidatorShare: 50}, false},
+		{"share of other validator", []abci.ValidatorUpdate{withPower(val2, 20)}, UpdatePolicy{MaxValidatorShare: 44}, false},
+		{"power at min", []abci.ValidatorUpdate{newUpdate(10)}, UpdatePolicy{MinPower: 10}, true},
+		{"power under min", []abci.ValidatorUpdate{withPower(val1, 9)}, UpdatePolicy{MinPower: 10}, false},
+		{"removal under min", []abci.ValidatorUpdate{withPower(val1, 0)}, UpdatePolicy{MinPower: 50}, true},
+		{"huge powers", []abci.ValidatorUpdate{withPower(val1, 1<<60), withPower(val2, 1<<60)}, UpdatePolicy{MaxValidatorShare: 50}, true},
+	}
+	for _, c := range cases {
+		err := ValidateValidatorUpdates(prev, c.updates, c.policy)
+		if c.valid {
+			require.NoError(t, err, c.name)
+		} else {
+			require.Error(t, err, c.name)
+		}
+	}
+
+	require.Error(t, UpdatePolicy{MaxPowerChange: -1}.ValidateBasic())
+	require.Error(t, UpdatePolicy{MaxValidatorShare: 101}.ValidateBasic())
+	require.Error(t, UpdatePolicy{MinPower: -1}.ValidateBasic())
+	require.NoError(t, UpdatePolicy{MaxPowerChange: 1, MaxValidatorShare: 100, MinPower: 1}.ValidateBasic())
+}
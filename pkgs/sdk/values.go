@@ -0,0 +1,146 @@
+package sdk
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/prefix"
+)
+
+// SetValue, GetValue, MustGetValue and DeleteValue store values of concrete
+// types by key, in their amino encoding with cdc, or with the global codec
+// of amino.Marshal if cdc is nil. They panic with the key and the type if a
+// value cannot be encoded or decoded, e.g. if the stored bytes are corrupted
+// or of another type.
+
+// SetValue sets the encoding of v at key of st.
+func SetValue(st store.Store, cdc *amino.Codec, key []byte, v interface{}) {
+	bz, err := marshalValue(cdc, v)
+	if err != nil {
+		panic(fmt.Sprintf("cannot encode %T at key %X: %v", v, key, err))
+	}
+	st.Set(key, bz)
+}
+
+// GetValue decodes the value at key of st into ptr, a pointer to a value of
+// its type, and returns true, or returns false if there is no value at key,
+// leaving ptr unchanged.
+func GetValue(st store.Store, cdc *amino.Codec, key []byte, ptr interface{}) bool {
+	bz := st.Get(key)
+	if bz == nil {
+		return false
+	}
+	if err := unmarshalValue(cdc, bz, ptr); err != nil {
+		panic(fmt.Sprintf("cannot decode %v at key %X: %v", reflect.TypeOf(ptr).Elem(), key, err))
+	}
+	return true
+}
+
+// MustGetValue decodes the value at key of st into ptr like GetValue, and
+// panics if there is no value at key.
+func MustGetValue(st store.Store, cdc *amino.Codec, key []byte, ptr interface{}) {
+	if !GetValue(st, cdc, key, ptr) {
+		panic(fmt.Sprintf("no %v at key %X", reflect.TypeOf(ptr).Elem(), key))
+	}
+}
+
+// DeleteValue deletes the value at key of st, if any.
+func DeleteValue(st store.Store, key []byte) {
+	st.Delete(key)
+}
+
+func marshalValue(cdc *amino.Codec, v interface{}) ([]byte, error) {
+	if cdc == nil {
+		return amino.Marshal(v)
+	}
+	return cdc.Marshal(v)
+}
+
+func unmarshalValue(cdc *amino.Codec, bz []byte, ptr interface{}) error {
+	if cdc == nil {
+		return amino.Unmarshal(bz, ptr)
+	}
+	return cdc.Unmarshal(bz, ptr)
+}
+
+// ObjectStore stores the values of a single concrete type under a prefix of
+// a store, with SetValue and GetValue. Its keys are relative to the prefix.
+// It is meant to be created from the store of the context of each request,
+// e.g.
+//
+//	NewObjectStore(ctx.Store(key), []byte("order/"), nil, Order{})
+type ObjectStore struct {
+	store store.Store
+	cdc   *amino.Codec
+	typ   reflect.Type
+}
+
+// NewObjectStore returns an ObjectStore of the values of the type of proto,
+// e.g. Order{}, under prefix of parent, encoded with cdc, or with the global
+// codec if nil.
+func NewObjectStore(parent store.Store, pfx []byte, cdc *amino.Codec, proto interface{}) ObjectStore {
+	if proto == nil {
+		panic("ObjectStore type cannot be nil")
+	}
+	return ObjectStore{
+		store: prefix.New(parent, pfx),
+		cdc:   cdc,
+		typ:   reflect.TypeOf(proto),
+	}
+}
+
+// Set sets v, of the type of the store, at key.
+func (ost ObjectStore) Set(key []byte, v interface{}) {
+	if reflect.TypeOf(v) != ost.typ {
+		panic(fmt.Sprintf("cannot set %T at key %X of a store of %v", v, key, ost.typ))
+	}
+	SetValue(ost.store, ost.cdc, key, v)
+}
+
+// Get decodes the value at key into ptr, a pointer to a value of the type of
+// the store, and returns true, or returns false if there is no value at key.
+func (ost ObjectStore) Get(key []byte, ptr interface{}) bool {
+	if reflect.TypeOf(ptr) != reflect.PtrTo(ost.typ) {
+		panic(fmt.Sprintf("cannot get %v at key %X of a store of %v", reflect.TypeOf(ptr), key, ost.typ))
+	}
+	return GetValue(ost.store, ost.cdc, key, ptr)
+}
+
+// MustGet decodes the value at key into ptr like Get, and panics if there
+// is no value at key.
+func (ost ObjectStore) MustGet(key []byte, ptr interface{}) {
+	if !ost.Get(key, ptr) {
+		panic(fmt.Sprintf("no %v at key %X", ost.typ, key))
+	}
+}
+
+// Has returns whether there is a value at key.
+func (ost ObjectStore) Has(key []byte) bool {
+	return ost.store.Has(key)
+}
+
+// Delete deletes the value at key, if any.
+func (ost ObjectStore) Delete(key []byte) {
+	DeleteValue(ost.store, key)
+}
+
+// Iterate calls fn with the keys and values of the store, in ascending
+// order of keys, until it returns true. The values are of the type of the
+// store.
+func (ost ObjectStore) Iterate(fn func(key []byte, v interface{}) (stop bool)) {
+	iter := ost.store.Iterator(nil, nil)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		ptr := reflect.New(ost.typ)
+		key := iter.Key()
+		if err := unmarshalValue(ost.cdc, iter.Value(), ptr.Interface()); err != nil {
+			panic(fmt.Sprintf("cannot decode %v at key %X: %v", ost.typ, key, err))
+		}
+		if fn(key, ptr.Elem().Interface()) {
+			return
+		}
+	}
+}
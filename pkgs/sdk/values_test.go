@@ -0,0 +1,91 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+)
+
+type testValue struct {
+	Name  string
+	Count int64
+}
+
+func TestValues(t *testing.T) {
+	st := dbadapter.Store{DB: dbm.NewMemDB()}
+	key := []byte("key")
+
+	// missing keys.
+	var v testValue
+	require.False(t, GetValue(st, nil, key, &v))
+	require.Equal(t, testValue{}, v)
+	require.PanicsWithValue(t, "no sdk.testValue at key 6B6579", func() {
+		MustGetValue(st, nil, key, &v)
+	})
+
+	// round trip.
+	SetValue(st, nil, key, testValue{"foo", 42})
+	require.True(t, GetValue(st, nil, key, &v))
+	require.Equal(t, testValue{"foo", 42}, v)
+	var v2 testValue
+	MustGetValue(st, nil, key, &v2)
+	require.Equal(t, v, v2)
+
+	DeleteValue(st, key)
+	require.False(t, GetValue(st, nil, key, &v2))
+
+	// corrupted values.
+	st.Set(key, []byte{0xff, 0xff})
+	require.PanicsWithValue(t, "cannot decode sdk.testValue at key 6B6579: "+
+		"unmarshal to sdk.testValue failed after 0 bytes (buffer too small): FFFF", func() {
+		GetValue(st, nil, key, &v)
+	})
+}
+
+func TestObjectStore(t *testing.T) {
+	st := dbadapter.Store{DB: dbm.NewMemDB()}
+	ost := NewObjectStore(st, []byte("values/"), nil, testValue{})
+
+	var v testValue
+	require.False(t, ost.Get([]byte("a"), &v))
+	ost.Set([]byte("b"), testValue{"b", 2})
+	ost.Set([]byte("a"), testValue{"a", 1})
+	ost.Set([]byte("c"), testValue{"c", 3})
+	require.True(t, ost.Get([]byte("a"), &v))
+	require.Equal(t, testValue{"a", 1}, v)
+	require.True(t, ost.Has([]byte("b")))
+	ost.Delete([]byte("b"))
+	require.False(t, ost.Has([]byte("b")))
+
+	// the keys are under the prefix, and values outside of it are not
+	// iterated over.
+	require.True(t, st.Has([]byte("values/a")))
+	st.Set([]byte("other"), []byte("not a value"))
+
+	var keys []string
+	var values []testValue
+	ost.Iterate(func(key []byte, v interface{}) bool {
+		keys = append(keys, string(key))
+		values = append(values, v.(testValue))
+		return false
+	})
+	require.Equal(t, []string{"a", "c"}, keys)
+	require.Equal(t, []testValue{{"a", 1}, {"c", 3}}, values)
+
+	keys = nil
+	ost.Iterate(func(key []byte, v interface{}) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	require.Equal(t, []string{"a"}, keys)
+
+	// values of other types are rejected.
+	require.Panics(t, func() { ost.Set([]byte("d"), &testValue{}) })
+	require.Panics(t, func() { ost.Get([]byte("a"), &keys) })
+
+	st.Set([]byte("values/d"), []byte{0xff})
+	require.Panics(t, func() { ost.Iterate(func(key []byte, v interface{}) bool { return false }) })
+}
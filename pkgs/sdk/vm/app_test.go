@@ -0,0 +1,486 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno"
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	"github.com/gnolang/gno/pkgs/crypto"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/log"
+	"github.com/gnolang/gno/pkgs/sdk"
+	authm "github.com/gnolang/gno/pkgs/sdk/auth"
+	bankm "github.com/gnolang/gno/pkgs/sdk/bank"
+	tu "github.com/gnolang/gno/pkgs/sdk/testutils"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/iavl"
+)
+
+const testChainID = "test-chain-id"
+
+type testApp struct {
+	*sdk.BaseApp
+	vmk  *VMKeeper
+	bank bankm.BankKeeper
+}
+
+// newTestApp returns a BaseApp on db with the stores of gnoland, where the
+// gno store is in the IAVL store "gnostore", and the account of addr
+// starts with 100gnot in genesis. The options are those of the BaseApp.
+func newTestApp(t *testing.T, db dbm.DB, addr crypto.Address, options ...func(*sdk.BaseApp)) testApp {
+	return newTestAppWithKeeper(t, db, addr, nil, options...)
+}
+
+// newTestAppWithKeeper returns a new testApp, and calls setup, if not nil,
+// with its keeper and base key before loading it.
+func newTestAppWithKeeper(t *testing.T, db dbm.DB, addr crypto.Address,
+	setup func(app *sdk.BaseApp, vmk *VMKeeper, baseKey store.StoreKey), options ...func(*sdk.BaseApp),
+) testApp {
+	mainKey := store.NewKVStoreKey("main")
+	baseKey := store.NewKVStoreKey("base")
+	gnoKey := store.NewKVStoreKey("gnostore")
+
+	app := sdk.NewBaseApp("test", log.NewNopLogger(), db, baseKey, mainKey, options...)
+	app.MountStoreWithDB(mainKey, iavl.StoreConstructor, db)
+	app.MountStoreWithDB(baseKey, dbadapter.StoreConstructor, db)
+	app.MountStoreWithDB(gnoKey, iavl.StoreConstructor, nil)
+
+	acck := authm.NewAccountKeeper(mainKey, std.ProtoBaseAccount)
+	bank := bankm.NewBankKeeper(acck)
+	vmk := NewVMKeeper(gnoKey, mainKey, acck, bank, "../../../stdlibs")
+	app.SetInitChainer(func(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
+		acck.SetAccount(ctx, acck.NewAccountWithAddress(ctx, addr))
+		require.NoError(t, bank.SetCoins(ctx, addr, std.MustParseCoins("100gnot")))
+		return abci.ResponseInitChain{}
+	})
+	app.Router().AddRoute("vm", NewHandler(vmk))
+	app.AddRecoveryHandler(RecoverStoreError)
+	if setup != nil {
+		setup(app, vmk, baseKey)
+	}
+	require.NoError(t, app.LoadLatestVersion())
+	return testApp{app, vmk, bank}
+}
+
+func (app testApp) deliverBlock(t *testing.T, height int64, commit bool, msgs ...[]std.Msg) []abci.ResponseDeliverTx {
+	app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: testChainID, Height: height}})
+	var results []abci.ResponseDeliverTx
+	for _, txMsgs := range msgs {
+		tx := std.Tx{Msgs: txMsgs, Fee: std.NewFee(1<<40, std.NewCoin("gnot", 0))}
+		results = append(results, app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(tx)}))
+	}
+	app.EndBlock(abci.RequestEndBlock{})
+	if commit {
+		app.Commit()
+	}
+	return results
+}
+
+// state returns the balance of addr and the counter of the realm, in the
+// last committed state.
+func (app testApp) state(t *testing.T, addr crypto.Address) (std.Coins, string) {
+	ctx := app.NewContext(sdk.RunTxModeCheck, &bft.Header{ChainID: testChainID, Height: app.LastBlockHeight()})
+	counter, err := app.vmk.QueryEval(ctx, counterPkgPath, "Get()")
+	require.NoError(t, err)
+	return app.bank.GetCoins(ctx, addr), counter
+}
+
+const counterPkgPath = "gno.land/r/counter"
+
+var counterFiles = []std.MemFile{
+	{Name: "counter.go", Body: `
+package counter
+
+var s []int
+
+func Inc() int {
+	s = append(s, 1)
+	return len(s)
+}
+
+func Get() int {
+	return len(s)
+}
+
+func Fail() {
+	panic("fail")
+}`},
+}
+
+// The gno store is committed atomically with the other stores: the writes
+// of a block to both a balance and a gno object are lost together if the
+// app stops before Commit, and are covered by the app hash after it.
+func TestGnoStoreCommit(t *testing.T) {
+	db := dbm.NewMemDB()
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	app := newTestApp(t, db, addr)
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+	res := app.deliverBlock(t, 1, true, []std.Msg{NewMsgAddPackage(addr, counterPkgPath, counterFiles)})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	hash1 := app.LastCommitID().Hash
+	coins1, counter := app.state(t, addr)
+	require.Equal(t, "(0 int)", counter)
+
+	inc := NewMsgCall(addr, std.MustParseCoins("10gnot"), counterPkgPath, "Inc", nil)
+	res = app.deliverBlock(t, 2, false, []std.Msg{inc})
+	require.True(t, res[0].IsOK(), res[0].Log)
+
+	// the app stops before Commit: neither change survives.
+	app = newTestApp(t, db, addr)
+	require.Equal(t, int64(1), app.LastBlockHeight())
+	require.Equal(t, hash1, app.LastCommitID().Hash)
+	coins, counter := app.state(t, addr)
+	require.Equal(t, coins1, coins)
+	require.Equal(t, "(0 int)", counter)
+
+	// both changes are committed, and change the app hash.
+	res = app.deliverBlock(t, 2, true, []std.Msg{inc})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	hash2 := app.LastCommitID().Hash
+	require.NotEqual(t, hash1, hash2)
+
+	app = newTestApp(t, db, addr)
+	require.Equal(t, hash2, app.LastCommitID().Hash)
+	coins, counter = app.state(t, addr)
+	require.Equal(t, coins1.Sub(std.MustParseCoins("11gnot")), coins)
+	require.Equal(t, "(1 int)", counter)
+
+	qres := app.Query(abci.RequestQuery{Path: "/.app/commit_info"})
+	require.True(t, qres.IsOK(), qres.Log)
+	var cInfo store.CommitInfo
+	require.NoError(t, amino.UnmarshalJSON(qres.Value, &cInfo))
+	var names []string
+	for _, si := range cInfo.StoreInfos {
+		names = append(names, si.Name)
+	}
+	require.Contains(t, names, "gnostore")
+}
+
+// The objects mutated by a discarded tx are not kept by the gno store of
+// DeliverTx, which is not constructed again.
+func TestGnoStoreDiscardedTx(t *testing.T) {
+	db := dbm.NewMemDB()
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	app := newTestApp(t, db, addr)
+	chain := tu.NewTestChain(app.BaseApp, testChainID)
+	chain.InitChain(abci.RequestInitChain{})
+	tx := func(msgs ...std.Msg) []byte {
+		return amino.MustMarshal(std.Tx{Msgs: msgs, Fee: std.NewFee(1<<40, std.NewCoin("gnot", 0))})
+	}
+	res := chain.RunBlock(tx(NewMsgAddPackage(addr, counterPkgPath, counterFiles)))
+	require.True(t, res[0].IsOK(), res[0].Log)
+
+	gnoStore := app.vmk.gnoStore
+	nodes := gnoStore.Stats().CachedNodes
+
+	inc := NewMsgCall(addr, nil, counterPkgPath, "Inc", nil)
+	fail := NewMsgCall(addr, nil, counterPkgPath, "Fail", nil)
+	res = chain.RunBlock(tx(inc), tx(inc, fail), tx(inc))
+	require.True(t, res[0].IsOK(), res[0].Log)
+	require.False(t, res[1].IsOK())
+	require.True(t, res[2].IsOK(), res[2].Log)
+	require.Contains(t, string(res[2].Data), "(2 int)")
+	require.True(t, gnoStore == app.vmk.gnoStore)
+	require.GreaterOrEqual(t, gnoStore.Stats().CachedNodes, nodes)
+
+	_, counter := app.state(t, addr)
+	require.Equal(t, "(2 int)", counter)
+}
+
+// The gno store of a chain which kept it in the base store is used there
+// until the height of its migration, where it is moved to the gno store,
+// committed with the other stores.
+func TestGnoStoreLegacyStore(t *testing.T) {
+	db := dbm.NewMemDB()
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	var legacyKey store.StoreKey
+	legacy := func(app *sdk.BaseApp, vmk *VMKeeper, baseKey store.StoreKey) {
+		vmk.SetLegacyStore(baseKey, 3)
+		app.SetBeginBlocker(vmk.BeginBlocker)
+		legacyKey = baseKey
+	}
+	app := newTestAppWithKeeper(t, db, addr, legacy)
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+	res := app.deliverBlock(t, 1, true, []std.Msg{NewMsgAddPackage(addr, counterPkgPath, counterFiles)})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	inc := NewMsgCall(addr, nil, counterPkgPath, "Inc", nil)
+	res = app.deliverBlock(t, 2, true, []std.Msg{inc})
+	require.True(t, res[0].IsOK(), res[0].Log)
+
+	// the objects are in the legacy store, which the app hash does not
+	// cover.
+	countObjects := func(key store.StoreKey) int {
+		ctx := app.NewContext(sdk.RunTxModeCheck, &bft.Header{ChainID: testChainID, Height: app.LastBlockHeight()})
+		n := 0
+		iter := store.PrefixIterator(ctx.Store(key), []byte("oid:"))
+		for ; iter.Valid(); iter.Next() {
+			n++
+		}
+		iter.Close()
+		return n
+	}
+	legacyObjects := countObjects(legacyKey)
+	require.NotZero(t, legacyObjects)
+	require.Zero(t, countObjects(app.vmk.baseKey))
+	_, counter := app.state(t, addr)
+	require.Equal(t, "(1 int)", counter)
+
+	// they are moved at the migration height.
+	hash2 := app.LastCommitID().Hash
+	res = app.deliverBlock(t, 3, true, []std.Msg{inc})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	require.Contains(t, string(res[0].Data), "(2 int)")
+	require.Zero(t, countObjects(legacyKey))
+	require.NotZero(t, countObjects(app.vmk.baseKey))
+	require.NotEqual(t, hash2, app.LastCommitID().Hash)
+
+	app = newTestAppWithKeeper(t, db, addr, legacy)
+	_, counter = app.state(t, addr)
+	require.Equal(t, "(2 int)", counter)
+}
+
+// With the determinism check, the objects mutated by the first execution of
+// a tx, which is discarded, are not kept for the second one.
+func TestGnoStoreDeterminismCheck(t *testing.T) {
+	db := dbm.NewMemDB()
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	app := newTestApp(t, db, addr, sdk.SetDeterminismCheck(true))
+	chain := tu.NewTestChain(app.BaseApp, testChainID)
+	chain.InitChain(abci.RequestInitChain{})
+	tx := func(msgs ...std.Msg) []byte {
+		return amino.MustMarshal(std.Tx{Msgs: msgs, Fee: std.NewFee(1<<40, std.NewCoin("gnot", 0))})
+	}
+	res := chain.RunBlock(tx(NewMsgAddPackage(addr, counterPkgPath, counterFiles)))
+	require.True(t, res[0].IsOK(), res[0].Log)
+
+	inc := NewMsgCall(addr, nil, counterPkgPath, "Inc", nil)
+	fail := NewMsgCall(addr, nil, counterPkgPath, "Fail", nil)
+	res = chain.RunBlock(tx(inc), tx(inc, fail), tx(inc))
+	require.True(t, res[0].IsOK(), res[0].Log)
+	require.Contains(t, string(res[0].Data), "(1 int)")
+	require.False(t, res[1].IsOK())
+	require.NotContains(t, res[1].Log, "nondeterministic")
+	require.True(t, res[2].IsOK(), res[2].Log)
+	require.Contains(t, string(res[2].Data), "(2 int)")
+
+	_, counter := app.state(t, addr)
+	require.Equal(t, "(2 int)", counter)
+}
+
+// panicHandler panics with value on processing msgs, as the gno store does
+// when its debug assertions fail.
+type panicHandler struct {
+	sdk.Handler
+	value interface{}
+}
+
+func (ph panicHandler) Process(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+	panic(ph.value)
+}
+
+// Txs panicking on the assertions of the gno store fail with an internal
+// error of the assertion, with no stack, which is redacted as any other.
+func TestStoreAssertionPanics(t *testing.T) {
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	pv := &gno.PackageValue{PkgPath: counterPkgPath}
+	for _, tc := range []struct {
+		value interface{}
+		log   string // the log of the tx, with debug errors.
+	}{
+		{gno.ZeroObjectIDError{}, "object id cannot be zero"},
+		{
+			gno.DuplicateObjectError{OID: gno.ObjectIDFromPkgPath(counterPkgPath), Existing: pv, New: pv},
+			gno.DuplicateObjectError{Existing: pv, New: pv}.Error(),
+		},
+		{gno.DuplicatePackageError{PkgPath: counterPkgPath}, "duplicate package value " + counterPkgPath + ": another is already cached"},
+		// as wrapped by the machine with its location.
+		{errors.Wrap(gno.ZeroObjectIDError{}, "location: counter.go:3"), "object id cannot be zero"},
+	} {
+		for _, debugErrors := range []bool{false, true} {
+			app := newTestApp(t, dbm.NewMemDB(), addr, sdk.SetDebugErrors(debugErrors), func(app *sdk.BaseApp) {
+				app.Router().Use(func(next sdk.Handler) sdk.Handler {
+					return panicHandler{next, tc.value}
+				})
+			})
+			app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+			res := app.deliverBlock(t, 1, true, []std.Msg{NewMsgCall(addr, nil, counterPkgPath, "Inc", nil)})
+			codespace, code, _ := std.ABCIInfo(res[0].Error, false)
+			require.Equal(t, std.CodespaceSDK, codespace)
+			require.Equal(t, std.CodeInternal, code)
+			if debugErrors {
+				require.Equal(t, tc.log, res[0].Log)
+			} else {
+				require.Equal(t, std.InternalError{}, res[0].Error)
+				require.NotContains(t, res[0].Log, tc.log)
+			}
+		}
+	}
+
+	// other panics fail with their stack.
+	app := newTestApp(t, dbm.NewMemDB(), addr, sdk.SetDebugErrors(true), func(app *sdk.BaseApp) {
+		app.Router().Use(func(next sdk.Handler) sdk.Handler {
+			return panicHandler{next, "other"}
+		})
+	})
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+	res := app.deliverBlock(t, 1, true, []std.Msg{NewMsgCall(addr, nil, counterPkgPath, "Inc", nil)})
+	require.Contains(t, res[0].Log, "recovered: other\nstack:")
+}
+
+// The meta of an object of a realm has the heights at which it was created
+// and last modified, which calls that only read it do not change.
+func TestGnoStoreObjectMeta(t *testing.T) {
+	db := dbm.NewMemDB()
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	app := newTestApp(t, db, addr)
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+
+	// meta returns the meta of the package block of the realm.
+	meta := func() gno.ObjectMeta {
+		ctx := app.NewContext(sdk.RunTxModeCheck, &bft.Header{ChainID: testChainID, Height: app.LastBlockHeight()})
+		gnostore := app.vmk.getGnoStore(ctx)
+		pv := gnostore.GetPackage(counterPkgPath)
+		meta, ok := gnostore.GetObjectMeta(pv.GetBlock(gnostore).GetObjectID())
+		require.True(t, ok)
+		return meta
+	}
+
+	res := app.deliverBlock(t, 1, true, []std.Msg{NewMsgAddPackage(addr, counterPkgPath, counterFiles)})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	require.Equal(t, gno.ObjectMeta{CreatedAt: 1, UpdatedAt: 1}, meta())
+
+	inc := NewMsgCall(addr, nil, counterPkgPath, "Inc", nil)
+	get := NewMsgCall(addr, nil, counterPkgPath, "Get", nil)
+	res = app.deliverBlock(t, 2, true, []std.Msg{inc})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	require.Equal(t, gno.ObjectMeta{CreatedAt: 1, UpdatedAt: 2}, meta())
+
+	res = app.deliverBlock(t, 3, true, []std.Msg{get})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	require.Contains(t, string(res[0].Data), "(1 int)")
+	require.Equal(t, gno.ObjectMeta{CreatedAt: 1, UpdatedAt: 2}, meta())
+
+	res = app.deliverBlock(t, 4, true, []std.Msg{inc})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	require.Equal(t, gno.ObjectMeta{CreatedAt: 1, UpdatedAt: 4}, meta())
+
+	// objects never set have no meta.
+	ctx := app.NewContext(sdk.RunTxModeCheck, &bft.Header{ChainID: testChainID, Height: app.LastBlockHeight()})
+	_, ok := app.vmk.getGnoStore(ctx).GetObjectMeta(gno.ObjectIDFromPkgPath("gno.land/r/none"))
+	require.False(t, ok)
+}
+
+const demoPkgPath = "gno.land/r/demo"
+
+var demoFiles = []std.MemFile{
+	{Name: "demo.go", Body: `
+package demo
+
+type Item struct {
+	Name  string
+	Count int
+}
+
+var (
+	Title = "demo"
+	Items = []Item{{"a", 1}, {"b", 2}}
+	Owner *Item
+	List  *Node
+	Ring  *Node
+	count int
+)
+
+type Node struct {
+	Value int
+	Next  *Node
+}
+
+func init() {
+	Owner = &Item{"o", 0}
+	for i := 10; i > 0; i-- {
+		List = &Node{i, List}
+	}
+	Ring = &Node{Value: 1}
+	Ring.Next = &Node{2, Ring}
+}
+
+func Add(name string) {
+	Items = append(Items, Item{name, 0})
+}`},
+}
+
+func (app testApp) query(path, data string, height int64) abci.ResponseQuery {
+	return app.Query(abci.RequestQuery{Path: path, Data: []byte(data), Height: height})
+}
+
+// demoObjectID returns the id of the object of the demo realm created at
+// newTime.
+func demoObjectID(newTime uint64) string {
+	return gno.ObjectID{PkgID: gno.PkgIDFromPkgPath(demoPkgPath), NewTime: newTime}.String()
+}
+
+func TestQueryPackageValues(t *testing.T) {
+	db := dbm.NewMemDB()
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	app := newTestApp(t, db, addr)
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+	res := app.deliverBlock(t, 1, true, []std.Msg{NewMsgAddPackage(addr, demoPkgPath, demoFiles)})
+	require.True(t, res[0].IsOK(), res[0].Log)
+	res = app.deliverBlock(t, 2, true, []std.Msg{NewMsgCall(addr, nil, demoPkgPath, "Add", []string{"c"})})
+	require.True(t, res[0].IsOK(), res[0].Log)
+
+	golden := func(items string) string {
+		return `{"path":"gno.land/r/demo","name":"demo",` +
+			`"names":["Item","Node","init.2","Add","Title","Items","Owner","List","Ring","count"],` +
+			`"values":{"Item":{"@type":"gno.land/r/demo.Item"},"Node":{"@type":"gno.land/r/demo.Node"},` +
+			`"Add":{"@func":"Add"},"Title":"demo","Items":[` + items + `],"Owner":{"Name":"o","Count":0},` +
+			// the list is rendered up to the max depth.
+			`"List":{"Value":1,"Next":{"Value":2,"Next":{"Value":3,"Next":{"Value":4,"Next":{"Value":5,` +
+			`"Next":{"Value":6,"Next":{"Value":7,"Next":{"Value":8,` +
+			`"Next":{"@ref":"` + demoObjectID(16) + `"}}}}}}}}},` +
+			// the cycle is rendered as a ref to its first node.
+			`"Ring":{"Value":1,"Next":{"Value":2,"Next":{"@ref":"` + demoObjectID(18) + `"}}}}}`
+	}
+	qres := app.query("/vm/pkgvalues", demoPkgPath, 0)
+	require.True(t, qres.IsOK(), qres.Log)
+	require.Equal(t, golden(`{"Name":"a","Count":1},{"Name":"b","Count":2},{"Name":"c","Count":0}`), string(qres.Data))
+
+	// the state at the height of a query.
+	qres = app.query("/vm/pkgvalues", demoPkgPath, 1)
+	require.True(t, qres.IsOK(), qres.Log)
+	require.Equal(t, golden(`{"Name":"a","Count":1},{"Name":"b","Count":2}`), string(qres.Data))
+
+	qres = app.query("/vm/pkgvalues", "gno.land/r/none", 0)
+	require.False(t, qres.IsOK())
+	require.IsType(t, InvalidPkgPathError{}, qres.Error)
+}
+
+func TestQueryObject(t *testing.T) {
+	db := dbm.NewMemDB()
+	addr := crypto.AddressFromPreimage([]byte("addr1"))
+	app := newTestApp(t, db, addr)
+	app.InitChain(abci.RequestInitChain{ChainID: testChainID})
+	res := app.deliverBlock(t, 1, true, []std.Msg{NewMsgAddPackage(addr, demoPkgPath, demoFiles)})
+	require.True(t, res[0].IsOK(), res[0].Log)
+
+	// the node beyond the max depth of the list, of unknown type, with its
+	// fields as a list.
+	qres := app.query("/vm/object", demoObjectID(16), 0)
+	require.True(t, qres.IsOK(), qres.Log)
+	require.Equal(t, `[9,{"Value":10,"Next":null}]`, string(qres.Data))
+
+	qres = app.query("/vm/object", demoObjectID(1000), 0)
+	require.False(t, qres.IsOK())
+	require.IsType(t, InvalidObjectIDError{}, qres.Error)
+
+	qres = app.query("/vm/object", "invalid", 0)
+	require.False(t, qres.IsOK())
+	require.IsType(t, InvalidObjectIDError{}, qres.Error)
+}
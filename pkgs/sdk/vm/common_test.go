@@ -26,8 +26,8 @@ type testEnv struct {
 func setupTestEnv() testEnv {
 	db := dbm.NewMemDB()
 
-	baseCapKey := store.NewStoreKey("baseCapKey")
-	iavlCapKey := store.NewStoreKey("iavlCapKey")
+	baseCapKey := store.NewKVStoreKey("baseCapKey")
+	iavlCapKey := store.NewKVStoreKey("iavlCapKey")
 
 	ms := store.NewCommitMultiStore(db)
 	ms.MountStoreWithDB(baseCapKey, dbadapter.StoreConstructor, db)
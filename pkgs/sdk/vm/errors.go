@@ -1,6 +1,11 @@
 package vm
 
-import "github.com/gnolang/gno/pkgs/errors"
+import (
+	"github.com/gnolang/gno"
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/gnolang/gno/pkgs/sdk"
+	"github.com/gnolang/gno/pkgs/std"
+)
 
 // for convenience:
 type abciError struct{}
@@ -12,10 +17,12 @@ func (_ abciError) AssertABCIError() {}
 type InvalidPkgPathError struct{ abciError }
 type InvalidStmtError struct{ abciError }
 type InvalidExprError struct{ abciError }
+type InvalidObjectIDError struct{ abciError }
 
-func (e InvalidPkgPathError) Error() string { return "invalid package path" }
-func (e InvalidStmtError) Error() string    { return "invalid statement" }
-func (e InvalidExprError) Error() string    { return "invalid expression" }
+func (e InvalidPkgPathError) Error() string  { return "invalid package path" }
+func (e InvalidStmtError) Error() string     { return "invalid statement" }
+func (e InvalidExprError) Error() string     { return "invalid expression" }
+func (e InvalidObjectIDError) Error() string { return "invalid object id" }
 
 func ErrInvalidPkgPath(msg string) error {
 	return errors.Wrap(InvalidPkgPathError{}, msg)
@@ -28,3 +35,27 @@ func ErrInvalidStmt(msg string) error {
 func ErrInvalidExpr(msg string) error {
 	return errors.Wrap(InvalidExprError{}, msg)
 }
+
+func ErrInvalidObjectID(msg string) error {
+	return errors.Wrap(InvalidObjectIDError{}, msg)
+}
+
+// RecoverStoreError is the sdk.RecoveryHandler of the panics of the debug
+// assertions of the gno store, e.g. gno.DuplicateObjectError, possibly
+// wrapped with the location of the machine. It fails the tx with an
+// internal error whose log is the message of the assertion, with no stack.
+func RecoverStoreError(r interface{}) (sdk.Result, bool) {
+	err, ok := r.(error)
+	if !ok {
+		return sdk.Result{}, false
+	}
+	switch cause := errors.Cause(err).(type) {
+	case gno.DuplicateObjectError, gno.ZeroObjectIDError, gno.DuplicatePackageError:
+		var res sdk.Result
+		res.Error = sdk.ABCIError(std.ErrInternal(cause.Error()))
+		res.Log = cause.Error()
+		return res, true
+	default:
+		return sdk.Result{}, false
+	}
+}
@@ -86,6 +86,8 @@ const (
 	QueryStore   = "store"
 	QueryEval    = "qeval"
 	QueryPath    = "qpath"
+	QueryValues  = "pkgvalues"
+	QueryObject  = "object"
 )
 
 func (vh vmHandler) Query(ctx sdk.Context, req abci.RequestQuery) (res abci.ResponseQuery) {
@@ -98,6 +100,10 @@ func (vh vmHandler) Query(ctx sdk.Context, req abci.RequestQuery) (res abci.Resp
 		return vh.queryEval(ctx, req)
 	case QueryPath:
 		return vh.queryPath(ctx, req)
+	case QueryValues:
+		return vh.queryValues(ctx, req)
+	case QueryObject:
+		return vh.queryObject(ctx, req)
 	default:
 		res = sdk.ABCIResponseQueryFromError(
 			std.ErrUnknownRequest(fmt.Sprintf(
@@ -156,6 +162,31 @@ func (vh vmHandler) queryPath(ctx sdk.Context, req abci.RequestQuery) (res abci.
 	return
 }
 
+// queryValues renders the names and exported values of the package of the
+// path in the input data as JSON.
+func (vh vmHandler) queryValues(ctx sdk.Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+	pkgPath := string(req.Data)
+	result, err := vh.vm.QueryPackageJSON(ctx, pkgPath)
+	if err != nil {
+		res = sdk.ABCIResponseQueryFromError(err)
+		return
+	}
+	res.Data = result
+	return
+}
+
+// queryObject renders the object of the id in the input data as JSON.
+func (vh vmHandler) queryObject(ctx sdk.Context, req abci.RequestQuery) (res abci.ResponseQuery) {
+	oid := string(req.Data)
+	result, err := vh.vm.QueryObjectJSON(ctx, oid)
+	if err != nil {
+		res = sdk.ABCIResponseQueryFromError(err)
+		return
+	}
+	res.Data = result
+	return
+}
+
 //----------------------------------------
 // misc
 
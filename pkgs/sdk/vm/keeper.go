@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/gnolang/gno"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
 	"github.com/gnolang/gno/pkgs/crypto"
 	"github.com/gnolang/gno/pkgs/sdk"
 	"github.com/gnolang/gno/pkgs/sdk/auth"
@@ -31,6 +32,10 @@ type VMKeeper struct {
 	bank       bank.BankKeeper
 	stdlibsDir string
 
+	// the store of the gno store before legacyHeight, see SetLegacyStore.
+	legacyKey    store.StoreKey
+	legacyHeight int64
+
 	// cached, the DeliverTx persistent state.
 	gnoStore gno.Store
 }
@@ -47,14 +52,48 @@ func NewVMKeeper(baseKey store.StoreKey, iavlKey store.StoreKey, acck auth.Accou
 	return vmk
 }
 
+// SetLegacyStore sets the store of the gno store of the chains which kept
+// it in legacyKey, before it was committed with the other stores: the
+// keeper uses legacyKey for the blocks before height, and BeginBlocker
+// moves the gno store to the store of the keeper at height, which changes
+// the app hash from that height on.
+func (vmk *VMKeeper) SetLegacyStore(legacyKey store.StoreKey, height int64) {
+	if height < 1 {
+		panic(fmt.Sprintf("invalid legacy store height: %d", height))
+	}
+	vmk.legacyKey = legacyKey
+	vmk.legacyHeight = height
+}
+
+// baseStore returns the base store of the gno store at the block of ctx,
+// see SetLegacyStore.
+func (vmk *VMKeeper) baseStore(ctx sdk.Context) store.Store {
+	if vmk.legacyKey != nil && ctx.BlockHeight() < vmk.legacyHeight {
+		return ctx.Store(vmk.legacyKey)
+	}
+	return ctx.Store(vmk.baseKey)
+}
+
+// BeginBlocker moves the gno store from the legacy store at the height set
+// by SetLegacyStore.
+func (vmk *VMKeeper) BeginBlocker(ctx sdk.Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+	if vmk.legacyKey != nil && ctx.BlockHeight() == vmk.legacyHeight {
+		n := gno.MoveBaseStore(ctx.Store(vmk.legacyKey), ctx.Store(vmk.baseKey))
+		ctx.Logger().Info("Moved the gno store from the legacy store",
+			"height", ctx.BlockHeight(), "from", vmk.legacyKey.Name(), "to", vmk.baseKey.Name(), "entries", n)
+	}
+	return abci.ResponseBeginBlock{}
+}
+
 func (vmk *VMKeeper) getGnoStore(ctx sdk.Context) gno.Store {
 	switch ctx.Mode() {
 	case sdk.RunTxModeDeliver:
 		// construct gnoStore if nil.
 		if vmk.gnoStore == nil {
-			baseSDKStore := ctx.Store(vmk.baseKey)
+			baseSDKStore := vmk.baseStore(ctx)
 			iavlSDKStore := ctx.Store(vmk.iavlKey)
 			vmk.gnoStore = gno.NewStore(baseSDKStore, iavlSDKStore)
+			vmk.gnoStore.SetCommitHeight(ctx.BlockHeight())
 			vmk.initBuiltinPackages(vmk.gnoStore)
 			if vmk.gnoStore.NumMemPackages() > 0 {
 				// for now, all mem packages must be re-run after reboot.
@@ -71,16 +110,29 @@ func (vmk *VMKeeper) getGnoStore(ctx sdk.Context) gno.Store {
 		} else {
 			// otherwise, swap sdk store of existing gnoStore.
 			// this is needed due to e.g. gas wrappers.
-			baseStore := ctx.Store(vmk.baseKey)
+			baseStore := vmk.baseStore(ctx)
 			iavlStore := ctx.Store(vmk.iavlKey)
 			vmk.gnoStore.SwapStores(baseStore, iavlStore)
+			vmk.gnoStore.SetCommitHeight(ctx.BlockHeight())
 		}
+		// the writes of the gno store go to the cache-wrapped stores of
+		// the tx, written with its other writes, or discarded with them,
+		// with the objects, types and nodes the gno store cached meanwhile.
+		vmk.gnoStore.StartTx()
+		ctx.OnTxEnd(vmk.onTxEnd)
 		return vmk.gnoStore
 	case sdk.RunTxModeCheck:
 		// For query??? XXX Why not RunTxModeQuery?
-		baseSDKStore := ctx.Store(vmk.baseKey)
+		baseSDKStore := vmk.baseStore(ctx)
 		iavlSDKStore := ctx.Store(vmk.iavlKey)
+		if ctx.IsReadOnly() {
+			// the builtin packages and block nodes are saved to a
+			// discarded cache of the read-only stores of queries.
+			baseSDKStore = baseSDKStore.CacheWrap()
+			iavlSDKStore = iavlSDKStore.CacheWrap()
+		}
 		simStore := gno.NewStore(baseSDKStore, iavlSDKStore)
+		simStore.SetCommitHeight(ctx.BlockHeight())
 		vmk.initBuiltinPackages(simStore)
 		// XXX This is crazy, there has to be a better way.
 		if simStore.NumMemPackages() > 0 {
@@ -95,9 +147,10 @@ func (vmk *VMKeeper) getGnoStore(ctx sdk.Context) gno.Store {
 		return simStore
 	case sdk.RunTxModeSimulate:
 		// always make a new store for simualte for isolation.
-		baseSDKStore := ctx.Store(vmk.baseKey)
+		baseSDKStore := vmk.baseStore(ctx)
 		iavlSDKStore := ctx.Store(vmk.iavlKey)
 		simStore := gno.NewStore(baseSDKStore, iavlSDKStore)
+		simStore.SetCommitHeight(ctx.BlockHeight())
 		vmk.initBuiltinPackages(simStore)
 		// XXX This is crazy, there has to be a better way.
 		if simStore.NumMemPackages() > 0 {
@@ -115,6 +168,24 @@ func (vmk *VMKeeper) getGnoStore(ctx sdk.Context) gno.Store {
 	}
 }
 
+// onTxEnd ends the tx of the gno store of DeliverTx. If the writes of the
+// tx are discarded, the gno store drops its cached objects, which the tx
+// may have mutated, and restores its cached types and nodes; the block
+// nodes are only in the cache, so the store is kept rather than
+// constructed again. It may be called more than once per tx.
+func (vmk *VMKeeper) onTxEnd(written bool) {
+	vmk.gnoStore.EndTx(written)
+}
+
+// StoreStats returns the stats of the gno store of DeliverTx, or nil if it
+// is not constructed yet. It must not be called concurrently with DeliverTx.
+func (vmk *VMKeeper) StoreStats() interface{} {
+	if vmk.gnoStore == nil {
+		return nil
+	}
+	return vmk.gnoStore.Stats()
+}
+
 // AddPackage adds a package with given fileset.
 func (vm *VMKeeper) AddPackage(ctx sdk.Context, msg MsgAddPackage) error {
 	creator := msg.Creator
@@ -278,6 +349,36 @@ func (vm *VMKeeper) QueryEval(ctx sdk.Context, pkgPath string, expr string) (res
 	return res, nil
 }
 
+// The max depth of the objects rendered by QueryPackageJSON and
+// QueryObjectJSON, beyond which they are rendered as refs.
+const queryRenderDepth = 8
+
+// QueryPackageJSON returns the declared names of the package of pkgPath,
+// and its exported values, rendered as JSON by gno.MarshalPackageJSON.
+func (vm *VMKeeper) QueryPackageJSON(ctx sdk.Context, pkgPath string) ([]byte, error) {
+	store := vm.getGnoStore(ctx)
+	if store.GetPackage(pkgPath) == nil {
+		return nil, ErrInvalidPkgPath(fmt.Sprintf(
+			"package not found: %s", pkgPath))
+	}
+	return gno.MarshalPackageJSON(store, pkgPath, queryRenderDepth)
+}
+
+// QueryObjectJSON returns the object of id, e.g. as rendered as a ref by
+// QueryPackageJSON, rendered as JSON by gno.MarshalObjectJSON.
+func (vm *VMKeeper) QueryObjectJSON(ctx sdk.Context, id string) ([]byte, error) {
+	oid, err := gno.ParseObjectID(id)
+	if err != nil {
+		return nil, ErrInvalidObjectID(err.Error())
+	}
+	store := vm.getGnoStore(ctx)
+	if store.GetObjectSafe(oid) == nil {
+		return nil, ErrInvalidObjectID(fmt.Sprintf(
+			"object not found: %s", id))
+	}
+	return gno.MarshalObjectJSON(store, oid, queryRenderDepth)
+}
+
 //----------------------------------------
 
 // For keeping record of package & realm coins.
@@ -16,4 +16,5 @@ var Package = amino.RegisterPackage(amino.NewPackage(
 	InvalidPkgPathError{}, "InvalidPkgPathError",
 	InvalidStmtError{}, "InvalidStmtError",
 	InvalidExprError{}, "InvalidExprError",
+	InvalidObjectIDError{}, "InvalidObjectIDError",
 ))
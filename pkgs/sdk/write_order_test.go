@@ -0,0 +1,108 @@
+package sdk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
+	bft "github.com/gnolang/gno/pkgs/bft/types"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/store/cache"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/iavl"
+	store "github.com/gnolang/gno/pkgs/store/types"
+)
+
+// listenedStore is a CommitStore which appends the writes to it, including
+// those of its cache wraps, to ops.
+type listenedStore struct {
+	store.CommitStore
+	name string
+	ops  *[]store.StoreOp
+}
+
+func (ls *listenedStore) Set(key, value []byte) {
+	ls.CommitStore.Set(key, value)
+	*ls.ops = append(*ls.ops, store.StoreOp{Store: ls.name, Type: store.StoreOpSet, Key: key, Value: value})
+}
+
+func (ls *listenedStore) Delete(key []byte) {
+	ls.CommitStore.Delete(key)
+	*ls.ops = append(*ls.ops, store.StoreOp{Store: ls.name, Type: store.StoreOpDelete, Key: key})
+}
+
+func (ls *listenedStore) CacheWrap() store.Store {
+	return cache.New(ls)
+}
+
+// listened returns a constructor of the store of name, listened by ops.
+func listened(cons store.CommitStoreConstructor, name string, ops *[]store.StoreOp) store.CommitStoreConstructor {
+	return func(db dbm.DB, opts store.StoreOptions) store.CommitStore {
+		return &listenedStore{CommitStore: cons(db, opts), name: name, ops: ops}
+	}
+}
+
+// The writes to the mounted stores, flushed from the caches of the blocks,
+// are in the order of the store names, then of the keys, on all nodes.
+func TestMultiStoreWriteOrder(t *testing.T) {
+	names := []string{"z", "m", "a", "q", "c"}
+	keys := make([]store.StoreKey, len(names))
+	for i, name := range names {
+		keys[i] = store.NewKVStoreKey(name)
+	}
+
+	newApp := func(ops *[]store.StoreOp) *BaseApp {
+		app := NewBaseApp(t.Name(), defaultLogger(), dbm.NewMemDB(), baseKey, mainKey)
+		app.MountStore(baseKey, dbadapter.StoreConstructor)
+		app.MountStore(mainKey, listened(iavl.StoreConstructor, mainKey.Name(), ops))
+		for _, key := range keys {
+			app.MountStore(key, listened(iavl.StoreConstructor, key.Name(), ops))
+		}
+		app.Router().AddRoute(routeMsgCounter, newTestHandler(func(ctx Context, msg Msg) Result {
+			counter := msg.(msgCounter).Counter
+			for _, key := range keys {
+				for i := counter; i >= 0; i-- {
+					ctx.Store(key).Set([]byte(fmt.Sprintf("k%d", i)), []byte(key.Name()))
+				}
+				if counter > 0 {
+					ctx.Store(key).Delete([]byte(fmt.Sprintf("k%d", counter-1)))
+				}
+			}
+			return Result{}
+		}))
+		require.NoError(t, app.LoadLatestVersion())
+		return app
+	}
+
+	var opsA, opsB []store.StoreOp
+	apps := []*BaseApp{newApp(&opsA), newApp(&opsB)}
+	var streams [2][][]store.StoreOp
+	for i, app := range apps {
+		ops := []*[]store.StoreOp{&opsA, &opsB}[i]
+		app.InitChain(abci.RequestInitChain{ChainID: "test-chain"})
+		for height := int64(1); height <= 3; height++ {
+			app.BeginBlock(abci.RequestBeginBlock{Header: &bft.Header{ChainID: "test-chain", Height: height}})
+			for counter := int64(0); counter < height; counter++ {
+				res := app.DeliverTx(abci.RequestDeliverTx{Tx: amino.MustMarshal(newTxCounter(counter, counter))})
+				require.True(t, res.IsOK(), res.Log)
+			}
+			app.EndBlock(abci.RequestEndBlock{Height: height})
+			*ops = nil
+			app.Commit()
+			streams[i] = append(streams[i], *ops)
+		}
+	}
+
+	require.Equal(t, streams[0], streams[1])
+	for _, ops := range streams[0] {
+		require.NotEmpty(t, ops)
+		for i := 1; i < len(ops); i++ {
+			prev, op := ops[i-1], ops[i]
+			require.True(t, prev.Store < op.Store || prev.Store == op.Store && string(prev.Key) < string(op.Key),
+				"%s before %s", prev, op)
+		}
+	}
+}
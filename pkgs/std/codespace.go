@@ -0,0 +1,147 @@
+package std
+
+import (
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+// CodespaceSDK is the codespace of the std errors.
+const CodespaceSDK = "sdk"
+
+// CodeInternal is the code of InternalError in CodespaceSDK, which is also
+// the one of the errors which are not registered.
+const CodeInternal uint32 = 1
+
+// CodedError is an error of a module, identified by the codespace of the
+// module and a code registered with Register.
+type CodedError struct {
+	abciError
+	Codespace string
+	Code      uint32
+}
+
+// Error returns the registered description of the error.
+func (e CodedError) Error() string {
+	if desc, ok := lookupDescription(e.Codespace, e.Code); ok {
+		return desc
+	}
+	return fmt.Sprintf("unregistered error %s:%d", e.Codespace, e.Code)
+}
+
+// abciErrorI is abci.Error, which std cannot import.
+type abciErrorI interface {
+	AssertABCIError()
+	Error() string
+}
+
+type codespaceCode struct {
+	codespace string
+	code      uint32
+}
+
+var registry = struct {
+	mtx          sync.RWMutex
+	descriptions map[codespaceCode]string
+	types        map[reflect.Type]codespaceCode
+}{
+	descriptions: make(map[codespaceCode]string),
+	types:        make(map[reflect.Type]codespaceCode),
+}
+
+// Register registers the code of an error in codespace, e.g. of a module,
+// with its description. It panics if the code is 0, which is reserved for
+// success, or already registered in codespace.
+func Register(codespace string, code uint32, description string) {
+	if codespace == "" {
+		panic("empty error codespace")
+	}
+	if code == 0 {
+		panic(fmt.Sprintf("error code 0 is reserved, in codespace %q", codespace))
+	}
+	key := codespaceCode{codespace, code}
+
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+	if desc, ok := registry.descriptions[key]; ok {
+		panic(fmt.Sprintf("error code %d already registered in codespace %q: %q",
+			code, codespace, desc))
+	}
+	registry.descriptions[key] = description
+}
+
+// RegisterError registers the code of the type of err, e.g. InternalError{},
+// in codespace, with the message of err as description, so that errors of
+// this type have this codespace and code in ABCIInfo.
+func RegisterError(codespace string, code uint32, err error) {
+	rt := reflect.TypeOf(err)
+	registry.mtx.RLock()
+	_, ok := registry.types[rt]
+	registry.mtx.RUnlock()
+	if ok {
+		panic(fmt.Sprintf("error type %v already registered", rt))
+	}
+
+	Register(codespace, code, err.Error())
+	registry.mtx.Lock()
+	registry.types[rt] = codespaceCode{codespace, code}
+	registry.mtx.Unlock()
+}
+
+func lookupDescription(codespace string, code uint32) (string, bool) {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+	desc, ok := registry.descriptions[codespaceCode{codespace, code}]
+	return desc, ok
+}
+
+func lookupType(err error) (codespaceCode, bool) {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+	cc, ok := registry.types[reflect.TypeOf(err)]
+	return cc, ok
+}
+
+// NewError returns an error with the code registered in codespace, and msg.
+// It panics if the code is not registered.
+func NewError(codespace string, code uint32, msg string) error {
+	if _, ok := lookupDescription(codespace, code); !ok {
+		panic(fmt.Sprintf("error code %d not registered in codespace %q", code, codespace))
+	}
+	return errors.Wrap(CodedError{Codespace: codespace, Code: code}, "%s", msg)
+}
+
+// ABCIInfo returns the codespace and the code of err, and its log. The
+// codespace and code are those of the CodedError wrapped by err, or of the
+// abci.Error wrapped by err if its type is registered with RegisterError,
+// or else those of InternalError. The log is err formatted with %+v if debug is
+// set, or else its message, or "internal error" for internal errors, so
+// that their details are not returned to clients. It is empty for a nil err.
+func ABCIInfo(err error, debug bool) (codespace string, code uint32, log string) {
+	if err == nil {
+		return "", 0, ""
+	}
+	codespace, code = CodespaceSDK, CodeInternal
+	var cerr CodedError
+	var abcierr abciErrorI
+	if stderrors.As(err, &cerr) {
+		codespace, code = cerr.Codespace, cerr.Code
+	} else if stderrors.As(err, &abcierr) {
+		if cc, ok := lookupType(abcierr); ok {
+			codespace, code = cc.codespace, cc.code
+		}
+	}
+
+	switch {
+	case debug:
+		log = fmt.Sprintf("%+v", err)
+	case codespace == CodespaceSDK && code == CodeInternal:
+		log = "internal error"
+	default:
+		log = err.Error()
+	}
+	return codespace, code, log
+}
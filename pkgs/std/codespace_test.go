@@ -0,0 +1,109 @@
+package std
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testModuleError struct{ abciError }
+
+func (testModuleError) Error() string { return "test module error" }
+
+func TestStdErrorCodes(t *testing.T) {
+	// the codes of the std errors are part of the protocol.
+	for code, err := range []error{
+		nil,
+		ErrInternal("msg"),
+		ErrTxDecode("msg"),
+		ErrInvalidSequence("msg"),
+		ErrUnauthorized("msg"),
+		ErrInsufficientFunds("msg"),
+		ErrUnknownRequest("msg"),
+		ErrInvalidAddress("msg"),
+		ErrUnknownAddress("msg"),
+		ErrInvalidPubKey("msg"),
+		ErrInsufficientCoins("msg"),
+		ErrInvalidCoins("msg"),
+		ErrOutOfGas("msg"),
+		ErrMemoTooLarge("msg"),
+		ErrInsufficientFee("msg"),
+		ErrTooManySignatures("msg"),
+		ErrNoSignatures("msg"),
+		ErrGasOverflow("msg"),
+	} {
+		if err == nil {
+			continue
+		}
+		codespace, c, _ := ABCIInfo(err, false)
+		assert.Equal(t, CodespaceSDK, codespace, "%v", err)
+		assert.Equal(t, uint32(code), c, "%v", err)
+	}
+}
+
+func TestABCIInfo(t *testing.T) {
+	Register("testmodule", 1, "test failure")
+	RegisterError("testmodule", 2, testModuleError{})
+
+	for _, tc := range []struct {
+		name      string
+		err       error
+		codespace string
+		code      uint32
+		log       string
+	}{
+		{"nil", nil, "", 0, ""},
+		{"std", ErrUnauthorized("msg"), CodespaceSDK, 4, "unauthorized error"},
+		{"coded", NewError("testmodule", 1, "msg"), "testmodule", 1, "test failure"},
+		{"wrapped coded", fmt.Errorf("outer: %w", errors.Wrap(NewError("testmodule", 1, "msg"), "inner")),
+			"testmodule", 1, "outer: test failure"},
+		{"registered type", errors.Wrap(testModuleError{}, "msg"), "testmodule", 2, "test module error"},
+		{"internal", ErrInternal("secret"), CodespaceSDK, CodeInternal, "internal error"},
+		{"unregistered", stderrors.New("secret"), CodespaceSDK, CodeInternal, "internal error"},
+	} {
+		codespace, code, log := ABCIInfo(tc.err, false)
+		assert.Equal(t, tc.codespace, codespace, tc.name)
+		assert.Equal(t, tc.code, code, tc.name)
+		assert.Equal(t, tc.log, log, tc.name)
+	}
+
+	// debug logs include the details.
+	_, _, log := ABCIInfo(ErrInternal("secret"), true)
+	assert.Contains(t, log, "secret")
+	assert.Contains(t, log, "Stack Trace:")
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	Register("dupmodule", 1, "first")
+	assert.Panics(t, func() { Register("dupmodule", 1, "second") })
+	assert.Panics(t, func() { RegisterError("dupmodule", 1, testModuleError{}) })
+	assert.Panics(t, func() { RegisterError(CodespaceSDK, 100, InternalError{}) })
+	assert.Panics(t, func() { Register("dupmodule", 0, "reserved") })
+	assert.Panics(t, func() { Register("", 2, "no codespace") })
+
+	// the same code can be registered in other codespaces.
+	assert.NotPanics(t, func() { Register("othermodule", 1, "other") })
+	assert.Equal(t, "first", CodedError{Codespace: "dupmodule", Code: 1}.Error())
+	assert.Equal(t, "other", CodedError{Codespace: "othermodule", Code: 1}.Error())
+
+	assert.Panics(t, func() { NewError("dupmodule", 2, "unregistered") })
+}
+
+func TestCodedErrorAmino(t *testing.T) {
+	Register("aminomodule", 7, "amino failure")
+	type response struct {
+		Error error
+	}
+	res := response{Error: CodedError{Codespace: "aminomodule", Code: 7}}
+	bz, err := amino.Marshal(res)
+	require.NoError(t, err)
+	var res2 response
+	require.NoError(t, amino.Unmarshal(bz, &res2))
+	assert.Equal(t, res, res2)
+	assert.True(t, stderrors.Is(NewError("aminomodule", 7, "msg"), res2.Error))
+}
@@ -2,6 +2,7 @@ package std
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"sort"
 	"strconv"
@@ -222,41 +223,35 @@ func (coins Coins) String() string {
 	return out[:len(out)-1]
 }
 
-// IsValid asserts the Coins are sorted, have positive amount,
-// and Denom does not contain upper case characters.
+// IsValid asserts the Coins are sorted, have unique denominations, have
+// positive amounts, and have valid denominations.
 func (coins Coins) IsValid() bool {
-	switch len(coins) {
-	case 0:
-		return true
-	case 1:
-		if err := validateDenom(coins[0].Denom); err != nil {
-			return false
+	return coins.Validate() == nil
+}
+
+// Validate returns an error describing why the Coins are not valid, or nil.
+// Valid Coins are sorted by denomination with no duplicates, every amount is
+// positive, and every denomination passes denom validation.
+func (coins Coins) Validate() error {
+	for i, coin := range coins {
+		if err := validateDenom(coin.Denom); err != nil {
+			return err
 		}
-		return coins[0].IsPositive()
-	default:
-		// check single coin case
-		if !(Coins{coins[0]}).IsValid() {
-			return false
+		if !coin.IsPositive() {
+			return fmt.Errorf("non-positive coin amount: %d%s", coin.Amount, coin.Denom)
 		}
-
-		lowDenom := coins[0].Denom
-		for _, coin := range coins[1:] {
-			if strings.ToLower(coin.Denom) != coin.Denom {
-				return false
-			}
-			if coin.Denom <= lowDenom {
-				return false
-			}
-			if !coin.IsPositive() {
-				return false
-			}
-
-			// we compare each coin against the last denom
-			lowDenom = coin.Denom
+		if i == 0 {
+			continue
+		}
+		// we compare each coin against the previous denom
+		switch prev := coins[i-1].Denom; {
+		case coin.Denom == prev:
+			return fmt.Errorf("duplicate denom: %s", coin.Denom)
+		case coin.Denom < prev:
+			return fmt.Errorf("unsorted denoms: %s after %s", coin.Denom, prev)
 		}
-
-		return true
 	}
+	return nil
 }
 
 // Add adds two sets of coins.
@@ -280,7 +275,28 @@ func (coins Coins) Add(coinsB Coins) Coins {
 // other set is returned. Otherwise, the coins are compared in order of their
 // denomination and addition only occurs when the denominations match, otherwise
 // the coin is simply added to the sum assuming it's not zero.
+// An overflow or underflow panics.
 func (coins Coins) AddUnsafe(coinsB Coins) Coins {
+	sum, ok := coins.addOverflow(coinsB)
+	if !ok {
+		panic(fmt.Sprintf("coins add overflow/underflow: %v, %v", coins, coinsB))
+	}
+	return sum
+}
+
+// SafeAdd adds two sets of coins without panicking. It returns the sum and
+// true, or nil and false if any amount overflows or the sum is not valid.
+func (coins Coins) SafeAdd(coinsB Coins) (Coins, bool) {
+	sum, ok := coins.addOverflow(coinsB)
+	if !ok || !sum.IsValid() {
+		return nil, false
+	}
+	return sum, true
+}
+
+// addOverflow is like AddUnsafe but reports int64 overflow instead of
+// panicking.
+func (coins Coins) addOverflow(coinsB Coins) (Coins, bool) {
 	sum := ([]Coin)(nil)
 	indexA, indexB := 0, 0
 	lenA, lenB := len(coins), len(coinsB)
@@ -289,14 +305,14 @@ func (coins Coins) AddUnsafe(coinsB Coins) Coins {
 		if indexA == lenA {
 			if indexB == lenB {
 				// return nil coins if both sets are empty
-				return sum
+				return sum, true
 			}
 
 			// return set B (excluding zero coins) if set A is empty
-			return append(sum, removeZeroCoins(coinsB[indexB:])...)
+			return append(sum, removeZeroCoins(coinsB[indexB:])...), true
 		} else if indexB == lenB {
 			// return set A (excluding zero coins) if set B is empty
-			return append(sum, removeZeroCoins(coins[indexA:])...)
+			return append(sum, removeZeroCoins(coins[indexA:])...), true
 		}
 
 		coinA, coinB := coins[indexA], coinsB[indexB]
@@ -310,9 +326,12 @@ func (coins Coins) AddUnsafe(coinsB Coins) Coins {
 			indexA++
 
 		case 0: // coin A denom == coin B denom
-			res := coinA.AddUnsafe(coinB)
-			if !res.IsZero() {
-				sum = append(sum, res)
+			amount, ok := overflow.Add64(coinA.Amount, coinB.Amount)
+			if !ok {
+				return nil, false
+			}
+			if amount != 0 {
+				sum = append(sum, Coin{coinA.Denom, amount})
 			}
 
 			indexA++
@@ -361,13 +380,36 @@ func (coins Coins) Sub(coinsB Coins) Coins {
 	return res
 }
 
-// SubUnsafe performs the same arithmetic as Sub but returns a boolean if any
-// negative coin amount was returned.
+// SubUnsafe performs the same arithmetic as Sub but does not validate the
+// result, which may contain negative amounts.
+// An overflow or underflow panics.
 func (coins Coins) SubUnsafe(coinsB Coins) Coins {
 	res := coins.AddUnsafe(coinsB.negative())
 	return res
 }
 
+// SafeSub subtracts a set of coins from another without panicking. It
+// returns the difference and true, or nil and false if any resulting amount
+// would be negative, the arithmetic overflows, or the difference is not
+// valid.
+//
+// e.g.
+// {2A, 3B}.SafeSub({A}) = {A, 3B}, true
+// {A}.SafeSub({2A}) = nil, false
+func (coins Coins) SafeSub(coinsB Coins) (Coins, bool) {
+	for _, coin := range coinsB {
+		if coin.Amount == math.MinInt64 {
+			// cannot be negated.
+			return nil, false
+		}
+	}
+	diff, ok := coins.addOverflow(coinsB.negative())
+	if !ok || !diff.IsValid() {
+		return nil, false
+	}
+	return diff, true
+}
+
 // IsAllGT returns true if for every denom in coinsB,
 // the denom is present at a greater amount in coins.
 func (coins Coins) IsAllGT(coinsB Coins) bool {
@@ -620,13 +662,16 @@ var (
 	// Denominations can be 3 ~ 16 characters long.
 	reDnmString = `[a-z][a-z0-9]{2,15}`
 	reAmt       = `[[:digit:]]+`
-	reDecAmt    = `[[:digit:]]*\.[[:digit:]]+`
+	reDecAmt    = `[[:digit:]]+(?:\.[[:digit:]]+)?|\.[[:digit:]]+`
 	reSpc       = `[[:space:]]*`
 	reDnm       = regexp.MustCompile(fmt.Sprintf(`^%s$`, reDnmString))
 	reCoin      = regexp.MustCompile(fmt.Sprintf(`^(%s)%s(%s)$`, reAmt, reSpc, reDnmString))
 	reDecCoin   = regexp.MustCompile(fmt.Sprintf(`^(%s)%s(%s)$`, reDecAmt, reSpc, reDnmString))
 )
 
+// validateDenom returns an error unless the denom is 3 to 16 characters long,
+// starts with a lowercase letter, and otherwise contains only lowercase
+// letters and digits.
 func validateDenom(denom string) error {
 	if !reDnm.MatchString(denom) {
 		return fmt.Errorf("invalid denom: %s", denom)
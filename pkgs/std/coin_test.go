@@ -1,6 +1,7 @@
 package std
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -276,6 +277,95 @@ func TestSubCoins(t *testing.T) {
 	}
 }
 
+func TestSafeAddCoins(t *testing.T) {
+	one := int64(1)
+	max := int64(math.MaxInt64)
+
+	cases := []struct {
+		inputOne Coins
+		inputTwo Coins
+		expected Coins
+		ok       bool
+	}{
+		{Coins{{testDenom1, one}}, Coins{{testDenom1, one}, {testDenom2, one}}, Coins{{testDenom1, 2}, {testDenom2, one}}, true},
+		{Coins{{testDenom1, max - 1}}, Coins{{testDenom1, one}}, Coins{{testDenom1, max}}, true},
+		{Coins{{testDenom1, max}}, Coins{{testDenom1, one}}, nil, false},
+		{Coins{{testDenom1, max}, {testDenom2, one}}, Coins{{testDenom1, max}}, nil, false},
+		{Coins{{testDenom1, one}}, Coins{{testDenom1, -2}}, nil, false},
+		{Coins{{testDenom1, one}}, Coins{{testDenom1, -1}}, nil, true},
+	}
+
+	for i, tc := range cases {
+		res, ok := tc.inputOne.SafeAdd(tc.inputTwo)
+		require.Equal(t, tc.ok, ok, "tc #%d", i)
+		require.Equal(t, tc.expected, res, "tc #%d", i)
+	}
+
+	require.Panics(t, func() { Coins{{testDenom1, max}}.Add(Coins{{testDenom1, one}}) })
+	require.Panics(t, func() { Coins{{testDenom1, max}}.AddUnsafe(Coins{{testDenom1, one}}) })
+}
+
+func TestSafeSubCoins(t *testing.T) {
+	one := int64(1)
+	two := int64(2)
+	max := int64(math.MaxInt64)
+	min := int64(math.MinInt64)
+
+	cases := []struct {
+		inputOne Coins
+		inputTwo Coins
+		expected Coins
+		ok       bool
+	}{
+		{Coins{{testDenom1, two}, {testDenom2, two}}, Coins{{testDenom1, one}}, Coins{{testDenom1, one}, {testDenom2, two}}, true},
+		{Coins{{testDenom1, one}, {testDenom2, one}}, Coins{{testDenom1, one}}, Coins{{testDenom2, one}}, true},
+		{Coins{{testDenom1, one}}, Coins{{testDenom1, one}}, nil, true},
+		{Coins{{testDenom1, one}}, Coins{}, Coins{{testDenom1, one}}, true},
+		{Coins{{testDenom1, one}}, Coins{{testDenom1, two}}, nil, false},
+		{Coins{{testDenom1, one}}, Coins{{testDenom2, one}}, nil, false},
+		{Coins{}, Coins{{testDenom1, one}}, nil, false},
+		{Coins{{testDenom1, max}}, Coins{{testDenom1, -1}}, nil, false},
+		{Coins{{testDenom1, one}}, Coins{{testDenom1, min}}, nil, false},
+	}
+
+	for i, tc := range cases {
+		res, ok := tc.inputOne.SafeSub(tc.inputTwo)
+		require.Equal(t, tc.ok, ok, "tc #%d", i)
+		require.Equal(t, tc.expected, res, "tc #%d", i)
+	}
+}
+
+func TestCoinsValidate(t *testing.T) {
+	cases := []struct {
+		coins  Coins
+		errMsg string
+	}{
+		{Coins{}, ""},
+		{Coins{{"atom", 1}, {"muon", 1}}, ""},
+		{Coins{{"atom", 0}}, "non-positive coin amount"},
+		{Coins{{"atom", 1}, {"muon", -1}}, "non-positive coin amount"},
+		{Coins{{"atom", 1}, {"atom", 2}}, "duplicate denom"},
+		{Coins{{"muon", 1}, {"atom", 1}}, "unsorted denoms"},
+		{Coins{{"atom", 1}, {"Muon", 1}}, "invalid denom"},
+		{Coins{{"atom", 1}, {"mu", 1}}, "invalid denom"},
+		{Coins{{"atom", 1}, {"muon-1", 1}}, "invalid denom"},
+		{Coins{{"atom", 1}, {"m1234567890123456", 1}}, "invalid denom"},
+		{Coins{{"1atom", 1}}, "invalid denom"},
+	}
+
+	for i, tc := range cases {
+		err := tc.coins.Validate()
+		if tc.errMsg == "" {
+			require.NoError(t, err, "tc #%d", i)
+			require.True(t, tc.coins.IsValid(), "tc #%d", i)
+		} else {
+			require.Error(t, err, "tc #%d", i)
+			require.Contains(t, err.Error(), tc.errMsg, "tc #%d", i)
+			require.False(t, tc.coins.IsValid(), "tc #%d", i)
+		}
+	}
+}
+
 func TestCoins(t *testing.T) {
 	good := Coins{
 		{"gas", int64(1)},
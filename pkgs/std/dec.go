@@ -0,0 +1,248 @@
+package std
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+//-----------------------------------------------------------------------------
+// Dec
+
+// DecPrecision is the number of decimal places of a Dec.
+const DecPrecision = 18
+
+var (
+	precisionMultiplier = new(big.Int).Exp(big.NewInt(10), big.NewInt(DecPrecision), nil)
+	bigOne              = big.NewInt(1)
+	bigMaxInt64         = big.NewInt(1<<63 - 1)
+	bigMinInt64         = big.NewInt(-1 << 63)
+)
+
+// Dec is a signed fixed-point decimal number with DecPrecision decimal
+// places. It is used where integer coin amounts are too coarse, such as for
+// gas prices.
+//
+// All operations are deterministic. Operations that would produce more than
+// DecPrecision decimal places truncate toward zero; no banker's rounding is
+// ever applied. The zero value is 0.
+type Dec struct {
+	i *big.Int // value * 10^DecPrecision
+}
+
+// NewDec returns an integer Dec.
+func NewDec(i int64) Dec {
+	return NewDecWithPrec(i, 0)
+}
+
+// NewDecWithPrec returns i * 10^-prec.
+// e.g. NewDecWithPrec(25, 3) is 0.025.
+func NewDecWithPrec(i int64, prec int) Dec {
+	if prec < 0 || prec > DecPrecision {
+		panic(fmt.Sprintf("invalid decimal precision: %d", prec))
+	}
+	bi := new(big.Int).Mul(big.NewInt(i), pow10(DecPrecision-prec))
+	return Dec{bi}
+}
+
+// ParseDec parses a decimal string such as "1", "0.025", ".5", or "-1.5".
+// At most DecPrecision decimal places are allowed. Exponents, thousands
+// separators, and locale specific decimal marks are not accepted.
+func ParseDec(str string) (Dec, error) {
+	orig := str
+	neg := false
+	if strings.HasPrefix(str, "-") {
+		neg = true
+		str = str[1:]
+	}
+	intStr, fracStr := str, ""
+	if idx := strings.IndexByte(str, '.'); idx >= 0 {
+		intStr, fracStr = str[:idx], str[idx+1:]
+		if fracStr == "" {
+			return Dec{}, errors.New("invalid decimal: %q (no digits after decimal point)", orig)
+		}
+	}
+	if intStr == "" && fracStr == "" {
+		return Dec{}, errors.New("invalid decimal: %q (empty)", orig)
+	}
+	if !isDigits(intStr) || !isDigits(fracStr) {
+		return Dec{}, errors.New("invalid decimal: %q", orig)
+	}
+	if len(fracStr) > DecPrecision {
+		return Dec{}, errors.New("invalid decimal: %q (too many decimal places, max %d)", orig, DecPrecision)
+	}
+	digits := intStr + fracStr + strings.Repeat("0", DecPrecision-len(fracStr))
+	bi, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Dec{}, errors.New("invalid decimal: %q", orig)
+	}
+	if neg {
+		bi.Neg(bi)
+	}
+	return Dec{bi}, nil
+}
+
+// MustParseDec is like ParseDec but panics on error.
+func MustParseDec(str string) Dec {
+	d, err := ParseDec(str)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func isDigits(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] < '0' || str[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// bigInt returns the underlying scaled integer, treating the zero value as 0.
+// The result must not be modified.
+func (d Dec) bigInt() *big.Int {
+	if d.i == nil {
+		return new(big.Int)
+	}
+	return d.i
+}
+
+// String returns the canonical representation of d: no exponent, no
+// trailing fractional zeros, and no decimal point for integers.
+// e.g. "0.025", "5", "-1.5".
+func (d Dec) String() string {
+	bi := d.bigInt()
+	abs := new(big.Int).Abs(bi)
+	intPart, fracPart := new(big.Int).QuoRem(abs, precisionMultiplier, new(big.Int))
+	sign := ""
+	if bi.Sign() < 0 {
+		sign = "-"
+	}
+	if fracPart.Sign() == 0 {
+		return sign + intPart.String()
+	}
+	frac := fracPart.String()
+	frac = strings.Repeat("0", DecPrecision-len(frac)) + frac
+	frac = strings.TrimRight(frac, "0")
+	return sign + intPart.String() + "." + frac
+}
+
+func (d Dec) MarshalAmino() (string, error) {
+	return d.String(), nil
+}
+
+func (d *Dec) UnmarshalAmino(str string) error {
+	if str == "" {
+		*d = Dec{}
+		return nil
+	}
+	d2, err := ParseDec(str)
+	if err != nil {
+		return err
+	}
+	*d = d2
+	return nil
+}
+
+// IsZero returns true if d is 0.
+func (d Dec) IsZero() bool { return d.bigInt().Sign() == 0 }
+
+// IsNegative returns true if d is less than 0.
+func (d Dec) IsNegative() bool { return d.bigInt().Sign() < 0 }
+
+// IsPositive returns true if d is greater than 0.
+func (d Dec) IsPositive() bool { return d.bigInt().Sign() > 0 }
+
+// Cmp compares d and d2 and returns -1, 0, or +1.
+func (d Dec) Cmp(d2 Dec) int { return d.bigInt().Cmp(d2.bigInt()) }
+
+// Equal returns true if d == d2.
+func (d Dec) Equal(d2 Dec) bool { return d.Cmp(d2) == 0 }
+
+// GT returns true if d > d2.
+func (d Dec) GT(d2 Dec) bool { return d.Cmp(d2) > 0 }
+
+// GTE returns true if d >= d2.
+func (d Dec) GTE(d2 Dec) bool { return d.Cmp(d2) >= 0 }
+
+// LT returns true if d < d2.
+func (d Dec) LT(d2 Dec) bool { return d.Cmp(d2) < 0 }
+
+// LTE returns true if d <= d2.
+func (d Dec) LTE(d2 Dec) bool { return d.Cmp(d2) <= 0 }
+
+// Add returns d + d2.
+func (d Dec) Add(d2 Dec) Dec {
+	return Dec{new(big.Int).Add(d.bigInt(), d2.bigInt())}
+}
+
+// Sub returns d - d2.
+func (d Dec) Sub(d2 Dec) Dec {
+	return Dec{new(big.Int).Sub(d.bigInt(), d2.bigInt())}
+}
+
+// Mul returns d * d2, truncated toward zero to DecPrecision decimal places.
+func (d Dec) Mul(d2 Dec) Dec {
+	mul := new(big.Int).Mul(d.bigInt(), d2.bigInt())
+	return Dec{mul.Quo(mul, precisionMultiplier)}
+}
+
+// MulInt64 returns d * i. The result is exact.
+func (d Dec) MulInt64(i int64) Dec {
+	return Dec{new(big.Int).Mul(d.bigInt(), big.NewInt(i))}
+}
+
+// Quo returns d / d2, truncated toward zero to DecPrecision decimal places.
+// It panics if d2 is zero.
+func (d Dec) Quo(d2 Dec) Dec {
+	if d2.IsZero() {
+		panic("decimal division by zero")
+	}
+	num := new(big.Int).Mul(d.bigInt(), precisionMultiplier)
+	return Dec{num.Quo(num, d2.bigInt())}
+}
+
+// QuoInt64 returns d / i, truncated toward zero to DecPrecision decimal
+// places. It panics if i is zero.
+func (d Dec) QuoInt64(i int64) Dec {
+	if i == 0 {
+		panic("decimal division by zero")
+	}
+	return Dec{new(big.Int).Quo(d.bigInt(), big.NewInt(i))}
+}
+
+// Neg returns -d.
+func (d Dec) Neg() Dec {
+	return Dec{new(big.Int).Neg(d.bigInt())}
+}
+
+// TruncateInt64 returns the integer part of d, truncated toward zero, and
+// false if it does not fit in an int64.
+func (d Dec) TruncateInt64() (int64, bool) {
+	return bigToInt64(new(big.Int).Quo(d.bigInt(), precisionMultiplier))
+}
+
+// CeilInt64 returns the smallest integer greater than or equal to d, and
+// false if it does not fit in an int64.
+func (d Dec) CeilInt64() (int64, bool) {
+	quo, rem := new(big.Int).QuoRem(d.bigInt(), precisionMultiplier, new(big.Int))
+	if rem.Sign() > 0 {
+		quo.Add(quo, bigOne)
+	}
+	return bigToInt64(quo)
+}
+
+func bigToInt64(bi *big.Int) (int64, bool) {
+	if bi.Cmp(bigMaxInt64) > 0 || bi.Cmp(bigMinInt64) < 0 {
+		return 0, false
+	}
+	return bi.Int64(), true
+}
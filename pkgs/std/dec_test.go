@@ -0,0 +1,250 @@
+package std
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDec(t *testing.T) {
+	cases := []struct {
+		input    string
+		valid    bool
+		expected string
+	}{
+		{"0", true, "0"},
+		{"1", true, "1"},
+		{"0.1", true, "0.1"},
+		{".5", true, "0.5"},
+		{"1.000000000000000000", true, "1"},
+		{"0.000000000000000001", true, "0.000000000000000001"},
+		{"00012.3400", true, "12.34"},
+		{"-1.5", true, "-1.5"},
+		{"-.5", true, "-0.5"},
+		{"123456789012345678901234567890", true, "123456789012345678901234567890"},
+		{"", false, ""},
+		{".", false, ""},
+		{"-", false, ""},
+		{"1.", false, ""},
+		{"0.0000000000000000001", false, ""}, // too many decimal places
+		{"1,5", false, ""},                   // no locale specific decimal marks
+		{"1e5", false, ""},
+		{"1.2.3", false, ""},
+		{"+1", false, ""},
+		{" 1", false, ""},
+		{"--1", false, ""},
+	}
+
+	for i, tc := range cases {
+		res, err := ParseDec(tc.input)
+		if !tc.valid {
+			require.Error(t, err, "%q: %v, tc #%d", tc.input, res, i)
+			continue
+		}
+		require.NoError(t, err, "%q, tc #%d", tc.input, i)
+		require.Equal(t, tc.expected, res.String(), "tc #%d", i)
+
+		// String() is canonical and round-trips.
+		res2, err := ParseDec(res.String())
+		require.NoError(t, err)
+		require.True(t, res.Equal(res2), "tc #%d", i)
+	}
+}
+
+func TestDecZeroValue(t *testing.T) {
+	var d Dec
+	assert.True(t, d.IsZero())
+	assert.Equal(t, "0", d.String())
+	assert.True(t, d.Equal(NewDec(0)))
+	assert.True(t, d.Add(NewDec(1)).Equal(NewDec(1)))
+}
+
+func TestDecArithmetic(t *testing.T) {
+	a := MustParseDec("1.5")
+	b := MustParseDec("0.25")
+
+	assert.Equal(t, "1.75", a.Add(b).String())
+	assert.Equal(t, "1.25", a.Sub(b).String())
+	assert.Equal(t, "-1.25", b.Sub(a).String())
+	assert.Equal(t, "0.375", a.Mul(b).String())
+	assert.Equal(t, "6", a.Quo(b).String())
+	assert.Equal(t, "4.5", a.MulInt64(3).String())
+	assert.Equal(t, "0.5", a.QuoInt64(3).String())
+	assert.Equal(t, "-1.5", a.Neg().String())
+	assert.True(t, a.GT(b))
+	assert.True(t, b.LT(a))
+	assert.True(t, a.GTE(a))
+	assert.True(t, a.LTE(a))
+	assert.True(t, NewDecWithPrec(25, 3).Equal(MustParseDec("0.025")))
+
+	require.Panics(t, func() { a.Quo(Dec{}) })
+	require.Panics(t, func() { a.QuoInt64(0) })
+	require.Panics(t, func() { NewDecWithPrec(1, DecPrecision+1) })
+}
+
+func TestDecTruncation(t *testing.T) {
+	// results with more than DecPrecision decimal places truncate toward
+	// zero, never round half to even.
+	third := NewDec(1).QuoInt64(3)
+	assert.Equal(t, "0.333333333333333333", third.String())
+	twoThirds := NewDec(2).Quo(NewDec(3))
+	assert.Equal(t, "0.666666666666666666", twoThirds.String())
+	negTwoThirds := NewDec(-2).Quo(NewDec(3))
+	assert.Equal(t, "-0.666666666666666666", negTwoThirds.String())
+	tiny := MustParseDec("0.000000000000000005")
+	assert.Equal(t, "0.000000000000000002", tiny.Mul(MustParseDec("0.5")).String())
+	assert.Equal(t, "0", tiny.Mul(MustParseDec("0.1")).String())
+}
+
+func TestDecToInt64(t *testing.T) {
+	cases := []struct {
+		input    string
+		truncate int64
+		ceil     int64
+	}{
+		{"0", 0, 0},
+		{"1", 1, 1},
+		{"1.000000000000000001", 1, 2},
+		{"1.5", 1, 2},
+		{"1.999999999999999999", 1, 2},
+		{"-1.5", -1, -1},
+		{"-0.5", 0, 0},
+	}
+	for i, tc := range cases {
+		d := MustParseDec(tc.input)
+		trunc, ok := d.TruncateInt64()
+		require.True(t, ok)
+		require.Equal(t, tc.truncate, trunc, "tc #%d", i)
+		ceil, ok := d.CeilInt64()
+		require.True(t, ok)
+		require.Equal(t, tc.ceil, ceil, "tc #%d", i)
+	}
+
+	_, ok := NewDec(math.MaxInt64).Add(MustParseDec("0.5")).CeilInt64()
+	require.False(t, ok)
+	_, ok = NewDec(math.MaxInt64).Add(MustParseDec("0.5")).TruncateInt64()
+	require.True(t, ok)
+	_, ok = NewDec(math.MaxInt64).MulInt64(2).TruncateInt64()
+	require.False(t, ok)
+}
+
+func TestParseDecCoins(t *testing.T) {
+	cases := []struct {
+		input    string
+		valid    bool
+		expected string
+	}{
+		{"", true, ""},
+		{"0.025stake", true, "0.025stake"},
+		{".5foo", true, "0.5foo"},
+		{"5 foo", true, "5foo"},
+		{"1.000000000000000000foo", true, "1foo"},
+		{"0.1foo,2bar", true, "2bar,0.1foo"},
+		{"1.foo", false, ""},
+		{"-1foo", false, ""},
+		{"0foo", false, ""},      // zero amounts are not valid in a set
+		{"1foo,2foo", false, ""}, // duplicate denoms
+		{"1.0000000000000000001foo", false, ""},
+		{"1FOO", false, ""},
+	}
+
+	for i, tc := range cases {
+		res, err := ParseDecCoins(tc.input)
+		if !tc.valid {
+			require.Error(t, err, "%q: %v, tc #%d", tc.input, res, i)
+			continue
+		}
+		require.NoError(t, err, "%q, tc #%d", tc.input, i)
+		require.Equal(t, tc.expected, res.String(), "tc #%d", i)
+	}
+}
+
+func TestParseGasPrice(t *testing.T) {
+	cases := []struct {
+		input    string
+		valid    bool
+		expected string
+	}{
+		{"0.025stake", true, "0.025stake"},
+		{"5000stake/10gas", true, "500stake"},
+		{"1stake/3gas", true, "0.333333333333333333stake"},
+		{"0.5stake/2gas", true, "0.25stake"},
+		{"5000stake/10foo", false, ""},
+		{"5000stake/0gas", false, ""},
+		{"5000stake/10gas/1gas", false, ""},
+		{"stake", false, ""},
+	}
+
+	for i, tc := range cases {
+		res, err := ParseGasPrice(tc.input)
+		if !tc.valid {
+			require.Error(t, err, "%q: %v, tc #%d", tc.input, res, i)
+			continue
+		}
+		require.NoError(t, err, "%q, tc #%d", tc.input, i)
+		require.Equal(t, tc.expected, res.String(), "tc #%d", i)
+	}
+}
+
+func TestParseGasPrices(t *testing.T) {
+	cases := []struct {
+		input    string
+		valid    bool
+		expected string
+	}{
+		{"0.025stake", true, "0.025stake"},
+		{"0.01atom,0.5photon", true, "0.01atom,0.5photon"},
+		{"0.5photon,0.01atom", true, "0.01atom,0.5photon"},
+		{"0.5photon, 5000stake/10gas ,0.01atom", true, "0.01atom,0.5photon,500stake"},
+		{"0.5photon;0.01atom", true, "0.01atom,0.5photon"},
+		{"", false, ""},
+		{",", false, ""},
+		{"0.01atom,0.5Photon", false, ""},
+		{"0.01atom,0.5photon,0.02atom", false, ""},
+		{"0.01atom,0photon", false, ""},
+		{"0.01atom,-1photon", false, ""},
+		{"0.01atom,1photon/0gas", false, ""},
+		{"1atom/1000000000000000000000gas", false, ""}, // truncated to zero
+	}
+
+	for i, tc := range cases {
+		res, err := ParseGasPrices(tc.input)
+		if !tc.valid {
+			require.Error(t, err, "%q: %v, tc #%d", tc.input, res, i)
+			continue
+		}
+		require.NoError(t, err, "%q, tc #%d", tc.input, i)
+		require.True(t, res.IsValid(), "tc #%d", i)
+		require.Equal(t, tc.expected, res.String(), "tc #%d", i)
+	}
+}
+
+func TestGasFee(t *testing.T) {
+	cases := []struct {
+		price    string
+		gas      int64
+		expected int64
+	}{
+		{"0.025stake", 0, 0},
+		{"0.025stake", 40, 1},
+		{"0.025stake", 41, 2}, // rounds up
+		{"0.025stake", 200000, 5000},
+		{"1stake", 200000, 200000},
+		{"0.000000000000000001stake", 1, 1},
+		{"1stake/3gas", 3, 1},
+		{"1stake/3gas", 4, 2},
+	}
+
+	for i, tc := range cases {
+		price, err := ParseGasPrice(tc.price)
+		require.NoError(t, err)
+		fee, ok := GasFee(price, tc.gas)
+		require.True(t, ok, "tc #%d", i)
+		require.Equal(t, Coin{Denom: "stake", Amount: tc.expected}, fee, "tc #%d", i)
+	}
+
+	_, ok := GasFee(NewDecCoin("stake", NewDec(2)), math.MaxInt64)
+	require.False(t, ok)
+}
@@ -0,0 +1,194 @@
+package std
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+//-----------------------------------------------------------------------------
+// DecCoin
+
+// DecCoin holds a decimal amount of one currency.
+// A negative amount is invalid.
+type DecCoin struct {
+	Denom  string `json:"denom"`
+	Amount Dec    `json:"amount"`
+}
+
+// NewDecCoin returns a new decimal coin with a denomination and amount.
+// It will panic if the amount is negative or the denom is invalid.
+func NewDecCoin(denom string, amount Dec) DecCoin {
+	mustValidateDenom(denom)
+	if amount.IsNegative() {
+		panic(fmt.Sprintf("negative decimal coin amount: %v", amount))
+	}
+	return DecCoin{
+		Denom:  denom,
+		Amount: amount,
+	}
+}
+
+func (coin DecCoin) MarshalAmino() (string, error) {
+	return coin.String(), nil
+}
+
+func (coin *DecCoin) UnmarshalAmino(coinstr string) (err error) {
+	if coinstr == "" {
+		return nil
+	}
+	coin2, err := ParseDecCoin(coinstr)
+	if err != nil {
+		return err
+	}
+	*coin = coin2
+	return nil
+}
+
+// String provides a human-readable representation of a decimal coin,
+// e.g. "0.025stake".
+func (coin DecCoin) String() string {
+	if coin.IsZero() {
+		return ""
+	}
+	return coin.Amount.String() + coin.Denom
+}
+
+// IsValid returns true if the DecCoin has a non-negative amount and the
+// denom is valid.
+func (coin DecCoin) IsValid() bool {
+	if err := validateDenom(coin.Denom); err != nil {
+		return false
+	}
+	return !coin.Amount.IsNegative()
+}
+
+// IsZero returns if this represents no money.
+func (coin DecCoin) IsZero() bool {
+	return coin.Amount.IsZero()
+}
+
+// IsPositive returns true if coin amount is positive.
+func (coin DecCoin) IsPositive() bool {
+	return coin.Amount.IsPositive()
+}
+
+// ParseDecCoin parses a decimal coin such as "0.025stake" or "5stake",
+// returning errors if invalid. This returns an error on an empty string as
+// well.
+func ParseDecCoin(coinStr string) (coin DecCoin, err error) {
+	coinStr = strings.TrimSpace(coinStr)
+
+	matches := reDecCoin.FindStringSubmatch(coinStr)
+	if matches == nil {
+		return DecCoin{}, fmt.Errorf("invalid decimal coin expression: %s", coinStr)
+	}
+
+	amountStr, denomStr := matches[1], matches[2]
+
+	amount, err := ParseDec(amountStr)
+	if err != nil {
+		return DecCoin{}, errors.Wrap(err, "failed to parse decimal coin amount: %s", amountStr)
+	}
+
+	if err := validateDenom(denomStr); err != nil {
+		return DecCoin{}, fmt.Errorf("invalid denom cannot contain upper case characters or spaces: %s", err)
+	}
+
+	return NewDecCoin(denomStr, amount), nil
+}
+
+//-----------------------------------------------------------------------------
+// DecCoins
+
+// DecCoins is a set of DecCoin, one per currency.
+type DecCoins []DecCoin
+
+func (coins DecCoins) String() string {
+	strs := make([]string, len(coins))
+	for i, coin := range coins {
+		strs[i] = coin.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+// IsValid asserts the DecCoins are sorted by denomination with no
+// duplicates, have positive amounts, and have valid denominations.
+func (coins DecCoins) IsValid() bool {
+	for i, coin := range coins {
+		if !coin.IsValid() || !coin.IsPositive() {
+			return false
+		}
+		if i > 0 && coin.Denom <= coins[i-1].Denom {
+			return false
+		}
+	}
+	return true
+}
+
+// AmountOf returns the amount of a denom from coins, or zero.
+func (coins DecCoins) AmountOf(denom string) Dec {
+	mustValidateDenom(denom)
+
+	for _, coin := range coins {
+		if coin.Denom == denom {
+			return coin.Amount
+		}
+	}
+	return Dec{}
+}
+
+// IsZero returns true if there are no coins or all coins are zero.
+func (coins DecCoins) IsZero() bool {
+	for _, coin := range coins {
+		if !coin.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseDecCoins will parse out a list of decimal coins separated by commas.
+// If nothing is provided, it returns nil DecCoins.
+// Returned coins must be valid, and are sorted.
+func ParseDecCoins(coinsStr string) (DecCoins, error) {
+	coinsStr = strings.TrimSpace(coinsStr)
+	if len(coinsStr) == 0 {
+		return nil, nil
+	}
+
+	coinStrs := strings.Split(coinsStr, ",")
+	coins := make(DecCoins, len(coinStrs))
+	for i, coinStr := range coinStrs {
+		coin, err := ParseDecCoin(coinStr)
+		if err != nil {
+			return nil, err
+		}
+		coins[i] = coin
+	}
+
+	// sort coins for determinism
+	coins.Sort()
+
+	// validate coins before returning
+	if !coins.IsValid() {
+		return nil, fmt.Errorf("parseDecCoins invalid: %s", coins)
+	}
+
+	return coins, nil
+}
+
+//-----------------------------------------------------------------------------
+// Sort interface
+
+func (coins DecCoins) Len() int           { return len(coins) }
+func (coins DecCoins) Less(i, j int) bool { return coins[i].Denom < coins[j].Denom }
+func (coins DecCoins) Swap(i, j int)      { coins[i], coins[j] = coins[j], coins[i] }
+
+// Sort is a helper function to sort the set of decimal coins in place.
+func (coins DecCoins) Sort() DecCoins {
+	sort.Sort(coins)
+	return coins
+}
@@ -11,6 +11,8 @@ func (_ abciError) AssertABCIError() {}
 
 // declare all std errors.
 // NOTE: these are meant to be used in conjunction with pkgs/errors.
+// Their zero values are sentinels for errors.Is, e.g.
+// errors.Is(err, OutOfGasError{}) through any number of errors.Wrap().
 type InternalError struct{ abciError }
 type TxDecodeError struct{ abciError }
 type InvalidSequenceError struct{ abciError }
@@ -28,6 +30,12 @@ type InsufficientFeeError struct{ abciError }
 type TooManySignaturesError struct{ abciError }
 type NoSignaturesError struct{ abciError }
 type GasOverflowError struct{ abciError }
+type TxInCacheError struct{ abciError }
+type ResponseTooLargeError struct{ abciError }
+type TooManyQueriesError struct{ abciError }
+type QueryTooExpensiveError struct{ abciError }
+type HandlerTimeoutError struct{ abciError }
+type ExecutionLimitError struct{ abciError }
 
 func (e InternalError) Error() string          { return "internal error" }
 func (e TxDecodeError) Error() string          { return "tx decode error" }
@@ -46,9 +54,42 @@ func (e InsufficientFeeError) Error() string   { return "insufficient fee error"
 func (e TooManySignaturesError) Error() string { return "too many signatures error" }
 func (e NoSignaturesError) Error() string      { return "no signatures error" }
 func (e GasOverflowError) Error() string       { return "gas overflow error" }
+func (e TxInCacheError) Error() string         { return "tx in cache error" }
+func (e ResponseTooLargeError) Error() string  { return "response too large error" }
+func (e TooManyQueriesError) Error() string    { return "too many queries error" }
+func (e QueryTooExpensiveError) Error() string { return "query too expensive error" }
+func (e HandlerTimeoutError) Error() string    { return "handler timeout error" }
+func (e ExecutionLimitError) Error() string    { return "execution limit error" }
 
 // NOTE also update pkg/std/package.go registrations.
 
+// The codes of the std errors must not change, as clients depend on them.
+func init() {
+	RegisterError(CodespaceSDK, CodeInternal, InternalError{})
+	RegisterError(CodespaceSDK, 2, TxDecodeError{})
+	RegisterError(CodespaceSDK, 3, InvalidSequenceError{})
+	RegisterError(CodespaceSDK, 4, UnauthorizedError{})
+	RegisterError(CodespaceSDK, 5, InsufficientFundsError{})
+	RegisterError(CodespaceSDK, 6, UnknownRequestError{})
+	RegisterError(CodespaceSDK, 7, InvalidAddressError{})
+	RegisterError(CodespaceSDK, 8, UnknownAddressError{})
+	RegisterError(CodespaceSDK, 9, InvalidPubKeyError{})
+	RegisterError(CodespaceSDK, 10, InsufficientCoinsError{})
+	RegisterError(CodespaceSDK, 11, InvalidCoinsError{})
+	RegisterError(CodespaceSDK, 12, OutOfGasError{})
+	RegisterError(CodespaceSDK, 13, MemoTooLargeError{})
+	RegisterError(CodespaceSDK, 14, InsufficientFeeError{})
+	RegisterError(CodespaceSDK, 15, TooManySignaturesError{})
+	RegisterError(CodespaceSDK, 16, NoSignaturesError{})
+	RegisterError(CodespaceSDK, 17, GasOverflowError{})
+	RegisterError(CodespaceSDK, 18, TxInCacheError{})
+	RegisterError(CodespaceSDK, 19, ResponseTooLargeError{})
+	RegisterError(CodespaceSDK, 20, TooManyQueriesError{})
+	RegisterError(CodespaceSDK, 21, QueryTooExpensiveError{})
+	RegisterError(CodespaceSDK, 22, HandlerTimeoutError{})
+	RegisterError(CodespaceSDK, 23, ExecutionLimitError{})
+}
+
 func ErrInternal(msg string) error {
 	return errors.Wrap(InternalError{}, msg)
 }
@@ -100,3 +141,21 @@ func ErrNoSignatures(msg string) error {
 func ErrGasOverflow(msg string) error {
 	return errors.Wrap(GasOverflowError{}, msg)
 }
+func ErrTxInCache(msg string) error {
+	return errors.Wrap(TxInCacheError{}, msg)
+}
+func ErrResponseTooLarge(msg string) error {
+	return errors.Wrap(ResponseTooLargeError{}, msg)
+}
+func ErrTooManyQueries(msg string) error {
+	return errors.Wrap(TooManyQueriesError{}, msg)
+}
+func ErrQueryTooExpensive(msg string) error {
+	return errors.Wrap(QueryTooExpensiveError{}, msg)
+}
+func ErrHandlerTimeout(msg string) error {
+	return errors.Wrap(HandlerTimeoutError{}, msg)
+}
+func ErrExecutionLimit(msg string) error {
+	return errors.Wrap(ExecutionLimitError{}, msg)
+}
@@ -6,45 +6,79 @@ import (
 	"github.com/gnolang/gno/pkgs/errors"
 )
 
-// minimum gas price is Price/Gas per gas unit.
-type GasPrice struct {
-	Gas   int64 `json:"gas"`
-	Price Coin  `json:"price"`
-}
-
-func ParseGasPrice(gasprice string) (GasPrice, error) {
+// ParseGasPrice parses a minimum gas price, which is the price of a single
+// unit of gas, e.g. "0.025stake". The ratio form "5000stake/10gas" is also
+// accepted and converted to a price per unit of gas, truncated to
+// DecPrecision decimal places.
+func ParseGasPrice(gasprice string) (DecCoin, error) {
 	parts := strings.Split(gasprice, "/")
-	if len(parts) != 2 {
-		return GasPrice{}, errors.New("invalid gas price: %s", gasprice)
-	}
-	price, err := ParseCoin(parts[0])
-	if err != nil {
-		return GasPrice{}, errors.Wrap(err, "invalid gas price: %s (invalid price)", gasprice)
-	}
-	gas, err := ParseCoin(parts[1])
-	if err != nil {
-		return GasPrice{}, errors.Wrap(err, "invalid gas price: %s (invalid gas denom)", gasprice)
-	}
-	if gas.Denom != "gas" {
-		return GasPrice{}, errors.New("invalid gas price: %s (invalid gas denom)", gasprice)
+	switch len(parts) {
+	case 1:
+		price, err := ParseDecCoin(parts[0])
+		if err != nil {
+			return DecCoin{}, errors.Wrap(err, "invalid gas price: %s (invalid price)", gasprice)
+		}
+		return price, nil
+	case 2:
+		price, err := ParseDecCoin(parts[0])
+		if err != nil {
+			return DecCoin{}, errors.Wrap(err, "invalid gas price: %s (invalid price)", gasprice)
+		}
+		gas, err := ParseCoin(parts[1])
+		if err != nil {
+			return DecCoin{}, errors.Wrap(err, "invalid gas price: %s (invalid gas denom)", gasprice)
+		}
+		if gas.Denom != "gas" {
+			return DecCoin{}, errors.New("invalid gas price: %s (invalid gas denom)", gasprice)
+		}
+		if gas.Amount == 0 {
+			return DecCoin{}, errors.New("invalid gas price: %s (zero gas)", gasprice)
+		}
+		return NewDecCoin(price.Denom, price.Amount.QuoInt64(gas.Amount)), nil
+	default:
+		return DecCoin{}, errors.New("invalid gas price: %s", gasprice)
 	}
-	return GasPrice{
-		Gas:   gas.Amount,
-		Price: price,
-	}, nil
 }
 
-func ParseGasPrices(gasprices string) (res []GasPrice, err error) {
-	parts := strings.Split(gasprices, ";")
+// ParseGasPrices parses a list of minimum gas prices separated by commas,
+// e.g. "0.025stake,0.1foo". Semicolons are also accepted as separators.
+// The prices must be positive, with at most one per denomination, and are
+// returned sorted by denomination. A fee satisfies the minimum gas prices
+// if it satisfies the price of its own denomination; see
+// auth.EnsureSufficientMempoolFees.
+func ParseGasPrices(gasprices string) (res DecCoins, err error) {
+	parts := strings.FieldsFunc(gasprices, func(r rune) bool {
+		return r == ',' || r == ';'
+	})
 	if len(parts) == 0 {
-		return nil, errors.New("invalid gas prices: %s", gasprices)
+		return nil, errors.New("invalid gas prices: %q (empty)", gasprices)
 	}
-	res = make([]GasPrice, len(parts))
+	res = make(DecCoins, len(parts))
 	for i, part := range parts {
-		res[i], err = ParseGasPrice(part)
+		res[i], err = ParseGasPrice(strings.TrimSpace(part))
 		if err != nil {
 			return nil, errors.Wrap(err, "invalid gas prices: %s", gasprices)
 		}
+		if !res[i].IsPositive() {
+			return nil, errors.New("invalid gas prices: %s (non-positive price %s)", gasprices, part)
+		}
+	}
+	res.Sort()
+	for i := 1; i < len(res); i++ {
+		if res[i].Denom == res[i-1].Denom {
+			return nil, errors.New("invalid gas prices: %s (duplicate denom %s)", gasprices, res[i].Denom)
+		}
 	}
 	return res, nil
 }
+
+// GasFee returns the fee required to pay for gas units of gas at the given
+// price per unit, which is ceil(price * gas) so that the fee is never
+// underestimated. It returns false if the fee does not fit in an int64.
+func GasFee(price DecCoin, gas int64) (Coin, bool) {
+	amount, ok := price.Amount.MulInt64(gas).CeilInt64()
+	if !ok {
+		return Coin{}, false
+	}
+	return Coin{Denom: price.Denom, Amount: amount}, true
+}
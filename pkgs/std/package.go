@@ -10,6 +10,9 @@ var Package = amino.RegisterPackage(amino.NewPackage(
 	amino.GetCallersDirname(),
 ).WithDependencies().WithTypes(
 	&BaseAccount{}, "BaseAccount",
+	&ContinuousVestingAccount{}, "ContinuousVestingAccount",
+	&DelayedVestingAccount{}, "DelayedVestingAccount",
+	MsgSignData{}, "MsgSignData",
 	InternalError{}, "InternalError",
 	TxDecodeError{}, "TxDecodeError",
 	InvalidSequenceError{}, "InvalidSequenceError",
@@ -26,4 +29,11 @@ var Package = amino.RegisterPackage(amino.NewPackage(
 	TooManySignaturesError{}, "TooManySignaturesError",
 	NoSignaturesError{}, "NoSignaturesError",
 	GasOverflowError{}, "GasOverflowError",
+	TxInCacheError{}, "TxInCacheError",
+	ResponseTooLargeError{}, "ResponseTooLargeError",
+	TooManyQueriesError{}, "TooManyQueriesError",
+	QueryTooExpensiveError{}, "QueryTooExpensiveError",
+	HandlerTimeoutError{}, "HandlerTimeoutError",
+	ExecutionLimitError{}, "ExecutionLimitError",
+	CodedError{}, "CodedError",
 ))
@@ -0,0 +1,82 @@
+package std
+
+import (
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+)
+
+// MsgSignData is a message to sign arbitrary data offline, e.g. to prove
+// the control of an address to an application without broadcasting a
+// transaction. It is only ever wrapped in a transaction to compute its
+// sign bytes (see SignDataBytes), and BaseApp rejects transactions that
+// contain it.
+type MsgSignData struct {
+	Signer crypto.Address `json:"signer" yaml:"signer"`
+	Data   []byte         `json:"data" yaml:"data"`
+}
+
+var _ Msg = MsgSignData{}
+
+// NewMsgSignData returns a MsgSignData of data by signer.
+func NewMsgSignData(signer crypto.Address, data []byte) MsgSignData {
+	return MsgSignData{Signer: signer, Data: data}
+}
+
+// Route Implements Msg. No handler is registered for this route.
+func (msg MsgSignData) Route() string { return "signdata" }
+
+// Type Implements Msg.
+func (msg MsgSignData) Type() string { return "signdata" }
+
+// ValidateBasic Implements Msg.
+func (msg MsgSignData) ValidateBasic() error {
+	if msg.Signer.IsZero() {
+		return ErrInvalidAddress("missing signer address")
+	}
+	if len(msg.Data) == 0 {
+		return ErrUnknownRequest("missing data to sign")
+	}
+	return nil
+}
+
+// GetSignBytes Implements Msg.
+func (msg MsgSignData) GetSignBytes() []byte {
+	return MustSortJSON(amino.MustMarshalJSON(msg))
+}
+
+// GetSigners Implements Msg.
+func (msg MsgSignData) GetSigners() []crypto.Address {
+	return []crypto.Address{msg.Signer}
+}
+
+// SignDataTx returns the transaction wrapping a MsgSignData of data by
+// signer, without fee, gas, or memo, and without signatures.
+func SignDataTx(signer crypto.Address, data []byte) Tx {
+	return NewTx([]Msg{NewMsgSignData(signer, data)}, Fee{}, nil, "")
+}
+
+// SignDataBytes returns the bytes to sign to sign data offline with the key
+// of signer: the sign bytes of SignDataTx with an empty chain ID, and zero
+// account number and sequence, so that they can never be valid sign bytes
+// of an on-chain transaction.
+func SignDataBytes(signer crypto.Address, data []byte) []byte {
+	return SignDataTx(signer, data).GetSignBytes("", 0, 0)
+}
+
+// VerifyArbitraryData verifies that sig is a signature of data by addr, as
+// produced by signing SignDataBytes(addr, data) with the key of pubKey.
+func VerifyArbitraryData(addr crypto.Address, pubKey crypto.PubKey, data, sig []byte) error {
+	if pubKey == nil {
+		return ErrInvalidPubKey("missing public key")
+	}
+	if pubKey.Address() != addr {
+		return ErrInvalidPubKey("public key does not match the signer address " + addr.String())
+	}
+	if err := NewMsgSignData(addr, data).ValidateBasic(); err != nil {
+		return err
+	}
+	if !pubKey.VerifyBytes(SignDataBytes(addr, data), sig) {
+		return ErrUnauthorized("signature verification failed")
+	}
+	return nil
+}
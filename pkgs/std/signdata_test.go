@@ -0,0 +1,70 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+	"github.com/gnolang/gno/pkgs/crypto/ed25519"
+	"github.com/gnolang/gno/pkgs/crypto/secp256k1"
+	"github.com/gnolang/gno/pkgs/errors"
+)
+
+func TestVerifyArbitraryData(t *testing.T) {
+	data := []byte("I control this address")
+	for _, priv := range []crypto.PrivKey{secp256k1.GenPrivKey(), ed25519.GenPrivKey()} {
+		pub := priv.PubKey()
+		addr := pub.Address()
+
+		sig, err := priv.Sign(SignDataBytes(addr, data))
+		require.NoError(t, err)
+		require.NoError(t, VerifyArbitraryData(addr, pub, data, sig))
+
+		// other data
+		err = VerifyArbitraryData(addr, pub, []byte("something else"), sig)
+		assert.IsType(t, UnauthorizedError{}, errors.Cause(err))
+		// mutated signature
+		mutated := append([]byte{}, sig...)
+		mutated[0] ^= 0x01
+		err = VerifyArbitraryData(addr, pub, data, mutated)
+		assert.IsType(t, UnauthorizedError{}, errors.Cause(err))
+		// other address
+		other := secp256k1.GenPrivKey().PubKey()
+		err = VerifyArbitraryData(other.Address(), pub, data, sig)
+		assert.IsType(t, InvalidPubKeyError{}, errors.Cause(err))
+		err = VerifyArbitraryData(addr, nil, data, sig)
+		assert.IsType(t, InvalidPubKeyError{}, errors.Cause(err))
+		// a signature of the data as a tx of some chain
+		txSig, err := priv.Sign(SignDataTx(addr, data).GetSignBytes("test-chain", 0, 0))
+		require.NoError(t, err)
+		err = VerifyArbitraryData(addr, pub, data, txSig)
+		assert.IsType(t, UnauthorizedError{}, errors.Cause(err))
+	}
+
+	priv := secp256k1.GenPrivKey()
+	addr := priv.PubKey().Address()
+	sig, err := priv.Sign(SignDataBytes(addr, nil))
+	require.NoError(t, err)
+	err = VerifyArbitraryData(addr, priv.PubKey(), nil, sig)
+	assert.IsType(t, UnknownRequestError{}, errors.Cause(err))
+}
+
+func TestSignDataTx(t *testing.T) {
+	addr := secp256k1.GenPrivKey().PubKey().Address()
+	tx := SignDataTx(addr, []byte("hello"))
+	assert.Equal(t, Fee{}, tx.Fee)
+	assert.Equal(t, "", tx.Memo)
+	assert.Equal(t, []crypto.Address{addr}, tx.GetSigners())
+
+	// the tx and its message round-trip through amino.
+	var decoded Tx
+	require.NoError(t, amino.Unmarshal(amino.MustMarshal(tx), &decoded))
+	assert.Equal(t, tx.Msgs, decoded.Msgs)
+
+	signBytes := string(SignDataBytes(addr, []byte("hello")))
+	assert.Contains(t, signBytes, `"chain_id":""`)
+	assert.Contains(t, signBytes, `"@type":"/std.MsgSignData"`)
+}
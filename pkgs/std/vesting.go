@@ -0,0 +1,196 @@
+package std
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// VestingAccount is an Account whose coins of OriginalVesting unlock over
+// time: the locked coins count toward its balance, but cannot be spent,
+// e.g. sent or used for fees.
+type VestingAccount interface {
+	Account
+
+	// LockedCoins returns the coins of the vesting schedule which are not
+	// vested at blockTime.
+	LockedCoins(blockTime time.Time) Coins
+
+	// SpendableCoins returns the coins of the account which are not
+	// locked at blockTime.
+	SpendableCoins(blockTime time.Time) Coins
+
+	// Validate returns an error if the vesting schedule is invalid.
+	Validate() error
+}
+
+// SpendableCoins returns the coins of acc which can be spent at blockTime,
+// which are all its coins unless it is a VestingAccount.
+func SpendableCoins(acc Account, blockTime time.Time) Coins {
+	if vacc, ok := acc.(VestingAccount); ok {
+		return vacc.SpendableCoins(blockTime)
+	}
+	return acc.GetCoins()
+}
+
+//----------------------------------------
+// BaseVestingAccount
+
+// BaseVestingAccount is the common part of the vesting accounts: a
+// BaseAccount, with the coins of its vesting schedule, which are all vested
+// at EndTime.
+type BaseVestingAccount struct {
+	BaseAccount
+
+	OriginalVesting Coins     `json:"original_vesting" yaml:"original_vesting"`
+	EndTime         time.Time `json:"end_time" yaml:"end_time"`
+}
+
+// spendableCoins returns the coins of the account minus locked, or zero of
+// the denoms of which it has less than locked, e.g. after slashing.
+func (bva BaseVestingAccount) spendableCoins(locked Coins) Coins {
+	var spendable Coins
+	for _, coin := range bva.Coins {
+		amount := coin.Amount - locked.AmountOf(coin.Denom)
+		if amount > 0 {
+			spendable = append(spendable, NewCoin(coin.Denom, amount))
+		}
+	}
+	return NewCoins(spendable...)
+}
+
+// validate returns an error if the vesting schedule is invalid.
+func (bva BaseVestingAccount) validate() error {
+	if err := bva.OriginalVesting.Validate(); err != nil {
+		return fmt.Errorf("invalid original vesting %s: %w", bva.OriginalVesting, err)
+	}
+	if bva.EndTime.IsZero() {
+		return fmt.Errorf("zero vesting end time")
+	}
+	return nil
+}
+
+//----------------------------------------
+// ContinuousVestingAccount
+
+// ContinuousVestingAccount is a vesting account whose coins vest linearly
+// from StartTime to EndTime.
+type ContinuousVestingAccount struct {
+	BaseVestingAccount
+
+	StartTime time.Time `json:"start_time" yaml:"start_time"`
+}
+
+var _ VestingAccount = (*ContinuousVestingAccount)(nil)
+
+// NewContinuousVestingAccount returns the account of base, whose coins of
+// originalVesting vest linearly from startTime to endTime.
+func NewContinuousVestingAccount(base BaseAccount, originalVesting Coins, startTime, endTime time.Time) *ContinuousVestingAccount {
+	return &ContinuousVestingAccount{
+		BaseVestingAccount: BaseVestingAccount{
+			BaseAccount:     base,
+			OriginalVesting: originalVesting,
+			EndTime:         endTime,
+		},
+		StartTime: startTime,
+	}
+}
+
+// Validate implements VestingAccount. The schedule must end after it
+// starts.
+func (cva *ContinuousVestingAccount) Validate() error {
+	if err := cva.validate(); err != nil {
+		return err
+	}
+	if !cva.EndTime.After(cva.StartTime) {
+		return fmt.Errorf("vesting end time %s is not after start time %s", cva.EndTime, cva.StartTime)
+	}
+	return nil
+}
+
+// LockedCoins implements VestingAccount. No coins are vested at StartTime,
+// and all are at EndTime.
+func (cva *ContinuousVestingAccount) LockedCoins(blockTime time.Time) Coins {
+	switch {
+	case !blockTime.After(cva.StartTime):
+		return cva.OriginalVesting
+	case !blockTime.Before(cva.EndTime):
+		return NewCoins()
+	}
+	// locked = original * (end - t) / (end - start), rounded up, so that
+	// coins vest once they are whole.
+	left := big.NewInt(int64(cva.EndTime.Sub(blockTime)))
+	total := big.NewInt(int64(cva.EndTime.Sub(cva.StartTime)))
+	var locked Coins
+	for _, coin := range cva.OriginalVesting {
+		amount := new(big.Int).Mul(big.NewInt(coin.Amount), left)
+		amount.Add(amount, total).Sub(amount, big.NewInt(1)).Quo(amount, total)
+		locked = append(locked, NewCoin(coin.Denom, amount.Int64()))
+	}
+	return NewCoins(locked...)
+}
+
+// SpendableCoins implements VestingAccount.
+func (cva *ContinuousVestingAccount) SpendableCoins(blockTime time.Time) Coins {
+	return cva.spendableCoins(cva.LockedCoins(blockTime))
+}
+
+// String implements fmt.Stringer
+func (cva ContinuousVestingAccount) String() string {
+	return fmt.Sprintf(`%s
+  OriginalVesting: %s
+  StartTime:       %s
+  EndTime:         %s`,
+		cva.BaseAccount.String(), cva.OriginalVesting, cva.StartTime, cva.EndTime,
+	)
+}
+
+//----------------------------------------
+// DelayedVestingAccount
+
+// DelayedVestingAccount is a vesting account whose coins all vest at
+// EndTime.
+type DelayedVestingAccount struct {
+	BaseVestingAccount
+}
+
+var _ VestingAccount = (*DelayedVestingAccount)(nil)
+
+// NewDelayedVestingAccount returns the account of base, whose coins of
+// originalVesting all vest at endTime.
+func NewDelayedVestingAccount(base BaseAccount, originalVesting Coins, endTime time.Time) *DelayedVestingAccount {
+	return &DelayedVestingAccount{
+		BaseVestingAccount: BaseVestingAccount{
+			BaseAccount:     base,
+			OriginalVesting: originalVesting,
+			EndTime:         endTime,
+		},
+	}
+}
+
+// Validate implements VestingAccount.
+func (dva *DelayedVestingAccount) Validate() error {
+	return dva.validate()
+}
+
+// LockedCoins implements VestingAccount.
+func (dva *DelayedVestingAccount) LockedCoins(blockTime time.Time) Coins {
+	if blockTime.Before(dva.EndTime) {
+		return dva.OriginalVesting
+	}
+	return NewCoins()
+}
+
+// SpendableCoins implements VestingAccount.
+func (dva *DelayedVestingAccount) SpendableCoins(blockTime time.Time) Coins {
+	return dva.spendableCoins(dva.LockedCoins(blockTime))
+}
+
+// String implements fmt.Stringer
+func (dva DelayedVestingAccount) String() string {
+	return fmt.Sprintf(`%s
+  OriginalVesting: %s
+  EndTime:         %s`,
+		dva.BaseAccount.String(), dva.OriginalVesting, dva.EndTime,
+	)
+}
@@ -0,0 +1,77 @@
+package std
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno/pkgs/amino"
+	"github.com/gnolang/gno/pkgs/crypto"
+)
+
+func TestContinuousVestingAccount(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Hour)
+	base := BaseAccount{Address: crypto.AddressFromPreimage([]byte("addr")), Coins: NewCoins(NewCoin("atom", 1000))}
+	acc := NewContinuousVestingAccount(base, NewCoins(NewCoin("atom", 1000)), start, end)
+	require.NoError(t, acc.Validate())
+
+	for _, tc := range []struct {
+		time      time.Time
+		spendable int64
+	}{
+		{start.Add(-time.Hour), 0},
+		{start, 0},
+		{start.Add(time.Nanosecond), 0}, // vested coins are whole.
+		{start.Add(36 * time.Second), 0},
+		{start.Add(360 * time.Second), 1},
+		{start.Add(25 * time.Hour), 250},
+		{end.Add(-time.Nanosecond), 999},
+		{end, 1000},
+		{end.Add(time.Hour), 1000},
+	} {
+		require.Equal(t, NewCoins(NewCoin("atom", tc.spendable)), acc.SpendableCoins(tc.time), "%s", tc.time)
+		require.Equal(t, NewCoins(NewCoin("atom", 1000-tc.spendable)), acc.LockedCoins(tc.time), "%s", tc.time)
+	}
+
+	// received coins are not locked, and spent ones are vested first.
+	acc.SetCoins(NewCoins(NewCoin("atom", 800), NewCoin("muon", 5)))
+	require.Equal(t, NewCoins(NewCoin("atom", 50), NewCoin("muon", 5)), acc.SpendableCoins(start.Add(25*time.Hour)))
+	require.Equal(t, NewCoins(NewCoin("muon", 5)), acc.SpendableCoins(start))
+	require.Equal(t, NewCoins(NewCoin("atom", 800), NewCoin("muon", 5)), SpendableCoins(acc, end))
+
+	require.Error(t, NewContinuousVestingAccount(base, NewCoins(NewCoin("atom", 1)), end, start).Validate())
+	require.Error(t, NewContinuousVestingAccount(base, NewCoins(NewCoin("atom", 1)), start, start).Validate())
+	require.Error(t, NewContinuousVestingAccount(base, Coins{{"atom", -1}}, start, end).Validate())
+}
+
+func TestDelayedVestingAccount(t *testing.T) {
+	end := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := BaseAccount{Coins: NewCoins(NewCoin("atom", 1000))}
+	acc := NewDelayedVestingAccount(base, NewCoins(NewCoin("atom", 600)), end)
+	require.NoError(t, acc.Validate())
+
+	require.Equal(t, NewCoins(NewCoin("atom", 400)), acc.SpendableCoins(end.Add(-time.Nanosecond)))
+	require.Equal(t, NewCoins(NewCoin("atom", 1000)), acc.SpendableCoins(end))
+	require.Equal(t, NewCoins(NewCoin("atom", 600)), acc.LockedCoins(end.Add(-time.Nanosecond)))
+	require.Equal(t, NewCoins(), acc.LockedCoins(end))
+
+	require.Error(t, NewDelayedVestingAccount(base, NewCoins(NewCoin("atom", 1)), time.Time{}).Validate())
+	require.Equal(t, base.Coins, SpendableCoins(&base, time.Time{}))
+}
+
+func TestVestingAccountAmino(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := BaseAccount{Address: crypto.AddressFromPreimage([]byte("addr")), Coins: NewCoins(NewCoin("atom", 10)), AccountNumber: 3}
+	for _, acc := range []Account{
+		NewContinuousVestingAccount(base, NewCoins(NewCoin("atom", 10)), start, start.Add(time.Hour)),
+		NewDelayedVestingAccount(base, NewCoins(NewCoin("atom", 10)), start),
+	} {
+		bz, err := amino.MarshalAny(acc)
+		require.NoError(t, err)
+		var decoded Account
+		require.NoError(t, amino.Unmarshal(bz, &decoded))
+		require.Equal(t, acc, decoded)
+	}
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gnolang/gno/pkgs/store/types"
+)
+
+// ConflictError is returned by OptimisticStore.Flush if entries it accessed
+// were changed in the parent meanwhile, e.g. by another store. The keys are
+// those of the parent, e.g. mapped back to object ids by
+// gno.ConflictObjectIDs for the backend of a gno store.
+type ConflictError struct {
+	Keys [][]byte // in ascending order.
+}
+
+func (e *ConflictError) Error() string {
+	keys := make([]string, len(e.Keys))
+	for i, key := range e.Keys {
+		keys[i] = fmt.Sprintf("%X", key)
+	}
+	return fmt.Sprintf("conflicting writes to keys %s", strings.Join(keys, ", "))
+}
+
+// OptimisticStore is a cache store which detects write conflicts with other
+// stores over the same parent, for off-chain tools, e.g. indexers or
+// migration scripts, which use several cache stores at once.
+//
+// It records the revision of the entries of the parent it accesses, with
+// Get, Has, Set or Delete, at their first access, and Flush writes its
+// changes to the parent only if the parent still has these revisions, with
+// the parent locked meanwhile, so that the Flush calls of the stores over
+// the same parent may run concurrently. A value changed and then restored
+// meanwhile is a conflict. Entries read only with iterators are not
+// checked.
+type OptimisticStore struct {
+	*cacheStore
+	parent types.RevisionStore
+
+	mtx       sync.Mutex
+	revisions map[string]uint64
+}
+
+var _ types.Store = (*OptimisticStore)(nil)
+
+// NewOptimistic returns an OptimisticStore over parent.
+func NewOptimistic(parent types.RevisionStore) *OptimisticStore {
+	return &OptimisticStore{
+		cacheStore: New(parent),
+		parent:     parent,
+		revisions:  make(map[string]uint64),
+	}
+}
+
+// record records the revision of the entry of key in the parent, if it is
+// accessed for the first time.
+func (store *OptimisticStore) record(key []byte) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	if _, ok := store.revisions[string(key)]; ok {
+		return
+	}
+	store.revisions[string(key)] = store.parent.Revision(key)
+}
+
+// Implements types.Store.
+func (store *OptimisticStore) Get(key []byte) []byte {
+	store.record(key)
+	return store.cacheStore.Get(key)
+}
+
+// Implements types.Store.
+func (store *OptimisticStore) Has(key []byte) bool {
+	return store.Get(key) != nil
+}
+
+// Implements types.Store.
+func (store *OptimisticStore) Set(key []byte, value []byte) {
+	store.record(key)
+	store.cacheStore.Set(key, value)
+}
+
+// Implements types.Store.
+func (store *OptimisticStore) Delete(key []byte) {
+	store.record(key)
+	store.cacheStore.Delete(key)
+}
+
+// Flush writes the changes to the parent like Write, unless entries were
+// changed in the parent since they were first accessed, in which case it
+// returns a *ConflictError with their keys, and writes nothing. The store
+// is then unchanged, and is to be discarded.
+func (store *OptimisticStore) Flush() (err error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+	store.cacheStore.mtx.Lock()
+	defer store.cacheStore.mtx.Unlock()
+
+	store.parent.Update(func(parent types.RevisionStore) {
+		var conflicts [][]byte
+		for key, rev := range store.revisions {
+			if parent.Revision([]byte(key)) != rev {
+				conflicts = append(conflicts, []byte(key))
+			}
+		}
+		if len(conflicts) > 0 {
+			sort.Slice(conflicts, func(i, j int) bool {
+				return string(conflicts[i]) < string(conflicts[j])
+			})
+			err = &ConflictError{Keys: conflicts}
+			return
+		}
+		store.cacheStore.write(parent)
+		store.revisions = make(map[string]uint64)
+	})
+	return err
+}
+
+// Write writes the changes to the parent without checking for conflicts,
+// and starts recording revisions again.
+func (store *OptimisticStore) Write() {
+	store.cacheStore.Write()
+
+	store.mtx.Lock()
+	store.revisions = make(map[string]uint64)
+	store.mtx.Unlock()
+}
+
+// Implements Store.
+func (store *OptimisticStore) CacheWrap() types.Store {
+	return New(store)
+}
@@ -0,0 +1,113 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	dbm "github.com/gnolang/gno/pkgs/db"
+
+	"github.com/gnolang/gno/pkgs/store/cache"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/revision"
+)
+
+func TestOptimisticStoreConflict(t *testing.T) {
+	mem := revision.New(dbadapter.Store{DB: dbm.NewMemDB()})
+	mem.Set(keyFmt(1), valFmt(1))
+	mem.Set(keyFmt(2), valFmt(2))
+
+	// both stores write key2 and read key1, which only st1 writes.
+	st1 := cache.NewOptimistic(mem)
+	st2 := cache.NewOptimistic(mem)
+	require.Equal(t, valFmt(1), st1.Get(keyFmt(1)))
+	require.Equal(t, valFmt(1), st2.Get(keyFmt(1)))
+	st1.Set(keyFmt(1), valFmt(10))
+	st1.Set(keyFmt(2), valFmt(20))
+	st1.Set(keyFmt(3), valFmt(30))
+	st2.Delete(keyFmt(2))
+	st2.Set(keyFmt(4), valFmt(40))
+
+	require.NoError(t, st1.Flush())
+	require.Equal(t, valFmt(10), mem.Get(keyFmt(1)))
+	require.Equal(t, valFmt(20), mem.Get(keyFmt(2)))
+	require.Equal(t, valFmt(30), mem.Get(keyFmt(3)))
+
+	// the second flush reports the overlapping keys, and writes nothing.
+	err := st2.Flush()
+	require.Equal(t, &cache.ConflictError{Keys: [][]byte{keyFmt(1), keyFmt(2)}}, err)
+	require.Equal(t, valFmt(20), mem.Get(keyFmt(2)))
+	require.Nil(t, mem.Get(keyFmt(4)))
+
+	// a store accessing other entries does not conflict.
+	st3 := cache.NewOptimistic(mem)
+	st3.Set(keyFmt(5), valFmt(50))
+	require.False(t, st3.Has(keyFmt(6)))
+	mem.Set(keyFmt(7), valFmt(70))
+	require.NoError(t, st3.Flush())
+	require.Equal(t, valFmt(50), mem.Get(keyFmt(5)))
+}
+
+func TestOptimisticStoreRestoredValue(t *testing.T) {
+	mem := revision.New(dbadapter.Store{DB: dbm.NewMemDB()})
+	mem.Set(keyFmt(1), valFmt(1))
+
+	st := cache.NewOptimistic(mem)
+	require.Equal(t, valFmt(1), st.Get(keyFmt(1)))
+	st.Set(keyFmt(2), valFmt(2))
+
+	// the entry is changed and restored meanwhile (A, B, A), which is a
+	// conflict.
+	mem.Set(keyFmt(1), valFmt(10))
+	mem.Set(keyFmt(1), valFmt(1))
+	require.Equal(t, &cache.ConflictError{Keys: [][]byte{keyFmt(1)}}, st.Flush())
+	require.Nil(t, mem.Get(keyFmt(2)))
+
+	// revisions are recorded again after a flush: an entry created in the
+	// parent meanwhile conflicts.
+	st = cache.NewOptimistic(mem)
+	st.Set(keyFmt(2), valFmt(2))
+	require.NoError(t, st.Flush())
+	require.Equal(t, valFmt(2), mem.Get(keyFmt(2)))
+	require.Nil(t, st.Get(keyFmt(3)))
+	mem.Set(keyFmt(3), valFmt(3))
+	require.Equal(t, &cache.ConflictError{Keys: [][]byte{keyFmt(3)}}, st.Flush())
+}
+
+// Concurrent flushes of stores writing the same entry are serialized: one
+// of them writes, and the others conflict.
+func TestOptimisticStoreConcurrentFlush(t *testing.T) {
+	mem := revision.New(dbadapter.Store{DB: dbm.NewMemDB()})
+	const n = 8
+	stores := make([]*cache.OptimisticStore, n)
+	for i := range stores {
+		stores[i] = cache.NewOptimistic(mem)
+		require.Nil(t, stores[i].Get(keyFmt(1)))
+		stores[i].Set(keyFmt(1), valFmt(i))
+		stores[i].Set(keyFmt(100+i), valFmt(i))
+	}
+	errs := make(chan error, n)
+	for _, st := range stores {
+		go func(st *cache.OptimisticStore) {
+			errs <- st.Flush()
+		}(st)
+	}
+	flushed := 0
+	for range stores {
+		if err := <-errs; err == nil {
+			flushed++
+		} else {
+			require.Equal(t, &cache.ConflictError{Keys: [][]byte{keyFmt(1)}}, err)
+		}
+	}
+	require.Equal(t, 1, flushed)
+	written := 0
+	for i := range stores {
+		if mem.Get(keyFmt(100+i)) != nil {
+			require.Equal(t, valFmt(i), mem.Get(keyFmt(1)))
+			written++
+		}
+	}
+	require.Equal(t, 1, written)
+	require.Equal(t, uint64(1), mem.Revision(keyFmt(1)))
+}
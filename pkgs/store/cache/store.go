@@ -87,7 +87,11 @@ func (store *cacheStore) Delete(key []byte) {
 func (store *cacheStore) Write() {
 	store.mtx.Lock()
 	defer store.mtx.Unlock()
+	store.write(store.parent)
+}
 
+// write writes the changes to parent, with the lock of store held.
+func (store *cacheStore) write(parent types.Store) {
 	// We need a copy of all of the keys.
 	// Not the best, but probably not a bottleneck depending.
 	keys := make([]string, 0, len(store.cache))
@@ -104,11 +108,11 @@ func (store *cacheStore) Write() {
 	for _, key := range keys {
 		cacheValue := store.cache[key]
 		if cacheValue.deleted {
-			store.parent.Delete([]byte(key))
+			parent.Delete([]byte(key))
 		} else if cacheValue.value == nil {
 			// Skip, it already doesn't exist in parent.
 		} else {
-			store.parent.Set([]byte(key), cacheValue.value)
+			parent.Set([]byte(key), cacheValue.value)
 		}
 	}
 
@@ -0,0 +1,88 @@
+package cachemulti
+
+import (
+	"github.com/gnolang/gno/pkgs/store/cache"
+	"github.com/gnolang/gno/pkgs/store/types"
+)
+
+// RecordingStore is a Store which records the ops written to the underlying
+// stores by MultiWrite. Implements RecordingMultiStore.
+type RecordingStore struct {
+	Store
+	ops *[]types.StoreOp
+}
+
+var _ types.RecordingMultiStore = RecordingStore{}
+
+// NewRecording returns a RecordingStore which cache-wraps stores.
+func NewRecording(
+	stores map[types.StoreKey]types.Store,
+	keys map[string]types.StoreKey,
+) RecordingStore {
+	rs := RecordingStore{ops: new([]types.StoreOp)}
+	recorders := make(map[types.StoreKey]types.Store, len(stores))
+	for key, store := range stores {
+		recorders[key] = &recorder{parent: store, name: key.Name(), ops: rs.ops}
+	}
+	// the cache stores write in the order of their keys, and the stores in
+	// the order of their names, so that the write set is deterministic.
+	rs.Store = NewFromStores(recorders, keys)
+	return rs
+}
+
+// WriteSet returns the ops written by MultiWrite.
+func (rs RecordingStore) WriteSet() []types.StoreOp {
+	return append([]types.StoreOp(nil), *rs.ops...)
+}
+
+// recorder records the writes to a store, and passes them through.
+type recorder struct {
+	parent types.Store
+	name   string
+	ops    *[]types.StoreOp
+}
+
+var _ types.Store = (*recorder)(nil)
+
+func (r *recorder) Get(key []byte) []byte {
+	return r.parent.Get(key)
+}
+
+func (r *recorder) Has(key []byte) bool {
+	return r.parent.Has(key)
+}
+
+func (r *recorder) Set(key, value []byte) {
+	r.parent.Set(key, value)
+	*r.ops = append(*r.ops, types.StoreOp{
+		Store: r.name,
+		Type:  types.StoreOpSet,
+		Key:   append([]byte(nil), key...),
+		Value: append([]byte(nil), value...),
+	})
+}
+
+func (r *recorder) Delete(key []byte) {
+	r.parent.Delete(key)
+	*r.ops = append(*r.ops, types.StoreOp{
+		Store: r.name,
+		Type:  types.StoreOpDelete,
+		Key:   append([]byte(nil), key...),
+	})
+}
+
+func (r *recorder) Iterator(start, end []byte) types.Iterator {
+	return r.parent.Iterator(start, end)
+}
+
+func (r *recorder) ReverseIterator(start, end []byte) types.Iterator {
+	return r.parent.ReverseIterator(start, end)
+}
+
+func (r *recorder) CacheWrap() types.Store {
+	return cache.New(r)
+}
+
+func (r *recorder) Write() {
+	r.parent.Write()
+}
@@ -1,6 +1,8 @@
 package cachemulti
 
 import (
+	"sort"
+
 	"github.com/gnolang/gno/pkgs/store/types"
 )
 
@@ -11,9 +13,13 @@ import (
 // Implements MultiStore.
 // NOTE: a Store (and MultiStores in general) should never expose the
 // keys for the substores.
+//
+// The stores are written by MultiWrite in the order of their names, so that
+// the writes to the underlying stores are in the same order on all nodes.
 type Store struct {
 	stores map[types.StoreKey]types.Store
 	keys   map[string]types.StoreKey
+	order  []types.StoreKey // the keys of stores, sorted by name
 }
 
 var _ types.MultiStore = Store{}
@@ -25,6 +31,7 @@ func NewFromStores(
 	cms := Store{
 		stores: make(map[types.StoreKey]types.Store, len(stores)),
 		keys:   keys,
+		order:  sortedKeys(stores),
 	}
 
 	for key, store := range stores {
@@ -34,6 +41,18 @@ func NewFromStores(
 	return cms
 }
 
+// sortedKeys returns the keys of stores, sorted by name.
+func sortedKeys(stores map[types.StoreKey]types.Store) []types.StoreKey {
+	keys := make([]types.StoreKey, 0, len(stores))
+	for key := range stores {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name() < keys[j].Name()
+	})
+	return keys
+}
+
 func New(
 	stores map[types.StoreKey]types.Store,
 	keys map[string]types.StoreKey,
@@ -49,10 +68,11 @@ func newStoreFromCMS(cms Store) Store {
 	return NewFromStores(stores, nil)
 }
 
-// MultiWrite calls Write on each underlying store.
+// MultiWrite calls Write on each underlying store, in the order of their
+// names.
 func (cms Store) MultiWrite() {
-	for _, store := range cms.stores {
-		store.Write()
+	for _, key := range cms.order {
+		cms.stores[key].Write()
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 type (
 	PruningOptions         = types.PruningOptions
 	Store                  = types.Store
+	RevisionStore          = types.RevisionStore
 	Committer              = types.Committer
 	CommitStore            = types.CommitStore
 	MultiStore             = types.MultiStore
@@ -17,26 +18,61 @@ type (
 	KVPair                 = types.KVPair
 	Iterator               = types.Iterator
 	CommitID               = types.CommitID
+	CommitInfo             = types.CommitInfo
+	CommitHashVersion      = types.CommitHashVersion
+	StoreInfo              = types.StoreInfo
 	StoreKey               = types.StoreKey
+	KVStoreKey             = types.KVStoreKey
+	TransientStoreKey      = types.TransientStoreKey
+	MemoryStoreKey         = types.MemoryStoreKey
+	StoreKind              = types.StoreKind
 	StoreOptions           = types.StoreOptions
 	Queryable              = types.Queryable
 	Gas                    = types.Gas
 	GasMeter               = types.GasMeter
+	GasConsumption         = types.GasConsumption
+	CategorizingGasMeter   = types.CategorizingGasMeter
 	GasConfig              = types.GasConfig
 	OutOfGasException      = types.OutOfGasException
 	GasOverflowException   = types.GasOverflowException
+	StoreOp                = types.StoreOp
+	StoreOpType            = types.StoreOpType
+	RecordingMultiStore    = types.RecordingMultiStore
+	Discrepancy            = types.Discrepancy
+)
+
+// nolint - reexport
+const (
+	StoreOpSet    = types.StoreOpSet
+	StoreOpDelete = types.StoreOpDelete
+
+	StoreKindKV        = types.StoreKindKV
+	StoreKindTransient = types.StoreKindTransient
+	StoreKindMemory    = types.StoreKindMemory
+
+	CommitHashSimpleMerkle = types.CommitHashSimpleMerkle
+	CommitHashSortedSHA256 = types.CommitHashSortedSHA256
 )
 
 // nolint - reexport
 var (
-	PruneNothing           = types.PruneNothing
-	PruneEverything        = types.PruneEverything
-	PruneSyncable          = types.PruneSyncable
-	NewGasMeter            = types.NewGasMeter
-	NewInfiniteGasMeter    = types.NewInfiniteGasMeter
-	NewPassthroughGasMeter = types.NewPassthroughGasMeter
-	DefaultGasConfig       = types.DefaultGasConfig
-	PrefixIterator         = types.PrefixIterator
-	ReversePrefixIterator  = types.ReversePrefixIterator
-	NewStoreKey            = types.NewStoreKey
+	PruneNothing            = types.PruneNothing
+	PruneEverything         = types.PruneEverything
+	PruneSyncable           = types.PruneSyncable
+	NewGasMeter             = types.NewGasMeter
+	NewGasMeterWithTrace    = types.NewGasMeterWithTrace
+	NewCategorizingGasMeter = types.NewCategorizingGasMeter
+	NewInfiniteGasMeter     = types.NewInfiniteGasMeter
+	NewPassthroughGasMeter  = types.NewPassthroughGasMeter
+	DefaultGasConfig        = types.DefaultGasConfig
+	PrefixIterator          = types.PrefixIterator
+	ReversePrefixIterator   = types.ReversePrefixIterator
+	PrefixEndBytes          = types.PrefixEndBytes
+	NewStoreKey             = types.NewStoreKey
+	NewKVStoreKey           = types.NewKVStoreKey
+	NewTransientStoreKey    = types.NewTransientStoreKey
+	NewMemoryStoreKey       = types.NewMemoryStoreKey
+	KindOf                  = types.KindOf
+	DiffWriteSets           = types.DiffWriteSets
+	ApplyWriteSet           = types.ApplyWriteSet
 )
@@ -59,16 +59,15 @@ func (gs *Store) Delete(key []byte) {
 }
 
 // Iterator implements the Store interface. It returns an iterator which
-// incurs a flat gas cost for seeking to the first key/value pair and a variable
-// gas cost based on the current value's length if the iterator is valid.
+// incurs a flat gas cost for seeking to the first key/value pair, and then
+// for each Next, and a variable gas cost based on the length of the key and
+// value of each pair iterated over.
 func (gs *Store) Iterator(start, end []byte) types.Iterator {
 	return gs.iterator(start, end, true)
 }
 
 // ReverseIterator implements the Store interface. It returns a reverse
-// iterator which incurs a flat gas cost for seeking to the first key/value pair
-// and a variable gas cost based on the current value's length if the iterator
-// is valid.
+// iterator which incurs the same gas costs as an Iterator.
 func (gs *Store) ReverseIterator(start, end []byte) types.Iterator {
 	return gs.iterator(start, end, false)
 }
@@ -84,6 +83,8 @@ func (gs *Store) Write() {
 }
 
 func (gs *Store) iterator(start, end []byte, ascending bool) types.Iterator {
+	gs.gasMeter.ConsumeGas(gs.gasConfig.IterSeekCostFlat, types.GasIterSeekCostFlatDesc)
+
 	var parent types.Iterator
 	if ascending {
 		parent = gs.parent.Iterator(start, end)
@@ -91,11 +92,12 @@ func (gs *Store) iterator(start, end []byte, ascending bool) types.Iterator {
 		parent = gs.parent.ReverseIterator(start, end)
 	}
 
-	gi := newGasIterator(gs.gasMeter, gs.gasConfig, parent)
-	if gi.Valid() {
-		gi.(*gasIterator).consumeSeekGas()
+	gi := &gasIterator{
+		gasMeter:  gs.gasMeter,
+		gasConfig: gs.gasConfig,
+		parent:    parent,
 	}
-
+	gi.consumePairGas()
 	return gi
 }
 
@@ -105,14 +107,6 @@ type gasIterator struct {
 	parent    types.Iterator
 }
 
-func newGasIterator(gasMeter types.GasMeter, gasConfig types.GasConfig, parent types.Iterator) types.Iterator {
-	return &gasIterator{
-		gasMeter:  gasMeter,
-		gasConfig: gasConfig,
-		parent:    parent,
-	}
-}
-
 // Implements Iterator.
 func (gi *gasIterator) Domain() (start []byte, end []byte) {
 	return gi.parent.Domain()
@@ -125,13 +119,11 @@ func (gi *gasIterator) Valid() bool {
 
 // Next implements the Iterator interface. It seeks to the next key/value pair
 // in the iterator. It incurs a flat gas cost for seeking and a variable gas
-// cost based on the current value's length if the iterator is valid.
+// cost based on the length of the next key and value, if any.
 func (gi *gasIterator) Next() {
-	if gi.Valid() {
-		gi.consumeSeekGas()
-	}
-
+	gi.gasMeter.ConsumeGas(gi.gasConfig.IterNextCostFlat, types.GasIterNextCostFlatDesc)
 	gi.parent.Next()
+	gi.consumePairGas()
 }
 
 // Key implements the Iterator interface. It returns the current key and it does
@@ -153,12 +145,16 @@ func (gi *gasIterator) Close() {
 	gi.parent.Close()
 }
 
-// consumeSeekGas consumes a flat gas cost for seeking and a variable gas cost
-// based on the current value's length.
-func (gi *gasIterator) consumeSeekGas() {
-	value := gi.Value()
+// consumePairGas consumes a variable gas cost based on the length of the
+// current key and value, if the iterator is valid. It is charged when the
+// pair is reached, whether or not the caller reads it.
+func (gi *gasIterator) consumePairGas() {
+	if !gi.parent.Valid() {
+		return
+	}
+	key, value := gi.parent.Key(), gi.parent.Value()
 
+	// TODO overflow-safe math?
+	gi.gasMeter.ConsumeGas(gi.gasConfig.ReadCostPerByte*types.Gas(len(key)), types.GasKeyPerByteDesc)
 	gi.gasMeter.ConsumeGas(gi.gasConfig.ReadCostPerByte*types.Gas(len(value)), types.GasValuePerByteDesc)
-	gi.gasMeter.ConsumeGas(gi.gasConfig.IterNextCostFlat, types.GasIterNextCostFlatDesc)
-
 }
@@ -57,18 +57,71 @@ func TestGasKVStoreIterator(t *testing.T) {
 	iterator.Next()
 	require.False(t, iterator.Valid())
 	require.Panics(t, iterator.Next)
-	require.Equal(t, meter.GasConsumed(), types.Gas(6987))
+	require.Equal(t, meter.GasConsumed(), types.Gas(8014))
+}
+
+// Iterators are charged for seeking, for each Next, and for the key and value
+// of each pair reached, even if they are not read.
+func TestGasKVStoreIteratorCost(t *testing.T) {
+	mem := dbadapter.Store{DB: dbm.NewMemDB()}
+	for i := 0; i < 10; i++ {
+		mem.Set(keyFmt(i), valFmt(i))
+	}
+	config := types.DefaultGasConfig()
+	// keyFmt and valFmt are 11 and 13 bytes long.
+	pairCost := config.ReadCostPerByte * (11 + 13)
+
+	cases := []struct {
+		name     string
+		reverse  bool
+		start    []byte
+		end      []byte
+		maxPairs int // to stop early, or -1
+		gas      types.Gas
+	}{
+		{"empty", false, bz("x"), nil, -1, config.IterSeekCostFlat},
+		{"full", false, nil, nil, -1,
+			config.IterSeekCostFlat + 10*config.IterNextCostFlat + 10*pairCost},
+		{"reverse", true, nil, nil, -1,
+			config.IterSeekCostFlat + 10*config.IterNextCostFlat + 10*pairCost},
+		{"range", false, keyFmt(2), keyFmt(5), -1,
+			config.IterSeekCostFlat + 3*config.IterNextCostFlat + 3*pairCost},
+		{"early termination", false, nil, nil, 4,
+			config.IterSeekCostFlat + 3*config.IterNextCostFlat + 4*pairCost},
+		{"reverse early termination", true, keyFmt(2), nil, 1,
+			config.IterSeekCostFlat + pairCost},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			meter := types.NewInfiniteGasMeter()
+			st := gas.New(mem, meter, config)
+			var iter types.Iterator
+			if tc.reverse {
+				iter = st.ReverseIterator(tc.start, tc.end)
+			} else {
+				iter = st.Iterator(tc.start, tc.end)
+			}
+			for pairs := 1; iter.Valid(); pairs++ {
+				if pairs == tc.maxPairs {
+					break
+				}
+				iter.Next()
+			}
+			iter.Close()
+			require.Equal(t, tc.gas, meter.GasConsumed())
+		})
+	}
 }
 
 func TestGasKVStoreOutOfGasSet(t *testing.T) {
-	mem := dbadapter.Store{dbm.NewMemDB()}
+	mem := dbadapter.Store{DB: dbm.NewMemDB()}
 	meter := types.NewGasMeter(0)
 	st := gas.New(mem, meter, types.DefaultGasConfig())
 	require.Panics(t, func() { st.Set(keyFmt(1), valFmt(1)) }, "Expected out-of-gas")
 }
 
 func TestGasKVStoreOutOfGasIterator(t *testing.T) {
-	mem := dbadapter.Store{dbm.NewMemDB()}
+	mem := dbadapter.Store{DB: dbm.NewMemDB()}
 	meter := types.NewGasMeter(20000)
 	st := gas.New(mem, meter, types.DefaultGasConfig())
 	st.Set(keyFmt(1), valFmt(1))
@@ -127,6 +127,11 @@ func (st *Store) LoadLatestVersion() error {
 // Implements Commiter.
 func (st *Store) LoadVersion(ver int64) error {
 	if st.opts.Immutable {
+		if ver == 0 {
+			// the initial state, which is empty and not stored.
+			st.tree = &immutableTree{iavl.NewImmutableTree(nil, 0)}
+			return nil
+		}
 		immutTree, err := st.tree.(*iavl.MutableTree).GetImmutable(ver)
 		if err != nil {
 			return err
@@ -150,6 +155,16 @@ func (st *Store) VersionExists(version int64) bool {
 	return st.tree.VersionExists(version)
 }
 
+// NodeCount returns the number of IAVL nodes stored in the database, or -1
+// if the store is immutable.
+func (st *Store) NodeCount() int {
+	tree, ok := st.tree.(*iavl.MutableTree)
+	if !ok {
+		return -1
+	}
+	return tree.NodeCount()
+}
+
 // Implements Store.
 func (st *Store) CacheWrap() types.Store {
 	return cache.New(st)
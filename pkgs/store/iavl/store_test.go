@@ -468,6 +468,49 @@ func TestIAVLPruneEverything(t *testing.T) {
 	}
 }
 
+// Keys set and deleted within a version leave no nodes behind once the
+// versions are pruned, including after a reload.
+func TestIAVLIntraVersionChurn(t *testing.T) {
+	db := dbm.NewMemDB()
+	iavlStore := UnsafeNewStore(iavl.NewMutableTree(db, cacheSize), storeOptions(1, 0))
+	for i := 0; i < 100; i++ {
+		iavlStore.Set([]byte(fmt.Sprintf("key%04d", i*10)), []byte("value"))
+	}
+	iavlStore.Commit()
+
+	rnd := random.NewRand()
+	rnd.Seed(0)
+	churn := func(version int) {
+		// the keys not multiple of 10 are set and deleted within the version,
+		// interleaved with the persisted ones, which are updated.
+		for _, i := range rnd.Perm(1000) {
+			iavlStore.Set([]byte(fmt.Sprintf("key%04d", i)), []byte(fmt.Sprintf("value%d", version)))
+		}
+		for _, i := range rnd.Perm(1000) {
+			if i%10 != 0 {
+				iavlStore.Delete([]byte(fmt.Sprintf("key%04d", i)))
+			}
+		}
+		iavlStore.Commit()
+	}
+
+	for version := 0; version < 5; version++ {
+		churn(version)
+	}
+	bound := iavlStore.NodeCount()
+	for version := 5; version < 50; version++ {
+		churn(version)
+		require.LessOrEqual(t, iavlStore.NodeCount(), bound, "version %d", version)
+	}
+
+	iavlStore = UnsafeNewStore(iavl.NewMutableTree(db, cacheSize), storeOptions(1, 0))
+	require.NoError(t, iavlStore.LoadLatestVersion())
+	for version := 50; version < 100; version++ {
+		churn(version)
+		require.LessOrEqual(t, iavlStore.NodeCount(), bound, "version %d", version)
+	}
+}
+
 func TestIAVLStoreQuery(t *testing.T) {
 	db := dbm.NewMemDB()
 	tree := iavl.NewMutableTree(db, cacheSize)
@@ -0,0 +1,27 @@
+package mem
+
+import (
+	dbm "github.com/gnolang/gno/pkgs/db"
+
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/types"
+)
+
+// Implements CommitStoreConstructor. The store is in memory, so db and
+// opts are ignored.
+func StoreConstructor(db dbm.DB, opts types.StoreOptions) types.CommitStore {
+	return NewStore()
+}
+
+var _ types.CommitStore = (*Store)(nil)
+
+// Store is a store whose writes are kept in memory across commits, but not
+// across restarts, for the keys of StoreKindMemory. It does not merkleize.
+type Store struct {
+	dbadapter.Store
+}
+
+// NewStore returns a new, empty memory store.
+func NewStore() *Store {
+	return &Store{dbadapter.Store{DB: dbm.NewMemDB()}}
+}
@@ -0,0 +1,139 @@
+package revision
+
+import (
+	"sync"
+
+	"github.com/gnolang/gno/pkgs/store/cache"
+	"github.com/gnolang/gno/pkgs/store/types"
+)
+
+var _ types.RevisionStore = &Store{}
+
+// Store wraps a store, and counts the writes of each of its entries since
+// it was wrapped, for the optimistic cache stores over it, e.g. of off-chain
+// tools. The entries must then be written only through it. It implements
+// the RevisionStore interface.
+type Store struct {
+	mtx       sync.Mutex
+	parent    types.Store
+	revisions map[string]uint64
+}
+
+// New returns a new Store over parent.
+func New(parent types.Store) *Store {
+	return &Store{
+		parent:    parent,
+		revisions: make(map[string]uint64),
+	}
+}
+
+// Implements Store.
+func (rs *Store) Get(key []byte) []byte {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	return rs.parent.Get(key)
+}
+
+// Implements Store.
+func (rs *Store) Has(key []byte) bool {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	return rs.parent.Has(key)
+}
+
+// Implements Store.
+func (rs *Store) Set(key []byte, value []byte) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	lockedStore{rs}.Set(key, value)
+}
+
+// Implements Store.
+func (rs *Store) Delete(key []byte) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	lockedStore{rs}.Delete(key)
+}
+
+// Iterator implements the Store interface. The iterator does not lock the
+// store.
+func (rs *Store) Iterator(start, end []byte) types.Iterator {
+	return rs.parent.Iterator(start, end)
+}
+
+// ReverseIterator implements the Store interface. The iterator does not
+// lock the store.
+func (rs *Store) ReverseIterator(start, end []byte) types.Iterator {
+	return rs.parent.ReverseIterator(start, end)
+}
+
+// Implements Store.
+func (rs *Store) CacheWrap() types.Store {
+	return cache.New(rs)
+}
+
+// Implements Store.
+func (rs *Store) Write() {
+	rs.parent.Write()
+}
+
+// Implements RevisionStore.
+func (rs *Store) Revision(key []byte) uint64 {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	return rs.revisions[string(key)]
+}
+
+// Implements RevisionStore.
+func (rs *Store) Update(update func(locked types.RevisionStore)) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	update(lockedStore{rs})
+}
+
+// lockedStore is a Store whose lock is held, by Update.
+type lockedStore struct {
+	rs *Store
+}
+
+func (ls lockedStore) Get(key []byte) []byte {
+	return ls.rs.parent.Get(key)
+}
+
+func (ls lockedStore) Has(key []byte) bool {
+	return ls.rs.parent.Has(key)
+}
+
+func (ls lockedStore) Set(key []byte, value []byte) {
+	ls.rs.parent.Set(key, value)
+	ls.rs.revisions[string(key)]++
+}
+
+func (ls lockedStore) Delete(key []byte) {
+	ls.rs.parent.Delete(key)
+	ls.rs.revisions[string(key)]++
+}
+
+func (ls lockedStore) Iterator(start, end []byte) types.Iterator {
+	return ls.rs.parent.Iterator(start, end)
+}
+
+func (ls lockedStore) ReverseIterator(start, end []byte) types.Iterator {
+	return ls.rs.parent.ReverseIterator(start, end)
+}
+
+func (ls lockedStore) CacheWrap() types.Store {
+	return cache.New(ls)
+}
+
+func (ls lockedStore) Write() {
+	ls.rs.parent.Write()
+}
+
+func (ls lockedStore) Revision(key []byte) uint64 {
+	return ls.rs.revisions[string(key)]
+}
+
+func (ls lockedStore) Update(update func(locked types.RevisionStore)) {
+	update(ls)
+}
@@ -0,0 +1,36 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/types"
+)
+
+func TestRevisions(t *testing.T) {
+	rs := New(dbadapter.Store{DB: dbm.NewMemDB()})
+	key := []byte("key")
+	require.Equal(t, uint64(0), rs.Revision(key))
+
+	rs.Set(key, []byte("a"))
+	rs.Set(key, []byte("b"))
+	rs.Set(key, []byte("a"))
+	require.Equal(t, uint64(3), rs.Revision(key))
+	rs.Delete(key)
+	require.Equal(t, uint64(4), rs.Revision(key))
+	require.Equal(t, uint64(0), rs.Revision([]byte("other")))
+
+	// the writes of the locked store, and of its cache, are counted.
+	rs.Update(func(locked types.RevisionStore) {
+		locked.Set(key, []byte("c"))
+		cached := locked.CacheWrap()
+		cached.Set(key, []byte("d"))
+		cached.Write()
+		require.Equal(t, uint64(6), locked.Revision(key))
+	})
+	require.Equal(t, []byte("d"), rs.Get(key))
+	require.Equal(t, uint64(6), rs.Revision(key))
+}
@@ -8,22 +8,29 @@ import (
 	"github.com/gnolang/gno/pkgs/crypto/merkle"
 	"github.com/gnolang/gno/pkgs/errors"
 	"github.com/gnolang/gno/pkgs/iavl"
+	"github.com/gnolang/gno/pkgs/store/types"
 )
 
 // MultiStoreProof defines a collection of store proofs in a multi-store
 type MultiStoreProof struct {
 	StoreInfos []storeInfo
+	// HashVersion is the algorithm of the root hash, that of the version
+	// of the proof, so that the proofs of the versions on both sides of a
+	// switch of algorithm verify, see CommitMultiStore.SetCommitHashVersion.
+	HashVersion types.CommitHashVersion
 }
 
-func NewMultiStoreProof(storeInfos []storeInfo) *MultiStoreProof {
-	return &MultiStoreProof{StoreInfos: storeInfos}
+func NewMultiStoreProof(storeInfos []storeInfo, hashVersion types.CommitHashVersion) *MultiStoreProof {
+	return &MultiStoreProof{StoreInfos: storeInfos, HashVersion: hashVersion}
 }
 
-// ComputeRootHash returns the root hash for a given multi-store proof.
+// ComputeRootHash returns the root hash for a given multi-store proof. It
+// panics on an unknown hash version.
 func (proof *MultiStoreProof) ComputeRootHash() []byte {
 	ci := commitInfo{
-		Version:    -1, // TODO: Not needed; improve code.
-		StoreInfos: proof.StoreInfos,
+		Version:     -1, // TODO: Not needed; improve code.
+		StoreInfos:  proof.StoreInfos,
+		HashVersion: proof.HashVersion,
 	}
 	return ci.Hash()
 }
@@ -108,6 +115,9 @@ func (op MultiStoreProofOp) Run(args [][]byte) ([][]byte, error) {
 	}
 
 	value := args[0]
+	if err := op.Proof.HashVersion.Validate(); err != nil {
+		return nil, errors.Wrap(err, "multistore proof")
+	}
 	root := op.Proof.ComputeRootHash()
 
 	for _, si := range op.Proof.StoreInfos {
@@ -1,6 +1,8 @@
 package rootmulti
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -61,7 +63,7 @@ func TestVerifyMultiStoreQueryProof(t *testing.T) {
 	// Create main tree for testing.
 	db := dbm.NewMemDB()
 	store := NewMultiStore(db)
-	iavlStoreKey := types.NewStoreKey("iavlStoreKey")
+	iavlStoreKey := types.NewKVStoreKey("iavlStoreKey")
 
 	store.MountStoreWithDB(iavlStoreKey, iavl.StoreConstructor, nil)
 	store.LoadVersion(0)
@@ -116,7 +118,7 @@ func TestVerifyMultiStoreQueryProofEmptyStore(t *testing.T) {
 	// Create main tree for testing.
 	db := dbm.NewMemDB()
 	store := NewMultiStore(db)
-	iavlStoreKey := types.NewStoreKey("iavlStoreKey")
+	iavlStoreKey := types.NewKVStoreKey("iavlStoreKey")
 
 	store.MountStoreWithDB(iavlStoreKey, iavl.StoreConstructor, nil)
 	store.LoadVersion(0)
@@ -145,7 +147,7 @@ func TestVerifyMultiStoreQueryProofAbsence(t *testing.T) {
 	// Create main tree for testing.
 	db := dbm.NewMemDB()
 	store := NewMultiStore(db)
-	iavlStoreKey := types.NewStoreKey("iavlStoreKey")
+	iavlStoreKey := types.NewKVStoreKey("iavlStoreKey")
 
 	store.MountStoreWithDB(iavlStoreKey, iavl.StoreConstructor, nil)
 	store.LoadVersion(0)
@@ -177,3 +179,72 @@ func TestVerifyMultiStoreQueryProofAbsence(t *testing.T) {
 	err = prt.VerifyValue(res.Proof, cid.Hash, "/iavlStoreKey/MYABSENTKEY", []byte(""))
 	require.NotNil(t, err)
 }
+
+func TestVerifyMultiStoreQueryProofAcrossHashVersions(t *testing.T) {
+	db := dbm.NewMemDB()
+	newStore := func() *multiStore {
+		store := NewMultiStore(db)
+		store.SetStoreOptions(types.StoreOptions{PruningOptions: types.PruneNothing})
+		store.MountStoreWithDB(types.NewKVStoreKey("iavlStoreKey"), iavl.StoreConstructor, nil)
+		store.MountStoreWithDB(types.NewKVStoreKey("other"), iavl.StoreConstructor, nil)
+		require.NoError(t, store.LoadLatestVersion())
+		return store
+	}
+	store := newStore()
+	store.SetCommitHashVersion(3, types.CommitHashSortedSHA256)
+	require.Panics(t, func() { store.SetCommitHashVersion(0, types.CommitHashSortedSHA256) })
+	require.Panics(t, func() { store.SetCommitHashVersion(3, types.CommitHashVersion(9)) })
+
+	set := func(store *multiStore, value string) types.CommitID {
+		store.getStoreByName("iavlStoreKey").(types.Store).Set([]byte("MYKEY"), []byte(value))
+		return store.Commit()
+	}
+	cids := []types.CommitID{{}, set(store, "v1"), set(store, "v2"), set(store, "v3")}
+	// the commits after the switch keep its version without it.
+	store = newStore()
+	cids = append(cids, set(store, "v4"))
+
+	prt := DefaultProofRuntime()
+	for height := int64(1); height <= 4; height++ {
+		cInfo, err := store.CommitInfo(height)
+		require.NoError(t, err)
+		if height < 3 {
+			require.Equal(t, types.CommitHashSimpleMerkle, cInfo.HashVersion, "height %d", height)
+		} else {
+			require.Equal(t, types.CommitHashSortedSHA256, cInfo.HashVersion, "height %d", height)
+			// sha256 of the length-prefixed names and hashes by name.
+			var buf []byte
+			for _, si := range cInfo.StoreInfos {
+				buf = append(buf, byte(len(si.Name)))
+				buf = append(buf, si.Name...)
+				buf = append(buf, byte(len(si.CommitID.Hash)))
+				buf = append(buf, si.CommitID.Hash...)
+			}
+			want := sha256.Sum256(buf)
+			require.Equal(t, want[:], cids[height].Hash, "height %d", height)
+		}
+
+		res := store.Query(abci.RequestQuery{
+			Path:   "/iavlStoreKey/key",
+			Data:   []byte("MYKEY"),
+			Height: height,
+			Prove:  true,
+		})
+		require.Nil(t, res.Error, "height %d", height)
+		value := []byte(fmt.Sprintf("v%d", height))
+		require.Equal(t, value, res.Value)
+		err = prt.VerifyValue(res.Proof, cids[height].Hash, "/iavlStoreKey/MYKEY", value)
+		require.NoError(t, err, "height %d", height)
+
+		// the proof does not verify with the algorithm of the other side.
+		op, err := MultiStoreProofOpDecoder(res.Proof.Ops[len(res.Proof.Ops)-1])
+		require.NoError(t, err)
+		msop := op.(MultiStoreProofOp)
+		other := *msop.Proof
+		other.HashVersion = 1 - other.HashVersion
+		require.NotEqual(t, cids[height].Hash, other.ComputeRootHash())
+		other.HashVersion = 9
+		_, err = NewMultiStoreProofOp(msop.GetKey(), &other).Run([][]byte{msop.Proof.StoreInfos[0].Core.CommitID.Hash})
+		require.Error(t, err)
+	}
+}
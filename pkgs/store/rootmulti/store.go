@@ -1,8 +1,14 @@
 package rootmulti
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/gnolang/gno/pkgs/amino"
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
@@ -20,6 +26,11 @@ import (
 const (
 	latestVersionKey = "s/latest"
 	commitInfoKeyFmt = "s/%d" // s/<version>
+
+	// commitInfoFormat is the format of the commit infos written, which
+	// record the metadata of the stores. Commit infos of format 0, before
+	// it, are migrated once by LoadLatestVersion.
+	commitInfoFormat = 1
 )
 
 // multiStore is composed of many CommitStores. Name contrasts with
@@ -28,10 +39,22 @@ const (
 type multiStore struct {
 	db           dbm.DB
 	lastCommitID types.CommitID
+	lastInfos    map[string]storeInfo // by name, of the last commit info
 	storeOpts    types.StoreOptions
 	storesParams map[types.StoreKey]storeParams
 	stores       map[types.StoreKey]types.CommitStore
 	keysByName   map[string]types.StoreKey
+	// the mounted keys, sorted by name: the stores are loaded and
+	// committed in this order, the same on all nodes.
+	keys []types.StoreKey
+	// the hash version of the last commit, and the switch to another one,
+	// see SetCommitHashVersion.
+	lastHashVersion   types.CommitHashVersion
+	hashSwitchHeight  int64
+	hashSwitchVersion types.CommitHashVersion
+	// the stores are committed concurrently unless serialCommit, which
+	// gives the same commit infos, see commitEach.
+	serialCommit bool
 }
 
 var _ types.CommitMultiStore = (*multiStore)(nil)
@@ -44,6 +67,7 @@ func NewMultiStore(db dbm.DB) *multiStore {
 		storesParams: make(map[types.StoreKey]storeParams),
 		stores:       make(map[types.StoreKey]types.CommitStore),
 		keysByName:   make(map[string]types.StoreKey),
+		lastInfos:    make(map[string]storeInfo),
 	}
 }
 
@@ -76,12 +100,19 @@ func (ms *multiStore) MountStoreWithDB(key types.StoreKey, cons types.CommitStor
 	if _, ok := ms.keysByName[key.Name()]; ok {
 		panic(fmt.Sprintf("Store duplicate store key name %v", key))
 	}
+	if kind := types.KindOf(key); kind != types.StoreKindKV && db != nil {
+		panic(fmt.Sprintf("Store %v of kind %s is in memory, and cannot have a DB", key, kind))
+	}
 	ms.storesParams[key] = storeParams{
 		key:         key,
 		constructor: cons,
 		db:          db,
 	}
 	ms.keysByName[key.Name()] = key
+	i := sort.Search(len(ms.keys), func(i int) bool { return ms.keys[i].Name() > key.Name() })
+	ms.keys = append(ms.keys, nil)
+	copy(ms.keys[i+1:], ms.keys[i:])
+	ms.keys[i] = key
 }
 
 // Implements CommitMultiStore.
@@ -90,9 +121,30 @@ func (ms *multiStore) GetCommitStore(key types.StoreKey) types.CommitStore {
 }
 
 // Implements CommitMultiStore.
+// The commit info of the latest version, if of an older format, is migrated
+// in place to the current one.
 func (ms *multiStore) LoadLatestVersion() error {
 	ver := getLatestVersion(ms.db)
-	return ms.LoadVersion(ver)
+	if err := ms.LoadVersion(ver); err != nil {
+		return err
+	}
+	if ver == 0 || ms.storeOpts.Immutable {
+		return nil
+	}
+	cInfo, err := getCommitInfo(ms.db, ver)
+	if err != nil {
+		return err
+	}
+	if cInfo.Format >= commitInfoFormat {
+		return nil
+	}
+	cInfo = migrateCommitInfo(cInfo)
+	batch := ms.db.NewBatch()
+	defer batch.Close()
+	setCommitInfo(batch, ver, cInfo)
+	batch.WriteSync()
+	ms.setLastInfos(cInfo)
+	return nil
 }
 
 // Implements CommitMultiStore.
@@ -100,7 +152,8 @@ func (ms *multiStore) LoadVersion(ver int64) error {
 	if ver == 0 {
 		// Special logic for version 0 where there is no need to get commit
 		// information.
-		for key, storeParams := range ms.storesParams {
+		for _, key := range ms.keys {
+			storeParams := ms.storesParams[key]
 			store, err := ms.constructStore(storeParams)
 			if err != nil {
 				return errors.New("failed to load Store: %v", err)
@@ -116,6 +169,8 @@ func (ms *multiStore) LoadVersion(ver int64) error {
 			ms.stores[key] = store
 		}
 		ms.lastCommitID = types.CommitID{}
+		ms.lastInfos = make(map[string]storeInfo)
+		ms.lastHashVersion = types.CommitHashSimpleMerkle
 		return nil
 	}
 
@@ -124,6 +179,9 @@ func (ms *multiStore) LoadVersion(ver int64) error {
 	if err != nil {
 		return err
 	}
+	if err := cInfo.HashVersion.Validate(); err != nil {
+		return errors.New("failed to load commit info of version %d: %v", ver, err)
+	}
 
 	// Convert StoreInfos slice to map.
 	infos := make(map[types.StoreKey]storeInfo)
@@ -133,10 +191,19 @@ func (ms *multiStore) LoadVersion(ver int64) error {
 
 	// Load each Store and check CommitID for each.
 	var newStores = make(map[types.StoreKey]types.CommitStore)
-	for key, storeParams := range ms.storesParams {
+	for _, key := range ms.keys {
+		storeParams := ms.storesParams[key]
 		var id types.CommitID
 		if info, ok := infos[key]; ok {
 			id = info.Core.CommitID
+			// a DB of its own may be ahead of ms.db, e.g. after a crash
+			// in Commit, or a rollback, but never behind.
+			if storeParams.ownDB(ms.db) {
+				if dbVer := getLatestVersion(storeParams.db); dbVer != 0 && dbVer < ver {
+					return errors.New("failed to load Store %s: its DB is at version %d, before version %d",
+						key.Name(), dbVer, ver)
+				}
+			}
 		}
 		store, err := ms.constructStore(storeParams)
 		if err != nil {
@@ -148,15 +215,17 @@ func (ms *multiStore) LoadVersion(ver int64) error {
 			return errors.New("failed to load Store version %d: %v", ver, err)
 		}
 		if !store.LastCommitID().Equals(id) {
-			return errors.New("failed to load Store: wrong commit id: %v vs %v",
-				store.LastCommitID(),
-				id)
+			lastID := store.LastCommitID()
+			return errors.New("failed to load Store %s: its hash is %X at version %d, but the commit info of version %d records %X at version %d",
+				key.Name(), lastID.Hash, lastID.Version, ver, id.Hash, id.Version)
 		}
 		newStores[key] = store
 	}
 
 	ms.lastCommitID = cInfo.CommitID()
 	ms.stores = newStores
+	ms.setLastInfos(cInfo)
+	ms.lastHashVersion = cInfo.HashVersion
 
 	return nil
 }
@@ -174,7 +243,19 @@ func (ms *multiStore) Commit() types.CommitID {
 
 	// Commit stores.
 	version := ms.lastCommitID.Version + 1
-	commitInfo := commitStores(version, ms.stores)
+	hashVersion := ms.lastHashVersion
+	if ms.hashSwitchHeight != 0 && version >= ms.hashSwitchHeight {
+		hashVersion = ms.hashSwitchVersion
+	}
+	commitInfo := commitStores(version, hashVersion, ms.keys, ms.stores, ms.lastInfos, ms.serialCommit)
+
+	// The writes of the stores in DBs of their own must be durable before
+	// the commit info, which commits the version, is written: a crash in
+	// between must not lose them.
+	for _, db := range ms.ownDBs() {
+		latestBytes, _ := amino.MarshalSized(version)
+		db.SetSync([]byte(latestVersionKey), latestBytes)
+	}
 
 	// Need to update atomically.
 	batch := ms.db.NewBatch()
@@ -186,12 +267,85 @@ func (ms *multiStore) Commit() types.CommitID {
 	// Prepare for next version.
 	commitID := types.CommitID{
 		Version: version,
-		Hash:    commitInfo.Hash(),
+		Hash:    commitInfo.AppHash,
 	}
 	ms.lastCommitID = commitID
+	ms.setLastInfos(commitInfo)
+	ms.lastHashVersion = hashVersion
 	return commitID
 }
 
+func (ms *multiStore) setLastInfos(cInfo commitInfo) {
+	ms.lastInfos = make(map[string]storeInfo, len(cInfo.StoreInfos))
+	for _, si := range cInfo.StoreInfos {
+		ms.lastInfos[si.Name] = si
+	}
+}
+
+// Implements CommitMultiStore.
+func (ms *multiStore) StoreInfo(name string) (types.StoreInfo, bool) {
+	si, ok := ms.lastInfos[name]
+	if !ok {
+		return types.StoreInfo{}, false
+	}
+	return si.toStoreInfo(), true
+}
+
+// Implements CommitMultiStore.
+func (ms *multiStore) CommitInfo(version int64) (types.CommitInfo, error) {
+	cInfo, err := getCommitInfo(ms.db, version)
+	if err != nil {
+		return types.CommitInfo{}, err
+	}
+	infos := make([]types.StoreInfo, len(cInfo.StoreInfos))
+	for i, si := range cInfo.StoreInfos {
+		infos[i] = si.toStoreInfo()
+	}
+	return types.CommitInfo{
+		Format:      cInfo.Format,
+		Version:     cInfo.Version,
+		StoreInfos:  infos,
+		HashVersion: cInfo.HashVersion,
+	}, nil
+}
+
+// Implements CommitMultiStore.
+func (ms *multiStore) SetCommitHashVersion(height int64, version types.CommitHashVersion) {
+	if height < 1 {
+		panic(fmt.Sprintf("invalid height %d of commit hash version switch", height))
+	}
+	if err := version.Validate(); err != nil {
+		panic(err)
+	}
+	ms.hashSwitchHeight = height
+	ms.hashSwitchVersion = version
+}
+
+// Implements CommitMultiStore.
+func (ms *multiStore) VerifyIntegrity() error {
+	ver := ms.lastCommitID.Version
+	if ver == 0 {
+		return nil
+	}
+	cInfo, err := getCommitInfo(ms.db, ver)
+	if err != nil {
+		return fmt.Errorf("commit info of version %d: %v", ver, err)
+	}
+	if err := cInfo.HashVersion.Validate(); err != nil {
+		return fmt.Errorf("commit info of version %d: %v", ver, err)
+	}
+	// commit infos written before the app hash was recorded have none.
+	if cInfo.AppHash != nil && !bytes.Equal(cInfo.Hash(), cInfo.AppHash) {
+		return fmt.Errorf("commit info of version %d: the hashes of its stores recombine to app hash %X, but it records %X",
+			ver, cInfo.Hash(), cInfo.AppHash)
+	}
+	// loading the stores anew, from their DBs, checks their hashes.
+	if _, err := ms.immutableWithVersion(ver); err != nil {
+		return err
+	}
+	return nil
+}
+
 //----------------------------------------
 // +MultiStore
 
@@ -205,6 +359,16 @@ func (ms *multiStore) MultiCacheWrap() types.MultiStore {
 	return cachemulti.New(stores, ms.keysByName)
 }
 
+// Implements CommitMultiStore.
+func (ms *multiStore) MultiCacheWrapWithRecording() types.RecordingMultiStore {
+	stores := make(map[types.StoreKey]types.Store)
+	for k, v := range ms.stores {
+		stores[k] = v
+	}
+
+	return cachemulti.NewRecording(stores, ms.keysByName)
+}
+
 // Implements MultiStore.
 func (ms *multiStore) MultiWrite() {
 	panic("unexpected .MultiWrite() on rootmulti.Store. Commit()?")
@@ -212,22 +376,39 @@ func (ms *multiStore) MultiWrite() {
 
 // Implements CommitMultiStore.
 func (ms *multiStore) MultiImmutableCacheWrapWithVersion(version int64) (types.MultiStore, error) {
+	ims, err := ms.immutableWithVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	stores := make(map[types.StoreKey]types.Store, len(ims.stores))
+	for storeKey, store := range ims.stores {
+		stores[storeKey] = immut.New(store)
+	}
+	return cachemulti.New(stores, ims.keysByName), nil
+}
+
+// Implements CommitMultiStore.
+func (ms *multiStore) QueryableWithVersion(version int64) (types.Queryable, error) {
+	return ms.immutableWithVersion(version)
+}
+
+// immutableWithVersion returns a multiStore of the stores loaded immutably
+// at version, which shares no mutable state with ms.
+func (ms *multiStore) immutableWithVersion(version int64) (*multiStore, error) {
 	ims := &multiStore{
 		db:           dbm.NewImmutableDB(ms.db),
 		storeOpts:    ms.storeOpts,
 		storesParams: ms.storesParams,
+		stores:       make(map[types.StoreKey]types.CommitStore),
 		keysByName:   ms.keysByName,
+		keys:         ms.keys,
 	}
 	ims.storeOpts.Immutable = true
 	err := ims.LoadVersion(version)
 	if err != nil {
 		return nil, err
 	}
-	stores := make(map[types.StoreKey]types.Store, len(ims.stores))
-	for storeKey, store := range ims.stores {
-		stores[storeKey] = immut.New(store)
-	}
-	return cachemulti.New(stores, ims.keysByName), nil
+	return ims, nil
 }
 
 // Implements MultiStore.
@@ -270,8 +451,9 @@ func (ms *multiStore) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
 		return
 	}
 
+	// only the persistent stores are exposed.
 	store := ms.getStoreByName(storeName)
-	if store == nil {
+	if store == nil || types.KindOf(ms.keysByName[storeName]) != types.StoreKindKV {
 		msg := fmt.Sprintf("no such store: %s", storeName)
 		res.Error = serrors.ErrUnknownRequest(msg)
 		return
@@ -304,7 +486,7 @@ func (ms *multiStore) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
 	// Restore origin path and append proof op.
 	res.Proof.Ops = append(res.Proof.Ops, NewMultiStoreProofOp(
 		[]byte(storeName),
-		NewMultiStoreProof(commitInfo.StoreInfos),
+		NewMultiStoreProof(commitInfo.StoreInfos, commitInfo.HashVersion),
 	).ProofOp())
 
 	// TODO: handle in another TM v0.26 update PR
@@ -349,6 +531,21 @@ func (ms *multiStore) constructStore(params storeParams) (store types.CommitStor
 	return store, nil
 }
 
+// ownDBs returns the DBs of the stores which are not ms.db, once each, in
+// the order of the names of their first stores.
+func (ms *multiStore) ownDBs() []dbm.DB {
+	var dbs []dbm.DB
+	seen := make(map[dbm.DB]bool)
+	for _, key := range ms.keys {
+		params := ms.storesParams[key]
+		if params.ownDB(ms.db) && !seen[params.db] {
+			seen[params.db] = true
+			dbs = append(dbs, params.db)
+		}
+	}
+	return dbs
+}
+
 func (ms *multiStore) nameToKey(name string) types.StoreKey {
 	for key := range ms.storesParams {
 		if key.Name() == name {
@@ -367,10 +564,19 @@ type storeParams struct {
 	db          dbm.DB
 }
 
+// ownDB returns whether the store is in a DB of its own, other than the DB
+// of the multistore, db. The latest version committed is also recorded in
+// its DB.
+func (params storeParams) ownDB(db dbm.DB) bool {
+	return params.db != nil && params.db != db
+}
+
 //----------------------------------------
 // commitInfo
 
 // NOTE: Keep commitInfo a simple immutable struct.
+// New fields must be appended, so that older decoders skip them, and must
+// not be covered by Hash, which would fork the app hash.
 type commitInfo struct {
 
 	// Version
@@ -378,17 +584,55 @@ type commitInfo struct {
 
 	// Store info for
 	StoreInfos []storeInfo
+
+	// Format of the commit info, 0 before commitInfoFormat.
+	Format uint8
+
+	// AppHash is the Hash of the commit info when it was written, to check
+	// its integrity, or nil if it was written before it was recorded.
+	AppHash []byte
+
+	// HashVersion is the algorithm of Hash, which is recorded rather than
+	// hashed.
+	HashVersion types.CommitHashVersion
 }
 
-// Hash returns the simple merkle root hash of the stores sorted by name.
+// Hash returns the root hash of the stores by the algorithm of HashVersion,
+// see types.CommitHashVersion. It panics on an unknown version.
 func (ci commitInfo) Hash() []byte {
 	// TODO: cache to ci.hash []byte
-	m := make(map[string][]byte, len(ci.StoreInfos))
-	for _, storeInfo := range ci.StoreInfos {
-		m[storeInfo.Name] = storeInfo.Hash()
+	switch ci.HashVersion {
+	case types.CommitHashSimpleMerkle:
+		m := make(map[string][]byte, len(ci.StoreInfos))
+		for _, storeInfo := range ci.StoreInfos {
+			m[storeInfo.Name] = storeInfo.Hash()
+		}
+		return merkle.SimpleHashFromMap(m)
+	case types.CommitHashSortedSHA256:
+		return sortedSHA256Hash(ci.StoreInfos)
+	default:
+		panic(ci.HashVersion.Validate())
+	}
+}
+
+// sortedSHA256Hash returns the SHA256 of the names and commit hashes of
+// storeInfos, sorted by name, each prefixed by its uvarint length.
+func sortedSHA256Hash(storeInfos []storeInfo) []byte {
+	sorted := make([]storeInfo, len(storeInfos))
+	copy(sorted, storeInfos)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	hasher := sha256.New()
+	var lenBuf [binary.MaxVarintLen64]byte
+	write := func(bz []byte) {
+		n := binary.PutUvarint(lenBuf[:], uint64(len(bz)))
+		hasher.Write(lenBuf[:n])
+		hasher.Write(bz)
+	}
+	for _, si := range sorted {
+		write([]byte(si.Name))
+		write(si.Core.CommitID.Hash)
 	}
-
-	return merkle.SimpleHashFromMap(m)
+	return hasher.Sum(nil)
 }
 
 func (ci commitInfo) CommitID() types.CommitID {
@@ -407,6 +651,7 @@ func (ci commitInfo) CommitID() types.CommitID {
 type storeInfo struct {
 	Name string
 	Core storeCore
+	Meta storeMeta // not hashed
 }
 
 type storeCore struct {
@@ -415,6 +660,21 @@ type storeCore struct {
 	// ... maybe add more state
 }
 
+// storeMeta is the metadata of a store, since commitInfoFormat 1.
+type storeMeta struct {
+	CreatedHeight  int64
+	UpgradedHeight int64
+}
+
+func (si storeInfo) toStoreInfo() types.StoreInfo {
+	return types.StoreInfo{
+		Name:           si.Name,
+		CommitID:       si.Core.CommitID,
+		CreatedHeight:  si.Meta.CreatedHeight,
+		UpgradedHeight: si.Meta.UpgradedHeight,
+	}
+}
+
 // Implements merkle.Hasher.
 func (si storeInfo) Hash() []byte {
 	// Doesn't write Name, since merkle.SimpleHashFromMap() will
@@ -455,13 +715,19 @@ func setLatestVersion(batch dbm.Batch, version int64) {
 	batch.Set([]byte(latestVersionKey), latestBytes)
 }
 
-// Commits each store and returns a new commitInfo.
-func commitStores(version int64, storeMap map[types.StoreKey]types.CommitStore) commitInfo {
+// Commits each store of keys, see commitEach, and returns a new commitInfo,
+// where the metadata of the stores is carried over from lastInfos, or is of
+// their creation at version. Only the persistent stores are in the
+// commitInfo.
+func commitStores(version int64, hashVersion types.CommitHashVersion, keys []types.StoreKey, storeMap map[types.StoreKey]types.CommitStore, lastInfos map[string]storeInfo, serial bool) commitInfo {
 	storeInfos := make([]storeInfo, 0, len(storeMap))
 
-	for key, store := range storeMap {
-		// Commit
-		commitID := store.Commit()
+	commitIDs := commitEach(keys, storeMap, serial)
+	for i, key := range keys {
+		commitID := commitIDs[i]
+		if types.KindOf(key) != types.StoreKindKV {
+			continue
+		}
 		/* Print all items.
 		itr := store.Iterator(nil, nil)
 		for ; itr.Valid(); itr.Next() {
@@ -476,17 +742,78 @@ func commitStores(version int64, storeMap map[types.StoreKey]types.CommitStore)
 		si := storeInfo{}
 		si.Name = key.Name()
 		si.Core.CommitID = commitID
+		if last, ok := lastInfos[si.Name]; ok {
+			si.Meta = last.Meta
+		} else {
+			si.Meta = storeMeta{CreatedHeight: version, UpgradedHeight: version}
+		}
 		// si.Core.StoreType = store.GetStoreType()
 		storeInfos = append(storeInfos, si)
 	}
 
 	ci := commitInfo{
-		Version:    version,
-		StoreInfos: storeInfos,
+		Version:     version,
+		StoreInfos:  storeInfos,
+		Format:      commitInfoFormat,
+		HashVersion: hashVersion,
 	}
+	ci.AppHash = ci.Hash()
 	return ci
 }
 
+// commitEach commits each store of keys, in order if serial, or else each
+// in a goroutine of its own, so that the hashing and writes of the stores
+// overlap, and returns their commit IDs in the order of keys. The stores
+// are independent, so the commit IDs are the same either way. If the
+// Commit of stores panics, the panic of the first of them in the order of
+// keys is raised again once all of them have returned, so that it does not
+// depend on their scheduling; the commit info is then not written. The
+// stores are committed in order on a single CPU, where goroutines only add
+// contention.
+func commitEach(keys []types.StoreKey, storeMap map[types.StoreKey]types.CommitStore, serial bool) []types.CommitID {
+	commitIDs := make([]types.CommitID, len(keys))
+	if serial || len(keys) < 2 || runtime.GOMAXPROCS(0) < 2 {
+		for i, key := range keys {
+			commitIDs[i] = storeMap[key].Commit()
+		}
+		return commitIDs
+	}
+	panics := make([]interface{}, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, store types.CommitStore) {
+			defer wg.Done()
+			defer func() { panics[i] = recover() }()
+			commitIDs[i] = store.Commit()
+		}(i, storeMap[key])
+	}
+	wg.Wait()
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
+	return commitIDs
+}
+
+// migrateCommitInfo returns cInfo, of format 0, in the current format. The
+// creation heights of the stores are unknown, and their upgrade height is
+// the version of cInfo. The hash is unchanged.
+func migrateCommitInfo(cInfo commitInfo) commitInfo {
+	storeInfos := make([]storeInfo, len(cInfo.StoreInfos))
+	for i, si := range cInfo.StoreInfos {
+		si.Meta = storeMeta{UpgradedHeight: cInfo.Version}
+		storeInfos[i] = si
+	}
+	return commitInfo{
+		Version:     cInfo.Version,
+		StoreInfos:  storeInfos,
+		Format:      commitInfoFormat,
+		HashVersion: cInfo.HashVersion,
+	}
+}
+
 // Gets commitInfo from disk.
 func getCommitInfo(db dbm.DB, ver int64) (commitInfo, error) {
 
@@ -0,0 +1,42 @@
+package rootmulti
+
+import (
+	"fmt"
+	"testing"
+
+	dbm "github.com/gnolang/gno/pkgs/db"
+
+	"github.com/gnolang/gno/pkgs/store/iavl"
+	"github.com/gnolang/gno/pkgs/store/types"
+)
+
+func BenchmarkCommitSerial(b *testing.B)   { benchmarkCommit(b, true, 8, 10000) }
+func BenchmarkCommitParallel(b *testing.B) { benchmarkCommit(b, false, 8, 10000) }
+
+// benchmarkCommit benchmarks the commits of blocks of writes to each of
+// numStores stores.
+func benchmarkCommit(b *testing.B, serial bool, numStores, writes int) {
+	ms := NewMultiStore(dbm.NewMemDB())
+	ms.serialCommit = serial
+	ms.SetStoreOptions(types.StoreOptions{PruningOptions: types.PruneEverything})
+	keys := make([]types.StoreKey, numStores)
+	for i := range keys {
+		keys[i] = types.NewKVStoreKey(fmt.Sprintf("store%d", i))
+		ms.MountStoreWithDB(keys[i], iavl.StoreConstructor, nil)
+	}
+	if err := ms.LoadLatestVersion(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		for _, key := range keys {
+			store := ms.GetCommitStore(key).(types.Store)
+			for i := 0; i < writes; i++ {
+				store.Set([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d-%d", n, i)))
+			}
+		}
+		b.StartTimer()
+		ms.Commit()
+	}
+}
@@ -1,16 +1,22 @@
 package rootmulti
 
 import (
+	"fmt"
+	"math/rand"
+	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/gnolang/gno/pkgs/amino"
 	abci "github.com/gnolang/gno/pkgs/bft/abci/types"
 	"github.com/gnolang/gno/pkgs/crypto/merkle"
 	dbm "github.com/gnolang/gno/pkgs/db"
 
 	"github.com/gnolang/gno/pkgs/store/iavl"
+	"github.com/gnolang/gno/pkgs/store/mem"
+	"github.com/gnolang/gno/pkgs/store/transient"
 	"github.com/gnolang/gno/pkgs/store/types"
 )
 
@@ -18,16 +24,16 @@ func TestStoreType(t *testing.T) {
 	db := dbm.NewMemDB()
 	store := NewMultiStore(db)
 	store.MountStoreWithDB(
-		types.NewStoreKey("store1"), iavl.StoreConstructor, db)
+		types.NewKVStoreKey("store1"), iavl.StoreConstructor, db)
 }
 
 func TestStoreMount(t *testing.T) {
 	db := dbm.NewMemDB()
 	store := NewMultiStore(db)
 
-	key1 := types.NewStoreKey("store1")
-	key2 := types.NewStoreKey("store2")
-	dup1 := types.NewStoreKey("store1")
+	key1 := types.NewKVStoreKey("store1")
+	key2 := types.NewKVStoreKey("store2")
+	dup1 := types.NewKVStoreKey("store1")
 
 	require.NotPanics(t, func() { store.MountStoreWithDB(key1, iavl.StoreConstructor, db) })
 	require.NotPanics(t, func() { store.MountStoreWithDB(key2, iavl.StoreConstructor, db) })
@@ -157,6 +163,267 @@ func TestMultistoreCommitLoad(t *testing.T) {
 	checkStore(t, store, commitID, commitID)
 }
 
+// oldCommitInfo and oldStoreInfo are the commit info of format 0, before
+// the metadata of the stores was recorded.
+type oldCommitInfo struct {
+	Version    int64
+	StoreInfos []oldStoreInfo
+}
+
+type oldStoreInfo struct {
+	Name string
+	Core storeCore
+}
+
+func TestCommitInfoMigration(t *testing.T) {
+	var db dbm.DB = dbm.NewMemDB()
+	ms := newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	ms.getStoreByName("store1").(types.Store).Set([]byte("wind"), []byte("blows"))
+	ms.Commit()
+	commitID := ms.Commit()
+
+	// rewrite the commit infos in the old format.
+	for ver := int64(1); ver <= 2; ver++ {
+		cInfo, err := getCommitInfo(db, ver)
+		require.NoError(t, err)
+		old := oldCommitInfo{Version: cInfo.Version}
+		for _, si := range cInfo.StoreInfos {
+			old.StoreInfos = append(old.StoreInfos, oldStoreInfo{Name: si.Name, Core: si.Core})
+		}
+		db.Set([]byte(fmt.Sprintf(commitInfoKeyFmt, ver)), amino.MustMarshalSized(old))
+	}
+	cInfo, err := getCommitInfo(db, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint8(0), cInfo.Format)
+	cInfoKey := []byte(fmt.Sprintf(commitInfoKeyFmt, 2))
+
+	// the commit info is migrated, with the same hash.
+	ms = newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	require.Equal(t, commitID, ms.LastCommitID())
+	cInfo, err = getCommitInfo(db, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint8(commitInfoFormat), cInfo.Format)
+	require.Equal(t, commitID.Hash, cInfo.Hash())
+	si, ok := ms.StoreInfo("store1")
+	require.True(t, ok)
+	require.Equal(t, types.StoreInfo{Name: "store1", CommitID: types.CommitID{Version: 2, Hash: si.CommitID.Hash}, UpgradedHeight: 2}, si)
+
+	// only once.
+	migrated := db.Get(cInfoKey)
+	ms = newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	require.Equal(t, migrated, db.Get(cInfoKey))
+
+	// the metadata is carried over by commits, and does not change the hash.
+	commitID = ms.Commit()
+	require.Equal(t, getExpectedCommitID(ms, 3), commitID)
+	si, ok = ms.StoreInfo("store1")
+	require.True(t, ok)
+	require.Equal(t, int64(0), si.CreatedHeight)
+	require.Equal(t, int64(2), si.UpgradedHeight)
+
+	// older commit infos are not migrated, and still readable.
+	info, err := ms.CommitInfo(1)
+	require.NoError(t, err)
+	require.Equal(t, uint8(0), info.Format)
+	require.Len(t, info.StoreInfos, 3)
+}
+
+func TestStoreInfoCreatedHeight(t *testing.T) {
+	var db dbm.DB = dbm.NewMemDB()
+	ms := newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	_, ok := ms.StoreInfo("store1")
+	require.False(t, ok)
+	ms.Commit()
+	ms.Commit()
+
+	// a store mounted later is created at the next version.
+	ms = newMultiStoreWithMounts(db)
+	ms.MountStoreWithDB(types.NewKVStoreKey("store4"), iavl.StoreConstructor, nil)
+	require.NoError(t, ms.LoadLatestVersion())
+	_, ok = ms.StoreInfo("store4")
+	require.False(t, ok)
+	ms.Commit()
+
+	si, ok := ms.StoreInfo("store1")
+	require.True(t, ok)
+	require.Equal(t, int64(1), si.CreatedHeight)
+	require.Equal(t, int64(1), si.UpgradedHeight)
+	si, ok = ms.StoreInfo("store4")
+	require.True(t, ok)
+	require.Equal(t, int64(3), si.CreatedHeight)
+	require.Equal(t, int64(3), si.UpgradedHeight)
+
+	info, err := ms.CommitInfo(3)
+	require.NoError(t, err)
+	require.Equal(t, uint8(commitInfoFormat), info.Format)
+	require.Equal(t, int64(3), info.Version)
+	require.Len(t, info.StoreInfos, 4)
+}
+
+// futureCommitInfo is a commit info of a later format, with a new field.
+type futureCommitInfo struct {
+	Version     int64
+	StoreInfos  []futureStoreInfo
+	Format      uint8
+	AppHash     []byte
+	HashVersion types.CommitHashVersion
+	Extra       string
+}
+
+type futureStoreInfo struct {
+	Name  string
+	Core  storeCore
+	Meta  storeMeta
+	Extra []byte
+}
+
+func TestCommitInfoForwardCompatible(t *testing.T) {
+	future := futureCommitInfo{
+		Version: 7,
+		StoreInfos: []futureStoreInfo{{
+			Name:  "store1",
+			Core:  storeCore{CommitID: types.CommitID{Version: 7, Hash: []byte{1}}},
+			Meta:  storeMeta{CreatedHeight: 2, UpgradedHeight: 5},
+			Extra: []byte("extra"),
+		}},
+		Format:      commitInfoFormat + 1,
+		AppHash:     []byte{2},
+		HashVersion: types.CommitHashSortedSHA256,
+		Extra:       "extra",
+	}
+	var cInfo commitInfo
+	require.NoError(t, amino.UnmarshalSized(amino.MustMarshalSized(future), &cInfo))
+	require.Equal(t, commitInfo{
+		Version: 7,
+		StoreInfos: []storeInfo{{
+			Name: "store1",
+			Core: storeCore{CommitID: types.CommitID{Version: 7, Hash: []byte{1}}},
+			Meta: storeMeta{CreatedHeight: 2, UpgradedHeight: 5},
+		}},
+		Format:      commitInfoFormat + 1,
+		AppHash:     []byte{2},
+		HashVersion: types.CommitHashSortedSHA256,
+	}, cInfo)
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	var db dbm.DB = dbm.NewMemDB()
+	ms := newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	require.NoError(t, ms.VerifyIntegrity())
+	ms.getStoreByName("store1").(types.Store).Set([]byte("wind"), []byte("blows"))
+	ms.Commit()
+	ms.getStoreByName("store2").(types.Store).Set([]byte("rain"), []byte("falls"))
+	commitID := ms.Commit()
+	require.NoError(t, ms.VerifyIntegrity())
+	cInfo, err := getCommitInfo(db, 2)
+	require.NoError(t, err)
+	require.Equal(t, commitID.Hash, cInfo.AppHash)
+	cInfoKey := []byte(fmt.Sprintf(commitInfoKeyFmt, 2))
+
+	ms = newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	require.NoError(t, ms.VerifyIntegrity())
+
+	// a recorded app hash which does not match the stores.
+	corrupted := cInfo
+	corrupted.AppHash = append([]byte{}, cInfo.AppHash...)
+	corrupted.AppHash[0] ^= 0xff
+	db.Set(cInfoKey, amino.MustMarshalSized(corrupted))
+	ms = newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	err = ms.VerifyIntegrity()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), fmt.Sprintf("commit info of version 2: the hashes of its stores recombine to app hash %X, but it records %X",
+		cInfo.AppHash, corrupted.AppHash))
+
+	// a recorded store hash which does not match the store, even with a
+	// consistent app hash.
+	corrupted = cInfo
+	corrupted.StoreInfos = append([]storeInfo{}, cInfo.StoreInfos...)
+	corrupted.StoreInfos[1].Core.CommitID.Hash = []byte("corrupted")
+	corrupted.AppHash = corrupted.Hash()
+	db.Set(cInfoKey, amino.MustMarshalSized(corrupted))
+	ms = newMultiStoreWithMounts(db)
+	err = ms.LoadLatestVersion()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), fmt.Sprintf("failed to load Store store2: its hash is %X at version 2, but the commit info of version 2 records %X at version 2",
+		cInfo.StoreInfos[1].Core.CommitID.Hash, []byte("corrupted")))
+
+	// commit infos which record no app hash are checked against their
+	// stores only.
+	legacy := cInfo
+	legacy.AppHash = nil
+	db.Set(cInfoKey, amino.MustMarshalSized(legacy))
+	ms = newMultiStoreWithMounts(db)
+	require.NoError(t, ms.LoadLatestVersion())
+	require.NoError(t, ms.VerifyIntegrity())
+}
+
+func TestInMemoryStores(t *testing.T) {
+	var db dbm.DB = dbm.NewMemDB()
+	ms := newMultiStoreWithMounts(db)
+	tkey, mkey := types.NewTransientStoreKey("transient"), types.NewMemoryStoreKey("memory")
+	ms.MountStoreWithDB(tkey, transient.StoreConstructor, nil)
+	ms.MountStoreWithDB(mkey, mem.StoreConstructor, nil)
+	require.Panics(t, func() {
+		ms.MountStoreWithDB(types.NewTransientStoreKey("other"), transient.StoreConstructor, dbm.NewMemDB())
+	})
+	require.NoError(t, ms.LoadLatestVersion())
+
+	k, v := []byte("wind"), []byte("blows")
+	ms.getStoreByName("store1").(types.Store).Set(k, v)
+	ms.GetStore(tkey).Set(k, v)
+	ms.GetStore(mkey).Set(k, v)
+	commitID := ms.Commit()
+
+	// the in-memory stores are not committed in the app hash.
+	plain := newMultiStoreWithMounts(dbm.NewMemDB())
+	require.NoError(t, plain.LoadLatestVersion())
+	plain.getStoreByName("store1").(types.Store).Set(k, v)
+	require.Equal(t, plain.Commit(), commitID)
+	info, err := ms.CommitInfo(1)
+	require.NoError(t, err)
+	require.Len(t, info.StoreInfos, 3)
+	_, ok := ms.StoreInfo("transient")
+	require.False(t, ok)
+
+	// the writes of the transient store are discarded on commit, and not
+	// those of the memory store.
+	require.Nil(t, ms.GetStore(tkey).Get(k))
+	require.Equal(t, v, ms.GetStore(mkey).Get(k))
+
+	// they are not queryable.
+	for _, name := range []string{"transient", "memory"} {
+		res := ms.Query(abci.RequestQuery{Path: "/" + name + "/key", Data: k})
+		require.Error(t, res.Error)
+		require.Contains(t, res.Error.Error(), "no such store: "+name)
+	}
+	res := ms.Query(abci.RequestQuery{Path: "/store1/key", Data: k})
+	require.NoError(t, res.Error)
+	require.Equal(t, v, res.Value)
+
+	// nor reloaded.
+	ms = newMultiStoreWithMounts(db)
+	ms.MountStoreWithDB(tkey, transient.StoreConstructor, nil)
+	ms.MountStoreWithDB(mkey, mem.StoreConstructor, nil)
+	require.NoError(t, ms.LoadLatestVersion())
+	require.Equal(t, commitID, ms.LastCommitID())
+	require.Nil(t, ms.GetStore(mkey).Get(k))
+}
+
+func TestKindOf(t *testing.T) {
+	require.Equal(t, types.StoreKindKV, types.KindOf(types.NewKVStoreKey("kv")))
+	require.Equal(t, types.StoreKindKV, types.KindOf(types.NewStoreKey("kv")))
+	require.Equal(t, types.StoreKindTransient, types.KindOf(types.NewTransientStoreKey("transient")))
+	require.Equal(t, types.StoreKindMemory, types.KindOf(types.NewMemoryStoreKey("memory")))
+	require.Equal(t, "transient", types.StoreKindTransient.String())
+}
+
 func TestParsePath(t *testing.T) {
 	_, _, err := parsePath("foo")
 	require.Error(t, err)
@@ -252,11 +519,11 @@ func newMultiStoreWithMounts(db dbm.DB) *multiStore {
 	store := NewMultiStore(db)
 	store.storeOpts = types.StoreOptions{PruningOptions: types.PruneSyncable}
 	store.MountStoreWithDB(
-		types.NewStoreKey("store1"), iavl.StoreConstructor, nil)
+		types.NewKVStoreKey("store1"), iavl.StoreConstructor, nil)
 	store.MountStoreWithDB(
-		types.NewStoreKey("store2"), iavl.StoreConstructor, nil)
+		types.NewKVStoreKey("store2"), iavl.StoreConstructor, nil)
 	store.MountStoreWithDB(
-		types.NewStoreKey("store3"), iavl.StoreConstructor, nil)
+		types.NewKVStoreKey("store3"), iavl.StoreConstructor, nil)
 	return store
 }
 
@@ -287,3 +554,77 @@ func hashStores(stores map[types.StoreKey]types.CommitStore) []byte {
 	}
 	return merkle.SimpleHashFromMap(m)
 }
+
+func TestParallelCommitMatchesSerial(t *testing.T) {
+	// the stores are committed concurrently on more than one CPU.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	newStore := func(serial bool) *multiStore {
+		ms := NewMultiStore(dbm.NewMemDB())
+		ms.serialCommit = serial
+		for i := 0; i < 8; i++ {
+			ms.MountStoreWithDB(types.NewKVStoreKey(fmt.Sprintf("store%d", i)), iavl.StoreConstructor, nil)
+		}
+		ms.MountStoreWithDB(types.NewKVStoreKey("own"), iavl.StoreConstructor, dbm.NewMemDB())
+		ms.MountStoreWithDB(types.NewTransientStoreKey("transient"), transient.StoreConstructor, nil)
+		require.NoError(t, ms.LoadLatestVersion())
+		return ms
+	}
+	serial, parallel := newStore(true), newStore(false)
+	rnd := rand.New(rand.NewSource(1))
+	for block := 0; block < 10; block++ {
+		for i := 0; i < 500; i++ {
+			key := []byte(fmt.Sprintf("key%d", rnd.Intn(1000)))
+			value := []byte(fmt.Sprintf("value%d", rnd.Int()))
+			name := fmt.Sprintf("store%d", rnd.Intn(8))
+			if i%10 == 0 {
+				name = "own"
+			}
+			del := rnd.Intn(5) == 0
+			for _, ms := range []*multiStore{serial, parallel} {
+				if del {
+					ms.getStoreByName(name).(types.Store).Delete(key)
+				} else {
+					ms.getStoreByName(name).(types.Store).Set(key, value)
+				}
+			}
+		}
+		require.Equal(t, serial.Commit(), parallel.Commit(), "block %d", block)
+	}
+	for ver := int64(1); ver <= 10; ver++ {
+		serialInfo, err := serial.CommitInfo(ver)
+		require.NoError(t, err)
+		parallelInfo, err := parallel.CommitInfo(ver)
+		require.NoError(t, err)
+		require.Equal(t, serialInfo, parallelInfo)
+	}
+}
+
+// panicStore is a store whose Commit panics with its name.
+type panicStore struct {
+	types.CommitStore
+	name string
+}
+
+func (ps panicStore) Commit() types.CommitID {
+	panic(ps.name)
+}
+
+func TestParallelCommitPanic(t *testing.T) {
+	// the stores are committed concurrently on more than one CPU.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	db := dbm.NewMemDB()
+	ms := newMultiStoreWithMounts(db)
+	for _, name := range []string{"a", "m", "z"} {
+		name := name
+		ms.MountStoreWithDB(types.NewKVStoreKey(name), func(db dbm.DB, opts types.StoreOptions) types.CommitStore {
+			return panicStore{iavl.StoreConstructor(db, opts), name}
+		}, nil)
+	}
+	require.NoError(t, ms.LoadLatestVersion())
+	// the panic of the first store, by name, whichever panics first.
+	for i := 0; i < 20; i++ {
+		require.PanicsWithValue(t, "a", func() { ms.Commit() })
+	}
+	require.Equal(t, types.CommitID{}, ms.LastCommitID())
+	require.Equal(t, int64(0), getLatestVersion(db))
+}
@@ -0,0 +1,34 @@
+package transient
+
+import (
+	dbm "github.com/gnolang/gno/pkgs/db"
+
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/types"
+)
+
+// Implements CommitStoreConstructor. The store is in memory, so db and
+// opts are ignored.
+func StoreConstructor(db dbm.DB, opts types.StoreOptions) types.CommitStore {
+	return NewStore()
+}
+
+var _ types.CommitStore = (*Store)(nil)
+
+// Store is a store whose writes are discarded on Commit, for the keys of
+// StoreKindTransient. It does not merkleize.
+type Store struct {
+	dbadapter.Store
+}
+
+// NewStore returns a new, empty transient store.
+func NewStore() *Store {
+	return &Store{dbadapter.Store{DB: dbm.NewMemDB()}}
+}
+
+// Implements Committer/CommitStore.
+// Discards the writes, and always returns a zero commitID.
+func (ts *Store) Commit() types.CommitID {
+	ts.Store = dbadapter.Store{DB: dbm.NewMemDB()}
+	return types.CommitID{}
+}
@@ -2,22 +2,28 @@ package types
 
 import (
 	"math"
+	"strings"
 
 	"github.com/gnolang/overflow"
 )
 
-// Gas consumption descriptors.
+// Gas consumption descriptors, in the "store" category.
 const (
-	GasIterNextCostFlatDesc = "IterNextFlat"
-	GasValuePerByteDesc     = "ValuePerByte"
-	GasWritePerByteDesc     = "WritePerByte"
-	GasReadPerByteDesc      = "ReadPerByte"
-	GasWriteCostFlatDesc    = "WriteFlat"
-	GasReadCostFlatDesc     = "ReadFlat"
-	GasHasDesc              = "Has"
-	GasDeleteDesc           = "Delete"
+	GasIterSeekCostFlatDesc = "store:IterSeekFlat"
+	GasIterNextCostFlatDesc = "store:IterNextFlat"
+	GasKeyPerByteDesc       = "store:KeyPerByte"
+	GasValuePerByteDesc     = "store:ValuePerByte"
+	GasWritePerByteDesc     = "store:WritePerByte"
+	GasReadPerByteDesc      = "store:ReadPerByte"
+	GasWriteCostFlatDesc    = "store:WriteFlat"
+	GasReadCostFlatDesc     = "store:ReadFlat"
+	GasHasDesc              = "store:Has"
+	GasDeleteDesc           = "store:Delete"
 )
 
+// GasCategoryOther is the category of the descriptors without a category.
+const GasCategoryOther = "other"
+
 // Gas measured by the SDK
 type Gas = int64
 
@@ -26,12 +32,34 @@ type OutOfGasException struct {
 	Descriptor string
 }
 
+func (e OutOfGasException) Error() string {
+	return "out of gas in location: " + e.Descriptor
+}
+
+// Is returns whether target is an OutOfGasException, whatever its
+// descriptor, e.g. for errors.Is(err, OutOfGasException{}).
+func (e OutOfGasException) Is(target error) bool {
+	_, ok := target.(OutOfGasException)
+	return ok
+}
+
 // GasOverflowException defines an error thrown when an action results gas consumption
 // unsigned integer overflow.
 type GasOverflowException struct {
 	Descriptor string
 }
 
+func (e GasOverflowException) Error() string {
+	return "gas overflow in location: " + e.Descriptor
+}
+
+// Is returns whether target is a GasOverflowException, whatever its
+// descriptor, e.g. for errors.Is(err, GasOverflowException{}).
+func (e GasOverflowException) Is(target error) bool {
+	_, ok := target.(GasOverflowException)
+	return ok
+}
+
 // GasMeter interface to track gas consumption
 type GasMeter interface {
 	GasConsumed() Gas
@@ -41,6 +69,15 @@ type GasMeter interface {
 	ConsumeGas(amount Gas, descriptor string)
 	IsPastLimit() bool
 	IsOutOfGas() bool
+
+	// Trace returns the last consumptions of gas, oldest first, if traced.
+	Trace() []GasConsumption
+}
+
+// GasConsumption is a call to GasMeter.ConsumeGas, for debugging.
+type GasConsumption struct {
+	Descriptor string
+	Amount     Gas
 }
 
 //----------------------------------------
@@ -49,6 +86,11 @@ type GasMeter interface {
 type basicGasMeter struct {
 	limit    Gas
 	consumed Gas
+
+	// ring buffer of the last consumptions, if traced.
+	trace     []GasConsumption
+	traceNext int
+	traceFull bool
 }
 
 // NewGasMeter returns a reference to a new basicGasMeter.
@@ -62,6 +104,17 @@ func NewGasMeter(limit Gas) *basicGasMeter {
 	}
 }
 
+// NewGasMeterWithTrace returns a basicGasMeter which also keeps the last
+// traceSize consumptions of gas, for Trace.
+func NewGasMeterWithTrace(limit Gas, traceSize int) *basicGasMeter {
+	if traceSize <= 0 {
+		panic("gas trace size must be positive")
+	}
+	g := NewGasMeter(limit)
+	g.trace = make([]GasConsumption, traceSize)
+	return g
+}
+
 func (g *basicGasMeter) GasConsumed() Gas {
 	return g.consumed
 }
@@ -86,6 +139,7 @@ func (g *basicGasMeter) ConsumeGas(amount Gas, descriptor string) {
 	if amount < 0 {
 		panic("gas must not be negative")
 	}
+	g.traceConsumption(amount, descriptor)
 	consumed, ok := overflow.Add64(g.consumed, amount)
 	if !ok {
 		panic(GasOverflowException{descriptor})
@@ -98,6 +152,27 @@ func (g *basicGasMeter) ConsumeGas(amount Gas, descriptor string) {
 	}
 }
 
+func (g *basicGasMeter) traceConsumption(amount Gas, descriptor string) {
+	if g.trace == nil {
+		return
+	}
+	g.trace[g.traceNext] = GasConsumption{descriptor, amount}
+	g.traceNext++
+	if g.traceNext == len(g.trace) {
+		g.traceNext = 0
+		g.traceFull = true
+	}
+}
+
+// Trace returns the last consumptions of gas, oldest first, or nil if the
+// meter was not created with NewGasMeterWithTrace.
+func (g *basicGasMeter) Trace() []GasConsumption {
+	if !g.traceFull {
+		return append([]GasConsumption(nil), g.trace[:g.traceNext]...)
+	}
+	return append(append([]GasConsumption(nil), g.trace[g.traceNext:]...), g.trace[:g.traceNext]...)
+}
+
 func (g *basicGasMeter) IsPastLimit() bool {
 	return g.consumed > g.limit
 }
@@ -152,6 +227,10 @@ func (g *infiniteGasMeter) IsOutOfGas() bool {
 	return false
 }
 
+func (g *infiniteGasMeter) Trace() []GasConsumption {
+	return nil
+}
+
 //----------------------------------------
 // passthroughGasMeter
 
@@ -198,6 +277,10 @@ func (g passthroughGasMeter) ConsumeGas(amount Gas, descriptor string) {
 	g.Head.ConsumeGas(amount, descriptor)
 }
 
+func (g passthroughGasMeter) Trace() []GasConsumption {
+	return g.Head.Trace()
+}
+
 func (g passthroughGasMeter) IsPastLimit() bool {
 	return g.Head.IsPastLimit()
 }
@@ -208,7 +291,9 @@ func (g passthroughGasMeter) IsOutOfGas() bool {
 
 //----------------------------------------
 
-// GasConfig defines gas cost for each operation on KVStores
+// GasConfig defines gas cost for each operation on KVStores.
+// Iterators cost IterSeekCostFlat on creation and IterNextCostFlat per Next,
+// plus ReadCostPerByte for the key and value of each pair iterated over.
 type GasConfig struct {
 	HasCost          Gas
 	DeleteCost       Gas
@@ -216,6 +301,7 @@ type GasConfig struct {
 	ReadCostPerByte  Gas
 	WriteCostFlat    Gas
 	WriteCostPerByte Gas
+	IterSeekCostFlat Gas
 	IterNextCostFlat Gas
 }
 
@@ -228,6 +314,66 @@ func DefaultGasConfig() GasConfig {
 		ReadCostPerByte:  3,
 		WriteCostFlat:    2000,
 		WriteCostPerByte: 30,
+		IterSeekCostFlat: 1000,
 		IterNextCostFlat: 30,
 	}
 }
+
+//----------------------------------------
+// categorizingGasMeter
+
+// CategorizingGasMeter is a GasMeter which also sums the gas consumed by
+// category of descriptors.
+type CategorizingGasMeter interface {
+	GasMeter
+
+	// GasBreakdown returns the gas consumed by category.
+	GasBreakdown() map[string]Gas
+}
+
+type categorizingGasMeter struct {
+	GasMeter
+	breakdown map[string]Gas
+}
+
+// NewCategorizingGasMeter returns a CategorizingGasMeter which consumes gas
+// from meter, or meter if it is already one.
+func NewCategorizingGasMeter(meter GasMeter) CategorizingGasMeter {
+	if cmeter, ok := meter.(CategorizingGasMeter); ok {
+		return cmeter
+	}
+	return &categorizingGasMeter{
+		GasMeter:  meter,
+		breakdown: make(map[string]Gas),
+	}
+}
+
+// ConsumeGas consumes gas from the underlying meter, and adds the gas it
+// consumed, even if it panics, to the category of descriptor.
+func (g *categorizingGasMeter) ConsumeGas(amount Gas, descriptor string) {
+	before := g.GasMeter.GasConsumed()
+	defer func() {
+		if consumed := g.GasMeter.GasConsumed() - before; consumed != 0 {
+			g.breakdown[GasCategory(descriptor)] += consumed
+		}
+	}()
+	g.GasMeter.ConsumeGas(amount, descriptor)
+}
+
+func (g *categorizingGasMeter) GasBreakdown() map[string]Gas {
+	breakdown := make(map[string]Gas, len(g.breakdown))
+	for category, consumed := range g.breakdown {
+		breakdown[category] = consumed
+	}
+	return breakdown
+}
+
+// GasCategory returns the category of a descriptor, which is its prefix
+// before ":", e.g. "store" for "store:ReadFlat", or GasCategoryOther if it
+// has none.
+func GasCategory(descriptor string) string {
+	if i := strings.IndexByte(descriptor, ':'); i > 0 {
+		return descriptor[:i]
+	}
+	return GasCategoryOther
+}
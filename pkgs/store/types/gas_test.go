@@ -1,6 +1,8 @@
 package types
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"testing"
 
@@ -71,3 +73,57 @@ func TestAddUint64Overflow(t *testing.T) {
 		)
 	}
 }
+
+func TestGasExceptionsIs(t *testing.T) {
+	meter := NewGasMeter(10)
+	var err error
+	func() {
+		defer func() { err = recover().(error) }()
+		meter.ConsumeGas(11, "test")
+	}()
+
+	// exceptions match whatever their descriptor.
+	wrapped := fmt.Errorf("running tx: %w", err)
+	require.True(t, errors.Is(wrapped, OutOfGasException{}))
+	require.True(t, errors.Is(wrapped, OutOfGasException{"other"}))
+	require.False(t, errors.Is(wrapped, GasOverflowException{}))
+	require.Equal(t, "running tx: out of gas in location: test", wrapped.Error())
+
+	require.True(t, errors.Is(GasOverflowException{"test"}, GasOverflowException{}))
+	require.False(t, errors.Is(GasOverflowException{"test"}, OutOfGasException{}))
+}
+
+func TestGasMeterTrace(t *testing.T) {
+	// meters do not trace by default.
+	meter := NewGasMeter(100)
+	meter.ConsumeGas(1, "one")
+	require.Nil(t, meter.Trace())
+	require.Nil(t, NewInfiniteGasMeter().Trace())
+
+	meter = NewGasMeterWithTrace(10, 3)
+	meter.ConsumeGas(1, "one")
+	meter.ConsumeGas(2, "two")
+	require.Equal(t, []GasConsumption{{"one", 1}, {"two", 2}}, meter.Trace())
+
+	// only the last ones are kept, including the one running out of gas.
+	meter.ConsumeGas(3, "three")
+	require.Panics(t, func() { meter.ConsumeGas(5, "five") })
+	require.Equal(t, []GasConsumption{{"two", 2}, {"three", 3}, {"five", 5}}, meter.Trace())
+
+	passthrough := NewPassthroughGasMeter(NewInfiniteGasMeter(), 10)
+	require.Nil(t, passthrough.Trace())
+}
+
+func TestCategorizingGasMeter(t *testing.T) {
+	meter := NewCategorizingGasMeter(NewGasMeter(10))
+	require.Equal(t, meter, NewCategorizingGasMeter(meter))
+
+	meter.ConsumeGas(1, "store:ReadFlat")
+	meter.ConsumeGas(2, "store:WriteFlat")
+	meter.ConsumeGas(3, "sig:ed25519")
+	meter.ConsumeGas(0, "handler:noop")
+	// the gas consumed running out of gas is counted too.
+	require.Panics(t, func() { meter.ConsumeGas(5, "uncategorized") })
+	require.Equal(t, map[string]Gas{"store": 3, "sig": 3, "other": 5}, meter.GasBreakdown())
+	require.Equal(t, Gas(11), meter.GasConsumed())
+}
@@ -47,6 +47,21 @@ type Store interface {
 // Alias iterator to db's Iterator for convenience.
 type Iterator = dbm.Iterator
 
+// RevisionStore is a store which counts the sets and deletes of each of its
+// entries, as their revision, for the optimistic cache stores over it.
+type RevisionStore interface {
+	Store
+
+	// Revision returns the revision of the entry of key, 0 if it was
+	// never written.
+	Revision(key []byte) uint64
+
+	// Update calls update with the store locked against the other
+	// goroutines, and with locked to access it meanwhile, so that the
+	// revisions checked by update are those of the entries it writes.
+	Update(update func(locked RevisionStore))
+}
+
 // Queryable allows a Store to expose internal state to the abci.Query
 // interface. Multistore can route requests to the proper Store.
 //
@@ -114,6 +129,89 @@ type CommitMultiStore interface {
 	// (height). An error is returned if any store cannot be loaded. This
 	// should only be used for querying and iterating at past heights.
 	MultiImmutableCacheWrapWithVersion(version int64) (MultiStore, error)
+
+	// MultiCacheWrapWithRecording is analogous to MultiCacheWrap except
+	// that the returned multi-store records the ops it writes to the
+	// stores, e.g. to capture the write set of a block.
+	MultiCacheWrapWithRecording() RecordingMultiStore
+
+	// QueryableWithVersion returns a Queryable of the stores loaded
+	// immutably at a given version (height), which can be queried
+	// concurrently with Commit. An error is returned if any store cannot be
+	// loaded.
+	QueryableWithVersion(version int64) (Queryable, error)
+
+	// StoreInfo returns the info of the store name in the commit info of
+	// the last loaded or committed version, and whether it is there.
+	StoreInfo(name string) (StoreInfo, bool)
+
+	// CommitInfo returns the commit info of a committed version, which can
+	// be called concurrently with Commit. An error is returned if there is
+	// none.
+	CommitInfo(version int64) (CommitInfo, error)
+
+	// VerifyIntegrity checks that the commit info of the last loaded or
+	// committed version is consistent: that the hashes of its stores
+	// recombine to the app hash it records, if any, and that each mounted
+	// store loads at the version with the hash recorded for it. The error
+	// names the store, and the expected and actual hashes.
+	VerifyIntegrity() error
+
+	// SetCommitHashVersion switches the commits from height on to the
+	// algorithm of version for their app hash. The version of each commit
+	// is recorded in its commit info, and the commits before height, or
+	// without a switch, keep the version of the commit before them, so
+	// that the switch need not be set again after it. It panics on an
+	// invalid height or an unknown version.
+	SetCommitHashVersion(height int64, version CommitHashVersion)
+}
+
+// CommitHashVersion is the version of the algorithm which combines the
+// hashes of the stores of a commit into its app hash.
+type CommitHashVersion uint8
+
+const (
+	// CommitHashSimpleMerkle is the simple merkle root of the hashes of
+	// the commit hashes of the stores, by name; the original algorithm.
+	CommitHashSimpleMerkle CommitHashVersion = 0
+	// CommitHashSortedSHA256 is the SHA256 of the names and commit hashes
+	// of the stores, sorted by name, each prefixed by its uvarint length.
+	CommitHashSortedSHA256 CommitHashVersion = 1
+)
+
+// Validate returns an error if the version is unknown.
+func (v CommitHashVersion) Validate() error {
+	switch v {
+	case CommitHashSimpleMerkle, CommitHashSortedSHA256:
+		return nil
+	default:
+		return fmt.Errorf("unknown commit hash version %d", v)
+	}
+}
+
+// CommitInfo is the info of the stores committed at a version.
+type CommitInfo struct {
+	// Format is the format of the commit info of the version, 0 if it was
+	// committed before the metadata of the stores was recorded.
+	Format     uint8
+	Version    int64
+	StoreInfos []StoreInfo
+	// HashVersion is the algorithm of the app hash of the version.
+	HashVersion CommitHashVersion
+}
+
+// StoreInfo is the info of a store committed at a version.
+type StoreInfo struct {
+	Name     string
+	CommitID CommitID
+	// CreatedHeight is the version at which the store was first committed,
+	// or 0 if it is unknown, i.e. if it was before the version at which the
+	// commit info was migrated to record it.
+	CreatedHeight int64
+	// UpgradedHeight is the last version at which the info of the store was
+	// upgraded, i.e. the version at which it was created, or at which the
+	// commit info was migrated to record it.
+	UpgradedHeight int64
 }
 
 // CommitID contains the tree version number and its merkle root.
@@ -137,30 +235,119 @@ func (cid CommitID) String() string {
 //----------------------------------------
 // Keys for accessing substores
 
-// StoreKey is a key used to index stores in a MultiStore.
+// StoreKey is a key used to index stores in a MultiStore. Its type is the
+// kind of the store, see StoreKind: a *KVStoreKey, *TransientStoreKey or
+// *MemoryStoreKey.
 type StoreKey interface {
 	Name() string
 	String() string
 }
 
-type storeKey struct {
-	name string
+// StoreKind is the kind of a store, and of its key.
+type StoreKind uint8
+
+const (
+	// StoreKindKV is the kind of the persistent stores, whose state is
+	// committed in the app hash.
+	StoreKindKV StoreKind = iota
+	// StoreKindTransient is the kind of the stores whose writes are
+	// discarded on Commit.
+	StoreKindTransient
+	// StoreKindMemory is the kind of the stores whose writes are kept in
+	// memory across blocks, but not across restarts.
+	StoreKindMemory
+)
+
+func (kind StoreKind) String() string {
+	switch kind {
+	case StoreKindKV:
+		return "kv"
+	case StoreKindTransient:
+		return "transient"
+	case StoreKindMemory:
+		return "memory"
+	default:
+		return fmt.Sprintf("StoreKind(%d)", uint8(kind))
+	}
 }
 
-// NewStoreKey returns a new pointer to a StoreKey.
-// Use a pointer so keys don't collide.
-func NewStoreKey(name string) *storeKey {
-	return &storeKey{
-		name: name,
+// KindOf returns the kind of the store of key, which is StoreKindKV for
+// the keys of other types than those of this package.
+func KindOf(key StoreKey) StoreKind {
+	switch key.(type) {
+	case *TransientStoreKey:
+		return StoreKindTransient
+	case *MemoryStoreKey:
+		return StoreKindMemory
+	default:
+		return StoreKindKV
 	}
 }
 
-func (key *storeKey) Name() string {
+// KVStoreKey is the key of a persistent store. Keys are compared by
+// pointer, so that they do not collide.
+type KVStoreKey struct {
+	name string
+}
+
+// NewKVStoreKey returns a new key of a persistent store of name.
+func NewKVStoreKey(name string) *KVStoreKey {
+	return &KVStoreKey{name: name}
+}
+
+// NewStoreKey returns a new key of a persistent store of name.
+//
+// Deprecated: use NewKVStoreKey, or the constructors of the other kinds of
+// keys.
+func NewStoreKey(name string) *KVStoreKey {
+	return NewKVStoreKey(name)
+}
+
+func (key *KVStoreKey) Name() string {
+	return key.name
+}
+
+func (key *KVStoreKey) String() string {
+	return fmt.Sprintf("KVStoreKey{%p, %s}", key, key.name)
+}
+
+// TransientStoreKey is the key of a transient store, whose writes are
+// discarded on Commit. It is not committed in the app hash, nor queryable.
+type TransientStoreKey struct {
+	name string
+}
+
+// NewTransientStoreKey returns a new key of a transient store of name.
+func NewTransientStoreKey(name string) *TransientStoreKey {
+	return &TransientStoreKey{name: name}
+}
+
+func (key *TransientStoreKey) Name() string {
+	return key.name
+}
+
+func (key *TransientStoreKey) String() string {
+	return fmt.Sprintf("TransientStoreKey{%p, %s}", key, key.name)
+}
+
+// MemoryStoreKey is the key of a memory store, whose writes are kept in
+// memory across blocks, but not across restarts, e.g. for caches which all
+// nodes rebuild alike. It is not committed in the app hash, nor queryable.
+type MemoryStoreKey struct {
+	name string
+}
+
+// NewMemoryStoreKey returns a new key of a memory store of name.
+func NewMemoryStoreKey(name string) *MemoryStoreKey {
+	return &MemoryStoreKey{name: name}
+}
+
+func (key *MemoryStoreKey) Name() string {
 	return key.name
 }
 
-func (key *storeKey) String() string {
-	return fmt.Sprintf("storeKey{%p, %s}", key, key.name)
+func (key *MemoryStoreKey) String() string {
+	return fmt.Sprintf("MemoryStoreKey{%p, %s}", key, key.name)
 }
 
 //----------------------------------------
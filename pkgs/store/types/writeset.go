@@ -0,0 +1,136 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// StoreOpType is the type of a StoreOp.
+type StoreOpType uint8
+
+const (
+	StoreOpSet StoreOpType = iota + 1
+	StoreOpDelete
+)
+
+func (t StoreOpType) String() string {
+	switch t {
+	case StoreOpSet:
+		return "set"
+	case StoreOpDelete:
+		return "delete"
+	default:
+		return fmt.Sprintf("StoreOpType(%d)", uint8(t))
+	}
+}
+
+// StoreOp is a write to a store of a multistore, identified by the name of
+// its key.
+type StoreOp struct {
+	Store string
+	Type  StoreOpType
+	Key   []byte
+	Value []byte // nil for deletes
+}
+
+func (op StoreOp) String() string {
+	if op.Type == StoreOpDelete {
+		return fmt.Sprintf("%s %s/%X", op.Type, op.Store, op.Key)
+	}
+	return fmt.Sprintf("%s %s/%X=%X", op.Type, op.Store, op.Key, op.Value)
+}
+
+// RecordingMultiStore is a cache-wrapped MultiStore which records the ops
+// written to the underlying stores by MultiWrite.
+type RecordingMultiStore interface {
+	MultiStore
+
+	// WriteSet returns the recorded ops, in the order of the writes: by
+	// MultiWrite, then by store name, then by key.
+	WriteSet() []StoreOp
+}
+
+// Discrepancy is a key whose last op differs between two write sets. A or
+// B is nil if the key is not written by the write set.
+type Discrepancy struct {
+	Store string
+	Key   []byte
+	A, B  *StoreOp
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("%s/%X: %v != %v", d.Store, d.Key, d.A, d.B)
+}
+
+// DiffWriteSets returns the discrepancies between the write sets a and b,
+// sorted by store name and key. Write sets without discrepancy have the
+// same effect on a multistore.
+func DiffWriteSets(a, b []StoreOp) []Discrepancy {
+	lastA, lastB := lastOps(a), lastOps(b)
+	keys := make([]storeOpKey, 0, len(lastA)+len(lastB))
+	for key := range lastA {
+		keys = append(keys, key)
+	}
+	for key := range lastB {
+		if _, ok := lastA[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].store != keys[j].store {
+			return keys[i].store < keys[j].store
+		}
+		return keys[i].key < keys[j].key
+	})
+
+	var diffs []Discrepancy
+	for _, key := range keys {
+		opA, opB := lastA[key], lastB[key]
+		if opA != nil && opB != nil && opA.Type == opB.Type && bytes.Equal(opA.Value, opB.Value) {
+			continue
+		}
+		diffs = append(diffs, Discrepancy{Store: key.store, Key: []byte(key.key), A: opA, B: opB})
+	}
+	return diffs
+}
+
+type storeOpKey struct {
+	store string
+	key   string
+}
+
+func lastOps(ops []StoreOp) map[storeOpKey]*StoreOp {
+	last := make(map[storeOpKey]*StoreOp, len(ops))
+	for i := range ops {
+		last[storeOpKey{ops[i].Store, string(ops[i].Key)}] = &ops[i]
+	}
+	return last
+}
+
+// ApplyWriteSet applies ops to the stores of ms, whose keys are looked up
+// by name in keys. It returns an error, before any write, if a store is not
+// in keys or an op type is unknown.
+func ApplyWriteSet(ms MultiStore, keys []StoreKey, ops []StoreOp) error {
+	byName := make(map[string]StoreKey, len(keys))
+	for _, key := range keys {
+		byName[key.Name()] = key
+	}
+	for _, op := range ops {
+		if _, ok := byName[op.Store]; !ok {
+			return fmt.Errorf("unknown store %q", op.Store)
+		}
+		if op.Type != StoreOpSet && op.Type != StoreOpDelete {
+			return fmt.Errorf("unknown store op type %v", op.Type)
+		}
+	}
+	for _, op := range ops {
+		store := ms.GetStore(byName[op.Store])
+		if op.Type == StoreOpSet {
+			store.Set(op.Key, op.Value)
+		} else {
+			store.Delete(op.Key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffWriteSets(t *testing.T) {
+	set := func(store, key, value string) StoreOp {
+		return StoreOp{Store: store, Type: StoreOpSet, Key: []byte(key), Value: []byte(value)}
+	}
+	del := func(store, key string) StoreOp {
+		return StoreOp{Store: store, Type: StoreOpDelete, Key: []byte(key)}
+	}
+
+	a := []StoreOp{set("main", "a", "1"), set("main", "b", "2"), del("base", "c"), set("main", "b", "3")}
+	require.Empty(t, DiffWriteSets(a, a))
+	require.Empty(t, DiffWriteSets(nil, nil))
+	// only the last op of a key matters.
+	require.Empty(t, DiffWriteSets(a, []StoreOp{del("base", "c"), set("main", "a", "1"), set("main", "b", "3")}))
+
+	b := []StoreOp{set("main", "a", "1"), set("main", "b", "4"), set("base", "c", "5"), set("base", "d", "6")}
+	diffs := DiffWriteSets(a, b)
+	require.Len(t, diffs, 3)
+	require.Equal(t, "base", diffs[0].Store)
+	require.Equal(t, []byte("c"), diffs[0].Key)
+	require.Equal(t, StoreOpDelete, diffs[0].A.Type)
+	require.Equal(t, StoreOpSet, diffs[0].B.Type)
+	require.Equal(t, []byte("d"), diffs[1].Key)
+	require.Nil(t, diffs[1].A)
+	require.Equal(t, []byte("b"), diffs[2].Key)
+	require.Equal(t, []byte("3"), diffs[2].A.Value)
+	require.Equal(t, []byte("4"), diffs[2].B.Value)
+}
@@ -1574,7 +1574,7 @@ func ensureUniq(oozz ...[]Object) {
 	for _, ooz := range oozz {
 		for _, uo := range ooz {
 			if _, ok := om[uo]; ok {
-				panic("duplicate object")
+				panic(fmt.Sprintf("duplicate object %s", objectString(uo)))
 			} else {
 				om[uo] = struct{}{}
 			}
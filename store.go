@@ -1,6 +1,7 @@
 package gno
 
 import (
+	"encoding/binary"
 	"fmt"
 	"strconv"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"github.com/gnolang/gno/pkgs/amino"
 	"github.com/gnolang/gno/pkgs/std"
 	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/cache"
 )
 
 const iavlCacheSize = 1024 * 1024 // TODO increase and parameterize.
@@ -18,6 +20,16 @@ type PackageGetter func(pkgPath string) (*PackageNode, *PackageValue)
 // inject natives into a new or loaded package (value and node)
 type PackageInjector func(store Store, pn *PackageNode, pv *PackageValue)
 
+// Store is the store of the packages, objects, types and nodes of gno.
+// Get*Safe return nil if missing, and the other getters panic. Setting an
+// object, or package, again updates it; setting one with the id of a
+// different object already set is a bug of the caller, which panics in
+// debug mode, and otherwise replaces the object. Setting a type with the id
+// of a type already set keeps the first, and SetCacheType panics instead.
+// A deleted object is missing at once, with its meta, including over
+// layers of cache stores which still have it below, and setting an object
+// of its id again re-creates it, with new meta. Mem packages are iterated in the order they were added. See
+// storetest.TestStoreConformance for the full contract.
 type Store interface {
 	// STABLE
 	SetPackageGetter(PackageGetter)
@@ -25,6 +37,8 @@ type Store interface {
 	SetPackage(*PackageValue)
 	GetObject(oid ObjectID) Object
 	GetObjectSafe(oid ObjectID) Object
+	PrefetchObjects(oids []ObjectID)
+	PrefetchPackage(pkgPath string)
 	SetObject(Object)
 	DelObject(Object)
 	GetType(tid TypeID) Type
@@ -44,13 +58,56 @@ type Store interface {
 	IterMemPackage() <-chan std.MemPackage
 	SwapStores(baseStore, iavlStore store.Store) // for gas wrappers.
 	SetPackageInjector(PackageInjector)          // for natives
+	SetCommitHeight(height int64)                // for object meta
+	GetObjectMeta(oid ObjectID) (ObjectMeta, bool)
+	// StartTx starts journaling the cached types and nodes, until
+	// EndTx, which keeps them if written, or else restores them and
+	// drops the cached objects, which are reloaded from the backend.
+	// StartTx is a noop within a tx, and EndTx outside of one.
+	StartTx()
+	EndTx(written bool)
 	// MISC
 	SetLogStoreOps(enabled bool)
 	SprintStoreOps() string
+	Stats() StoreStats
 	ClearCache()
 	Print()
 }
 
+// The errors of the debug assertions of the store, which it panics with.
+// They are bugs of the caller, e.g. of the realm finalization, and not of
+// the transaction run.
+
+// DuplicateObjectError is the error of setting New, with the id OID of a
+// different object Existing, already set or gotten.
+type DuplicateObjectError struct {
+	OID      ObjectID
+	Existing Object
+	New      Object
+}
+
+func (e DuplicateObjectError) Error() string {
+	return fmt.Sprintf("duplicate object %s: %s is already cached",
+		objectString(e.New), objectString(e.Existing))
+}
+
+// ZeroObjectIDError is the error of setting an object with no id.
+type ZeroObjectIDError struct{}
+
+func (ZeroObjectIDError) Error() string {
+	return "object id cannot be zero"
+}
+
+// DuplicatePackageError is the error of setting a package value of PkgPath,
+// with a different package value of PkgPath already set or gotten.
+type DuplicatePackageError struct {
+	PkgPath string
+}
+
+func (e DuplicatePackageError) Error() string {
+	return fmt.Sprintf("duplicate package value %s: another is already cached", e.PkgPath)
+}
+
 // Used to keep track of in-mem objects during tx.
 type defaultStore struct {
 	pkgGetter    PackageGetter // non-realm packages
@@ -60,10 +117,13 @@ type defaultStore struct {
 	baseStore    store.Store     // for objects, types, nodes
 	iavlStore    store.Store     // for escaped object hashes
 	pkgInjector  PackageInjector // for injecting natives
+	height       int64           // block height of set objects
 
 	// transient
 	opslog  []StoreOp           // for debugging and testing.
 	current map[string]struct{} // for detecting import cycles.
+	txTypes map[TypeID]Type     // types before the tx, nil if absent.
+	txNodes map[Location]BlockNode
 }
 
 func NewStore(baseStore, iavlStore store.Store) *defaultStore {
@@ -171,6 +231,11 @@ func (ds *defaultStore) SetPackage(pv *PackageValue) {
 		// .SetRealm() should have set object id.
 		panic("should not happen")
 	}
+	if debug {
+		if err := ds.checkSetPackage(pv); err != nil {
+			panic(err)
+		}
+	}
 	ds.SetObject(pv)
 	// }
 }
@@ -207,6 +272,12 @@ func (ds *defaultStore) GetObjectSafe(oid ObjectID) Object {
 func (ds *defaultStore) loadObjectSafe(oid ObjectID) Object {
 	key := backendObjectKey(oid)
 	hashbz := ds.baseStore.Get([]byte(key))
+	return ds.decodeObject(oid, hashbz)
+}
+
+// decodes and caches an object from its backend value,
+// or returns nil if hashbz is nil.
+func (ds *defaultStore) decodeObject(oid ObjectID, hashbz []byte) Object {
 	if hashbz != nil {
 		hash := hashbz[:HashSize]
 		bz := hashbz[HashSize:]
@@ -226,6 +297,84 @@ func (ds *defaultStore) loadObjectSafe(oid ObjectID) Object {
 	return nil
 }
 
+// MultiGetter is implemented by backend stores which get several keys at
+// once faster than one by one, e.g. remote ones, in one round trip.
+type MultiGetter interface {
+	// MultiGet returns the values of keys, with nil for missing keys.
+	MultiGet(keys [][]byte) [][]byte
+}
+
+// PrefetchObjects loads the objects of oids which are not cached yet into
+// the cache, with their types, with one MultiGet if the backend store is a
+// MultiGetter, or one by one otherwise. Missing objects are skipped.
+func (ds *defaultStore) PrefetchObjects(oids []ObjectID) {
+	if ds.baseStore == nil {
+		return
+	}
+	missing := make([]ObjectID, 0, len(oids))
+	for _, oid := range oids {
+		if _, exists := ds.cacheObjects[oid]; !exists {
+			missing = append(missing, oid)
+		}
+	}
+	if mg, ok := ds.baseStore.(MultiGetter); ok && len(missing) > 1 {
+		keys := make([][]byte, len(missing))
+		for i, oid := range missing {
+			keys[i] = []byte(backendObjectKey(oid))
+		}
+		values := mg.MultiGet(keys)
+		for i, oid := range missing {
+			// the same oid may be twice in oids.
+			if _, exists := ds.cacheObjects[oid]; !exists {
+				ds.decodeObject(oid, values[i])
+			}
+		}
+		return
+	}
+	for _, oid := range missing {
+		if _, exists := ds.cacheObjects[oid]; !exists {
+			ds.loadObjectSafe(oid)
+		}
+	}
+}
+
+// PrefetchPackage loads the package of pkgPath, with its blocks, and the
+// objects its package block refers to directly, with PrefetchObjects, e.g.
+// the arrays of its slices. Their types are loaded with them.
+func (ds *defaultStore) PrefetchPackage(pkgPath string) {
+	pv := ds.GetPackage(pkgPath)
+	if pv == nil {
+		return
+	}
+	var oids []ObjectID
+	for _, tv := range pv.GetBlock(ds).Values {
+		if oid, ok := refObjectID(tv.V); ok {
+			oids = append(oids, oid)
+		}
+	}
+	ds.PrefetchObjects(oids)
+}
+
+// refObjectID returns the id of the object v refers to, if v is a ref to an
+// object, or a slice or a pointer to one, as loaded from the backend.
+func refObjectID(v Value) (ObjectID, bool) {
+	switch cv := v.(type) {
+	case RefValue:
+		return cv.ObjectID, cv.PkgPath == ""
+	case *SliceValue:
+		return refObjectID(cv.Base)
+	case PointerValue:
+		if cv.Base != nil {
+			return refObjectID(cv.Base)
+		} else if cv.TV != nil {
+			return refObjectID(cv.TV.V)
+		}
+		return ObjectID{}, false
+	default:
+		return ObjectID{}, false
+	}
+}
+
 func (ds *defaultStore) SetObject(oo Object) {
 	oid := oo.GetObjectID()
 	// replace children/fields with Ref.
@@ -248,13 +397,8 @@ func (ds *defaultStore) SetObject(oo Object) {
 	}
 	// save object to cache.
 	if debug {
-		if oid.IsZero() {
-			panic("object id cannot be zero")
-		}
-		if oo2, exists := ds.cacheObjects[oid]; exists {
-			if oo != oo2 {
-				panic("duplicate object")
-			}
+		if err := ds.checkSetObject(oo); err != nil {
+			panic(err)
 		}
 	}
 	ds.cacheObjects[oid] = oo
@@ -269,6 +413,10 @@ func (ds *defaultStore) SetObject(oo Object) {
 		ds.opslog = append(ds.opslog,
 			StoreOp{op, o2.(Object)})
 	}
+	// stamp the object meta with the height.
+	if ds.baseStore != nil {
+		ds.setObjectMeta(oid)
+	}
 	// if escaped, add hash to iavl.
 	if oo.GetIsEscaped() && ds.iavlStore != nil {
 		var key, value []byte
@@ -278,6 +426,28 @@ func (ds *defaultStore) SetObject(oo Object) {
 	}
 }
 
+// checkSetPackage returns the error of the debug assertion of SetPackage
+// of pv, or nil.
+func (ds *defaultStore) checkSetPackage(pv *PackageValue) error {
+	if oo2, exists := ds.cacheObjects[pv.ObjectInfo.ID]; exists && oo2 != pv {
+		return DuplicatePackageError{PkgPath: pv.PkgPath}
+	}
+	return nil
+}
+
+// checkSetObject returns the error of the debug assertions of SetObject of
+// oo, or nil.
+func (ds *defaultStore) checkSetObject(oo Object) error {
+	oid := oo.GetObjectID()
+	if oid.IsZero() {
+		return ZeroObjectIDError{}
+	}
+	if oo2, exists := ds.cacheObjects[oid]; exists && oo != oo2 {
+		return DuplicateObjectError{OID: oid, Existing: oo2, New: oo}
+	}
+	return nil
+}
+
 func (ds *defaultStore) DelObject(oo Object) {
 	oid := oo.GetObjectID()
 	// delete from cache.
@@ -286,6 +456,7 @@ func (ds *defaultStore) DelObject(oo Object) {
 	if ds.baseStore != nil {
 		key := backendObjectKey(oid)
 		ds.baseStore.Delete([]byte(key))
+		ds.baseStore.Delete([]byte(backendObjectMetaKey(oid)))
 	}
 	// make realm op log entry
 	if ds.opslog != nil {
@@ -325,7 +496,7 @@ func (ds *defaultStore) GetTypeSafe(tid TypeID) Type {
 				}
 			}
 			// set in cache.
-			ds.cacheTypes[tid] = tt
+			ds.setCacheType(tid, tt)
 			// after setting in cache, fill tt.
 			fillType(ds, tt)
 			return tt
@@ -344,7 +515,7 @@ func (ds *defaultStore) SetCacheType(tt Type) {
 			// already set.
 		}
 	} else {
-		ds.cacheTypes[tid] = tt
+		ds.setCacheType(tid, tt)
 	}
 }
 
@@ -366,6 +537,17 @@ func (ds *defaultStore) SetType(tt Type) {
 		ds.baseStore.Set([]byte(key), bz)
 	}
 	// save type to cache.
+	ds.setCacheType(tid, tt)
+}
+
+// setCacheType sets tt in the cache, and journals the type it replaces
+// within a tx.
+func (ds *defaultStore) setCacheType(tid TypeID, tt Type) {
+	if ds.txTypes != nil {
+		if _, journaled := ds.txTypes[tid]; !journaled {
+			ds.txTypes[tid] = ds.cacheTypes[tid]
+		}
+	}
 	ds.cacheTypes[tid] = tt
 }
 
@@ -395,7 +577,7 @@ func (ds *defaultStore) GetBlockNodeSafe(loc Location) BlockNode {
 						loc, bn.GetLocation()))
 				}
 			}
-			ds.cacheNodes[loc] = bn
+			ds.setCacheNode(loc, bn)
 			return bn
 		}
 	}
@@ -414,11 +596,22 @@ func (ds *defaultStore) SetBlockNode(bn BlockNode) {
 		// ds.backend.Set([]byte(key), bz)
 	}
 	// save node to cache.
-	ds.cacheNodes[loc] = bn
+	ds.setCacheNode(loc, bn)
 	// XXX duplicate?
 	// XXX
 }
 
+// setCacheNode sets bn in the cache, and journals the node it replaces
+// within a tx.
+func (ds *defaultStore) setCacheNode(loc Location, bn BlockNode) {
+	if ds.txNodes != nil {
+		if _, journaled := ds.txNodes[loc]; !journaled {
+			ds.txNodes[loc] = ds.cacheNodes[loc]
+		}
+	}
+	ds.cacheNodes[loc] = bn
+}
+
 func (ds *defaultStore) NumMemPackages() int64 {
 	ctrkey := []byte(backendPackageIndexCtrKey())
 	ctrbz := ds.iavlStore.Get(ctrkey)
@@ -462,7 +655,9 @@ func (ds *defaultStore) IterMemPackage() <-chan std.MemPackage {
 	ctrkey := []byte(backendPackageIndexCtrKey())
 	ctrbz := ds.iavlStore.Get(ctrkey)
 	if ctrbz == nil {
-		return nil
+		ch := make(chan std.MemPackage)
+		close(ch)
+		return ch
 	} else {
 		ctr, err := strconv.Atoi(string(ctrbz))
 		if err != nil {
@@ -497,6 +692,53 @@ func (ds *defaultStore) SetPackageInjector(inj PackageInjector) {
 	ds.pkgInjector = inj
 }
 
+// SetCommitHeight sets the block height stamped on the meta of the objects
+// set from now on, e.g. before the txs of a block.
+func (ds *defaultStore) SetCommitHeight(height int64) {
+	ds.height = height
+}
+
+// ObjectMeta is the block heights at which an object was first set, and
+// last set, i.e. created and last modified, in the backend.
+type ObjectMeta struct {
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+// GetObjectMeta returns the meta of the object with oid, or false if there
+// is none, e.g. if the object is not in the backend.
+func (ds *defaultStore) GetObjectMeta(oid ObjectID) (ObjectMeta, bool) {
+	if ds.baseStore == nil {
+		return ObjectMeta{}, false
+	}
+	bz := ds.baseStore.Get([]byte(backendObjectMetaKey(oid)))
+	if bz == nil {
+		return ObjectMeta{}, false
+	}
+	if len(bz) != 16 {
+		panic(fmt.Sprintf("unexpected object meta of length %d", len(bz)))
+	}
+	meta := ObjectMeta{
+		CreatedAt: int64(binary.BigEndian.Uint64(bz[:8])),
+		UpdatedAt: int64(binary.BigEndian.Uint64(bz[8:])),
+	}
+	return meta, true
+}
+
+// the created height is kept from the first set.
+func (ds *defaultStore) setObjectMeta(oid ObjectID) {
+	meta, ok := ds.GetObjectMeta(oid)
+	if !ok {
+		meta.CreatedAt = ds.height
+	}
+	meta.UpdatedAt = ds.height
+	bz := make([]byte, 16)
+	binary.BigEndian.PutUint64(bz[:8], uint64(meta.CreatedAt))
+	binary.BigEndian.PutUint64(bz[8:], uint64(meta.UpdatedAt))
+	key := backendObjectMetaKey(oid)
+	ds.baseStore.Set([]byte(key), bz)
+}
+
 func (ds *defaultStore) Flush() {
 	// XXX
 }
@@ -559,6 +801,21 @@ func (ds *defaultStore) SprintStoreOps() string {
 	return strings.Join(ss, "\n")
 }
 
+// StoreStats are the sizes of the caches of a Store.
+type StoreStats struct {
+	CachedObjects int
+	CachedTypes   int
+	CachedNodes   int
+}
+
+func (ds *defaultStore) Stats() StoreStats {
+	return StoreStats{
+		CachedObjects: len(ds.cacheObjects),
+		CachedTypes:   len(ds.cacheTypes),
+		CachedNodes:   len(ds.cacheNodes),
+	}
+}
+
 func (ds *defaultStore) ClearCache() {
 	ds.cacheObjects = make(map[ObjectID]Object)
 	ds.cacheTypes = make(map[TypeID]Type)
@@ -567,6 +824,43 @@ func (ds *defaultStore) ClearCache() {
 	InitCacheTypes(ds)
 }
 
+func (ds *defaultStore) StartTx() {
+	if ds.txTypes != nil {
+		return
+	}
+	ds.txTypes = make(map[TypeID]Type)
+	ds.txNodes = make(map[Location]BlockNode)
+}
+
+func (ds *defaultStore) EndTx(written bool) {
+	if ds.txTypes == nil {
+		return
+	}
+	txTypes, txNodes := ds.txTypes, ds.txNodes
+	ds.txTypes, ds.txNodes = nil, nil
+	if written {
+		return
+	}
+	// the objects of the tx may have been mutated in place, through
+	// any of the cached objects, so they are all dropped; the types
+	// and nodes are as after a restart.
+	ds.cacheObjects = make(map[ObjectID]Object)
+	for tid, tt := range txTypes {
+		if tt == nil {
+			delete(ds.cacheTypes, tid)
+		} else {
+			ds.cacheTypes[tid] = tt
+		}
+	}
+	for loc, bn := range txNodes {
+		if bn == nil {
+			delete(ds.cacheNodes, loc)
+		} else {
+			ds.cacheNodes[loc] = bn
+		}
+	}
+}
+
 // for debugging
 func (ds *defaultStore) Print() {
 	fmt.Println("//----------------------------------------")
@@ -598,6 +892,10 @@ func backendObjectKey(oid ObjectID) string {
 	return "oid:" + oid.String()
 }
 
+func backendObjectMetaKey(oid ObjectID) string {
+	return "oidmeta:" + oid.String()
+}
+
 func backendTypeKey(tid TypeID) string {
 	return "tid:" + tid.String()
 }
@@ -606,6 +904,30 @@ func backendNodeKey(loc Location) string {
 	return "node:" + loc.String()
 }
 
+// baseStorePrefixes are the prefixes of the keys of the base store.
+var baseStorePrefixes = []string{"oid:", "oidmeta:", "tid:", "node:"}
+
+// MoveBaseStore moves the objects, types and nodes of a gno store from the
+// base store from to the base store to, e.g. to another store of the app,
+// and returns the number of entries moved. The entries of from are read
+// one prefix at a time, and only deleted once all of them are written.
+func MoveBaseStore(from, to store.Store) int {
+	var keys [][]byte
+	for _, prefix := range baseStorePrefixes {
+		iter := store.PrefixIterator(from, []byte(prefix))
+		for ; iter.Valid(); iter.Next() {
+			key := append([]byte(nil), iter.Key()...)
+			to.Set(key, iter.Value())
+			keys = append(keys, key)
+		}
+		iter.Close()
+	}
+	for _, key := range keys {
+		from.Delete(key)
+	}
+	return len(keys)
+}
+
 func backendPackageIndexCtrKey() string {
 	return fmt.Sprintf("pkgidx:counter")
 }
@@ -614,6 +936,30 @@ func backendPackageIndexKey(index uint64) string {
 	return fmt.Sprintf("pkgidx:%020d", index)
 }
 
+// ConflictObjectIDs returns the ids of the objects of the keys of err, of
+// optimistic cache stores over the backend of gno stores, in the order of
+// the keys, without the keys of the types, nodes and other entries.
+func ConflictObjectIDs(err *cache.ConflictError) []ObjectID {
+	var oids []ObjectID
+	seen := make(map[ObjectID]struct{})
+	for _, key := range err.Keys {
+		// the keys of objects, or of their meta.
+		parts := strings.SplitN(string(key), ":", 2)
+		if len(parts) != 2 || (parts[0] != "oid" && parts[0] != "oidmeta") {
+			continue
+		}
+		oid, err := ParseObjectID(parts[1])
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[oid]; !ok {
+			seen[oid] = struct{}{}
+			oids = append(oids, oid)
+		}
+	}
+	return oids
+}
+
 //----------------------------------------
 // builtin types
 
@@ -0,0 +1,51 @@
+package gno_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/storetest"
+)
+
+func TestStoreConformance(t *testing.T) {
+	storetest.TestStoreConformance(t, func() gno.Store {
+		return gno.NewStore(storetest.NewBackend())
+	})
+}
+
+// countingStore is a backend store counting its gets.
+type countingStore struct {
+	store.Store
+	gets int
+}
+
+func (cs *countingStore) Get(key []byte) []byte {
+	cs.gets++
+	return cs.Store.Get(key)
+}
+
+func TestPrefetchPackage(t *testing.T) {
+	pkgPath := "gno.land/r/test"
+	baseStore, iavlStore := storetest.NewBackend()
+	pkg := storetest.SetPackage(gno.NewStore(baseStore, iavlStore), pkgPath)
+
+	cs := &countingStore{Store: baseStore}
+	st := gno.NewStore(cs, iavlStore)
+	st.PrefetchPackage(pkgPath)
+	require.Equal(t, 7, cs.gets) // the package, its node, its block, and arrays 2 to 5.
+
+	cs.gets = 0
+	for _, oid := range pkg.Refs {
+		require.NotNil(t, st.GetObject(oid))
+	}
+	require.Equal(t, 0, cs.gets)
+	// the objects of the objects of the package block are not prefetched.
+	require.NotNil(t, st.GetObject(pkg.Nested))
+	require.Equal(t, 1, cs.gets)
+
+	// missing packages are skipped.
+	st.PrefetchPackage("gno.land/r/none")
+}
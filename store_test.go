@@ -0,0 +1,228 @@
+package gno
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaekwon/testify/require"
+
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/cache"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/revision"
+)
+
+// slowStore is a backend store with a latency per Get, as of a remote
+// store, and slowMultiStore one with a latency per MultiGet.
+type slowStore struct {
+	store.Store
+	latency time.Duration
+	gets    int
+}
+
+func (ss *slowStore) Get(key []byte) []byte {
+	ss.gets++
+	time.Sleep(ss.latency)
+	return ss.Store.Get(key)
+}
+
+type slowMultiStore struct {
+	*slowStore
+}
+
+func (sms slowMultiStore) MultiGet(keys [][]byte) [][]byte {
+	sms.gets++
+	time.Sleep(sms.latency)
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = sms.Store.Get(key)
+	}
+	return values
+}
+
+// newTestObjects sets n arrays of ints in a new backend, and returns it
+// with their ids.
+func newTestObjects(n int) (store.Store, []ObjectID) {
+	backend := dbadapter.Store{DB: dbm.NewMemDB()}
+	ds := NewStore(backend, nil)
+	pid := PkgIDFromPkgPath("gno.land/r/test")
+	oids := make([]ObjectID, n)
+	for i := range oids {
+		av := &ArrayValue{List: []TypedValue{{T: IntType}}}
+		av.List[0].SetInt(i)
+		oids[i] = ObjectID{PkgID: pid, NewTime: uint64(i + 1)}
+		av.SetObjectID(oids[i])
+		ds.SetObject(av)
+	}
+	return backend, oids
+}
+
+func TestPrefetchObjects(t *testing.T) {
+	backend, oids := newTestObjects(10)
+	missing := ObjectID{PkgID: PkgIDFromPkgPath("gno.land/r/none"), NewTime: 1}
+
+	for _, multi := range []bool{false, true} {
+		ss := &slowStore{Store: backend}
+		var ds *defaultStore
+		if multi {
+			ds = NewStore(slowMultiStore{ss}, nil)
+		} else {
+			ds = NewStore(ss, nil)
+		}
+		require.NotNil(t, ds.GetObject(oids[0]))
+		ss.gets = 0
+
+		ds.PrefetchObjects(append(oids, oids[1], missing))
+		if multi {
+			require.Equal(t, ss.gets, 1)
+		} else {
+			// the cached object is not fetched again.
+			require.Equal(t, ss.gets, len(oids))
+		}
+
+		// prefetched objects are served from the cache.
+		ss.gets = 0
+		for i, oid := range oids {
+			av := ds.GetObject(oid).(*ArrayValue)
+			require.Equal(t, av.List[0].GetInt(), i)
+		}
+		require.Equal(t, ss.gets, 0)
+		require.Nil(t, ds.GetObjectSafe(missing))
+	}
+}
+
+// The debug assertions of the store fail with typed errors, which the
+// store panics with in debug mode.
+func TestStoreDebugAssertions(t *testing.T) {
+	ds := NewStore(dbadapter.Store{DB: dbm.NewMemDB()}, nil)
+	pkgPath := "gno.land/r/test"
+
+	av := &ArrayValue{}
+	require.Equal(t, ds.checkSetObject(av), ZeroObjectIDError{})
+
+	oid := ObjectID{PkgID: PkgIDFromPkgPath(pkgPath), NewTime: 2}
+	av.SetObjectID(oid)
+	require.Nil(t, ds.checkSetObject(av))
+	ds.SetObject(av)
+	require.Nil(t, ds.checkSetObject(av)) // setting it again is not a duplicate.
+	av2 := &ArrayValue{}
+	av2.SetObjectID(oid)
+	err := ds.checkSetObject(av2)
+	require.Equal(t, err, DuplicateObjectError{OID: oid, Existing: av, New: av2})
+	require.Regexp(t, err.Error(), `^duplicate object .*:2 \(\*gno.ArrayValue\): .* is already cached$`)
+
+	block := &Block{Source: RefNode{Location: PackageNodeLocation(pkgPath)}}
+	block.SetObjectID(ObjectID{PkgID: PkgIDFromPkgPath(pkgPath), NewTime: 3})
+	ds.SetObject(block)
+	pv := &PackageValue{Block: block, PkgName: "test", PkgPath: pkgPath}
+	pv.SetObjectID(ObjectIDFromPkgPath(pkgPath))
+	require.Nil(t, ds.checkSetPackage(pv))
+	ds.SetPackage(pv)
+	pv2 := &PackageValue{Block: block, PkgName: "test", PkgPath: pkgPath}
+	pv2.SetObjectID(ObjectIDFromPkgPath(pkgPath))
+	require.Equal(t, ds.checkSetPackage(pv2), DuplicatePackageError{PkgPath: pkgPath})
+}
+
+func TestStoreTx(t *testing.T) {
+	backend, oids := newTestObjects(1)
+	ds := NewStore(backend, nil)
+	av := ds.GetObject(oids[0])
+	kept := &SliceType{Elt: IntType}
+	ds.SetType(kept)
+	loc := Location{PkgPath: "gno.land/r/test", File: "test.gno", Line: 1}
+	fn := &FuncDecl{}
+	fn.SetLocation(loc)
+	ds.SetBlockNode(fn)
+
+	// written.
+	written := &MapType{Key: IntType, Value: IntType}
+	ds.StartTx()
+	ds.SetType(written)
+	ds.EndTx(true)
+	require.True(t, written == ds.GetType(written.TypeID()))
+	require.True(t, av == ds.GetObject(oids[0]))
+
+	// discarded, with the writes of the tx to its backend, within one tx
+	// however many times it is started.
+	ds.SwapStores(backend.CacheWrap(), nil)
+	ds.StartTx()
+	ds.StartTx()
+	discarded := &ChanType{Dir: BOTH, Elt: IntType}
+	ds.SetType(discarded)
+	fn2 := &FuncDecl{}
+	fn2.SetLocation(loc)
+	ds.SetBlockNode(fn2)
+	loc2 := Location{PkgPath: "gno.land/r/test", File: "test.gno", Line: 2}
+	fn3 := &FuncDecl{}
+	fn3.SetLocation(loc2)
+	ds.SetBlockNode(fn3)
+	ds.EndTx(false)
+	ds.EndTx(false)
+	ds.SwapStores(backend, nil)
+	require.True(t, kept == ds.GetType(kept.TypeID()))
+	require.Nil(t, ds.GetTypeSafe(discarded.TypeID()))
+	require.True(t, fn == ds.GetBlockNode(loc))
+	require.Nil(t, ds.GetBlockNodeSafe(loc2))
+	// the objects are reloaded from the backend.
+	av2 := ds.GetObject(oids[0])
+	require.False(t, av == av2)
+	require.Equal(t, av.(*ArrayValue).List[0].GetInt(), av2.(*ArrayValue).List[0].GetInt())
+}
+
+func TestConflictObjectIDs(t *testing.T) {
+	backend := revision.New(dbadapter.Store{DB: dbm.NewMemDB()})
+	pid := PkgIDFromPkgPath("gno.land/r/test")
+	oids := []ObjectID{{PkgID: pid, NewTime: 1}, {PkgID: pid, NewTime: 2}}
+	setInts := func(ds Store, oids []ObjectID, value int) {
+		for _, oid := range oids {
+			av := &ArrayValue{List: []TypedValue{{T: IntType}}}
+			av.List[0].SetInt(value)
+			av.SetObjectID(oid)
+			ds.SetObject(av)
+		}
+	}
+
+	// both stores write the first object, and types.
+	st1, st2 := cache.NewOptimistic(backend), cache.NewOptimistic(backend)
+	ds1, ds2 := NewStore(st1, nil), NewStore(st2, nil)
+	setInts(ds1, oids[:1], 1)
+	ds1.SetType(&SliceType{Elt: IntType})
+	setInts(ds2, oids, 2)
+	ds2.SetType(&SliceType{Elt: IntType})
+	require.NoError(t, st1.Flush())
+
+	err := st2.Flush()
+	require.IsType(t, &cache.ConflictError{}, err)
+	require.Equal(t, oids[:1], ConflictObjectIDs(err.(*cache.ConflictError)))
+	require.Nil(t, backend.Get([]byte(backendObjectKey(oids[1]))))
+}
+
+func BenchmarkPrefetchObjects(b *testing.B) {
+	backend, oids := newTestObjects(100)
+	for _, bc := range []struct {
+		name     string
+		prefetch bool
+		multi    bool
+	}{
+		{"GetObject", false, false},
+		{"PrefetchObjects", true, false},
+		{"PrefetchObjectsMultiGet", true, true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ss := &slowStore{Store: backend, latency: 10 * time.Microsecond}
+				ds := NewStore(ss, nil)
+				if bc.multi {
+					ds = NewStore(slowMultiStore{ss}, nil)
+				}
+				if bc.prefetch {
+					ds.PrefetchObjects(oids)
+				}
+				for _, oid := range oids {
+					ds.GetObject(oid)
+				}
+			}
+		})
+	}
+}
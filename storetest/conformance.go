@@ -0,0 +1,498 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno"
+	"github.com/gnolang/gno/pkgs/std"
+)
+
+const fixturePkgPath = "gno.land/r/storetest"
+
+// storeRule is a rule of the contract of gno.Store, checked on a new store.
+type storeRule struct {
+	name string
+	test func(t *testing.T, st gno.Store)
+}
+
+// layerRule is a rule of the visibility of writes, checked on new layers.
+type layerRule struct {
+	name string
+	test func(t *testing.T, ls Layers)
+}
+
+// TestStoreConformance checks that the stores of newStore follow the
+// contract of gno.Store, with a subtest per rule of the contract, e.g.
+//
+//	func TestMyStore(t *testing.T) {
+//		storetest.TestStoreConformance(t, func() gno.Store { return NewMyStore() })
+//	}
+//
+// newStore must return a new empty store, with no package getter, on each
+// call. The rules are those of the methods of gno.Store which are stable,
+// and of the mem packages and object meta. In particular, setting an object
+// or package with the id of a different object already set must either
+// panic, as the default store does in debug mode, or replace the object.
+func TestStoreConformance(t *testing.T, newStore func() gno.Store) {
+	for _, r := range storeRules {
+		r := r
+		t.Run(r.name, func(t *testing.T) { r.test(t, newStore()) })
+	}
+}
+
+var storeRules = append([]storeRule{
+	// objects.
+	{"GetObjectSafeMissingIsNil", func(t *testing.T, st gno.Store) {
+		require.Nil(t, st.GetObjectSafe(ObjectID(fixturePkgPath, 2)))
+	}},
+	{"GetObjectMissingPanics", func(t *testing.T, st gno.Store) {
+		require.Panics(t, func() { st.GetObject(ObjectID(fixturePkgPath, 2)) })
+	}},
+	{"SetObjectThenGet", func(t *testing.T, st gno.Store) {
+		SetArray(st, fixturePkgPath, 2, 1, 2, 3)
+		requireArray(t, st, ObjectID(fixturePkgPath, 2), 1, 2, 3)
+		require.NotNil(t, st.GetObjectSafe(ObjectID(fixturePkgPath, 2)))
+	}},
+	{"SetObjectAgainUpdates", func(t *testing.T, st gno.Store) {
+		av := SetArray(st, fixturePkgPath, 2, 1, 2, 3)
+		av.List[0].SetInt(10)
+		st.SetObject(av)
+		requireArray(t, st, av.GetObjectID(), 10, 2, 3)
+	}},
+	{"SetObjectDuplicatePanicsOrReplaces", func(t *testing.T, st gno.Store) {
+		SetArray(st, fixturePkgPath, 2, 1)
+		if !setPanics(func() { SetArray(st, fixturePkgPath, 2, 2) }) {
+			requireArray(t, st, ObjectID(fixturePkgPath, 2), 2)
+		}
+	}},
+	{"DelObjectThenMissing", func(t *testing.T, st gno.Store) {
+		av := SetArray(st, fixturePkgPath, 2, 1)
+		st.DelObject(av)
+		require.Nil(t, st.GetObjectSafe(av.GetObjectID()))
+	}},
+	{"DelObjectMissingIsNoop", func(t *testing.T, st gno.Store) {
+		st.DelObject(NewArray(fixturePkgPath, 2, 1))
+		require.Nil(t, st.GetObjectSafe(ObjectID(fixturePkgPath, 2)))
+	}},
+	{"DelObjectKeepsOthers", func(t *testing.T, st gno.Store) {
+		av := SetArray(st, fixturePkgPath, 2, 1)
+		SetArray(st, fixturePkgPath, 3, 2)
+		st.DelObject(av)
+		requireArray(t, st, ObjectID(fixturePkgPath, 3), 2)
+	}},
+
+	// object meta.
+	{"GetObjectMetaMissing", func(t *testing.T, st gno.Store) {
+		_, ok := st.GetObjectMeta(ObjectID(fixturePkgPath, 2))
+		require.False(t, ok)
+	}},
+	{"ObjectMetaCreatedAtCommitHeight", func(t *testing.T, st gno.Store) {
+		st.SetCommitHeight(5)
+		SetArray(st, fixturePkgPath, 2, 1)
+		meta, ok := st.GetObjectMeta(ObjectID(fixturePkgPath, 2))
+		require.True(t, ok)
+		require.Equal(t, gno.ObjectMeta{CreatedAt: 5, UpdatedAt: 5}, meta)
+	}},
+	{"ObjectMetaUpdatedAtCommitHeight", func(t *testing.T, st gno.Store) {
+		st.SetCommitHeight(5)
+		av := SetArray(st, fixturePkgPath, 2, 1)
+		st.SetCommitHeight(6)
+		av.List[0].SetInt(2)
+		st.SetObject(av)
+		meta, _ := st.GetObjectMeta(av.GetObjectID())
+		require.Equal(t, gno.ObjectMeta{CreatedAt: 5, UpdatedAt: 6}, meta)
+	}},
+	{"ObjectMetaUnchangedByGets", func(t *testing.T, st gno.Store) {
+		st.SetCommitHeight(5)
+		av := SetArray(st, fixturePkgPath, 2, 1)
+		st.SetCommitHeight(6)
+		st.GetObject(av.GetObjectID())
+		meta, _ := st.GetObjectMeta(av.GetObjectID())
+		require.Equal(t, gno.ObjectMeta{CreatedAt: 5, UpdatedAt: 5}, meta)
+	}},
+	{"DelObjectDeletesMeta", func(t *testing.T, st gno.Store) {
+		av := SetArray(st, fixturePkgPath, 2, 1)
+		st.DelObject(av)
+		_, ok := st.GetObjectMeta(av.GetObjectID())
+		require.False(t, ok)
+	}},
+
+	// types.
+	{"GetTypeSafeMissingIsNil", func(t *testing.T, st gno.Store) {
+		require.Nil(t, st.GetTypeSafe(fixtureType(gno.IntType).TypeID()))
+	}},
+	{"GetTypeMissingPanics", func(t *testing.T, st gno.Store) {
+		require.Panics(t, func() { st.GetType(fixtureType(gno.IntType).TypeID()) })
+	}},
+	{"SetTypeThenGet", func(t *testing.T, st gno.Store) {
+		tt := fixtureType(gno.IntType)
+		st.SetType(tt)
+		require.Equal(t, tt.TypeID(), st.GetType(tt.TypeID()).TypeID())
+		require.Equal(t, tt.TypeID(), st.GetTypeSafe(tt.TypeID()).TypeID())
+	}},
+	{"SetTypeDuplicateKeepsFirst", func(t *testing.T, st gno.Store) {
+		tt := fixtureType(gno.IntType)
+		st.SetType(tt)
+		st.SetType(fixtureType(gno.IntType))
+		require.True(t, st.GetType(tt.TypeID()) == tt)
+	}},
+	{"SetCacheTypeThenGet", func(t *testing.T, st gno.Store) {
+		tt := fixtureType(gno.IntType)
+		st.SetCacheType(tt)
+		require.True(t, st.GetType(tt.TypeID()) == tt)
+	}},
+	{"SetCacheTypeDuplicatePanics", func(t *testing.T, st gno.Store) {
+		st.SetCacheType(fixtureType(gno.IntType))
+		require.Panics(t, func() { st.SetCacheType(fixtureType(gno.IntType)) })
+	}},
+
+	// block nodes.
+	{"GetBlockNodeSafeMissingIsNil", func(t *testing.T, st gno.Store) {
+		require.Nil(t, st.GetBlockNodeSafe(gno.PackageNodeLocation(fixturePkgPath)))
+	}},
+	{"GetBlockNodeMissingPanics", func(t *testing.T, st gno.Store) {
+		require.Panics(t, func() { st.GetBlockNode(gno.PackageNodeLocation(fixturePkgPath)) })
+	}},
+	{"SetBlockNodeThenGet", func(t *testing.T, st gno.Store) {
+		loc := gno.PackageNodeLocation(fixturePkgPath)
+		st.SetBlockNode(gno.NewPackageNode("fixture", fixturePkgPath, nil))
+		require.Equal(t, loc, st.GetBlockNode(loc).GetLocation())
+		require.Equal(t, loc, st.GetBlockNodeSafe(loc).GetLocation())
+	}},
+	{"SetBlockNodeZeroLocationPanics", func(t *testing.T, st gno.Store) {
+		require.Panics(t, func() { st.SetBlockNode(&gno.FuncDecl{}) })
+	}},
+
+	// packages.
+	{"GetPackageMissingIsNil", func(t *testing.T, st gno.Store) {
+		require.Nil(t, st.GetPackage(fixturePkgPath))
+	}},
+	{"SetPackageThenGet", func(t *testing.T, st gno.Store) {
+		pkg := SetPackage(st, fixturePkgPath)
+		pv := st.GetPackage(fixturePkgPath)
+		require.NotNil(t, pv)
+		require.Equal(t, fixturePkgPath, pv.PkgPath)
+		require.Equal(t, pkg.Value.GetObjectID(), pv.GetObjectID())
+		require.Len(t, pv.GetBlock(st).Values, len(pkg.Block.Values))
+	}},
+	{"SetPackageAgainUpdates", func(t *testing.T, st gno.Store) {
+		pkg := SetPackage(st, fixturePkgPath)
+		pkg.Value.PkgName = "renamed"
+		st.SetPackage(pkg.Value)
+		require.Equal(t, gno.Name("renamed"), st.GetPackage(fixturePkgPath).PkgName)
+	}},
+	{"SetPackageDuplicatePanicsOrReplaces", func(t *testing.T, st gno.Store) {
+		pkg := SetPackage(st, fixturePkgPath)
+		pv := &gno.PackageValue{Block: pkg.Block, PkgName: "duplicate", PkgPath: fixturePkgPath}
+		pv.SetObjectID(pkg.Value.GetObjectID())
+		if !setPanics(func() { st.SetPackage(pv) }) {
+			require.Equal(t, gno.Name("duplicate"), st.GetPackage(fixturePkgPath).PkgName)
+		}
+	}},
+	{"PackageIsObject", func(t *testing.T, st gno.Store) {
+		pkg := SetPackage(st, fixturePkgPath)
+		pv, ok := st.GetObject(gno.ObjectIDFromPkgPath(fixturePkgPath)).(*gno.PackageValue)
+		require.True(t, ok)
+		require.Equal(t, pkg.Value.GetObjectID(), pv.GetObjectID())
+	}},
+	{"PackageObjectsAreObjects", func(t *testing.T, st gno.Store) {
+		pkg := SetPackage(st, fixturePkgPath)
+		require.NotNil(t, st.GetObjectSafe(pkg.Block.GetObjectID()))
+		for i, oid := range pkg.Refs[:3] {
+			requireArray(t, st, oid, i+2)
+		}
+		requireArray(t, st, pkg.Nested, 6)
+	}},
+
+	// mem packages.
+	{"NumMemPackagesEmptyIsZero", func(t *testing.T, st gno.Store) {
+		require.Equal(t, int64(0), st.NumMemPackages())
+	}},
+	{"IterMemPackageEmptyIsClosed", func(t *testing.T, st gno.Store) {
+		require.Empty(t, memPackagePaths(st))
+	}},
+	{"AddMemPackageCounts", func(t *testing.T, st gno.Store) {
+		addMemPackages(st, "gno.land/p/b", "gno.land/p/a")
+		require.Equal(t, int64(2), st.NumMemPackages())
+	}},
+	{"IterMemPackageInAddOrder", func(t *testing.T, st gno.Store) {
+		paths := []string{"gno.land/p/b", "gno.land/p/a", "gno.land/p/c"}
+		addMemPackages(st, paths...)
+		require.Equal(t, paths, memPackagePaths(st))
+	}},
+
+	// prefetching.
+	{"PrefetchPackageMissingIsNoop", func(t *testing.T, st gno.Store) {
+		st.PrefetchPackage(fixturePkgPath)
+		require.Nil(t, st.GetPackage(fixturePkgPath))
+	}},
+	{"PrefetchObjectsMissingIsNoop", func(t *testing.T, st gno.Store) {
+		missing := ObjectID(fixturePkgPath, 1000)
+		st.PrefetchObjects([]gno.ObjectID{missing, missing})
+		require.Nil(t, st.GetObjectSafe(missing))
+	}},
+	{"PrefetchKeepsObjects", func(t *testing.T, st gno.Store) {
+		pkg := SetPackage(st, fixturePkgPath)
+		st.PrefetchPackage(fixturePkgPath)
+		st.PrefetchObjects(append(pkg.Refs, pkg.Nested, pkg.Nested))
+		for i, oid := range pkg.Refs[:3] {
+			requireArray(t, st, oid, i+2)
+		}
+		requireArray(t, st, pkg.Nested, 6)
+	}},
+}, objectOpRules()...)
+
+// objectOpRules returns the rules of the sequences of ops, of up to 4 ops,
+// on an object, checked on a new store: the object is set, with a new
+// value, deleted, or after a delete, set again with a new object of the
+// same id, which is re-created.
+func objectOpRules() []storeRule {
+	var rules []storeRule
+	for _, ops := range opSequences("SD", 4) {
+		ops := ops
+		rules = append(rules, storeRule{"ObjectOps/" + ops, func(t *testing.T, st gno.Store) {
+			requireObjectState(t, st, runObjectOps(st, nil, ops))
+		}})
+	}
+	return rules
+}
+
+// Layers are a gno store over a layer of a backend, as of a cache store over
+// the stores of a block, to check the visibility of the writes of the store
+// in the backend.
+type Layers struct {
+	// Store is the gno store over the layer.
+	Store gno.Store
+	// Flush writes the layer to the backend: the last write of each key,
+	// so that an object deleted and then re-created is set, and one set and
+	// then deleted is deleted, whatever the order of the keys written.
+	Flush func()
+	// Reload returns a new gno store over the backend, with no cache.
+	Reload func() gno.Store
+}
+
+// TestLayeredStoreConformance checks that the layers of newLayers follow the
+// contract of gno.Store, as of TestStoreConformance, and that the writes of
+// their stores are in their backend once, and only once, flushed. Block
+// nodes are not persisted, so only objects, types and mem packages are
+// checked in the backend.
+func TestLayeredStoreConformance(t *testing.T, newLayers func() Layers) {
+	TestStoreConformance(t, func() gno.Store { return newLayers().Store })
+	for _, r := range layerRules {
+		r := r
+		t.Run(r.name, func(t *testing.T) { r.test(t, newLayers()) })
+	}
+}
+
+var layerRules = append([]layerRule{
+	{"SetObjectNotVisibleBeforeFlush", func(t *testing.T, ls Layers) {
+		SetArray(ls.Store, fixturePkgPath, 2, 1)
+		require.Nil(t, ls.Reload().GetObjectSafe(ObjectID(fixturePkgPath, 2)))
+	}},
+	{"SetObjectVisibleAfterFlush", func(t *testing.T, ls Layers) {
+		ls.Store.SetCommitHeight(5)
+		SetArray(ls.Store, fixturePkgPath, 2, 1)
+		ls.Flush()
+		st := ls.Reload()
+		requireArray(t, st, ObjectID(fixturePkgPath, 2), 1)
+		meta, _ := st.GetObjectMeta(ObjectID(fixturePkgPath, 2))
+		require.Equal(t, gno.ObjectMeta{CreatedAt: 5, UpdatedAt: 5}, meta)
+	}},
+	{"DelObjectVisibleAfterFlush", func(t *testing.T, ls Layers) {
+		av := SetArray(ls.Store, fixturePkgPath, 2, 1)
+		ls.Flush()
+		ls.Store.DelObject(av)
+		requireArray(t, ls.Reload(), av.GetObjectID(), 1)
+		ls.Flush()
+		require.Nil(t, ls.Reload().GetObjectSafe(av.GetObjectID()))
+	}},
+	{"SetPackageVisibleAfterFlush", func(t *testing.T, ls Layers) {
+		pkg := SetPackage(ls.Store, fixturePkgPath)
+		require.Nil(t, ls.Reload().GetPackage(fixturePkgPath))
+		ls.Flush()
+		st := ls.Reload()
+		require.NotNil(t, st.GetPackage(fixturePkgPath))
+		requireArray(t, st, pkg.Nested, 6)
+	}},
+	{"SetTypeVisibleAfterFlush", func(t *testing.T, ls Layers) {
+		tt := fixtureType(gno.IntType)
+		ls.Store.SetType(tt)
+		require.Nil(t, ls.Reload().GetTypeSafe(tt.TypeID()))
+		ls.Flush()
+		require.Equal(t, tt.TypeID(), ls.Reload().GetType(tt.TypeID()).TypeID())
+	}},
+	{"SetCacheTypeNotPersisted", func(t *testing.T, ls Layers) {
+		tt := fixtureType(gno.IntType)
+		ls.Store.SetCacheType(tt)
+		ls.Flush()
+		require.Nil(t, ls.Reload().GetTypeSafe(tt.TypeID()))
+	}},
+	{"AddMemPackageVisibleAfterFlush", func(t *testing.T, ls Layers) {
+		addMemPackages(ls.Store, "gno.land/p/a")
+		require.Equal(t, int64(0), ls.Reload().NumMemPackages())
+		ls.Flush()
+		require.Equal(t, []string{"gno.land/p/a"}, memPackagePaths(ls.Reload()))
+	}},
+}, objectOpLayerRules()...)
+
+// objectOpLayerRules returns the rules of the sequences of ops, of up to 4
+// ops, on an object of the store of new layers, with flushes in between:
+// the store reads its own writes, deletes included, the backend has the
+// state of the last flush, and after a last flush, the state of the store.
+// The last write of an object before a flush is the one flushed, e.g. an
+// object deleted and then re-created is set.
+func objectOpLayerRules() []layerRule {
+	var rules []layerRule
+	for _, ops := range opSequences("SDF", 4) {
+		ops := ops
+		rules = append(rules, layerRule{"ObjectOps/" + ops, func(t *testing.T, ls Layers) {
+			state := runObjectOps(ls.Store, ls.Flush, ops)
+			requireObjectState(t, ls.Store, state)
+			requireObjectState(t, ls.Reload(), state.flushed())
+			ls.Flush()
+			requireObjectState(t, ls.Reload(), state)
+		}})
+	}
+	return rules
+}
+
+// opSequences returns the sequences of the ops of alphabet, of 1 to maxLen
+// ops, e.g. "S", "D", "SS", "SD"... for "SD".
+func opSequences(alphabet string, maxLen int) []string {
+	seqs := []string{""}
+	var all []string
+	for n := 0; n < maxLen; n++ {
+		var next []string
+		for _, seq := range seqs {
+			for _, op := range alphabet {
+				next = append(next, seq+string(op))
+			}
+		}
+		all = append(all, next...)
+		seqs = next
+	}
+	return all
+}
+
+// objectState is the expected state of the object of a sequence of ops.
+type objectState struct {
+	value int // of the object, or 0 if missing.
+	meta  gno.ObjectMeta
+
+	lastFlush *objectState // as of the last flush, if any.
+}
+
+// flushed returns the state as of the last flush.
+func (state objectState) flushed() objectState {
+	if state.lastFlush == nil {
+		return objectState{}
+	}
+	return *state.lastFlush
+}
+
+// runObjectOps runs ops on the object ObjectID(fixturePkgPath, 2) of st,
+// at the commit height of the index of the op, from 1: 'S' sets it with the
+// index as value, 'D' deletes it, and 'F' calls flush. It returns the
+// expected state of the object.
+func runObjectOps(st gno.Store, flush func(), ops string) objectState {
+	var av *gno.ArrayValue
+	var state objectState
+	for i, op := range ops {
+		height := int64(i + 1)
+		st.SetCommitHeight(height)
+		switch op {
+		case 'S':
+			if av == nil {
+				av = NewArray(fixturePkgPath, 2, 0)
+				state.meta.CreatedAt = height
+			}
+			av.List[0].SetInt(i + 1)
+			st.SetObject(av)
+			state.value = i + 1
+			state.meta.UpdatedAt = height
+		case 'D':
+			if av == nil {
+				av = NewArray(fixturePkgPath, 2, 0)
+			}
+			st.DelObject(av)
+			av = nil
+			state.value, state.meta = 0, gno.ObjectMeta{}
+		case 'F':
+			flushed := state
+			flushed.lastFlush = nil
+			flush()
+			state.lastFlush = &flushed
+		default:
+			panic("unknown op " + string(op))
+		}
+	}
+	return state
+}
+
+// requireObjectState requires the object of runObjectOps in st to be in
+// state, with its meta.
+func requireObjectState(t *testing.T, st gno.Store, state objectState) {
+	t.Helper()
+	oid := ObjectID(fixturePkgPath, 2)
+	meta, ok := st.GetObjectMeta(oid)
+	if state.value == 0 {
+		require.Nil(t, st.GetObjectSafe(oid))
+		require.False(t, ok)
+		return
+	}
+	requireArray(t, st, oid, state.value)
+	require.True(t, ok)
+	require.Equal(t, state.meta, meta)
+}
+
+// requireArray requires the object oid of st to be an array of values.
+func requireArray(t *testing.T, st gno.Store, oid gno.ObjectID, values ...int) {
+	t.Helper()
+	av, ok := st.GetObject(oid).(*gno.ArrayValue)
+	require.True(t, ok, "object %s is not an array", oid)
+	require.Equal(t, oid, av.GetObjectID())
+	require.Len(t, av.List, len(values))
+	for i, value := range values {
+		require.Equal(t, value, av.List[i].GetInt())
+	}
+}
+
+// setPanics returns whether set panics.
+func setPanics(set func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+		}
+	}()
+	set()
+	return false
+}
+
+// fixtureType returns a new struct type of the fixture package, with a
+// field of type elt.
+func fixtureType(elt gno.Type) *gno.StructType {
+	return &gno.StructType{
+		PkgPath: fixturePkgPath,
+		Fields:  []gno.FieldType{{Name: "A", Type: elt}},
+	}
+}
+
+func addMemPackages(st gno.Store, paths ...string) {
+	for _, path := range paths {
+		st.AddMemPackage(std.MemPackage{
+			Name:  "x",
+			Path:  path,
+			Files: []std.MemFile{{Name: "x.go", Body: "package x"}},
+		})
+	}
+}
+
+func memPackagePaths(st gno.Store) []string {
+	var paths []string
+	for memPkg := range st.IterMemPackage() {
+		paths = append(paths, memPkg.Path)
+	}
+	return paths
+}
@@ -0,0 +1,86 @@
+package storetest
+
+import (
+	"github.com/gnolang/gno"
+)
+
+// ObjectID returns the id of the object of the realm of pkgPath created at
+// newTime. The package value of the realm is created at 1.
+func ObjectID(pkgPath string, newTime uint64) gno.ObjectID {
+	return gno.ObjectID{PkgID: gno.PkgIDFromPkgPath(pkgPath), NewTime: newTime}
+}
+
+// NewArray returns an array of the ints values, as the object of the realm
+// of pkgPath created at newTime. It is not set in a store.
+func NewArray(pkgPath string, newTime uint64, values ...int) *gno.ArrayValue {
+	av := &gno.ArrayValue{List: make([]gno.TypedValue, len(values))}
+	for i, value := range values {
+		av.List[i].T = gno.IntType
+		av.List[i].SetInt(value)
+	}
+	av.SetObjectID(ObjectID(pkgPath, newTime))
+	return av
+}
+
+// SetArray sets a new array of NewArray in st, and returns it.
+func SetArray(st gno.Store, pkgPath string, newTime uint64, values ...int) *gno.ArrayValue {
+	av := NewArray(pkgPath, newTime, values...)
+	st.SetObject(av)
+	return av
+}
+
+// Package is a realm package set in a store by SetPackage.
+type Package struct {
+	Value *gno.PackageValue
+	Block *gno.Block
+
+	// Refs are the ids of the objects the package block refers to.
+	Refs []gno.ObjectID
+	// Nested is the id of the array in the array of the package block.
+	Nested gno.ObjectID
+}
+
+// SetPackage sets in st a realm package of pkgPath, with no files, whose
+// package block has, in order:
+//
+//   - a slice of an array of ints [2];
+//   - a pointer to an array of ints [3];
+//   - an array of ints [4];
+//   - an array [5] of an array of ints [6];
+//
+// with the times the objects are created at in brackets.
+func SetPackage(st gno.Store, pkgPath string) Package {
+	at := &gno.ArrayType{Len: 1, Elt: gno.IntType}
+	nested := SetArray(st, pkgPath, 6, 6)
+	outer := &gno.ArrayValue{List: []gno.TypedValue{{T: at, V: nested}}}
+	outer.SetObjectID(ObjectID(pkgPath, 5))
+	st.SetObject(outer)
+
+	block := &gno.Block{
+		Source: gno.RefNode{Location: gno.PackageNodeLocation(pkgPath)},
+		Values: []gno.TypedValue{
+			{T: &gno.SliceType{Elt: gno.IntType}, V: &gno.SliceValue{Base: SetArray(st, pkgPath, 2, 2), Length: 1, Maxcap: 1}},
+			{T: &gno.PointerType{Elt: at}, V: gno.PointerValue{TV: &gno.TypedValue{T: at, V: SetArray(st, pkgPath, 3, 3)}}},
+			{T: at, V: SetArray(st, pkgPath, 4, 4)},
+			{T: &gno.ArrayType{Len: 1, Elt: at}, V: outer},
+		},
+	}
+	block.SetObjectID(ObjectID(pkgPath, 7))
+	st.SetObject(block)
+
+	pv := &gno.PackageValue{Block: block, PkgName: "fixture", PkgPath: pkgPath}
+	pv.SetObjectID(gno.ObjectIDFromPkgPath(pkgPath))
+	st.SetPackage(pv)
+
+	return Package{
+		Value: pv,
+		Block: block,
+		Refs: []gno.ObjectID{
+			ObjectID(pkgPath, 2),
+			ObjectID(pkgPath, 3),
+			ObjectID(pkgPath, 4),
+			ObjectID(pkgPath, 5),
+		},
+		Nested: nested.GetObjectID(),
+	}
+}
@@ -0,0 +1,201 @@
+package storetest
+
+import (
+	"github.com/gnolang/gno"
+	"github.com/gnolang/gno/pkgs/std"
+	"github.com/gnolang/gno/pkgs/store"
+)
+
+// StoreCall is a call of a method of a gno.Store, with its arguments.
+type StoreCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// Recorder is a gno.Store which records the calls of its methods, and
+// passes them to the store it wraps. Calls the wrapped store makes to
+// itself are not recorded.
+type Recorder struct {
+	st    gno.Store
+	calls []StoreCall
+}
+
+var _ gno.Store = (*Recorder)(nil)
+
+// NewRecorder returns a Recorder wrapping st.
+func NewRecorder(st gno.Store) *Recorder {
+	return &Recorder{st: st}
+}
+
+// Calls returns the calls recorded since the last Reset.
+func (r *Recorder) Calls() []StoreCall {
+	return r.calls
+}
+
+// Methods returns the methods of the calls recorded since the last Reset.
+func (r *Recorder) Methods() []string {
+	methods := make([]string, len(r.calls))
+	for i, call := range r.calls {
+		methods[i] = call.Method
+	}
+	return methods
+}
+
+// Reset forgets the recorded calls.
+func (r *Recorder) Reset() {
+	r.calls = nil
+}
+
+func (r *Recorder) record(method string, args ...interface{}) {
+	r.calls = append(r.calls, StoreCall{Method: method, Args: args})
+}
+
+func (r *Recorder) SetPackageGetter(pg gno.PackageGetter) {
+	r.record("SetPackageGetter")
+	r.st.SetPackageGetter(pg)
+}
+
+func (r *Recorder) GetPackage(pkgPath string) *gno.PackageValue {
+	r.record("GetPackage", pkgPath)
+	return r.st.GetPackage(pkgPath)
+}
+
+func (r *Recorder) SetPackage(pv *gno.PackageValue) {
+	r.record("SetPackage", pv.PkgPath)
+	r.st.SetPackage(pv)
+}
+
+func (r *Recorder) GetObject(oid gno.ObjectID) gno.Object {
+	r.record("GetObject", oid)
+	return r.st.GetObject(oid)
+}
+
+func (r *Recorder) GetObjectSafe(oid gno.ObjectID) gno.Object {
+	r.record("GetObjectSafe", oid)
+	return r.st.GetObjectSafe(oid)
+}
+
+func (r *Recorder) PrefetchObjects(oids []gno.ObjectID) {
+	r.record("PrefetchObjects", oids)
+	r.st.PrefetchObjects(oids)
+}
+
+func (r *Recorder) PrefetchPackage(pkgPath string) {
+	r.record("PrefetchPackage", pkgPath)
+	r.st.PrefetchPackage(pkgPath)
+}
+
+func (r *Recorder) SetObject(oo gno.Object) {
+	r.record("SetObject", oo.GetObjectID())
+	r.st.SetObject(oo)
+}
+
+func (r *Recorder) DelObject(oo gno.Object) {
+	r.record("DelObject", oo.GetObjectID())
+	r.st.DelObject(oo)
+}
+
+func (r *Recorder) GetType(tid gno.TypeID) gno.Type {
+	r.record("GetType", tid)
+	return r.st.GetType(tid)
+}
+
+func (r *Recorder) GetTypeSafe(tid gno.TypeID) gno.Type {
+	r.record("GetTypeSafe", tid)
+	return r.st.GetTypeSafe(tid)
+}
+
+func (r *Recorder) SetCacheType(tt gno.Type) {
+	r.record("SetCacheType", tt.TypeID())
+	r.st.SetCacheType(tt)
+}
+
+func (r *Recorder) SetType(tt gno.Type) {
+	r.record("SetType", tt.TypeID())
+	r.st.SetType(tt)
+}
+
+func (r *Recorder) GetBlockNode(loc gno.Location) gno.BlockNode {
+	r.record("GetBlockNode", loc)
+	return r.st.GetBlockNode(loc)
+}
+
+func (r *Recorder) GetBlockNodeSafe(loc gno.Location) gno.BlockNode {
+	r.record("GetBlockNodeSafe", loc)
+	return r.st.GetBlockNodeSafe(loc)
+}
+
+func (r *Recorder) SetBlockNode(bn gno.BlockNode) {
+	r.record("SetBlockNode", bn.GetLocation())
+	r.st.SetBlockNode(bn)
+}
+
+func (r *Recorder) NumMemPackages() int64 {
+	r.record("NumMemPackages")
+	return r.st.NumMemPackages()
+}
+
+func (r *Recorder) AddMemPackage(memPkg std.MemPackage) {
+	r.record("AddMemPackage", memPkg.Path)
+	r.st.AddMemPackage(memPkg)
+}
+
+func (r *Recorder) IterMemPackage() <-chan std.MemPackage {
+	r.record("IterMemPackage")
+	return r.st.IterMemPackage()
+}
+
+func (r *Recorder) SwapStores(baseStore, iavlStore store.Store) {
+	r.record("SwapStores")
+	r.st.SwapStores(baseStore, iavlStore)
+}
+
+func (r *Recorder) SetPackageInjector(inj gno.PackageInjector) {
+	r.record("SetPackageInjector")
+	r.st.SetPackageInjector(inj)
+}
+
+func (r *Recorder) SetCommitHeight(height int64) {
+	r.record("SetCommitHeight", height)
+	r.st.SetCommitHeight(height)
+}
+
+func (r *Recorder) GetObjectMeta(oid gno.ObjectID) (gno.ObjectMeta, bool) {
+	r.record("GetObjectMeta", oid)
+	return r.st.GetObjectMeta(oid)
+}
+
+func (r *Recorder) StartTx() {
+	r.record("StartTx")
+	r.st.StartTx()
+}
+
+func (r *Recorder) EndTx(written bool) {
+	r.record("EndTx", written)
+	r.st.EndTx(written)
+}
+
+func (r *Recorder) SetLogStoreOps(enabled bool) {
+	r.record("SetLogStoreOps", enabled)
+	r.st.SetLogStoreOps(enabled)
+}
+
+func (r *Recorder) SprintStoreOps() string {
+	r.record("SprintStoreOps")
+	return r.st.SprintStoreOps()
+}
+
+func (r *Recorder) Stats() gno.StoreStats {
+	r.record("Stats")
+	return r.st.Stats()
+}
+
+func (r *Recorder) ClearCache() {
+	r.record("ClearCache")
+	r.st.ClearCache()
+}
+
+func (r *Recorder) Print() {
+	r.record("Print")
+	r.st.Print()
+}
@@ -0,0 +1,170 @@
+// Package storetest provides utilities to test code using a gno.Store, and
+// implementations of gno.Store: an in-memory reference store, a wrapper
+// injecting faults, a wrapper recording calls, fixtures of small object
+// graphs and packages, and conformance suites, TestStoreConformance and
+// TestLayeredStoreConformance.
+package storetest
+
+import (
+	"fmt"
+
+	"github.com/gnolang/gno"
+	dbm "github.com/gnolang/gno/pkgs/db"
+	"github.com/gnolang/gno/pkgs/store"
+	"github.com/gnolang/gno/pkgs/store/cache"
+	"github.com/gnolang/gno/pkgs/store/dbadapter"
+	"github.com/gnolang/gno/pkgs/store/gas"
+)
+
+// NewStore returns the reference store, a gno.Store in memory, on new
+// in-memory base and iavl stores, which iterates deterministically.
+func NewStore() gno.Store {
+	baseStore, iavlStore := NewBackend()
+	return gno.NewStore(baseStore, iavlStore)
+}
+
+// NewBackend returns new in-memory base and iavl stores, e.g. to construct
+// several gno stores over the same backend, with gno.NewStore.
+func NewBackend() (baseStore, iavlStore store.Store) {
+	baseStore = dbadapter.Store{DB: dbm.NewMemDB()}
+	iavlStore = dbadapter.Store{DB: dbm.NewMemDB()}
+	return baseStore, iavlStore
+}
+
+// NewCacheLayers returns a gno store over cache stores of new in-memory
+// backends, as the vm keeper has over the stores of a block, and flushes by
+// writing the cache stores.
+func NewCacheLayers() Layers {
+	baseStore, iavlStore := NewBackend()
+	baseCache, iavlCache := cache.New(baseStore), cache.New(iavlStore)
+	return Layers{
+		Store: gno.NewStore(baseCache, iavlCache),
+		Flush: func() {
+			baseCache.Write()
+			iavlCache.Write()
+		},
+		Reload: func() gno.Store {
+			return gno.NewStore(baseStore, iavlStore)
+		},
+	}
+}
+
+// NewNestedCacheLayers returns a gno store over cache stores of cache
+// stores of new in-memory backends, as the vm keeper has over the stores of
+// a tx, over those of its block, and flushes by writing the cache stores of
+// the tx. The backend of the layers is the stores of the block.
+func NewNestedCacheLayers() Layers {
+	baseStore, iavlStore := NewBackend()
+	baseBlock, iavlBlock := cache.New(baseStore), cache.New(iavlStore)
+	baseTx, iavlTx := cache.New(baseBlock), cache.New(iavlBlock)
+	return Layers{
+		Store: gno.NewStore(baseTx, iavlTx),
+		Flush: func() {
+			baseTx.Write()
+			iavlTx.Write()
+		},
+		Reload: func() gno.Store {
+			return gno.NewStore(baseBlock, iavlBlock)
+		},
+	}
+}
+
+// NewSwappedCacheLayers returns a gno store over cache stores of new
+// in-memory backends, which flushes by writing the cache stores and then
+// swapping new ones in the store, with SwapStores, as the vm keeper does
+// for each block, so that the objects cached by the store outlive the
+// layers.
+func NewSwappedCacheLayers() Layers {
+	baseStore, iavlStore := NewBackend()
+	baseCache, iavlCache := cache.New(baseStore), cache.New(iavlStore)
+	st := gno.NewStore(baseCache, iavlCache)
+	return Layers{
+		Store: st,
+		Flush: func() {
+			baseCache.Write()
+			iavlCache.Write()
+			baseCache, iavlCache = cache.New(baseStore), cache.New(iavlStore)
+			st.SwapStores(baseCache, iavlCache)
+		},
+		Reload: func() gno.Store {
+			return gno.NewStore(baseStore, iavlStore)
+		},
+	}
+}
+
+// NewGasStore returns a gno store over gas stores, with an infinite gas
+// meter, of new in-memory backends, as the vm keeper has in transactions.
+func NewGasStore() gno.Store {
+	baseStore, iavlStore := NewBackend()
+	meter := store.NewInfiniteGasMeter()
+	config := store.DefaultGasConfig()
+	return gno.NewStore(
+		gas.New(baseStore, meter, config),
+		gas.New(iavlStore, meter, config))
+}
+
+// Fault is the panic value of the calls of a FaultStore which fail.
+type Fault struct {
+	Method string
+	N      int // the number of the call of the method, from 1.
+}
+
+func (f Fault) Error() string {
+	return fmt.Sprintf("storetest: injected fault in call %d of %s", f.N, f.Method)
+}
+
+// FaultStore is a gno.Store which panics with a Fault on a given call of
+// GetObject, GetObjectSafe, GetPackage or SetObject, e.g. to test that a
+// failed read or write leaves state consistent, and passes the other calls
+// to the store it wraps.
+type FaultStore struct {
+	gno.Store
+
+	failAt map[string]int // method to the number of the failing call.
+	calls  map[string]int // method to its number of calls.
+}
+
+var _ gno.Store = (*FaultStore)(nil)
+
+// NewFaultStore returns a FaultStore wrapping st, with no faults.
+func NewFaultStore(st gno.Store) *FaultStore {
+	return &FaultStore{
+		Store:  st,
+		failAt: make(map[string]int),
+		calls:  make(map[string]int),
+	}
+}
+
+// FailAt makes the nth call of method, from now on and counting from 1,
+// fail, e.g. FailAt("GetObject", 3) fails the third GetObject.
+func (fs *FaultStore) FailAt(method string, n int) {
+	fs.failAt[method] = fs.calls[method] + n
+}
+
+// fault counts a call of method, and panics if it is to fail.
+func (fs *FaultStore) fault(method string) {
+	fs.calls[method]++
+	if n := fs.calls[method]; fs.failAt[method] == n {
+		panic(Fault{Method: method, N: n})
+	}
+}
+
+func (fs *FaultStore) GetObject(oid gno.ObjectID) gno.Object {
+	fs.fault("GetObject")
+	return fs.Store.GetObject(oid)
+}
+
+func (fs *FaultStore) GetObjectSafe(oid gno.ObjectID) gno.Object {
+	fs.fault("GetObjectSafe")
+	return fs.Store.GetObjectSafe(oid)
+}
+
+func (fs *FaultStore) GetPackage(pkgPath string) *gno.PackageValue {
+	fs.fault("GetPackage")
+	return fs.Store.GetPackage(pkgPath)
+}
+
+func (fs *FaultStore) SetObject(oo gno.Object) {
+	fs.fault("SetObject")
+	fs.Store.SetObject(oo)
+}
@@ -0,0 +1,71 @@
+package storetest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gnolang/gno"
+	"github.com/gnolang/gno/storetest"
+)
+
+func TestReferenceStore(t *testing.T) {
+	storetest.TestStoreConformance(t, storetest.NewStore)
+}
+
+func TestCacheLayers(t *testing.T) {
+	storetest.TestLayeredStoreConformance(t, storetest.NewCacheLayers)
+}
+
+func TestNestedCacheLayers(t *testing.T) {
+	storetest.TestLayeredStoreConformance(t, storetest.NewNestedCacheLayers)
+}
+
+func TestSwappedCacheLayers(t *testing.T) {
+	storetest.TestLayeredStoreConformance(t, storetest.NewSwappedCacheLayers)
+}
+
+func TestGasStore(t *testing.T) {
+	storetest.TestStoreConformance(t, storetest.NewGasStore)
+}
+
+func TestFaultStore(t *testing.T) {
+	storetest.TestStoreConformance(t, func() gno.Store {
+		return storetest.NewFaultStore(storetest.NewStore())
+	})
+
+	fs := storetest.NewFaultStore(storetest.NewStore())
+	pkg := storetest.SetPackage(fs, "gno.land/r/test")
+	fs.FailAt("GetObject", 2)
+	require.NotNil(t, fs.GetObject(pkg.Nested))
+	require.PanicsWithValue(t,
+		storetest.Fault{Method: "GetObject", N: 2},
+		func() { fs.GetObject(pkg.Nested) })
+	require.NotNil(t, fs.GetObject(pkg.Nested))
+
+	// faults are counted from the FailAt call.
+	fs.FailAt("GetObject", 1)
+	require.Panics(t, func() { fs.GetObject(pkg.Nested) })
+}
+
+func TestRecorder(t *testing.T) {
+	storetest.TestStoreConformance(t, func() gno.Store {
+		return storetest.NewRecorder(storetest.NewStore())
+	})
+
+	rec := storetest.NewRecorder(storetest.NewStore())
+	pkg := storetest.SetPackage(rec, "gno.land/r/test")
+	require.Equal(t, []string{
+		"SetObject", "SetObject", // nested and outer arrays.
+		"SetObject", "SetObject", "SetObject", // arrays of the block.
+		"SetObject", "SetPackage", // block and package.
+	}, rec.Methods())
+
+	rec.Reset()
+	rec.GetObjectSafe(pkg.Nested)
+	rec.SetCommitHeight(3)
+	require.Equal(t, []storetest.StoreCall{
+		{Method: "GetObjectSafe", Args: []interface{}{pkg.Nested}},
+		{Method: "SetCommitHeight", Args: []interface{}{int64(3)}},
+	}, rec.Calls())
+}
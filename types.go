@@ -6,6 +6,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/gnolang/gno/pkgs/errors"
 )
 
 // NOTE: TypeID() implementations are currently
@@ -35,10 +37,20 @@ func (tid TypeID) Bytes() []byte {
 	return []byte(tid)
 }
 
+// String returns the canonical form of tid, as parsed by ParseTypeID.
 func (tid TypeID) String() string {
 	return string(tid)
 }
 
+// ParseTypeID parses the canonical form of a TypeID, as returned by
+// TypeID.String, e.g. from logs or query parameters.
+func ParseTypeID(tids string) (TypeID, error) {
+	if tids == "" {
+		return "", errors.New("invalid TypeID: empty")
+	}
+	return TypeID(tids), nil
+}
+
 func typeid(f string, args ...interface{}) (tid TypeID) {
 	fs := fmt.Sprintf(f, args...)
 	x := TypeID(fs)
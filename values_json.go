@@ -0,0 +1,270 @@
+package gno
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalPackageJSON renders the package of pkgPath in store as JSON, with
+// its declared names, and the values of the exported ones, rendered as by
+// MarshalValueJSON.
+func MarshalPackageJSON(store Store, pkgPath string, maxDepth int) ([]byte, error) {
+	pv := store.GetPackage(pkgPath)
+	if pv == nil {
+		return nil, fmt.Errorf("package %s not found", pkgPath)
+	}
+	jr := newJSONRenderer(store, maxDepth)
+	block := pv.GetBlock(store)
+	names := pv.GetPackageNode(store).GetBlockNames()
+	values := jsonObject{}
+	for i, name := range names {
+		if isUpper(string(name)) {
+			values = append(values, jsonField{string(name), jr.renderValue(block.Values[i], 0)})
+		}
+	}
+	return json.Marshal(jsonObject{
+		{"path", pv.PkgPath},
+		{"name", string(pv.PkgName)},
+		{"names", names},
+		{"values", values},
+	})
+}
+
+// MarshalObjectJSON renders the object of oid in store as JSON. As objects
+// are rendered without their type, the fields of a struct object are
+// rendered as a list, unlike those of the structs of MarshalValueJSON.
+func MarshalObjectJSON(store Store, oid ObjectID, maxDepth int) ([]byte, error) {
+	oo := store.GetObjectSafe(oid)
+	if oo == nil {
+		return nil, fmt.Errorf("object %s not found", oid)
+	}
+	jr := newJSONRenderer(store, maxDepth)
+	return json.Marshal(jr.renderUntyped(oo))
+}
+
+// MarshalValueJSON renders tv as JSON, deterministically, loading the
+// objects it refers to from store:
+//
+//   - booleans, strings and integers are JSON values, with bigints as
+//     strings;
+//   - arrays and slices are lists, or hex strings if of bytes stored as
+//     data;
+//   - structs are objects, with their fields in order;
+//   - maps are lists of {"key": ..., "value": ...}, in insertion order;
+//   - pointers are the values they point to;
+//   - nil values are null, and functions, types and packages are
+//     {"@func": name}, {"@type": type} and {"@package": path}.
+//
+// Objects beyond maxDepth levels of objects, and objects within themselves,
+// i.e. cycles, are rendered as {"@ref": id}, with the id of the object.
+func MarshalValueJSON(store Store, tv TypedValue, maxDepth int) ([]byte, error) {
+	jr := newJSONRenderer(store, maxDepth)
+	return json.Marshal(jr.renderValue(tv, 0))
+}
+
+// jsonObject is a JSON object whose fields are marshaled in order.
+type jsonObject []jsonField
+
+type jsonField struct {
+	Name  string
+	Value interface{}
+}
+
+func (jo jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range jo {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type jsonRenderer struct {
+	store    Store
+	maxDepth int
+	rendered map[Object]struct{} // objects being rendered, for cycles.
+}
+
+func newJSONRenderer(store Store, maxDepth int) *jsonRenderer {
+	return &jsonRenderer{
+		store:    store,
+		maxDepth: maxDepth,
+		rendered: make(map[Object]struct{}),
+	}
+}
+
+// renderValue renders tv, within depth levels of objects. tv is a copy, so
+// that loading its refs does not modify the value it was copied from.
+func (jr *jsonRenderer) renderValue(tv TypedValue, depth int) interface{} {
+	if tv.IsUndefined() {
+		return nil
+	}
+	fillValueTV(jr.store, &tv)
+	switch cv := tv.V.(type) {
+	case nil:
+		return renderPrimitive(&tv)
+	case StringValue:
+		return string(cv)
+	case BigintValue:
+		return cv.V.String()
+	case DataByteValue:
+		return cv.GetByte()
+	case PointerValue:
+		if cv.TV == nil {
+			return nil
+		}
+		return jr.renderValue(*cv.TV, depth)
+	case *ArrayValue:
+		return jr.renderObject(cv, depth, func(depth int) interface{} {
+			return jr.renderList(cv.List, cv.Data, depth)
+		})
+	case *SliceValue:
+		base := cv.GetBase(jr.store)
+		if base == nil {
+			return nil
+		}
+		return jr.renderObject(base, depth, func(depth int) interface{} {
+			start, end := cv.Offset, cv.Offset+cv.Length
+			if base.Data != nil {
+				return jr.renderList(nil, base.Data[start:end], depth)
+			}
+			return jr.renderList(base.List[start:end], nil, depth)
+		})
+	case *StructValue:
+		return jr.renderObject(cv, depth, func(depth int) interface{} {
+			st := baseOf(tv.T).(*StructType)
+			fields := make(jsonObject, len(cv.Fields))
+			for i, ftv := range cv.Fields {
+				fields[i] = jsonField{string(st.Fields[i].Name), jr.renderValue(ftv, depth)}
+			}
+			return fields
+		})
+	case *MapValue:
+		return jr.renderObject(cv, depth, func(depth int) interface{} {
+			return jr.renderMap(cv, depth)
+		})
+	case *FuncValue:
+		return jsonObject{{"@func", string(cv.Name)}}
+	case *BoundMethodValue:
+		return jsonObject{{"@func", string(cv.Func.Name)}}
+	case TypeValue:
+		return jsonObject{{"@type", cv.Type.String()}}
+	case *PackageValue:
+		return jsonObject{{"@package", cv.PkgPath}}
+	case *Block:
+		return jsonObject{{"@ref", cv.GetObjectID().String()}}
+	case *NativeValue:
+		return jsonObject{{"@native", fmt.Sprintf("%v", cv.Value.Interface())}}
+	default:
+		panic(fmt.Sprintf("unexpected value type %T", cv))
+	}
+}
+
+// renderObject renders oo with render, within one more level of objects,
+// or as a ref if beyond the max depth, or within itself, i.e. in a cycle.
+func (jr *jsonRenderer) renderObject(oo Object, depth int, render func(depth int) interface{}) interface{} {
+	if _, ok := jr.rendered[oo]; ok || depth >= jr.maxDepth {
+		return jsonObject{{"@ref", oo.GetObjectID().String()}}
+	}
+	jr.rendered[oo] = struct{}{}
+	defer delete(jr.rendered, oo)
+	return render(depth + 1)
+}
+
+// renderUntyped renders oo, of unknown type, with the fields of structs and
+// the values of blocks as lists.
+func (jr *jsonRenderer) renderUntyped(oo Object) interface{} {
+	return jr.renderObject(oo, 0, func(depth int) interface{} {
+		switch cv := oo.(type) {
+		case *ArrayValue:
+			return jr.renderList(cv.List, cv.Data, depth)
+		case *StructValue:
+			return jr.renderList(cv.Fields, nil, depth)
+		case *MapValue:
+			return jr.renderMap(cv, depth)
+		case *Block:
+			return jr.renderList(cv.Values, nil, depth)
+		case *PackageValue:
+			return jsonObject{{"@package", cv.PkgPath}}
+		case *BoundMethodValue:
+			return jsonObject{{"@func", string(cv.Func.Name)}}
+		default:
+			panic(fmt.Sprintf("unexpected object type %T", cv))
+		}
+	})
+}
+
+func (jr *jsonRenderer) renderMap(mv *MapValue, depth int) interface{} {
+	items := []interface{}{}
+	if mv.List != nil {
+		for item := mv.List.Head; item != nil; item = item.Next {
+			items = append(items, jsonObject{
+				{"key", jr.renderValue(item.Key, depth)},
+				{"value", jr.renderValue(item.Value, depth)},
+			})
+		}
+	}
+	return items
+}
+
+func (jr *jsonRenderer) renderList(list []TypedValue, data []byte, depth int) interface{} {
+	if data != nil {
+		return hex.EncodeToString(data)
+	}
+	items := make([]interface{}, len(list))
+	for i, etv := range list {
+		items[i] = jr.renderValue(etv, depth)
+	}
+	return items
+}
+
+// renderPrimitive renders the value of tv, whose V is nil, e.g. a number,
+// or a nil pointer, slice or map.
+func renderPrimitive(tv *TypedValue) interface{} {
+	switch baseOf(tv.T) {
+	case BoolType, UntypedBoolType:
+		return tv.GetBool()
+	case StringType, UntypedStringType:
+		return tv.GetString()
+	case IntType:
+		return tv.GetInt()
+	case Int8Type:
+		return tv.GetInt8()
+	case Int16Type:
+		return tv.GetInt16()
+	case Int32Type, UntypedRuneType:
+		return tv.GetInt32()
+	case Int64Type:
+		return tv.GetInt64()
+	case UintType:
+		return tv.GetUint()
+	case Uint8Type:
+		return tv.GetUint8()
+	case DataByteType:
+		return tv.GetDataByte()
+	case Uint16Type:
+		return tv.GetUint16()
+	case Uint32Type:
+		return tv.GetUint32()
+	case Uint64Type:
+		return tv.GetUint64()
+	default:
+		return nil
+	}
+}